@@ -1,57 +1,532 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	_ "github.com/AlecSmith96/faceit-user-service/docs"
 	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
 	"github.com/AlecSmith96/faceit-user-service/internal/drivers"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
 	_ "github.com/lib/pq"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 const (
-	gooseDir = "./db/goose"
+	auditVerifyPageSize = 1000
 )
 
+// storage is what the postgres and memory backends store besides users
+type storage interface {
+	usecases.UserStatusUpdater
+	usecases.ExpiredBanLifter
+	usecases.EmailVerificationStore
+	usecases.EmailChangeStore
+	usecases.PasswordManager
+	usecases.MFAStore
+	usecases.SessionStore
+	usecases.WebAuthnStore
+	usecases.OIDCStore
+	adapters.SigningKeyStore
+	usecases.LoginAuditStore
+	usecases.APIKeyStore
+	usecases.AuditLog
+	usecases.UserImporter
+	usecases.ImportJobStore
+	usecases.UserExporter
+	usecases.UserBatchWriter
+	usecases.UserSearcher
+}
+
 // @title faceit-user-service
 // @version 1.0
 // @description This is a simple REST server providing CRUD operations on a User object
 
 func main() {
+	// creating a migration only writes to the source tree, so it doesn't need any config
+	if len(os.Args) > 2 && os.Args[1] == "migrate" && os.Args[2] == adapters.MigrateCreate {
+		createMigration(os.Args[3:])
+		return
+	}
+
 	conf, err := adapters.NewConfig()
 	if err != nil {
 		slog.Error("reading config", "err", err)
 		os.Exit(1)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		if conf.StorageBackend != adapters.StorageBackendPostgres {
+			slog.Error("the audit log is only kept between runs in postgres", "storageBackend", conf.StorageBackend)
+			os.Exit(1)
+		}
+
+		verifyAuditLog(newPostgresAdapter(conf))
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(conf, os.Args[2:])
+		return
+	}
+
+	// store is everything but users for the backends that store it, the sqlite backend only stores users so it's left
+	// nil and the endpoints that need it aren't served
+	var store storage
+	var userRepository usecases.UserRepository
+	var userCommandReplyStore usecases.UserCommandReplyStore
+	var postgresAdapter *adapters.PostgresAdapter
+	switch conf.StorageBackend {
+	case adapters.StorageBackendPostgres:
+		postgresAdapter = newPostgresAdapter(conf)
+		store = postgresAdapter
+		userRepository = postgresAdapter
+		userCommandReplyStore = postgresAdapter
+	case adapters.StorageBackendMemory:
+		slog.Warn("STORAGE_BACKEND is memory, everything will be lost on restart")
+		memoryStore := adapters.NewMemoryStore()
+		store = memoryStore
+		userRepository = memoryStore
+		userCommandReplyStore = memoryStore
+	case adapters.StorageBackendSQLite:
+		slog.Warn("STORAGE_BACKEND is sqlite, only the core user endpoints are served")
+		sqliteAdapter := newSQLiteAdapter(conf)
+		if conf.AutoMigrate {
+			err = sqliteAdapter.PerformDataMigration(context.Background())
+			if err != nil {
+				slog.Error("running sqlite migrations", "err", err)
+				os.Exit(1)
+			}
+		}
+		userRepository = sqliteAdapter
+		userCommandReplyStore = sqliteAdapter
+	default:
+		slog.Error("unknown storage backend", "storageBackend", conf.StorageBackend)
+		os.Exit(1)
+	}
+	usersOnly := store == nil
+
+	var maintainPII func(ctx context.Context) error
+	if postgresAdapter != nil {
+		if conf.AutoMigrate {
+			err = postgresAdapter.PerformDataMigration(context.Background())
+			if err != nil {
+				slog.Error("running migrations", "err", err)
+				os.Exit(1)
+			}
+		}
+
+		err = postgresAdapter.LoadDataKeys(context.Background())
+		if err != nil {
+			slog.Error("loading pii data keys", "err", err)
+			os.Exit(1)
+		}
+
+		// users written before PII was encrypted have no blind indexes, so they have to be sealed before serving
+		// requests or their emails could be claimed again
+		maintainPII = usecases.NewMaintainPII(postgresAdapter, conf.PIIMaintenanceBatchSize)
+		err = maintainPII(context.Background())
+		if err != nil {
+			slog.Error("maintaining pii", "err", err)
+			os.Exit(1)
+		}
+	}
+	if !conf.AutoMigrate {
+		slog.Info("AUTO_MIGRATE is off, run user-service migrate up before deploying a new version")
+	}
+
+	kafkaDialer := &adapters.KafkaDialer{}
+	var changelogWriter usecases.ChangelogWriter
+	var securityEventPublisher usecases.SecurityEventPublisher
+	switch conf.EventPublisher {
+	case adapters.EventPublisherTypeKafka:
+		kafkaAdapter, err := adapters.NewKafkaAdapter(conf.KafkaHost, kafkaDialer)
+		if err != nil {
+			slog.Error("creating kafka adapter", "err", err)
+			os.Exit(1)
+		}
+		defer kafkaAdapter.CloseConn()
+
+		kafkaSecurityEventPublisher, err := adapters.NewKafkaSecurityEventPublisher(conf.KafkaHost, kafkaDialer)
+		if err != nil {
+			slog.Error("creating kafka security event publisher", "err", err)
+			os.Exit(1)
+		}
+		defer kafkaSecurityEventPublisher.CloseConn()
+
+		changelogWriter = kafkaAdapter
+		securityEventPublisher = kafkaSecurityEventPublisher
+	case adapters.EventPublisherTypeLog:
+		slog.Warn("EVENT_PUBLISHER is log, changelog entries and security events will only be logged")
+		logEventPublisher := adapters.NewLogEventPublisher()
+		changelogWriter = logEventPublisher
+		securityEventPublisher = logEventPublisher
+	default:
+		slog.Error("unknown event publisher", "eventPublisher", conf.EventPublisher)
+		os.Exit(1)
+	}
+
+	var mailer usecases.Mailer
+	switch conf.MailerType {
+	case adapters.MailerTypeSMTP:
+		mailer = adapters.NewSMTPMailer(conf.SMTPHost, conf.SMTPPort, conf.SMTPUsername, conf.SMTPPassword, conf.MailFrom)
+	case adapters.MailerTypeLog:
+		mailer = adapters.NewLocalMailer(conf.MailOutputDir, conf.MailFrom)
+	default:
+		slog.Error("unknown mailer type", "mailerType", conf.MailerType)
+		os.Exit(1)
+	}
+
+	var loginLimiter usecases.LoginLimiter
+	switch conf.LoginLimiter {
+	case adapters.LoginLimiterTypePostgres:
+		// the sqlite backend doesn't serve logins, so it doesn't need a limiter
+		if postgresAdapter != nil {
+			loginLimiter = postgresAdapter
+		} else if !usersOnly {
+			slog.Error("LOGIN_LIMITER postgres needs STORAGE_BACKEND postgres, set it to memory", "storageBackend", conf.StorageBackend)
+			os.Exit(1)
+		}
+	case adapters.LoginLimiterTypeMemory:
+		loginLimiter = adapters.NewMemoryLoginLimiter()
+	default:
+		slog.Error("unknown login limiter", "loginLimiter", conf.LoginLimiter)
+		os.Exit(1)
+	}
+
+	tokenSigner := adapters.NewHMACTokenSigner(conf.TokenSigningKey)
+	verificationEmailSender := usecases.NewVerificationEmailSender(
+		store,
+		tokenSigner,
+		mailer,
+		conf.EmailVerificationTokenTTL,
+		conf.EmailVerificationURL,
+	)
+	passwordResetSender := usecases.NewPasswordResetSender(
+		store,
+		mailer,
+		conf.PasswordResetTokenTTL,
+		conf.PasswordResetURL,
+	)
+	emailChanger := usecases.NewEmailChanger(
+		store,
+		tokenSigner,
+		mailer,
+		conf.EmailChangeTokenTTL,
+		conf.EmailChangeRevertTokenTTL,
+		conf.EmailChangeConfirmURL,
+		conf.EmailChangeRevertURL,
+	)
+	mfaManager := usecases.NewMFAManager(
+		store,
+		adapters.NewAESGCMSecretBox(conf.MFAEncryptionKey),
+		tokenSigner,
+		conf.MFAIssuer,
+		conf.MFAChallengeTTL,
+	)
+	sessionIssuer := usecases.NewSessionIssuer(store, tokenSigner, conf.AccessTokenTTL, conf.SessionTTL)
+	webAuthnManager, err := usecases.NewWebAuthnManager(
+		store,
+		conf.WebAuthnRPID,
+		conf.WebAuthnRPDisplayName,
+		conf.WebAuthnRPOrigins,
+		conf.WebAuthnCeremonyTTL,
+	)
+	if err != nil {
+		slog.Error("creating webauthn manager", "err", err)
+		os.Exit(1)
+	}
+	idTokenSigner := adapters.NewRotatingRSAIDTokenSigner(
+		store,
+		adapters.NewAESGCMSecretBox(conf.SigningKeyEncryptionKey),
+		conf.SigningKeyRotationInterval,
+		conf.SigningKeyRetention,
+	)
+	if !usersOnly {
+		err = idTokenSigner.Refresh(context.Background())
+		if err != nil {
+			slog.Error("loading signing keys", "err", err)
+			os.Exit(1)
+		}
+	}
+	oidcProvider := usecases.NewOIDCProvider(
+		store,
+		userRepository,
+		tokenSigner,
+		idTokenSigner,
+		sessionIssuer,
+		conf.OIDCIssuer,
+		conf.OIDCAuthorizationCodeTTL,
+		conf.OIDCIDTokenTTL,
+	)
+	loginThrottler := usecases.NewLoginThrottler(
+		loginLimiter,
+		store,
+		securityEventPublisher,
+		entities.LoginThrottlePolicy{
+			FreeFailures:    conf.AccountLoginFreeFailures,
+			BaseDelay:       conf.LoginBackoffBaseDelay,
+			MaxDelay:        conf.LoginBackoffMaxDelay,
+			LockoutFailures: conf.AccountLockoutFailures,
+			LockoutDuration: conf.AccountLockoutDuration,
+			ResetAfter:      conf.LoginFailureWindow,
+		},
+		entities.LoginThrottlePolicy{
+			FreeFailures: conf.IPLoginFreeFailures,
+			BaseDelay:    conf.LoginBackoffBaseDelay,
+			MaxDelay:     conf.LoginBackoffMaxDelay,
+			ResetAfter:   conf.LoginFailureWindow,
+		},
+	)
+	var breachCorpus usecases.BreachCorpus
+	if conf.BreachedPasswordsDir != "" {
+		breachCorpus = adapters.NewRangeFileBreachCorpus(conf.BreachedPasswordsDir)
+	} else {
+		slog.Warn("BREACHED_PASSWORDS_DIR not set, passwords won't be checked for breaches")
+	}
+	passwordValidator := usecases.NewPasswordValidator(
+		entities.PasswordPolicy{
+			MinLength:      conf.PasswordMinLength,
+			MaxLength:      conf.PasswordMaxLength,
+			MinEntropyBits: conf.PasswordMinEntropyBits,
+		},
+		breachCorpus,
+	)
+
+	// the other backends start without API keys, so the first have to be minted with the bootstrap key
+	if postgresAdapter == nil && conf.APIKeysRequired && conf.BootstrapAdminAPIKey == "" {
+		slog.Error("no API keys are stored on startup, set BOOTSTRAP_ADMIN_API_KEY or turn API_KEYS_REQUIRED off", "storageBackend", conf.StorageBackend)
+		os.Exit(1)
+	}
+
+	// the sqlite backend only stores users, so what the core user endpoints keep elsewhere is left out
+	var apiKeyStore usecases.APIKeyStore = store
+	var auditLog usecases.AuditLog = store
+	if usersOnly {
+		apiKeyStore = nil
+		auditLog = nil
+		verificationEmailSender = nil
+		emailChanger = nil
+	}
+
+	apiKeyAuthenticator := usecases.NewAPIKeyAuthenticator(apiKeyStore, conf.BootstrapAdminAPIKey, conf.APIKeysRequired)
+	if !conf.APIKeysRequired {
+		slog.Warn("API_KEYS_REQUIRED turned off, requests without an API key will be let through")
+	}
+	userImport := usecases.NewUserImport(
+		store,
+		store,
+		changelogWriter,
+		passwordValidator,
+		conf.ImportBatchSize,
+		conf.ImportSpoolDir,
+	)
+	unverifiedAccountPolicy := entities.UnverifiedAccountPolicy{
+		Mode:        conf.UnverifiedAccountPolicy,
+		GracePeriod: conf.UnverifiedAccountGracePeriod,
+	}
+
+	// cancelled when the service is told to stop, which shuts down the server and the background jobs
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if !usersOnly {
+		// jobs left running by an instance that was killed are failed before GET /users/import/{jobId} can report them
+		err = userImport.FailInterruptedJobs(ctx)
+		if err != nil {
+			slog.Error("failing interrupted import jobs", "err", err)
+			os.Exit(1)
+		}
+
+		go drivers.RunPeriodically(ctx, "lift expired bans", conf.BanExpiryCheckInterval, usecases.NewLiftExpiredBans(store, changelogWriter))
+		go drivers.RunPeriodically(ctx, "refresh signing keys", conf.SigningKeyCheckInterval, idTokenSigner.Refresh)
+		go drivers.RunPeriodically(ctx, "fail interrupted import jobs", time.Minute, userImport.FailInterruptedJobs)
+	}
+	if maintainPII != nil {
+		go drivers.RunPeriodically(ctx, "maintain pii", conf.PIIMaintenanceInterval, maintainPII)
+	}
+
+	if conf.UserCommandsEnabled {
+		replyPublisher, err := adapters.NewKafkaUserCommandReplyPublisher(conf.KafkaHost, kafkaDialer)
+		if err != nil {
+			slog.Error("creating kafka user command reply publisher", "err", err)
+			os.Exit(1)
+		}
+		defer replyPublisher.CloseConn()
+
+		deadLetterPublisher, err := adapters.NewKafkaDeadLetterPublisher(conf.KafkaHost, kafkaDialer)
+		if err != nil {
+			slog.Error("creating kafka dead letter publisher", "err", err)
+			os.Exit(1)
+		}
+		defer deadLetterPublisher.CloseConn()
+
+		userCommandConsumer := adapters.NewKafkaUserCommandConsumer(conf.KafkaHost, adapters.NewKafkaMessageReader)
+		defer userCommandConsumer.Close()
+
+		userCommandProcessor := usecases.NewUserCommandProcessor(
+			userRepository,
+			changelogWriter,
+			verificationEmailSender,
+			emailChanger,
+			passwordValidator,
+			unverifiedAccountPolicy,
+			auditLog,
+			replyPublisher,
+			deadLetterPublisher,
+			userCommandReplyStore,
+			conf.UserCommandsMaxAttempts,
+			conf.UserCommandsRetryDelay,
+			conf.UserCommandsReplyRetention,
+		)
+		go drivers.RunConsumer(ctx, "user commands", userCommandConsumer, conf.UserCommandsRetryDelay, userCommandProcessor.Process)
+		go drivers.RunPeriodically(ctx, "delete old user command replies", time.Hour, userCommandProcessor.DeleteOldReplies)
+	}
+
+	router := drivers.NewRouter(drivers.RouterDependencies{
+		ChangelogWriter:         changelogWriter,
+		UserRepository:          userRepository,
+		UserStatusUpdater:       store,
+		TokenSigner:             tokenSigner,
+		EmailVerificationStore:  store,
+		VerificationEmailSender: verificationEmailSender,
+		UnverifiedAccountPolicy: unverifiedAccountPolicy,
+		PasswordManager:         store,
+		PasswordResetSender:     passwordResetSender,
+		EmailChangeStore:        store,
+		EmailChanger:            emailChanger,
+		MFAManager:              mfaManager,
+		SessionStore:            store,
+		SessionIssuer:           sessionIssuer,
+		WebAuthnStore:           store,
+		WebAuthnManager:         webAuthnManager,
+		OIDCStore:               store,
+		OIDCProvider:            oidcProvider,
+		LoginThrottler:          loginThrottler,
+		PasswordValidator:       passwordValidator,
+		APIKeyStore:             apiKeyStore,
+		APIKeyAuthenticator:     apiKeyAuthenticator,
+		AuditLog:                auditLog,
+		UserImport:              userImport,
+		ImportJobStore:          store,
+		UserExporter:            store,
+		UserBatchWriter:         store,
+		UserSearcher:            store,
+		BatchMaxUsers:           conf.BatchMaxUsers,
+		UsersOnly:               usersOnly,
+	})
+
+	err = drivers.Serve(ctx, ":"+conf.Port, router, conf.ShutdownTimeout)
+	if err != nil {
+		slog.Error("running gin router", "err", err)
+	}
+
+	// import jobs are stopped after the server, so no more can be started, and given the same time to record that
+	// they were interrupted
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancelShutdown()
+	err = userImport.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("stopping import jobs", "err", err)
+	}
+}
+
+// verifyAuditLog checks the audit log's hash chain, exiting with a non-zero status if it has been tampered with
+func verifyAuditLog(auditLog usecases.AuditLog) {
+	last, err := usecases.VerifyAuditLog(context.Background(), auditLog, auditVerifyPageSize)
+	if err != nil {
+		slog.Error("verifying audit log", "err", err)
+		os.Exit(1)
+	}
+
+	if last == nil {
+		slog.Info("audit log is empty")
+		return
+	}
+
+	// the last hash should be kept somewhere else, entries removed from the end can only be detected by comparing it
+	slog.Info("audit log verified", "entries", last.Sequence, "lastHash", last.Hash)
+}
+
+func newPostgresAdapter(conf *adapters.Config) *adapters.PostgresAdapter {
 	db, err := sql.Open("postgres", conf.PostgresConnectionURI)
 	if err != nil {
 		slog.Error("connecting to postgres", "err", err)
 		os.Exit(1)
 	}
 
-	postgresAdapter := adapters.NewPostgresAdapter(db)
-
-	err = postgresAdapter.PerformDataMigration(gooseDir)
+	piiCipher, err := adapters.NewPIICipher(conf.PIIMasterKeys, conf.PIIBlindIndexKey)
 	if err != nil {
-		slog.Error("running migrations", "err", err)
+		slog.Error("creating pii cipher", "err", err)
 		os.Exit(1)
 	}
 
-	kafkaDialer := &adapters.KafkaDialer{}
-	kafkaAdapter, err := adapters.NewKafkaAdapter(conf.KafkaHost, kafkaDialer)
+	return adapters.NewPostgresAdapter(db, piiCipher)
+}
+
+func newSQLiteAdapter(conf *adapters.Config) *adapters.SQLiteAdapter {
+	sqliteDB, err := adapters.NewSQLiteDB(conf.SQLitePath)
 	if err != nil {
-		slog.Error("creating kafka adapter", "err", err)
+		slog.Error("opening sqlite database", "err", err)
+		os.Exit(1)
+	}
+
+	return adapters.NewSQLiteAdapter(sqliteDB)
+}
+
+// migrator runs goose commands against the migrations embedded for a storage backend
+type migrator interface {
+	Migrate(ctx context.Context, command string) error
+}
+
+// migrate runs user-service migrate up|down|status|redo against the configured storage backend
+func migrate(conf *adapters.Config, args []string) {
+	if len(args) != 1 {
+		slog.Error("usage: user-service migrate up|down|status|redo|create --dir <dir> <name>")
 		os.Exit(1)
 	}
-	defer kafkaAdapter.CloseConn()
 
-	router := drivers.NewRouter(kafkaAdapter, postgresAdapter, postgresAdapter, postgresAdapter, postgresAdapter, postgresAdapter)
+	var m migrator
+	switch conf.StorageBackend {
+	case adapters.StorageBackendPostgres:
+		m = newPostgresAdapter(conf)
+	case adapters.StorageBackendSQLite:
+		m = newSQLiteAdapter(conf)
+	default:
+		slog.Error("only the postgres and sqlite backends have migrations", "storageBackend", conf.StorageBackend)
+		os.Exit(1)
+	}
 
-	err = router.Run()
+	err := m.Migrate(context.Background(), args[0])
 	if err != nil {
-		slog.Error("running gin router", "err", err)
+		slog.Error("migrating", "command", args[0], "err", err)
+		os.Exit(1)
+	}
+}
+
+// createMigration runs user-service migrate create --dir <dir> <name>, the name can also be given before --dir
+func createMigration(args []string) {
+	flags := flag.NewFlagSet("migrate create", flag.ExitOnError)
+	dir := flags.String("dir", "", "the directory to write the migration to, db/goose or db/sqlite in the source tree")
+	_ = flags.Parse(args)
+	name := flags.Arg(0)
+	if flags.NArg() > 0 {
+		_ = flags.Parse(flags.Args()[1:])
 	}
 
+	if *dir == "" || name == "" || flags.NArg() > 0 {
+		slog.Error("usage: user-service migrate create --dir <dir> <name>")
+		os.Exit(1)
+	}
+
+	err := adapters.CreateMigration(*dir, name)
+	if err != nil {
+		slog.Error("creating migration", "err", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,594 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultSearchLimit matches the default of GET /users/search
+const defaultSearchLimit = 20
+
+// withUserService opens the user service for the duration of run
+func withUserService(opts *options, run func(service userService) error) error {
+	service, err := opts.userService()
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	return run(service)
+}
+
+func parseUserIDs(args []string) ([]uuid.UUID, error) {
+	userIDs := make([]uuid.UUID, 0, len(args))
+	for _, arg := range args {
+		userID, err := uuid.Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %q: %w", arg, err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
+
+func newGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <user-id>...",
+		Short: "Get users by ID",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userIDs, err := parseUserIDs(args)
+			if err != nil {
+				return err
+			}
+
+			return withUserService(opts, func(service userService) error {
+				users, err := service.GetUsers(cmd.Context(), userIDs)
+				if err != nil {
+					return err
+				}
+
+				err = printUsers(cmd.OutOrStdout(), opts.output, users)
+				if err != nil {
+					return err
+				}
+
+				if len(users) < len(userIDs) {
+					found := map[uuid.UUID]bool{}
+					for _, user := range users {
+						found[user.ID] = true
+					}
+					missing := []string{}
+					for _, userID := range userIDs {
+						if !found[userID] {
+							missing = append(missing, userID.String())
+						}
+					}
+					return fmt.Errorf("%w: %s", entities.ErrUserNotFound, strings.Join(missing, ", "))
+				}
+
+				return nil
+			})
+		},
+	}
+}
+
+func newSearchCommand(opts *options) *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search users by nickname, name or email",
+		Long: "Search users the same way as GET /users/search. Nicknames are matched by similarity so typos are " +
+			"tolerated, names and emails only match a word of the query, or all of it, in full.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withUserService(opts, func(service userService) error {
+				results, err := service.SearchUsers(cmd.Context(), args[0], limit)
+				if err != nil {
+					return err
+				}
+
+				return printSearchResults(cmd.OutOrStdout(), opts.output, results)
+			})
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", defaultSearchLimit, "most users to return, at most 100")
+
+	return cmd
+}
+
+// profileFlags are the flags for the fields of a user's profile
+type profileFlags struct {
+	firstName     string
+	lastName      string
+	nickname      string
+	password      string
+	passwordStdin bool
+	email         string
+	country       string
+}
+
+func (p *profileFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&p.firstName, "first-name", "", "first name")
+	cmd.Flags().StringVar(&p.lastName, "last-name", "", "last name")
+	cmd.Flags().StringVar(&p.nickname, "nickname", "", "nickname")
+	cmd.Flags().StringVar(&p.password, "password", "", "password, prefer --password-stdin to keep it out of the shell history")
+	cmd.Flags().BoolVar(&p.passwordStdin, "password-stdin", false, "read the password from stdin")
+	cmd.Flags().StringVar(&p.email, "email", "", "email address")
+	cmd.Flags().StringVar(&p.country, "country", "", "country")
+	cmd.MarkFlagsMutuallyExclusive("password", "password-stdin")
+}
+
+// changed reports whether any field of the profile was given
+func (p *profileFlags) changed(cmd *cobra.Command) bool {
+	for _, flag := range []string{"first-name", "last-name", "nickname", "password", "password-stdin", "email", "country"} {
+		if cmd.Flags().Changed(flag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readPassword replaces the password with the first line of stdin if --password-stdin was given
+func (p *profileFlags) readPassword(cmd *cobra.Command) error {
+	if !p.passwordStdin {
+		return nil
+	}
+
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading password: %w", err)
+	}
+	p.password = strings.TrimRight(line, "\r\n")
+	if p.password == "" {
+		return errors.New("no password given on stdin")
+	}
+
+	return nil
+}
+
+func newCreateCommand(opts *options) *cobra.Command {
+	profile := &profileFlags{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		Long: "Create a user. Through the API the user is sent a verification email, offline they are created " +
+			"pending verification without one.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := profile.readPassword(cmd)
+			if err != nil {
+				return err
+			}
+			if profile.password == "" {
+				return errors.New("a password is required, with --password or --password-stdin")
+			}
+
+			return withUserService(opts, func(service userService) error {
+				user, err := service.CreateUser(
+					cmd.Context(),
+					profile.firstName,
+					profile.lastName,
+					profile.nickname,
+					profile.password,
+					profile.email,
+					profile.country,
+				)
+				if err != nil {
+					return err
+				}
+
+				return printUser(cmd.OutOrStdout(), opts.output, *user)
+			})
+		},
+	}
+	profile.register(cmd)
+	for _, flag := range []string{"first-name", "last-name", "nickname", "email", "country"} {
+		_ = cmd.MarkFlagRequired(flag)
+	}
+
+	return cmd
+}
+
+func newUpdateCommand(opts *options) *cobra.Command {
+	profile := &profileFlags{}
+	cmd := &cobra.Command{
+		Use:   "update <user-id>",
+		Short: "Update fields of a user's profile",
+		Long: "Update the fields of a user's profile given as flags, keeping the rest. Through the API a new email " +
+			"address is held as pending until it is confirmed, offline it replaces the current one straight away, " +
+			"unverified.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userIDs, err := parseUserIDs(args)
+			if err != nil {
+				return err
+			}
+
+			if !profile.changed(cmd) {
+				return errors.New("nothing to update, give at least one field to change")
+			}
+			err = profile.readPassword(cmd)
+			if err != nil {
+				return err
+			}
+
+			return withUserService(opts, func(service userService) error {
+				users, err := service.GetUsers(cmd.Context(), userIDs)
+				if err != nil {
+					return err
+				}
+				if len(users) == 0 {
+					return fmt.Errorf("%w: %s", entities.ErrUserNotFound, userIDs[0])
+				}
+
+				// the API replaces the whole profile, so the fields that weren't given are sent unchanged
+				current := users[0]
+				changed := func(flag, value, currentValue string) string {
+					if cmd.Flags().Changed(flag) {
+						return value
+					}
+					return currentValue
+				}
+				password := current.Password
+				if cmd.Flags().Changed("password") || profile.passwordStdin {
+					password = profile.password
+				}
+				if password == "" {
+					// the API never returns passwords, so the current one can't be sent back unchanged
+					return errors.New("updating a user through the API replaces their password too, give it with --password or --password-stdin")
+				}
+
+				user, err := service.UpdateUser(
+					cmd.Context(),
+					current.ID,
+					changed("first-name", profile.firstName, current.FirstName),
+					changed("last-name", profile.lastName, current.LastName),
+					changed("nickname", profile.nickname, current.Nickname),
+					password,
+					changed("email", profile.email, current.Email),
+					changed("country", profile.country, current.Country),
+				)
+				if err != nil {
+					return err
+				}
+
+				return printUser(cmd.OutOrStdout(), opts.output, *user)
+			})
+		},
+	}
+	profile.register(cmd)
+
+	return cmd
+}
+
+// newStatusCommand creates a command that changes the status of each user given, printing the updated users
+func newStatusCommand(
+	opts *options,
+	use, short, long string,
+	change func(service userService, cmd *cobra.Command, userID uuid.UUID) (*entities.User, error),
+) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <user-id>...",
+		Short: short,
+		Long:  long,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userIDs, err := parseUserIDs(args)
+			if err != nil {
+				return err
+			}
+
+			return withUserService(opts, func(service userService) error {
+				users := []entities.User{}
+				for _, userID := range userIDs {
+					user, err := change(service, cmd, userID)
+					if err != nil {
+						// the users already changed are printed so it's clear where to carry on from
+						_ = printUsers(cmd.OutOrStdout(), opts.output, users)
+						return fmt.Errorf("%s %s: %w", use, userID, err)
+					}
+					users = append(users, *user)
+				}
+
+				return printUsers(cmd.OutOrStdout(), opts.output, users)
+			})
+		},
+	}
+}
+
+func newSuspendCommand(opts *options) *cobra.Command {
+	return newStatusCommand(
+		opts,
+		"suspend",
+		"Suspend users",
+		"Suspend users until they are restored. Offline this needs the postgres storage backend.",
+		func(service userService, cmd *cobra.Command, userID uuid.UUID) (*entities.User, error) {
+			return service.SuspendUser(cmd.Context(), userID)
+		},
+	)
+}
+
+func newRestoreCommand(opts *options) *cobra.Command {
+	return newStatusCommand(
+		opts,
+		"restore",
+		"Lift users' suspensions or bans",
+		"Lift the suspension or ban of users, moving them back to active, or to pending verification if they "+
+			"haven't verified their email. Deleted users are gone for good and can't be restored, re-import them "+
+			"from an export instead. Offline this needs the postgres storage backend.",
+		func(service userService, cmd *cobra.Command, userID uuid.UUID) (*entities.User, error) {
+			return service.RestoreUser(cmd.Context(), userID)
+		},
+	)
+}
+
+func newDeleteCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <user-id>...",
+		Short: "Delete users",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userIDs, err := parseUserIDs(args)
+			if err != nil {
+				return err
+			}
+
+			return withUserService(opts, func(service userService) error {
+				for _, userID := range userIDs {
+					err := service.DeleteUser(cmd.Context(), userID)
+					if err != nil {
+						return fmt.Errorf("delete %s: %w", userID, err)
+					}
+					fmt.Fprintln(cmd.ErrOrStderr(), "deleted", userID)
+				}
+
+				return nil
+			})
+		},
+	}
+}
+
+func newExportCommand(opts *options) *cobra.Command {
+	export := exportOptions{}
+	var file string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export users to a file",
+		Long: "Export the users matching the filters as CSV, NDJSON or Parquet, the same way as GET /users/export. " +
+			"Passwords are never exported. Offline this needs the postgres storage backend.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withUserService(opts, func(service userService) error {
+				if file == "-" {
+					return service.ExportUsers(cmd.Context(), export, cmd.OutOrStdout())
+				}
+
+				out, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+
+				err = service.ExportUsers(cmd.Context(), export, out)
+				if err == nil {
+					err = out.Close()
+				}
+				if err != nil {
+					// an incomplete export could be mistaken for a complete one
+					out.Close()
+					os.Remove(file)
+					return err
+				}
+
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&export.format, "format", entities.ExportFormatCSV, "csv, ndjson or parquet")
+	cmd.Flags().StringVar(&export.columns, "columns", "", "comma separated columns to export, all of them by default")
+	cmd.Flags().StringVar(&export.firstName, "first-name", "", "only users with this first name, ignoring case")
+	cmd.Flags().StringVar(&export.lastName, "last-name", "", "only users with this last name, ignoring case")
+	cmd.Flags().StringVar(&export.nickname, "nickname", "", "only users whose nickname contains this")
+	cmd.Flags().StringVar(&export.email, "email", "", "only the user with this email")
+	cmd.Flags().StringVar(&export.country, "country", "", "only users whose country contains this")
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "file to write, or - for stdout")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletions(entities.ExportFormatCSV, entities.ExportFormatNDJSON, entities.ExportFormatParquet))
+	_ = cmd.RegisterFlagCompletionFunc("columns", fixedCompletions(entities.ExportColumns...))
+
+	return cmd
+}
+
+// importFormatForFile guesses a file's import format from its extension
+func importFormatForFile(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return entities.ImportFormatNDJSON
+	default:
+		return entities.ImportFormatCSV
+	}
+}
+
+func newImportCommand(opts *options) *cobra.Command {
+	var format string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import users from a file",
+		Long: "Create users from a CSV file with a header row, or an NDJSON file with one user per line, the same way " +
+			"as POST /users/import. Each user needs a first_name, last_name, nickname, password, email and country. " +
+			"Rows that fail are reported without stopping the import, and make userctl exit with an error. Offline " +
+			"this needs the postgres storage backend.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				format = importFormatForFile(args[0])
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			return withUserService(opts, func(service userService) error {
+				results, importErr := service.ImportUsers(cmd.Context(), format, file, dryRun)
+				// rows imported before an error are printed so the import can be carried on from where it stopped
+				err := printImportResults(cmd.OutOrStdout(), opts.output, results)
+				if importErr != nil {
+					return importErr
+				}
+				if err != nil {
+					return err
+				}
+
+				failed := 0
+				for _, result := range results {
+					if result.Error != "" {
+						failed++
+					}
+				}
+				if failed > 0 {
+					return fmt.Errorf("%d of %d rows failed", failed, len(results))
+				}
+
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "", "csv or ndjson, guessed from the file's extension by default")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only validate the file")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletions(entities.ImportFormatCSV, entities.ImportFormatNDJSON))
+
+	return cmd
+}
+
+func newReplayChangelogCommand(opts *options) *cobra.Command {
+	var file string
+	var changeType string
+	var kafkaHost string
+	cmd := &cobra.Command{
+		Use:   "replay-changelog [user-id...]",
+		Short: "Publish changelog entries again",
+		Long: "Publish changelog entries to the users-changelog topic straight to Kafka, so that consumers find out " +
+			"about changes made offline or catch up after missing entries. Entries are either read from an NDJSON " +
+			"file in the format they are published in, such as a dump of the topic, or made for each user given " +
+			"with --change-type. Entries without a creation time are given the current time.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isOutputFormat(opts.output) {
+				return fmt.Errorf("unknown output format %q, expected table, json or yaml", opts.output)
+			}
+			if (file == "") == (len(args) == 0) {
+				return errors.New("give either user ids or --file")
+			}
+			if kafkaHost == "" {
+				return errors.New("a kafka host is required, with --kafka-host or $KAFKA_HOST")
+			}
+
+			var entries []entities.ChangelogEntry
+			var err error
+			if file != "" {
+				entries, err = readChangelogEntries(cmd, file)
+			} else {
+				entries, err = changelogEntriesForUsers(args, changeType)
+			}
+			if err != nil {
+				return err
+			}
+
+			kafkaAdapter, err := adapters.NewKafkaAdapter(kafkaHost, &adapters.KafkaDialer{})
+			if err != nil {
+				return fmt.Errorf("connecting to kafka: %w", err)
+			}
+			defer kafkaAdapter.CloseConn()
+
+			for i, entry := range entries {
+				err = kafkaAdapter.PublishChangelogEntry(entry)
+				if err != nil {
+					// the entries already published are printed so the replay can be carried on from where it stopped
+					_ = printChangelogEntries(cmd.OutOrStdout(), opts.output, entries[:i])
+					return fmt.Errorf("publishing changelog entry %d: %w", i+1, err)
+				}
+			}
+
+			return printChangelogEntries(cmd.OutOrStdout(), opts.output, entries)
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "NDJSON file of changelog entries, or - for stdin")
+	cmd.Flags().StringVar(&changeType, "change-type", "PUT", "change type of the entries made for the users given")
+	cmd.Flags().StringVar(&kafkaHost, "kafka-host", os.Getenv("KAFKA_HOST"), "Kafka broker to publish to, or $KAFKA_HOST")
+	cmd.MarkFlagsMutuallyExclusive("file", "change-type")
+
+	return cmd
+}
+
+// readChangelogEntries reads one changelog entry from each line of an NDJSON file, or stdin if the path is -
+func readChangelogEntries(cmd *cobra.Command, path string) ([]entities.ChangelogEntry, error) {
+	in := cmd.InOrStdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	now := time.Now().UTC()
+	entries := []entities.ChangelogEntry{}
+	decoder := json.NewDecoder(in)
+	for line := 1; ; line++ {
+		var entry entities.ChangelogEntry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading changelog entry %d: %w", line, err)
+		}
+		if entry.ChangeType == "" {
+			return nil, fmt.Errorf("changelog entry %d has no ChangeType", line)
+		}
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = now
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func changelogEntriesForUsers(args []string, changeType string) ([]entities.ChangelogEntry, error) {
+	if changeType == "" {
+		return nil, errors.New("--change-type can't be empty")
+	}
+
+	userIDs, err := parseUserIDs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	entries := make([]entities.ChangelogEntry, 0, len(userIDs))
+	for _, userID := range userIDs {
+		entries = append(entries, entities.ChangelogEntry{
+			UserID:     userID,
+			CreatedAt:  now,
+			ChangeType: changeType,
+		})
+	}
+
+	return entries, nil
+}
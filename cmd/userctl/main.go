@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/http"
+	"os"
+	"os/signal"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// options holds the flags shared by every command
+type options struct {
+	server  string
+	apiKey  string
+	offline bool
+	output  string
+}
+
+func main() {
+	// interrupting stops the request in flight rather than leaving it to finish unseen
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := newRootCommand().ExecuteContext(ctx)
+	if err != nil {
+		stop()
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:   "userctl",
+		Short: "Manage users of the user service",
+		Long: "userctl manages users through the user service's REST API, authenticating with an API key. With --offline " +
+			"it works on the storage backend directly, reading the same configuration as the service, for when the " +
+			"service isn't running. Offline changes don't publish changelog entries or send emails, use " +
+			"replay-changelog afterwards to tell consumers about them.",
+		SilenceUsage: true,
+	}
+
+	root.PersistentFlags().StringVar(&opts.server, "server", envOrDefault("USERCTL_SERVER", defaultServer), "URL of the user service, or $USERCTL_SERVER")
+	root.PersistentFlags().StringVar(&opts.apiKey, "api-key", os.Getenv("USERCTL_API_KEY"), "API key to authenticate with, or $USERCTL_API_KEY")
+	root.PersistentFlags().BoolVar(&opts.offline, "offline", false, "work on the storage backend directly instead of through the API")
+	root.PersistentFlags().StringVarP(&opts.output, "output", "o", outputTable, "output format, table, json or yaml")
+	_ = root.RegisterFlagCompletionFunc("output", fixedCompletions(outputTable, outputJSON, outputYAML))
+
+	root.AddCommand(
+		newGetCommand(opts),
+		newSearchCommand(opts),
+		newCreateCommand(opts),
+		newUpdateCommand(opts),
+		newSuspendCommand(opts),
+		newRestoreCommand(opts),
+		newDeleteCommand(opts),
+		newExportCommand(opts),
+		newImportCommand(opts),
+		newReplayChangelogCommand(opts),
+	)
+
+	return root
+}
+
+// userService opens the API client or, with --offline, the storage backend. The caller has to close it.
+func (o *options) userService() (userService, error) {
+	if !isOutputFormat(o.output) {
+		return nil, fmt.Errorf("unknown output format %q, expected table, json or yaml", o.output)
+	}
+
+	if o.offline {
+		service, err := newOfflineUserService()
+		if err != nil {
+			return nil, err
+		}
+		return service, nil
+	}
+
+	return newAPIUserService(o.server, o.apiKey, http.DefaultClient), nil
+}
+
+func envOrDefault(name, defaultValue string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// fixedCompletions completes a flag with one of values
+func fixedCompletions(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runUserctl runs userctl with args and returns what it wrote to stdout
+func runUserctl(stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	root := newRootCommand()
+	root.SetArgs(args)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetOut(&stdout)
+	root.SetErr(&bytes.Buffer{})
+
+	err := root.Execute()
+	return stdout.String(), err
+}
+
+// setOfflineSQLiteConfig configures offline mode to use a new SQLite database
+func setOfflineSQLiteConfig(g *WithT, t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "sqlite")
+	sqlitePath := filepath.Join(t.TempDir(), "users.db")
+	t.Setenv("SQLITE_PATH", sqlitePath)
+	t.Setenv("KAFKA_HOST", "localhost:9092")
+	t.Setenv("TOKEN_SIGNING_KEY", "some-signing-key")
+	t.Setenv("MFA_ENCRYPTION_KEY", "some-mfa-key")
+	t.Setenv("SIGNING_KEY_ENCRYPTION_KEY", "some-signing-key-encryption-key")
+	t.Setenv("PII_MASTER_KEYS", "1:some-master-key")
+	t.Setenv("PII_BLIND_INDEX_KEY", "some-blind-index-key")
+
+	// userctl doesn't migrate, so the schema is set up the way the service would
+	db, err := adapters.NewSQLiteDB(sqlitePath)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	g.Expect(adapters.NewSQLiteAdapter(db).PerformDataMigration(context.Background())).To(Succeed())
+}
+
+func TestOffline_ManagingAUser(t *testing.T) {
+	g := NewWithT(t)
+	setOfflineSQLiteConfig(g, t)
+
+	out, err := runUserctl("correct horse battery staple\n",
+		"create", "--offline", "-o", "json", "--password-stdin",
+		"--first-name", "alec", "--last-name", "smith", "--nickname", "alecsmith", "--email", "alec@email.com", "--country", "UK",
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+	var created userView
+	g.Expect(json.Unmarshal([]byte(out), &created)).To(Succeed())
+	g.Expect(created.Nickname).To(Equal("alecsmith"))
+	g.Expect(created.Status).To(Equal(string(entities.UserStatusPendingVerification)))
+	g.Expect(out).ToNot(ContainSubstring("correct horse"))
+
+	out, err = runUserctl("", "update", created.ID, "--offline", "-o", "yaml", "--nickname", "alex")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(out).To(ContainSubstring("nickname: alex\n"))
+	g.Expect(out).To(ContainSubstring("email: alec@email.com\n"))
+
+	out, err = runUserctl("", "get", created.ID, "--offline")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(out).To(HavePrefix("ID"))
+	g.Expect(out).To(ContainSubstring(created.ID))
+	g.Expect(out).To(ContainSubstring("alec smith"))
+
+	// statuses can only be changed offline with postgres
+	_, err = runUserctl("", "suspend", created.ID, "--offline")
+	g.Expect(err).To(MatchError(ContainSubstring("suspend isn't supported offline")))
+
+	_, err = runUserctl("", "delete", created.ID, "--offline")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = runUserctl("", "get", created.ID, "--offline")
+	g.Expect(err).To(MatchError(ContainSubstring(entities.ErrUserNotFound.Error())))
+}
+
+func TestOffline_WeakPassword(t *testing.T) {
+	g := NewWithT(t)
+	setOfflineSQLiteConfig(g, t)
+
+	_, err := runUserctl("",
+		"create", "--offline", "--password", "short",
+		"--first-name", "alec", "--last-name", "smith", "--nickname", "alecsmith", "--email", "alec@email.com", "--country", "UK",
+	)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestRestore_UnbansBannedUsers(t *testing.T) {
+	g := NewWithT(t)
+	userID := "a0b3c5a6-9c7e-4e5f-9d1a-1f0e2d3c4b5a"
+	var calls []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		g.Expect(r.Header.Get("Authorization")).To(Equal("ApiKey some-api-key"))
+
+		user := usecases.PublicUserResponse{ID: userID, Nickname: "alecsmith", Status: string(entities.UserStatusBanned)}
+		if r.URL.Path == "/users:batchGet" {
+			json.NewEncoder(w).Encode(usecases.BatchGetUsersResponseBody{Users: []usecases.PublicUserResponse{user}})
+			return
+		}
+
+		user.Status = string(entities.UserStatusActive)
+		json.NewEncoder(w).Encode(user)
+	}))
+	defer server.Close()
+
+	out, err := runUserctl("", "restore", userID, "--server", server.URL, "--api-key", "some-api-key", "-o", "json")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(calls).To(Equal([]string{
+		"POST /users:batchGet",
+		"POST /admin/user/" + userID + "/unban",
+	}))
+
+	var restored []userView
+	g.Expect(json.Unmarshal([]byte(out), &restored)).To(Succeed())
+	g.Expect(restored).To(HaveLen(1))
+	g.Expect(restored[0].Status).To(Equal(string(entities.UserStatusActive)))
+}
+
+func TestUpdate_NeedsPasswordThroughAPI(t *testing.T) {
+	g := NewWithT(t)
+	userID := "a0b3c5a6-9c7e-4e5f-9d1a-1f0e2d3c4b5a"
+	var updates []usecases.UpdateUserRequestBody
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := usecases.PublicUserResponse{ID: userID, FirstName: "alec", Nickname: "alecsmith", Email: "alec@email.com"}
+		if r.URL.Path == "/users:batchGet" {
+			json.NewEncoder(w).Encode(usecases.BatchGetUsersResponseBody{Users: []usecases.PublicUserResponse{user}})
+			return
+		}
+
+		var update usecases.UpdateUserRequestBody
+		g.Expect(json.NewDecoder(r.Body).Decode(&update)).To(Succeed())
+		updates = append(updates, update)
+		json.NewEncoder(w).Encode(usecases.UpdateUserResponseBody{ID: userID, Nickname: update.Nickname})
+	}))
+	defer server.Close()
+
+	_, err := runUserctl("", "update", userID, "--server", server.URL, "--nickname", "alex")
+	g.Expect(err).To(MatchError(ContainSubstring("--password")))
+	g.Expect(updates).To(BeEmpty())
+
+	_, err = runUserctl("correct horse battery\n", "update", userID, "--server", server.URL, "--nickname", "alex", "--password-stdin")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updates).To(HaveLen(1))
+	g.Expect(updates[0].Nickname).To(Equal("alex"))
+	g.Expect(updates[0].FirstName).To(Equal("alec"))
+	g.Expect(updates[0].Password).To(Equal("correct horse battery"))
+}
+
+func TestReplayChangelog_ReadsEntries(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := runUserctl("", "replay-changelog", "--kafka-host", "localhost:9092")
+	g.Expect(err).To(MatchError("give either user ids or --file"))
+
+	root := newRootCommand()
+	root.SetIn(strings.NewReader(
+		`{"UserID":"a0b3c5a6-9c7e-4e5f-9d1a-1f0e2d3c4b5a","CreatedAt":"2024-07-01T09:00:00Z","ChangeType":"PUT"}` + "\n" +
+			`{"UserID":"a0b3c5a6-9c7e-4e5f-9d1a-1f0e2d3c4b5a","ChangeType":"SUSPENDED"}` + "\n",
+	))
+	entries, err := readChangelogEntries(root, "-")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries[0].ChangeType).To(Equal("PUT"))
+	g.Expect(entries[1].CreatedAt.IsZero()).To(BeFalse())
+
+	root.SetIn(strings.NewReader(`{"UserID":"a0b3c5a6-9c7e-4e5f-9d1a-1f0e2d3c4b5a"}`))
+	_, err = readChangelogEntries(root, "-")
+	g.Expect(err).To(MatchError("changelog entry 1 has no ChangeType"))
+}
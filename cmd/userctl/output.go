@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func isOutputFormat(format string) bool {
+	return format == outputTable || format == outputJSON || format == outputYAML
+}
+
+// userView is how a user is printed, passwords are left out
+type userView struct {
+	ID              string     `json:"id" yaml:"id"`
+	FirstName       string     `json:"first_name" yaml:"first_name"`
+	LastName        string     `json:"last_name" yaml:"last_name"`
+	Nickname        string     `json:"nickname" yaml:"nickname"`
+	Email           string     `json:"email" yaml:"email"`
+	Country         string     `json:"country" yaml:"country"`
+	Status          string     `json:"status" yaml:"status"`
+	BanReason       string     `json:"ban_reason,omitempty" yaml:"ban_reason,omitempty"`
+	BanExpiresAt    *time.Time `json:"ban_expires_at,omitempty" yaml:"ban_expires_at,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" yaml:"email_verified_at,omitempty"`
+	PendingEmail    *string    `json:"pending_email,omitempty" yaml:"pending_email,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" yaml:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" yaml:"updated_at"`
+}
+
+func newUserView(user entities.User) userView {
+	return userView{
+		ID:              user.ID.String(),
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Nickname:        user.Nickname,
+		Email:           user.Email,
+		Country:         user.Country,
+		Status:          string(user.Status),
+		BanReason:       user.BanReason,
+		BanExpiresAt:    user.BanExpiresAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PendingEmail:    user.PendingEmail,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+type searchResultView struct {
+	userView      `yaml:",inline"`
+	Score         float64  `json:"score" yaml:"score"`
+	MatchedFields []string `json:"matched_fields" yaml:"matched_fields"`
+}
+
+type importResultView struct {
+	Row    int    `json:"row" yaml:"row"`
+	UserID string `json:"user_id,omitempty" yaml:"user_id,omitempty"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type changelogEntryView struct {
+	UserID     string    `json:"user_id,omitempty" yaml:"user_id,omitempty"`
+	ChangeType string    `json:"change_type" yaml:"change_type"`
+	CreatedAt  time.Time `json:"created_at" yaml:"created_at"`
+	UserIDs    []string  `json:"user_ids,omitempty" yaml:"user_ids,omitempty"`
+}
+
+// printValue writes value as JSON or YAML, or the rows under header as a table
+func printValue(w io.Writer, format string, value any, header []string, rows [][]string) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		err := encoder.Encode(value)
+		if err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
+
+var userTableHeader = []string{"ID", "NICKNAME", "NAME", "EMAIL", "COUNTRY", "STATUS", "CREATED"}
+
+func userTableRow(user entities.User) []string {
+	return []string{
+		user.ID.String(),
+		user.Nickname,
+		user.FirstName + " " + user.LastName,
+		user.Email,
+		user.Country,
+		string(user.Status),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func printUsers(w io.Writer, format string, users []entities.User) error {
+	views := make([]userView, 0, len(users))
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		views = append(views, newUserView(user))
+		rows = append(rows, userTableRow(user))
+	}
+
+	return printValue(w, format, views, userTableHeader, rows)
+}
+
+// printUser prints a single user as an object rather than a list
+func printUser(w io.Writer, format string, user entities.User) error {
+	return printValue(w, format, newUserView(user), userTableHeader, [][]string{userTableRow(user)})
+}
+
+func printSearchResults(w io.Writer, format string, results []entities.UserSearchResult) error {
+	views := make([]searchResultView, 0, len(results))
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		views = append(views, searchResultView{
+			userView:      newUserView(result.User),
+			Score:         result.Score,
+			MatchedFields: result.MatchedFields,
+		})
+		rows = append(rows, append(
+			userTableRow(result.User),
+			fmt.Sprintf("%.2f", result.Score),
+			strings.Join(result.MatchedFields, ","),
+		))
+	}
+
+	return printValue(w, format, views, append(userTableHeader, "SCORE", "MATCHED"), rows)
+}
+
+func printImportResults(w io.Writer, format string, results []entities.ImportRowResult) error {
+	views := make([]importResultView, 0, len(results))
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		view := importResultView{Row: result.Row, Error: result.Error}
+		if result.UserID != nil {
+			view.UserID = result.UserID.String()
+		}
+		views = append(views, view)
+		rows = append(rows, []string{fmt.Sprint(view.Row), view.UserID, view.Error})
+	}
+
+	return printValue(w, format, views, []string{"ROW", "USER ID", "ERROR"}, rows)
+}
+
+func printChangelogEntries(w io.Writer, format string, entries []entities.ChangelogEntry) error {
+	views := make([]changelogEntryView, 0, len(entries))
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		view := changelogEntryView{ChangeType: entry.ChangeType, CreatedAt: entry.CreatedAt}
+		if entry.UserID != uuid.Nil {
+			view.UserID = entry.UserID.String()
+		}
+		for _, userID := range entry.UserIDs {
+			view.UserIDs = append(view.UserIDs, userID.String())
+		}
+		views = append(views, view)
+
+		users := view.UserID
+		if users == "" {
+			users = fmt.Sprintf("%d users", len(view.UserIDs))
+		}
+		rows = append(rows, []string{users, view.ChangeType, view.CreatedAt.UTC().Format(time.RFC3339)})
+	}
+
+	return printValue(w, format, views, []string{"USER", "CHANGE TYPE", "CREATED"}, rows)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"io"
+	"net/http"
+)
+
+// userService is what the commands manage users through, either the service's API or the storage backend
+type userService interface {
+	// GetUsers returns the users that exist out of userIDs, in the order they were given
+	GetUsers(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error)
+	SearchUsers(ctx context.Context, query string, limit int) ([]entities.UserSearchResult, error)
+	CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error)
+	UpdateUser(ctx context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error)
+	SuspendUser(ctx context.Context, userID uuid.UUID) (*entities.User, error)
+	// RestoreUser lifts a suspension or ban, moving the user back to active, or pending verification if they haven't
+	// verified their email
+	RestoreUser(ctx context.Context, userID uuid.UUID) (*entities.User, error)
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	ExportUsers(ctx context.Context, export exportOptions, w io.Writer) error
+	// ImportUsers imports a CSV or NDJSON file, returning the results of the rows imported before any error
+	ImportUsers(ctx context.Context, format string, file io.Reader, dryRun bool) ([]entities.ImportRowResult, error)
+	Close() error
+}
+
+// exportOptions are the format, columns and filters of an export, matching the query parameters of GET /users/export
+type exportOptions struct {
+	format    string
+	columns   string
+	firstName string
+	lastName  string
+	nickname  string
+	email     string
+	country   string
+}
+
+// apiUserService manages users through the service's REST API, so changes have the same side effects as any other
+// request
+type apiUserService struct {
+	client *adapters.UserServiceClient
+}
+
+func newAPIUserService(server, apiKey string, httpClient *http.Client) *apiUserService {
+	return &apiUserService{client: adapters.NewUserServiceClient(server, apiKey, httpClient)}
+}
+
+func (a *apiUserService) GetUsers(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error) {
+	return a.client.GetUsersByIDs(ctx, userIDs)
+}
+
+func (a *apiUserService) SearchUsers(ctx context.Context, query string, limit int) ([]entities.UserSearchResult, error) {
+	return a.client.SearchUsers(ctx, query, limit)
+}
+
+func (a *apiUserService) CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	return a.client.CreateUser(ctx, firstName, lastName, nickname, password, email, country)
+}
+
+func (a *apiUserService) UpdateUser(ctx context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	return a.client.UpdateUser(ctx, userID, firstName, lastName, nickname, password, email, country)
+}
+
+func (a *apiUserService) SuspendUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	return a.client.SuspendUser(ctx, userID)
+}
+
+func (a *apiUserService) RestoreUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := a.client.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == entities.UserStatusBanned {
+		return a.client.UnbanUser(ctx, userID)
+	}
+
+	return a.client.UnsuspendUser(ctx, userID)
+}
+
+func (a *apiUserService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return a.client.DeleteUser(ctx, userID)
+}
+
+func (a *apiUserService) ExportUsers(ctx context.Context, export exportOptions, w io.Writer) error {
+	return a.client.ExportUsers(
+		ctx,
+		export.format,
+		export.columns,
+		export.firstName,
+		export.lastName,
+		export.nickname,
+		export.email,
+		export.country,
+		w,
+	)
+}
+
+func (a *apiUserService) ImportUsers(ctx context.Context, format string, file io.Reader, dryRun bool) ([]entities.ImportRowResult, error) {
+	return a.client.ImportFile(ctx, format, file, dryRun)
+}
+
+func (a *apiUserService) Close() error {
+	return nil
+}
+
+// offlineUserService manages users on the storage backend directly. It checks passwords against the same policy as
+// the service, but publishes no changelog entries and sends no emails. Features beyond storing users are only
+// available with the postgres backend.
+type offlineUserService struct {
+	repository        usecases.UserRepository
+	passwordValidator *usecases.PasswordValidator
+	importBatchSize   int
+	close             func() error
+}
+
+// newOfflineUserService opens the storage backend the service is configured with
+func newOfflineUserService() (*offlineUserService, error) {
+	conf, err := adapters.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	var breachCorpus usecases.BreachCorpus
+	if conf.BreachedPasswordsDir != "" {
+		breachCorpus = adapters.NewRangeFileBreachCorpus(conf.BreachedPasswordsDir)
+	}
+	service := &offlineUserService{
+		passwordValidator: usecases.NewPasswordValidator(
+			entities.PasswordPolicy{
+				MinLength:      conf.PasswordMinLength,
+				MaxLength:      conf.PasswordMaxLength,
+				MinEntropyBits: conf.PasswordMinEntropyBits,
+			},
+			breachCorpus,
+		),
+		importBatchSize: conf.ImportBatchSize,
+	}
+
+	switch conf.StorageBackend {
+	case adapters.StorageBackendPostgres:
+		db, err := sql.Open("postgres", conf.PostgresConnectionURI)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to postgres: %w", err)
+		}
+
+		piiCipher, err := adapters.NewPIICipher(conf.PIIMasterKeys, conf.PIIBlindIndexKey)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("creating pii cipher: %w", err)
+		}
+
+		postgresAdapter := adapters.NewPostgresAdapter(db, piiCipher)
+		err = postgresAdapter.LoadDataKeys(context.Background())
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("loading pii data keys: %w", err)
+		}
+
+		service.repository = postgresAdapter
+		service.close = db.Close
+	case adapters.StorageBackendSQLite:
+		db, err := adapters.NewSQLiteDB(conf.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("opening sqlite database: %w", err)
+		}
+
+		service.repository = adapters.NewSQLiteAdapter(db)
+		service.close = db.Close
+	case adapters.StorageBackendMemory:
+		return nil, errors.New("STORAGE_BACKEND is memory, users only exist inside the running service so can't be managed offline")
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", conf.StorageBackend)
+	}
+
+	return service, nil
+}
+
+// errNotSupportedOffline is returned for commands the configured storage backend can't run offline
+func errNotSupportedOffline(command string) error {
+	return fmt.Errorf("%s isn't supported offline by this storage backend, use the postgres backend or the API", command)
+}
+
+func (o *offlineUserService) GetUsers(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error) {
+	found, err := o.repository.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// the repository returns users in no particular order
+	byID := make(map[uuid.UUID]entities.User, len(found))
+	for _, user := range found {
+		byID[user.ID] = user
+	}
+
+	users := make([]entities.User, 0, len(found))
+	for _, userID := range userIDs {
+		if user, ok := byID[userID]; ok {
+			users = append(users, user)
+			delete(byID, userID)
+		}
+	}
+
+	return users, nil
+}
+
+func (o *offlineUserService) SearchUsers(ctx context.Context, query string, limit int) ([]entities.UserSearchResult, error) {
+	searcher, ok := o.repository.(usecases.UserSearcher)
+	if !ok {
+		return nil, errNotSupportedOffline("search")
+	}
+
+	return searcher.SearchUsers(ctx, query, limit)
+}
+
+func (o *offlineUserService) CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	err := o.passwordValidator.Validate(ctx, password, nickname, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.repository.CreateUser(ctx, firstName, lastName, nickname, password, email, country)
+}
+
+// UpdateUser replaces the user's profile, unlike through the API a new email address replaces the current one straight
+// away, unverified
+func (o *offlineUserService) UpdateUser(ctx context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	current, err := o.repository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// passwords set before the policy existed can be kept, only new ones are checked
+	if password != current.Password {
+		err = o.passwordValidator.Validate(ctx, password, nickname, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return o.repository.UpdateUser(ctx, userID, firstName, lastName, nickname, password, email, country)
+}
+
+func (o *offlineUserService) SuspendUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	userStatusUpdater, ok := o.repository.(usecases.UserStatusUpdater)
+	if !ok {
+		return nil, errNotSupportedOffline("suspend")
+	}
+
+	return usecases.SuspendUser(ctx, o.repository, userStatusUpdater, userID)
+}
+
+func (o *offlineUserService) RestoreUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	userStatusUpdater, ok := o.repository.(usecases.UserStatusUpdater)
+	if !ok {
+		return nil, errNotSupportedOffline("restore")
+	}
+
+	user, _, err := usecases.RestoreUser(ctx, o.repository, userStatusUpdater, userID)
+	return user, err
+}
+
+func (o *offlineUserService) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return o.repository.DeleteUser(ctx, userID)
+}
+
+func (o *offlineUserService) ExportUsers(ctx context.Context, export exportOptions, w io.Writer) error {
+	userExporter, ok := o.repository.(usecases.UserExporter)
+	if !ok {
+		return errNotSupportedOffline("export")
+	}
+
+	return usecases.WriteUserExport(
+		ctx,
+		userExporter,
+		export.format,
+		export.columns,
+		export.firstName,
+		export.lastName,
+		export.nickname,
+		export.email,
+		export.country,
+		w,
+	)
+}
+
+func (o *offlineUserService) ImportUsers(ctx context.Context, format string, file io.Reader, dryRun bool) ([]entities.ImportRowResult, error) {
+	userImporter, ok := o.repository.(usecases.UserImporter)
+	if !ok {
+		return nil, errNotSupportedOffline("import")
+	}
+
+	// files are only imported in the background by the service, so no job store is needed
+	userImport := usecases.NewUserImport(userImporter, nil, discardChangelog{}, o.passwordValidator, o.importBatchSize, "")
+
+	results := []entities.ImportRowResult{}
+	err := userImport.Import(ctx, format, file, dryRun, func(batch []entities.ImportRowResult) error {
+		results = append(results, batch...)
+		return nil
+	})
+
+	return results, err
+}
+
+func (o *offlineUserService) Close() error {
+	return o.close()
+}
+
+// discardChangelog drops the changelog entries of offline changes, which can be published with replay-changelog
+type discardChangelog struct{}
+
+func (discardChangelog) PublishChangelogEntry(entities.ChangelogEntry) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE platform_user
+    ADD COLUMN status         TEXT DEFAULT 'active' NOT NULL
+        CHECK (status IN ('pending_verification', 'active', 'suspended', 'banned')),
+    ADD COLUMN ban_reason     TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN ban_expires_at TIMESTAMP;
+
+CREATE INDEX platform_user_ban_expires_at_idx ON platform_user (ban_expires_at) WHERE status = 'banned';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX platform_user_ban_expires_at_idx;
+
+ALTER TABLE platform_user
+    DROP COLUMN ban_expires_at,
+    DROP COLUMN ban_reason,
+    DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE platform_user
+    ADD COLUMN email_verified_at TIMESTAMP,
+    ALTER COLUMN status SET DEFAULT 'pending_verification';
+
+CREATE TABLE verification_token(
+    id          uuid PRIMARY KEY,
+    user_id     uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    email       TEXT NOT NULL,
+    purpose     TEXT NOT NULL,
+    expires_at  TIMESTAMP NOT NULL,
+    used_at     TIMESTAMP,
+    created_at  TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX verification_token_user_id_idx ON verification_token (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE verification_token;
+
+ALTER TABLE platform_user
+    ALTER COLUMN status SET DEFAULT 'active',
+    DROP COLUMN email_verified_at;
+-- +goose StatementEnd
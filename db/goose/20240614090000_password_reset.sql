@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE platform_user
+    ADD COLUMN password_changed_at TIMESTAMP;
+
+CREATE TABLE password_reset_token(
+    token_hash  TEXT PRIMARY KEY,
+    user_id     uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    expires_at  TIMESTAMP NOT NULL,
+    used_at     TIMESTAMP,
+    created_at  TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX password_reset_token_user_id_idx ON password_reset_token (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE password_reset_token;
+
+ALTER TABLE platform_user
+    DROP COLUMN password_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE platform_user
+    ADD COLUMN pending_email TEXT;
+
+CREATE UNIQUE INDEX platform_user_pending_email_key ON platform_user (pending_email);
+
+-- email and pending_email share one namespace, an address can't be registered to one user while it is pending for
+-- another. The advisory locks serialise concurrent claims on the same address so the checks can't race.
+CREATE FUNCTION check_email_not_claimed() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext(NEW.email));
+    IF EXISTS (SELECT 1 FROM platform_user WHERE pending_email = NEW.email AND id <> NEW.id) THEN
+        RAISE EXCEPTION 'duplicate key value violates unique constraint "platform_user_email_key"'
+            USING ERRCODE = 'unique_violation';
+    END IF;
+
+    IF NEW.pending_email IS NOT NULL THEN
+        PERFORM pg_advisory_xact_lock(hashtext(NEW.pending_email));
+        IF EXISTS (SELECT 1 FROM platform_user WHERE email = NEW.pending_email AND id <> NEW.id) THEN
+            RAISE EXCEPTION 'duplicate key value violates unique constraint "platform_user_pending_email_key"'
+                USING ERRCODE = 'unique_violation';
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER platform_user_email_not_claimed
+    BEFORE INSERT OR UPDATE OF email, pending_email ON platform_user
+    FOR EACH ROW EXECUTE FUNCTION check_email_not_claimed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER platform_user_email_not_claimed ON platform_user;
+DROP FUNCTION check_email_not_claimed();
+DROP INDEX platform_user_pending_email_key;
+
+ALTER TABLE platform_user
+    DROP COLUMN pending_email;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE mfa_totp(
+    user_id             uuid PRIMARY KEY REFERENCES platform_user (id) ON DELETE CASCADE,
+    encrypted_secret    TEXT NOT NULL,
+    confirmed_at        TIMESTAMP,
+    last_used_counter   BIGINT,
+    created_at          TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE mfa_recovery_code(
+    code_hash   TEXT PRIMARY KEY,
+    user_id     uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    used_at     TIMESTAMP,
+    created_at  TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX mfa_recovery_code_user_id_idx ON mfa_recovery_code (user_id);
+
+CREATE TABLE user_session(
+    id                  uuid PRIMARY KEY,
+    user_id             uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    refresh_token_hash  TEXT NOT NULL UNIQUE,
+    created_at          TIMESTAMP NOT NULL,
+    expires_at          TIMESTAMP NOT NULL,
+    revoked_at          TIMESTAMP
+);
+
+CREATE INDEX user_session_user_id_idx ON user_session (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_session;
+DROP TABLE mfa_recovery_code;
+DROP TABLE mfa_totp;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webauthn_credential(
+    id                  uuid PRIMARY KEY,
+    user_id             uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    credential_id       BYTEA NOT NULL UNIQUE,
+    public_key          BYTEA NOT NULL,
+    attestation_type    TEXT NOT NULL,
+    transports          TEXT NOT NULL,
+    aaguid              BYTEA NOT NULL,
+    sign_count          BIGINT NOT NULL,
+    backup_eligible     BOOLEAN NOT NULL,
+    backup_state        BOOLEAN NOT NULL,
+    name                TEXT NOT NULL,
+    created_at          TIMESTAMP NOT NULL,
+    last_used_at        TIMESTAMP
+);
+
+CREATE INDEX webauthn_credential_user_id_idx ON webauthn_credential (user_id);
+
+CREATE TABLE webauthn_ceremony(
+    id              uuid PRIMARY KEY,
+    user_id         uuid REFERENCES platform_user (id) ON DELETE CASCADE,
+    purpose         TEXT NOT NULL,
+    session_data    JSONB NOT NULL,
+    expires_at      TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webauthn_ceremony;
+DROP TABLE webauthn_credential;
+-- +goose StatementEnd
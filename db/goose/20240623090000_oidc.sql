@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE oidc_client(
+    id              TEXT PRIMARY KEY,
+    secret_hash     TEXT,
+    name            TEXT NOT NULL,
+    redirect_uris   TEXT[] NOT NULL,
+    allowed_scopes  TEXT[] NOT NULL,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE TABLE oidc_consent(
+    user_id     uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    client_id   TEXT NOT NULL REFERENCES oidc_client (id) ON DELETE CASCADE,
+    scopes      TEXT[] NOT NULL,
+    granted_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, client_id)
+);
+
+CREATE TABLE oidc_authorization_code(
+    code_hash       TEXT PRIMARY KEY,
+    client_id       TEXT NOT NULL REFERENCES oidc_client (id) ON DELETE CASCADE,
+    user_id         uuid NOT NULL REFERENCES platform_user (id) ON DELETE CASCADE,
+    redirect_uri    TEXT NOT NULL,
+    scopes          TEXT[] NOT NULL,
+    nonce           TEXT NOT NULL,
+    code_challenge  TEXT NOT NULL,
+    auth_time       TIMESTAMP NOT NULL,
+    expires_at      TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE oidc_authorization_code;
+DROP TABLE oidc_consent;
+DROP TABLE oidc_client;
+-- +goose StatementEnd
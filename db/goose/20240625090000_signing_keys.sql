@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE signing_key(
+    id                      TEXT PRIMARY KEY,
+    status                  TEXT NOT NULL,
+    encrypted_private_key   TEXT NOT NULL,
+    created_at              TIMESTAMP NOT NULL,
+    activated_at            TIMESTAMP,
+    retired_at              TIMESTAMP,
+    expires_at              TIMESTAMP
+);
+
+-- there is only ever one active and one next key, so instances racing to create or rotate them conflict here
+CREATE UNIQUE INDEX signing_key_status_key ON signing_key (status) WHERE status IN ('active', 'next');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE signing_key;
+-- +goose StatementEnd
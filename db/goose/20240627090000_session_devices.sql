@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_session
+    ADD COLUMN device_name  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN user_agent   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN scope        TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_used_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_session
+    DROP COLUMN last_used_at,
+    DROP COLUMN scope,
+    DROP COLUMN ip_address,
+    DROP COLUMN user_agent,
+    DROP COLUMN device_name;
+-- +goose StatementEnd
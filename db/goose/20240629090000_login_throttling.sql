@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE login_throttle(
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    blocked_until   TIMESTAMP,
+    locked          BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE login_attempt(
+    id              uuid PRIMARY KEY,
+    user_id         uuid REFERENCES platform_user (id) ON DELETE SET NULL,
+    email           TEXT NOT NULL,
+    ip_address      TEXT NOT NULL,
+    user_agent      TEXT NOT NULL,
+    succeeded       BOOLEAN NOT NULL,
+    failure_reason  TEXT,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX login_attempt_user_id_idx ON login_attempt (user_id, created_at);
+CREATE INDEX login_attempt_ip_address_idx ON login_attempt (ip_address, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_attempt;
+DROP TABLE login_throttle;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_key(
+    id              uuid PRIMARY KEY,
+    name            TEXT NOT NULL,
+    owner           TEXT NOT NULL,
+    scopes          TEXT[] NOT NULL,
+    secret_hash     TEXT NOT NULL UNIQUE,
+    created_at      TIMESTAMP NOT NULL,
+    expires_at      TIMESTAMP,
+    last_used_at    TIMESTAMP,
+    revoked_at      TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE api_key;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_log(
+    sequence            BIGINT PRIMARY KEY,
+    actor               TEXT NOT NULL,
+    actor_api_key_id    uuid,
+    action              TEXT NOT NULL,
+    target_user_id      uuid,
+    details             JSONB NOT NULL,
+    ip_address          TEXT NOT NULL,
+    created_at          TIMESTAMP NOT NULL,
+    previous_hash       TEXT NOT NULL,
+    hash                TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX audit_log_target_user_id_idx ON audit_log (target_user_id, sequence);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- the hash chain detects changes, this stops them being made by accident
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_or_delete BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only;
+-- +goose StatementEnd
@@ -0,0 +1,102 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE data_key(
+    id              uuid PRIMARY KEY,
+    master_key_id   TEXT NOT NULL,
+    wrapped_key     TEXT NOT NULL,
+    created_at      TIMESTAMP NOT NULL
+);
+
+-- first_name, last_name, email and pending_email hold values encrypted by the service, rows written before this
+-- migration are encrypted by it on startup, and until then have no blind indexes
+ALTER TABLE platform_user
+    ADD COLUMN email_index TEXT,
+    ADD COLUMN pending_email_index TEXT,
+    ADD COLUMN first_name_index TEXT,
+    ADD COLUMN last_name_index TEXT;
+
+-- encrypted values are never equal, uniqueness is kept by the blind indexes instead
+ALTER TABLE platform_user DROP CONSTRAINT platform_user_email_key;
+DROP INDEX platform_user_pending_email_key;
+CREATE UNIQUE INDEX platform_user_email_index_key ON platform_user (email_index);
+CREATE UNIQUE INDEX platform_user_pending_email_index_key ON platform_user (pending_email_index);
+CREATE INDEX platform_user_first_name_index_idx ON platform_user (first_name_index);
+CREATE INDEX platform_user_last_name_index_idx ON platform_user (last_name_index);
+
+DROP TRIGGER platform_user_email_not_claimed ON platform_user;
+DROP FUNCTION check_email_not_claimed();
+
+-- email and pending_email share one namespace, an address can't be registered to one user while it is pending for
+-- another. The advisory locks serialise concurrent claims on the same address so the checks can't race.
+CREATE FUNCTION check_email_not_claimed() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.email_index IS NOT NULL THEN
+        PERFORM pg_advisory_xact_lock(hashtext(NEW.email_index));
+        IF EXISTS (SELECT 1 FROM platform_user WHERE pending_email_index = NEW.email_index AND id <> NEW.id) THEN
+            RAISE EXCEPTION 'duplicate key value violates unique constraint "platform_user_email_index_key"'
+                USING ERRCODE = 'unique_violation';
+        END IF;
+    END IF;
+
+    IF NEW.pending_email_index IS NOT NULL THEN
+        PERFORM pg_advisory_xact_lock(hashtext(NEW.pending_email_index));
+        IF EXISTS (SELECT 1 FROM platform_user WHERE email_index = NEW.pending_email_index AND id <> NEW.id) THEN
+            RAISE EXCEPTION 'duplicate key value violates unique constraint "platform_user_pending_email_index_key"'
+                USING ERRCODE = 'unique_violation';
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER platform_user_email_not_claimed
+    BEFORE INSERT OR UPDATE OF email_index, pending_email_index ON platform_user
+    FOR EACH ROW EXECUTE FUNCTION check_email_not_claimed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- encrypted values can't be decrypted here, this only restores the schema
+DROP TRIGGER platform_user_email_not_claimed ON platform_user;
+DROP FUNCTION check_email_not_claimed();
+
+CREATE FUNCTION check_email_not_claimed() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext(NEW.email));
+    IF EXISTS (SELECT 1 FROM platform_user WHERE pending_email = NEW.email AND id <> NEW.id) THEN
+        RAISE EXCEPTION 'duplicate key value violates unique constraint "platform_user_email_key"'
+            USING ERRCODE = 'unique_violation';
+    END IF;
+
+    IF NEW.pending_email IS NOT NULL THEN
+        PERFORM pg_advisory_xact_lock(hashtext(NEW.pending_email));
+        IF EXISTS (SELECT 1 FROM platform_user WHERE email = NEW.pending_email AND id <> NEW.id) THEN
+            RAISE EXCEPTION 'duplicate key value violates unique constraint "platform_user_pending_email_key"'
+                USING ERRCODE = 'unique_violation';
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER platform_user_email_not_claimed
+    BEFORE INSERT OR UPDATE OF email, pending_email ON platform_user
+    FOR EACH ROW EXECUTE FUNCTION check_email_not_claimed();
+
+DROP INDEX platform_user_last_name_index_idx;
+DROP INDEX platform_user_first_name_index_idx;
+DROP INDEX platform_user_pending_email_index_key;
+DROP INDEX platform_user_email_index_key;
+CREATE UNIQUE INDEX platform_user_pending_email_key ON platform_user (pending_email);
+ALTER TABLE platform_user ADD CONSTRAINT platform_user_email_key UNIQUE (email);
+
+ALTER TABLE platform_user
+    DROP COLUMN last_name_index,
+    DROP COLUMN first_name_index,
+    DROP COLUMN pending_email_index,
+    DROP COLUMN email_index;
+
+DROP TABLE data_key;
+-- +goose StatementEnd
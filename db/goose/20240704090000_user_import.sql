@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_import_job(
+    id              uuid PRIMARY KEY,
+    format          TEXT NOT NULL,
+    dry_run         BOOLEAN NOT NULL,
+    status          TEXT NOT NULL,
+    imported_rows   INTEGER NOT NULL DEFAULT 0,
+    failed_rows     INTEGER NOT NULL DEFAULT 0,
+    error           TEXT,
+    created_at      TIMESTAMP NOT NULL,
+    finished_at     TIMESTAMP
+);
+
+-- row_number is the row's position in the import file, user_id is only set for rows that created a user
+CREATE TABLE user_import_result(
+    job_id          uuid NOT NULL REFERENCES user_import_job(id) ON DELETE CASCADE,
+    row_number      INTEGER NOT NULL,
+    user_id         uuid,
+    error           TEXT,
+    PRIMARY KEY (job_id, row_number)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_import_result;
+DROP TABLE user_import_job;
+-- +goose StatementEnd
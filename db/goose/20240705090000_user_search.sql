@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- names and emails are encrypted so only nicknames can be searched by similarity, the trigram index also serves the
+-- nickname ILIKE filter of GET /users. The full text index is on an expression rather than a column as users are read
+-- with SELECT *.
+CREATE INDEX platform_user_nickname_trgm_idx ON platform_user USING GIN (nickname gin_trgm_ops);
+CREATE INDEX platform_user_nickname_tsv_idx ON platform_user USING GIN (to_tsvector('simple', nickname));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX platform_user_nickname_tsv_idx;
+DROP INDEX platform_user_nickname_trgm_idx;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- blind indexes of the lower case n-grams of each encrypted name and email, so GET /users can filter by any part of
+-- them. Rows written before this migration are given their tokens on startup, and until then don't match those filters.
+ALTER TABLE platform_user
+    ADD COLUMN first_name_tokens TEXT[],
+    ADD COLUMN last_name_tokens TEXT[],
+    ADD COLUMN email_tokens TEXT[];
+
+CREATE INDEX platform_user_first_name_tokens_idx ON platform_user USING GIN (first_name_tokens);
+CREATE INDEX platform_user_last_name_tokens_idx ON platform_user USING GIN (last_name_tokens);
+CREATE INDEX platform_user_email_tokens_idx ON platform_user USING GIN (email_tokens);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX platform_user_email_tokens_idx;
+DROP INDEX platform_user_last_name_tokens_idx;
+DROP INDEX platform_user_first_name_tokens_idx;
+
+ALTER TABLE platform_user
+    DROP COLUMN email_tokens,
+    DROP COLUMN last_name_tokens,
+    DROP COLUMN first_name_tokens;
+-- +goose StatementEnd
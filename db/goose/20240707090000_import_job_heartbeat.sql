@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- running jobs record a heartbeat, so jobs left running by an instance that stopped without failing them can be found.
+-- Jobs running when it is applied have none, so they are failed by the first check for interrupted jobs.
+ALTER TABLE user_import_job ADD COLUMN heartbeat_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_import_job DROP COLUMN heartbeat_at;
+-- +goose StatementEnd
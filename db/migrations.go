@@ -0,0 +1,19 @@
+// Package db embeds the goose migrations, so that the binary can migrate a database whatever directory it runs in
+package db
+
+import "embed"
+
+// PostgresMigrations holds the Postgres migrations under PostgresMigrationsDir
+//
+//go:embed goose/*.sql
+var PostgresMigrations embed.FS
+
+// SQLiteMigrations holds the SQLite migrations under SQLiteMigrationsDir
+//
+//go:embed sqlite/*.sql
+var SQLiteMigrations embed.FS
+
+const (
+	PostgresMigrationsDir = "goose"
+	SQLiteMigrationsDir   = "sqlite"
+)
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- timestamps are stored as fixed width UTC text, to the microsecond, so that they sort in time order
+CREATE TABLE platform_user(
+    id                  TEXT PRIMARY KEY,
+    first_name          TEXT NOT NULL,
+    last_name           TEXT NOT NULL,
+    nickname            TEXT NOT NULL,
+    password            TEXT NOT NULL,
+    email               TEXT NOT NULL UNIQUE,
+    country             TEXT NOT NULL,
+    created_at          TEXT NOT NULL,
+    updated_at          TEXT NOT NULL,
+    status              TEXT NOT NULL DEFAULT 'pending_verification'
+        CHECK (status IN ('pending_verification', 'active', 'suspended', 'banned')),
+    ban_reason          TEXT NOT NULL DEFAULT '',
+    ban_expires_at      TEXT,
+    email_verified_at   TEXT,
+    password_changed_at TEXT,
+    pending_email       TEXT UNIQUE
+);
+
+CREATE INDEX platform_user_created_at_idx ON platform_user (created_at, id);
+
+-- an email can't be used by one user while it is another's pending email
+CREATE TRIGGER platform_user_email_not_claimed_insert
+    BEFORE INSERT ON platform_user
+    WHEN EXISTS (SELECT 1 FROM platform_user WHERE pending_email = NEW.email)
+BEGIN
+    SELECT RAISE(ABORT, 'UNIQUE constraint failed: platform_user.email');
+END;
+
+CREATE TRIGGER platform_user_email_not_claimed_update
+    BEFORE UPDATE OF email, pending_email ON platform_user
+    WHEN EXISTS (SELECT 1 FROM platform_user WHERE pending_email = NEW.email AND id <> NEW.id)
+        OR EXISTS (SELECT 1 FROM platform_user WHERE email = NEW.pending_email AND id <> NEW.id)
+BEGIN
+    SELECT RAISE(ABORT, 'UNIQUE constraint failed: platform_user.email');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER platform_user_email_not_claimed_update;
+DROP TRIGGER platform_user_email_not_claimed_insert;
+DROP TABLE platform_user;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the reply given to each user command processed, so a redelivered command is given it again rather than applied twice.
+-- violations is a JSON array.
+CREATE TABLE user_command_reply(
+    correlation_id  TEXT PRIMARY KEY,
+    type            TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    user_id         TEXT,
+    error           TEXT NOT NULL DEFAULT '',
+    violations      TEXT NOT NULL DEFAULT '[]',
+    processed_at    TEXT NOT NULL
+);
+
+CREATE INDEX user_command_reply_processed_at_idx ON user_command_reply (processed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_command_reply;
+-- +goose StatementEnd
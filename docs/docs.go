@@ -15,9 +15,79 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/user": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Returns the public keys clients can check the signatures of ID tokens with",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.JSONWebKeySetResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/.well-known/openid-configuration": {
+            "get": {
+                "description": "Describes where the provider's endpoints are and what it supports",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OpenID Connect discovery",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.OpenIDConfigurationResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys": {
+            "get": {
+                "description": "Lists every API key that has been minted, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ListAPIKeysResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
             "post": {
-                "description": "Create a new user with the provided details",
+                "description": "Mints a key for another service to call the API with, sent as ` + "`" + `Authorization: ApiKey \u003ckey\u003e` + "`" + `. The key is\nonly shown once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,25 +95,182 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Create a new user",
+                "summary": "Mint API key",
                 "parameters": [
                     {
-                        "description": "Create User Request Body",
-                        "name": "user",
+                        "description": "Create API Key Request Body",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/usecases.CreateUserRequestBody"
+                            "$ref": "#/definitions/usecases.CreateAPIKeyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.APIKeyResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/api-keys/{apiKeyId}": {
+            "delete": {
+                "description": "Stops an API key from working, requests already authenticated with it are unaffected",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key ID",
+                        "name": "apiKeyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/audit": {
+            "get": {
+                "description": "Returns audit log entries in the order they were recorded, optionally filtered by actor, action, user and\ntime. Pages are fetched by passing next_after as after.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Query audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key name",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest time, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest time, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sequence to return entries after",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/usecases.CreateUserResponseBody"
+                            "$ref": "#/definitions/usecases.GetAuditLogResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/oidc/clients": {
+            "post": {
+                "description": "Registers a first-party client. Confidential clients are given a secret, which is only shown once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "Register OpenID Connect client",
+                "parameters": [
+                    {
+                        "description": "Create OIDC Client Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.CreateOIDCClientRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.OIDCClientResponse"
                         }
                     },
                     "400": {
@@ -55,9 +282,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/{userId}": {
-            "put": {
-                "description": "Updates user information for the provided userId",
+        "/admin/user/{userId}/ban": {
+            "post": {
+                "description": "Bans a user account, either permanently or until the provided expiry",
                 "consumes": [
                     "application/json"
                 ],
@@ -65,9 +292,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Update User",
+                "summary": "Ban user",
                 "parameters": [
                     {
                         "type": "string",
@@ -77,12 +304,11 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Create User Request Body",
-                        "name": "user",
+                        "description": "Ban User Request Body",
+                        "name": "ban",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/usecases.UpdateUserRequestBody"
+                            "$ref": "#/definitions/usecases.BanUserRequestBody"
                         }
                     }
                 ],
@@ -90,29 +316,31 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/usecases.UpdateUserResponseBody"
+                            "$ref": "#/definitions/usecases.UserResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a user from the system",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/admin/user/{userId}/suspend": {
+            "post": {
+                "description": "Suspends a user account until it is unsuspended by an admin",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Delete user",
+                "summary": "Suspend user",
                 "parameters": [
                     {
                         "type": "string",
@@ -124,101 +352,3179 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UserResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/users": {
-            "get": {
-                "description": "Gets  list of users based on optional search criteria",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/user/{userId}/unban": {
+            "post": {
+                "description": "Reactivates a banned user account before its ban expires",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get a list of users",
+                "summary": "Unban user",
                 "parameters": [
                     {
-                        "description": "Get Users Request Body",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/usecases.GetUsersRequestBody"
-                        }
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/usecases.GetUsersResponseBody"
+                            "$ref": "#/definitions/usecases.UserResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/user/{userId}/unlock": {
+            "post": {
+                "description": "Clears the user's failed logins, lifting any lockout or backoff on their account before it expires.\nFailures counted against IP addresses are left as they are.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unlock user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "usecases.CreateUserRequestBody": {
-            "description": "Request body for creating a new user",
+        },
+        "/admin/user/{userId}/unsuspend": {
+            "post": {
+                "description": "Reactivates a suspended user account",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unsuspend user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Checks the user's credentials. Users without MFA get the tokens for a new session, users with MFA get a\nchallenge to complete at /auth/login/mfa",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Login Request Body",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.LoginRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.LoginResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/auth/login/mfa": {
+            "post": {
+                "description": "Exchanges an MFA challenge and a second factor for the tokens of a new session. The challenge can only be\nsubmitted once, so a wrong code means logging in again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Complete MFA login",
+                "parameters": [
+                    {
+                        "description": "Login MFA Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.LoginMFARequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.LoginResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Emails a password reset token to the user with the provided email. Always returns 202 Accepted so that it can't be used to discover which emails are registered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Forgot password",
+                "parameters": [
+                    {
+                        "description": "Forgot Password Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ForgotPasswordRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Redeems a password reset token and sets the user's new password, invalidating any credentials issued before the change",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset Password Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ResetPasswordRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.PasswordRejectedResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can only be\nused once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh session",
+                "parameters": [
+                    {
+                        "description": "Refresh Session Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.RefreshSessionRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.LoginResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/login/begin": {
+            "post": {
+                "description": "Returns the options for logging in with a passkey. No username is needed, the authenticator picks the account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Begin WebAuthn login",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.WebAuthnOptionsResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/login/finish": {
+            "post": {
+                "description": "Checks the authenticator's response and returns the tokens for a new session. Passkeys verify the user\nthemselves, so no MFA challenge follows.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish WebAuthn login",
+                "parameters": [
+                    {
+                        "description": "Finish WebAuthn Login Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.FinishWebAuthnLoginRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.LoginResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/authorize": {
+            "get": {
+                "description": "Starts the authorization code flow for a user signed in with a bearer access token. Redirects to the\nclient with a code, or with an error. Returns the consent to ask for if the user hasn't consented yet.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OpenID Connect authorize",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "A redirect URI registered for the client",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated scopes, including openid",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Returned to the client unchanged",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Copied into the ID token",
+                        "name": "nonce",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "none to fail rather than ask for consent",
+                        "name": "prompt",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ConsentRequiredResponseBody"
+                        }
+                    },
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/authorize/consent": {
+            "post": {
+                "description": "Takes the same query parameters as /authorize. If the user approves, their consent is stored and they are\nredirected to the client with a code. Otherwise they are redirected with an access_denied error.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OpenID Connect consent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "A redirect URI registered for the client",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated scopes, including openid",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Returned to the client unchanged",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Copied into the ID token",
+                        "name": "nonce",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Consent Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ConsentRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/email-change/confirm": {
+            "post": {
+                "description": "Redeems the token sent to the new address, replacing the user's email with their pending email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Confirm email change",
+                "parameters": [
+                    {
+                        "description": "Email Change Token Request Body",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.EmailChangeTokenRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/email-change/revert": {
+            "post": {
+                "description": "Redeems the token sent to the previous address, restoring it as the user's email and cancelling any pending change",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revert email change",
+                "parameters": [
+                    {
+                        "description": "Email Change Token Request Body",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.EmailChangeTokenRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/token": {
+            "post": {
+                "description": "Exchanges an authorization code and its PKCE code verifier for an ID token and the tokens of a new\nsession. Confidential clients authenticate with HTTP basic authentication or client_secret.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OpenID Connect token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must be authorization_code",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The redirect URI the code was issued to",
+                        "name": "redirect_uri",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID, if not using basic authentication",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret, if not using basic authentication",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code verifier",
+                        "name": "code_verifier",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.TokenResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.OAuthErrorResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.OAuthErrorResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user": {
+            "post": {
+                "description": "Create a new user with the provided details, and email them a token to verify their email address.\nThe password must satisfy the password policy and not have appeared in a known data breach.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "Create User Request Body",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.CreateUserRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.CreateUserResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.PasswordRejectedResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}": {
+            "put": {
+                "description": "Updates user information for the provided userId. A new email address is held as pending until it is\nconfirmed from that address",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create User Request Body",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UpdateUserRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UpdateUserResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.PasswordRejectedResponseBody"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a user from the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/mfa/recovery-codes": {
+            "post": {
+                "description": "Replaces the user's recovery codes after checking a second factor. Any unused codes stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Regenerate recovery codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "MFA Code Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.MFACodeRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.RecoveryCodesResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/mfa/totp": {
+            "post": {
+                "description": "Generates a TOTP secret for the user. MFA isn't enabled until the enrolment is confirmed with a first\ncode, and enrolling again before then replaces the secret.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Enrol TOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Enrol TOTP Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.EnrolTOTPRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.EnrolTOTPResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/mfa/totp/confirm": {
+            "post": {
+                "description": "Enables MFA once the user submits a first code from their authenticator, returning their recovery codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Confirm TOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "MFA Code Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.MFACodeRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.RecoveryCodesResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/mfa/totp/disable": {
+            "post": {
+                "description": "Removes the user's authenticator and recovery codes after checking a second factor",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Disable TOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "MFA Code Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.MFACodeRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/password": {
+            "post": {
+                "description": "Changes the user's password after checking their current one, invalidating any credentials issued before the change",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Change Password Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ChangePasswordRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.PasswordRejectedResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/sessions": {
+            "get": {
+                "description": "Lists the devices the user is logged in on",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ListSessionsResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Ends every one of the user's sessions, so they have to log in again on all of their devices",
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke all sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/sessions/{sessionId}": {
+            "delete": {
+                "description": "Ends one of the user's sessions so that it can no longer be refreshed. Access tokens already issued for it\nremain valid until they expire, services should drop the session when they see the changelog entry.",
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/verify-email/send": {
+            "post": {
+                "description": "Emails the user a new single-use token to verify their email address",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Send verification email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/webauthn/credentials": {
+            "get": {
+                "description": "Lists the passkeys and security keys registered to the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "List WebAuthn credentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ListWebAuthnCredentialsResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/webauthn/credentials/{credentialId}": {
+            "delete": {
+                "description": "Removes a passkey or security key so it can no longer be used to log in",
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Revoke WebAuthn credential",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Credential ID",
+                        "name": "credentialId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/webauthn/register/begin": {
+            "post": {
+                "description": "Returns the options for creating a passkey, excluding the user's existing credentials",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Begin WebAuthn registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Begin WebAuthn Registration Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BeginWebAuthnRegistrationRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.WebAuthnOptionsResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "429": {
+                        "description": "Too Many Requests"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/user/{userId}/webauthn/register/finish": {
+            "post": {
+                "description": "Checks the authenticator's response and stores the new credential. Each ceremony can only be finished once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Finish WebAuthn registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token for the user",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Finish WebAuthn Registration Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.FinishWebAuthnRegistrationRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.WebAuthnCredentialResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/userinfo": {
+            "get": {
+                "description": "Returns the claims about the user that the access token's scopes allow: sub always, nickname,\ngiven_name, family_name and country for the profile scope, email and email_verified for the email scope",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OpenID Connect userinfo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Gets  list of users based on optional search criteria",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a list of users",
+                "parameters": [
+                    {
+                        "description": "Get Users Request Body",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.GetUsersRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.GetUsersResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/export": {
+            "get": {
+                "description": "Streams every user matching the same filters as GET /users, in creation order, as a CSV file with a\nheader row, an NDJSON file with one user per line or a Parquet file. Passwords are never exported. If\nthe export fails part way through the connection is dropped rather than the file being finished.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export users",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "parquet"
+                        ],
+                        "type": "string",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated columns to export",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First name",
+                        "name": "first_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last name",
+                        "name": "last_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nickname",
+                        "name": "nickname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Country",
+                        "name": "country",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/import": {
+            "post": {
+                "description": "Creates users from a CSV file with a header row, or an NDJSON file with one user per line. Each user\nneeds a first_name, last_name, nickname, password, email and country, and is validated the same way as\nPOST /user. Valid rows are imported in batches, rows that fail are reported without stopping the\nimport. Imported users aren't sent verification emails. With async the file is imported in the\nbackground and the job is returned, its results can be fetched from GET /users/import/{jobId}.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Import users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson, defaults to matching the Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Import in the background",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ImportUsersResponseBody"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ImportJobResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ImportUsersResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ImportUsersResponseBody"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable"
+                    }
+                }
+            }
+        },
+        "/users/import/{jobId}": {
+            "get": {
+                "description": "Returns an import job started with async, along with a page of its row results. Pages are fetched by\npassing next_after as after.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get import job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Row to return results after",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.ImportJobResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/search": {
+            "get": {
+                "description": "Searches users by nickname, names and email, best match first. Nicknames are matched by trigram\nsimilarity and full text search, names and emails by trigram similarity once decrypted, so typos are\ntolerated in all of them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Search users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most users to return, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.SearchUsersResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users:batchDelete": {
+            "post": {
+                "description": "Deletes up to BATCH_MAX_USERS users in one transaction. In all_or_nothing mode, the default, nothing is\ndeleted if any user doesn't exist and the response is a 400 listing them. In best_effort mode every user\nthat exists is deleted and the rest are reported as failed. IDs given more than once are only reported\nonce, at their first position.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete users",
+                "parameters": [
+                    {
+                        "description": "Batch Delete Users Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchDeleteUsersRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchWriteResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchWriteResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users:batchGet": {
+            "post": {
+                "description": "Gets up to BATCH_MAX_USERS users by ID in one request. Found users are returned in the order their IDs\nwere given and IDs that no user has are listed separately. IDs given more than once are only returned\nonce, at their first position. Passwords are never returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get users by ID",
+                "parameters": [
+                    {
+                        "description": "Batch Get Users Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchGetUsersRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchGetUsersResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users:batchUpdate": {
+            "post": {
+                "description": "Updates up to BATCH_MAX_USERS users in one transaction. In all_or_nothing mode, the default, nothing is\nupdated if any user can't be and the response is a 400 listing why. In best_effort mode every user that\ncan be updated is and the rest are reported as failed. Each user only needs the fields that change, so\nusers can be suspended or banned without giving their profile. Profile changes are checked as\nPUT /user/{userId} checks them, but emails can't be changed in a batch as a new address has to be\nconfirmed first. Status changes are checked as the suspend and ban endpoints check them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update users",
+                "parameters": [
+                    {
+                        "description": "Batch Update Users Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchUpdateUsersRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchWriteResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.BatchWriteResponseBody"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/verify-email": {
+            "post": {
+                "description": "Redeems an email verification token, marking the user's email as verified and activating a pending account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Verify email",
+                "parameters": [
+                    {
+                        "description": "Verify Email Request Body",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/usecases.VerifyEmailRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/usecases.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "usecases.APIKeyResponse": {
+            "description": "A key another service calls the API with",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt represents when the key was minted",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt represents when the key stops working, if it does",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID represents the key's identifier",
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Key represents the key's secret, it is only returned when the key is minted",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "LastUsedAt represents when the key was last used, if it has been",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name represents what the key is used for",
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner represents the service or team responsible for the key",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "RevokedAt represents when the key was revoked, if it has been",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes represents what the key can do",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.AuditEntryResponse": {
+            "description": "Who did what, and the hashes chaining the entry to the one before it",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action represents the kind of action",
+                    "type": "string"
+                },
+                "actor": {
+                    "description": "Actor represents the name of the API key the action was taken with",
+                    "type": "string"
+                },
+                "actor_api_key_id": {
+                    "description": "ActorAPIKeyID represents the ID of the API key the action was taken with, if it wasn't the bootstrap key",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "CreatedAt represents when the action was taken",
+                    "type": "string"
+                },
+                "details": {
+                    "description": "Details represents anything else identifying what the action was taken on",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "hash": {
+                    "description": "Hash represents the hash of the entry's contents and PreviousHash",
+                    "type": "string"
+                },
+                "ip_address": {
+                    "description": "IPAddress represents the IP address the request came from",
+                    "type": "string"
+                },
+                "previous_hash": {
+                    "description": "PreviousHash represents the hash of the entry before, empty for the first entry",
+                    "type": "string"
+                },
+                "sequence": {
+                    "description": "Sequence represents the entry's position in the log",
+                    "type": "integer"
+                },
+                "target_user_id": {
+                    "description": "TargetUserID represents the user the action was taken on, if it was taken on one",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.BanUserRequestBody": {
+            "description": "Optional reason and expiry for a ban, a ban with no expiry is permanent",
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt represents the timestamp when the ban is automatically lifted",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason represents why the user was banned",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.BatchDeleteUsersRequestBody": {
+            "description": "The users to delete and how to apply the batch",
+            "type": "object",
+            "required": [
+                "user_ids"
+            ],
+            "properties": {
+                "mode": {
+                    "description": "Mode represents how the batch is applied, all_or_nothing by default or best_effort",
+                    "type": "string",
+                    "enum": [
+                        "all_or_nothing",
+                        "best_effort"
+                    ]
+                },
+                "user_ids": {
+                    "description": "UserIDs represents the IDs of the users to delete, there can be at most BATCH_MAX_USERS of them",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.BatchGetUsersRequestBody": {
+            "description": "The IDs of the users to get",
+            "type": "object",
+            "required": [
+                "user_ids"
+            ],
+            "properties": {
+                "user_ids": {
+                    "description": "UserIDs represents the IDs of the users to get, there can be at most BATCH_MAX_USERS of them",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.BatchGetUsersResponseBody": {
+            "description": "The users that were found and the IDs that weren't",
+            "type": "object",
+            "properties": {
+                "missing_user_ids": {
+                    "description": "MissingUserIDs represents the IDs that no user has, in the order they were given",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "users": {
+                    "description": "Users represents the users that were found, in the order their IDs were given",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.PublicUserResponse"
+                    }
+                }
+            }
+        },
+        "usecases.BatchItemResultResponse": {
+            "description": "The outcome for one user of a batch",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error represents why the user couldn't be written, it is only set for FAILED",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status represents the outcome, SUCCEEDED, FAILED or SKIPPED",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "User represents the updated user, it is only set for successful updates",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/usecases.PublicUserResponse"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "description": "UserID represents the user's unique identifier",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.BatchUpdateUsersRequestBody": {
+            "description": "The users to update and how to apply the batch",
+            "type": "object",
+            "required": [
+                "users"
+            ],
+            "properties": {
+                "mode": {
+                    "description": "Mode represents how the batch is applied, all_or_nothing by default or best_effort",
+                    "type": "string",
+                    "enum": [
+                        "all_or_nothing",
+                        "best_effort"
+                    ]
+                },
+                "users": {
+                    "description": "Users represents the new profile of each user, there can be at most BATCH_MAX_USERS of them",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/usecases.BatchUserUpdate"
+                    }
+                }
+            }
+        },
+        "usecases.BatchUserUpdate": {
+            "description": "The changes to one user, emails can only be changed with PUT /user/{userId}",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "ban_expires_at": {
+                    "description": "BanExpiresAt represents the timestamp when the ban is automatically lifted, it can only be given with the banned\nstatus and a ban with no expiry is permanent",
+                    "type": "string"
+                },
+                "ban_reason": {
+                    "description": "BanReason represents why the user was banned, it can only be given with the banned status",
+                    "type": "string"
+                },
+                "country": {
+                    "description": "Country represents the user's country",
+                    "type": "string"
+                },
+                "first_name": {
+                    "description": "FirstName represents the user's first name",
+                    "type": "string"
+                },
+                "last_name": {
+                    "description": "LastName represents the user's last name",
+                    "type": "string"
+                },
+                "nickname": {
+                    "description": "Nickname represents the user's nickname",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password represents the user's password",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status represents the status to move the user into, suspended or banned",
+                    "type": "string",
+                    "enum": [
+                        "suspended",
+                        "banned"
+                    ]
+                },
+                "user_id": {
+                    "description": "UserID represents the user's unique identifier",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.BatchWriteResponseBody": {
+            "description": "The outcome of every user in the batch",
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "description": "Failed represents how many users couldn't be written",
+                    "type": "integer"
+                },
+                "mode": {
+                    "description": "Mode represents whether the batch was applied all or nothing or best effort",
+                    "type": "string"
+                },
+                "results": {
+                    "description": "Results represents the outcome for each user, in the order they were given",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.BatchItemResultResponse"
+                    }
+                },
+                "skipped": {
+                    "description": "Skipped represents how many users could have been written but weren't, as the batch was all or nothing",
+                    "type": "integer"
+                },
+                "succeeded": {
+                    "description": "Succeeded represents how many users were written",
+                    "type": "integer"
+                }
+            }
+        },
+        "usecases.BeginWebAuthnRegistrationRequestBody": {
+            "description": "The user's current password",
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "description": "Password represents the user's current password",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.ChangePasswordRequestBody": {
+            "description": "The user's current password and the new password",
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "description": "CurrentPassword represents the user's existing password",
+                    "type": "string"
+                },
+                "new_password": {
+                    "description": "NewPassword represents the password to set",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.ConsentRequestBody": {
+            "description": "Whether the user allows the client the scopes it asked for",
+            "type": "object",
+            "properties": {
+                "approve": {
+                    "description": "Approve is true if the user allows the client access",
+                    "type": "boolean"
+                }
+            }
+        },
+        "usecases.ConsentRequiredResponseBody": {
+            "description": "The client and scopes to ask the user to consent to",
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "ClientID represents the client asking for access",
+                    "type": "string"
+                },
+                "client_name": {
+                    "description": "ClientName represents the name of the client to show the user",
+                    "type": "string"
+                },
+                "consent_required": {
+                    "description": "ConsentRequired is always true, the user's decision is submitted to /authorize/consent",
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "description": "Scopes represents the scopes the client is asking for",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.CreateAPIKeyRequestBody": {
+            "description": "The API key to mint",
+            "type": "object",
+            "required": [
+                "name",
+                "owner",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt represents when the key stops working, it never expires if empty",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name represents what the key is used for",
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner represents the service or team responsible for the key",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes represents what the key can do, users:write includes users:read and users:admin includes both",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.CreateOIDCClientRequestBody": {
+            "description": "The client to register",
+            "type": "object",
+            "required": [
+                "name",
+                "redirect_uris"
+            ],
+            "properties": {
+                "allowed_scopes": {
+                    "description": "AllowedScopes represents the scopes the client can ask for, all supported scopes if empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "confidential": {
+                    "description": "Confidential is true for clients that can keep a secret, such as server-side web apps",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "Name represents the name shown to users when asking for consent",
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "description": "RedirectURIs represents the URIs the client can be redirected to, they must be matched exactly",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.CreateUserRequestBody": {
+            "description": "Request body for creating a new user",
+            "type": "object",
+            "required": [
+                "country",
+                "email",
+                "first_name",
+                "last_name",
+                "nickname",
+                "password"
+            ],
+            "properties": {
+                "country": {
+                    "description": "Country represents the user's country",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email represents the user's email address",
+                    "type": "string"
+                },
+                "first_name": {
+                    "description": "FirstName represents the user's first name",
+                    "type": "string"
+                },
+                "last_name": {
+                    "description": "LastName represents the user's last name",
+                    "type": "string"
+                },
+                "nickname": {
+                    "description": "Nickname represents the user's nickname",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password represents the user's password",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.CreateUserResponseBody": {
+            "description": "Response body for a created user",
+            "type": "object",
+            "properties": {
+                "country": {
+                    "description": "Country represents the user's country",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "CreatedAt represents the timestamp when the user was created",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email represents the user's email address",
+                    "type": "string"
+                },
+                "first_name": {
+                    "description": "FirstName represents the user's first name",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID represents the user's unique identifier",
+                    "type": "string"
+                },
+                "last_name": {
+                    "description": "LastName represents the user's last name",
+                    "type": "string"
+                },
+                "nickname": {
+                    "description": "Nickname represents the user's nickname",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password represents the user's password",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status represents the user's account status",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt represents the timestamp when the user was last updated",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.EmailChangeTokenRequestBody": {
+            "description": "The token emailed to the user",
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Token represents the signed token from the email",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.EnrolTOTPRequestBody": {
+            "description": "The user's current password",
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "description": "Password represents the user's current password",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.EnrolTOTPResponseBody": {
+            "description": "The secret to add to an authenticator app",
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "description": "OTPAuthURI represents the otpauth URI, for rendering as a QR code",
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Secret represents the base32 encoded TOTP secret, for entering manually",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.FinishWebAuthnLoginRequestBody": {
+            "description": "The authenticator's response to the login options",
+            "type": "object",
+            "required": [
+                "ceremony_id",
+                "credential"
+            ],
+            "properties": {
+                "ceremony_id": {
+                    "description": "CeremonyID represents the ceremony returned when the login began",
+                    "type": "string"
+                },
+                "credential": {
+                    "description": "Credential represents the PublicKeyCredential returned by navigator.credentials.get()",
+                    "type": "object"
+                },
+                "device_name": {
+                    "description": "DeviceName represents a name for the device logging in, shown when the user lists their sessions",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.FinishWebAuthnRegistrationRequestBody": {
+            "description": "The authenticator's response to the registration options",
+            "type": "object",
+            "required": [
+                "ceremony_id",
+                "credential",
+                "name"
+            ],
+            "properties": {
+                "ceremony_id": {
+                    "description": "CeremonyID represents the ceremony returned when registration began",
+                    "type": "string"
+                },
+                "credential": {
+                    "description": "Credential represents the PublicKeyCredential returned by navigator.credentials.create()",
+                    "type": "object"
+                },
+                "name": {
+                    "description": "Name represents a label for the credential, so the user can tell their credentials apart",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.ForgotPasswordRequestBody": {
+            "description": "The email address of the account to reset",
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email represents the user's email address",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.GetAuditLogResponseBody": {
+            "description": "Audit log entries matching the query",
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "description": "Entries represents the matching entries in sequence order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.AuditEntryResponse"
+                    }
+                },
+                "next_after": {
+                    "description": "NextAfter represents the value of after to get the next page with, 0 if this is the last page",
+                    "type": "integer"
+                }
+            }
+        },
+        "usecases.GetUsersRequestBody": {
+            "description": "Optional search criteria for getting users",
+            "type": "object",
+            "properties": {
+                "country": {
+                    "description": "Country represents the user's country",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email represents the user's email address",
+                    "type": "string"
+                },
+                "first_name": {
+                    "description": "FirstName represents the user's first name",
+                    "type": "string"
+                },
+                "last_name": {
+                    "description": "LastName represents the user's last name",
+                    "type": "string"
+                },
+                "nickname": {
+                    "description": "Nickname represents the user's nickname",
+                    "type": "string"
+                },
+                "page_info": {
+                    "description": "PageInfo represents the pagination information for the request",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/usecases.PageInfo"
+                        }
+                    ]
+                }
+            }
+        },
+        "usecases.GetUsersResponseBody": {
+            "description": "List of users matching search criteria and pagination info",
+            "type": "object",
+            "properties": {
+                "page_info": {
+                    "description": "PageInfo represents the pagination information for the request",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/usecases.PageInfo"
+                        }
+                    ]
+                },
+                "users": {
+                    "description": "Users represents the users matching the search criteria",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.UserResponse"
+                    }
+                }
+            }
+        },
+        "usecases.ImportJobResponseBody": {
+            "description": "The import's progress and a page of its row results",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt represents when the import was started",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "description": "DryRun represents whether the import is only being validated",
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Error represents why a FAILED import stopped, rows after the last result weren't imported",
+                    "type": "string"
+                },
+                "failed_rows": {
+                    "description": "FailedRows represents the number of rows so far that weren't imported",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "description": "FinishedAt represents when the import finished, if it has",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "Format represents the format of the file being imported",
+                    "type": "string"
+                },
+                "imported_rows": {
+                    "description": "ImportedRows represents the number of rows so far that created a user, or would have in a dry run",
+                    "type": "integer"
+                },
+                "job_id": {
+                    "description": "JobID represents the import job's unique identifier",
+                    "type": "string"
+                },
+                "next_after": {
+                    "description": "NextAfter represents the value of after to get the next page with, 0 if there are no more results yet",
+                    "type": "integer"
+                },
+                "results": {
+                    "description": "Results represents a page of row results in row order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.ImportRowResultResponse"
+                    }
+                },
+                "status": {
+                    "description": "Status represents whether the import is RUNNING, SUCCEEDED or FAILED",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.ImportRowResultResponse": {
+            "description": "The user a row created, or why it wasn't imported",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error represents why the row wasn't imported",
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row represents the row's position in the file, the first user is row 1 whether or not there is a header row",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "description": "UserID represents the user the row created, it is empty for rows that failed and in dry runs",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.ImportUsersResponseBody": {
+            "description": "The result of every row in the file",
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "description": "DryRun represents whether the import was only validated, in which case no users were created",
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Error represents why the import stopped early, rows after the last result weren't imported",
+                    "type": "string"
+                },
+                "failed_rows": {
+                    "description": "FailedRows represents the number of rows that weren't imported",
+                    "type": "integer"
+                },
+                "imported_rows": {
+                    "description": "ImportedRows represents the number of rows that created a user, or would have in a dry run",
+                    "type": "integer"
+                },
+                "results": {
+                    "description": "Results represents the result of each row in row order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.ImportRowResultResponse"
+                    }
+                }
+            }
+        },
+        "usecases.JSONWebKeySetResponseBody": {
+            "description": "The public keys ID tokens are signed with",
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "description": "Keys represents the public keys, identified by their kid",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
+        "usecases.ListAPIKeysResponseBody": {
+            "description": "The API keys that have been minted",
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "description": "APIKeys represents every key, including revoked and expired ones",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.APIKeyResponse"
+                    }
+                }
+            }
+        },
+        "usecases.ListSessionsResponseBody": {
+            "description": "The devices the user is logged in on",
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "description": "Sessions represents the user's sessions that haven't expired or been revoked",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.SessionResponse"
+                    }
+                }
+            }
+        },
+        "usecases.ListWebAuthnCredentialsResponseBody": {
+            "description": "The passkeys and security keys registered to the user",
+            "type": "object",
+            "properties": {
+                "credentials": {
+                    "description": "Credentials represents the user's registered credentials",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usecases.WebAuthnCredentialResponse"
+                    }
+                }
+            }
+        },
+        "usecases.LoginMFARequestBody": {
+            "description": "The challenge from /auth/login and a second factor",
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_challenge"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code represents a code from the user's authenticator, or one of their recovery codes",
+                    "type": "string"
+                },
+                "device_name": {
+                    "description": "DeviceName represents a name for the device logging in, shown when the user lists their sessions",
+                    "type": "string"
+                },
+                "mfa_challenge": {
+                    "description": "MFAChallenge represents the challenge returned when logging in",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.LoginRequestBody": {
+            "description": "The user's credentials",
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "device_name": {
+                    "description": "DeviceName represents a name for the device logging in, shown when the user lists their sessions",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email represents the user's email address",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password represents the user's password",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.LoginResponseBody": {
+            "description": "Either the tokens for a new session, or an MFA challenge to complete first",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "description": "AccessToken represents the bearer token for the session",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "ExpiresIn represents the number of seconds the access token is valid for",
+                    "type": "integer"
+                },
+                "mfa_challenge": {
+                    "description": "MFAChallenge represents the challenge to submit along with a second factor",
+                    "type": "string"
+                },
+                "mfa_required": {
+                    "description": "MFARequired is true when the challenge must be completed with a second factor before tokens are issued",
+                    "type": "boolean"
+                },
+                "refresh_token": {
+                    "description": "RefreshToken represents the token identifying the session",
+                    "type": "string"
+                },
+                "token_type": {
+                    "description": "TokenType represents how the access token should be presented",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.MFACodeRequestBody": {
+            "description": "A code from the user's authenticator, or one of their recovery codes",
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code represents a code from the user's authenticator, or one of their recovery codes",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.OAuthErrorResponseBody": {
+            "description": "An OAuth 2.0 error",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error represents the OAuth error code",
+                    "type": "string"
+                },
+                "error_description": {
+                    "description": "ErrorDescription represents a human readable explanation of the error",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.OIDCClientResponse": {
+            "description": "A client registered to sign users in",
+            "type": "object",
+            "properties": {
+                "allowed_scopes": {
+                    "description": "AllowedScopes represents the scopes the client can ask for",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "client_id": {
+                    "description": "ClientID represents the client's identifier",
+                    "type": "string"
+                },
+                "client_secret": {
+                    "description": "ClientSecret represents the client's secret, it is only returned when the client is registered",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "CreatedAt represents the timestamp when the client was registered",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name represents the name shown to users when asking for consent",
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "description": "RedirectURIs represents the URIs the client can be redirected to",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.OpenIDConfigurationResponseBody": {
+            "description": "Where the provider's endpoints are and what it supports",
             "type": "object",
-            "required": [
-                "country",
-                "email",
-                "first_name",
-                "last_name",
-                "nickname",
-                "password"
-            ],
             "properties": {
-                "country": {
-                    "description": "Country represents the user's country",
+                "authorization_endpoint": {
+                    "description": "AuthorizationEndpoint represents the URL of the authorize endpoint",
                     "type": "string"
                 },
-                "email": {
-                    "description": "Email represents the user's email address",
+                "claims_supported": {
+                    "description": "ClaimsSupported represents the claims that can be released about a user",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code_challenge_methods_supported": {
+                    "description": "CodeChallengeMethodsSupported represents the supported PKCE code challenge methods",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "grant_types_supported": {
+                    "description": "GrantTypesSupported represents the supported grant types",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id_token_signing_alg_values_supported": {
+                    "description": "IDTokenSigningAlgValuesSupported represents the algorithms ID tokens are signed with",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "issuer": {
+                    "description": "Issuer represents the issuer identifier, it is the iss claim of every ID token",
                     "type": "string"
                 },
-                "first_name": {
-                    "description": "FirstName represents the user's first name",
+                "jwks_uri": {
+                    "description": "JWKSURI represents the URL of the keys ID tokens are signed with",
                     "type": "string"
                 },
-                "last_name": {
-                    "description": "LastName represents the user's last name",
+                "response_types_supported": {
+                    "description": "ResponseTypesSupported represents the supported response types",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes_supported": {
+                    "description": "ScopesSupported represents the scopes clients can ask for",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subject_types_supported": {
+                    "description": "SubjectTypesSupported represents the supported subject identifier types",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint": {
+                    "description": "TokenEndpoint represents the URL of the token endpoint",
                     "type": "string"
                 },
-                "nickname": {
-                    "description": "Nickname represents the user's nickname",
+                "token_endpoint_auth_methods_supported": {
+                    "description": "TokenEndpointAuthMethodsSupported represents how clients can authenticate at the token endpoint",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userinfo_endpoint": {
+                    "description": "UserInfoEndpoint represents the URL of the userinfo endpoint",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.PageInfo": {
+            "description": "Provides page size and the token used to get the next page of users",
+            "type": "object",
+            "properties": {
+                "next_page_token": {
+                    "description": "NextPageToken represents the token used to get the next page of results",
                     "type": "string"
                 },
-                "password": {
-                    "description": "Password represents the user's password",
+                "page_size": {
+                    "description": "PageSize represents the number of results per page, default is 10",
+                    "type": "integer"
+                }
+            }
+        },
+        "usecases.PasswordRejectedResponseBody": {
+            "description": "The rules the password broke",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error represents the error code, always password_rejected",
                     "type": "string"
+                },
+                "violations": {
+                    "description": "Violations represents a code for each rule the password broke",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "usecases.CreateUserResponseBody": {
-            "description": "Response body for a created user",
+        "usecases.PublicUserResponse": {
+            "description": "Information of an individual user, without their password",
             "type": "object",
             "properties": {
+                "ban_expires_at": {
+                    "description": "BanExpiresAt represents the timestamp when the user's ban is lifted, a banned user with no expiry is banned permanently",
+                    "type": "string"
+                },
+                "ban_reason": {
+                    "description": "BanReason represents the reason given when the user was banned",
+                    "type": "string"
+                },
                 "country": {
                     "description": "Country represents the user's country",
                     "type": "string"
@@ -231,6 +3537,10 @@ const docTemplate = `{
                     "description": "Email represents the user's email address",
                     "type": "string"
                 },
+                "email_verified_at": {
+                    "description": "EmailVerifiedAt represents the timestamp when the user verified their email address",
+                    "type": "string"
+                },
                 "first_name": {
                     "description": "FirstName represents the user's first name",
                     "type": "string"
@@ -247,8 +3557,12 @@ const docTemplate = `{
                     "description": "Nickname represents the user's nickname",
                     "type": "string"
                 },
-                "password": {
-                    "description": "Password represents the user's password",
+                "pending_email": {
+                    "description": "PendingEmail represents an email address awaiting confirmation before it replaces Email",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status represents the user's account status",
                     "type": "string"
                 },
                 "updated_at": {
@@ -257,72 +3571,124 @@ const docTemplate = `{
                 }
             }
         },
-        "usecases.GetUsersRequestBody": {
-            "description": "Optional search criteria for getting users",
+        "usecases.RecoveryCodesResponseBody": {
+            "description": "One-time recovery codes. They are only shown once.",
             "type": "object",
             "properties": {
-                "country": {
-                    "description": "Country represents the user's country",
-                    "type": "string"
-                },
-                "email": {
-                    "description": "Email represents the user's email address",
-                    "type": "string"
-                },
-                "first_name": {
-                    "description": "FirstName represents the user's first name",
+                "recovery_codes": {
+                    "description": "RecoveryCodes represents the codes that can each be used once in place of an authenticator code",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.RefreshSessionRequestBody": {
+            "description": "The session's current refresh token",
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "RefreshToken represents the token returned when the session was started or last refreshed",
                     "type": "string"
-                },
-                "last_name": {
-                    "description": "LastName represents the user's last name",
+                }
+            }
+        },
+        "usecases.ResetPasswordRequestBody": {
+            "description": "The emailed reset token and the new password",
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "description": "NewPassword represents the password to set",
                     "type": "string"
                 },
-                "nickname": {
-                    "description": "Nickname represents the user's nickname",
+                "token": {
+                    "description": "Token represents the password reset token from the email",
                     "type": "string"
-                },
-                "page_info": {
-                    "description": "PageInfo represents the pagination information for the request",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/usecases.PageInfo"
-                        }
-                    ]
                 }
             }
         },
-        "usecases.GetUsersResponseBody": {
-            "description": "List of users matching search criteria and pagination info",
+        "usecases.SearchUsersResponseBody": {
+            "description": "The users matching the search, best match first",
             "type": "object",
             "properties": {
-                "page_info": {
-                    "description": "PageInfo represents the pagination information for the request",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/usecases.PageInfo"
-                        }
-                    ]
-                },
-                "users": {
-                    "description": "Users represents the users matching the search criteria",
+                "results": {
+                    "description": "Results represents the matching users, best match first",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/usecases.UserResponse"
+                        "$ref": "#/definitions/usecases.UserSearchResultResponse"
                     }
                 }
             }
         },
-        "usecases.PageInfo": {
-            "description": "Provides page size and the token used to get the next page of users",
+        "usecases.SessionResponse": {
+            "description": "A device the user is logged in on",
             "type": "object",
             "properties": {
-                "next_page_token": {
-                    "description": "NextPageToken represents the token used to get the next page of results",
+                "created_at": {
+                    "description": "CreatedAt represents when the user logged in",
                     "type": "string"
                 },
-                "page_size": {
-                    "description": "PageSize represents the number of results per page, default is 10",
+                "device_name": {
+                    "description": "DeviceName represents the name given to the device when logging in, or the client for OpenID Connect sessions",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt represents when the session ends if it isn't revoked first",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID represents the session's identifier",
+                    "type": "string"
+                },
+                "ip_address": {
+                    "description": "IPAddress represents the IP address the session was last used from",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "LastUsedAt represents when the session was last refreshed, if it has been",
+                    "type": "string"
+                },
+                "user_agent": {
+                    "description": "UserAgent represents the user agent the session was last used from",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.TokenResponseBody": {
+            "description": "The tokens for a session started by an OpenID Connect client",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "description": "AccessToken represents the bearer token for the session, it can be used at the userinfo endpoint",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "ExpiresIn represents the number of seconds the access token is valid for",
                     "type": "integer"
+                },
+                "id_token": {
+                    "description": "IDToken represents the signed JWT describing the user",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "RefreshToken represents the token identifying the session",
+                    "type": "string"
+                },
+                "scope": {
+                    "description": "Scope represents the space separated scopes granted",
+                    "type": "string"
+                },
+                "token_type": {
+                    "description": "TokenType represents how the access token should be presented",
+                    "type": "string"
                 }
             }
         },
@@ -400,6 +3766,14 @@ const docTemplate = `{
                     "description": "Password represents the user's password",
                     "type": "string"
                 },
+                "pending_email": {
+                    "description": "PendingEmail represents an email address awaiting confirmation before it replaces Email",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status represents the user's account status",
+                    "type": "string"
+                },
                 "updated_at": {
                     "description": "UpdatedAt represents the timestamp when the user was last updated",
                     "type": "string"
@@ -410,6 +3784,14 @@ const docTemplate = `{
             "description": "Information of an individual user in the list of users",
             "type": "object",
             "properties": {
+                "ban_expires_at": {
+                    "description": "BanExpiresAt represents the timestamp when the user's ban is lifted, a banned user with no expiry is banned permanently",
+                    "type": "string"
+                },
+                "ban_reason": {
+                    "description": "BanReason represents the reason given when the user was banned",
+                    "type": "string"
+                },
                 "country": {
                     "description": "Country represents the user's country",
                     "type": "string"
@@ -422,6 +3804,10 @@ const docTemplate = `{
                     "description": "Email represents the user's email address",
                     "type": "string"
                 },
+                "email_verified_at": {
+                    "description": "EmailVerifiedAt represents the timestamp when the user verified their email address",
+                    "type": "string"
+                },
                 "first_name": {
                     "description": "FirstName represents the user's first name",
                     "type": "string"
@@ -442,11 +3828,112 @@ const docTemplate = `{
                     "description": "Password represents the user's password",
                     "type": "string"
                 },
+                "pending_email": {
+                    "description": "PendingEmail represents an email address awaiting confirmation before it replaces Email",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status represents the user's account status",
+                    "type": "string"
+                },
                 "updated_at": {
                     "description": "UpdatedAt represents the timestamp when the user was last updated",
                     "type": "string"
                 }
             }
+        },
+        "usecases.UserSearchResultResponse": {
+            "description": "A user matching a search and where it matched",
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "description": "Highlights represents each field that matched, HTML escaped with the matching parts wrapped in \u003cmark\u003e tags",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "Score represents how well the user matched, between 0 and 1 where 1 is an exact match",
+                    "type": "number"
+                },
+                "user": {
+                    "description": "User represents the matching user",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/usecases.PublicUserResponse"
+                        }
+                    ]
+                }
+            }
+        },
+        "usecases.VerifyEmailRequestBody": {
+            "description": "The token sent to the user's email address",
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Token represents the signed verification token from the email",
+                    "type": "string"
+                }
+            }
+        },
+        "usecases.WebAuthnCredentialResponse": {
+            "description": "A passkey or security key registered to the user",
+            "type": "object",
+            "properties": {
+                "backup_eligible": {
+                    "description": "BackupEligible is true for credentials that can be synced between devices",
+                    "type": "boolean"
+                },
+                "backup_state": {
+                    "description": "BackupState is true for credentials that have been synced between devices",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "description": "CreatedAt represents the timestamp when the credential was registered",
+                    "type": "string"
+                },
+                "credential_id": {
+                    "description": "CredentialID represents the authenticator's identifier for the credential, base64url encoded",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID represents the credential's unique identifier",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "LastUsedAt represents the timestamp when the credential was last used to log in",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name represents the label the user gave the credential",
+                    "type": "string"
+                },
+                "transports": {
+                    "description": "Transports represents how the browser can reach the authenticator",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "usecases.WebAuthnOptionsResponseBody": {
+            "description": "The ceremony to finish and the options to pass to the authenticator",
+            "type": "object",
+            "properties": {
+                "ceremony_id": {
+                    "description": "CeremonyID represents the ceremony to submit the authenticator's response to",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "Options represents the options to pass to navigator.credentials.create() or navigator.credentials.get()",
+                    "type": "object"
+                }
+            }
         }
     }
 }`
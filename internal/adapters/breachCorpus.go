@@ -0,0 +1,79 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hashPrefixPattern = regexp.MustCompile(`^[0-9A-F]{5}$`)
+
+var _ usecases.BreachCorpus = &RangeFileBreachCorpus{}
+
+// RangeFileBreachCorpus reads breached password hashes from a directory of Have I Been Pwned range files, as written by
+// the PwnedPasswordsDownloader. There is one file per 5 character hash prefix, named <PREFIX>.txt, and each line of it
+// is the rest of a hash and how many times it has been seen, e.g. "0018A45C4D1DEF81644B54AB7F969B88D65:10".
+type RangeFileBreachCorpus struct {
+	dir string
+}
+
+func NewRangeFileBreachCorpus(dir string) *RangeFileBreachCorpus {
+	return &RangeFileBreachCorpus{dir: dir}
+}
+
+// GetBreachedSuffixes reads the prefix's range file. A missing file is treated as an empty range, so a partial corpus
+// can be used for testing.
+func (corpus *RangeFileBreachCorpus) GetBreachedSuffixes(_ context.Context, prefix string) (map[string]int, error) {
+	if !hashPrefixPattern.MatchString(prefix) {
+		return nil, fmt.Errorf("invalid hash prefix %q", prefix)
+	}
+
+	file, err := os.Open(filepath.Join(corpus.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.Debug("no range file for hash prefix", "prefix", prefix)
+			return map[string]int{}, nil
+		}
+		slog.Debug("error opening range file", "err", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	suffixes := map[string]int{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		suffix, countText, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("malformed line in range file %s: %q", prefix, line)
+		}
+
+		count, err := strconv.Atoi(countText)
+		if err != nil {
+			return nil, fmt.Errorf("malformed count in range file %s: %w", prefix, err)
+		}
+
+		suffixes[strings.ToUpper(suffix)] = count
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		slog.Debug("error reading range file", "err", err)
+		return nil, err
+	}
+
+	return suffixes, nil
+}
@@ -0,0 +1,61 @@
+package adapters_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRangeFileBreachCorpus_GetBreachedSuffixes(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(dir, "5BAA6.txt"),
+		[]byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:10437277\r\n"),
+		0o600,
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	corpus := adapters.NewRangeFileBreachCorpus(dir)
+	suffixes, err := corpus.GetBreachedSuffixes(context.Background(), "5BAA6")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(suffixes).To(Equal(map[string]int{
+		"003D68EB55068C33ACE09247EE4C639306B": 3,
+		"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 10437277,
+	}))
+}
+
+func TestRangeFileBreachCorpus_GetBreachedSuffixes_MissingRangeFile(t *testing.T) {
+	g := NewWithT(t)
+
+	corpus := adapters.NewRangeFileBreachCorpus(t.TempDir())
+	suffixes, err := corpus.GetBreachedSuffixes(context.Background(), "5BAA6")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(suffixes).To(BeEmpty())
+}
+
+func TestRangeFileBreachCorpus_GetBreachedSuffixes_MalformedRangeFile(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte("003D68EB55068C33ACE09247EE4C639306B\n"), 0o600)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	corpus := adapters.NewRangeFileBreachCorpus(dir)
+	suffixes, err := corpus.GetBreachedSuffixes(context.Background(), "5BAA6")
+	g.Expect(err).To(MatchError(ContainSubstring("malformed line")))
+	g.Expect(suffixes).To(BeNil())
+}
+
+func TestRangeFileBreachCorpus_GetBreachedSuffixes_InvalidPrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	corpus := adapters.NewRangeFileBreachCorpus(t.TempDir())
+	suffixes, err := corpus.GetBreachedSuffixes(context.Background(), "../..")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid hash prefix")))
+	g.Expect(suffixes).To(BeNil())
+}
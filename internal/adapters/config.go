@@ -1,12 +1,133 @@
 package adapters
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
+	"time"
 )
 
 type Config struct {
-	PostgresConnectionURI string `yaml:"postgres-connection-uri" env:"POSTGRES_CONNECTION_URI" env-required:"true"`
-	KafkaHost             string `yaml:"kafka-host" env:"KAFKA_HOST" env-required:"true"`
+	// StorageBackend is either "postgres", "sqlite" or "memory". Memory stores everything in memory, for running the
+	// whole service without a database. SQLite only stores users, for running the core user endpoints on a single node.
+	StorageBackend string `yaml:"storage-backend" env:"STORAGE_BACKEND" env-default:"postgres"`
+	// PostgresConnectionURI is required when StorageBackend is "postgres", as are TokenSigningKey, MFAEncryptionKey,
+	// SigningKeyEncryptionKey, PIIMasterKeys and PIIBlindIndexKey. The other backends generate the keys they aren't
+	// given each time they start, as nothing signed or encrypted with them is kept.
+	PostgresConnectionURI string `yaml:"postgres-connection-uri" env:"POSTGRES_CONNECTION_URI"`
+	// AutoMigrate runs the migrations on startup. It can be turned off where they are run with user-service migrate up
+	// before a deploy, so replicas don't need permission to change the schema.
+	AutoMigrate bool `yaml:"auto-migrate" env:"AUTO_MIGRATE" env-default:"true"`
+	// Port is the port the API is served on
+	Port string `yaml:"port" env:"PORT" env-default:"8080"`
+	// ShutdownTimeout is how long the service waits, once told to stop, for requests and import jobs to finish
+	ShutdownTimeout time.Duration `yaml:"shutdown-timeout" env:"SHUTDOWN_TIMEOUT" env-default:"30s"`
+	// SQLitePath is the database file users are stored in when StorageBackend is "sqlite"
+	SQLitePath string `yaml:"sqlite-path" env:"SQLITE_PATH" env-default:"./user-service.db"`
+	// EventPublisher is either "kafka" or "log", the log publisher writes changelog entries and security events to
+	// the log instead, for running without Kafka. KafkaHost is required for "kafka" and for UserCommandsEnabled.
+	EventPublisher         string        `yaml:"event-publisher" env:"EVENT_PUBLISHER" env-default:"kafka"`
+	KafkaHost              string        `yaml:"kafka-host" env:"KAFKA_HOST"`
+	BanExpiryCheckInterval time.Duration `yaml:"ban-expiry-check-interval" env:"BAN_EXPIRY_CHECK_INTERVAL" env-default:"1m"`
+	TokenSigningKey        string        `yaml:"token-signing-key" env:"TOKEN_SIGNING_KEY"`
+	// EmailVerificationURL is included in verification emails as the place to submit the token
+	EmailVerificationURL      string        `yaml:"email-verification-url" env:"EMAIL_VERIFICATION_URL" env-default:"http://localhost:8080/verify-email"`
+	EmailVerificationTokenTTL time.Duration `yaml:"email-verification-token-ttl" env:"EMAIL_VERIFICATION_TOKEN_TTL" env-default:"24h"`
+	// EmailChangeConfirmURL is included in emails sent to a new address as the place to confirm the change
+	EmailChangeConfirmURL string        `yaml:"email-change-confirm-url" env:"EMAIL_CHANGE_CONFIRM_URL" env-default:"http://localhost:8080/email-change/confirm"`
+	EmailChangeTokenTTL   time.Duration `yaml:"email-change-token-ttl" env:"EMAIL_CHANGE_TOKEN_TTL" env-default:"24h"`
+	// EmailChangeRevertURL is included in notices sent to the previous address as the place to undo the change
+	EmailChangeRevertURL      string        `yaml:"email-change-revert-url" env:"EMAIL_CHANGE_REVERT_URL" env-default:"http://localhost:8080/email-change/revert"`
+	EmailChangeRevertTokenTTL time.Duration `yaml:"email-change-revert-token-ttl" env:"EMAIL_CHANGE_REVERT_TOKEN_TTL" env-default:"168h"`
+	// PasswordResetURL is included in password reset emails as the place to submit the token
+	PasswordResetURL      string        `yaml:"password-reset-url" env:"PASSWORD_RESET_URL" env-default:"http://localhost:8080/auth/password/reset"`
+	PasswordResetTokenTTL time.Duration `yaml:"password-reset-token-ttl" env:"PASSWORD_RESET_TOKEN_TTL" env-default:"1h"`
+	// MFAEncryptionKey encrypts TOTP secrets at rest
+	MFAEncryptionKey string `yaml:"mfa-encryption-key" env:"MFA_ENCRYPTION_KEY"`
+	// MFAIssuer is the account issuer shown in authenticator apps
+	MFAIssuer       string        `yaml:"mfa-issuer" env:"MFA_ISSUER" env-default:"FACEIT"`
+	MFAChallengeTTL time.Duration `yaml:"mfa-challenge-ttl" env:"MFA_CHALLENGE_TTL" env-default:"5m"`
+	AccessTokenTTL  time.Duration `yaml:"access-token-ttl" env:"ACCESS_TOKEN_TTL" env-default:"15m"`
+	SessionTTL      time.Duration `yaml:"session-ttl" env:"SESSION_TTL" env-default:"720h"`
+	// WebAuthnRPID is the domain passkeys are scoped to, WebAuthnRPOrigins the origins allowed to use them
+	WebAuthnRPID          string        `yaml:"webauthn-rp-id" env:"WEBAUTHN_RP_ID" env-default:"localhost"`
+	WebAuthnRPDisplayName string        `yaml:"webauthn-rp-display-name" env:"WEBAUTHN_RP_DISPLAY_NAME" env-default:"FACEIT"`
+	WebAuthnRPOrigins     []string      `yaml:"webauthn-rp-origins" env:"WEBAUTHN_RP_ORIGINS" env-separator:"," env-default:"http://localhost:8080"`
+	WebAuthnCeremonyTTL   time.Duration `yaml:"webauthn-ceremony-ttl" env:"WEBAUTHN_CEREMONY_TTL" env-default:"5m"`
+	// OIDCIssuer is the URL the service is reached at, it is the iss claim of ID tokens
+	OIDCIssuer               string        `yaml:"oidc-issuer" env:"OIDC_ISSUER" env-default:"http://localhost:8080"`
+	OIDCAuthorizationCodeTTL time.Duration `yaml:"oidc-authorization-code-ttl" env:"OIDC_AUTHORIZATION_CODE_TTL" env-default:"1m"`
+	OIDCIDTokenTTL           time.Duration `yaml:"oidc-id-token-ttl" env:"OIDC_ID_TOKEN_TTL" env-default:"1h"`
+	// SigningKeyEncryptionKey is the master key ID token signing keys are encrypted with at rest
+	SigningKeyEncryptionKey    string        `yaml:"signing-key-encryption-key" env:"SIGNING_KEY_ENCRYPTION_KEY"`
+	SigningKeyRotationInterval time.Duration `yaml:"signing-key-rotation-interval" env:"SIGNING_KEY_ROTATION_INTERVAL" env-default:"720h"`
+	// SigningKeyRetention is how long retired keys stay published, it should be longer than OIDCIDTokenTTL
+	SigningKeyRetention     time.Duration `yaml:"signing-key-retention" env:"SIGNING_KEY_RETENTION" env-default:"48h"`
+	SigningKeyCheckInterval time.Duration `yaml:"signing-key-check-interval" env:"SIGNING_KEY_CHECK_INTERVAL" env-default:"1m"`
+	// LoginLimiter is either "postgres" or "memory", the memory limiter should only be used with a single instance
+	LoginLimiter string `yaml:"login-limiter" env:"LOGIN_LIMITER" env-default:"postgres"`
+	// LoginFailureWindow is how long failed logins are counted for after the most recent one
+	LoginFailureWindow    time.Duration `yaml:"login-failure-window" env:"LOGIN_FAILURE_WINDOW" env-default:"1h"`
+	LoginBackoffBaseDelay time.Duration `yaml:"login-backoff-base-delay" env:"LOGIN_BACKOFF_BASE_DELAY" env-default:"1s"`
+	LoginBackoffMaxDelay  time.Duration `yaml:"login-backoff-max-delay" env:"LOGIN_BACKOFF_MAX_DELAY" env-default:"5m"`
+	// AccountLoginFreeFailures is how many failures an account gets before backing off, AccountLockoutFailures how
+	// many lock it for AccountLockoutDuration
+	AccountLoginFreeFailures int           `yaml:"account-login-free-failures" env:"ACCOUNT_LOGIN_FREE_FAILURES" env-default:"3"`
+	AccountLockoutFailures   int           `yaml:"account-lockout-failures" env:"ACCOUNT_LOCKOUT_FAILURES" env-default:"10"`
+	AccountLockoutDuration   time.Duration `yaml:"account-lockout-duration" env:"ACCOUNT_LOCKOUT_DURATION" env-default:"30m"`
+	// IPLoginFreeFailures is how many failures an IP address gets before backing off, IP addresses are never locked
+	IPLoginFreeFailures int `yaml:"ip-login-free-failures" env:"IP_LOGIN_FREE_FAILURES" env-default:"20"`
+	// PasswordMinLength and PasswordMaxLength are counted in characters, PasswordMinEntropyBits is the lowest estimated
+	// strength accepted
+	PasswordMinLength      int     `yaml:"password-min-length" env:"PASSWORD_MIN_LENGTH" env-default:"10"`
+	PasswordMaxLength      int     `yaml:"password-max-length" env:"PASSWORD_MAX_LENGTH" env-default:"128"`
+	PasswordMinEntropyBits float64 `yaml:"password-min-entropy-bits" env:"PASSWORD_MIN_ENTROPY_BITS" env-default:"40"`
+	// BreachedPasswordsDir is a directory of Have I Been Pwned range files, passwords aren't checked for breaches if
+	// it isn't set
+	BreachedPasswordsDir string `yaml:"breached-passwords-dir" env:"BREACHED_PASSWORDS_DIR"`
+	// APIKeysRequired rejects requests to the user and admin endpoints without an API key, it should only be turned off
+	// where callers are authenticated in front of the service
+	APIKeysRequired bool `yaml:"api-keys-required" env:"API_KEYS_REQUIRED" env-default:"true"`
+	// BootstrapAdminAPIKey is a users:admin API key for minting the first keys, it should be unset once they have been
+	BootstrapAdminAPIKey string `yaml:"bootstrap-admin-api-key" env:"BOOTSTRAP_ADMIN_API_KEY"`
+	// PIIMasterKeys wrap the data keys users' names and emails are encrypted with, given as "<id>:<key>". The first
+	// wraps new data keys, previous master keys should be kept after it until PII maintenance has rewrapped their
+	// data keys.
+	PIIMasterKeys []string `yaml:"pii-master-keys" env:"PII_MASTER_KEYS" env-separator:","`
+	// PIIBlindIndexKey is the HMAC key for looking up encrypted emails and names, changing it breaks lookups of
+	// existing users
+	PIIBlindIndexKey        string        `yaml:"pii-blind-index-key" env:"PII_BLIND_INDEX_KEY"`
+	PIIMaintenanceInterval  time.Duration `yaml:"pii-maintenance-interval" env:"PII_MAINTENANCE_INTERVAL" env-default:"1h"`
+	PIIMaintenanceBatchSize int           `yaml:"pii-maintenance-batch-size" env:"PII_MAINTENANCE_BATCH_SIZE" env-default:"500"`
+	// ImportBatchSize is how many users an import inserts at a time, each batch publishes one changelog entry
+	ImportBatchSize int `yaml:"import-batch-size" env:"IMPORT_BATCH_SIZE" env-default:"1000"`
+	// ImportSpoolDir is where files imported in the background are kept until they are done, the default temporary
+	// directory is used if it isn't set
+	ImportSpoolDir string `yaml:"import-spool-dir" env:"IMPORT_SPOOL_DIR"`
+	// BatchMaxUsers is the most users a single batch request can name
+	BatchMaxUsers int `yaml:"batch-max-users" env:"BATCH_MAX_USERS" env-default:"500"`
+	// UnverifiedAccountPolicy is either "allow" or "restrict", restricted accounts can't update their details once
+	// UnverifiedAccountGracePeriod has passed without verifying their email
+	UnverifiedAccountPolicy      string        `yaml:"unverified-account-policy" env:"UNVERIFIED_ACCOUNT_POLICY" env-default:"allow"`
+	UnverifiedAccountGracePeriod time.Duration `yaml:"unverified-account-grace-period" env:"UNVERIFIED_ACCOUNT_GRACE_PERIOD" env-default:"72h"`
+	// UserCommandsEnabled consumes create, update and delete commands from the users-commands topic, for systems that
+	// can only write users through Kafka. Commands failing for reasons other than what they ask for are tried
+	// UserCommandsMaxAttempts times, waiting UserCommandsRetryDelay longer each time, before being dead-lettered.
+	UserCommandsEnabled     bool          `yaml:"user-commands-enabled" env:"USER_COMMANDS_ENABLED" env-default:"false"`
+	UserCommandsMaxAttempts int           `yaml:"user-commands-max-attempts" env:"USER_COMMANDS_MAX_ATTEMPTS" env-default:"3"`
+	UserCommandsRetryDelay  time.Duration `yaml:"user-commands-retry-delay" env:"USER_COMMANDS_RETRY_DELAY" env-default:"1s"`
+	// UserCommandsReplyRetention is how long replies are kept to be given again to redelivered commands, it should be
+	// longer than the users-commands topic keeps messages
+	UserCommandsReplyRetention time.Duration `yaml:"user-commands-reply-retention" env:"USER_COMMANDS_REPLY_RETENTION" env-default:"168h"`
+	// MailerType is either "smtp" or "log", the log mailer writes emails to MailOutputDir if set or the log otherwise
+	MailerType    string `yaml:"mailer-type" env:"MAILER_TYPE" env-default:"log"`
+	MailOutputDir string `yaml:"mail-output-dir" env:"MAIL_OUTPUT_DIR"`
+	MailFrom      string `yaml:"mail-from" env:"MAIL_FROM" env-default:"no-reply@faceit-user-service.local"`
+	SMTPHost      string `yaml:"smtp-host" env:"SMTP_HOST"`
+	SMTPPort      int    `yaml:"smtp-port" env:"SMTP_PORT" env-default:"587"`
+	SMTPUsername  string `yaml:"smtp-username" env:"SMTP_USERNAME"`
+	SMTPPassword  string `yaml:"smtp-password" env:"SMTP_PASSWORD"`
 }
 
 func NewConfig() (*Config, error) {
@@ -19,5 +140,52 @@ func NewConfig() (*Config, error) {
 		}
 	}
 
+	usePostgres := conf.StorageBackend == StorageBackendPostgres
+	useKafka := conf.EventPublisher == EventPublisherTypeKafka || conf.UserCommandsEnabled
+	for _, setting := range []struct {
+		name     string
+		missing  bool
+		required bool
+		because  string
+	}{
+		{"POSTGRES_CONNECTION_URI", conf.PostgresConnectionURI == "", usePostgres, "STORAGE_BACKEND is postgres"},
+		{"TOKEN_SIGNING_KEY", conf.TokenSigningKey == "", usePostgres, "STORAGE_BACKEND is postgres"},
+		{"MFA_ENCRYPTION_KEY", conf.MFAEncryptionKey == "", usePostgres, "STORAGE_BACKEND is postgres"},
+		{"SIGNING_KEY_ENCRYPTION_KEY", conf.SigningKeyEncryptionKey == "", usePostgres, "STORAGE_BACKEND is postgres"},
+		{"PII_MASTER_KEYS", len(conf.PIIMasterKeys) == 0, usePostgres, "STORAGE_BACKEND is postgres"},
+		{"PII_BLIND_INDEX_KEY", conf.PIIBlindIndexKey == "", usePostgres, "STORAGE_BACKEND is postgres"},
+		{"KAFKA_HOST", conf.KafkaHost == "", useKafka, "EVENT_PUBLISHER is kafka or USER_COMMANDS_ENABLED is on"},
+	} {
+		if setting.required && setting.missing {
+			return nil, fmt.Errorf("%s is required when %s", setting.name, setting.because)
+		}
+	}
+
+	// the other backends don't keep anything signed or encrypted with these once they stop, so keys made up on startup
+	// do as well as configured ones
+	if !usePostgres {
+		for _, key := range []*string{&conf.TokenSigningKey, &conf.MFAEncryptionKey, &conf.SigningKeyEncryptionKey} {
+			if *key != "" {
+				continue
+			}
+
+			*key, err = generateKey()
+			if err != nil {
+				return nil, fmt.Errorf("generating key: %w", err)
+			}
+		}
+	}
+
 	return &conf, nil
 }
+
+// generateKey returns a random 256 bit key, base64 encoded
+func generateKey() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
@@ -0,0 +1,481 @@
+// Package conformance holds behavioural tests that every storage adapter has to pass. They only use an adapter through
+// its usecases interfaces, so the same tests check that each backend behaves like the others rather than that it runs
+// particular queries.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewUserRepository returns an empty repository for a single test
+type NewUserRepository func(t *testing.T) usecases.UserRepository
+
+// TestUserRepository runs every conformance test against the repositories returned by newRepository
+func TestUserRepository(t *testing.T, newRepository NewUserRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repository usecases.UserRepository)
+	}{
+		{"CreateUser", testCreateUser},
+		{"CreateUser_EmailAlreadyUsed", testCreateUserEmailAlreadyUsed},
+		{"CreateUser_EmailsAreCaseSensitive", testCreateUserEmailsAreCaseSensitive},
+		{"UpdateUser", testUpdateUser},
+		{"UpdateUser_EmailAlreadyUsed", testUpdateUserEmailAlreadyUsed},
+		{"DeleteUser", testDeleteUser},
+		{"NotFound", testNotFound},
+		{"GetUsersByIDs", testGetUsersByIDs},
+		{"GetPaginatedUsers_Pages", testGetPaginatedUsersPages},
+		{"GetPaginatedUsers_FullLastPage", testGetPaginatedUsersFullLastPage},
+		{"GetPaginatedUsers_ChangesBetweenPages", testGetPaginatedUsersChangesBetweenPages},
+		{"GetPaginatedUsers_Filters", testGetPaginatedUsersFilters},
+		{"GetPaginatedUsers_InvalidPageToken", testGetPaginatedUsersInvalidPageToken},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"ConcurrentCreates_SameEmail", testConcurrentCreatesSameEmail},
+		{"CheckConnection", testCheckConnection},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newRepository(t))
+		})
+	}
+}
+
+func createUser(g *WithT, repository usecases.UserRepository, firstName, lastName, nickname, email, country string) *entities.User {
+	user, err := repository.CreateUser(context.Background(), firstName, lastName, nickname, "password", email, country)
+	g.Expect(err).ToNot(HaveOccurred())
+	return user
+}
+
+// expectSameUser compares users field by field, as backends can return the same instant in different locations
+func expectSameUser(g *WithT, actual, expected entities.User) {
+	g.Expect(actual.ID).To(Equal(expected.ID))
+	g.Expect(actual.FirstName).To(Equal(expected.FirstName))
+	g.Expect(actual.LastName).To(Equal(expected.LastName))
+	g.Expect(actual.Nickname).To(Equal(expected.Nickname))
+	g.Expect(actual.Password).To(Equal(expected.Password))
+	g.Expect(actual.Email).To(Equal(expected.Email))
+	g.Expect(actual.Country).To(Equal(expected.Country))
+	g.Expect(actual.Status).To(Equal(expected.Status))
+	g.Expect(actual.CreatedAt).To(BeTemporally("==", expected.CreatedAt))
+	g.Expect(actual.UpdatedAt).To(BeTemporally("==", expected.UpdatedAt))
+	g.Expect(actual.PasswordChangedAt == nil).To(Equal(expected.PasswordChangedAt == nil))
+	g.Expect(actual.EmailVerifiedAt == nil).To(Equal(expected.EmailVerifiedAt == nil))
+}
+
+func userIDs(users []entities.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+
+	return ids
+}
+
+// listAllUsers follows the page tokens to the end, failing if that takes more than maxPages
+func listAllUsers(g *WithT, repository usecases.UserRepository, pageSize, maxPages int) ([]entities.User, [][]uuid.UUID) {
+	var users []entities.User
+	var pages [][]uuid.UUID
+	pageInfo := entities.PageInfo{PageSize: pageSize}
+	for len(pages) < maxPages {
+		page, nextPageToken, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", pageInfo)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(page)).To(BeNumerically("<=", pageSize))
+		users = append(users, page...)
+		pages = append(pages, userIDs(page))
+
+		if nextPageToken == "" {
+			return users, pages
+		}
+		pageInfo.NextPageToken = nextPageToken
+	}
+
+	g.Expect(len(pages)).To(BeNumerically("<", maxPages), "paging never ended")
+	return users, pages
+}
+
+func testCreateUser(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+
+	user := createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+	g.Expect(user.ID).ToNot(Equal(uuid.Nil))
+	g.Expect(user.FirstName).To(Equal("alec"))
+	g.Expect(user.Email).To(Equal("alec@email.com"))
+	g.Expect(user.Status).To(Equal(entities.UserStatusPendingVerification))
+	g.Expect(user.CreatedAt).To(BeTemporally("~", time.Now(), time.Minute))
+	g.Expect(user.UpdatedAt).To(BeTemporally("==", user.CreatedAt))
+	g.Expect(user.EmailVerifiedAt).To(BeNil())
+	g.Expect(user.PasswordChangedAt).To(BeNil())
+
+	found, err := repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	expectSameUser(g, *found, *user)
+
+	found, err = repository.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	expectSameUser(g, *found, *user)
+}
+
+func testCreateUserEmailAlreadyUsed(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+
+	_, err := repository.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "alec@email.com", "FR")
+	g.Expect(err).To(MatchError(entities.ErrEmailAlreadyUsed))
+
+	users, _ := listAllUsers(g, repository, 10, 2)
+	g.Expect(users).To(HaveLen(1))
+}
+
+func testCreateUserEmailsAreCaseSensitive(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+	createUser(g, repository, "alec", "smith", "alecsmith", "ALEC@email.com", "UK")
+
+	found, err := repository.GetUserByEmail(context.Background(), "ALEC@email.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found.Email).To(Equal("ALEC@email.com"))
+}
+
+func testUpdateUser(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	user := createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+
+	updated, err := repository.UpdateUser(context.Background(), user.ID, "alex", "jones", "alexjones", "password", "alec@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.ID).To(Equal(user.ID))
+	g.Expect(updated.FirstName).To(Equal("alex"))
+	g.Expect(updated.LastName).To(Equal("jones"))
+	g.Expect(updated.Nickname).To(Equal("alexjones"))
+	g.Expect(updated.Country).To(Equal("FR"))
+	g.Expect(updated.Status).To(Equal(user.Status))
+	g.Expect(updated.CreatedAt).To(BeTemporally("==", user.CreatedAt))
+	g.Expect(updated.UpdatedAt).To(BeTemporally(">=", user.UpdatedAt))
+	// the password didn't change
+	g.Expect(updated.PasswordChangedAt).To(BeNil())
+
+	updated, err = repository.UpdateUser(context.Background(), user.ID, "alex", "jones", "alexjones", "new-password", "alex@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.Password).To(Equal("new-password"))
+	g.Expect(updated.Email).To(Equal("alex@email.com"))
+	g.Expect(updated.PasswordChangedAt).ToNot(BeNil())
+	g.Expect(*updated.PasswordChangedAt).To(BeTemporally("==", updated.UpdatedAt))
+	g.Expect(updated.EmailVerifiedAt).To(BeNil())
+
+	found, err := repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	expectSameUser(g, *found, *updated)
+
+	// the old email is free for someone else
+	_, err = repository.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+	createUser(g, repository, "jane", "doe", "janedoe", "alec@email.com", "FR")
+}
+
+func testUpdateUserEmailAlreadyUsed(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	user := createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+	createUser(g, repository, "jane", "doe", "janedoe", "jane@email.com", "FR")
+
+	_, err := repository.UpdateUser(context.Background(), user.ID, "alec", "smith", "alecsmith", "password", "jane@email.com", "UK")
+	g.Expect(err).To(MatchError(entities.ErrEmailAlreadyUsed))
+
+	found, err := repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	expectSameUser(g, *found, *user)
+}
+
+func testDeleteUser(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	user := createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+	other := createUser(g, repository, "jane", "doe", "janedoe", "jane@email.com", "FR")
+
+	err := repository.DeleteUser(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+
+	err = repository.DeleteUser(context.Background(), user.ID)
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+
+	users, _ := listAllUsers(g, repository, 10, 2)
+	g.Expect(userIDs(users)).To(Equal([]uuid.UUID{other.ID}))
+
+	// the email is free to use again
+	createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+}
+
+func testNotFound(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+	missingUserID := uuid.New()
+
+	_, err := repository.GetUserByID(context.Background(), missingUserID)
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+
+	_, err = repository.GetUserByEmail(context.Background(), "jane@email.com")
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+
+	_, err = repository.UpdateUser(context.Background(), missingUserID, "jane", "doe", "janedoe", "password", "jane@email.com", "FR")
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+
+	err = repository.DeleteUser(context.Background(), missingUserID)
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+
+	// a failed update doesn't create the user
+	_, err = repository.GetUserByEmail(context.Background(), "jane@email.com")
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+}
+
+func testGetUsersByIDs(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	alec := createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+	jane := createUser(g, repository, "jane", "doe", "janedoe", "jane@email.com", "FR")
+	createUser(g, repository, "john", "doe", "johndoe", "john@email.com", "FR")
+
+	users, err := repository.GetUsersByIDs(context.Background(), []uuid.UUID{jane.ID, uuid.New(), alec.ID})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(userIDs(users)).To(ConsistOf(alec.ID, jane.ID))
+
+	users, err = repository.GetUsersByIDs(context.Background(), []uuid.UUID{uuid.New()})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(BeEmpty())
+}
+
+func testGetPaginatedUsersPages(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	var created []uuid.UUID
+	for i := 0; i < 7; i++ {
+		user := createUser(g, repository, "alec", "smith", "alecsmith", fmt.Sprintf("alec%d@email.com", i), "UK")
+		created = append(created, user.ID)
+	}
+
+	users, pages := listAllUsers(g, repository, 3, 5)
+	g.Expect(pages).To(HaveLen(3))
+	g.Expect(pages[0]).To(HaveLen(3))
+	g.Expect(pages[1]).To(HaveLen(3))
+	g.Expect(pages[2]).To(HaveLen(1))
+	// every user is listed exactly once
+	g.Expect(userIDs(users)).To(ConsistOf(created))
+
+	// in order of creation, then ID
+	for i := 1; i < len(users); i++ {
+		previous, current := users[i-1], users[i]
+		g.Expect(current.CreatedAt).To(BeTemporally(">=", previous.CreatedAt))
+		if current.CreatedAt.Equal(previous.CreatedAt) {
+			g.Expect(current.ID.String() > previous.ID.String()).To(BeTrue())
+		}
+	}
+}
+
+func testGetPaginatedUsersFullLastPage(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	for i := 0; i < 4; i++ {
+		createUser(g, repository, "alec", "smith", "alecsmith", fmt.Sprintf("alec%d@email.com", i), "UK")
+	}
+
+	// a full page can't tell whether there are more users, so it is followed by an empty one
+	users, pages := listAllUsers(g, repository, 2, 5)
+	g.Expect(users).To(HaveLen(4))
+	g.Expect(pages).To(HaveLen(3))
+	g.Expect(pages[2]).To(BeEmpty())
+
+	page, nextPageToken, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", entities.PageInfo{PageSize: 10})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(page).To(HaveLen(4))
+	g.Expect(nextPageToken).To(BeEmpty())
+
+	// nothing to list
+	page, nextPageToken, err = repository.GetPaginatedUsers(context.Background(), "jane", "", "", "", "", entities.PageInfo{PageSize: 10})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(page).To(BeEmpty())
+	g.Expect(nextPageToken).To(BeEmpty())
+}
+
+func testGetPaginatedUsersChangesBetweenPages(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	var created []*entities.User
+	for i := 0; i < 4; i++ {
+		created = append(created, createUser(g, repository, "alec", "smith", "alecsmith", fmt.Sprintf("alec%d@email.com", i), "UK"))
+	}
+
+	firstPage, nextPageToken, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", entities.PageInfo{PageSize: 2})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(firstPage).To(HaveLen(2))
+	g.Expect(nextPageToken).ToNot(BeEmpty())
+
+	// deleting a listed user and adding a new one doesn't move the cursor
+	err = repository.DeleteUser(context.Background(), firstPage[0].ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	added := createUser(g, repository, "alec", "smith", "alecsmith", "alec4@email.com", "UK")
+
+	var rest []entities.User
+	pageInfo := entities.PageInfo{PageSize: 2, NextPageToken: nextPageToken}
+	for pages := 0; pages < 5; pages++ {
+		page, nextPageToken, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", pageInfo)
+		g.Expect(err).ToNot(HaveOccurred())
+		rest = append(rest, page...)
+		if nextPageToken == "" {
+			break
+		}
+		pageInfo.NextPageToken = nextPageToken
+	}
+
+	expected := []uuid.UUID{added.ID}
+	for _, user := range created {
+		if user.ID != firstPage[0].ID && user.ID != firstPage[1].ID {
+			expected = append(expected, user.ID)
+		}
+	}
+	g.Expect(userIDs(rest)).To(ConsistOf(expected))
+}
+
+func testGetPaginatedUsersFilters(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	alec := createUser(g, repository, "Alec", "Smith", "Alec_Smith", "alec@email.com", "United Kingdom")
+	jane := createUser(g, repository, "Jane", "Smith", "janesmith", "jane@email.com", "France")
+	john := createUser(g, repository, "John", "Doe", "johnny", "john@email.com", "United States")
+
+	tests := []struct {
+		name      string
+		firstName string
+		lastName  string
+		nickname  string
+		email     string
+		country   string
+		expected  []uuid.UUID
+	}{
+		{name: "no filters", expected: []uuid.UUID{alec.ID, jane.ID, john.ID}},
+		{name: "first name ignoring case", firstName: "alec", expected: []uuid.UUID{alec.ID}},
+		{name: "part of a first name ignoring case", firstName: "LE", expected: []uuid.UUID{alec.ID}},
+		{name: "last name ignoring case", lastName: "SMITH", expected: []uuid.UUID{alec.ID, jane.ID}},
+		{name: "part of a last name ignoring case", lastName: "o", expected: []uuid.UUID{john.ID}},
+		{name: "part of a nickname ignoring case", nickname: "SMITH", expected: []uuid.UUID{alec.ID, jane.ID}},
+		{name: "email", email: "jane@email.com", expected: []uuid.UUID{jane.ID}},
+		{name: "part of an email ignoring case", email: "JA", expected: []uuid.UUID{jane.ID}},
+		{name: "part of every email", email: "@email.com", expected: []uuid.UUID{alec.ID, jane.ID, john.ID}},
+		{name: "part of a country ignoring case", country: "united", expected: []uuid.UUID{alec.ID, john.ID}},
+		{name: "last name and country", lastName: "smith", country: "kingdom", expected: []uuid.UUID{alec.ID}},
+		{name: "nickname and country", nickname: "j", country: "states", expected: []uuid.UUID{john.ID}},
+		{name: "names and email", firstName: "jane", lastName: "smith", email: "jane@email.com", expected: []uuid.UUID{jane.ID}},
+		{name: "filters that contradict", firstName: "jane", email: "alec@email.com", expected: []uuid.UUID{}},
+		{name: "every filter", firstName: "ALEC", lastName: "smi", nickname: "c_s", email: "alec@", country: "KING", expected: []uuid.UUID{alec.ID}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			users, nextPageToken, err := repository.GetPaginatedUsers(
+				context.Background(),
+				test.firstName,
+				test.lastName,
+				test.nickname,
+				test.email,
+				test.country,
+				entities.PageInfo{PageSize: 10},
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(nextPageToken).To(BeEmpty())
+			g.Expect(userIDs(users)).To(ConsistOf(test.expected))
+		})
+	}
+
+	// filters still apply on later pages
+	page, nextPageToken, err := repository.GetPaginatedUsers(context.Background(), "", "smith", "", "", "", entities.PageInfo{PageSize: 1})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(page).To(HaveLen(1))
+	page, _, err = repository.GetPaginatedUsers(context.Background(), "", "smith", "", "", "", entities.PageInfo{PageSize: 1, NextPageToken: nextPageToken})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(page).To(HaveLen(1))
+	g.Expect(page[0].LastName).To(Equal("Smith"))
+}
+
+func testGetPaginatedUsersInvalidPageToken(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+
+	_, _, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", entities.PageInfo{
+		PageSize:      10,
+		NextPageToken: "not-a-token",
+	})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func testConcurrentUpdates(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+	user := createUser(g, repository, "alec", "smith", "alecsmith", "alec@email.com", "UK")
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repository.UpdateUser(context.Background(), user.ID, "alec", "smith", fmt.Sprintf("nickname%d", i), "password", "alec@email.com", "UK")
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	// the last write wins whole, updates aren't merged
+	found, err := repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	var nicknames []string
+	for i := 0; i < writers; i++ {
+		nicknames = append(nicknames, fmt.Sprintf("nickname%d", i))
+	}
+	g.Expect(nicknames).To(ContainElement(found.Nickname))
+	g.Expect(found.Email).To(Equal("alec@email.com"))
+}
+
+func testConcurrentCreatesSameEmail(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repository.CreateUser(context.Background(), "alec", "smith", fmt.Sprintf("alec%d", i), "password", "alec@email.com", "UK")
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		g.Expect(err).To(MatchError(entities.ErrEmailAlreadyUsed))
+	}
+	g.Expect(created).To(Equal(1))
+
+	users, _ := listAllUsers(g, repository, 20, 2)
+	g.Expect(users).To(HaveLen(1))
+}
+
+func testCheckConnection(t *testing.T, repository usecases.UserRepository) {
+	g := NewWithT(t)
+
+	err := repository.CheckConnection()
+	g.Expect(err).ToNot(HaveOccurred())
+}
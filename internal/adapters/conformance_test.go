@@ -0,0 +1,57 @@
+package adapters_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters/conformance"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	. "github.com/onsi/gomega"
+	"os"
+	"testing"
+)
+
+func TestMemoryUserRepository_Conformance(t *testing.T) {
+	conformance.TestUserRepository(t, func(t *testing.T) usecases.UserRepository {
+		return adapters.NewMemoryUserRepository()
+	})
+}
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	conformance.TestUserRepository(t, func(t *testing.T) usecases.UserRepository {
+		return adapters.NewMemoryStore()
+	})
+}
+
+func TestSQLiteAdapter_Conformance(t *testing.T) {
+	conformance.TestUserRepository(t, func(t *testing.T) usecases.UserRepository {
+		return newTestSQLiteAdapter(NewWithT(t), t)
+	})
+}
+
+// TestPostgresAdapter_Conformance needs a database it can empty, given with POSTGRES_TEST_CONNECTION_URI
+func TestPostgresAdapter_Conformance(t *testing.T) {
+	connectionURI := os.Getenv("POSTGRES_TEST_CONNECTION_URI")
+	if connectionURI == "" {
+		t.Skip("POSTGRES_TEST_CONNECTION_URI isn't set")
+	}
+
+	g := NewWithT(t)
+	db, err := sql.Open("postgres", connectionURI)
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	pii, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	adapter := adapters.NewPostgresAdapter(db, pii)
+	g.Expect(adapter.PerformDataMigration(context.Background())).To(Succeed())
+	g.Expect(adapter.LoadDataKeys(context.Background())).To(Succeed())
+
+	conformance.TestUserRepository(t, func(t *testing.T) usecases.UserRepository {
+		_, err := db.Exec("TRUNCATE platform_user CASCADE")
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+		return adapter
+	})
+}
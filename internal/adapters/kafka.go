@@ -4,13 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
 	"github.com/segmentio/kafka-go"
 	"log/slog"
+	"strconv"
 	"time"
 )
 
 const (
-	topicName = "users-changelog"
+	topicName              = "users-changelog"
+	securityEventTopicName = "users-security-events"
+	// userCommandsTopicName is consumed by the instances of the service as userCommandsGroupID, replies to the
+	// commands are published to userCommandRepliesTopicName and messages that can't be processed to
+	// userCommandsDeadLetterTopicName
+	userCommandsTopicName           = "users-commands"
+	userCommandsGroupID             = "user-service"
+	userCommandRepliesTopicName     = "users-commands-replies"
+	userCommandsDeadLetterTopicName = "users-commands-dlq"
+)
+
+var (
+	_ usecases.MessageConsumer        = &KafkaUserCommandConsumer{}
+	_ usecases.UserCommandReplyWriter = &KafkaUserCommandReplyPublisher{}
+	_ usecases.DeadLetterWriter       = &KafkaDeadLetterPublisher{}
 )
 
 type KafkaAdapter struct {
@@ -29,20 +45,181 @@ func NewKafkaAdapter(kafkaHost string, dialer Dialer) (*KafkaAdapter, error) {
 }
 
 func (adapter *KafkaAdapter) PublishChangelogEntry(entry entities.ChangelogEntry) error {
-	err := adapter.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return writeJSONMessage(adapter.conn, entry)
+}
+
+func (adapter *KafkaAdapter) CloseConn() error {
+	return closeConn(adapter.conn)
+}
+
+// KafkaSecurityEventPublisher publishes security events to their own topic, so that security tooling can consume them
+// without reading every change to every user
+type KafkaSecurityEventPublisher struct {
+	conn KafkaConnection
+}
+
+func NewKafkaSecurityEventPublisher(kafkaHost string, dialer Dialer) (*KafkaSecurityEventPublisher, error) {
+	conn, err := dialer.DialLeader(context.Background(), "tcp", kafkaHost, securityEventTopicName, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaSecurityEventPublisher{
+		conn: conn,
+	}, nil
+}
+
+func (publisher *KafkaSecurityEventPublisher) PublishSecurityEvent(event entities.SecurityEvent) error {
+	return writeJSONMessage(publisher.conn, event)
+}
+
+func (publisher *KafkaSecurityEventPublisher) CloseConn() error {
+	return closeConn(publisher.conn)
+}
+
+// KafkaUserCommandConsumer consumes user commands as a member of the service's consumer group
+type KafkaUserCommandConsumer struct {
+	reader KafkaMessageReader
+}
+
+func NewKafkaUserCommandConsumer(kafkaHost string, newReader func(kafkaHost, topic, groupID string) KafkaMessageReader) *KafkaUserCommandConsumer {
+	return &KafkaUserCommandConsumer{
+		reader: newReader(kafkaHost, userCommandsTopicName, userCommandsGroupID),
+	}
+}
+
+func (consumer *KafkaUserCommandConsumer) FetchMessage(ctx context.Context) (entities.InboundMessage, error) {
+	message, err := consumer.reader.FetchMessage(ctx)
+	if err != nil {
+		slog.Debug("unable to fetch message", "err", err)
+		return entities.InboundMessage{}, err
+	}
+
+	return entities.InboundMessage{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       message.Key,
+		Value:     message.Value,
+	}, nil
+}
+
+func (consumer *KafkaUserCommandConsumer) CommitMessage(ctx context.Context, message entities.InboundMessage) error {
+	// only the position of a message is needed to commit it
+	err := consumer.reader.CommitMessages(ctx, kafka.Message{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+	})
+	if err != nil {
+		slog.Debug("unable to commit message", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (consumer *KafkaUserCommandConsumer) Close() error {
+	if err := consumer.reader.Close(); err != nil {
+		slog.Error("failed to close reader", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// KafkaUserCommandReplyPublisher publishes replies to user commands keyed by correlation ID, so a sender can find the
+// reply to each of its commands
+type KafkaUserCommandReplyPublisher struct {
+	conn KafkaConnection
+}
+
+func NewKafkaUserCommandReplyPublisher(kafkaHost string, dialer Dialer) (*KafkaUserCommandReplyPublisher, error) {
+	conn, err := dialer.DialLeader(context.Background(), "tcp", kafkaHost, userCommandRepliesTopicName, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaUserCommandReplyPublisher{
+		conn: conn,
+	}, nil
+}
+
+func (publisher *KafkaUserCommandReplyPublisher) PublishUserCommandReply(reply usecases.UserCommandReplyBody) error {
+	return writeKeyedJSONMessage(publisher.conn, []byte(reply.CorrelationID), reply)
+}
+
+func (publisher *KafkaUserCommandReplyPublisher) CloseConn() error {
+	return closeConn(publisher.conn)
+}
+
+// KafkaDeadLetterPublisher publishes messages that couldn't be processed to the dead-letter topic as they were
+// received, with why they failed in the headers, so they can be replayed to the topic they came from
+type KafkaDeadLetterPublisher struct {
+	conn KafkaConnection
+}
+
+func NewKafkaDeadLetterPublisher(kafkaHost string, dialer Dialer) (*KafkaDeadLetterPublisher, error) {
+	conn, err := dialer.DialLeader(context.Background(), "tcp", kafkaHost, userCommandsDeadLetterTopicName, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaDeadLetterPublisher{
+		conn: conn,
+	}, nil
+}
+
+func (publisher *KafkaDeadLetterPublisher) PublishDeadLetter(deadLetter entities.DeadLetter) error {
+	err := publisher.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err != nil {
+		slog.Debug("unable to set write deadline", "err", err)
+		return err
+	}
+
+	_, err = publisher.conn.WriteMessages(kafka.Message{
+		Key:   deadLetter.Message.Key,
+		Value: deadLetter.Message.Value,
+		Headers: []kafka.Header{
+			{Key: "dlq-reason", Value: []byte(deadLetter.Reason)},
+			{Key: "dlq-attempts", Value: []byte(strconv.Itoa(deadLetter.Attempts))},
+			{Key: "dlq-failed-at", Value: []byte(deadLetter.FailedAt.Format(time.RFC3339Nano))},
+			{Key: "dlq-source-topic", Value: []byte(deadLetter.Message.Topic)},
+			{Key: "dlq-source-partition", Value: []byte(strconv.Itoa(deadLetter.Message.Partition))},
+			{Key: "dlq-source-offset", Value: []byte(strconv.FormatInt(deadLetter.Message.Offset, 10))},
+		},
+	})
+	if err != nil {
+		slog.Debug("failed to write message", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (publisher *KafkaDeadLetterPublisher) CloseConn() error {
+	return closeConn(publisher.conn)
+}
+
+func writeJSONMessage(conn KafkaConnection, value any) error {
+	return writeKeyedJSONMessage(conn, nil, value)
+}
+
+func writeKeyedJSONMessage(conn KafkaConnection, key []byte, value any) error {
+	err := conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 	if err != nil {
 		slog.Debug("unable to set write deadline", "err", err)
 		return err
 	}
 
-	entryJSON, err := json.Marshal(entry)
+	valueJSON, err := json.Marshal(value)
 	if err != nil {
-		slog.Debug("unable to convert entry to json", "err", err)
+		slog.Debug("unable to convert message to json", "err", err)
 		return err
 	}
 
-	_, err = adapter.conn.WriteMessages(
-		kafka.Message{Value: entryJSON},
+	_, err = conn.WriteMessages(
+		kafka.Message{Key: key, Value: valueJSON},
 	)
 	if err != nil {
 		slog.Debug("failed to write message", "err", err)
@@ -52,8 +229,8 @@ func (adapter *KafkaAdapter) PublishChangelogEntry(entry entities.ChangelogEntry
 	return nil
 }
 
-func (adapter *KafkaAdapter) CloseConn() error {
-	if err := adapter.conn.Close(); err != nil {
+func closeConn(conn KafkaConnection) error {
+	if err := conn.Close(); err != nil {
 		slog.Error("failed to close writer", "err", err)
 		return err
 	}
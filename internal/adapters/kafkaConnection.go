@@ -46,8 +46,27 @@ type Dialer interface {
 type KafkaDialer struct{}
 
 func (dialer *KafkaDialer) DialLeader(ctx context.Context, network string, kafkaHost string, topic string, partition int) (KafkaConnection, error) {
-	conn, err := kafka.DialLeader(context.Background(), "tcp", fmt.Sprintf("%s:9092", kafkaHost), topicName, 0)
+	conn, err := kafka.DialLeader(ctx, network, fmt.Sprintf("%s:9092", kafkaHost), topic, partition)
 	return &KafkaConnectionWrapper{
 		conn: conn,
 	}, err
 }
+
+// KafkaMessageReader is an interface used for mocking a consumer group subscription in tests
+//
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/adapters/kafkaMessageReader.go  . "KafkaMessageReader"
+type KafkaMessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewKafkaMessageReader subscribes to topic as part of the consumer group, partitions are shared out between the
+// group's members
+func NewKafkaMessageReader(kafkaHost string, topic string, groupID string) KafkaMessageReader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{fmt.Sprintf("%s:9092", kafkaHost)},
+		GroupID: groupID,
+		Topic:   topic,
+	})
+}
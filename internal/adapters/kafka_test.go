@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
 	mock_adapters "github.com/AlecSmith96/faceit-user-service/mocks/adapters"
 	"github.com/google/uuid"
 	. "github.com/onsi/gomega"
@@ -166,3 +167,194 @@ func TestKafkaAdapter_CloseConnErr(t *testing.T) {
 	err = adapter.CloseConn()
 	g.Expect(err).To(MatchError("an error occurred"))
 }
+
+func TestKafkaSecurityEventPublisher_PublishSecurityEvent(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockDialer := mock_adapters.NewMockDialer(ctrl)
+	mockKafkaConnection := mock_adapters.NewMockKafkaConnection(ctrl)
+
+	userID := uuid.New()
+	event := entities.SecurityEvent{
+		Type:      entities.SecurityEventLoginFailed,
+		UserID:    &userID,
+		Email:     "alec@email.com",
+		IPAddress: "198.51.100.4",
+		Reason:    entities.LoginFailureIncorrectPassword,
+		CreatedAt: time.Now(),
+	}
+	eventJSON, err := json.Marshal(event)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mockDialer.EXPECT().
+		DialLeader(gomock.AssignableToTypeOf(ctxType), "tcp", "localhost", "users-security-events", 0).
+		Return(mockKafkaConnection, nil)
+	mockKafkaConnection.EXPECT().SetWriteDeadline(gomock.AssignableToTypeOf(time.Time{})).Return(nil)
+	mockKafkaConnection.EXPECT().WriteMessages(kafka.Message{Value: eventJSON}).Return(0, nil)
+
+	publisher, err := adapters.NewKafkaSecurityEventPublisher("localhost", mockDialer)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = publisher.PublishSecurityEvent(event)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestNewKafkaSecurityEventPublisher_ReturnsErr(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockDialer := mock_adapters.NewMockDialer(ctrl)
+
+	mockDialer.EXPECT().
+		DialLeader(gomock.AssignableToTypeOf(ctxType), "tcp", "localhost", "users-security-events", 0).
+		Return(nil, errors.New("an error occurred"))
+
+	publisher, err := adapters.NewKafkaSecurityEventPublisher("localhost", mockDialer)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(publisher).To(BeNil())
+}
+
+func TestKafkaUserCommandReplyPublisher_PublishUserCommandReply(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockDialer := mock_adapters.NewMockDialer(ctrl)
+	mockKafkaConnection := mock_adapters.NewMockKafkaConnection(ctrl)
+
+	reply := usecases.UserCommandReplyBody{
+		CorrelationID: "some-correlation-id",
+		Type:          entities.UserCommandDelete,
+		Status:        entities.UserCommandStatusSucceeded,
+	}
+	replyJSON, err := json.Marshal(reply)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mockDialer.EXPECT().
+		DialLeader(gomock.AssignableToTypeOf(ctxType), "tcp", "localhost", "users-commands-replies", 0).
+		Return(mockKafkaConnection, nil)
+	mockKafkaConnection.EXPECT().SetWriteDeadline(gomock.AssignableToTypeOf(time.Time{})).Return(nil)
+	mockKafkaConnection.EXPECT().WriteMessages(kafka.Message{Key: []byte("some-correlation-id"), Value: replyJSON}).Return(0, nil)
+
+	publisher, err := adapters.NewKafkaUserCommandReplyPublisher("localhost", mockDialer)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = publisher.PublishUserCommandReply(reply)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestKafkaDeadLetterPublisher_PublishDeadLetter(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockDialer := mock_adapters.NewMockDialer(ctrl)
+	mockKafkaConnection := mock_adapters.NewMockKafkaConnection(ctrl)
+
+	deadLetter := entities.DeadLetter{
+		Message: entities.InboundMessage{
+			Topic:     "users-commands",
+			Partition: 1,
+			Offset:    42,
+			Key:       []byte("some-key"),
+			Value:     []byte("not-json"),
+		},
+		Reason:   "decoding command",
+		Attempts: 1,
+		FailedAt: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	mockDialer.EXPECT().
+		DialLeader(gomock.AssignableToTypeOf(ctxType), "tcp", "localhost", "users-commands-dlq", 0).
+		Return(mockKafkaConnection, nil)
+	mockKafkaConnection.EXPECT().SetWriteDeadline(gomock.AssignableToTypeOf(time.Time{})).Return(nil)
+	mockKafkaConnection.EXPECT().WriteMessages(kafka.Message{
+		Key:   []byte("some-key"),
+		Value: []byte("not-json"),
+		Headers: []kafka.Header{
+			{Key: "dlq-reason", Value: []byte("decoding command")},
+			{Key: "dlq-attempts", Value: []byte("1")},
+			{Key: "dlq-failed-at", Value: []byte("2024-07-01T09:00:00Z")},
+			{Key: "dlq-source-topic", Value: []byte("users-commands")},
+			{Key: "dlq-source-partition", Value: []byte("1")},
+			{Key: "dlq-source-offset", Value: []byte("42")},
+		},
+	}).Return(0, nil)
+
+	publisher, err := adapters.NewKafkaDeadLetterPublisher("localhost", mockDialer)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = publisher.PublishDeadLetter(deadLetter)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestNewKafkaDeadLetterPublisher_ReturnsErr(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockDialer := mock_adapters.NewMockDialer(ctrl)
+
+	mockDialer.EXPECT().
+		DialLeader(gomock.AssignableToTypeOf(ctxType), "tcp", "localhost", "users-commands-dlq", 0).
+		Return(nil, errors.New("an error occurred"))
+
+	publisher, err := adapters.NewKafkaDeadLetterPublisher("localhost", mockDialer)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(publisher).To(BeNil())
+}
+
+func TestKafkaUserCommandConsumer_FetchAndCommitMessage(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockReader := mock_adapters.NewMockKafkaMessageReader(ctrl)
+
+	mockReader.EXPECT().FetchMessage(gomock.AssignableToTypeOf(ctxType)).Return(kafka.Message{
+		Topic:     "users-commands",
+		Partition: 1,
+		Offset:    42,
+		Key:       []byte("some-key"),
+		Value:     []byte("some-value"),
+		Time:      time.Now(),
+	}, nil)
+	mockReader.EXPECT().CommitMessages(gomock.AssignableToTypeOf(ctxType), kafka.Message{
+		Topic:     "users-commands",
+		Partition: 1,
+		Offset:    42,
+	}).Return(nil)
+
+	consumer := adapters.NewKafkaUserCommandConsumer("localhost", func(kafkaHost, topic, groupID string) adapters.KafkaMessageReader {
+		g.Expect(kafkaHost).To(Equal("localhost"))
+		g.Expect(topic).To(Equal("users-commands"))
+		g.Expect(groupID).To(Equal("user-service"))
+		return mockReader
+	})
+
+	message, err := consumer.FetchMessage(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(message).To(Equal(entities.InboundMessage{
+		Topic:     "users-commands",
+		Partition: 1,
+		Offset:    42,
+		Key:       []byte("some-key"),
+		Value:     []byte("some-value"),
+	}))
+
+	err = consumer.CommitMessage(context.Background(), message)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestKafkaUserCommandConsumer_FetchMessageErr(t *testing.T) {
+	g := NewWithT(t)
+
+	ctrl := gomock.NewController(t)
+	mockReader := mock_adapters.NewMockKafkaMessageReader(ctrl)
+
+	mockReader.EXPECT().FetchMessage(gomock.AssignableToTypeOf(ctxType)).Return(kafka.Message{}, errors.New("an error occurred"))
+
+	consumer := adapters.NewKafkaUserCommandConsumer("localhost", func(string, string, string) adapters.KafkaMessageReader {
+		return mockReader
+	})
+
+	_, err := consumer.FetchMessage(context.Background())
+	g.Expect(err).To(MatchError("an error occurred"))
+}
@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"log/slog"
+)
+
+const (
+	EventPublisherTypeKafka = "kafka"
+	EventPublisherTypeLog   = "log"
+)
+
+// LogEventPublisher is a ChangelogWriter and SecurityEventPublisher for running without Kafka. Rather than publishing
+// changelog entries and security events it writes each one to the log.
+type LogEventPublisher struct{}
+
+var (
+	_ usecases.ChangelogWriter        = &LogEventPublisher{}
+	_ usecases.SecurityEventPublisher = &LogEventPublisher{}
+)
+
+func NewLogEventPublisher() *LogEventPublisher {
+	return &LogEventPublisher{}
+}
+
+func (publisher *LogEventPublisher) PublishChangelogEntry(entry entities.ChangelogEntry) error {
+	slog.Info("changelog entry published", "entry", entry)
+	return nil
+}
+
+func (publisher *LogEventPublisher) PublishSecurityEvent(event entities.SecurityEvent) error {
+	slog.Info("security event published", "event", event)
+	return nil
+}
@@ -0,0 +1,104 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MailerTypeSMTP = "smtp"
+	MailerTypeLog  = "log"
+)
+
+// SMTPMailer delivers emails through an SMTP relay
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+var _ usecases.Mailer = &SMTPMailer{}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTPMailer) SendMail(ctx context.Context, email entities.Email) error {
+	err := smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, formatMessage(m.from, email))
+	if err != nil {
+		slog.Debug("unable to send email", "err", err, "to", email.To)
+		return err
+	}
+
+	return nil
+}
+
+// LocalMailer is a Mailer for local development and tests. Rather than delivering emails it writes each one to a file
+// in outputDir, or to the log if no directory is configured.
+type LocalMailer struct {
+	outputDir string
+	from      string
+}
+
+var _ usecases.Mailer = &LocalMailer{}
+
+func NewLocalMailer(outputDir, from string) *LocalMailer {
+	return &LocalMailer{
+		outputDir: outputDir,
+		from:      from,
+	}
+}
+
+func (m *LocalMailer) SendMail(ctx context.Context, email entities.Email) error {
+	if m.outputDir == "" {
+		slog.Info("email sent", "to", email.To, "subject", email.Subject, "body", email.Body)
+		return nil
+	}
+
+	err := os.MkdirAll(m.outputDir, 0o755)
+	if err != nil {
+		slog.Debug("unable to create mail output directory", "err", err)
+		return err
+	}
+
+	fileName := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.ReplaceAll(email.To, "/", "_"))
+	err = os.WriteFile(filepath.Join(m.outputDir, fileName), formatMessage(m.from, email), 0o644)
+	if err != nil {
+		slog.Debug("unable to write email to file", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// headerSanitiser strips line breaks from header values so they can't be used to inject extra headers
+var headerSanitiser = strings.NewReplacer("\r", "", "\n", "")
+
+func formatMessage(from string, email entities.Email) []byte {
+	return []byte(fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s\r\n",
+		headerSanitiser.Replace(from),
+		headerSanitiser.Replace(email.To),
+		headerSanitiser.Replace(email.Subject),
+		email.Body,
+	))
+}
@@ -0,0 +1,44 @@
+package adapters_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalMailer_SendMail(t *testing.T) {
+	g := NewWithT(t)
+
+	outputDir := t.TempDir()
+	mailer := adapters.NewLocalMailer(outputDir, "no-reply@email.com")
+
+	err := mailer.SendMail(context.Background(), entities.Email{
+		To:      "alec@email.com\r\nBcc: someone@email.com",
+		Subject: "Verify your email address",
+		Body:    "some-token",
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	files, err := os.ReadDir(outputDir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+
+	contents, err := os.ReadFile(filepath.Join(outputDir, files[0].Name()))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(contents)).To(ContainSubstring("To: alec@email.comBcc: someone@email.com\r\n"))
+	g.Expect(string(contents)).To(ContainSubstring("Subject: Verify your email address\r\n"))
+	g.Expect(string(contents)).To(HaveSuffix("\r\n\r\nsome-token\r\n"))
+}
+
+func TestLocalMailer_SendMail_NoOutputDir(t *testing.T) {
+	g := NewWithT(t)
+
+	mailer := adapters.NewLocalMailer("", "no-reply@email.com")
+
+	err := mailer.SendMail(context.Background(), entities.Email{To: "alec@email.com"})
+	g.Expect(err).ToNot(HaveOccurred())
+}
@@ -0,0 +1,50 @@
+package adapters
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"maps"
+)
+
+// AppendAuditEntry chains the entry on to the last one and stores it, appends are serialised by the store's lock
+func (m *MemoryStore) AppendAuditEntry(_ context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var last *entities.AuditEntry
+	if len(m.auditEntries) > 0 {
+		last = &m.auditEntries[len(m.auditEntries)-1]
+	}
+
+	entry.Details = maps.Clone(entry.Details)
+	entry = entry.Chain(last)
+	m.auditEntries = append(m.auditEntries, entry)
+
+	return &entry, nil
+}
+
+// GetAuditEntries returns up to the filter's limit of the entries matching it, in sequence order
+func (m *MemoryStore) GetAuditEntries(_ context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := []entities.AuditEntry{}
+	for _, entry := range m.auditEntries {
+		if len(entries) == filter.Limit {
+			break
+		}
+
+		if entry.Sequence <= filter.AfterSequence ||
+			(filter.Actor != "" && entry.Actor != filter.Actor) ||
+			(filter.Action != "" && entry.Action != filter.Action) ||
+			(filter.TargetUserID != nil && (entry.TargetUserID == nil || *entry.TargetUserID != *filter.TargetUserID)) ||
+			(filter.From != nil && entry.CreatedAt.Before(*filter.From)) ||
+			(filter.To != nil && !entry.CreatedAt.Before(*filter.To)) {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
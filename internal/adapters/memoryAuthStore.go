@@ -0,0 +1,582 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+type memorySession struct {
+	session          entities.Session
+	refreshTokenHash string
+}
+
+type memoryOIDCConsentKey struct {
+	userID   uuid.UUID
+	clientID string
+}
+
+// compareCreationOrder orders records by creation time then ID, as Postgres orders (created_at, id)
+func compareCreationOrder(aCreatedAt time.Time, aID uuid.UUID, bCreatedAt time.Time, bID uuid.UUID) int {
+	if c := aCreatedAt.Compare(bCreatedAt); c != 0 {
+		return c
+	}
+
+	return bytes.Compare(aID[:], bID[:])
+}
+
+// GetTOTPEnrolment returns the user's TOTP enrolment, confirmed or not
+func (m *MemoryStore) GetTOTPEnrolment(_ context.Context, userID uuid.UUID) (*entities.TOTPEnrolment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	enrolment, found := m.totpEnrolments[userID]
+	if !found {
+		slog.Debug("totp not enrolled", "userID", userID)
+		return nil, entities.ErrMFANotEnrolled
+	}
+
+	return &enrolment, nil
+}
+
+// EnrolTOTP stores a new TOTP secret for the user, replacing one that hasn't been confirmed. A confirmed enrolment is
+// left alone and ErrMFAAlreadyEnabled returned.
+func (m *MemoryStore) EnrolTOTP(_ context.Context, userID uuid.UUID, encryptedSecret string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enrolment, found := m.totpEnrolments[userID]
+	if found && enrolment.Enabled() {
+		slog.Debug("totp already enabled", "userID", userID)
+		return entities.ErrMFAAlreadyEnabled
+	}
+
+	m.totpEnrolments[userID] = entities.TOTPEnrolment{
+		UserID:          userID,
+		EncryptedSecret: encryptedSecret,
+		CreatedAt:       now,
+	}
+	return nil
+}
+
+// ConfirmTOTP enables the user's TOTP enrolment, recording the time step of the code it was confirmed with, and
+// replaces their recovery codes
+func (m *MemoryStore) ConfirmTOTP(_ context.Context, userID uuid.UUID, counter int64, recoveryCodeHashes []string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enrolment, found := m.totpEnrolments[userID]
+	if !found || enrolment.Enabled() {
+		slog.Debug("totp already enabled", "userID", userID)
+		return entities.ErrMFAAlreadyEnabled
+	}
+
+	enrolment.ConfirmedAt = &now
+	enrolment.LastUsedCounter = &counter
+	m.totpEnrolments[userID] = enrolment
+	m.replaceRecoveryCodes(userID, recoveryCodeHashes)
+
+	return nil
+}
+
+// DisableTOTP removes the user's TOTP enrolment along with their recovery codes
+func (m *MemoryStore) DisableTOTP(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, found := m.totpEnrolments[userID]
+	if !found {
+		slog.Debug("totp not enrolled", "userID", userID)
+		return entities.ErrMFANotEnrolled
+	}
+
+	delete(m.totpEnrolments, userID)
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+
+func (m *MemoryStore) ReplaceRecoveryCodes(_ context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.replaceRecoveryCodes(userID, recoveryCodeHashes)
+	return nil
+}
+
+// replaceRecoveryCodes drops all of the user's recovery codes, used or not, and stores the new ones. The caller must
+// hold the lock.
+func (m *MemoryStore) replaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) {
+	codes := make(map[string]bool, len(recoveryCodeHashes))
+	for _, codeHash := range recoveryCodeHashes {
+		codes[codeHash] = false
+	}
+	m.recoveryCodes[userID] = codes
+}
+
+// UseTOTPCounter records the time step of an accepted TOTP code, failing with ErrInvalidMFACode if a code from that
+// time step or a later one has already been used
+func (m *MemoryStore) UseTOTPCounter(_ context.Context, userID uuid.UUID, counter int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enrolment, found := m.totpEnrolments[userID]
+	if !found || !enrolment.Enabled() || (enrolment.LastUsedCounter != nil && *enrolment.LastUsedCounter >= counter) {
+		slog.Debug("totp code replayed", "userID", userID)
+		return entities.ErrInvalidMFACode
+	}
+
+	enrolment.LastUsedCounter = &counter
+	m.totpEnrolments[userID] = enrolment
+	return nil
+}
+
+// UseRecoveryCode marks one of the user's recovery codes as used, failing with ErrInvalidMFACode if it doesn't exist or
+// has already been used
+func (m *MemoryStore) UseRecoveryCode(_ context.Context, userID uuid.UUID, codeHash string, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	used, found := m.recoveryCodes[userID][codeHash]
+	if !found || used {
+		slog.Debug("recovery code not usable", "userID", userID)
+		return entities.ErrInvalidMFACode
+	}
+
+	m.recoveryCodes[userID][codeHash] = true
+	return nil
+}
+
+func (m *MemoryStore) CreateSession(_ context.Context, session entities.Session, refreshTokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[session.ID] = memorySession{session: session, refreshTokenHash: refreshTokenHash}
+	return nil
+}
+
+// usable reports whether the session hasn't been revoked or expired
+func (s memorySession) usable(now time.Time) bool {
+	return s.session.RevokedAt == nil && s.session.ExpiresAt.After(now)
+}
+
+// GetSessions returns the user's sessions that haven't expired or been revoked, oldest first
+func (m *MemoryStore) GetSessions(_ context.Context, userID uuid.UUID, now time.Time) ([]entities.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := []entities.Session{}
+	for _, stored := range m.sessions {
+		if stored.session.UserID == userID && stored.usable(now) {
+			sessions = append(sessions, stored.session)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b entities.Session) int {
+		return compareCreationOrder(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return sessions, nil
+}
+
+// RefreshSession replaces a usable session's refresh token hash, recording where and when it was used. Refresh tokens
+// that don't match a session that hasn't expired or been revoked return ErrInvalidToken.
+func (m *MemoryStore) RefreshSession(
+	_ context.Context,
+	refreshTokenHash,
+	newRefreshTokenHash string,
+	device entities.SessionDevice,
+	now time.Time,
+) (*entities.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, stored := range m.sessions {
+		if stored.refreshTokenHash != refreshTokenHash || !stored.usable(now) {
+			continue
+		}
+
+		stored.refreshTokenHash = newRefreshTokenHash
+		stored.session.Device.UserAgent = device.UserAgent
+		stored.session.Device.IPAddress = device.IPAddress
+		stored.session.LastUsedAt = &now
+		m.sessions[id] = stored
+
+		return &stored.session, nil
+	}
+
+	slog.Debug("refresh token not usable")
+	return nil, entities.ErrInvalidToken
+}
+
+// RevokeSession ends one of the user's sessions, returning ErrSessionNotFound if it isn't theirs or has already ended
+func (m *MemoryStore) RevokeSession(_ context.Context, userID, sessionID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, found := m.sessions[sessionID]
+	if !found || stored.session.UserID != userID || !stored.usable(now) {
+		slog.Debug("session not found", "userID", userID, "sessionID", sessionID)
+		return entities.ErrSessionNotFound
+	}
+
+	stored.session.RevokedAt = &now
+	m.sessions[sessionID] = stored
+	return nil
+}
+
+// RevokeSessions ends all the user's sessions and returns the IDs of the ones that were still in use
+func (m *MemoryStore) RevokeSessions(_ context.Context, userID uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.revokeSessions(userID, now), nil
+}
+
+// revokeSessions ends all the user's usable sessions, the caller must hold the lock
+func (m *MemoryStore) revokeSessions(userID uuid.UUID, now time.Time) []uuid.UUID {
+	sessionIDs := []uuid.UUID{}
+	for id, stored := range m.sessions {
+		if stored.session.UserID != userID || !stored.usable(now) {
+			continue
+		}
+
+		stored.session.RevokedAt = &now
+		m.sessions[id] = stored
+		sessionIDs = append(sessionIDs, id)
+	}
+
+	return sessionIDs
+}
+
+func (m *MemoryStore) CreateWebAuthnCeremony(_ context.Context, ceremony entities.WebAuthnCeremony) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.webAuthnCeremonies[ceremony.ID] = ceremony
+	return nil
+}
+
+// RedeemWebAuthnCeremony removes and returns an unexpired ceremony so that it can only be finished once
+func (m *MemoryStore) RedeemWebAuthnCeremony(_ context.Context, ceremonyID uuid.UUID, purpose string, now time.Time) (*entities.WebAuthnCeremony, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ceremony, found := m.webAuthnCeremonies[ceremonyID]
+	if !found || ceremony.Purpose != purpose || !ceremony.ExpiresAt.After(now) {
+		slog.Debug("webauthn ceremony not redeemable", "ceremonyID", ceremonyID)
+		return nil, entities.ErrInvalidToken
+	}
+
+	delete(m.webAuthnCeremonies, ceremonyID)
+	return &ceremony, nil
+}
+
+// GetWebAuthnCredentials returns the user's WebAuthn credentials, oldest first
+func (m *MemoryStore) GetWebAuthnCredentials(_ context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	credentials := []entities.WebAuthnCredential{}
+	for _, credential := range m.webAuthnCredentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+
+	slices.SortFunc(credentials, func(a, b entities.WebAuthnCredential) int {
+		return compareCreationOrder(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return credentials, nil
+}
+
+// CreateWebAuthnCredential stores a newly registered credential. A credential that is already registered, to this user
+// or any other, returns ErrWebAuthnCredentialExists.
+func (m *MemoryStore) CreateWebAuthnCredential(_ context.Context, credential entities.WebAuthnCredential) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.webAuthnCredentials {
+		if bytes.Equal(existing.CredentialID, credential.CredentialID) {
+			slog.Debug("webauthn credential already registered")
+			return entities.ErrWebAuthnCredentialExists
+		}
+	}
+
+	if credential.Transports == nil {
+		credential.Transports = []string{}
+	}
+	m.webAuthnCredentials[credential.ID] = credential
+	return nil
+}
+
+// UpdateWebAuthnCredentialUsage records a login with a credential, storing the authenticator's new signature counter
+func (m *MemoryStore) UpdateWebAuthnCredentialUsage(_ context.Context, credentialID []byte, signCount uint32, backupState bool, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, credential := range m.webAuthnCredentials {
+		if !bytes.Equal(credential.CredentialID, credentialID) {
+			continue
+		}
+
+		credential.SignCount = signCount
+		credential.BackupState = backupState
+		credential.LastUsedAt = &usedAt
+		m.webAuthnCredentials[id] = credential
+		return nil
+	}
+
+	slog.Debug("webauthn credential not found")
+	return entities.ErrWebAuthnCredentialNotFound
+}
+
+// DeleteWebAuthnCredential removes one of the user's credentials
+func (m *MemoryStore) DeleteWebAuthnCredential(_ context.Context, userID, credentialID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	credential, found := m.webAuthnCredentials[credentialID]
+	if !found || credential.UserID != userID {
+		slog.Debug("webauthn credential not found", "userID", userID, "credentialID", credentialID)
+		return entities.ErrWebAuthnCredentialNotFound
+	}
+
+	delete(m.webAuthnCredentials, credentialID)
+	return nil
+}
+
+func (m *MemoryStore) CreateOIDCClient(_ context.Context, client entities.OIDCClient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.oidcClients[client.ID] = client
+	return nil
+}
+
+func (m *MemoryStore) GetOIDCClient(_ context.Context, clientID string) (*entities.OIDCClient, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, found := m.oidcClients[clientID]
+	if !found {
+		slog.Debug("oidc client not found", "clientID", clientID)
+		return nil, entities.ErrOIDCClientNotFound
+	}
+
+	return &client, nil
+}
+
+// GetOIDCConsent returns the scopes the user has allowed the client, which is empty if they haven't consented yet
+func (m *MemoryStore) GetOIDCConsent(_ context.Context, userID uuid.UUID, clientID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scopes, found := m.oidcConsents[memoryOIDCConsentKey{userID: userID, clientID: clientID}]
+	if !found {
+		return []string{}, nil
+	}
+
+	return slices.Clone(scopes), nil
+}
+
+// GrantOIDCConsent stores the scopes the user has allowed the client, replacing any previously stored
+func (m *MemoryStore) GrantOIDCConsent(_ context.Context, userID uuid.UUID, clientID string, scopes []string, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.oidcConsents[memoryOIDCConsentKey{userID: userID, clientID: clientID}] = slices.Clone(scopes)
+	return nil
+}
+
+func (m *MemoryStore) CreateOIDCAuthorizationCode(_ context.Context, code entities.OIDCAuthorizationCode, codeHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.oidcCodes[codeHash] = code
+	return nil
+}
+
+// RedeemOIDCAuthorizationCode removes and returns an unexpired authorization code so that it can only be used once
+func (m *MemoryStore) RedeemOIDCAuthorizationCode(_ context.Context, codeHash string, now time.Time) (*entities.OIDCAuthorizationCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	code, found := m.oidcCodes[codeHash]
+	if !found || !code.ExpiresAt.After(now) {
+		slog.Debug("oidc authorization code not redeemable")
+		return nil, entities.ErrInvalidToken
+	}
+
+	delete(m.oidcCodes, codeHash)
+	return &code, nil
+}
+
+// GetSigningKeys returns the keys that haven't expired, oldest first
+func (m *MemoryStore) GetSigningKeys(_ context.Context, now time.Time) ([]entities.SigningKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := []entities.SigningKey{}
+	for _, key := range m.signingKeys {
+		if key.ExpiresAt == nil || key.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b entities.SigningKey) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return keys, nil
+}
+
+// CreateSigningKey stores a new key, returning ErrSigningKeysChanged if there is already a key with its status
+func (m *MemoryStore) CreateSigningKey(_ context.Context, key entities.SigningKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insertSigningKey(key)
+}
+
+// insertSigningKey stores a key, there can only be one active and one next key. The caller must hold the lock.
+func (m *MemoryStore) insertSigningKey(key entities.SigningKey) error {
+	if key.Status == entities.SigningKeyStatusActive || key.Status == entities.SigningKeyStatusNext {
+		for _, existing := range m.signingKeys {
+			if existing.Status == key.Status {
+				slog.Debug("signing key with status already exists", "status", key.Status)
+				return entities.ErrSigningKeysChanged
+			}
+		}
+	}
+
+	m.signingKeys[key.ID] = key
+	return nil
+}
+
+// RotateSigningKeys retires the active key, activates the next key and stores a new next key, deleting any keys that
+// have expired. If the active key has already been retired ErrSigningKeysChanged is returned.
+func (m *MemoryStore) RotateSigningKeys(
+	_ context.Context,
+	activeKeyID string,
+	next entities.SigningKey,
+	now,
+	retiredKeyExpiresAt time.Time,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active, found := m.signingKeys[activeKeyID]
+	if !found || active.Status != entities.SigningKeyStatusActive {
+		slog.Debug("signing key no longer active", "kid", activeKeyID)
+		return entities.ErrSigningKeysChanged
+	}
+
+	var nextKey entities.SigningKey
+	for _, key := range m.signingKeys {
+		if key.Status == entities.SigningKeyStatusNext {
+			nextKey = key
+		}
+	}
+	if nextKey.ID == "" {
+		slog.Debug("no next signing key to activate")
+		return entities.ErrSigningKeysChanged
+	}
+
+	active.Status = entities.SigningKeyStatusRetired
+	active.RetiredAt = &now
+	active.ExpiresAt = &retiredKeyExpiresAt
+	m.signingKeys[active.ID] = active
+	nextKey.Status = entities.SigningKeyStatusActive
+	nextKey.ActivatedAt = &now
+	m.signingKeys[nextKey.ID] = nextKey
+
+	err := m.insertSigningKey(next)
+	if err != nil {
+		return err
+	}
+
+	for id, key := range m.signingKeys {
+		if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+			delete(m.signingKeys, id)
+		}
+	}
+
+	return nil
+}
+
+// RecordLoginAttempt keeps every attempt, like the login_attempt table, so a long running instance keeps growing
+func (m *MemoryStore) RecordLoginAttempt(_ context.Context, attempt entities.LoginAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if attempt.Succeeded {
+		attempt.FailureReason = ""
+	}
+	m.loginAttempts = append(m.loginAttempts, attempt)
+	return nil
+}
+
+func (m *MemoryStore) CreateAPIKey(_ context.Context, key entities.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.apiKeys[key.ID] = key
+	return nil
+}
+
+// GetAPIKeys returns every key, revoked and expired ones included, oldest first
+func (m *MemoryStore) GetAPIKeys(_ context.Context) ([]entities.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]entities.APIKey, 0, len(m.apiKeys))
+	for _, key := range m.apiKeys {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b entities.APIKey) int {
+		return compareCreationOrder(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return keys, nil
+}
+
+// AuthenticateAPIKey records that the key with the secret hash was used and returns it, keys that have been revoked or
+// have expired return ErrAPIKeyNotFound
+func (m *MemoryStore) AuthenticateAPIKey(_ context.Context, secretHash string, now time.Time) (*entities.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, key := range m.apiKeys {
+		if key.SecretHash != secretHash || key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+			continue
+		}
+
+		key.LastUsedAt = &now
+		m.apiKeys[id] = key
+		return &key, nil
+	}
+
+	slog.Debug("api key not found")
+	return nil, entities.ErrAPIKeyNotFound
+}
+
+func (m *MemoryStore) RevokeAPIKey(_ context.Context, id uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, found := m.apiKeys[id]
+	if !found || key.RevokedAt != nil {
+		slog.Debug("api key not found", "apiKeyID", id)
+		return entities.ErrAPIKeyNotFound
+	}
+
+	key.RevokedAt = &now
+	m.apiKeys[id] = key
+	return nil
+}
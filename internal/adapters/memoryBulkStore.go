@@ -0,0 +1,388 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type memoryImportJob struct {
+	job         entities.ImportJob
+	heartbeatAt time.Time
+	results     []entities.ImportRowResult
+}
+
+// ImportUsers creates the users together, skipping any whose email is already registered or pending for another user
+// or was given to an earlier user of the same import. Nothing is stored in a dry run.
+func (m *MemoryStore) ImportUsers(_ context.Context, users []entities.ImportedUser, dryRun bool) ([]entities.ImportRowResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	imported := map[string]bool{}
+	results := make([]entities.ImportRowResult, len(users))
+	for i, user := range users {
+		results[i] = entities.ImportRowResult{Row: user.Row}
+		if imported[user.Email] || m.emailTaken(user.Email, uuid.Nil) {
+			results[i].Error = entities.ErrEmailAlreadyUsed.Error()
+			continue
+		}
+		imported[user.Email] = true
+		if dryRun {
+			continue
+		}
+
+		userID := uuid.New()
+		m.users[userID] = entities.User{
+			ID:        userID,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Nickname:  user.Nickname,
+			Password:  user.Password,
+			Email:     user.Email,
+			Country:   user.Country,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Status:    entities.UserStatusPendingVerification,
+		}
+		results[i].UserID = &userID
+	}
+
+	return results, nil
+}
+
+func (m *MemoryStore) CreateImportJob(_ context.Context, job entities.ImportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.importJobs[job.ID] = &memoryImportJob{job: job, heartbeatAt: job.CreatedAt}
+	return nil
+}
+
+// RecordImportResults stores the results and adds them to the job's counts
+func (m *MemoryStore) RecordImportResults(_ context.Context, jobID uuid.UUID, results []entities.ImportRowResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, found := m.importJobs[jobID]
+	if !found {
+		slog.Debug("import job not found", "jobID", jobID)
+		return entities.ErrImportJobNotFound
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			stored.job.FailedRows++
+		} else {
+			stored.job.ImportedRows++
+		}
+	}
+	stored.results = append(stored.results, results...)
+
+	return nil
+}
+
+func (m *MemoryStore) FinishImportJob(
+	_ context.Context,
+	jobID uuid.UUID,
+	status entities.ImportJobStatus,
+	errorMessage string,
+	now time.Time,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, found := m.importJobs[jobID]
+	if !found || stored.job.Status != entities.ImportJobStatusRunning {
+		slog.Debug("running import job not found", "jobID", jobID)
+		return entities.ErrImportJobNotFound
+	}
+
+	stored.job.Status = status
+	stored.job.Error = errorMessage
+	stored.job.FinishedAt = &now
+	return nil
+}
+
+func (m *MemoryStore) HeartbeatImportJob(_ context.Context, jobID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, found := m.importJobs[jobID]
+	if found && stored.job.Status == entities.ImportJobStatusRunning {
+		stored.heartbeatAt = now
+	}
+
+	return nil
+}
+
+// FailStaleImportJobs fails running jobs whose last heartbeat was before staleBefore
+func (m *MemoryStore) FailStaleImportJobs(_ context.Context, staleBefore time.Time, errorMessage string, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failed := 0
+	for _, stored := range m.importJobs {
+		if stored.job.Status != entities.ImportJobStatusRunning || !stored.heartbeatAt.Before(staleBefore) {
+			continue
+		}
+
+		stored.job.Status = entities.ImportJobStatusFailed
+		stored.job.Error = errorMessage
+		stored.job.FinishedAt = &now
+		failed++
+	}
+
+	return failed, nil
+}
+
+func (m *MemoryStore) GetImportJob(_ context.Context, jobID uuid.UUID) (*entities.ImportJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, found := m.importJobs[jobID]
+	if !found {
+		slog.Debug("import job not found", "jobID", jobID)
+		return nil, entities.ErrImportJobNotFound
+	}
+
+	job := stored.job
+	return &job, nil
+}
+
+func (m *MemoryStore) GetImportResults(_ context.Context, jobID uuid.UUID, afterRow, limit int) ([]entities.ImportRowResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []entities.ImportRowResult{}
+	stored, found := m.importJobs[jobID]
+	if !found {
+		return results, nil
+	}
+
+	for _, result := range stored.results {
+		if result.Row > afterRow {
+			results = append(results, result)
+		}
+	}
+
+	slices.SortFunc(results, func(a, b entities.ImportRowResult) int {
+		return a.Row - b.Row
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// ExportUsers calls each for the users matching the filters as they were when the export started, in creation order.
+// The lock isn't held while each runs, so a slow download doesn't block every other request.
+func (m *MemoryStore) ExportUsers(
+	_ context.Context,
+	firstName,
+	lastName,
+	nickname,
+	email,
+	country string,
+	each func(entities.User) error,
+) error {
+	m.mu.RLock()
+	users := make([]entities.User, 0)
+	for _, user := range m.users {
+		if matchesMemoryUserFilter(user, firstName, lastName, nickname, email, country) {
+			users = append(users, user)
+		}
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(users, func(a, b entities.User) int {
+		return compareUserOrder(a, b.CreatedAt, b.ID)
+	})
+	for _, user := range users {
+		err := each(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateUsers applies the updates together and returns a result for each, in the same order. An all or nothing batch
+// with a failed update is undone, as PostgresAdapter rolls its transaction back.
+func (m *MemoryStore) UpdateUsers(_ context.Context, updates []entities.UserUpdate, mode entities.BatchMode) ([]entities.BatchItemResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before := maps.Clone(m.users)
+	results := make([]entities.BatchItemResult, len(updates))
+	for i, update := range updates {
+		results[i].UserID = update.UserID
+		user, err := m.updateUserInBatch(update)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) || errors.Is(err, entities.ErrInvalidStatusTransition) {
+				results[i].Status = entities.BatchItemStatusFailed
+				results[i].Error = err.Error()
+				continue
+			}
+
+			m.users = before
+			return nil, err
+		}
+
+		results[i].Status = entities.BatchItemStatusSucceeded
+		results[i].User = user
+	}
+
+	if !finishMemoryBatch(results, mode) {
+		m.users = before
+	}
+
+	return results, nil
+}
+
+// updateUserInBatch applies the profile and then the status of one update of a batch, the caller must hold the lock
+func (m *MemoryStore) updateUserInBatch(update entities.UserUpdate) (*entities.User, error) {
+	var user *entities.User
+	if !update.StatusOnly {
+		var err error
+		user, err = m.updateUser(update)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Status == "" {
+		return user, nil
+	}
+
+	return m.updateUserStatus(update.UserID, update.FromStatus, update.Status, update.BanReason, update.BanExpiresAt)
+}
+
+// DeleteUsers deletes the users together and returns a result for each, in the same order. An all or nothing batch
+// with a failed delete deletes nothing.
+func (m *MemoryStore) DeleteUsers(_ context.Context, userIDs []uuid.UUID, mode entities.BatchMode) ([]entities.BatchItemResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := map[uuid.UUID]bool{}
+	results := make([]entities.BatchItemResult, len(userIDs))
+	for i, userID := range userIDs {
+		results[i].UserID = userID
+		_, found := m.users[userID]
+		if !found || deleted[userID] {
+			slog.Debug("user not found", "userID", userID)
+			results[i].Status = entities.BatchItemStatusFailed
+			results[i].Error = entities.ErrUserNotFound.Error()
+			continue
+		}
+
+		deleted[userID] = true
+		results[i].Status = entities.BatchItemStatusSucceeded
+	}
+
+	if finishMemoryBatch(results, mode) {
+		for userID := range deleted {
+			delete(m.users, userID)
+			m.deleteUserData(userID)
+		}
+	}
+
+	return results, nil
+}
+
+// finishMemoryBatch reports whether a batch write should be kept. An all or nothing batch with a failed item isn't, and
+// the items that were written are reported as skipped, as finishBatch does.
+func finishMemoryBatch(results []entities.BatchItemResult, mode entities.BatchMode) bool {
+	if mode != entities.BatchModeAllOrNothing {
+		return true
+	}
+
+	failed := slices.ContainsFunc(results, func(result entities.BatchItemResult) bool {
+		return result.Status == entities.BatchItemStatusFailed
+	})
+	if !failed {
+		return true
+	}
+
+	for i := range results {
+		if results[i].Status == entities.BatchItemStatusSucceeded {
+			results[i].Status = entities.BatchItemStatusSkipped
+			results[i].User = nil
+		}
+	}
+
+	return false
+}
+
+// SearchUsers scores every user with the word similarity PostgresAdapter uses, so results match Postgres's apart from
+// nicknames, which are matched by word similarity or containing one of the query's words rather than pg_trgm and full
+// text search
+func (m *MemoryStore) SearchUsers(_ context.Context, query string, limit int) ([]entities.UserSearchResult, error) {
+	query = strings.TrimSpace(query)
+	terms := append([]string{query}, strings.Fields(query)...)
+	queryWords := entities.SearchWords(query)
+
+	m.mu.RLock()
+	results := []entities.UserSearchResult{}
+	for _, user := range m.users {
+		result := entities.UserSearchResult{User: user, MatchedFields: []string{}}
+		nicknameScore := entities.WordSimilarity(query, user.Nickname)
+		nicknameWords := entities.SearchWords(user.Nickname)
+		if nicknameScore >= entities.SearchSimilarityThreshold || slices.ContainsFunc(queryWords, func(word entities.SearchWord) bool {
+			return slices.ContainsFunc(nicknameWords, func(nicknameWord entities.SearchWord) bool {
+				return nicknameWord.Text == word.Text
+			})
+		}) {
+			result.MatchedFields = append(result.MatchedFields, entities.SearchFieldNickname)
+			result.Score = nicknameScore
+		}
+
+		for _, field := range []struct {
+			name  string
+			value string
+		}{
+			{entities.SearchFieldFirstName, user.FirstName},
+			{entities.SearchFieldLastName, user.LastName},
+			{entities.SearchFieldEmail, user.Email},
+		} {
+			score := 0.0
+			for _, term := range terms {
+				score = max(score, entities.WordSimilarity(term, field.value))
+			}
+			if score >= entities.SearchSimilarityThreshold {
+				result.MatchedFields = append(result.MatchedFields, field.name)
+				result.Score = max(result.Score, score)
+			}
+		}
+
+		if len(result.MatchedFields) > 0 {
+			results = append(results, result)
+		}
+	}
+	m.mu.RUnlock()
+
+	// creation order first, so users that score the same stay in it as they do in Postgres
+	slices.SortFunc(results, func(a, b entities.UserSearchResult) int {
+		return compareUserOrder(a.User, b.User.CreatedAt, b.User.ID)
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return len(results[i].MatchedFields) > len(results[j].MatchedFields)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
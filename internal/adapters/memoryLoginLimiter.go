@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"sync"
+	"time"
+)
+
+const (
+	memoryLoginLimiterSweepInterval = time.Minute
+
+	LoginLimiterTypePostgres = "postgres"
+	LoginLimiterTypeMemory   = "memory"
+)
+
+var _ usecases.LoginLimiter = &MemoryLoginLimiter{}
+
+// MemoryLoginLimiter keeps failed login counts in memory. They are lost on restart and not shared between instances,
+// so it is only suitable when running a single instance.
+type MemoryLoginLimiter struct {
+	mu        sync.Mutex
+	throttles map[string]entities.LoginThrottle
+	lastSweep time.Time
+}
+
+func NewMemoryLoginLimiter() *MemoryLoginLimiter {
+	return &MemoryLoginLimiter{
+		throttles: map[string]entities.LoginThrottle{},
+	}
+}
+
+func (l *MemoryLoginLimiter) GetLoginThrottle(_ context.Context, key string, _ time.Time) (*entities.LoginThrottle, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	throttle := l.throttles[key]
+	return &throttle, nil
+}
+
+// RecordLoginFailure also periodically forgets throttles that can no longer block a login, so that the map doesn't keep
+// growing with every IP address that has ever failed
+func (l *MemoryLoginLimiter) RecordLoginFailure(
+	_ context.Context,
+	key string,
+	policy entities.LoginThrottlePolicy,
+	now time.Time,
+) (*entities.LoginThrottle, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= memoryLoginLimiterSweepInterval {
+		for existingKey, throttle := range l.throttles {
+			if throttle.RetryAfter(now) == 0 && now.Sub(throttle.LastFailureAt) >= policy.ResetAfter {
+				delete(l.throttles, existingKey)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	next := policy.Fail(l.throttles[key], now)
+	l.throttles[key] = next
+
+	return &next, nil
+}
+
+func (l *MemoryLoginLimiter) ResetLoginThrottle(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.throttles, key)
+	return nil
+}
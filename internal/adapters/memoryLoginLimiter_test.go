@@ -0,0 +1,104 @@
+package adapters_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+var testLoginThrottlePolicy = entities.LoginThrottlePolicy{
+	FreeFailures:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Second,
+	LockoutFailures: 8,
+	LockoutDuration: 30 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+func TestMemoryLoginLimiter_RecordLoginFailure_BacksOffExponentially(t *testing.T) {
+	g := NewWithT(t)
+	limiter := adapters.NewMemoryLoginLimiter()
+	now := time.Now().UTC()
+
+	var retryAfters []time.Duration
+	for i := 0; i < 7; i++ {
+		throttle, err := limiter.RecordLoginFailure(context.Background(), "user:1", testLoginThrottlePolicy, now)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(throttle.Locked).To(BeFalse())
+		retryAfters = append(retryAfters, throttle.RetryAfter(now))
+	}
+
+	g.Expect(retryAfters).To(Equal([]time.Duration{
+		0,
+		0,
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	}))
+
+	throttle, err := limiter.GetLoginThrottle(context.Background(), "user:1", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(throttle.Failures).To(Equal(7))
+}
+
+func TestMemoryLoginLimiter_RecordLoginFailure_Locks(t *testing.T) {
+	g := NewWithT(t)
+	limiter := adapters.NewMemoryLoginLimiter()
+	now := time.Now().UTC()
+
+	var throttle *entities.LoginThrottle
+	var err error
+	for i := 0; i < 8; i++ {
+		throttle, err = limiter.RecordLoginFailure(context.Background(), "user:1", testLoginThrottlePolicy, now)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	g.Expect(throttle.Locked).To(BeTrue())
+	g.Expect(throttle.RetryAfter(now)).To(Equal(30 * time.Minute))
+
+	// once the lockout has passed the count starts again
+	afterLockout := now.Add(31 * time.Minute)
+	throttle, err = limiter.RecordLoginFailure(context.Background(), "user:1", testLoginThrottlePolicy, afterLockout)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(throttle.Failures).To(Equal(1))
+	g.Expect(throttle.Locked).To(BeFalse())
+	g.Expect(throttle.RetryAfter(afterLockout)).To(BeZero())
+}
+
+func TestMemoryLoginLimiter_RecordLoginFailure_ResetsAfterWindow(t *testing.T) {
+	g := NewWithT(t)
+	limiter := adapters.NewMemoryLoginLimiter()
+	now := time.Now().UTC()
+
+	for i := 0; i < 4; i++ {
+		_, err := limiter.RecordLoginFailure(context.Background(), "ip:198.51.100.4", testLoginThrottlePolicy, now)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	throttle, err := limiter.RecordLoginFailure(context.Background(), "ip:198.51.100.4", testLoginThrottlePolicy, now.Add(time.Hour))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(throttle.Failures).To(Equal(1))
+}
+
+func TestMemoryLoginLimiter_ResetLoginThrottle(t *testing.T) {
+	g := NewWithT(t)
+	limiter := adapters.NewMemoryLoginLimiter()
+	now := time.Now().UTC()
+
+	for i := 0; i < 8; i++ {
+		_, err := limiter.RecordLoginFailure(context.Background(), "user:1", testLoginThrottlePolicy, now)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	err := limiter.ResetLoginThrottle(context.Background(), "user:1")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	throttle, err := limiter.GetLoginThrottle(context.Background(), "user:1", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*throttle).To(Equal(entities.LoginThrottle{}))
+}
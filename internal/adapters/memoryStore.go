@@ -0,0 +1,506 @@
+package adapters
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+var (
+	_ usecases.UserRepository         = &MemoryStore{}
+	_ usecases.UserStatusUpdater      = &MemoryStore{}
+	_ usecases.ExpiredBanLifter       = &MemoryStore{}
+	_ usecases.EmailVerificationStore = &MemoryStore{}
+	_ usecases.EmailChangeStore       = &MemoryStore{}
+	_ usecases.PasswordManager        = &MemoryStore{}
+	_ usecases.MFAStore               = &MemoryStore{}
+	_ usecases.SessionStore           = &MemoryStore{}
+	_ usecases.WebAuthnStore          = &MemoryStore{}
+	_ usecases.OIDCStore              = &MemoryStore{}
+	_ SigningKeyStore                 = &MemoryStore{}
+	_ usecases.LoginAuditStore        = &MemoryStore{}
+	_ usecases.APIKeyStore            = &MemoryStore{}
+	_ usecases.AuditLog               = &MemoryStore{}
+	_ usecases.UserImporter           = &MemoryStore{}
+	_ usecases.ImportJobStore         = &MemoryStore{}
+	_ usecases.UserExporter           = &MemoryStore{}
+	_ usecases.UserBatchWriter        = &MemoryStore{}
+	_ usecases.UserSearcher           = &MemoryStore{}
+	_ usecases.UserCommandReplyStore  = &MemoryStore{}
+)
+
+// MemoryStore keeps everything PostgresAdapter stores in memory, so the whole service can run without a database. It
+// shares the users and lock of its MemoryUserRepository, and deleting a user removes what was stored for them as
+// Postgres's cascading deletes do. Everything is lost on restart and not shared between instances, so it is only
+// suitable for development and tests.
+type MemoryStore struct {
+	*MemoryUserRepository
+	verificationTokens  map[uuid.UUID]memoryVerificationToken
+	passwordResetTokens map[string]memoryPasswordResetToken
+	totpEnrolments      map[uuid.UUID]entities.TOTPEnrolment
+	// recoveryCodes maps each user's recovery code hashes to whether they have been used
+	recoveryCodes       map[uuid.UUID]map[string]bool
+	sessions            map[uuid.UUID]memorySession
+	webAuthnCeremonies  map[uuid.UUID]entities.WebAuthnCeremony
+	webAuthnCredentials map[uuid.UUID]entities.WebAuthnCredential
+	oidcClients         map[string]entities.OIDCClient
+	oidcConsents        map[memoryOIDCConsentKey][]string
+	oidcCodes           map[string]entities.OIDCAuthorizationCode
+	signingKeys         map[string]entities.SigningKey
+	loginAttempts       []entities.LoginAttempt
+	apiKeys             map[uuid.UUID]entities.APIKey
+	auditEntries        []entities.AuditEntry
+	importJobs          map[uuid.UUID]*memoryImportJob
+	userCommandReplies  map[string]entities.UserCommandReply
+}
+
+type memoryVerificationToken struct {
+	token  entities.VerificationToken
+	usedAt *time.Time
+}
+
+type memoryPasswordResetToken struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+	usedAt    *time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		MemoryUserRepository: NewMemoryUserRepository(),
+		verificationTokens:   map[uuid.UUID]memoryVerificationToken{},
+		passwordResetTokens:  map[string]memoryPasswordResetToken{},
+		totpEnrolments:       map[uuid.UUID]entities.TOTPEnrolment{},
+		recoveryCodes:        map[uuid.UUID]map[string]bool{},
+		sessions:             map[uuid.UUID]memorySession{},
+		webAuthnCeremonies:   map[uuid.UUID]entities.WebAuthnCeremony{},
+		webAuthnCredentials:  map[uuid.UUID]entities.WebAuthnCredential{},
+		oidcClients:          map[string]entities.OIDCClient{},
+		oidcConsents:         map[memoryOIDCConsentKey][]string{},
+		oidcCodes:            map[string]entities.OIDCAuthorizationCode{},
+		signingKeys:          map[string]entities.SigningKey{},
+		apiKeys:              map[uuid.UUID]entities.APIKey{},
+		importJobs:           map[uuid.UUID]*memoryImportJob{},
+		userCommandReplies:   map[string]entities.UserCommandReply{},
+	}
+}
+
+// DeleteUser also deletes the user's tokens, sessions, MFA, credentials and consents, and forgets them in the login
+// attempts, as the foreign keys of the Postgres tables do
+func (m *MemoryStore) DeleteUser(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, found := m.users[userID]
+	if !found {
+		slog.Debug("user not found", "userID", userID)
+		return entities.ErrUserNotFound
+	}
+
+	delete(m.users, userID)
+	m.deleteUserData(userID)
+	return nil
+}
+
+// deleteUserData deletes everything stored for a deleted user, the caller must hold the lock
+func (m *MemoryStore) deleteUserData(userID uuid.UUID) {
+	for id, stored := range m.verificationTokens {
+		if stored.token.UserID == userID {
+			delete(m.verificationTokens, id)
+		}
+	}
+	for hash, stored := range m.passwordResetTokens {
+		if stored.userID == userID {
+			delete(m.passwordResetTokens, hash)
+		}
+	}
+	delete(m.totpEnrolments, userID)
+	delete(m.recoveryCodes, userID)
+	for id, stored := range m.sessions {
+		if stored.session.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	for id, ceremony := range m.webAuthnCeremonies {
+		if ceremony.UserID != nil && *ceremony.UserID == userID {
+			delete(m.webAuthnCeremonies, id)
+		}
+	}
+	for id, credential := range m.webAuthnCredentials {
+		if credential.UserID == userID {
+			delete(m.webAuthnCredentials, id)
+		}
+	}
+	for key := range m.oidcConsents {
+		if key.userID == userID {
+			delete(m.oidcConsents, key)
+		}
+	}
+	for hash, code := range m.oidcCodes {
+		if code.UserID == userID {
+			delete(m.oidcCodes, hash)
+		}
+	}
+	for i, attempt := range m.loginAttempts {
+		if attempt.UserID != nil && *attempt.UserID == userID {
+			m.loginAttempts[i].UserID = nil
+		}
+	}
+}
+
+// UpdateUserStatus only applies the transition if the user is still in the expected status, as
+// PostgresAdapter.UpdateUserStatus does
+func (m *MemoryStore) UpdateUserStatus(
+	_ context.Context,
+	userID uuid.UUID,
+	from,
+	to entities.UserStatus,
+	banReason string,
+	banExpiresAt *time.Time,
+) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.updateUserStatus(userID, from, to, banReason, banExpiresAt)
+}
+
+// updateUserStatus moves the user from one status to another, the caller must hold the lock
+func (m *MemoryStore) updateUserStatus(
+	userID uuid.UUID,
+	from,
+	to entities.UserStatus,
+	banReason string,
+	banExpiresAt *time.Time,
+) (*entities.User, error) {
+	user, found := m.users[userID]
+	if !found || user.Status != from {
+		slog.Debug("user status changed before transition could be applied", "userID", userID, "from", from, "to", to)
+		return nil, entities.ErrInvalidStatusTransition
+	}
+
+	user.Status = to
+	user.BanReason = banReason
+	user.BanExpiresAt = banExpiresAt
+	user.UpdatedAt = m.now()
+	m.users[userID] = user
+
+	return &user, nil
+}
+
+// LiftExpiredBans reactivates every banned user whose ban expired at or before now, users that never verified their
+// email go back to pending verification
+func (m *MemoryStore) LiftExpiredBans(_ context.Context, now time.Time) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userIDs := make([]uuid.UUID, 0)
+	for userID, user := range m.users {
+		if user.Status != entities.UserStatusBanned || user.BanExpiresAt == nil || user.BanExpiresAt.After(now) {
+			continue
+		}
+
+		user.Status = entities.UserStatusActive
+		if user.EmailVerifiedAt == nil {
+			user.Status = entities.UserStatusPendingVerification
+		}
+		user.BanReason = ""
+		user.BanExpiresAt = nil
+		user.UpdatedAt = now
+		m.users[userID] = user
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
+
+func (m *MemoryStore) CreateVerificationToken(_ context.Context, token entities.VerificationToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.verificationTokens[token.ID] = memoryVerificationToken{token: token}
+	return nil
+}
+
+// redeemVerificationToken marks a stored verification token as used, failing with ErrInvalidToken if it has already
+// been used, has expired or doesn't match the stored token. The caller must hold the lock.
+func (m *MemoryStore) redeemVerificationToken(token entities.VerificationToken, now time.Time) error {
+	stored, found := m.verificationTokens[token.ID]
+	if !found ||
+		stored.token.UserID != token.UserID ||
+		stored.token.Purpose != token.Purpose ||
+		stored.usedAt != nil ||
+		!stored.token.ExpiresAt.After(now) {
+		slog.Debug("verification token not redeemable", "tokenID", token.ID)
+		return entities.ErrInvalidToken
+	}
+
+	stored.usedAt = &now
+	m.verificationTokens[token.ID] = stored
+	return nil
+}
+
+// invalidateVerificationTokens marks the user's unused tokens with the purpose as used, the caller must hold the lock
+func (m *MemoryStore) invalidateVerificationTokens(userID uuid.UUID, purpose string, now time.Time) {
+	for id, stored := range m.verificationTokens {
+		if stored.token.UserID == userID && stored.token.Purpose == purpose && stored.usedAt == nil {
+			stored.usedAt = &now
+			m.verificationTokens[id] = stored
+		}
+	}
+}
+
+func (m *MemoryStore) RedeemVerificationToken(_ context.Context, token entities.VerificationToken, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.redeemVerificationToken(token, now)
+}
+
+// VerifyEmail redeems an email verification token and marks the user's email as verified, if they still have the
+// email address it was issued for
+func (m *MemoryStore) VerifyEmail(_ context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, found := m.users[token.UserID]
+	if !found || user.Email != token.Email {
+		slog.Debug("user email changed since verification token was issued", "userID", token.UserID)
+		return nil, entities.ErrInvalidToken
+	}
+
+	err := m.redeemVerificationToken(token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user.EmailVerifiedAt = &now
+	if user.Status == entities.UserStatusPendingVerification {
+		user.Status = entities.UserStatusActive
+	}
+	user.UpdatedAt = now
+	m.users[user.ID] = user
+
+	return &user, nil
+}
+
+// RequestEmailChange sets the user's pending email and stores the tokens used to confirm or revert the change,
+// superseding any earlier change that hasn't been confirmed
+func (m *MemoryStore) RequestEmailChange(
+	_ context.Context,
+	userID uuid.UUID,
+	newEmail string,
+	confirmToken,
+	revertToken entities.VerificationToken,
+) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, found := m.users[userID]
+	if !found {
+		slog.Debug("user not found", "userID", userID)
+		return nil, entities.ErrUserNotFound
+	}
+
+	if m.emailTaken(newEmail, userID) {
+		slog.Debug("email already registered to a user", "email", newEmail)
+		return nil, entities.ErrEmailAlreadyUsed
+	}
+
+	now := m.now()
+	user.PendingEmail = &newEmail
+	user.UpdatedAt = now
+	m.users[userID] = user
+
+	m.invalidateVerificationTokens(userID, entities.TokenPurposeEmailChange, now)
+	for _, token := range []entities.VerificationToken{confirmToken, revertToken} {
+		m.verificationTokens[token.ID] = memoryVerificationToken{token: token}
+	}
+
+	return &user, nil
+}
+
+// ConfirmEmailChange redeems an email change token and swaps the user's email for the pending one, marking it as
+// verified
+func (m *MemoryStore) ConfirmEmailChange(_ context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, found := m.users[token.UserID]
+	if !found || user.PendingEmail == nil || *user.PendingEmail != token.Email {
+		slog.Debug("pending email changed since token was issued", "userID", token.UserID)
+		return nil, entities.ErrInvalidToken
+	}
+
+	if m.emailTaken(token.Email, token.UserID) {
+		slog.Debug("email already registered to a user", "email", token.Email)
+		return nil, entities.ErrEmailAlreadyUsed
+	}
+
+	err := m.redeemVerificationToken(token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Email = token.Email
+	user.PendingEmail = nil
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	m.users[user.ID] = user
+
+	return &user, nil
+}
+
+// RevertEmailChange redeems an email change revert token, restoring the user's previous email whether or not the change
+// had been confirmed, and cancelling any pending change
+func (m *MemoryStore) RevertEmailChange(_ context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, found := m.users[token.UserID]
+	if !found {
+		slog.Debug("user not found", "userID", token.UserID)
+		return nil, entities.ErrInvalidToken
+	}
+
+	if m.emailTaken(token.Email, token.UserID) {
+		slog.Debug("email already registered to a user", "email", token.Email)
+		return nil, entities.ErrEmailAlreadyUsed
+	}
+
+	err := m.redeemVerificationToken(token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	m.invalidateVerificationTokens(token.UserID, entities.TokenPurposeEmailChange, now)
+	user.Email = token.Email
+	user.PendingEmail = nil
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	m.users[user.ID] = user
+
+	return &user, nil
+}
+
+func (m *MemoryStore) CreatePasswordResetToken(_ context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.passwordResetTokens[tokenHash] = memoryPasswordResetToken{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+// redeemablePasswordResetToken returns the token with the hash if it hasn't been used or expired, the caller must hold
+// the lock
+func (m *MemoryStore) redeemablePasswordResetToken(tokenHash string, now time.Time) (memoryPasswordResetToken, bool) {
+	stored, found := m.passwordResetTokens[tokenHash]
+	if !found || stored.usedAt != nil || !stored.expiresAt.After(now) {
+		return memoryPasswordResetToken{}, false
+	}
+
+	return stored, true
+}
+
+// GetPasswordResetTokenUser returns the user a redeemable password reset token was issued to, without redeeming it
+func (m *MemoryStore) GetPasswordResetTokenUser(_ context.Context, tokenHash string, now time.Time) (*entities.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, redeemable := m.redeemablePasswordResetToken(tokenHash, now)
+	if !redeemable {
+		slog.Debug("password reset token not redeemable")
+		return nil, entities.ErrInvalidToken
+	}
+
+	user := m.users[stored.userID]
+	return &user, nil
+}
+
+// ResetPassword redeems a password reset token and sets the user's new password, invalidating their other reset tokens
+// and revoking their sessions
+func (m *MemoryStore) ResetPassword(_ context.Context, tokenHash, newPassword string, now time.Time) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, redeemable := m.redeemablePasswordResetToken(tokenHash, now)
+	if !redeemable {
+		slog.Debug("password reset token not redeemable")
+		return nil, entities.ErrInvalidToken
+	}
+
+	for hash, other := range m.passwordResetTokens {
+		if other.userID == stored.userID && other.usedAt == nil {
+			other.usedAt = &now
+			m.passwordResetTokens[hash] = other
+		}
+	}
+
+	return m.changePassword(stored.userID, newPassword, now)
+}
+
+// ChangePassword sets the user's new password and revokes their sessions, so they have to log in again everywhere
+func (m *MemoryStore) ChangePassword(_ context.Context, userID uuid.UUID, newPassword string, now time.Time) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.changePassword(userID, newPassword, now)
+}
+
+// changePassword sets the user's password and revokes their sessions, the caller must hold the lock
+func (m *MemoryStore) changePassword(userID uuid.UUID, newPassword string, now time.Time) (*entities.User, error) {
+	user, found := m.users[userID]
+	if !found {
+		slog.Debug("user not found", "userID", userID)
+		return nil, entities.ErrUserNotFound
+	}
+
+	user.Password = newPassword
+	user.PasswordChangedAt = &now
+	user.UpdatedAt = now
+	m.users[userID] = user
+	m.revokeSessions(userID, now)
+
+	return &user, nil
+}
+
+func (m *MemoryStore) GetUserCommandReply(_ context.Context, correlationID string) (*entities.UserCommandReply, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reply, found := m.userCommandReplies[correlationID]
+	if !found {
+		slog.Debug("user command reply not found", "correlationID", correlationID)
+		return nil, entities.ErrUserCommandReplyNotFound
+	}
+
+	reply.Violations = slices.Clone(reply.Violations)
+	return &reply, nil
+}
+
+func (m *MemoryStore) RecordUserCommandReply(_ context.Context, reply entities.UserCommandReply) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reply.Violations = slices.Clone(reply.Violations)
+	m.userCommandReplies[reply.CorrelationID] = reply
+	return nil
+}
+
+func (m *MemoryStore) DeleteUserCommandReplies(_ context.Context, processedBefore time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for correlationID, reply := range m.userCommandReplies {
+		if reply.ProcessedAt.Before(processedBefore) {
+			delete(m.userCommandReplies, correlationID)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
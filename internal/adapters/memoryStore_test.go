@@ -0,0 +1,179 @@
+package adapters_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_DeleteUser_RemovesSessions(t *testing.T) {
+	g := NewWithT(t)
+	store := adapters.NewMemoryStore()
+	now := time.Now().UTC()
+
+	user, err := store.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	err = store.CreateSession(context.Background(), entities.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}, "refresh-token-hash")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = store.DeleteUser(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	sessions, err := store.GetSessions(context.Background(), user.ID, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sessions).To(BeEmpty())
+
+	_, err = store.RefreshSession(context.Background(), "refresh-token-hash", "new-refresh-token-hash", entities.SessionDevice{}, now)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMemoryStore_ConfirmEmailChange(t *testing.T) {
+	g := NewWithT(t)
+	store := adapters.NewMemoryStore()
+	now := time.Now().UTC()
+
+	user, err := store.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	confirmToken := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     "alex@email.com",
+		Purpose:   entities.TokenPurposeEmailChange,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	revertToken := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     "alec@email.com",
+		Purpose:   entities.TokenPurposeEmailChangeRevert,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	_, err = store.RequestEmailChange(context.Background(), user.ID, "alex@email.com", confirmToken, revertToken)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// the pending email can't be taken by another user before it is confirmed
+	_, err = store.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "alex@email.com", "FR")
+	g.Expect(err).To(Equal(entities.ErrEmailAlreadyUsed))
+
+	confirmed, err := store.ConfirmEmailChange(context.Background(), confirmToken, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(confirmed.Email).To(Equal("alex@email.com"))
+	g.Expect(confirmed.PendingEmail).To(BeNil())
+	g.Expect(confirmed.EmailVerifiedAt).ToNot(BeNil())
+
+	_, err = store.ConfirmEmailChange(context.Background(), confirmToken, now)
+	g.Expect(err).To(Equal(entities.ErrInvalidToken))
+}
+
+func TestMemoryStore_AuthenticateAPIKey(t *testing.T) {
+	g := NewWithT(t)
+	store := adapters.NewMemoryStore()
+	now := time.Now().UTC()
+
+	key := entities.APIKey{
+		ID:         uuid.New(),
+		Name:       "backoffice",
+		Scopes:     []string{entities.APIKeyScopeUsersRead},
+		SecretHash: "secret-hash",
+		CreatedAt:  now,
+	}
+	err := store.CreateAPIKey(context.Background(), key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	authenticated, err := store.AuthenticateAPIKey(context.Background(), "secret-hash", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(authenticated.ID).To(Equal(key.ID))
+	g.Expect(authenticated.LastUsedAt).ToNot(BeNil())
+
+	err = store.RevokeAPIKey(context.Background(), key.ID, now)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = store.AuthenticateAPIKey(context.Background(), "secret-hash", now)
+	g.Expect(err).To(Equal(entities.ErrAPIKeyNotFound))
+}
+
+func TestMemoryStore_AuditLog(t *testing.T) {
+	g := NewWithT(t)
+	store := adapters.NewMemoryStore()
+	now := time.Now().UTC()
+	userID := uuid.New()
+
+	for _, action := range []string{entities.AuditActionUsersRead, entities.AuditActionUserErased} {
+		_, err := store.AppendAuditEntry(context.Background(), entities.AuditEntry{
+			Actor:        "backoffice",
+			Action:       action,
+			TargetUserID: &userID,
+			CreatedAt:    now,
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	entries, err := store.GetAuditEntries(context.Background(), entities.AuditFilter{Limit: 10})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries[1].VerifyFollows(&entries[0])).To(Succeed())
+
+	entries, err = store.GetAuditEntries(context.Background(), entities.AuditFilter{
+		Action:       entities.AuditActionUserErased,
+		TargetUserID: &userID,
+		Limit:        10,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+	g.Expect(entries[0].Sequence).To(Equal(int64(2)))
+}
+
+func TestMemoryStore_UpdateUsers_AllOrNothing(t *testing.T) {
+	g := NewWithT(t)
+	store := adapters.NewMemoryStore()
+
+	user, err := store.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	results, err := store.UpdateUsers(context.Background(), []entities.UserUpdate{
+		{UserID: user.ID, FirstName: "alex", LastName: "smith", Nickname: "alecsmith", Password: "password", Email: "alec@email.com", Country: "UK"},
+		{UserID: uuid.New(), FirstName: "jane", LastName: "doe", Nickname: "janedoe", Password: "password", Email: "jane@email.com", Country: "FR"},
+	}, entities.BatchModeAllOrNothing)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results[0].Status).To(Equal(entities.BatchItemStatusSkipped))
+	g.Expect(results[1].Status).To(Equal(entities.BatchItemStatusFailed))
+
+	found, err := store.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found.FirstName).To(Equal("alec"))
+}
+
+func TestMemoryStore_UserCommandReplies(t *testing.T) {
+	g := NewWithT(t)
+	store := adapters.NewMemoryStore()
+	now := time.Now().UTC()
+
+	reply := entities.UserCommandReply{
+		CorrelationID: "some-correlation-id",
+		Type:          entities.UserCommandDelete,
+		Status:        entities.UserCommandStatusSucceeded,
+		ProcessedAt:   now.Add(-2 * time.Hour),
+	}
+	g.Expect(store.RecordUserCommandReply(context.Background(), reply)).To(Succeed())
+
+	found, err := store.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*found).To(Equal(reply))
+
+	deleted, err := store.DeleteUserCommandReplies(context.Background(), now.Add(-time.Hour))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(Equal(1))
+
+	_, err = store.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).To(Equal(entities.ErrUserCommandReplyNotFound))
+}
@@ -0,0 +1,264 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StorageBackendPostgres = "postgres"
+	StorageBackendMemory   = "memory"
+)
+
+var _ usecases.UserRepository = &MemoryUserRepository{}
+
+// MemoryUserRepository keeps users in memory with the same semantics as PostgresAdapter: emails are unique across both
+// current and pending emails, pages are ordered by creation time then ID, and filters match the same way. Users are
+// lost on restart and not shared between instances, so it is only suitable for development and tests.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]entities.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: map[uuid.UUID]entities.User{},
+	}
+}
+
+// now returns the current time as Postgres would store it, to the microsecond
+func (m *MemoryUserRepository) now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// emailTaken reports whether any user other than userID has the email as their email or pending email
+func (m *MemoryUserRepository) emailTaken(email string, userID uuid.UUID) bool {
+	for _, user := range m.users {
+		if user.ID == userID {
+			continue
+		}
+		if user.Email == email || (user.PendingEmail != nil && *user.PendingEmail == email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *MemoryUserRepository) CreateUser(_ context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.emailTaken(email, uuid.Nil) {
+		slog.Debug("email already registered to a user", "email", email)
+		return nil, entities.ErrEmailAlreadyUsed
+	}
+
+	now := m.now()
+	user := entities.User{
+		ID:        uuid.New(),
+		FirstName: firstName,
+		LastName:  lastName,
+		Nickname:  nickname,
+		Password:  password,
+		Email:     email,
+		Country:   country,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Status:    entities.UserStatusPendingVerification,
+	}
+	m.users[user.ID] = user
+
+	return &user, nil
+}
+
+func (m *MemoryUserRepository) DeleteUser(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, found := m.users[userID]
+	if !found {
+		slog.Debug("user not found", "userID", userID)
+		return entities.ErrUserNotFound
+	}
+
+	delete(m.users, userID)
+	return nil
+}
+
+// UpdateUser clears the email verification if the email changes and records when the password changes, as
+// PostgresAdapter.UpdateUser does
+func (m *MemoryUserRepository) UpdateUser(_ context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.updateUser(entities.UserUpdate{
+		UserID:    userID,
+		FirstName: firstName,
+		LastName:  lastName,
+		Nickname:  nickname,
+		Password:  password,
+		Email:     email,
+		Country:   country,
+	})
+}
+
+// updateUser applies the update's profile, the caller must hold the lock
+func (m *MemoryUserRepository) updateUser(update entities.UserUpdate) (*entities.User, error) {
+	user, found := m.users[update.UserID]
+	if !found {
+		slog.Debug("user not found", "userID", update.UserID)
+		return nil, entities.ErrUserNotFound
+	}
+
+	if m.emailTaken(update.Email, update.UserID) {
+		slog.Debug("email already registered to a user", "email", update.Email)
+		return nil, entities.ErrEmailAlreadyUsed
+	}
+
+	now := m.now()
+	if user.Email != update.Email {
+		user.EmailVerifiedAt = nil
+	}
+	if user.Password != update.Password {
+		user.PasswordChangedAt = &now
+	}
+	user.FirstName = update.FirstName
+	user.LastName = update.LastName
+	user.Nickname = update.Nickname
+	user.Password = update.Password
+	user.Email = update.Email
+	user.Country = update.Country
+	user.UpdatedAt = now
+	m.users[update.UserID] = user
+
+	return &user, nil
+}
+
+// GetPaginatedUsers filters users as PostgresAdapter does, each filter matching any part of its field ignoring case
+func (m *MemoryUserRepository) GetPaginatedUsers(
+	_ context.Context,
+	firstName,
+	lastName,
+	nickname,
+	email,
+	country string,
+	pageInfo entities.PageInfo,
+) ([]entities.User, string, error) {
+	var afterUserID uuid.UUID
+	var afterCreatedAt time.Time
+	var err error
+	if pageInfo.NextPageToken != "" {
+		afterUserID, afterCreatedAt, err = decodePageToken(pageInfo.NextPageToken)
+		if err != nil {
+			slog.Debug("decoding page token", "err", err)
+			return nil, "", err
+		}
+	}
+
+	m.mu.RLock()
+	users := make([]entities.User, 0)
+	for _, user := range m.users {
+		if !matchesMemoryUserFilter(user, firstName, lastName, nickname, email, country) {
+			continue
+		}
+		if pageInfo.NextPageToken != "" && compareUserOrder(user, afterCreatedAt, afterUserID) <= 0 {
+			continue
+		}
+
+		users = append(users, user)
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(users, func(a, b entities.User) int {
+		return compareUserOrder(a, b.CreatedAt, b.ID)
+	})
+	if len(users) > pageInfo.PageSize {
+		users = users[:pageInfo.PageSize]
+	}
+
+	if len(users) == 0 || len(users) < pageInfo.PageSize {
+		return users, "", nil
+	}
+
+	lastUser := users[len(users)-1]
+	return users, encodePageToken(lastUser.ID, lastUser.CreatedAt), nil
+}
+
+// matchesMemoryUserFilter checks each filter is part of its field ignoring case, empty filters match anything
+func matchesMemoryUserFilter(user entities.User, firstName, lastName, nickname, email, country string) bool {
+	return containsFold(user.FirstName, firstName) &&
+		containsFold(user.LastName, lastName) &&
+		containsFold(user.Nickname, nickname) &&
+		containsFold(user.Email, email) &&
+		containsFold(user.Country, country)
+}
+
+// compareUserOrder compares a user's position in a page with the position given, ordering by creation time then ID as
+// Postgres orders (created_at, id)
+func compareUserOrder(user entities.User, createdAt time.Time, userID uuid.UUID) int {
+	if c := user.CreatedAt.Compare(createdAt); c != 0 {
+		return c
+	}
+
+	return bytes.Compare(user.ID[:], userID[:])
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (m *MemoryUserRepository) GetUserByID(_ context.Context, userID uuid.UUID) (*entities.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, found := m.users[userID]
+	if !found {
+		slog.Debug("user not found", "userID", userID)
+		return nil, entities.ErrUserNotFound
+	}
+
+	return &user, nil
+}
+
+func (m *MemoryUserRepository) GetUsersByIDs(_ context.Context, userIDs []uuid.UUID) ([]entities.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]entities.User, 0, len(userIDs))
+	for _, userID := range userIDs {
+		user, found := m.users[userID]
+		if found {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (m *MemoryUserRepository) GetUserByEmail(_ context.Context, email string) (*entities.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+
+	slog.Debug("user not found", "email", email)
+	return nil, entities.ErrUserNotFound
+}
+
+// CheckConnection always succeeds, there is nothing to connect to
+func (m *MemoryUserRepository) CheckConnection() error {
+	return nil
+}
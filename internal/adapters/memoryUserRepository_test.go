@@ -0,0 +1,184 @@
+package adapters_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestMemoryUserRepository_CreateUser(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	user, err := repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(user.ID).ToNot(Equal(uuid.Nil))
+	g.Expect(user.Status).To(Equal(entities.UserStatusPendingVerification))
+	g.Expect(user.CreatedAt).To(Equal(user.UpdatedAt))
+
+	found, err := repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(Equal(user))
+
+	found, err = repository.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(Equal(user))
+}
+
+func TestMemoryUserRepository_CreateUser_EmailAlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	_, err := repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = repository.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "alec@email.com", "FR")
+	g.Expect(err).To(Equal(entities.ErrEmailAlreadyUsed))
+}
+
+func TestMemoryUserRepository_UpdateUser(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	user, err := repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	updated, err := repository.UpdateUser(context.Background(), user.ID, "alex", "smith", "alexsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.FirstName).To(Equal("alex"))
+	g.Expect(updated.Nickname).To(Equal("alexsmith"))
+	g.Expect(updated.CreatedAt).To(Equal(user.CreatedAt))
+	g.Expect(updated.PasswordChangedAt).To(BeNil())
+
+	updated, err = repository.UpdateUser(context.Background(), user.ID, "alex", "smith", "alexsmith", "new-password", "alex@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.PasswordChangedAt).ToNot(BeNil())
+	g.Expect(updated.EmailVerifiedAt).To(BeNil())
+
+	found, err := repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(Equal(updated))
+}
+
+func TestMemoryUserRepository_UpdateUser_EmailAlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	user, err := repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = repository.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "jane@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = repository.UpdateUser(context.Background(), user.ID, "alec", "smith", "alecsmith", "password", "jane@email.com", "UK")
+	g.Expect(err).To(Equal(entities.ErrEmailAlreadyUsed))
+}
+
+func TestMemoryUserRepository_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	_, err := repository.GetUserByID(context.Background(), uuid.New())
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	_, err = repository.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	_, err = repository.UpdateUser(context.Background(), uuid.New(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	err = repository.DeleteUser(context.Background(), uuid.New())
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	users, err := repository.GetUsersByIDs(context.Background(), []uuid.UUID{uuid.New()})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(BeEmpty())
+}
+
+func TestMemoryUserRepository_DeleteUser(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	user, err := repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = repository.DeleteUser(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = repository.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	// the email is free to use again
+	_, err = repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestMemoryUserRepository_GetPaginatedUsers(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	var created []uuid.UUID
+	for _, email := range []string{"a@email.com", "b@email.com", "c@email.com", "d@email.com", "e@email.com"} {
+		user, err := repository.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", email, "UK")
+		g.Expect(err).ToNot(HaveOccurred())
+		created = append(created, user.ID)
+	}
+
+	var listed []uuid.UUID
+	pageInfo := entities.PageInfo{PageSize: 2}
+	for pages := 0; pages < 5; pages++ {
+		users, nextPageToken, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", pageInfo)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(users)).To(BeNumerically("<=", 2))
+		for _, user := range users {
+			listed = append(listed, user.ID)
+		}
+
+		if nextPageToken == "" {
+			break
+		}
+		pageInfo.NextPageToken = nextPageToken
+	}
+
+	g.Expect(listed).To(ConsistOf(created))
+}
+
+func TestMemoryUserRepository_GetPaginatedUsers_Filters(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	alec, err := repository.CreateUser(context.Background(), "Alec", "Smith", "alec_smith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	jane, err := repository.CreateUser(context.Background(), "Jane", "Smith", "janesmith", "password", "jane@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pageInfo := entities.PageInfo{PageSize: 10}
+	users, _, err := repository.GetPaginatedUsers(context.Background(), "alec", "", "", "", "", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(Equal([]entities.User{*alec}))
+
+	users, _, err = repository.GetPaginatedUsers(context.Background(), "", "smith", "SMITH", "", "", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(ConsistOf(*alec, *jane))
+
+	users, _, err = repository.GetPaginatedUsers(context.Background(), "", "smith", "", "jane@email.com", "f", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(Equal([]entities.User{*jane}))
+
+	users, _, err = repository.GetPaginatedUsers(context.Background(), "LE", "", "", "JA", "", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(BeEmpty())
+}
+
+func TestMemoryUserRepository_GetPaginatedUsers_InvalidPageToken(t *testing.T) {
+	g := NewWithT(t)
+	repository := adapters.NewMemoryUserRepository()
+
+	_, _, err := repository.GetPaginatedUsers(context.Background(), "", "", "", "", "", entities.PageInfo{
+		PageSize:      10,
+		NextPageToken: "not-a-token",
+	})
+	g.Expect(err).To(HaveOccurred())
+}
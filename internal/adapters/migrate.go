@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/pressly/goose/v3"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// The goose commands that can be run with user-service migrate
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateStatus = "status"
+	MigrateRedo   = "redo"
+	MigrateCreate = "create"
+)
+
+// migrationLockID is the Postgres advisory lock held while migrating, so that instances starting together don't run
+// the same DDL at once
+const migrationLockID int64 = 2024_0529_1619
+
+// runMigration runs a goose command against the migrations embedded under dir. New migrations are written to the
+// source tree by CreateMigration, as the binary only runs the migrations it was built with.
+func runMigration(ctx context.Context, db *sql.DB, dialect string, migrations fs.FS, dir, command string) error {
+	// the dialect and filesystem are shared by every goose command in the process, so they are set each time
+	err := goose.SetDialect(dialect)
+	if err != nil {
+		return err
+	}
+	goose.SetBaseFS(migrations)
+
+	switch command {
+	case MigrateUp:
+		return goose.UpContext(ctx, db, dir)
+	case MigrateDown:
+		return goose.DownContext(ctx, db, dir)
+	case MigrateStatus:
+		return goose.StatusContext(ctx, db, dir)
+	case MigrateRedo:
+		return goose.RedoContext(ctx, db, dir)
+	}
+
+	return fmt.Errorf("unknown migrate command %q, expected up, down, status or redo", command)
+}
+
+// CreateMigration writes an empty SQL migration called name to dir. The migrations are embedded when the binary is
+// built, so dir should be db/goose or db/sqlite in the source tree for the new one to be run by the next build.
+func CreateMigration(dir, name string) error {
+	if name == "" {
+		return errors.New("create takes the name of the migration")
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("migrations directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("migrations directory %q isn't a directory", dir)
+	}
+
+	// the base filesystem is the embedded migrations for the other commands, new ones are written to the real one
+	goose.SetBaseFS(nil)
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// WithMigrationLock runs fn while holding the migration advisory lock, waiting for any other instance holding it. The
+// lock is held by a connection of its own, so fn can use the rest of the pool.
+func (p *PostgresAdapter) WithMigrationLock(ctx context.Context, fn func() error) error {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		slog.Debug("error getting connection", "err", err)
+		return err
+	}
+	defer conn.Close()
+
+	slog.Debug("waiting for migration lock")
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID)
+	if err != nil {
+		slog.Debug("error taking migration lock", "err", err)
+		return err
+	}
+	defer func() {
+		// the lock belongs to the session, so it would outlive the connection being returned to the pool
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+		if err != nil {
+			slog.Error("releasing migration lock", "err", err)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,151 @@
+package adapters_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPostgresAdapter_WithMigrationLock(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	called := false
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+	err = adapter.WithMigrationLock(context.Background(), func() error {
+		called = true
+		return nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(called).To(BeTrue())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_WithMigrationLock_UnlocksWhenMigrationFails(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+	err = adapter.WithMigrationLock(context.Background(), func() error {
+		return errors.New("an error occurred")
+	})
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_WithMigrationLock_LockErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnError(errors.New("an error occurred"))
+
+	called := false
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+	err = adapter.WithMigrationLock(context.Background(), func() error {
+		called = true
+		return nil
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(called).To(BeFalse())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestSQLiteAdapter_Migrate(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	// migrating again finds nothing to do
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateUp)).To(Succeed())
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateStatus)).To(Succeed())
+
+	user, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	err = adapter.RecordUserCommandReply(context.Background(), entities.UserCommandReply{
+		CorrelationID: "some-correlation-id",
+		Type:          entities.UserCommandCreate,
+		Status:        entities.UserCommandStatusSucceeded,
+		ProcessedAt:   time.Now(),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// redo rolls the last migration back and applies it again, which drops the user command replies
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateRedo)).To(Succeed())
+	_, err = adapter.GetUserCommandReply(context.Background(), "some-correlation-id")
+	g.Expect(err).To(Equal(entities.ErrUserCommandReplyNotFound))
+	_, err = adapter.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// rolling back every migration drops the users
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateDown)).To(Succeed())
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateDown)).To(Succeed())
+	_, err = adapter.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(Equal(entities.ErrUserNotFound))
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateUp)).To(Succeed())
+	_, err = adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestSQLiteAdapter_Migrate_UnknownCommand(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	err := adapter.Migrate(context.Background(), "sideways")
+	g.Expect(err).To(MatchError(ContainSubstring("unknown migrate command")))
+}
+
+func TestCreateMigration(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	err := adapters.CreateMigration(dir, "add_avatar")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	created, err := filepath.Glob(filepath.Join(dir, "*_add_avatar.sql"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(created).To(HaveLen(1))
+	contents, err := os.ReadFile(created[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(contents)).To(HavePrefix("-- +goose Up"))
+
+	// the other commands still run the embedded migrations afterwards
+	adapter := newTestSQLiteAdapter(g, t)
+	g.Expect(adapter.Migrate(context.Background(), adapters.MigrateStatus)).To(Succeed())
+}
+
+func TestCreateMigration_InvalidArgs(t *testing.T) {
+	g := NewWithT(t)
+
+	err := adapters.CreateMigration(t.TempDir(), "")
+	g.Expect(err).To(MatchError("create takes the name of the migration"))
+
+	err = adapters.CreateMigration(filepath.Join(t.TempDir(), "missing"), "add_avatar")
+	g.Expect(err).To(MatchError(ContainSubstring("migrations directory")))
+}
@@ -0,0 +1,203 @@
+package adapters
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+// encryptedValuePrefix marks values sealed by PIICipher, anything else is plaintext written before encryption was
+// turned on
+const encryptedValuePrefix = "enc:v1:"
+
+var (
+	errUnknownDataKey   = errors.New("data key not loaded")
+	errUnknownMasterKey = errors.New("master key not configured")
+	errNoActiveDataKey  = errors.New("no active data key loaded")
+)
+
+// DataKey is a key PII is encrypted with, stored wrapped by a master key
+type DataKey struct {
+	ID          uuid.UUID
+	MasterKeyID string
+	WrappedKey  string
+	CreatedAt   time.Time
+}
+
+// PIICipher encrypts PII columns using envelope encryption. Values are sealed with AES-256-GCM under a data key, and
+// data keys are stored wrapped by a master key from config, so rotating the master key only means rewrapping the data
+// keys rather than re-encrypting every row. Sealed values name the data key they were sealed with.
+//
+// Blind indexes are HMACs of values under a separate key, so values can be looked up and kept unique without being
+// decrypted.
+type PIICipher struct {
+	masterKeys         map[string]*AESGCMSecretBox
+	currentMasterKeyID string
+	blindIndexKey      []byte
+
+	mu              sync.RWMutex
+	dataKeys        map[uuid.UUID]*AESGCMSecretBox
+	activeDataKeyID uuid.UUID
+}
+
+// NewPIICipher creates a PIICipher from master keys given as "<id>:<key>". The first master key wraps new data keys,
+// the others are previous master keys kept until every data key has been rewrapped.
+func NewPIICipher(masterKeys []string, blindIndexKey string) (*PIICipher, error) {
+	if len(masterKeys) == 0 {
+		return nil, errors.New("at least one master key is required")
+	}
+
+	cipher := &PIICipher{
+		masterKeys:    map[string]*AESGCMSecretBox{},
+		blindIndexKey: []byte(blindIndexKey),
+		dataKeys:      map[uuid.UUID]*AESGCMSecretBox{},
+	}
+	for i, masterKey := range masterKeys {
+		id, key, found := strings.Cut(masterKey, ":")
+		if !found || id == "" || key == "" {
+			return nil, fmt.Errorf("master key %d isn't in the form <id>:<key>", i+1)
+		}
+		if i == 0 {
+			cipher.currentMasterKeyID = id
+		}
+		cipher.masterKeys[id] = NewAESGCMSecretBox(key)
+	}
+
+	return cipher, nil
+}
+
+// GenerateDataKey creates a data key wrapped by the current master key. It has to be stored and then loaded before it
+// is used, so that nothing is encrypted with a key that could be lost.
+func (c *PIICipher) GenerateDataKey(now time.Time) (*DataKey, error) {
+	rawKey := make([]byte, 32)
+	_, err := rand.Read(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := DataKey{
+		ID:          uuid.New(),
+		MasterKeyID: c.currentMasterKeyID,
+		CreatedAt:   now,
+	}
+	dataKey.WrappedKey, err = c.masterKeys[c.currentMasterKeyID].Seal(rawKey, dataKey.ID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataKey, nil
+}
+
+// LoadDataKey unwraps a stored data key, making it the active key if active is true
+func (c *PIICipher) LoadDataKey(dataKey DataKey, active bool) error {
+	rawKey, err := c.unwrap(dataKey)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dataKeys[dataKey.ID] = NewAESGCMSecretBox(string(rawKey))
+	if active {
+		c.activeDataKeyID = dataKey.ID
+	}
+
+	return nil
+}
+
+// Rewrap returns the data key wrapped by the current master key
+func (c *PIICipher) Rewrap(dataKey DataKey) (*DataKey, error) {
+	rawKey, err := c.unwrap(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey.MasterKeyID = c.currentMasterKeyID
+	dataKey.WrappedKey, err = c.masterKeys[c.currentMasterKeyID].Seal(rawKey, dataKey.ID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataKey, nil
+}
+
+func (c *PIICipher) unwrap(dataKey DataKey) ([]byte, error) {
+	masterKey, found := c.masterKeys[dataKey.MasterKeyID]
+	if !found {
+		return nil, fmt.Errorf("unwrapping data key %s: %w %q", dataKey.ID, errUnknownMasterKey, dataKey.MasterKeyID)
+	}
+
+	return masterKey.Open(dataKey.WrappedKey, dataKey.ID[:])
+}
+
+// Encrypt seals the value with the active data key. The associated data ties the value to the row and column it
+// belongs in, so it can't be copied into another.
+func (c *PIICipher) Encrypt(value string, associatedData string) (string, error) {
+	c.mu.RLock()
+	dataKeyID := c.activeDataKeyID
+	dataKey, found := c.dataKeys[dataKeyID]
+	c.mu.RUnlock()
+	if !found {
+		return "", errNoActiveDataKey
+	}
+
+	sealed, err := dataKey.Seal([]byte(value), []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedValuePrefix + dataKeyID.String() + ":" + sealed, nil
+}
+
+// Decrypt opens a value sealed by Encrypt, plaintext values are returned as they are. errUnknownDataKey is returned,
+// along with the ID of the key, if the value was sealed with a data key that hasn't been loaded.
+func (c *PIICipher) Decrypt(value string, associatedData string) (string, uuid.UUID, error) {
+	encrypted, found := strings.CutPrefix(value, encryptedValuePrefix)
+	if !found {
+		return value, uuid.Nil, nil
+	}
+
+	encodedDataKeyID, sealed, found := strings.Cut(encrypted, ":")
+	if !found {
+		return "", uuid.Nil, errSealedValueMalformed
+	}
+	dataKeyID, err := uuid.Parse(encodedDataKeyID)
+	if err != nil {
+		return "", uuid.Nil, errSealedValueMalformed
+	}
+
+	c.mu.RLock()
+	dataKey, found := c.dataKeys[dataKeyID]
+	c.mu.RUnlock()
+	if !found {
+		return "", dataKeyID, errUnknownDataKey
+	}
+
+	plaintext, err := dataKey.Open(sealed, []byte(associatedData))
+	if err != nil {
+		return "", dataKeyID, err
+	}
+
+	return string(plaintext), dataKeyID, nil
+}
+
+// BlindIndex returns the hex HMAC-SHA256 of the value, separated by column so that equal values in different columns
+// don't have equal indexes
+func (c *PIICipher) BlindIndex(column, value string) string {
+	mac := hmac.New(sha256.New, c.blindIndexKey)
+	mac.Write([]byte(column + ":" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// piiAssociatedData ties an encrypted value to its user and column, email and pending_email share a column name as
+// a confirmed email change moves the pending email's ciphertext into email
+func piiAssociatedData(userID uuid.UUID, column string) string {
+	return "platform_user:" + userID.String() + ":" + column
+}
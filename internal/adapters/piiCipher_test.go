@@ -0,0 +1,133 @@
+package adapters_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestPIICipher_Encrypt(t *testing.T) {
+	g := NewWithT(t)
+
+	cipher := newTestPIICipher(g)
+
+	encrypted, err := cipher.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(encrypted).To(HavePrefix("enc:v1:"))
+	g.Expect(encrypted).ToNot(ContainSubstring("alec@email.com"))
+
+	plaintext, _, err := cipher.Decrypt(encrypted, "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(plaintext).To(Equal("alec@email.com"))
+}
+
+func TestPIICipher_Encrypt_NoActiveDataKey(t *testing.T) {
+	g := NewWithT(t)
+
+	cipher, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	encrypted, err := cipher.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).To(MatchError("no active data key loaded"))
+	g.Expect(encrypted).To(BeEmpty())
+}
+
+func TestPIICipher_Decrypt_Plaintext(t *testing.T) {
+	g := NewWithT(t)
+
+	plaintext, _, err := newTestPIICipher(g).Decrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(plaintext).To(Equal("alec@email.com"))
+}
+
+func TestPIICipher_Decrypt_DifferentAssociatedData(t *testing.T) {
+	g := NewWithT(t)
+
+	cipher := newTestPIICipher(g)
+
+	encrypted, err := cipher.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	plaintext, _, err := cipher.Decrypt(encrypted, "another-user:email")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(plaintext).To(BeEmpty())
+}
+
+func TestPIICipher_Decrypt_UnloadedDataKey(t *testing.T) {
+	g := NewWithT(t)
+
+	other, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	dataKey, err := other.GenerateDataKey(time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(other.LoadDataKey(*dataKey, true)).To(Succeed())
+
+	encrypted, err := other.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	cipher := newTestPIICipher(g)
+	_, dataKeyID, err := cipher.Decrypt(encrypted, "some-user:email")
+	g.Expect(err).To(MatchError("data key not loaded"))
+	g.Expect(dataKeyID).To(Equal(dataKey.ID))
+
+	g.Expect(cipher.LoadDataKey(*dataKey, false)).To(Succeed())
+	plaintext, _, err := cipher.Decrypt(encrypted, "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(plaintext).To(Equal("alec@email.com"))
+}
+
+func TestPIICipher_Rewrap(t *testing.T) {
+	g := NewWithT(t)
+
+	previous, err := adapters.NewPIICipher([]string{"old:old-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	dataKey, err := previous.GenerateDataKey(time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(previous.LoadDataKey(*dataKey, true)).To(Succeed())
+	encrypted, err := previous.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	rotating, err := adapters.NewPIICipher([]string{"new:new-master-key", "old:old-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	rewrapped, err := rotating.Rewrap(*dataKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rewrapped.ID).To(Equal(dataKey.ID))
+	g.Expect(rewrapped.MasterKeyID).To(Equal("new"))
+
+	// once every data key is rewrapped the old master key can be dropped
+	rotated, err := adapters.NewPIICipher([]string{"new:new-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rotated.LoadDataKey(*dataKey, true)).To(MatchError(ContainSubstring("master key not configured")))
+	g.Expect(rotated.LoadDataKey(*rewrapped, true)).To(Succeed())
+
+	plaintext, _, err := rotated.Decrypt(encrypted, "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(plaintext).To(Equal("alec@email.com"))
+}
+
+func TestPIICipher_BlindIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	cipher := newTestPIICipher(g)
+
+	g.Expect(cipher.BlindIndex("email", "alec@email.com")).To(Equal(cipher.BlindIndex("email", "alec@email.com")))
+	g.Expect(cipher.BlindIndex("email", "alec@email.com")).ToNot(Equal(cipher.BlindIndex("email", "smith@email.com")))
+	g.Expect(cipher.BlindIndex("first_name", "alec")).ToNot(Equal(cipher.BlindIndex("last_name", "alec")))
+
+	other, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "another-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(other.BlindIndex("email", "alec@email.com")).ToNot(Equal(cipher.BlindIndex("email", "alec@email.com")))
+}
+
+func TestNewPIICipher_InvalidMasterKey(t *testing.T) {
+	g := NewWithT(t)
+
+	cipher, err := adapters.NewPIICipher([]string{"test:test-master-key", "no-id"}, "test-blind-index-key")
+	g.Expect(err).To(MatchError(`master key 2 isn't in the form <id>:<key>`))
+	g.Expect(cipher).To(BeNil())
+
+	cipher, err = adapters.NewPIICipher(nil, "test-blind-index-key")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(cipher).To(BeNil())
+}
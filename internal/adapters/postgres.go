@@ -4,18 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	migrations "github.com/AlecSmith96/faceit-user-service/db"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
 	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
 	"github.com/google/uuid"
-	"github.com/pressly/goose"
+	"github.com/lib/pq"
 	"log/slog"
+	"sort"
 	"strings"
 	"time"
 )
 
 type PostgresAdapter struct {
-	db *sql.DB
+	db  *sql.DB
+	pii *PIICipher
 }
 
 var _ usecases.UserCreator = &PostgresAdapter{}
@@ -23,14 +28,45 @@ var _ usecases.UserDeleter = &PostgresAdapter{}
 var _ usecases.UserUpdater = &PostgresAdapter{}
 var _ usecases.UserGetter = &PostgresAdapter{}
 var _ usecases.ReadinessChecker = &PostgresAdapter{}
+var _ usecases.UserStatusUpdater = &PostgresAdapter{}
+var _ usecases.ExpiredBanLifter = &PostgresAdapter{}
+var _ usecases.EmailVerificationStore = &PostgresAdapter{}
+var _ usecases.PasswordManager = &PostgresAdapter{}
+var _ usecases.EmailChangeStore = &PostgresAdapter{}
+var _ usecases.MFAStore = &PostgresAdapter{}
+var _ usecases.SessionStore = &PostgresAdapter{}
+var _ usecases.WebAuthnStore = &PostgresAdapter{}
+var _ usecases.OIDCStore = &PostgresAdapter{}
+var _ SigningKeyStore = &PostgresAdapter{}
+var _ usecases.LoginLimiter = &PostgresAdapter{}
+var _ usecases.LoginAuditStore = &PostgresAdapter{}
+var _ usecases.APIKeyStore = &PostgresAdapter{}
+var _ usecases.AuditLog = &PostgresAdapter{}
+var _ usecases.PIIMaintainer = &PostgresAdapter{}
+var _ usecases.UserImporter = &PostgresAdapter{}
+var _ usecases.ImportJobStore = &PostgresAdapter{}
+var _ usecases.UserExporter = &PostgresAdapter{}
+var _ usecases.UserBatchWriter = &PostgresAdapter{}
+var _ usecases.UserSearcher = &PostgresAdapter{}
+var _ usecases.UserCommandReplyStore = &PostgresAdapter{}
 
-func NewPostgresAdapter(db *sql.DB) *PostgresAdapter {
-	return &PostgresAdapter{db: db}
+// NewPostgresAdapter creates a PostgresAdapter that encrypts users' PII with pii, LoadDataKeys must be called before
+// users can be written
+func NewPostgresAdapter(db *sql.DB, pii *PIICipher) *PostgresAdapter {
+	return &PostgresAdapter{db: db, pii: pii}
 }
 
 // PerformDataMigration is a function that ensure that the database has had all migration ran against it on startup
-func (p *PostgresAdapter) PerformDataMigration(gooseDir string) error {
-	return goose.Up(p.db, gooseDir)
+func (p *PostgresAdapter) PerformDataMigration(ctx context.Context) error {
+	return p.Migrate(ctx, MigrateUp)
+}
+
+// Migrate runs a goose command against the embedded Postgres migrations while holding the migration lock, so an
+// instance starting while another migrates waits and then finds nothing left to do
+func (p *PostgresAdapter) Migrate(ctx context.Context, command string) error {
+	return p.WithMigrationLock(ctx, func() error {
+		return runMigration(ctx, p.db, "postgres", migrations.PostgresMigrations, migrations.PostgresMigrationsDir, command)
+	})
 }
 
 func encodePageToken(userID uuid.UUID, createdAt time.Time) string {
@@ -61,18 +97,40 @@ func decodePageToken(token string) (uuid.UUID, time.Time, error) {
 	return userID, createdAt, nil
 }
 
-func (p *PostgresAdapter) CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+// isEmailConflict reports whether err is a violation of the uniqueness of email addresses, which covers both the
+// email and pending_email columns
+func isEmailConflict(err error) bool {
+	return strings.Contains(err.Error(), "duplicate key value violates unique constraint \"platform_user_email_index_key\"") ||
+		strings.Contains(err.Error(), "duplicate key value violates unique constraint \"platform_user_pending_email_index_key\"")
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// piiField is an encrypted platform_user column along with the name its associated data is made with
+type piiField struct {
+	column string
+	value  *string
+}
+
+// scanUser reads a full platform_user row, in table column order, into a User, decrypting its PII
+func (p *PostgresAdapter) scanUser(ctx context.Context, row rowScanner) (*entities.User, error) {
 	var user entities.User
-	err := p.db.QueryRowContext(
-		ctx,
-		"INSERT INTO platform_user (first_name, last_name, nickname, password, email, country) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *",
-		firstName,
-		lastName,
-		nickname,
-		password,
-		email,
-		country,
-	).Scan(
+	// the blind indexes and search tokens are only used in queries
+	var blindIndexes [7]sql.NullString
+	err := row.Scan(
 		&user.ID,
 		&user.FirstName,
 		&user.LastName,
@@ -82,9 +140,193 @@ func (p *PostgresAdapter) CreateUser(ctx context.Context, firstName, lastName, n
 		&user.Country,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Status,
+		&user.BanReason,
+		&user.BanExpiresAt,
+		&user.EmailVerifiedAt,
+		&user.PasswordChangedAt,
+		&user.PendingEmail,
+		&blindIndexes[0],
+		&blindIndexes[1],
+		&blindIndexes[2],
+		&blindIndexes[3],
+		&blindIndexes[4],
+		&blindIndexes[5],
+		&blindIndexes[6],
 	)
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"platform_user_email_key\"") {
+		return nil, err
+	}
+
+	fields := []piiField{
+		{column: "first_name", value: &user.FirstName},
+		{column: "last_name", value: &user.LastName},
+		{column: "email", value: &user.Email},
+	}
+	if user.PendingEmail != nil {
+		// a confirmed email change moves the pending email's ciphertext into email, so they share associated data
+		fields = append(fields, piiField{column: "email", value: user.PendingEmail})
+	}
+	for _, field := range fields {
+		*field.value, err = p.decryptPII(ctx, *field.value, piiAssociatedData(user.ID, field.column))
+		if err != nil {
+			return nil, fmt.Errorf("decrypting %s: %w", field.column, err)
+		}
+	}
+
+	return &user, nil
+}
+
+// sealedUserPII is a user's PII encrypted for storage along with its blind indexes and search tokens
+type sealedUserPII struct {
+	firstName       string
+	lastName        string
+	email           string
+	firstNameIndex  string
+	lastNameIndex   string
+	emailIndex      string
+	firstNameTokens []string
+	lastNameTokens  []string
+	emailTokens     []string
+}
+
+func (p *PostgresAdapter) sealUserPII(userID uuid.UUID, firstName, lastName, email string) (*sealedUserPII, error) {
+	sealed := sealedUserPII{
+		firstNameIndex:  p.nameIndex("first_name", firstName),
+		lastNameIndex:   p.nameIndex("last_name", lastName),
+		firstNameTokens: p.searchTokens("first_name", firstName),
+		lastNameTokens:  p.searchTokens("last_name", lastName),
+		emailTokens:     p.searchTokens("email", email),
+	}
+
+	var err error
+	sealed.firstName, err = p.pii.Encrypt(firstName, piiAssociatedData(userID, "first_name"))
+	if err != nil {
+		return nil, fmt.Errorf("encrypting first_name: %w", err)
+	}
+
+	sealed.lastName, err = p.pii.Encrypt(lastName, piiAssociatedData(userID, "last_name"))
+	if err != nil {
+		return nil, fmt.Errorf("encrypting last_name: %w", err)
+	}
+
+	sealed.email, sealed.emailIndex, err = p.sealEmail(userID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sealed, nil
+}
+
+// sealEmail returns the email encrypted for either the email or pending_email column, and its blind index
+func (p *PostgresAdapter) sealEmail(userID uuid.UUID, email string) (string, string, error) {
+	encrypted, err := p.pii.Encrypt(email, piiAssociatedData(userID, "email"))
+	if err != nil {
+		return "", "", fmt.Errorf("encrypting email: %w", err)
+	}
+
+	return encrypted, p.emailIndex(email), nil
+}
+
+// emailIndex is shared by email and pending_email, as both are in one namespace and an email change moves one to the
+// other
+func (p *PostgresAdapter) emailIndex(email string) string {
+	return p.pii.BlindIndex("email", email)
+}
+
+// nameIndex ignores case, so that names can be searched for however they were capitalised
+func (p *PostgresAdapter) nameIndex(column, name string) string {
+	return p.pii.BlindIndex(column, strings.ToLower(name))
+}
+
+// searchTokenLength is the longest n-gram a value is given a search token for
+const searchTokenLength = 3
+
+// searchTokens returns the blind indexes of every n-gram, up to searchTokenLength long, of the lower case value. Any
+// part of the value has all of its filterTokens among them, so encrypted values can be filtered by part of them.
+func (p *PostgresAdapter) searchTokens(column, value string) []string {
+	runes := []rune(strings.ToLower(value))
+	tokens := []string{}
+	for n := 1; n <= searchTokenLength; n++ {
+		tokens = append(tokens, p.ngramTokens(column, runes, n)...)
+	}
+
+	return tokens
+}
+
+// filterTokens returns the search tokens a value has to have for the filter to be part of it. Having them doesn't
+// mean it is, as the filter's n-grams could be spread across the value, so matches are checked again once decrypted.
+func (p *PostgresAdapter) filterTokens(column, filter string) []string {
+	runes := []rune(strings.ToLower(filter))
+	if len(runes) < searchTokenLength {
+		return p.ngramTokens(column, runes, len(runes))
+	}
+
+	return p.ngramTokens(column, runes, searchTokenLength)
+}
+
+// ngramTokens returns the search tokens of the distinct n-grams of length n
+func (p *PostgresAdapter) ngramTokens(column string, runes []rune, n int) []string {
+	seen := map[string]bool{}
+	tokens := []string{}
+	for i := 0; i+n <= len(runes); i++ {
+		ngram := string(runes[i : i+n])
+		if seen[ngram] {
+			continue
+		}
+		seen[ngram] = true
+
+		// namespaced apart from the column's blind index, so a one to three character value's index isn't its token
+		tokens = append(tokens, p.pii.BlindIndex(column+"_token", ngram))
+	}
+
+	return tokens
+}
+
+// decryptPII decrypts a value, loading the data key it was encrypted with if another instance created it since this
+// one loaded its keys
+func (p *PostgresAdapter) decryptPII(ctx context.Context, value, associatedData string) (string, error) {
+	plaintext, dataKeyID, err := p.pii.Decrypt(value, associatedData)
+	if errors.Is(err, errUnknownDataKey) {
+		err = p.loadDataKey(ctx, dataKeyID)
+		if err != nil {
+			return "", err
+		}
+
+		plaintext, _, err = p.pii.Decrypt(value, associatedData)
+	}
+
+	return plaintext, err
+}
+
+func (p *PostgresAdapter) CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	userID := uuid.New()
+	sealed, err := p.sealUserPII(userID, firstName, lastName, email)
+	if err != nil {
+		slog.Debug("error encrypting user pii", "err", err)
+		return nil, err
+	}
+
+	user, err := p.scanUser(ctx, p.db.QueryRowContext(
+		ctx,
+		`INSERT INTO platform_user (id, first_name, last_name, nickname, password, email, country, email_index, first_name_index, last_name_index,
+		first_name_tokens, last_name_tokens, email_tokens) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING *`,
+		userID,
+		sealed.firstName,
+		sealed.lastName,
+		nickname,
+		password,
+		sealed.email,
+		country,
+		sealed.emailIndex,
+		sealed.firstNameIndex,
+		sealed.lastNameIndex,
+		pq.Array(sealed.firstNameTokens),
+		pq.Array(sealed.lastNameTokens),
+		pq.Array(sealed.emailTokens),
+	))
+	if err != nil {
+		if isEmailConflict(err) {
 			slog.Debug("email already registered to a user", "err", err)
 			return nil, entities.ErrEmailAlreadyUsed
 
@@ -93,11 +335,16 @@ func (p *PostgresAdapter) CreateUser(ctx context.Context, firstName, lastName, n
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (p *PostgresAdapter) DeleteUser(ctx context.Context, userID uuid.UUID) error {
-	result, err := p.db.Exec("DELETE FROM platform_user WHERE id = $1;", userID)
+	return deleteUser(ctx, p.db, userID)
+}
+
+// deleteUser deletes the user with either the database or a transaction
+func deleteUser(ctx context.Context, e execer, userID uuid.UUID) error {
+	result, err := e.ExecContext(ctx, "DELETE FROM platform_user WHERE id = $1;", userID)
 	if err != nil {
 		slog.Debug("unable to delete user", "err", err)
 		return err
@@ -118,19 +365,49 @@ func (p *PostgresAdapter) DeleteUser(ctx context.Context, userID uuid.UUID) erro
 }
 
 func (p *PostgresAdapter) UpdateUser(ctx context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
-	result, err := p.db.Query(
-		"UPDATE platform_user SET first_name = $2, last_name = $3, nickname = $4, password = $5, email = $6, country = $7, updated_at = $8 WHERE id = $1 RETURNING *",
+	return p.updateUser(ctx, p.db, entities.UserUpdate{
+		UserID:    userID,
+		FirstName: firstName,
+		LastName:  lastName,
+		Nickname:  nickname,
+		Password:  password,
+		Email:     email,
+		Country:   country,
+	})
+}
+
+// updateUser updates the user with either the database or a transaction
+func (p *PostgresAdapter) updateUser(ctx context.Context, q queryer, update entities.UserUpdate) (*entities.User, error) {
+	userID := update.UserID
+	sealed, err := p.sealUserPII(userID, update.FirstName, update.LastName, update.Email)
+	if err != nil {
+		slog.Debug("error encrypting user pii", "err", err)
+		return nil, err
+	}
+
+	result, err := q.QueryContext(
+		ctx,
+		`UPDATE platform_user SET first_name = $2, last_name = $3, nickname = $4, password = $5, email = $6, country = $7, updated_at = $8,
+		email_verified_at = CASE WHEN email_index = $9 THEN email_verified_at END, password_changed_at = CASE WHEN password = $5 THEN password_changed_at ELSE $8 END,
+		email_index = $9, first_name_index = $10, last_name_index = $11, first_name_tokens = $12, last_name_tokens = $13, email_tokens = $14
+		WHERE id = $1 RETURNING *`,
 		userID,
-		firstName,
-		lastName,
-		nickname,
-		password,
-		email,
-		country,
+		sealed.firstName,
+		sealed.lastName,
+		update.Nickname,
+		update.Password,
+		sealed.email,
+		update.Country,
 		time.Now(),
+		sealed.emailIndex,
+		sealed.firstNameIndex,
+		sealed.lastNameIndex,
+		pq.Array(sealed.firstNameTokens),
+		pq.Array(sealed.lastNameTokens),
+		pq.Array(sealed.emailTokens),
 	)
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"platform_user_email_key\"") {
+		if isEmailConflict(err) {
 			slog.Debug("email already registered to a user", "err", err)
 			return nil, entities.ErrEmailAlreadyUsed
 
@@ -138,30 +415,128 @@ func (p *PostgresAdapter) UpdateUser(ctx context.Context, userID uuid.UUID, firs
 		slog.Debug("error updating creator", "err", err)
 		return nil, err
 	}
+	// a transaction can't run its next query until the rows are closed
+	defer result.Close()
 
-	var user entities.User
-	if result.Next() {
-		err = result.Scan(
-			&user.ID,
-			&user.FirstName,
-			&user.LastName,
-			&user.Nickname,
-			&user.Password,
-			&user.Email,
-			&user.Country,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+	if !result.Next() {
+		slog.Debug("user not found", "userID", userID)
+		return nil, entities.ErrUserNotFound
+	}
+
+	user, err := p.scanUser(ctx, result)
+	if err != nil {
+		slog.Debug("marshalling user to struct", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (p *PostgresAdapter) UpdateUsers(ctx context.Context, updates []entities.UserUpdate, mode entities.BatchMode) ([]entities.BatchItemResult, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]entities.BatchItemResult, len(updates))
+	for i, update := range updates {
+		results[i].UserID = update.UserID
+		// a missing user or a changed status doesn't abort the transaction, so the rest of the batch can still be applied
+		user, err := p.updateUserInBatch(ctx, tx, update)
 		if err != nil {
-			slog.Debug("marshalling user to struct", "err", err)
+			if errors.Is(err, entities.ErrUserNotFound) || errors.Is(err, entities.ErrInvalidStatusTransition) {
+				results[i].Status = entities.BatchItemStatusFailed
+				results[i].Error = err.Error()
+				continue
+			}
+
+			slog.Debug("error updating user in batch", "err", err, "userID", update.UserID)
 			return nil, err
 		}
-	} else {
-		slog.Debug("user not found", "userID", userID)
-		return nil, entities.ErrUserNotFound
+
+		results[i].Status = entities.BatchItemStatusSucceeded
+		results[i].User = user
 	}
 
-	return &user, nil
+	return finishBatch(tx, results, mode)
+}
+
+// updateUserInBatch applies the profile and then the status of one update of a batch
+func (p *PostgresAdapter) updateUserInBatch(ctx context.Context, q queryer, update entities.UserUpdate) (*entities.User, error) {
+	var user *entities.User
+	if !update.StatusOnly {
+		var err error
+		user, err = p.updateUser(ctx, q, update)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Status == "" {
+		return user, nil
+	}
+
+	return p.updateUserStatus(ctx, q, update.UserID, update.FromStatus, update.Status, update.BanReason, update.BanExpiresAt)
+}
+
+func (p *PostgresAdapter) DeleteUsers(ctx context.Context, userIDs []uuid.UUID, mode entities.BatchMode) ([]entities.BatchItemResult, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]entities.BatchItemResult, len(userIDs))
+	for i, userID := range userIDs {
+		results[i].UserID = userID
+		err = deleteUser(ctx, tx, userID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				results[i].Status = entities.BatchItemStatusFailed
+				results[i].Error = err.Error()
+				continue
+			}
+
+			slog.Debug("error deleting user in batch", "err", err, "userID", userID)
+			return nil, err
+		}
+
+		results[i].Status = entities.BatchItemStatusSucceeded
+	}
+
+	return finishBatch(tx, results, mode)
+}
+
+// finishBatch commits a batch write, unless it is all or nothing and an item failed in which case the items that were
+// written are rolled back and reported as skipped
+func finishBatch(tx *sql.Tx, results []entities.BatchItemResult, mode entities.BatchMode) ([]entities.BatchItemResult, error) {
+	if mode == entities.BatchModeAllOrNothing {
+		for _, result := range results {
+			if result.Status != entities.BatchItemStatusFailed {
+				continue
+			}
+
+			for i := range results {
+				if results[i].Status == entities.BatchItemStatusSucceeded {
+					results[i].Status = entities.BatchItemStatusSkipped
+					results[i].User = nil
+				}
+			}
+			// the deferred rollback undoes the rest of the batch
+			return results, nil
+		}
+	}
+
+	err := tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return results, nil
 }
 
 func (p *PostgresAdapter) GetPaginatedUsers(
@@ -184,19 +559,74 @@ func (p *PostgresAdapter) GetPaginatedUsers(
 		}
 	}
 
-	queryString := `SELECT * FROM platform_user WHERE 1=1 `
+	// users whose search tokens match but whose names or emails don't are dropped once decrypted, so batches are
+	// fetched until the page is full or there are no more users
+	users := make([]entities.User, 0)
+	after := pageInfo.NextPageToken != ""
+	for {
+		filterClause, queryParams := p.userFilterClause(firstName, lastName, nickname, email, country)
+		queryString := `SELECT * FROM platform_user WHERE 1=1 ` + filterClause
+		queryParamIndex := len(queryParams) + 1
+		if after {
+			queryString += fmt.Sprintf(`AND (created_at, id) > ($%d, $%d)`, queryParamIndex, queryParamIndex+1)
+			queryParams = append(queryParams, createdAtPageToken, userID)
+		}
+
+		queryString += fmt.Sprintf(` ORDER BY created_at, id LIMIT %d;`, pageInfo.PageSize)
+		rows, err := p.db.Query(queryString, queryParams...)
+		if err != nil {
+			slog.Debug("error updating creator", "err", err)
+			return nil, "", err
+		}
+
+		fetched := 0
+		for len(users) < pageInfo.PageSize && rows.Next() {
+			user, err := p.scanUser(ctx, rows)
+			if err != nil {
+				rows.Close()
+				slog.Debug("marshalling user to struct", "err", err)
+				return nil, "", err
+			}
+			fetched++
+			userID, createdAtPageToken, after = user.ID, user.CreatedAt, true
+
+			if matchesUserFilter(*user, firstName, lastName, email) {
+				users = append(users, *user)
+			}
+		}
+		rows.Close()
+
+		if fetched < pageInfo.PageSize || len(users) == pageInfo.PageSize {
+			break
+		}
+	}
+
+	if len(users) == 0 || len(users) < pageInfo.PageSize {
+		return users, "", nil
+	}
+
+	lastUser := users[len(users)-1]
+	nextPageToken := encodePageToken(lastUser.ID, lastUser.CreatedAt)
+
+	return users, nextPageToken, nil
+}
+
+// userFilterClause returns the conditions users are filtered by, each starting with AND, and their parameters from $1.
+// Names and emails are encrypted, so the users it matches may still have to be dropped by matchesUserFilter.
+func (p *PostgresAdapter) userFilterClause(firstName, lastName, nickname, email, country string) (string, []any) {
+	queryString := ""
 	queryParamIndex := 1
 	queryParams := make([]any, 0)
 	if firstName != "" {
-		queryString += fmt.Sprintf("AND first_name ILIKE $%d ", queryParamIndex)
+		queryString += fmt.Sprintf("AND first_name_tokens @> $%d ", queryParamIndex)
 		queryParamIndex++
-		queryParams = append(queryParams, "%"+firstName+"%")
+		queryParams = append(queryParams, pq.Array(p.filterTokens("first_name", firstName)))
 	}
 
 	if lastName != "" {
-		queryString += fmt.Sprintf("AND last_name ILIKE $%d ", queryParamIndex)
+		queryString += fmt.Sprintf("AND last_name_tokens @> $%d ", queryParamIndex)
 		queryParamIndex++
-		queryParams = append(queryParams, "%"+lastName+"%")
+		queryParams = append(queryParams, pq.Array(p.filterTokens("last_name", lastName)))
 	}
 
 	if nickname != "" {
@@ -206,59 +636,2812 @@ func (p *PostgresAdapter) GetPaginatedUsers(
 	}
 
 	if email != "" {
-		queryString += fmt.Sprintf("AND email ILIKE $%d ", queryParamIndex)
+		queryString += fmt.Sprintf("AND email_tokens @> $%d ", queryParamIndex)
 		queryParamIndex++
-		queryParams = append(queryParams, "%"+email+"%")
+		queryParams = append(queryParams, pq.Array(p.filterTokens("email", email)))
 	}
 
 	if country != "" {
 		queryString += fmt.Sprintf("AND country ILIKE $%d ", queryParamIndex)
-		queryParamIndex++
 		queryParams = append(queryParams, "%"+country+"%")
 	}
 
-	if pageInfo.NextPageToken != "" {
-		queryString += fmt.Sprintf(`AND (created_at, id) > ($%d, $%d)`, queryParamIndex, queryParamIndex+1)
-		queryParams = append(queryParams, createdAtPageToken, userID)
-	}
+	return queryString, queryParams
+}
+
+// matchesUserFilter checks the decrypted names and email of a user matched by userFilterClause's search tokens contain
+// their filters, ignoring case
+func matchesUserFilter(user entities.User, firstName, lastName, email string) bool {
+	return containsFold(user.FirstName, firstName) && containsFold(user.LastName, lastName) && containsFold(user.Email, email)
+}
+
+// extraColumnsScanner scans rows that have columns after the user's, from a query selecting more than SELECT *
+type extraColumnsScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func (e extraColumnsScanner) Scan(dest ...any) error {
+	return e.row.Scan(append(dest, e.extra...)...)
+}
+
+// searchCandidatesPerResult is how many users SearchUsers fetches for each result it returns. Names and emails are
+// encrypted, so Postgres can only rank them roughly and they are scored properly once decrypted.
+const searchCandidatesPerResult = 5
+
+// SearchUsers matches nicknames with pg_trgm's word similarity, which tolerates typos, or full text search. Names and
+// emails are encrypted, so users sharing an n-gram search token with a word of the query are fetched, ranked by how many
+// they share, then decrypted and scored with the same word similarity against the whole query and each of its words. A
+// user's score is its best field, with ties going to users matching more fields.
+func (p *PostgresAdapter) SearchUsers(ctx context.Context, query string, limit int) ([]entities.UserSearchResult, error) {
+	query = strings.TrimSpace(query)
+	terms := append([]string{query}, strings.Fields(query)...)
+	firstNameTokens := p.searchQueryTokens("first_name", query)
+	lastNameTokens := p.searchQueryTokens("last_name", query)
+	emailTokens := p.searchQueryTokens("email", query)
 
-	queryString += fmt.Sprintf(` ORDER BY created_at, id LIMIT %d;`, pageInfo.PageSize)
-	rows, err := p.db.Query(queryString, queryParams...)
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT * FROM (
+			SELECT *,
+				$1 <% nickname OR to_tsvector('simple', nickname) @@ websearch_to_tsquery('simple', $1) AS nickname_matched,
+				word_similarity($1, nickname) AS nickname_score,
+				cardinality(ARRAY(SELECT unnest(first_name_tokens) INTERSECT SELECT unnest($2::text[]))) AS first_name_shared,
+				cardinality(ARRAY(SELECT unnest(last_name_tokens) INTERSECT SELECT unnest($3::text[]))) AS last_name_shared,
+				cardinality(ARRAY(SELECT unnest(email_tokens) INTERSECT SELECT unnest($4::text[]))) AS email_shared
+			FROM platform_user
+			WHERE $1 <% nickname OR to_tsvector('simple', nickname) @@ websearch_to_tsquery('simple', $1)
+				OR first_name_tokens && $2 OR last_name_tokens && $3 OR email_tokens && $4
+		) matched
+		ORDER BY GREATEST(
+				CASE WHEN nickname_matched THEN nickname_score ELSE 0 END,
+				GREATEST(first_name_shared, last_name_shared, email_shared)::float8 / $5
+			) DESC,
+			created_at, id
+		LIMIT $6;`,
+		query,
+		pq.Array(firstNameTokens),
+		pq.Array(lastNameTokens),
+		pq.Array(emailTokens),
+		max(len(firstNameTokens), 1),
+		limit*searchCandidatesPerResult,
+	)
 	if err != nil {
-		slog.Debug("error updating creator", "err", err)
-		return nil, "", err
+		slog.Debug("error searching users", "err", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	users := make([]entities.User, 0)
+	results := []entities.UserSearchResult{}
 	for rows.Next() {
-		var user entities.User
-		err = rows.Scan(
-			&user.ID,
-			&user.FirstName,
-			&user.LastName,
-			&user.Nickname,
-			&user.Password,
-			&user.Email,
-			&user.Country,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		var nicknameMatched bool
+		var nicknameScore float64
+		var firstNameShared, lastNameShared, emailShared int
+		user, err := p.scanUser(ctx, extraColumnsScanner{
+			row:   rows,
+			extra: []any{&nicknameMatched, &nicknameScore, &firstNameShared, &lastNameShared, &emailShared},
+		})
 		if err != nil {
 			slog.Debug("marshalling user to struct", "err", err)
-			return nil, "", err
+			return nil, err
+		}
+
+		result := entities.UserSearchResult{User: *user, MatchedFields: []string{}}
+		if nicknameMatched {
+			result.MatchedFields = append(result.MatchedFields, entities.SearchFieldNickname)
+			result.Score = nicknameScore
+		}
+		for _, field := range []struct {
+			name  string
+			value string
+		}{
+			{entities.SearchFieldFirstName, user.FirstName},
+			{entities.SearchFieldLastName, user.LastName},
+			{entities.SearchFieldEmail, user.Email},
+		} {
+			score := 0.0
+			for _, term := range terms {
+				score = max(score, entities.WordSimilarity(term, field.value))
+			}
+			if score >= entities.SearchSimilarityThreshold {
+				result.MatchedFields = append(result.MatchedFields, field.name)
+				result.Score = max(result.Score, score)
+			}
 		}
 
-		users = append(users, user)
+		// sharing search tokens only means a user might match
+		if len(result.MatchedFields) == 0 {
+			continue
+		}
+		results = append(results, result)
 	}
 
-	if len(users) == 0 || len(users) < pageInfo.PageSize {
-		return users, "", nil
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating users", "err", err)
+		return nil, err
 	}
 
-	lastUser := users[len(users)-1]
-	nextPageToken := encodePageToken(lastUser.ID, lastUser.CreatedAt)
+	// stable, so users that score the same stay in the order they were created
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return len(results[i].MatchedFields) > len(results[j].MatchedFields)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
 
-	return users, nextPageToken, nil
+	return results, nil
+}
+
+// searchQueryTokens returns the search tokens of the bigrams and trigrams of each word of a search query, or of a one
+// letter word itself. A value sharing none of them can't be similar enough to any of the words to match.
+func (p *PostgresAdapter) searchQueryTokens(column, query string) []string {
+	seen := map[string]bool{}
+	tokens := []string{}
+	for _, word := range entities.SearchWords(query) {
+		runes := []rune(word.Text)
+		for n := min(2, len(runes)); n <= min(searchTokenLength, len(runes)); n++ {
+			for _, token := range p.ngramTokens(column, runes, n) {
+				if !seen[token] {
+					seen[token] = true
+					tokens = append(tokens, token)
+				}
+			}
+		}
+	}
+
+	return tokens
+}
+
+// exportFetchSize is how many users ExportUsers fetches from its cursor at a time
+const exportFetchSize = 1000
+
+// ExportUsers reads the users through a cursor, exportFetchSize at a time, so only one batch is held in memory. The
+// cursor is declared in a read only transaction, which keeps the export a consistent snapshot however long it runs.
+func (p *PostgresAdapter) ExportUsers(
+	ctx context.Context,
+	firstName,
+	lastName,
+	nickname,
+	email,
+	country string,
+	each func(entities.User) error,
+) error {
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	filterClause, queryParams := p.userFilterClause(firstName, lastName, nickname, email, country)
+	_, err = tx.ExecContext(
+		ctx,
+		`DECLARE user_export NO SCROLL CURSOR FOR SELECT * FROM platform_user WHERE 1=1 `+filterClause+`ORDER BY created_at, id`,
+		queryParams...,
+	)
+	if err != nil {
+		slog.Debug("error declaring export cursor", "err", err)
+		return err
+	}
+
+	matching := func(user entities.User) error {
+		if !matchesUserFilter(user, firstName, lastName, email) {
+			return nil
+		}
+
+		return each(user)
+	}
+	for {
+		fetched, err := p.fetchExportedUsers(ctx, tx, matching)
+		if err != nil {
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// fetchExportedUsers fetches the next batch from the export cursor, returning how many users it held
+func (p *PostgresAdapter) fetchExportedUsers(ctx context.Context, tx *sql.Tx, each func(entities.User) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH FORWARD %d FROM user_export", exportFetchSize))
+	if err != nil {
+		slog.Debug("error fetching from export cursor", "err", err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		user, err := p.scanUser(ctx, rows)
+		if err != nil {
+			slog.Debug("marshalling user to struct", "err", err)
+			return 0, err
+		}
+		fetched++
+
+		err = each(*user)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating export cursor", "err", err)
+		return 0, err
+	}
+
+	return fetched, nil
+}
+
+func (p *PostgresAdapter) GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := p.scanUser(ctx, p.db.QueryRowContext(ctx, "SELECT * FROM platform_user WHERE id = $1;", userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "userID", userID)
+			return nil, entities.ErrUserNotFound
+		}
+		slog.Debug("error getting user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUsersByIDs looks every user up in one query rather than a round trip each
+func (p *PostgresAdapter) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT * FROM platform_user WHERE id = ANY($1);", pq.Array(userIDs))
+	if err != nil {
+		slog.Debug("error getting users by ids", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]entities.User, 0, len(userIDs))
+	for rows.Next() {
+		user, err := p.scanUser(ctx, rows)
+		if err != nil {
+			slog.Debug("marshalling user to struct", "err", err)
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating users", "err", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (p *PostgresAdapter) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	user, err := p.scanUser(ctx, p.db.QueryRowContext(ctx, "SELECT * FROM platform_user WHERE email_index = $1;", p.emailIndex(email)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "email", email)
+			return nil, entities.ErrUserNotFound
+		}
+		slog.Debug("error getting user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdateUserStatus moves a user from one status to another. The update only applies if the user is still in the
+// expected status, so that two concurrent transitions can't both succeed.
+func (p *PostgresAdapter) UpdateUserStatus(
+	ctx context.Context,
+	userID uuid.UUID,
+	from,
+	to entities.UserStatus,
+	banReason string,
+	banExpiresAt *time.Time,
+) (*entities.User, error) {
+	return p.updateUserStatus(ctx, p.db, userID, from, to, banReason, banExpiresAt)
+}
+
+func (p *PostgresAdapter) updateUserStatus(
+	ctx context.Context,
+	q queryer,
+	userID uuid.UUID,
+	from,
+	to entities.UserStatus,
+	banReason string,
+	banExpiresAt *time.Time,
+) (*entities.User, error) {
+	result, err := q.QueryContext(
+		ctx,
+		"UPDATE platform_user SET status = $3, ban_reason = $4, ban_expires_at = $5, updated_at = $6 WHERE id = $1 AND status = $2 RETURNING *",
+		userID,
+		from,
+		to,
+		banReason,
+		banExpiresAt,
+		time.Now(),
+	)
+	if err != nil {
+		slog.Debug("error updating user status", "err", err)
+		return nil, err
+	}
+	// a transaction can't run its next query until the rows are closed
+	defer result.Close()
+
+	if !result.Next() {
+		if err = result.Err(); err != nil {
+			slog.Debug("error updating user status", "err", err)
+			return nil, err
+		}
+		slog.Debug("user status changed before transition could be applied", "userID", userID, "from", from, "to", to)
+		return nil, entities.ErrInvalidStatusTransition
+	}
+
+	user, err := p.scanUser(ctx, result)
+	if err != nil {
+		slog.Debug("marshalling user to struct", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// LiftExpiredBans reactivates every banned user whose ban expired at or before now, returning their IDs. Users that
+// never verified their email go back to pending verification rather than active.
+func (p *PostgresAdapter) LiftExpiredBans(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		"UPDATE platform_user SET status = CASE WHEN email_verified_at IS NULL THEN $1 ELSE $2 END, ban_reason = '', ban_expires_at = NULL, updated_at = $3 WHERE status = $4 AND ban_expires_at <= $3 RETURNING id",
+		entities.UserStatusPendingVerification,
+		entities.UserStatusActive,
+		now,
+		entities.UserStatusBanned,
+	)
+	if err != nil {
+		slog.Debug("error lifting expired bans", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var userID uuid.UUID
+		err = rows.Scan(&userID)
+		if err != nil {
+			slog.Debug("scanning unbanned user id", "err", err)
+			return nil, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+func (p *PostgresAdapter) CreateVerificationToken(ctx context.Context, token entities.VerificationToken) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO verification_token (id, user_id, email, purpose, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		token.ID,
+		token.UserID,
+		token.Email,
+		token.Purpose,
+		token.ExpiresAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting verification token", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// redeemVerificationToken marks a stored verification token as used, failing with ErrInvalidToken if it has already
+// been used, has expired or doesn't match the stored token
+func redeemVerificationToken(ctx context.Context, tx execer, token entities.VerificationToken, now time.Time) error {
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE verification_token SET used_at = $2 WHERE id = $1 AND user_id = $3 AND purpose = $4 AND used_at IS NULL AND expires_at > $2",
+		token.ID,
+		now,
+		token.UserID,
+		token.Purpose,
+	)
+	if err != nil {
+		slog.Debug("error redeeming verification token", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("verification token not redeemable", "tokenID", token.ID)
+		return entities.ErrInvalidToken
+	}
+
+	return nil
+}
+
+// RedeemVerificationToken marks a stored verification token as used, for tokens that don't change anything else when
+// they are redeemed
+func (p *PostgresAdapter) RedeemVerificationToken(ctx context.Context, token entities.VerificationToken, now time.Time) error {
+	return redeemVerificationToken(ctx, p.db, token, now)
+}
+
+// VerifyEmail redeems an email verification token and marks the user's email as verified. The token is only accepted
+// if it hasn't been used or expired and the user still has the email address it was issued for.
+func (p *PostgresAdapter) VerifyEmail(ctx context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = redeemVerificationToken(ctx, tx, token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := p.scanUser(ctx, tx.QueryRowContext(
+		ctx,
+		"UPDATE platform_user SET email_verified_at = $3, status = CASE WHEN status = $4 THEN $5 ELSE status END, updated_at = $3 WHERE id = $1 AND email_index = $2 RETURNING *",
+		token.UserID,
+		p.emailIndex(token.Email),
+		now,
+		entities.UserStatusPendingVerification,
+		entities.UserStatusActive,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user email changed since verification token was issued", "userID", token.UserID)
+			return nil, entities.ErrInvalidToken
+		}
+		slog.Debug("error verifying user email", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RequestEmailChange sets the user's pending email and stores the tokens used to confirm or revert the change. Any
+// earlier email change that hasn't been confirmed is superseded.
+func (p *PostgresAdapter) RequestEmailChange(
+	ctx context.Context,
+	userID uuid.UUID,
+	newEmail string,
+	confirmToken,
+	revertToken entities.VerificationToken,
+) (*entities.User, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sealedEmail, emailIndex, err := p.sealEmail(userID, newEmail)
+	if err != nil {
+		slog.Debug("error encrypting pending email", "err", err)
+		return nil, err
+	}
+
+	user, err := p.scanUser(ctx, tx.QueryRowContext(
+		ctx,
+		"UPDATE platform_user SET pending_email = $2, pending_email_index = $4, updated_at = $3 WHERE id = $1 RETURNING *",
+		userID,
+		sealedEmail,
+		time.Now(),
+		emailIndex,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "userID", userID)
+			return nil, entities.ErrUserNotFound
+		}
+		if isEmailConflict(err) {
+			slog.Debug("email already registered to a user", "err", err)
+			return nil, entities.ErrEmailAlreadyUsed
+		}
+		slog.Debug("error setting pending email", "err", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE verification_token SET used_at = $2 WHERE user_id = $1 AND purpose = $3 AND used_at IS NULL",
+		userID,
+		time.Now(),
+		entities.TokenPurposeEmailChange,
+	)
+	if err != nil {
+		slog.Debug("error invalidating email change tokens", "err", err)
+		return nil, err
+	}
+
+	for _, token := range []entities.VerificationToken{confirmToken, revertToken} {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO verification_token (id, user_id, email, purpose, expires_at) VALUES ($1, $2, $3, $4, $5)",
+			token.ID,
+			token.UserID,
+			token.Email,
+			token.Purpose,
+			token.ExpiresAt,
+		)
+		if err != nil {
+			slog.Debug("error inserting verification token", "err", err)
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ConfirmEmailChange redeems an email change token and swaps the user's email for the pending one. Confirming proves
+// control of the new address so it is marked as verified.
+func (p *PostgresAdapter) ConfirmEmailChange(ctx context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = redeemVerificationToken(ctx, tx, token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := p.scanUser(ctx, tx.QueryRowContext(
+		ctx,
+		`UPDATE platform_user SET email = pending_email, email_index = pending_email_index, pending_email = NULL, pending_email_index = NULL,
+		email_tokens = $4, email_verified_at = $3, updated_at = $3 WHERE id = $1 AND pending_email_index = $2 RETURNING *`,
+		token.UserID,
+		p.emailIndex(token.Email),
+		now,
+		pq.Array(p.searchTokens("email", token.Email)),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("pending email changed since token was issued", "userID", token.UserID)
+			return nil, entities.ErrInvalidToken
+		}
+		if isEmailConflict(err) {
+			slog.Debug("email already registered to a user", "err", err)
+			return nil, entities.ErrEmailAlreadyUsed
+		}
+		slog.Debug("error confirming email change", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RevertEmailChange redeems an email change revert token, restoring the user's previous email whether or not the
+// change had been confirmed, and cancelling any pending change.
+func (p *PostgresAdapter) RevertEmailChange(ctx context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = redeemVerificationToken(ctx, tx, token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE verification_token SET used_at = $2 WHERE user_id = $1 AND purpose = $3 AND used_at IS NULL",
+		token.UserID,
+		now,
+		entities.TokenPurposeEmailChange,
+	)
+	if err != nil {
+		slog.Debug("error invalidating email change tokens", "err", err)
+		return nil, err
+	}
+
+	sealedEmail, emailIndex, err := p.sealEmail(token.UserID, token.Email)
+	if err != nil {
+		slog.Debug("error encrypting email", "err", err)
+		return nil, err
+	}
+
+	user, err := p.scanUser(ctx, tx.QueryRowContext(
+		ctx,
+		`UPDATE platform_user SET email = $2, email_index = $4, email_tokens = $5, pending_email = NULL, pending_email_index = NULL,
+		email_verified_at = $3, updated_at = $3 WHERE id = $1 RETURNING *`,
+		token.UserID,
+		sealedEmail,
+		now,
+		emailIndex,
+		pq.Array(p.searchTokens("email", token.Email)),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "userID", token.UserID)
+			return nil, entities.ErrInvalidToken
+		}
+		if isEmailConflict(err) {
+			slog.Debug("email already registered to a user", "err", err)
+			return nil, entities.ErrEmailAlreadyUsed
+		}
+		slog.Debug("error reverting email change", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (p *PostgresAdapter) CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO password_reset_token (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash,
+		userID,
+		expiresAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting password reset token", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetPasswordResetTokenUser returns the user a redeemable password reset token was issued to, without redeeming it
+func (p *PostgresAdapter) GetPasswordResetTokenUser(ctx context.Context, tokenHash string, now time.Time) (*entities.User, error) {
+	user, err := p.scanUser(ctx, p.db.QueryRowContext(
+		ctx,
+		`SELECT platform_user.* FROM platform_user JOIN password_reset_token ON password_reset_token.user_id = platform_user.id
+		WHERE password_reset_token.token_hash = $1 AND password_reset_token.used_at IS NULL AND password_reset_token.expires_at > $2`,
+		tokenHash,
+		now,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("password reset token not redeemable")
+			return nil, entities.ErrInvalidToken
+		}
+		slog.Debug("error getting password reset token user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ResetPassword redeems a password reset token and sets the user's new password. Every other outstanding reset token
+// for the user is invalidated along with it, and their sessions are revoked.
+func (p *PostgresAdapter) ResetPassword(ctx context.Context, tokenHash, newPassword string, now time.Time) (*entities.User, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	err = tx.QueryRowContext(
+		ctx,
+		"UPDATE password_reset_token SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 RETURNING user_id",
+		tokenHash,
+		now,
+	).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("password reset token not redeemable")
+			return nil, entities.ErrInvalidToken
+		}
+		slog.Debug("error redeeming password reset token", "err", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE password_reset_token SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL", userID, now)
+	if err != nil {
+		slog.Debug("error invalidating password reset tokens", "err", err)
+		return nil, err
+	}
+
+	user, err := p.scanUser(ctx, tx.QueryRowContext(
+		ctx,
+		"UPDATE platform_user SET password = $2, password_changed_at = $3, updated_at = $3 WHERE id = $1 RETURNING *",
+		userID,
+		newPassword,
+		now,
+	))
+	if err != nil {
+		slog.Debug("error updating password", "err", err)
+		return nil, err
+	}
+
+	_, err = revokeSessions(ctx, tx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ChangePassword sets the user's new password and revokes their sessions, so they have to log in again everywhere
+func (p *PostgresAdapter) ChangePassword(ctx context.Context, userID uuid.UUID, newPassword string, now time.Time) (*entities.User, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := p.scanUser(ctx, tx.QueryRowContext(
+		ctx,
+		"UPDATE platform_user SET password = $2, password_changed_at = $3, updated_at = $3 WHERE id = $1 RETURNING *",
+		userID,
+		newPassword,
+		now,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "userID", userID)
+			return nil, entities.ErrUserNotFound
+		}
+		slog.Debug("error updating password", "err", err)
+		return nil, err
+	}
+
+	_, err = revokeSessions(ctx, tx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetTOTPEnrolment returns the user's TOTP enrolment, confirmed or not
+func (p *PostgresAdapter) GetTOTPEnrolment(ctx context.Context, userID uuid.UUID) (*entities.TOTPEnrolment, error) {
+	var enrolment entities.TOTPEnrolment
+	err := p.db.QueryRowContext(
+		ctx,
+		"SELECT user_id, encrypted_secret, confirmed_at, last_used_counter, created_at FROM mfa_totp WHERE user_id = $1",
+		userID,
+	).Scan(
+		&enrolment.UserID,
+		&enrolment.EncryptedSecret,
+		&enrolment.ConfirmedAt,
+		&enrolment.LastUsedCounter,
+		&enrolment.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("totp not enrolled", "userID", userID)
+			return nil, entities.ErrMFANotEnrolled
+		}
+		slog.Debug("error getting totp enrolment", "err", err)
+		return nil, err
+	}
+
+	return &enrolment, nil
+}
+
+// EnrolTOTP stores a new TOTP secret for the user, replacing one that hasn't been confirmed. A confirmed enrolment is
+// left alone and ErrMFAAlreadyEnabled returned.
+func (p *PostgresAdapter) EnrolTOTP(ctx context.Context, userID uuid.UUID, encryptedSecret string, now time.Time) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO mfa_totp (user_id, encrypted_secret, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET encrypted_secret = EXCLUDED.encrypted_secret, last_used_counter = NULL, created_at = EXCLUDED.created_at
+		WHERE mfa_totp.confirmed_at IS NULL`,
+		userID,
+		encryptedSecret,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error enrolling totp", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("totp already enabled", "userID", userID)
+		return entities.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// ConfirmTOTP enables the user's TOTP enrolment, recording the time step of the code it was confirmed with, and
+// replaces their recovery codes
+func (p *PostgresAdapter) ConfirmTOTP(ctx context.Context, userID uuid.UUID, counter int64, recoveryCodeHashes []string, now time.Time) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE mfa_totp SET confirmed_at = $2, last_used_counter = $3 WHERE user_id = $1 AND confirmed_at IS NULL",
+		userID,
+		now,
+		counter,
+	)
+	if err != nil {
+		slog.Debug("error confirming totp", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("totp already enabled", "userID", userID)
+		return entities.ErrMFAAlreadyEnabled
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// DisableTOTP removes the user's TOTP enrolment along with their recovery codes
+func (p *PostgresAdapter) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM mfa_totp WHERE user_id = $1", userID)
+	if err != nil {
+		slog.Debug("error deleting totp enrolment", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("totp not enrolled", "userID", userID)
+		return entities.ErrMFANotEnrolled
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM mfa_recovery_code WHERE user_id = $1", userID)
+	if err != nil {
+		slog.Debug("error deleting recovery codes", "err", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes deletes all of the user's recovery codes, used or not, and stores the new ones
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID uuid.UUID, recoveryCodeHashes []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_code WHERE user_id = $1", userID)
+	if err != nil {
+		slog.Debug("error deleting recovery codes", "err", err)
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO mfa_recovery_code (code_hash, user_id) VALUES ($1, $2)",
+			codeHash,
+			userID,
+		)
+		if err != nil {
+			slog.Debug("error inserting recovery code", "err", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseTOTPCounter records the time step of an accepted TOTP code, failing with ErrInvalidMFACode if a code from that
+// time step or a later one has already been used
+func (p *PostgresAdapter) UseTOTPCounter(ctx context.Context, userID uuid.UUID, counter int64) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		"UPDATE mfa_totp SET last_used_counter = $2 WHERE user_id = $1 AND confirmed_at IS NOT NULL AND (last_used_counter IS NULL OR last_used_counter < $2)",
+		userID,
+		counter,
+	)
+	if err != nil {
+		slog.Debug("error recording totp counter", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("totp code replayed", "userID", userID)
+		return entities.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks one of the user's recovery codes as used, failing with ErrInvalidMFACode if it doesn't exist or
+// has already been used
+func (p *PostgresAdapter) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		"UPDATE mfa_recovery_code SET used_at = $3 WHERE code_hash = $1 AND user_id = $2 AND used_at IS NULL",
+		codeHash,
+		userID,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error using recovery code", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("recovery code not usable", "userID", userID)
+		return entities.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) CreateSession(ctx context.Context, session entities.Session, refreshTokenHash string) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO user_session (id, user_id, refresh_token_hash, device_name, user_agent, ip_address, scope, created_at,
+		expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		session.ID,
+		session.UserID,
+		refreshTokenHash,
+		session.Device.Name,
+		session.Device.UserAgent,
+		session.Device.IPAddress,
+		session.Scope,
+		session.CreatedAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting session", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetSessions returns the user's sessions that haven't expired or been revoked, oldest first
+func (p *PostgresAdapter) GetSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]entities.Session, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT `+sessionColumns+` FROM user_session WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY created_at, id`,
+		userID,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error getting sessions", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []entities.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			slog.Debug("error scanning session", "err", err)
+			return nil, err
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating sessions", "err", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RefreshSession replaces a usable session's refresh token hash, recording where and when it was used. Refresh tokens
+// that don't match a session that hasn't expired or been revoked return ErrInvalidToken.
+func (p *PostgresAdapter) RefreshSession(
+	ctx context.Context,
+	refreshTokenHash,
+	newRefreshTokenHash string,
+	device entities.SessionDevice,
+	now time.Time,
+) (*entities.Session, error) {
+	session, err := scanSession(p.db.QueryRowContext(
+		ctx,
+		`UPDATE user_session SET refresh_token_hash = $2, user_agent = $3, ip_address = $4, last_used_at = $5
+		WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > $5 RETURNING `+sessionColumns,
+		refreshTokenHash,
+		newRefreshTokenHash,
+		device.UserAgent,
+		device.IPAddress,
+		now,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("refresh token not usable")
+			return nil, entities.ErrInvalidToken
+		}
+		slog.Debug("error refreshing session", "err", err)
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RevokeSession ends one of the user's sessions, returning ErrSessionNotFound if it isn't theirs or has already ended
+func (p *PostgresAdapter) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, now time.Time) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		"UPDATE user_session SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > $3",
+		sessionID,
+		userID,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error revoking session", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("session not found", "userID", userID, "sessionID", sessionID)
+		return entities.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeSessions ends all the user's sessions and returns the IDs of the ones that were still in use
+func (p *PostgresAdapter) RevokeSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	return revokeSessions(ctx, p.db, userID, now)
+}
+
+// sessionColumns lists the user_session columns scanSession expects, the refresh token hash is never read back
+const sessionColumns = "id, user_id, device_name, user_agent, ip_address, scope, created_at, last_used_at, expires_at, revoked_at"
+
+func scanSession(row rowScanner) (*entities.Session, error) {
+	var session entities.Session
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Device.Name,
+		&session.Device.UserAgent,
+		&session.Device.IPAddress,
+		&session.Scope,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// revokeSessions ends all the user's sessions, it is shared by logging out everywhere and changing password
+func revokeSessions(ctx context.Context, db queryer, userID uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		"UPDATE user_session SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2 RETURNING id",
+		userID,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error revoking sessions", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessionIDs := []uuid.UUID{}
+	for rows.Next() {
+		var sessionID uuid.UUID
+		err = rows.Scan(&sessionID)
+		if err != nil {
+			slog.Debug("error scanning session id", "err", err)
+			return nil, err
+		}
+
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating revoked sessions", "err", err)
+		return nil, err
+	}
+
+	return sessionIDs, nil
+}
+
+// CreateWebAuthnCeremony stores the session data of a WebAuthn ceremony until it is finished
+func (p *PostgresAdapter) CreateWebAuthnCeremony(ctx context.Context, ceremony entities.WebAuthnCeremony) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO webauthn_ceremony (id, user_id, purpose, session_data, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		ceremony.ID,
+		ceremony.UserID,
+		ceremony.Purpose,
+		ceremony.SessionData,
+		ceremony.ExpiresAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting webauthn ceremony", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// RedeemWebAuthnCeremony deletes and returns an unexpired ceremony so that it can only be finished once
+func (p *PostgresAdapter) RedeemWebAuthnCeremony(ctx context.Context, ceremonyID uuid.UUID, purpose string, now time.Time) (*entities.WebAuthnCeremony, error) {
+	var ceremony entities.WebAuthnCeremony
+	err := p.db.QueryRowContext(
+		ctx,
+		`DELETE FROM webauthn_ceremony WHERE id = $1 AND purpose = $2 AND expires_at > $3
+		RETURNING id, user_id, purpose, session_data, expires_at`,
+		ceremonyID,
+		purpose,
+		now,
+	).Scan(
+		&ceremony.ID,
+		&ceremony.UserID,
+		&ceremony.Purpose,
+		&ceremony.SessionData,
+		&ceremony.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("webauthn ceremony not redeemable", "ceremonyID", ceremonyID)
+			return nil, entities.ErrInvalidToken
+		}
+		slog.Debug("error redeeming webauthn ceremony", "err", err)
+		return nil, err
+	}
+
+	return &ceremony, nil
+}
+
+// GetWebAuthnCredentials returns the user's WebAuthn credentials, oldest first
+func (p *PostgresAdapter) GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT id, user_id, credential_id, public_key, attestation_type, transports, aaguid, sign_count, backup_eligible,
+		backup_state, name, created_at, last_used_at FROM webauthn_credential WHERE user_id = $1 ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		slog.Debug("error getting webauthn credentials", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []entities.WebAuthnCredential{}
+	for rows.Next() {
+		var credential entities.WebAuthnCredential
+		var transports string
+		err = rows.Scan(
+			&credential.ID,
+			&credential.UserID,
+			&credential.CredentialID,
+			&credential.PublicKey,
+			&credential.AttestationType,
+			&transports,
+			&credential.AAGUID,
+			&credential.SignCount,
+			&credential.BackupEligible,
+			&credential.BackupState,
+			&credential.Name,
+			&credential.CreatedAt,
+			&credential.LastUsedAt,
+		)
+		if err != nil {
+			slog.Debug("error scanning webauthn credential", "err", err)
+			return nil, err
+		}
+
+		credential.Transports = []string{}
+		if transports != "" {
+			credential.Transports = strings.Split(transports, ",")
+		}
+
+		credentials = append(credentials, credential)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating webauthn credentials", "err", err)
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// CreateWebAuthnCredential stores a newly registered credential. A credential that is already registered, to this user
+// or any other, returns ErrWebAuthnCredentialExists.
+func (p *PostgresAdapter) CreateWebAuthnCredential(ctx context.Context, credential entities.WebAuthnCredential) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO webauthn_credential (id, user_id, credential_id, public_key, attestation_type, transports, aaguid,
+		sign_count, backup_eligible, backup_state, name, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		credential.ID,
+		credential.UserID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		strings.Join(credential.Transports, ","),
+		credential.AAGUID,
+		int64(credential.SignCount),
+		credential.BackupEligible,
+		credential.BackupState,
+		credential.Name,
+		credential.CreatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"webauthn_credential_credential_id_key\"") {
+			slog.Debug("webauthn credential already registered", "err", err)
+			return entities.ErrWebAuthnCredentialExists
+		}
+		slog.Debug("error inserting webauthn credential", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateWebAuthnCredentialUsage records a login with a credential, storing the authenticator's new signature counter
+func (p *PostgresAdapter) UpdateWebAuthnCredentialUsage(ctx context.Context, credentialID []byte, signCount uint32, backupState bool, usedAt time.Time) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		"UPDATE webauthn_credential SET sign_count = $2, backup_state = $3, last_used_at = $4 WHERE credential_id = $1",
+		credentialID,
+		int64(signCount),
+		backupState,
+		usedAt,
+	)
+	if err != nil {
+		slog.Debug("error updating webauthn credential", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("webauthn credential not found")
+		return entities.ErrWebAuthnCredentialNotFound
+	}
+
+	return nil
+}
+
+// DeleteWebAuthnCredential removes one of the user's credentials
+func (p *PostgresAdapter) DeleteWebAuthnCredential(ctx context.Context, userID, credentialID uuid.UUID) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		"DELETE FROM webauthn_credential WHERE id = $1 AND user_id = $2",
+		credentialID,
+		userID,
+	)
+	if err != nil {
+		slog.Debug("error deleting webauthn credential", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("webauthn credential not found", "userID", userID, "credentialID", credentialID)
+		return entities.ErrWebAuthnCredentialNotFound
+	}
+
+	return nil
+}
+
+// CreateOIDCClient registers a client, public clients are stored without a secret hash
+func (p *PostgresAdapter) CreateOIDCClient(ctx context.Context, client entities.OIDCClient) error {
+	var secretHash *string
+	if !client.Public() {
+		secretHash = &client.SecretHash
+	}
+
+	_, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO oidc_client (id, secret_hash, name, redirect_uris, allowed_scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		client.ID,
+		secretHash,
+		client.Name,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.AllowedScopes),
+		client.CreatedAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting oidc client", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) GetOIDCClient(ctx context.Context, clientID string) (*entities.OIDCClient, error) {
+	var client entities.OIDCClient
+	var secretHash *string
+	err := p.db.QueryRowContext(
+		ctx,
+		"SELECT id, secret_hash, name, redirect_uris, allowed_scopes, created_at FROM oidc_client WHERE id = $1",
+		clientID,
+	).Scan(
+		&client.ID,
+		&secretHash,
+		&client.Name,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.AllowedScopes),
+		&client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("oidc client not found", "clientID", clientID)
+			return nil, entities.ErrOIDCClientNotFound
+		}
+		slog.Debug("error getting oidc client", "err", err)
+		return nil, err
+	}
+
+	if secretHash != nil {
+		client.SecretHash = *secretHash
+	}
+
+	return &client, nil
+}
+
+// GetOIDCConsent returns the scopes the user has allowed the client, which is empty if they haven't consented yet
+func (p *PostgresAdapter) GetOIDCConsent(ctx context.Context, userID uuid.UUID, clientID string) ([]string, error) {
+	scopes := []string{}
+	err := p.db.QueryRowContext(
+		ctx,
+		"SELECT scopes FROM oidc_consent WHERE user_id = $1 AND client_id = $2",
+		userID,
+		clientID,
+	).Scan(pq.Array(&scopes))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []string{}, nil
+		}
+		slog.Debug("error getting oidc consent", "err", err)
+		return nil, err
+	}
+
+	return scopes, nil
+}
+
+// GrantOIDCConsent stores the scopes the user has allowed the client, replacing any previously stored
+func (p *PostgresAdapter) GrantOIDCConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string, now time.Time) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO oidc_consent (user_id, client_id, scopes, granted_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, granted_at = EXCLUDED.granted_at`,
+		userID,
+		clientID,
+		pq.Array(scopes),
+		now,
+	)
+	if err != nil {
+		slog.Debug("error storing oidc consent", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) CreateOIDCAuthorizationCode(ctx context.Context, code entities.OIDCAuthorizationCode, codeHash string) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO oidc_authorization_code (code_hash, client_id, user_id, redirect_uri, scopes, nonce, code_challenge,
+		auth_time, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		codeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		pq.Array(code.Scopes),
+		code.Nonce,
+		code.CodeChallenge,
+		code.AuthTime,
+		code.ExpiresAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting oidc authorization code", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// RedeemOIDCAuthorizationCode deletes and returns an unexpired authorization code so that it can only be used once
+func (p *PostgresAdapter) RedeemOIDCAuthorizationCode(ctx context.Context, codeHash string, now time.Time) (*entities.OIDCAuthorizationCode, error) {
+	var code entities.OIDCAuthorizationCode
+	err := p.db.QueryRowContext(
+		ctx,
+		`DELETE FROM oidc_authorization_code WHERE code_hash = $1 AND expires_at > $2
+		RETURNING client_id, user_id, redirect_uri, scopes, nonce, code_challenge, auth_time, expires_at`,
+		codeHash,
+		now,
+	).Scan(
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		pq.Array(&code.Scopes),
+		&code.Nonce,
+		&code.CodeChallenge,
+		&code.AuthTime,
+		&code.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("oidc authorization code not redeemable")
+			return nil, entities.ErrInvalidToken
+		}
+		slog.Debug("error redeeming oidc authorization code", "err", err)
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+// GetSigningKeys returns the keys that haven't expired, oldest first
+func (p *PostgresAdapter) GetSigningKeys(ctx context.Context, now time.Time) ([]entities.SigningKey, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT id, status, encrypted_private_key, created_at, activated_at, retired_at, expires_at FROM signing_key
+		WHERE expires_at IS NULL OR expires_at > $1 ORDER BY created_at, id`,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error getting signing keys", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entities.SigningKey{}
+	for rows.Next() {
+		var key entities.SigningKey
+		err = rows.Scan(
+			&key.ID,
+			&key.Status,
+			&key.EncryptedPrivateKey,
+			&key.CreatedAt,
+			&key.ActivatedAt,
+			&key.RetiredAt,
+			&key.ExpiresAt,
+		)
+		if err != nil {
+			slog.Debug("error scanning signing key", "err", err)
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating signing keys", "err", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// CreateSigningKey stores a new key. There can only be one active and one next key, so if another instance has already
+// created a key with the same status ErrSigningKeysChanged is returned.
+func (p *PostgresAdapter) CreateSigningKey(ctx context.Context, key entities.SigningKey) error {
+	err := insertSigningKey(ctx, p.db, key)
+	if err != nil {
+		if isSigningKeyStatusConflict(err) {
+			slog.Debug("signing key with status already exists", "err", err, "status", key.Status)
+			return entities.ErrSigningKeysChanged
+		}
+		slog.Debug("error inserting signing key", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// RotateSigningKeys retires the active key, activates the next key and stores a new next key, deleting any keys that
+// have expired. If the active key has already been retired by another instance ErrSigningKeysChanged is returned.
+func (p *PostgresAdapter) RotateSigningKeys(
+	ctx context.Context,
+	activeKeyID string,
+	next entities.SigningKey,
+	now,
+	retiredKeyExpiresAt time.Time,
+) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE signing_key SET status = $2, retired_at = $3, expires_at = $4 WHERE id = $1 AND status = $5",
+		activeKeyID,
+		entities.SigningKeyStatusRetired,
+		now,
+		retiredKeyExpiresAt,
+		entities.SigningKeyStatusActive,
+	)
+	if err != nil {
+		slog.Debug("error retiring signing key", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("signing key no longer active", "kid", activeKeyID)
+		return entities.ErrSigningKeysChanged
+	}
+
+	result, err = tx.ExecContext(
+		ctx,
+		"UPDATE signing_key SET status = $1, activated_at = $2 WHERE status = $3",
+		entities.SigningKeyStatusActive,
+		now,
+		entities.SigningKeyStatusNext,
+	)
+	if err != nil {
+		slog.Debug("error activating signing key", "err", err)
+		return err
+	}
+
+	rowsAffected, err = result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("no next signing key to activate")
+		return entities.ErrSigningKeysChanged
+	}
+
+	err = insertSigningKey(ctx, tx, next)
+	if err != nil {
+		if isSigningKeyStatusConflict(err) {
+			slog.Debug("next signing key already exists", "err", err)
+			return entities.ErrSigningKeysChanged
+		}
+		slog.Debug("error inserting signing key", "err", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM signing_key WHERE expires_at <= $1", now)
+	if err != nil {
+		slog.Debug("error deleting expired signing keys", "err", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func insertSigningKey(ctx context.Context, db execer, key entities.SigningKey) error {
+	_, err := db.ExecContext(
+		ctx,
+		"INSERT INTO signing_key (id, status, encrypted_private_key, created_at, activated_at) VALUES ($1, $2, $3, $4, $5)",
+		key.ID,
+		key.Status,
+		key.EncryptedPrivateKey,
+		key.CreatedAt,
+		key.ActivatedAt,
+	)
+	return err
+}
+
+// isSigningKeyStatusConflict reports whether the error is from a second active or next key being stored
+func isSigningKeyStatusConflict(err error) bool {
+	return strings.Contains(err.Error(), "duplicate key value violates unique constraint \"signing_key_status_key\"")
+}
+
+// GetLoginThrottle returns the key's failed logins, keys without any failures get an empty throttle
+func (p *PostgresAdapter) GetLoginThrottle(ctx context.Context, key string, _ time.Time) (*entities.LoginThrottle, error) {
+	throttle, err := scanLoginThrottle(p.db.QueryRowContext(
+		ctx,
+		"SELECT failures, last_failure_at, blocked_until, locked FROM login_throttle WHERE key = $1",
+		key,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &entities.LoginThrottle{}, nil
+		}
+
+		slog.Debug("error getting login throttle", "err", err)
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+// RecordLoginFailure locks the key's row while the policy is applied, so concurrent failures are all counted
+func (p *PostgresAdapter) RecordLoginFailure(
+	ctx context.Context,
+	key string,
+	policy entities.LoginThrottlePolicy,
+	now time.Time,
+) (*entities.LoginThrottle, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the row has to exist before it can be locked
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO login_throttle (key, failures, last_failure_at) VALUES ($1, 0, $2) ON CONFLICT (key) DO NOTHING",
+		key,
+		now,
+	)
+	if err != nil {
+		slog.Debug("error creating login throttle", "err", err)
+		return nil, err
+	}
+
+	throttle, err := scanLoginThrottle(tx.QueryRowContext(
+		ctx,
+		"SELECT failures, last_failure_at, blocked_until, locked FROM login_throttle WHERE key = $1 FOR UPDATE",
+		key,
+	))
+	if err != nil {
+		slog.Debug("error getting login throttle", "err", err)
+		return nil, err
+	}
+
+	next := policy.Fail(*throttle, now)
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE login_throttle SET failures = $2, last_failure_at = $3, blocked_until = $4, locked = $5 WHERE key = $1",
+		key,
+		next.Failures,
+		next.LastFailureAt,
+		next.BlockedUntil,
+		next.Locked,
+	)
+	if err != nil {
+		slog.Debug("error updating login throttle", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return &next, nil
+}
+
+func (p *PostgresAdapter) ResetLoginThrottle(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM login_throttle WHERE key = $1", key)
+	if err != nil {
+		slog.Debug("error resetting login throttle", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func scanLoginThrottle(row rowScanner) (*entities.LoginThrottle, error) {
+	var throttle entities.LoginThrottle
+	err := row.Scan(&throttle.Failures, &throttle.LastFailureAt, &throttle.BlockedUntil, &throttle.Locked)
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+func (p *PostgresAdapter) RecordLoginAttempt(ctx context.Context, attempt entities.LoginAttempt) error {
+	var failureReason *string
+	if !attempt.Succeeded {
+		failureReason = &attempt.FailureReason
+	}
+
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO login_attempt (id, user_id, email, ip_address, user_agent, succeeded, failure_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		attempt.ID,
+		attempt.UserID,
+		attempt.Email,
+		attempt.IPAddress,
+		attempt.UserAgent,
+		attempt.Succeeded,
+		failureReason,
+		attempt.CreatedAt,
+	)
+	if err != nil {
+		slog.Debug("error recording login attempt", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) CreateAPIKey(ctx context.Context, key entities.APIKey) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO api_key (id, name, owner, scopes, secret_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		key.ID,
+		key.Name,
+		key.Owner,
+		pq.Array(key.Scopes),
+		key.SecretHash,
+		key.CreatedAt,
+		key.ExpiresAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting api key", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) GetAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_key ORDER BY created_at, id")
+	if err != nil {
+		slog.Debug("error getting api keys", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entities.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			slog.Debug("error scanning api key", "err", err)
+			return nil, err
+		}
+
+		keys = append(keys, *key)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating api keys", "err", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// AuthenticateAPIKey records that the key with the secret hash was used and returns it, keys that have been revoked or
+// have expired return ErrAPIKeyNotFound
+func (p *PostgresAdapter) AuthenticateAPIKey(ctx context.Context, secretHash string, now time.Time) (*entities.APIKey, error) {
+	key, err := scanAPIKey(p.db.QueryRowContext(
+		ctx,
+		`UPDATE api_key SET last_used_at = $2
+		WHERE secret_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING `+apiKeyColumns,
+		secretHash,
+		now,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("api key not found")
+			return nil, entities.ErrAPIKeyNotFound
+		}
+
+		slog.Debug("error authenticating api key", "err", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (p *PostgresAdapter) RevokeAPIKey(ctx context.Context, id uuid.UUID, now time.Time) error {
+	result, err := p.db.ExecContext(ctx, "UPDATE api_key SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL", id, now)
+	if err != nil {
+		slog.Debug("error revoking api key", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("api key not found", "apiKeyID", id)
+		return entities.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// apiKeyColumns lists the api_key columns scanAPIKey expects
+const apiKeyColumns = "id, name, owner, scopes, secret_hash, created_at, expires_at, last_used_at, revoked_at"
+
+func scanAPIKey(row rowScanner) (*entities.APIKey, error) {
+	var key entities.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Owner,
+		pq.Array(&key.Scopes),
+		&key.SecretHash,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// AppendAuditEntry chains the entry on to the last one and inserts it. The table is locked against other appends until
+// the transaction commits so that no two entries are chained on to the same one.
+func (p *PostgresAdapter) AppendAuditEntry(ctx context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		slog.Debug("error locking audit log", "err", err)
+		return nil, err
+	}
+
+	last, err := scanAuditEntry(tx.QueryRowContext(ctx, "SELECT "+auditEntryColumns+" FROM audit_log ORDER BY sequence DESC LIMIT 1"))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Debug("error getting last audit entry", "err", err)
+		return nil, err
+	}
+
+	entry = entry.Chain(last)
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		slog.Debug("error marshalling audit entry details", "err", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO audit_log (`+auditEntryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		entry.Sequence,
+		entry.Actor,
+		entry.ActorAPIKeyID,
+		entry.Action,
+		entry.TargetUserID,
+		details,
+		entry.IPAddress,
+		entry.CreatedAt,
+		entry.PreviousHash,
+		entry.Hash,
+	)
+	if err != nil {
+		slog.Debug("error inserting audit entry", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (p *PostgresAdapter) GetAuditEntries(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+	queryString := "SELECT " + auditEntryColumns + " FROM audit_log WHERE sequence > $1 "
+	queryParams := []any{filter.AfterSequence}
+	if filter.Actor != "" {
+		queryParams = append(queryParams, filter.Actor)
+		queryString += fmt.Sprintf("AND actor = $%d ", len(queryParams))
+	}
+
+	if filter.Action != "" {
+		queryParams = append(queryParams, filter.Action)
+		queryString += fmt.Sprintf("AND action = $%d ", len(queryParams))
+	}
+
+	if filter.TargetUserID != nil {
+		queryParams = append(queryParams, *filter.TargetUserID)
+		queryString += fmt.Sprintf("AND target_user_id = $%d ", len(queryParams))
+	}
+
+	if filter.From != nil {
+		queryParams = append(queryParams, *filter.From)
+		queryString += fmt.Sprintf("AND created_at >= $%d ", len(queryParams))
+	}
+
+	if filter.To != nil {
+		queryParams = append(queryParams, *filter.To)
+		queryString += fmt.Sprintf("AND created_at < $%d ", len(queryParams))
+	}
+
+	queryString += fmt.Sprintf("ORDER BY sequence LIMIT %d", filter.Limit)
+	rows, err := p.db.QueryContext(ctx, queryString, queryParams...)
+	if err != nil {
+		slog.Debug("error getting audit entries", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []entities.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			slog.Debug("error scanning audit entry", "err", err)
+			return nil, err
+		}
+
+		entries = append(entries, *entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating audit entries", "err", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// auditEntryColumns lists the audit_log columns scanAuditEntry expects
+const auditEntryColumns = "sequence, actor, actor_api_key_id, action, target_user_id, details, ip_address, created_at, previous_hash, hash"
+
+func scanAuditEntry(row rowScanner) (*entities.AuditEntry, error) {
+	var entry entities.AuditEntry
+	var details []byte
+	err := row.Scan(
+		&entry.Sequence,
+		&entry.Actor,
+		&entry.ActorAPIKeyID,
+		&entry.Action,
+		&entry.TargetUserID,
+		&details,
+		&entry.IPAddress,
+		&entry.CreatedAt,
+		&entry.PreviousHash,
+		&entry.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(details, &entry.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// LoadDataKeys loads the data keys users' PII is encrypted with, creating the first one if there are none. The newest
+// key encrypts new values.
+func (p *PostgresAdapter) LoadDataKeys(ctx context.Context) error {
+	rows, err := p.db.QueryContext(ctx, "SELECT "+dataKeyColumns+" FROM data_key ORDER BY created_at, id")
+	if err != nil {
+		slog.Debug("error getting data keys", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	dataKeys := []DataKey{}
+	for rows.Next() {
+		dataKey, err := scanDataKey(rows)
+		if err != nil {
+			slog.Debug("error scanning data key", "err", err)
+			return err
+		}
+
+		dataKeys = append(dataKeys, *dataKey)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating data keys", "err", err)
+		return err
+	}
+
+	if len(dataKeys) == 0 {
+		dataKey, err := p.pii.GenerateDataKey(time.Now().UTC())
+		if err != nil {
+			slog.Debug("error generating data key", "err", err)
+			return err
+		}
+
+		_, err = p.db.ExecContext(
+			ctx,
+			"INSERT INTO data_key ("+dataKeyColumns+") VALUES ($1, $2, $3, $4)",
+			dataKey.ID,
+			dataKey.MasterKeyID,
+			dataKey.WrappedKey,
+			dataKey.CreatedAt,
+		)
+		if err != nil {
+			slog.Debug("error inserting data key", "err", err)
+			return err
+		}
+
+		dataKeys = append(dataKeys, *dataKey)
+	}
+
+	for i, dataKey := range dataKeys {
+		err = p.pii.LoadDataKey(dataKey, i == len(dataKeys)-1)
+		if err != nil {
+			slog.Debug("error loading data key", "err", err, "dataKeyID", dataKey.ID)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) loadDataKey(ctx context.Context, dataKeyID uuid.UUID) error {
+	dataKey, err := scanDataKey(p.db.QueryRowContext(ctx, "SELECT "+dataKeyColumns+" FROM data_key WHERE id = $1", dataKeyID))
+	if err != nil {
+		slog.Debug("error getting data key", "err", err, "dataKeyID", dataKeyID)
+		return err
+	}
+
+	err = p.pii.LoadDataKey(*dataKey, false)
+	if err != nil {
+		slog.Debug("error loading data key", "err", err, "dataKeyID", dataKeyID)
+		return err
+	}
+
+	return nil
+}
+
+// RewrapDataKeys wraps data keys wrapped by a previous master key with the current one, once it returns 0 the previous
+// master keys can be removed from config
+func (p *PostgresAdapter) RewrapDataKeys(ctx context.Context) (int, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		"SELECT "+dataKeyColumns+" FROM data_key WHERE master_key_id <> $1 ORDER BY created_at, id",
+		p.pii.currentMasterKeyID,
+	)
+	if err != nil {
+		slog.Debug("error getting data keys", "err", err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	dataKeys := []DataKey{}
+	for rows.Next() {
+		dataKey, err := scanDataKey(rows)
+		if err != nil {
+			slog.Debug("error scanning data key", "err", err)
+			return 0, err
+		}
+
+		dataKeys = append(dataKeys, *dataKey)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating data keys", "err", err)
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, dataKey := range dataKeys {
+		rewrappedKey, err := p.pii.Rewrap(dataKey)
+		if err != nil {
+			slog.Debug("error rewrapping data key", "err", err, "dataKeyID", dataKey.ID)
+			return rewrapped, err
+		}
+
+		// another instance may have rewrapped it already
+		result, err := p.db.ExecContext(
+			ctx,
+			"UPDATE data_key SET master_key_id = $2, wrapped_key = $3 WHERE id = $1 AND master_key_id = $4",
+			dataKey.ID,
+			rewrappedKey.MasterKeyID,
+			rewrappedKey.WrappedKey,
+			dataKey.MasterKeyID,
+		)
+		if err != nil {
+			slog.Debug("error updating data key", "err", err, "dataKeyID", dataKey.ID)
+			return rewrapped, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			slog.Debug("error getting rows affected", "err", err)
+			return rewrapped, err
+		}
+		rewrapped += int(rowsAffected)
+	}
+
+	return rewrapped, nil
+}
+
+// SealUsers encrypts and tokenises the PII of up to limit users that were written before it was encrypted or before
+// it had search tokens, returning how many it sealed. Values already encrypted are sealed again with the active data
+// key.
+func (p *PostgresAdapter) SealUsers(ctx context.Context, limit int) (int, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT * FROM platform_user WHERE email_index IS NULL OR email_tokens IS NULL ORDER BY id LIMIT $1
+		FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		slog.Debug("error getting unsealed users", "err", err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	users := []entities.User{}
+	for rows.Next() {
+		user, err := p.scanUser(ctx, rows)
+		if err != nil {
+			slog.Debug("error scanning user", "err", err)
+			return 0, err
+		}
+
+		users = append(users, *user)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating unsealed users", "err", err)
+		return 0, err
+	}
+	rows.Close()
+
+	for _, user := range users {
+		sealed, err := p.sealUserPII(user.ID, user.FirstName, user.LastName, user.Email)
+		if err != nil {
+			slog.Debug("error encrypting user pii", "err", err)
+			return 0, err
+		}
+
+		var sealedPendingEmail, pendingEmailIndex *string
+		if user.PendingEmail != nil {
+			encrypted, index, err := p.sealEmail(user.ID, *user.PendingEmail)
+			if err != nil {
+				slog.Debug("error encrypting pending email", "err", err)
+				return 0, err
+			}
+			sealedPendingEmail, pendingEmailIndex = &encrypted, &index
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE platform_user SET first_name = $2, last_name = $3, email = $4, pending_email = $5, email_index = $6,
+			pending_email_index = $7, first_name_index = $8, last_name_index = $9, first_name_tokens = $10, last_name_tokens = $11,
+			email_tokens = $12 WHERE id = $1`,
+			user.ID,
+			sealed.firstName,
+			sealed.lastName,
+			sealed.email,
+			sealedPendingEmail,
+			sealed.emailIndex,
+			pendingEmailIndex,
+			sealed.firstNameIndex,
+			sealed.lastNameIndex,
+			pq.Array(sealed.firstNameTokens),
+			pq.Array(sealed.lastNameTokens),
+			pq.Array(sealed.emailTokens),
+		)
+		if err != nil {
+			slog.Debug("error encrypting user", "err", err, "userID", user.ID)
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return 0, err
+	}
+
+	return len(users), nil
+}
+
+// dataKeyColumns lists the data_key columns scanDataKey expects
+const dataKeyColumns = "id, master_key_id, wrapped_key, created_at"
+
+func scanDataKey(row rowScanner) (*DataKey, error) {
+	var dataKey DataKey
+	err := row.Scan(&dataKey.ID, &dataKey.MasterKeyID, &dataKey.WrappedKey, &dataKey.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataKey, nil
+}
+
+// importColumns are the platform_user columns imported users are copied into the staging table with
+var importColumns = []string{
+	"id",
+	"first_name",
+	"last_name",
+	"nickname",
+	"password",
+	"email",
+	"country",
+	"email_index",
+	"first_name_index",
+	"last_name_index",
+	"first_name_tokens",
+	"last_name_tokens",
+	"email_tokens",
+}
+
+// ImportUsers copies the users into a staging table then inserts them together, skipping any whose email is already
+// registered or pending for another user. A dry run rolls back once it knows which users would have been created.
+func (p *PostgresAdapter) ImportUsers(ctx context.Context, users []entities.ImportedUser, dryRun bool) ([]entities.ImportRowResult, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "CREATE TEMP TABLE user_import_staging (LIKE platform_user INCLUDING DEFAULTS) ON COMMIT DROP")
+	if err != nil {
+		slog.Debug("error creating import staging table", "err", err)
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("user_import_staging", importColumns...))
+	if err != nil {
+		slog.Debug("error starting copy", "err", err)
+		return nil, err
+	}
+	defer stmt.Close()
+
+	userIDs := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		userIDs[i] = uuid.New()
+		sealed, err := p.sealUserPII(userIDs[i], user.FirstName, user.LastName, user.Email)
+		if err != nil {
+			slog.Debug("error encrypting user pii", "err", err)
+			return nil, err
+		}
+
+		_, err = stmt.ExecContext(
+			ctx,
+			userIDs[i],
+			sealed.firstName,
+			sealed.lastName,
+			user.Nickname,
+			user.Password,
+			sealed.email,
+			user.Country,
+			sealed.emailIndex,
+			sealed.firstNameIndex,
+			sealed.lastNameIndex,
+			pq.Array(sealed.firstNameTokens),
+			pq.Array(sealed.lastNameTokens),
+			pq.Array(sealed.emailTokens),
+		)
+		if err != nil {
+			slog.Debug("error copying user", "err", err, "row", user.Row)
+			return nil, err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		slog.Debug("error finishing copy", "err", err)
+		return nil, err
+	}
+
+	columns := strings.Join(importColumns, ", ")
+	rows, err := tx.QueryContext(
+		ctx,
+		`INSERT INTO platform_user (`+columns+`) SELECT `+columns+` FROM user_import_staging staged
+		WHERE NOT EXISTS (SELECT 1 FROM platform_user WHERE pending_email_index = staged.email_index)
+		ON CONFLICT (email_index) DO NOTHING RETURNING id`,
+	)
+	if err != nil {
+		slog.Debug("error inserting imported users", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	created := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var userID uuid.UUID
+		err = rows.Scan(&userID)
+		if err != nil {
+			slog.Debug("error scanning imported user id", "err", err)
+			return nil, err
+		}
+		created[userID] = true
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating imported users", "err", err)
+		return nil, err
+	}
+	rows.Close()
+
+	results := make([]entities.ImportRowResult, len(users))
+	for i, user := range users {
+		results[i] = entities.ImportRowResult{Row: user.Row}
+		if !created[userIDs[i]] {
+			results[i].Error = entities.ErrEmailAlreadyUsed.Error()
+		} else if !dryRun {
+			results[i].UserID = &userIDs[i]
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (p *PostgresAdapter) CreateImportJob(ctx context.Context, job entities.ImportJob) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO user_import_job (id, format, dry_run, status, created_at, heartbeat_at) VALUES ($1, $2, $3, $4, $5, $5)",
+		job.ID,
+		job.Format,
+		job.DryRun,
+		job.Status,
+		job.CreatedAt,
+	)
+	if err != nil {
+		slog.Debug("error inserting import job", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordImportResults copies the results in and adds them to the job's counts in one transaction, so the counts always
+// match the stored results
+func (p *PostgresAdapter) RecordImportResults(ctx context.Context, jobID uuid.UUID, results []entities.ImportRowResult) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Debug("beginning transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("user_import_result", "job_id", "row_number", "user_id", "error"))
+	if err != nil {
+		slog.Debug("error starting copy", "err", err)
+		return err
+	}
+	defer stmt.Close()
+
+	imported, failed := 0, 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		} else {
+			imported++
+		}
+
+		_, err = stmt.ExecContext(ctx, jobID, result.Row, result.UserID, sql.NullString{String: result.Error, Valid: result.Error != ""})
+		if err != nil {
+			slog.Debug("error copying import result", "err", err, "row", result.Row)
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		slog.Debug("error finishing copy", "err", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE user_import_job SET imported_rows = imported_rows + $2, failed_rows = failed_rows + $3 WHERE id = $1",
+		jobID,
+		imported,
+		failed,
+	)
+	if err != nil {
+		slog.Debug("error updating import job counts", "err", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Debug("committing transaction", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) FinishImportJob(
+	ctx context.Context,
+	jobID uuid.UUID,
+	status entities.ImportJobStatus,
+	errorMessage string,
+	now time.Time,
+) error {
+	result, err := p.db.ExecContext(
+		ctx,
+		"UPDATE user_import_job SET status = $2, error = $3, finished_at = $4 WHERE id = $1 AND status = $5",
+		jobID,
+		status,
+		sql.NullString{String: errorMessage, Valid: errorMessage != ""},
+		now,
+		entities.ImportJobStatusRunning,
+	)
+	if err != nil {
+		slog.Debug("error finishing import job", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("running import job not found", "jobID", jobID)
+		return entities.ErrImportJobNotFound
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) HeartbeatImportJob(ctx context.Context, jobID uuid.UUID, now time.Time) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"UPDATE user_import_job SET heartbeat_at = $2 WHERE id = $1 AND status = $3",
+		jobID,
+		now,
+		entities.ImportJobStatusRunning,
+	)
+	if err != nil {
+		slog.Debug("error recording import job heartbeat", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// FailStaleImportJobs fails running jobs whose last heartbeat was before staleBefore, or that have never had one
+func (p *PostgresAdapter) FailStaleImportJobs(ctx context.Context, staleBefore time.Time, errorMessage string, now time.Time) (int, error) {
+	result, err := p.db.ExecContext(
+		ctx,
+		`UPDATE user_import_job SET status = $1, error = $2, finished_at = $3
+		WHERE status = $4 AND (heartbeat_at IS NULL OR heartbeat_at < $5)`,
+		entities.ImportJobStatusFailed,
+		errorMessage,
+		now,
+		entities.ImportJobStatusRunning,
+		staleBefore,
+	)
+	if err != nil {
+		slog.Debug("error failing stale import jobs", "err", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+func (p *PostgresAdapter) GetImportJob(ctx context.Context, jobID uuid.UUID) (*entities.ImportJob, error) {
+	var job entities.ImportJob
+	var errorMessage sql.NullString
+	err := p.db.QueryRowContext(
+		ctx,
+		`SELECT id, format, dry_run, status, imported_rows, failed_rows, error, created_at, finished_at
+		FROM user_import_job WHERE id = $1`,
+		jobID,
+	).Scan(
+		&job.ID,
+		&job.Format,
+		&job.DryRun,
+		&job.Status,
+		&job.ImportedRows,
+		&job.FailedRows,
+		&errorMessage,
+		&job.CreatedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("import job not found", "jobID", jobID)
+			return nil, entities.ErrImportJobNotFound
+		}
+
+		slog.Debug("error getting import job", "err", err)
+		return nil, err
+	}
+	job.Error = errorMessage.String
+
+	return &job, nil
+}
+
+func (p *PostgresAdapter) GetImportResults(ctx context.Context, jobID uuid.UUID, afterRow, limit int) ([]entities.ImportRowResult, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT row_number, user_id, error FROM user_import_result WHERE job_id = $1 AND row_number > $2
+		ORDER BY row_number LIMIT $3`,
+		jobID,
+		afterRow,
+		limit,
+	)
+	if err != nil {
+		slog.Debug("error getting import results", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []entities.ImportRowResult{}
+	for rows.Next() {
+		var result entities.ImportRowResult
+		var errorMessage sql.NullString
+		err = rows.Scan(&result.Row, &result.UserID, &errorMessage)
+		if err != nil {
+			slog.Debug("error scanning import result", "err", err)
+			return nil, err
+		}
+		result.Error = errorMessage.String
+
+		results = append(results, result)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating import results", "err", err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (p *PostgresAdapter) GetUserCommandReply(ctx context.Context, correlationID string) (*entities.UserCommandReply, error) {
+	var reply entities.UserCommandReply
+	err := p.db.QueryRowContext(
+		ctx,
+		`SELECT correlation_id, type, status, user_id, error, violations, processed_at
+		FROM user_command_reply WHERE correlation_id = $1`,
+		correlationID,
+	).Scan(
+		&reply.CorrelationID,
+		&reply.Type,
+		&reply.Status,
+		&reply.UserID,
+		&reply.Error,
+		pq.Array(&reply.Violations),
+		&reply.ProcessedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user command reply not found", "correlationID", correlationID)
+			return nil, entities.ErrUserCommandReplyNotFound
+		}
+
+		slog.Debug("error getting user command reply", "err", err)
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (p *PostgresAdapter) RecordUserCommandReply(ctx context.Context, reply entities.UserCommandReply) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO user_command_reply (correlation_id, type, status, user_id, error, violations, processed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (correlation_id) DO UPDATE SET type = EXCLUDED.type, status = EXCLUDED.status,
+			user_id = EXCLUDED.user_id, error = EXCLUDED.error, violations = EXCLUDED.violations,
+			processed_at = EXCLUDED.processed_at`,
+		reply.CorrelationID,
+		reply.Type,
+		reply.Status,
+		reply.UserID,
+		reply.Error,
+		pq.Array(reply.Violations),
+		reply.ProcessedAt,
+	)
+	if err != nil {
+		slog.Debug("error recording user command reply", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresAdapter) DeleteUserCommandReplies(ctx context.Context, processedBefore time.Time) (int, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM user_command_reply WHERE processed_at < $1", processedBefore)
+	if err != nil {
+		slog.Debug("error deleting user command replies", "err", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
 }
 
 func (p *PostgresAdapter) CheckConnection() error {
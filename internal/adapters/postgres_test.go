@@ -2,24 +2,78 @@ package adapters_test
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	. "github.com/onsi/gomega"
+	"strings"
 	"testing"
 	"time"
 )
 
+// newUserRows builds a platform_user result set, in table column order, from the given users
+func newUserRows(users ...entities.User) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at", "status", "ban_reason", "ban_expires_at", "email_verified_at", "password_changed_at", "pending_email", "email_index", "pending_email_index", "first_name_index", "last_name_index", "first_name_tokens", "last_name_tokens", "email_tokens"})
+	for _, user := range users {
+		rows.AddRow(user.ID, user.FirstName, user.LastName, user.Nickname, user.Password, user.Email, user.Country, user.CreatedAt, user.UpdatedAt, string(user.Status), user.BanReason, nullableTime(user.BanExpiresAt), nullableTime(user.EmailVerifiedAt), nullableTime(user.PasswordChangedAt), nullableString(user.PendingEmail), nil, nil, nil, nil, nil, nil, nil)
+	}
+
+	return rows
+}
+
+// newTestPIICipher returns a PIICipher with an active data key, as LoadDataKeys would leave it
+func newTestPIICipher(g *WithT) *adapters.PIICipher {
+	cipher, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	dataKey, err := cipher.GenerateDataKey(time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cipher.LoadDataKey(*dataKey, true)).To(Succeed())
+
+	return cipher
+}
+
+// searchTokens returns the search tokens of the n-grams of a column's value, as the query argument they are sent as
+func searchTokens(pii *adapters.PIICipher, column string, ngrams ...string) driver.Valuer {
+	tokens := make([]string, len(ngrams))
+	for i, ngram := range ngrams {
+		tokens[i] = pii.BlindIndex(column+"_token", ngram)
+	}
+
+	return pq.Array(tokens)
+}
+
+// nullableTime converts an optional timestamp into the value a driver would return for it
+func nullableTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return *t
+}
+
+// nullableString converts an optional string into the value a driver would return for it
+func nullableString(s *string) any {
+	if s == nil {
+		return nil
+	}
+
+	return *s
+}
+
 func TestNewPostgresAdapter(t *testing.T) {
 	g := NewWithT(t)
 	db, _, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
 	g.Expect(adapter).To(BeAssignableToTypeOf(&adapters.PostgresAdapter{}))
 
 	defer db.Close()
@@ -30,7 +84,8 @@ func TestPostgresAdapter_CreateUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntity := entities.User{
 		ID:        uuid.New(),
@@ -43,11 +98,9 @@ func TestPostgresAdapter_CreateUser(t *testing.T) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	mock.ExpectQuery(`INSERT INTO platform_user \(first_name, last_name, nickname, password, email, country\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\) RETURNING *`).
-		WithArgs("alec", "smith", "alecsmith", "somepassword", "alec@email.com", "UK").
-		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}).
-				AddRow(userEntity.ID, userEntity.FirstName, userEntity.LastName, userEntity.Nickname, userEntity.Password, userEntity.Email, userEntity.Country, userEntity.CreatedAt, userEntity.UpdatedAt))
+	mock.ExpectQuery(`INSERT INTO platform_user \(id, first_name, last_name, nickname, password, email, country, email_index, first_name_index, last_name_index,\s+first_name_tokens, last_name_tokens, email_tokens\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13\) RETURNING \*`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", pii.BlindIndex("email", "alec@email.com"), pii.BlindIndex("first_name", "alec"), pii.BlindIndex("last_name", "smith"), searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
 
 	user, err := adapter.CreateUser(
 		context.Background(),
@@ -67,11 +120,12 @@ func TestPostgresAdapter_CreateUser_EmailUniqueConstraint(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
-	mock.ExpectQuery(`INSERT INTO platform_user \(first_name, last_name, nickname, password, email, country\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\) RETURNING *`).
-		WithArgs("alec", "smith", "alecsmith", "somepassword", "alec@email.com", "UK").
-		WillReturnError(errors.New("pq: duplicate key value violates unique constraint \"platform_user_email_key\""))
+	mock.ExpectQuery(`INSERT INTO platform_user \(id, first_name, last_name, nickname, password, email, country, email_index, first_name_index, last_name_index,\s+first_name_tokens, last_name_tokens, email_tokens\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13\) RETURNING \*`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", pii.BlindIndex("email", "alec@email.com"), pii.BlindIndex("first_name", "alec"), pii.BlindIndex("last_name", "smith"), searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(errors.New("pq: duplicate key value violates unique constraint \"platform_user_email_index_key\""))
 
 	user, err := adapter.CreateUser(
 		context.Background(),
@@ -91,10 +145,11 @@ func TestPostgresAdapter_CreateUser_ExecError(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
-	mock.ExpectQuery(`INSERT INTO platform_user \(first_name, last_name, nickname, password, email, country\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\) RETURNING *`).
-		WithArgs("alec", "smith", "alecsmith", "somepassword", "alec@email.com", "UK").
+	mock.ExpectQuery(`INSERT INTO platform_user \(id, first_name, last_name, nickname, password, email, country, email_index, first_name_index, last_name_index,\s+first_name_tokens, last_name_tokens, email_tokens\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13\) RETURNING \*`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", pii.BlindIndex("email", "alec@email.com"), pii.BlindIndex("first_name", "alec"), pii.BlindIndex("last_name", "smith"), searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("an error occurred"))
 
 	user, err := adapter.CreateUser(
@@ -115,7 +170,7 @@ func TestPostgresAdapter_DeleteUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
 
 	userID := uuid.New()
 	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).
@@ -134,7 +189,7 @@ func TestPostgresAdapter_DeleteUser_ExecErr(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
 
 	userID := uuid.New()
 	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).
@@ -153,7 +208,7 @@ func TestPostgresAdapter_DeleteUser_NoRowsAffected(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
 
 	userID := uuid.New()
 	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).
@@ -172,7 +227,8 @@ func TestPostgresAdapter_UpdateUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntity := entities.User{
 		ID:        uuid.New(),
@@ -186,11 +242,9 @@ func TestPostgresAdapter_UpdateUser(t *testing.T) {
 		UpdatedAt: time.Now().UTC(),
 	}
 
-	mock.ExpectQuery(`UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8 WHERE id = \$1 RETURNING \*`).
-		WithArgs(userEntity.ID, "alec", "smith", "alecsmith", "somepassword", "alec@email.com", "UK", sqlmock.AnyArg()).
-		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}).
-				AddRow(userEntity.ID, userEntity.FirstName, userEntity.LastName, userEntity.Nickname, userEntity.Password, userEntity.Email, userEntity.Country, userEntity.CreatedAt, userEntity.UpdatedAt))
+	mock.ExpectQuery(`UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8,\s+email_verified_at = CASE WHEN email_index = \$9 THEN email_verified_at END, password_changed_at = CASE WHEN password = \$5 THEN password_changed_at ELSE \$8 END,\s+email_index = \$9, first_name_index = \$10, last_name_index = \$11, first_name_tokens = \$12, last_name_tokens = \$13, email_tokens = \$14\s+WHERE id = \$1 RETURNING \*`).
+		WithArgs(userEntity.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", sqlmock.AnyArg(), pii.BlindIndex("email", "alec@email.com"), pii.BlindIndex("first_name", "alec"), pii.BlindIndex("last_name", "smith"), searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
 
 	user, err := adapter.UpdateUser(
 		context.Background(),
@@ -211,7 +265,8 @@ func TestPostgresAdapter_UpdateUser_QueryErr(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntity := entities.User{
 		ID:        uuid.New(),
@@ -225,8 +280,8 @@ func TestPostgresAdapter_UpdateUser_QueryErr(t *testing.T) {
 		UpdatedAt: time.Now().UTC(),
 	}
 
-	mock.ExpectQuery(`UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8 WHERE id = \$1 RETURNING \*`).
-		WithArgs(userEntity.ID, "alec", "smith", "alecsmith", "somepassword", "alec@email.com", "UK", sqlmock.AnyArg()).
+	mock.ExpectQuery(`UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8,\s+email_verified_at = CASE WHEN email_index = \$9 THEN email_verified_at END, password_changed_at = CASE WHEN password = \$5 THEN password_changed_at ELSE \$8 END,\s+email_index = \$9, first_name_index = \$10, last_name_index = \$11, first_name_tokens = \$12, last_name_tokens = \$13, email_tokens = \$14\s+WHERE id = \$1 RETURNING \*`).
+		WithArgs(userEntity.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", sqlmock.AnyArg(), pii.BlindIndex("email", "alec@email.com"), pii.BlindIndex("first_name", "alec"), pii.BlindIndex("last_name", "smith"), searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("an error occurred"))
 
 	user, err := adapter.UpdateUser(
@@ -248,7 +303,8 @@ func TestPostgresAdapter_UpdateUser_NoUserReturned(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntity := entities.User{
 		ID:        uuid.New(),
@@ -262,9 +318,9 @@ func TestPostgresAdapter_UpdateUser_NoUserReturned(t *testing.T) {
 		UpdatedAt: time.Now().UTC(),
 	}
 
-	mock.ExpectQuery(`UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8 WHERE id = \$1 RETURNING \*`).
-		WithArgs(userEntity.ID, "alec", "smith", "alecsmith", "somepassword", "alec@email.com", "UK", sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}))
+	mock.ExpectQuery(`UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8,\s+email_verified_at = CASE WHEN email_index = \$9 THEN email_verified_at END, password_changed_at = CASE WHEN password = \$5 THEN password_changed_at ELSE \$8 END,\s+email_index = \$9, first_name_index = \$10, last_name_index = \$11, first_name_tokens = \$12, last_name_tokens = \$13, email_tokens = \$14\s+WHERE id = \$1 RETURNING \*`).
+		WithArgs(userEntity.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", sqlmock.AnyArg(), pii.BlindIndex("email", "alec@email.com"), pii.BlindIndex("first_name", "alec"), pii.BlindIndex("last_name", "smith"), searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows())
 
 	user, err := adapter.UpdateUser(
 		context.Background(),
@@ -285,7 +341,8 @@ func TestNewPostgresAdapter_GetPaginatedUsers_firstName(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntities := []entities.User{
 		{
@@ -312,12 +369,9 @@ func TestNewPostgresAdapter_GetPaginatedUsers_firstName(t *testing.T) {
 		},
 	}
 
-	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name ILIKE \$1 ORDER BY created_at, id LIMIT 2;`).
-		WithArgs("%alec%").
-		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}).
-				AddRow(userEntities[0].ID, userEntities[0].FirstName, userEntities[0].LastName, userEntities[0].Nickname, userEntities[0].Password, userEntities[0].Email, userEntities[0].Country, userEntities[0].CreatedAt, userEntities[0].UpdatedAt).
-				AddRow(userEntities[1].ID, userEntities[1].FirstName, userEntities[1].LastName, userEntities[1].Nickname, userEntities[1].Password, userEntities[1].Email, userEntities[1].Country, userEntities[1].CreatedAt, userEntities[1].UpdatedAt))
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 ORDER BY created_at, id LIMIT 2;`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec")).
+		WillReturnRows(newUserRows(userEntities...))
 
 	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "", "", "", "", entities.PageInfo{
 		NextPageToken: "",
@@ -333,7 +387,8 @@ func TestNewPostgresAdapter_GetPaginatedUsers_firstNameWithLastName(t *testing.T
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntities := []entities.User{
 		{
@@ -360,14 +415,11 @@ func TestNewPostgresAdapter_GetPaginatedUsers_firstNameWithLastName(t *testing.T
 		},
 	}
 
-	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name ILIKE \$1 AND last_name ILIKE \$2 ORDER BY created_at, id LIMIT 10;`).
-		WithArgs("%alec%", "%smith%").
-		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}).
-				AddRow(userEntities[0].ID, userEntities[0].FirstName, userEntities[0].LastName, userEntities[0].Nickname, userEntities[0].Password, userEntities[0].Email, userEntities[0].Country, userEntities[0].CreatedAt, userEntities[0].UpdatedAt).
-				AddRow(userEntities[1].ID, userEntities[1].FirstName, userEntities[1].LastName, userEntities[1].Nickname, userEntities[1].Password, userEntities[1].Email, userEntities[1].Country, userEntities[1].CreatedAt, userEntities[1].UpdatedAt))
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 AND last_name_tokens @> \$2 ORDER BY created_at, id LIMIT 10;`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec"), searchTokens(pii, "last_name", "smi", "mit", "ith")).
+		WillReturnRows(newUserRows(userEntities...))
 
-	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "smith", "", "", "", entities.PageInfo{
+	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "Alec", "Smith", "", "", "", entities.PageInfo{
 		NextPageToken: "",
 		PageSize:      10,
 	})
@@ -381,7 +433,8 @@ func TestNewPostgresAdapter_GetPaginatedUsers_allParams(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntities := []entities.User{
 		{
@@ -397,13 +450,11 @@ func TestNewPostgresAdapter_GetPaginatedUsers_allParams(t *testing.T) {
 		},
 	}
 
-	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name ILIKE \$1 AND last_name ILIKE \$2 AND nickname ILIKE \$3 AND email ILIKE \$4 AND country ILIKE \$5 ORDER BY created_at, id LIMIT 10;`).
-		WithArgs("%alec%", "%smith%", "%alecsmith%", "%alec@email.com%", "%UK%").
-		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}).
-				AddRow(userEntities[0].ID, userEntities[0].FirstName, userEntities[0].LastName, userEntities[0].Nickname, userEntities[0].Password, userEntities[0].Email, userEntities[0].Country, userEntities[0].CreatedAt, userEntities[0].UpdatedAt))
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 AND last_name_tokens @> \$2 AND nickname ILIKE \$3 AND email_tokens @> \$4 AND country ILIKE \$5 ORDER BY created_at, id LIMIT 10;`).
+		WithArgs(searchTokens(pii, "first_name", "al"), searchTokens(pii, "last_name", "s"), "%alecsmith%", searchTokens(pii, "email", "@em", "ema", "mai", "ail"), "%UK%").
+		WillReturnRows(newUserRows(userEntities...))
 
-	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "smith", "alecsmith", "alec@email.com", "UK", entities.PageInfo{
+	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "AL", "s", "alecsmith", "@email", "UK", entities.PageInfo{
 		NextPageToken: "",
 		PageSize:      10,
 	})
@@ -417,7 +468,8 @@ func TestNewPostgresAdapter_GetPaginatedUsers_WithNextPageToken(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	userEntities := []entities.User{
 		{
@@ -449,12 +501,9 @@ func TestNewPostgresAdapter_GetPaginatedUsers_WithNextPageToken(t *testing.T) {
 	token := fmt.Sprintf("%s|%s", createdAt.Format(time.RFC3339Nano), userID.String())
 	nextPageToken := base64.URLEncoding.EncodeToString([]byte(token))
 
-	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name ILIKE \$1 AND \(created_at, id\) > \(\$2, \$3\) ORDER BY created_at, id LIMIT 10;`).
-		WithArgs("%alec%", createdAt.UTC(), userID).
-		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at"}).
-				AddRow(userEntities[0].ID, userEntities[0].FirstName, userEntities[0].LastName, userEntities[0].Nickname, userEntities[0].Password, userEntities[0].Email, userEntities[0].Country, userEntities[0].CreatedAt, userEntities[0].UpdatedAt).
-				AddRow(userEntities[1].ID, userEntities[1].FirstName, userEntities[1].LastName, userEntities[1].Nickname, userEntities[1].Password, userEntities[1].Email, userEntities[1].Country, userEntities[1].CreatedAt, userEntities[1].UpdatedAt))
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 AND \(created_at, id\) > \(\$2, \$3\) ORDER BY created_at, id LIMIT 10;`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec"), createdAt.UTC(), userID).
+		WillReturnRows(newUserRows(userEntities...))
 
 	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "", "", "", "", entities.PageInfo{
 		NextPageToken: nextPageToken,
@@ -465,12 +514,44 @@ func TestNewPostgresAdapter_GetPaginatedUsers_WithNextPageToken(t *testing.T) {
 	g.Expect(users).To(HaveLen(2))
 }
 
+func TestNewPostgresAdapter_GetPaginatedUsers_TokensMatchWithoutName(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	now := time.Now().UTC()
+	// has every token of "alec" without containing it
+	lecale := entities.User{ID: uuid.New(), FirstName: "Lecale", Email: "lecale@email.com", CreatedAt: now, UpdatedAt: now}
+	alec := entities.User{ID: uuid.New(), FirstName: "Alec", Email: "alec@email.com", CreatedAt: now.Add(time.Second), UpdatedAt: now}
+	alecia := entities.User{ID: uuid.New(), FirstName: "Alecia", Email: "alecia@email.com", CreatedAt: now.Add(2 * time.Second), UpdatedAt: now}
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 ORDER BY created_at, id LIMIT 2;`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec")).
+		WillReturnRows(newUserRows(lecale, alec))
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 AND \(created_at, id\) > \(\$2, \$3\) ORDER BY created_at, id LIMIT 2;`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec"), alec.CreatedAt, alec.ID).
+		WillReturnRows(newUserRows(alecia))
+
+	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "", "", "", "", entities.PageInfo{
+		PageSize: 2,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(nextPageToken).ToNot(BeEmpty())
+	g.Expect(users).To(HaveLen(2))
+	g.Expect(users[0].ID).To(Equal(alec.ID))
+	g.Expect(users[1].ID).To(Equal(alecia.ID))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
 func TestNewPostgresAdapter_GetPaginatedUsers_InvalidNextPageToken(t *testing.T) {
 	g := NewWithT(t)
 	db, _, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
 
 	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "", "", "", "", entities.PageInfo{
 		NextPageToken: "invalid-page-token",
@@ -486,15 +567,16 @@ func TestNewPostgresAdapter_GetPaginatedUsers_QueryReturnsErr(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	g.Expect(err).ToNot(HaveOccurred())
 
-	adapter := adapters.NewPostgresAdapter(db)
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
 
 	createdAt := time.Now().UTC()
 	userID := uuid.New()
 	token := fmt.Sprintf("%s|%s", createdAt.Format(time.RFC3339Nano), userID.String())
 	nextPageToken := base64.URLEncoding.EncodeToString([]byte(token))
 
-	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name ILIKE \$1 ORDER BY created_at, id LIMIT 10;`).
-		WithArgs("%alec%").
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 ORDER BY created_at, id LIMIT 10;`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec")).
 		WillReturnError(errors.New("an error occurred"))
 
 	users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "alec", "", "", "", "", entities.PageInfo{
@@ -505,3 +587,2972 @@ func TestNewPostgresAdapter_GetPaginatedUsers_QueryReturnsErr(t *testing.T) {
 	g.Expect(nextPageToken).To(Equal(""))
 	g.Expect(users).To(BeEmpty())
 }
+
+func TestPostgresAdapter_GetUserByID(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Nickname:  "alecsmith",
+		Password:  "somepassword",
+		Email:     "alec@email.com",
+		Country:   "UK",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Status:    entities.UserStatusActive,
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = \$1;`).
+		WithArgs(userEntity.ID).
+		WillReturnRows(newUserRows(userEntity))
+
+	user, err := adapter.GetUserByID(context.Background(), userEntity.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+}
+
+func TestPostgresAdapter_GetUserByID_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = \$1;`).
+		WithArgs(userID).
+		WillReturnRows(newUserRows())
+
+	user, err := adapter.GetUserByID(context.Background(), userID)
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+	g.Expect(user).To(BeNil())
+}
+
+func TestPostgresAdapter_UpdateUserStatus(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	banExpiresAt := time.Now().Add(24 * time.Hour).UTC()
+	userEntity := entities.User{
+		ID:           uuid.New(),
+		FirstName:    "alec",
+		LastName:     "smith",
+		Nickname:     "alecsmith",
+		Password:     "somepassword",
+		Email:        "alec@email.com",
+		Country:      "UK",
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		Status:       entities.UserStatusBanned,
+		BanReason:    "cheating",
+		BanExpiresAt: &banExpiresAt,
+	}
+
+	mock.ExpectQuery(`UPDATE platform_user SET status = \$3, ban_reason = \$4, ban_expires_at = \$5, updated_at = \$6 WHERE id = \$1 AND status = \$2 RETURNING \*`).
+		WithArgs(userEntity.ID, entities.UserStatusActive, entities.UserStatusBanned, "cheating", &banExpiresAt, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
+
+	user, err := adapter.UpdateUserStatus(
+		context.Background(),
+		userEntity.ID,
+		entities.UserStatusActive,
+		entities.UserStatusBanned,
+		"cheating",
+		&banExpiresAt,
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+}
+
+func TestPostgresAdapter_UpdateUserStatus_StatusChanged(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	mock.ExpectQuery(`UPDATE platform_user SET status = \$3, ban_reason = \$4, ban_expires_at = \$5, updated_at = \$6 WHERE id = \$1 AND status = \$2 RETURNING \*`).
+		WithArgs(userID, entities.UserStatusActive, entities.UserStatusSuspended, "", nil, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows())
+
+	user, err := adapter.UpdateUserStatus(
+		context.Background(),
+		userID,
+		entities.UserStatusActive,
+		entities.UserStatusSuspended,
+		"",
+		nil,
+	)
+	g.Expect(err).To(MatchError(entities.ErrInvalidStatusTransition))
+	g.Expect(user).To(BeNil())
+}
+
+func TestPostgresAdapter_LiftExpiredBans(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now()
+	userIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	mock.ExpectQuery(`UPDATE platform_user SET status = CASE WHEN email_verified_at IS NULL THEN \$1 ELSE \$2 END, ban_reason = '', ban_expires_at = NULL, updated_at = \$3 WHERE status = \$4 AND ban_expires_at <= \$3 RETURNING id`).
+		WithArgs(entities.UserStatusPendingVerification, entities.UserStatusActive, now, entities.UserStatusBanned).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userIDs[0]).AddRow(userIDs[1]))
+
+	liftedUserIDs, err := adapter.LiftExpiredBans(context.Background(), now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(liftedUserIDs).To(Equal(userIDs))
+}
+
+func TestPostgresAdapter_LiftExpiredBans_QueryErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now()
+	mock.ExpectQuery(`UPDATE platform_user SET status = CASE`).
+		WithArgs(entities.UserStatusPendingVerification, entities.UserStatusActive, now, entities.UserStatusBanned).
+		WillReturnError(errors.New("an error occurred"))
+
+	liftedUserIDs, err := adapter.LiftExpiredBans(context.Background(), now)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(liftedUserIDs).To(BeNil())
+}
+
+func TestPostgresAdapter_CreateVerificationToken(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	token := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Email:     "alec@email.com",
+		Purpose:   entities.TokenPurposeEmailVerification,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	mock.ExpectExec(`INSERT INTO verification_token \(id, user_id, email, purpose, expires_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\)`).
+		WithArgs(token.ID, token.UserID, token.Email, token.Purpose, token.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.CreateVerificationToken(context.Background(), token)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestPostgresAdapter_VerifyEmail(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Email:     "alec@email.com",
+		Purpose:   entities.TokenPurposeEmailVerification,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	userEntity := entities.User{
+		ID:              token.UserID,
+		FirstName:       "alec",
+		LastName:        "smith",
+		Nickname:        "alecsmith",
+		Password:        "somepassword",
+		Email:           "alec@email.com",
+		Country:         "UK",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Status:          entities.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE verification_token SET used_at = \$2 WHERE id = \$1 AND user_id = \$3 AND purpose = \$4 AND used_at IS NULL AND expires_at > \$2`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE platform_user SET email_verified_at = \$3, status = CASE WHEN status = \$4 THEN \$5 ELSE status END, updated_at = \$3 WHERE id = \$1 AND email_index = \$2 RETURNING \*`).
+		WithArgs(token.UserID, pii.BlindIndex("email", token.Email), now, entities.UserStatusPendingVerification, entities.UserStatusActive).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectCommit()
+
+	user, err := adapter.VerifyEmail(context.Background(), token, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_VerifyEmail_TokenAlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Email:   "alec@email.com",
+		Purpose: entities.TokenPurposeEmailVerification,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE verification_token SET used_at`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	user, err := adapter.VerifyEmail(context.Background(), token, now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(user).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_VerifyEmail_EmailChanged(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Email:   "alec@email.com",
+		Purpose: entities.TokenPurposeEmailVerification,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE verification_token SET used_at`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE platform_user SET email_verified_at`).
+		WithArgs(token.UserID, pii.BlindIndex("email", token.Email), now, entities.UserStatusPendingVerification, entities.UserStatusActive).
+		WillReturnRows(newUserRows())
+	mock.ExpectRollback()
+
+	user, err := adapter.VerifyEmail(context.Background(), token, now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(user).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RequestEmailChange(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	pendingEmail := "new@email.com"
+	userEntity := entities.User{
+		ID:           uuid.New(),
+		Email:        "alec@email.com",
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		Status:       entities.UserStatusActive,
+		PendingEmail: &pendingEmail,
+	}
+	confirmToken := entities.VerificationToken{ID: uuid.New(), UserID: userEntity.ID, Email: pendingEmail, Purpose: entities.TokenPurposeEmailChange, ExpiresAt: time.Now().Add(time.Hour)}
+	revertToken := entities.VerificationToken{ID: uuid.New(), UserID: userEntity.ID, Email: userEntity.Email, Purpose: entities.TokenPurposeEmailChangeRevert, ExpiresAt: time.Now().Add(time.Hour)}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE platform_user SET pending_email = \$2, pending_email_index = \$4, updated_at = \$3 WHERE id = \$1 RETURNING \*`).
+		WithArgs(userEntity.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), pii.BlindIndex("email", pendingEmail)).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectExec(`UPDATE verification_token SET used_at = \$2 WHERE user_id = \$1 AND purpose = \$3 AND used_at IS NULL`).
+		WithArgs(userEntity.ID, sqlmock.AnyArg(), entities.TokenPurposeEmailChange).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, token := range []entities.VerificationToken{confirmToken, revertToken} {
+		mock.ExpectExec(`INSERT INTO verification_token \(id, user_id, email, purpose, expires_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\)`).
+			WithArgs(token.ID, token.UserID, token.Email, token.Purpose, token.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	user, err := adapter.RequestEmailChange(context.Background(), userEntity.ID, pendingEmail, confirmToken, revertToken)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RequestEmailChange_EmailAlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE platform_user SET pending_email`).
+		WithArgs(userID, sqlmock.AnyArg(), sqlmock.AnyArg(), pii.BlindIndex("email", "new@email.com")).
+		WillReturnError(errors.New(`pq: duplicate key value violates unique constraint "platform_user_email_index_key"`))
+	mock.ExpectRollback()
+
+	user, err := adapter.RequestEmailChange(context.Background(), userID, "new@email.com", entities.VerificationToken{}, entities.VerificationToken{})
+	g.Expect(err).To(MatchError(entities.ErrEmailAlreadyUsed))
+	g.Expect(user).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ConfirmEmailChange(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Email:   "new@email.com",
+		Purpose: entities.TokenPurposeEmailChange,
+	}
+	userEntity := entities.User{
+		ID:              token.UserID,
+		Email:           "new@email.com",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Status:          entities.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE verification_token SET used_at = \$2 WHERE id = \$1`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE platform_user SET email = pending_email, email_index = pending_email_index, pending_email = NULL, pending_email_index = NULL,\s+email_tokens = \$4, email_verified_at = \$3, updated_at = \$3 WHERE id = \$1 AND pending_email_index = \$2 RETURNING \*`).
+		WithArgs(token.UserID, pii.BlindIndex("email", token.Email), now, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectCommit()
+
+	user, err := adapter.ConfirmEmailChange(context.Background(), token, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ConfirmEmailChange_Superseded(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Email:   "new@email.com",
+		Purpose: entities.TokenPurposeEmailChange,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE verification_token SET used_at`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE platform_user SET email = pending_email`).
+		WithArgs(token.UserID, pii.BlindIndex("email", token.Email), now, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows())
+	mock.ExpectRollback()
+
+	user, err := adapter.ConfirmEmailChange(context.Background(), token, now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(user).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RevertEmailChange(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Email:   "alec@email.com",
+		Purpose: entities.TokenPurposeEmailChangeRevert,
+	}
+	userEntity := entities.User{
+		ID:              token.UserID,
+		Email:           "alec@email.com",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Status:          entities.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE verification_token SET used_at = \$2 WHERE id = \$1`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE verification_token SET used_at = \$2 WHERE user_id = \$1 AND purpose = \$3 AND used_at IS NULL`).
+		WithArgs(token.UserID, now, entities.TokenPurposeEmailChange).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`UPDATE platform_user SET email = \$2, email_index = \$4, email_tokens = \$5, pending_email = NULL, pending_email_index = NULL,\s+email_verified_at = \$3, updated_at = \$3 WHERE id = \$1 RETURNING \*`).
+		WithArgs(token.UserID, sqlmock.AnyArg(), now, pii.BlindIndex("email", token.Email), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectCommit()
+
+	user, err := adapter.RevertEmailChange(context.Background(), token, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetUserByEmail(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Nickname:  "alecsmith",
+		Password:  "somepassword",
+		Email:     "alec@email.com",
+		Country:   "UK",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Status:    entities.UserStatusActive,
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE email_index = \$1;`).
+		WithArgs(pii.BlindIndex("email", "alec@email.com")).
+		WillReturnRows(newUserRows(userEntity))
+
+	user, err := adapter.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+}
+
+func TestPostgresAdapter_GetUserByEmail_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE email_index = \$1;`).
+		WithArgs(pii.BlindIndex("email", "alec@email.com")).
+		WillReturnRows(newUserRows())
+
+	user, err := adapter.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+	g.Expect(user).To(BeNil())
+}
+
+func TestPostgresAdapter_CreatePasswordResetToken(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectExec(`INSERT INTO password_reset_token \(token_hash, user_id, expires_at\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs("token-hash", userID, expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.CreatePasswordResetToken(context.Background(), userID, "token-hash", expiresAt)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestPostgresAdapter_ResetPassword(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	userEntity := entities.User{
+		ID:                uuid.New(),
+		FirstName:         "alec",
+		LastName:          "smith",
+		Nickname:          "alecsmith",
+		Password:          "new-password",
+		Email:             "alec@email.com",
+		Country:           "UK",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Status:            entities.UserStatusActive,
+		PasswordChangedAt: &now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE password_reset_token SET used_at = \$2 WHERE token_hash = \$1 AND used_at IS NULL AND expires_at > \$2 RETURNING user_id`).
+		WithArgs("token-hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userEntity.ID))
+	mock.ExpectExec(`UPDATE password_reset_token SET used_at = \$2 WHERE user_id = \$1 AND used_at IS NULL`).
+		WithArgs(userEntity.ID, now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`UPDATE platform_user SET password = \$2, password_changed_at = \$3, updated_at = \$3 WHERE id = \$1 RETURNING \*`).
+		WithArgs(userEntity.ID, "new-password", now).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectQuery(`UPDATE user_session SET revoked_at = \$2 WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > \$2 RETURNING id`).
+		WithArgs(userEntity.ID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	user, err := adapter.ResetPassword(context.Background(), "token-hash", "new-password", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ResetPassword_InvalidToken(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE password_reset_token SET used_at`).
+		WithArgs("token-hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectRollback()
+
+	user, err := adapter.ResetPassword(context.Background(), "token-hash", "new-password", now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(user).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ChangePassword(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	userEntity := entities.User{
+		ID:                uuid.New(),
+		Password:          "new-password",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Status:            entities.UserStatusActive,
+		PasswordChangedAt: &now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE platform_user SET password = \$2, password_changed_at = \$3, updated_at = \$3 WHERE id = \$1 RETURNING \*`).
+		WithArgs(userEntity.ID, "new-password", now).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectQuery(`UPDATE user_session SET revoked_at = \$2 WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > \$2 RETURNING id`).
+		WithArgs(userEntity.ID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	user, err := adapter.ChangePassword(context.Background(), userEntity.ID, "new-password", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ChangePassword_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	userID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE platform_user SET password = \$2`).
+		WithArgs(userID, "new-password", now).
+		WillReturnRows(newUserRows())
+	mock.ExpectRollback()
+
+	user, err := adapter.ChangePassword(context.Background(), userID, "new-password", now)
+	g.Expect(err).To(MatchError(entities.ErrUserNotFound))
+	g.Expect(user).To(BeNil())
+}
+
+func TestPostgresAdapter_RedeemVerificationToken(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	token := entities.VerificationToken{ID: uuid.New(), UserID: uuid.New(), Purpose: entities.TokenPurposeMFAChallenge}
+
+	mock.ExpectExec(`UPDATE verification_token SET used_at = \$2 WHERE id = \$1 AND user_id = \$3 AND purpose = \$4 AND used_at IS NULL AND expires_at > \$2`).
+		WithArgs(token.ID, now, token.UserID, token.Purpose).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.RedeemVerificationToken(context.Background(), token, now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetTOTPEnrolment(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	confirmedAt := time.Now().UTC()
+	lastUsedCounter := int64(57000000)
+	enrolment := entities.TOTPEnrolment{
+		UserID:          uuid.New(),
+		EncryptedSecret: "sealed-secret",
+		ConfirmedAt:     &confirmedAt,
+		LastUsedCounter: &lastUsedCounter,
+		CreatedAt:       confirmedAt,
+	}
+
+	mock.ExpectQuery(`SELECT user_id, encrypted_secret, confirmed_at, last_used_counter, created_at FROM mfa_totp WHERE user_id = \$1`).
+		WithArgs(enrolment.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "encrypted_secret", "confirmed_at", "last_used_counter", "created_at"}).
+			AddRow(enrolment.UserID, enrolment.EncryptedSecret, confirmedAt, lastUsedCounter, enrolment.CreatedAt))
+
+	result, err := adapter.GetTOTPEnrolment(context.Background(), enrolment.UserID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(enrolment))
+}
+
+func TestPostgresAdapter_GetTOTPEnrolment_NotEnrolled(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT user_id, encrypted_secret, confirmed_at, last_used_counter, created_at FROM mfa_totp`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "encrypted_secret", "confirmed_at", "last_used_counter", "created_at"}))
+
+	result, err := adapter.GetTOTPEnrolment(context.Background(), userID)
+	g.Expect(err).To(MatchError(entities.ErrMFANotEnrolled))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_EnrolTOTP(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`INSERT INTO mfa_totp \(user_id, encrypted_secret, created_at\) VALUES \(\$1, \$2, \$3\)\s+ON CONFLICT \(user_id\) DO UPDATE .* WHERE mfa_totp.confirmed_at IS NULL`).
+		WithArgs(userID, "sealed-secret", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.EnrolTOTP(context.Background(), userID, "sealed-secret", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_EnrolTOTP_AlreadyEnabled(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`INSERT INTO mfa_totp`).
+		WithArgs(userID, "sealed-secret", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.EnrolTOTP(context.Background(), userID, "sealed-secret", now)
+	g.Expect(err).To(MatchError(entities.ErrMFAAlreadyEnabled))
+}
+
+func TestPostgresAdapter_ConfirmTOTP(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+	recoveryCodeHashes := []string{"first-hash", "second-hash"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE mfa_totp SET confirmed_at = \$2, last_used_counter = \$3 WHERE user_id = \$1 AND confirmed_at IS NULL`).
+		WithArgs(userID, now, int64(57000000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM mfa_recovery_code WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, codeHash := range recoveryCodeHashes {
+		mock.ExpectExec(`INSERT INTO mfa_recovery_code \(code_hash, user_id\) VALUES \(\$1, \$2\)`).
+			WithArgs(codeHash, userID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	err = adapter.ConfirmTOTP(context.Background(), userID, 57000000, recoveryCodeHashes, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ConfirmTOTP_AlreadyEnabled(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE mfa_totp SET confirmed_at`).
+		WithArgs(userID, now, int64(57000000)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = adapter.ConfirmTOTP(context.Background(), userID, 57000000, []string{"first-hash"}, now)
+	g.Expect(err).To(MatchError(entities.ErrMFAAlreadyEnabled))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DisableTOTP(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM mfa_totp WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM mfa_recovery_code WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectCommit()
+
+	err = adapter.DisableTOTP(context.Background(), userID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DisableTOTP_NotEnrolled(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM mfa_totp WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = adapter.DisableTOTP(context.Background(), userID)
+	g.Expect(err).To(MatchError(entities.ErrMFANotEnrolled))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_UseTOTPCounter_Replayed(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+
+	mock.ExpectExec(`UPDATE mfa_totp SET last_used_counter = \$2 WHERE user_id = \$1 AND confirmed_at IS NOT NULL AND \(last_used_counter IS NULL OR last_used_counter < \$2\)`).
+		WithArgs(userID, int64(57000000)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.UseTOTPCounter(context.Background(), userID, 57000000)
+	g.Expect(err).To(MatchError(entities.ErrInvalidMFACode))
+}
+
+func TestPostgresAdapter_UseRecoveryCode(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`UPDATE mfa_recovery_code SET used_at = \$3 WHERE code_hash = \$1 AND user_id = \$2 AND used_at IS NULL`).
+		WithArgs("some-hash", userID, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.UseRecoveryCode(context.Background(), userID, "some-hash", now)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestPostgresAdapter_UseRecoveryCode_AlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`UPDATE mfa_recovery_code SET used_at`).
+		WithArgs("some-hash", userID, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.UseRecoveryCode(context.Background(), userID, "some-hash", now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidMFACode))
+}
+
+func TestPostgresAdapter_CreateSession(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	session := entities.Session{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Device:    entities.SessionDevice{Name: "Alec's phone", UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.7"},
+		Scope:     "openid",
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().Add(time.Hour).UTC(),
+	}
+
+	mock.ExpectExec(`INSERT INTO user_session \(id, user_id, refresh_token_hash, device_name, user_agent, ip_address, scope, created_at,\s+expires_at\)`).
+		WithArgs(session.ID, session.UserID, "refresh-token-hash", "Alec's phone", "Mozilla/5.0", "203.0.113.7", "openid", session.CreatedAt, session.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.CreateSession(context.Background(), session, "refresh-token-hash")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+// newSessionRows builds a user_session result set, in the order the adapter selects it, from the given sessions
+func newSessionRows(sessions ...entities.Session) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "device_name", "user_agent", "ip_address", "scope", "created_at", "last_used_at", "expires_at", "revoked_at"})
+	for _, session := range sessions {
+		rows.AddRow(session.ID, session.UserID, session.Device.Name, session.Device.UserAgent, session.Device.IPAddress, session.Scope, session.CreatedAt, nullableTime(session.LastUsedAt), session.ExpiresAt, nullableTime(session.RevokedAt))
+	}
+
+	return rows
+}
+
+func TestPostgresAdapter_GetSessions(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+	sessions := []entities.Session{
+		{
+			ID:         uuid.New(),
+			UserID:     userID,
+			Device:     entities.SessionDevice{Name: "Alec's phone", UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.7"},
+			CreatedAt:  now.Add(-time.Hour),
+			LastUsedAt: &now,
+			ExpiresAt:  now.Add(time.Hour),
+		},
+		{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Device:    entities.SessionDevice{Name: "FACEIT Forum", UserAgent: "forum/1.0", IPAddress: "198.51.100.2"},
+			Scope:     "openid profile",
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, user_id, device_name, .* FROM user_session WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > \$2\s+ORDER BY created_at, id`).
+		WithArgs(userID, now).
+		WillReturnRows(newSessionRows(sessions...))
+
+	result, err := adapter.GetSessions(context.Background(), userID, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(sessions))
+}
+
+func TestPostgresAdapter_RefreshSession(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	device := entities.SessionDevice{UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.8"}
+	session := entities.Session{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Device:     entities.SessionDevice{Name: "Alec's phone", UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.8"},
+		CreatedAt:  now.Add(-time.Hour),
+		LastUsedAt: &now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+
+	mock.ExpectQuery(`UPDATE user_session SET refresh_token_hash = \$2, user_agent = \$3, ip_address = \$4, last_used_at = \$5\s+WHERE refresh_token_hash = \$1 AND revoked_at IS NULL AND expires_at > \$5 RETURNING id`).
+		WithArgs("refresh-token-hash", "new-refresh-token-hash", "Mozilla/5.0", "203.0.113.8", now).
+		WillReturnRows(newSessionRows(session))
+
+	result, err := adapter.RefreshSession(context.Background(), "refresh-token-hash", "new-refresh-token-hash", device, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(session))
+}
+
+func TestPostgresAdapter_RefreshSession_InvalidToken(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(`UPDATE user_session SET refresh_token_hash`).
+		WillReturnRows(newSessionRows())
+
+	result, err := adapter.RefreshSession(context.Background(), "refresh-token-hash", "new-refresh-token-hash", entities.SessionDevice{}, now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_RevokeSession_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`UPDATE user_session SET revoked_at = \$3 WHERE id = \$1 AND user_id = \$2 AND revoked_at IS NULL AND expires_at > \$3`).
+		WithArgs(sessionID, userID, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.RevokeSession(context.Background(), userID, sessionID, now)
+	g.Expect(err).To(MatchError(entities.ErrSessionNotFound))
+}
+
+func TestPostgresAdapter_RevokeSessions(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	sessionIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(`UPDATE user_session SET revoked_at = \$2 WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > \$2 RETURNING id`).
+		WithArgs(userID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(sessionIDs[0]).AddRow(sessionIDs[1]))
+
+	result, err := adapter.RevokeSessions(context.Background(), userID, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(sessionIDs))
+}
+
+func TestPostgresAdapter_RedeemWebAuthnCeremony(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+	ceremony := entities.WebAuthnCeremony{
+		ID:          uuid.New(),
+		UserID:      &userID,
+		Purpose:     entities.WebAuthnCeremonyRegistration,
+		SessionData: []byte(`{"challenge":"abc"}`),
+		ExpiresAt:   now.Add(5 * time.Minute),
+	}
+
+	mock.ExpectQuery(`DELETE FROM webauthn_ceremony WHERE id = \$1 AND purpose = \$2 AND expires_at > \$3\s+RETURNING id, user_id, purpose, session_data, expires_at`).
+		WithArgs(ceremony.ID, ceremony.Purpose, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "purpose", "session_data", "expires_at"}).
+			AddRow(ceremony.ID, userID, ceremony.Purpose, ceremony.SessionData, ceremony.ExpiresAt))
+
+	result, err := adapter.RedeemWebAuthnCeremony(context.Background(), ceremony.ID, ceremony.Purpose, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(ceremony))
+}
+
+func TestPostgresAdapter_RedeemWebAuthnCeremony_Invalid(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	ceremonyID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(`DELETE FROM webauthn_ceremony`).
+		WithArgs(ceremonyID, entities.WebAuthnCeremonyLogin, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "purpose", "session_data", "expires_at"}))
+
+	result, err := adapter.RedeemWebAuthnCeremony(context.Background(), ceremonyID, entities.WebAuthnCeremonyLogin, now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_GetWebAuthnCredentials(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	createdAt := time.Now().UTC()
+	credentials := []entities.WebAuthnCredential{
+		{
+			ID:              uuid.New(),
+			UserID:          userID,
+			CredentialID:    []byte("credential-1"),
+			PublicKey:       []byte("public-key-1"),
+			AttestationType: "none",
+			Transports:      []string{"internal", "hybrid"},
+			AAGUID:          make([]byte, 16),
+			SignCount:       7,
+			BackupEligible:  true,
+			BackupState:     true,
+			Name:            "Phone",
+			CreatedAt:       createdAt,
+			LastUsedAt:      &createdAt,
+		},
+		{
+			ID:              uuid.New(),
+			UserID:          userID,
+			CredentialID:    []byte("credential-2"),
+			PublicKey:       []byte("public-key-2"),
+			AttestationType: "packed",
+			Transports:      []string{},
+			AAGUID:          make([]byte, 16),
+			Name:            "Security key",
+			CreatedAt:       createdAt,
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{
+		"id", "user_id", "credential_id", "public_key", "attestation_type", "transports", "aaguid", "sign_count",
+		"backup_eligible", "backup_state", "name", "created_at", "last_used_at",
+	})
+	rows.AddRow(credentials[0].ID, userID, credentials[0].CredentialID, credentials[0].PublicKey, "none", "internal,hybrid",
+		credentials[0].AAGUID, int64(7), true, true, "Phone", createdAt, createdAt)
+	rows.AddRow(credentials[1].ID, userID, credentials[1].CredentialID, credentials[1].PublicKey, "packed", "",
+		credentials[1].AAGUID, int64(0), false, false, "Security key", createdAt, nil)
+
+	mock.ExpectQuery(`SELECT id, user_id, credential_id, .* FROM webauthn_credential WHERE user_id = \$1 ORDER BY created_at, id`).
+		WithArgs(userID).
+		WillReturnRows(rows)
+
+	result, err := adapter.GetWebAuthnCredentials(context.Background(), userID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(credentials))
+}
+
+func TestPostgresAdapter_CreateWebAuthnCredential(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	credential := entities.WebAuthnCredential{
+		ID:              uuid.New(),
+		UserID:          uuid.New(),
+		CredentialID:    []byte("credential"),
+		PublicKey:       []byte("public-key"),
+		AttestationType: "none",
+		Transports:      []string{"usb", "nfc"},
+		AAGUID:          make([]byte, 16),
+		Name:            "Security key",
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	mock.ExpectExec(`INSERT INTO webauthn_credential`).
+		WithArgs(credential.ID, credential.UserID, credential.CredentialID, credential.PublicKey, "none", "usb,nfc",
+			credential.AAGUID, int64(0), false, false, "Security key", credential.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.CreateWebAuthnCredential(context.Background(), credential)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_CreateWebAuthnCredential_AlreadyRegistered(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectExec(`INSERT INTO webauthn_credential`).
+		WillReturnError(errors.New(`pq: duplicate key value violates unique constraint "webauthn_credential_credential_id_key"`))
+
+	err = adapter.CreateWebAuthnCredential(context.Background(), entities.WebAuthnCredential{ID: uuid.New(), UserID: uuid.New()})
+	g.Expect(err).To(MatchError(entities.ErrWebAuthnCredentialExists))
+}
+
+func TestPostgresAdapter_UpdateWebAuthnCredentialUsage(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	usedAt := time.Now().UTC()
+
+	mock.ExpectExec(`UPDATE webauthn_credential SET sign_count = \$2, backup_state = \$3, last_used_at = \$4 WHERE credential_id = \$1`).
+		WithArgs([]byte("credential"), int64(8), true, usedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.UpdateWebAuthnCredentialUsage(context.Background(), []byte("credential"), 8, true, usedAt)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DeleteWebAuthnCredential(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	credentialID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM webauthn_credential WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(credentialID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.DeleteWebAuthnCredential(context.Background(), userID, credentialID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DeleteWebAuthnCredential_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	credentialID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM webauthn_credential`).
+		WithArgs(credentialID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.DeleteWebAuthnCredential(context.Background(), userID, credentialID)
+	g.Expect(err).To(MatchError(entities.ErrWebAuthnCredentialNotFound))
+}
+
+func TestPostgresAdapter_CreateOIDCClient_Public(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	client := entities.OIDCClient{
+		ID:            "forum",
+		Name:          "FACEIT Forum",
+		RedirectURIs:  []string{"https://forum.faceit.com/callback"},
+		AllowedScopes: []string{"openid", "profile"},
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	mock.ExpectExec(`INSERT INTO oidc_client \(id, secret_hash, name, redirect_uris, allowed_scopes, created_at\)`).
+		WithArgs("forum", nil, "FACEIT Forum", "{\"https://forum.faceit.com/callback\"}", "{\"openid\",\"profile\"}", client.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.CreateOIDCClient(context.Background(), client)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetOIDCClient(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	client := entities.OIDCClient{
+		ID:            "forum",
+		SecretHash:    "secret-hash",
+		Name:          "FACEIT Forum",
+		RedirectURIs:  []string{"https://forum.faceit.com/callback", "https://forum.faceit.com/alt"},
+		AllowedScopes: []string{"openid", "email"},
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	mock.ExpectQuery(`SELECT id, secret_hash, name, redirect_uris, allowed_scopes, created_at FROM oidc_client WHERE id = \$1`).
+		WithArgs("forum").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "secret_hash", "name", "redirect_uris", "allowed_scopes", "created_at"}).
+			AddRow("forum", "secret-hash", "FACEIT Forum", "{https://forum.faceit.com/callback,https://forum.faceit.com/alt}", "{openid,email}", client.CreatedAt))
+
+	result, err := adapter.GetOIDCClient(context.Background(), "forum")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(client))
+}
+
+func TestPostgresAdapter_GetOIDCClient_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectQuery(`SELECT .* FROM oidc_client`).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "secret_hash", "name", "redirect_uris", "allowed_scopes", "created_at"}))
+
+	result, err := adapter.GetOIDCClient(context.Background(), "unknown")
+	g.Expect(err).To(MatchError(entities.ErrOIDCClientNotFound))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_GetOIDCConsent_NotGranted(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT scopes FROM oidc_consent WHERE user_id = \$1 AND client_id = \$2`).
+		WithArgs(userID, "forum").
+		WillReturnRows(sqlmock.NewRows([]string{"scopes"}))
+
+	scopes, err := adapter.GetOIDCConsent(context.Background(), userID, "forum")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(scopes).To(BeEmpty())
+}
+
+func TestPostgresAdapter_GrantOIDCConsent(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`INSERT INTO oidc_consent .* ON CONFLICT \(user_id, client_id\) DO UPDATE`).
+		WithArgs(userID, "forum", "{\"openid\",\"email\"}", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.GrantOIDCConsent(context.Background(), userID, "forum", []string{"openid", "email"}, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RedeemOIDCAuthorizationCode(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	code := entities.OIDCAuthorizationCode{
+		ClientID:      "forum",
+		UserID:        uuid.New(),
+		RedirectURI:   "https://forum.faceit.com/callback",
+		Scopes:        []string{"openid", "profile"},
+		Nonce:         "nonce",
+		CodeChallenge: "challenge",
+		AuthTime:      now.Add(-time.Minute),
+		ExpiresAt:     now.Add(time.Minute),
+	}
+
+	mock.ExpectQuery(`DELETE FROM oidc_authorization_code WHERE code_hash = \$1 AND expires_at > \$2\s+RETURNING client_id`).
+		WithArgs("code-hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"client_id", "user_id", "redirect_uri", "scopes", "nonce", "code_challenge", "auth_time", "expires_at"}).
+			AddRow("forum", code.UserID, code.RedirectURI, "{openid,profile}", "nonce", "challenge", code.AuthTime, code.ExpiresAt))
+
+	result, err := adapter.RedeemOIDCAuthorizationCode(context.Background(), "code-hash", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(code))
+}
+
+func TestPostgresAdapter_RedeemOIDCAuthorizationCode_Invalid(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(`DELETE FROM oidc_authorization_code`).
+		WithArgs("code-hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"client_id", "user_id", "redirect_uri", "scopes", "nonce", "code_challenge", "auth_time", "expires_at"}))
+
+	result, err := adapter.RedeemOIDCAuthorizationCode(context.Background(), "code-hash", now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_GetSigningKeys(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	activatedAt := now.Add(-time.Hour)
+	expiresAt := now.Add(time.Hour)
+	keys := []entities.SigningKey{
+		{ID: "retired", Status: entities.SigningKeyStatusRetired, EncryptedPrivateKey: "sealed-1", CreatedAt: activatedAt, ActivatedAt: &activatedAt, RetiredAt: &activatedAt, ExpiresAt: &expiresAt},
+		{ID: "active", Status: entities.SigningKeyStatusActive, EncryptedPrivateKey: "sealed-2", CreatedAt: activatedAt, ActivatedAt: &activatedAt},
+		{ID: "next", Status: entities.SigningKeyStatusNext, EncryptedPrivateKey: "sealed-3", CreatedAt: activatedAt},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "status", "encrypted_private_key", "created_at", "activated_at", "retired_at", "expires_at"})
+	for _, key := range keys {
+		rows.AddRow(key.ID, string(key.Status), key.EncryptedPrivateKey, key.CreatedAt, nullableTime(key.ActivatedAt), nullableTime(key.RetiredAt), nullableTime(key.ExpiresAt))
+	}
+
+	mock.ExpectQuery(`SELECT id, status, encrypted_private_key, .* FROM signing_key\s+WHERE expires_at IS NULL OR expires_at > \$1 ORDER BY created_at, id`).
+		WithArgs(now).
+		WillReturnRows(rows)
+
+	result, err := adapter.GetSigningKeys(context.Background(), now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(keys))
+}
+
+func TestPostgresAdapter_CreateSigningKey_AlreadyExists(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	key := entities.SigningKey{ID: "next", Status: entities.SigningKeyStatusNext, EncryptedPrivateKey: "sealed", CreatedAt: time.Now().UTC()}
+
+	mock.ExpectExec(`INSERT INTO signing_key \(id, status, encrypted_private_key, created_at, activated_at\)`).
+		WithArgs("next", entities.SigningKeyStatusNext, "sealed", key.CreatedAt, nil).
+		WillReturnError(errors.New(`pq: duplicate key value violates unique constraint "signing_key_status_key"`))
+
+	err = adapter.CreateSigningKey(context.Background(), key)
+	g.Expect(err).To(MatchError(entities.ErrSigningKeysChanged))
+}
+
+func TestPostgresAdapter_RotateSigningKeys(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(48 * time.Hour)
+	next := entities.SigningKey{ID: "new-next", Status: entities.SigningKeyStatusNext, EncryptedPrivateKey: "sealed", CreatedAt: now}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE signing_key SET status = \$2, retired_at = \$3, expires_at = \$4 WHERE id = \$1 AND status = \$5`).
+		WithArgs("active", entities.SigningKeyStatusRetired, now, expiresAt, entities.SigningKeyStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE signing_key SET status = \$1, activated_at = \$2 WHERE status = \$3`).
+		WithArgs(entities.SigningKeyStatusActive, now, entities.SigningKeyStatusNext).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO signing_key`).
+		WithArgs("new-next", entities.SigningKeyStatusNext, "sealed", now, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM signing_key WHERE expires_at <= \$1`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = adapter.RotateSigningKeys(context.Background(), "active", next, now, expiresAt)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RotateSigningKeys_AlreadyRotated(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE signing_key SET status = \$2`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = adapter.RotateSigningKeys(context.Background(), "active", entities.SigningKey{ID: "new-next"}, now, now.Add(time.Hour))
+	g.Expect(err).To(MatchError(entities.ErrSigningKeysChanged))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetLoginThrottle(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	blockedUntil := now.Add(time.Minute)
+
+	mock.ExpectQuery(`SELECT failures, last_failure_at, blocked_until, locked FROM login_throttle WHERE key = \$1`).
+		WithArgs("ip:198.51.100.4").
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "blocked_until", "locked"}).
+			AddRow(5, now, blockedUntil, false))
+
+	throttle, err := adapter.GetLoginThrottle(context.Background(), "ip:198.51.100.4", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*throttle).To(Equal(entities.LoginThrottle{Failures: 5, LastFailureAt: now, BlockedUntil: &blockedUntil}))
+}
+
+func TestPostgresAdapter_GetLoginThrottle_NoFailures(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectQuery(`SELECT failures, last_failure_at, blocked_until, locked FROM login_throttle WHERE key = \$1`).
+		WithArgs("user:1").
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "blocked_until", "locked"}))
+
+	throttle, err := adapter.GetLoginThrottle(context.Background(), "user:1", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*throttle).To(Equal(entities.LoginThrottle{}))
+}
+
+func TestPostgresAdapter_RecordLoginFailure(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	policy := entities.LoginThrottlePolicy{
+		FreeFailures:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutFailures: 10,
+		LockoutDuration: 30 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+	blockedUntil := now.Add(2 * time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO login_throttle \(key, failures, last_failure_at\) VALUES \(\$1, 0, \$2\) ON CONFLICT \(key\) DO NOTHING`).
+		WithArgs("user:1", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT failures, last_failure_at, blocked_until, locked FROM login_throttle WHERE key = \$1 FOR UPDATE`).
+		WithArgs("user:1").
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "blocked_until", "locked"}).
+			AddRow(4, now.Add(-time.Minute), nil, false))
+	mock.ExpectExec(`UPDATE login_throttle SET failures = \$2, last_failure_at = \$3, blocked_until = \$4, locked = \$5 WHERE key = \$1`).
+		WithArgs("user:1", 5, now, &blockedUntil, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	throttle, err := adapter.RecordLoginFailure(context.Background(), "user:1", policy, now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*throttle).To(Equal(entities.LoginThrottle{Failures: 5, LastFailureAt: now, BlockedUntil: &blockedUntil}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RecordLoginFailure_ReturnsErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO login_throttle`).
+		WithArgs("user:1", now).
+		WillReturnError(errors.New("an error occurred"))
+	mock.ExpectRollback()
+
+	throttle, err := adapter.RecordLoginFailure(context.Background(), "user:1", entities.LoginThrottlePolicy{}, now)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(throttle).To(BeNil())
+}
+
+func TestPostgresAdapter_ResetLoginThrottle(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectExec(`DELETE FROM login_throttle WHERE key = \$1`).
+		WithArgs("user:1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.ResetLoginThrottle(context.Background(), "user:1")
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestPostgresAdapter_RecordLoginAttempt(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	attempt := entities.LoginAttempt{
+		ID:            uuid.New(),
+		UserID:        &userID,
+		Email:         "alec@email.com",
+		IPAddress:     "198.51.100.4",
+		UserAgent:     "faceit-test",
+		FailureReason: entities.LoginFailureIncorrectPassword,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	mock.ExpectExec(`INSERT INTO login_attempt \(id, user_id, email, ip_address, user_agent, succeeded, failure_reason, created_at\)\s+VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\)`).
+		WithArgs(attempt.ID, &userID, "alec@email.com", "198.51.100.4", "faceit-test", false, &attempt.FailureReason, attempt.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.RecordLoginAttempt(context.Background(), attempt)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestPostgresAdapter_GetPasswordResetTokenUser(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	user := entities.User{
+		ID:        uuid.New(),
+		Nickname:  "alecsmith",
+		Email:     "alec@email.com",
+		Status:    entities.UserStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	mock.ExpectQuery(`SELECT platform_user.\* FROM platform_user JOIN password_reset_token ON password_reset_token.user_id = platform_user.id\s+WHERE password_reset_token.token_hash = \$1 AND password_reset_token.used_at IS NULL AND password_reset_token.expires_at > \$2`).
+		WithArgs("token-hash", now).
+		WillReturnRows(newUserRows(user))
+
+	result, err := adapter.GetPasswordResetTokenUser(context.Background(), "token-hash", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(user))
+}
+
+func TestPostgresAdapter_GetPasswordResetTokenUser_InvalidToken(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	mock.ExpectQuery(`SELECT platform_user.\* FROM platform_user JOIN password_reset_token`).
+		WithArgs("token-hash", now).
+		WillReturnRows(newUserRows())
+
+	result, err := adapter.GetPasswordResetTokenUser(context.Background(), "token-hash", now)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_CreateAPIKey(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	key := entities.APIKey{
+		ID:         uuid.New(),
+		Name:       "matchmaking",
+		Owner:      "matchmaking-team",
+		Scopes:     []string{entities.APIKeyScopeUsersRead},
+		SecretHash: "secret-hash",
+		CreatedAt:  now,
+	}
+
+	mock.ExpectExec(`INSERT INTO api_key \(id, name, owner, scopes, secret_hash, created_at, expires_at\)`).
+		WithArgs(key.ID, "matchmaking", "matchmaking-team", "{\"users:read\"}", "secret-hash", now, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.CreateAPIKey(context.Background(), key)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_AuthenticateAPIKey(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(time.Hour)
+	key := entities.APIKey{
+		ID:         uuid.New(),
+		Name:       "billing",
+		Owner:      "billing-team",
+		Scopes:     []string{entities.APIKeyScopeUsersRead, entities.APIKeyScopeUsersWrite},
+		SecretHash: "secret-hash",
+		CreatedAt:  now.Add(-time.Hour),
+		ExpiresAt:  &expiresAt,
+		LastUsedAt: &now,
+	}
+
+	mock.ExpectQuery(`UPDATE api_key SET last_used_at = \$2\s+WHERE secret_hash = \$1 AND revoked_at IS NULL AND \(expires_at IS NULL OR expires_at > \$2\)\s+RETURNING id`).
+		WithArgs("secret-hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner", "scopes", "secret_hash", "created_at", "expires_at", "last_used_at", "revoked_at"}).
+			AddRow(key.ID, "billing", "billing-team", "{users:read,users:write}", "secret-hash", key.CreatedAt, expiresAt, now, nil))
+
+	result, err := adapter.AuthenticateAPIKey(context.Background(), "secret-hash", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(key))
+}
+
+func TestPostgresAdapter_AuthenticateAPIKey_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(`UPDATE api_key SET last_used_at`).
+		WithArgs("secret-hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner", "scopes", "secret_hash", "created_at", "expires_at", "last_used_at", "revoked_at"}))
+
+	result, err := adapter.AuthenticateAPIKey(context.Background(), "secret-hash", now)
+	g.Expect(err).To(MatchError(entities.ErrAPIKeyNotFound))
+	g.Expect(result).To(BeNil())
+}
+
+func TestPostgresAdapter_RevokeAPIKey_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	id := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`UPDATE api_key SET revoked_at = \$2 WHERE id = \$1 AND revoked_at IS NULL`).
+		WithArgs(id, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.RevokeAPIKey(context.Background(), id, now)
+	g.Expect(err).To(MatchError(entities.ErrAPIKeyNotFound))
+}
+
+func newAuditEntryRows(entries ...entities.AuditEntry) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"sequence", "actor", "actor_api_key_id", "action", "target_user_id", "details", "ip_address", "created_at", "previous_hash", "hash"})
+	for _, entry := range entries {
+		details, _ := json.Marshal(entry.Details)
+		rows.AddRow(entry.Sequence, entry.Actor, entry.ActorAPIKeyID, entry.Action, entry.TargetUserID, details, entry.IPAddress, entry.CreatedAt, entry.PreviousHash, entry.Hash)
+	}
+
+	return rows
+}
+
+func TestPostgresAdapter_AppendAuditEntry(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	userID := uuid.New()
+	last := entities.AuditEntry{
+		Actor:     "support-tooling",
+		Action:    entities.AuditActionUsersRead,
+		IPAddress: "198.51.100.9",
+		CreatedAt: now.Add(-time.Minute),
+	}.Chain(nil)
+	entry := entities.AuditEntry{
+		Actor:        "support-tooling",
+		Action:       entities.AuditActionAPIKeyRevoked,
+		TargetUserID: &userID,
+		Details:      map[string]string{"apiKeyId": "0b5a3d52-6b0e-4d4a-9f59-3d4b1f1d9e0a"},
+		IPAddress:    "198.51.100.9",
+		CreatedAt:    now,
+	}
+	expected := entry.Chain(&last)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT sequence, .* FROM audit_log ORDER BY sequence DESC LIMIT 1`).
+		WillReturnRows(newAuditEntryRows(last))
+	mock.ExpectExec(`INSERT INTO audit_log \(sequence, actor, .*\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10\)`).
+		WithArgs(int64(2), "support-tooling", nil, entities.AuditActionAPIKeyRevoked, &userID, []byte(`{"apiKeyId":"0b5a3d52-6b0e-4d4a-9f59-3d4b1f1d9e0a"}`), "198.51.100.9", now, last.Hash, expected.Hash).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	result, err := adapter.AppendAuditEntry(context.Background(), entry)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*result).To(Equal(expected))
+	g.Expect(result.VerifyFollows(&last)).To(Succeed())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_AppendAuditEntry_First(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	entry := entities.AuditEntry{
+		Actor:     "bootstrap",
+		Action:    entities.AuditActionAPIKeyCreated,
+		IPAddress: "198.51.100.9",
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE audit_log`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT sequence, .* FROM audit_log ORDER BY sequence DESC LIMIT 1`).
+		WillReturnRows(newAuditEntryRows())
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(int64(1), "bootstrap", nil, entities.AuditActionAPIKeyCreated, nil, []byte("null"), "198.51.100.9", entry.CreatedAt, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	result, err := adapter.AppendAuditEntry(context.Background(), entry)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.Sequence).To(Equal(int64(1)))
+	g.Expect(result.VerifyFollows(nil)).To(Succeed())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetAuditEntries(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	from := time.Now().UTC().Add(-time.Hour)
+	entry := entities.AuditEntry{
+		Actor:        "support-tooling",
+		Action:       entities.AuditActionUserBanned,
+		TargetUserID: &userID,
+		Details:      map[string]string{},
+		IPAddress:    "198.51.100.9",
+		CreatedAt:    time.Now().UTC(),
+	}.Chain(nil)
+
+	mock.ExpectQuery(`SELECT sequence, .* FROM audit_log WHERE sequence > \$1 AND action = \$2 AND target_user_id = \$3 AND created_at >= \$4 ORDER BY sequence LIMIT 50`).
+		WithArgs(int64(0), entities.AuditActionUserBanned, userID, from).
+		WillReturnRows(newAuditEntryRows(entry))
+
+	result, err := adapter.GetAuditEntries(context.Background(), entities.AuditFilter{
+		Action:       entities.AuditActionUserBanned,
+		TargetUserID: &userID,
+		From:         &from,
+		Limit:        50,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal([]entities.AuditEntry{entry}))
+	g.Expect(result[0].VerifyFollows(nil)).To(Succeed())
+}
+
+// sealUser returns the user with its PII encrypted the way it is stored
+func sealUser(g *WithT, cipher *adapters.PIICipher, user entities.User) entities.User {
+	for column, value := range map[string]*string{"first_name": &user.FirstName, "last_name": &user.LastName, "email": &user.Email} {
+		encrypted, err := cipher.Encrypt(*value, "platform_user:"+user.ID.String()+":"+column)
+		g.Expect(err).ToNot(HaveOccurred())
+		*value = encrypted
+	}
+
+	return user
+}
+
+// newDataKeyRows builds a data_key result set from the given keys
+func newDataKeyRows(dataKeys ...adapters.DataKey) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "master_key_id", "wrapped_key", "created_at"})
+	for _, dataKey := range dataKeys {
+		rows.AddRow(dataKey.ID, dataKey.MasterKeyID, dataKey.WrappedKey, dataKey.CreatedAt)
+	}
+
+	return rows
+}
+
+func TestPostgresAdapter_GetUserByID_EncryptedPII(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Nickname:  "alecsmith",
+		Password:  "somepassword",
+		Email:     "alec@email.com",
+		Country:   "UK",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Status:    entities.UserStatusActive,
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = \$1;`).
+		WithArgs(userEntity.ID).
+		WillReturnRows(newUserRows(sealUser(g, pii, userEntity)))
+
+	user, err := adapter.GetUserByID(context.Background(), userEntity.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+}
+
+func TestPostgresAdapter_GetUserByID_EncryptedForAnotherUser(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Email:     "alec@email.com",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Status:    entities.UserStatusActive,
+	}
+	// ciphertext copied from another user's row doesn't open
+	copied := sealUser(g, pii, userEntity)
+	copied.ID = uuid.New()
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = \$1;`).
+		WithArgs(copied.ID).
+		WillReturnRows(newUserRows(copied))
+
+	user, err := adapter.GetUserByID(context.Background(), copied.ID)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(user).To(BeNil())
+}
+
+func TestPostgresAdapter_GetUserByID_LoadsNewDataKey(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	// another instance created a data key since this one loaded its keys
+	other, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	dataKey, err := other.GenerateDataKey(time.Now().UTC())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(other.LoadDataKey(*dataKey, true)).To(Succeed())
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Email:     "alec@email.com",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Status:    entities.UserStatusActive,
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = \$1;`).
+		WithArgs(userEntity.ID).
+		WillReturnRows(newUserRows(sealUser(g, other, userEntity)))
+	mock.ExpectQuery(`SELECT id, master_key_id, wrapped_key, created_at FROM data_key WHERE id = \$1`).
+		WithArgs(dataKey.ID).
+		WillReturnRows(newDataKeyRows(*dataKey))
+
+	user, err := adapter.GetUserByID(context.Background(), userEntity.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(userEntity))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_LoadDataKeys(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	older, err := pii.GenerateDataKey(time.Now().UTC().Add(-time.Hour))
+	g.Expect(err).ToNot(HaveOccurred())
+	newer, err := pii.GenerateDataKey(time.Now().UTC())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mock.ExpectQuery(`SELECT id, master_key_id, wrapped_key, created_at FROM data_key ORDER BY created_at, id`).
+		WillReturnRows(newDataKeyRows(*older, *newer))
+
+	err = adapter.LoadDataKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	encrypted, err := pii.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(encrypted).To(HavePrefix("enc:v1:" + newer.ID.String()))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_LoadDataKeys_CreatesFirstKey(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	mock.ExpectQuery(`SELECT id, master_key_id, wrapped_key, created_at FROM data_key ORDER BY created_at, id`).
+		WillReturnRows(newDataKeyRows())
+	mock.ExpectExec(`INSERT INTO data_key \(id, master_key_id, wrapped_key, created_at\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs(sqlmock.AnyArg(), "test", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = adapter.LoadDataKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = pii.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_LoadDataKeys_InsertErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii, err := adapters.NewPIICipher([]string{"test:test-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	mock.ExpectQuery(`SELECT id, master_key_id, wrapped_key, created_at FROM data_key`).
+		WillReturnRows(newDataKeyRows())
+	mock.ExpectExec(`INSERT INTO data_key`).
+		WillReturnError(errors.New("an error occurred"))
+
+	err = adapter.LoadDataKeys(context.Background())
+	g.Expect(err).To(MatchError("an error occurred"))
+
+	// nothing can be encrypted with a key that wasn't stored
+	_, err = pii.Encrypt("alec@email.com", "some-user:email")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestPostgresAdapter_RewrapDataKeys(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	previous, err := adapters.NewPIICipher([]string{"old:old-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	dataKey, err := previous.GenerateDataKey(time.Now().UTC())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii, err := adapters.NewPIICipher([]string{"new:new-master-key", "old:old-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	mock.ExpectQuery(`SELECT id, master_key_id, wrapped_key, created_at FROM data_key WHERE master_key_id <> \$1 ORDER BY created_at, id`).
+		WithArgs("new").
+		WillReturnRows(newDataKeyRows(*dataKey))
+	mock.ExpectExec(`UPDATE data_key SET master_key_id = \$2, wrapped_key = \$3 WHERE id = \$1 AND master_key_id = \$4`).
+		WithArgs(dataKey.ID, "new", sqlmock.AnyArg(), "old").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rewrapped, err := adapter.RewrapDataKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(rewrapped).To(Equal(1))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RewrapDataKeys_UnknownMasterKey(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	previous, err := adapters.NewPIICipher([]string{"old:old-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	dataKey, err := previous.GenerateDataKey(time.Now().UTC())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii, err := adapters.NewPIICipher([]string{"new:new-master-key"}, "test-blind-index-key")
+	g.Expect(err).ToNot(HaveOccurred())
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	mock.ExpectQuery(`SELECT id, master_key_id, wrapped_key, created_at FROM data_key WHERE master_key_id <> \$1`).
+		WithArgs("new").
+		WillReturnRows(newDataKeyRows(*dataKey))
+
+	rewrapped, err := adapter.RewrapDataKeys(context.Background())
+	g.Expect(err).To(MatchError(ContainSubstring("master key not configured")))
+	g.Expect(rewrapped).To(Equal(0))
+}
+
+func TestPostgresAdapter_SealUsers(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	pendingEmail := "new@email.com"
+	userEntity := entities.User{
+		ID:           uuid.New(),
+		FirstName:    "Alec",
+		LastName:     "Smith",
+		Email:        "alec@email.com",
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		Status:       entities.UserStatusActive,
+		PendingEmail: &pendingEmail,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE email_index IS NULL OR email_tokens IS NULL ORDER BY id LIMIT \$1\s+FOR UPDATE SKIP LOCKED`).
+		WithArgs(100).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectExec(`UPDATE platform_user SET first_name = \$2, last_name = \$3, email = \$4, pending_email = \$5, email_index = \$6,\s+pending_email_index = \$7, first_name_index = \$8, last_name_index = \$9, first_name_tokens = \$10, last_name_tokens = \$11,\s+email_tokens = \$12 WHERE id = \$1`).
+		WithArgs(
+			userEntity.ID,
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+			pii.BlindIndex("email", "alec@email.com"),
+			pii.BlindIndex("email", "new@email.com"),
+			pii.BlindIndex("first_name", "alec"),
+			pii.BlindIndex("last_name", "smith"),
+			searchTokens(pii, "first_name", "a", "l", "e", "c", "al", "le", "ec", "ale", "lec"),
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	sealed, err := adapter.SealUsers(context.Background(), 100)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sealed).To(Equal(1))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_SealUsers_UpdateErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Email:     "alec@email.com",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE email_index IS NULL OR email_tokens IS NULL`).
+		WithArgs(100).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectExec(`UPDATE platform_user SET first_name`).
+		WillReturnError(errors.New("an error occurred"))
+	mock.ExpectRollback()
+
+	sealed, err := adapter.SealUsers(context.Background(), 100)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(sealed).To(Equal(0))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+// capturedUUID matches any uuid argument and keeps it, rows built with its text return it once it has been captured
+type capturedUUID struct {
+	text []byte
+}
+
+func newCapturedUUID() *capturedUUID {
+	return &capturedUUID{text: make([]byte, 36)}
+}
+
+func (c *capturedUUID) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok || uuid.Validate(s) != nil {
+		return false
+	}
+	copy(c.text, s)
+	return true
+}
+
+func (c *capturedUUID) value() uuid.UUID {
+	return uuid.MustParse(string(c.text))
+}
+
+func TestPostgresAdapter_ImportUsers(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	users := []entities.ImportedUser{
+		{Row: 1, FirstName: "Alec", LastName: "Smith", Nickname: "alecsmith", Password: "some-password", Email: "alec@email.com", Country: "UK"},
+		{Row: 3, FirstName: "Jane", LastName: "Doe", Nickname: "janedoe", Password: "some-password", Email: "jane@email.com", Country: "UK"},
+	}
+	userIDs := []*capturedUUID{newCapturedUUID(), newCapturedUUID()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TEMP TABLE user_import_staging \(LIKE platform_user INCLUDING DEFAULTS\) ON COMMIT DROP`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	copyIn := mock.ExpectPrepare(`COPY "user_import_staging" \("id", "first_name", "last_name", "nickname", "password", "email", "country", "email_index", "first_name_index", "last_name_index",\s+"first_name_tokens", "last_name_tokens", "email_tokens"\) FROM STDIN`)
+	for i, user := range users {
+		copyIn.ExpectExec().
+			WithArgs(
+				userIDs[i],
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				user.Nickname,
+				user.Password,
+				sqlmock.AnyArg(),
+				user.Country,
+				pii.BlindIndex("email", user.Email),
+				pii.BlindIndex("first_name", strings.ToLower(user.FirstName)),
+				pii.BlindIndex("last_name", strings.ToLower(user.LastName)),
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+				sqlmock.AnyArg(),
+			).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	copyIn.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO platform_user \(id, first_name, .+\) SELECT id, first_name, .+ FROM user_import_staging staged\s+WHERE NOT EXISTS \(SELECT 1 FROM platform_user WHERE pending_email_index = staged.email_index\)\s+ON CONFLICT \(email_index\) DO NOTHING RETURNING id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userIDs[0].text))
+	mock.ExpectCommit()
+
+	results, err := adapter.ImportUsers(context.Background(), users, false)
+	g.Expect(err).ToNot(HaveOccurred())
+	createdUserID := userIDs[0].value()
+	g.Expect(results).To(Equal([]entities.ImportRowResult{
+		{Row: 1, UserID: &createdUserID},
+		{Row: 3, Error: entities.ErrEmailAlreadyUsed.Error()},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ImportUsers_DryRun(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	users := []entities.ImportedUser{
+		{Row: 1, FirstName: "alec", LastName: "smith", Nickname: "alecsmith", Password: "some-password", Email: "alec@email.com", Country: "UK"},
+	}
+	userID := newCapturedUUID()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TEMP TABLE user_import_staging`).WillReturnResult(sqlmock.NewResult(0, 0))
+	copyIn := mock.ExpectPrepare(`COPY "user_import_staging"`)
+	copyIn.ExpectExec().
+		WithArgs(userID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO platform_user`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID.text))
+	mock.ExpectRollback()
+
+	results, err := adapter.ImportUsers(context.Background(), users, true)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.ImportRowResult{{Row: 1}}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ImportUsers_InsertErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TEMP TABLE user_import_staging`).WillReturnResult(sqlmock.NewResult(0, 0))
+	copyIn := mock.ExpectPrepare(`COPY "user_import_staging"`)
+	copyIn.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO platform_user`).
+		WillReturnError(errors.New("an error occurred"))
+	mock.ExpectRollback()
+
+	results, err := adapter.ImportUsers(context.Background(), []entities.ImportedUser{{Row: 1, Email: "alec@email.com"}}, false)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(results).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RecordImportResults(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	jobID := uuid.New()
+	userID := uuid.New()
+	results := []entities.ImportRowResult{
+		{Row: 1, UserID: &userID},
+		{Row: 2, Error: "missing country"},
+		{Row: 3, Error: entities.ErrEmailAlreadyUsed.Error()},
+	}
+
+	mock.ExpectBegin()
+	copyIn := mock.ExpectPrepare(`COPY "user_import_result" \("job_id", "row_number", "user_id", "error"\) FROM STDIN`)
+	copyIn.ExpectExec().WithArgs(jobID, 1, &userID, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithArgs(jobID, 2, nil, "missing country").WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithArgs(jobID, 3, nil, entities.ErrEmailAlreadyUsed.Error()).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE user_import_job SET imported_rows = imported_rows \+ \$2, failed_rows = failed_rows \+ \$3 WHERE id = \$1`).
+		WithArgs(jobID, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = adapter.RecordImportResults(context.Background(), jobID, results)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_FinishImportJob(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	jobID := uuid.New()
+	now := time.Now().UTC()
+
+	mock.ExpectExec(`UPDATE user_import_job SET status = \$2, error = \$3, finished_at = \$4 WHERE id = \$1 AND status = \$5`).
+		WithArgs(jobID, entities.ImportJobStatusFailed, "importing rows 1 to 3: an error occurred", now, entities.ImportJobStatusRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.FinishImportJob(context.Background(), jobID, entities.ImportJobStatusFailed, "importing rows 1 to 3: an error occurred", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_FinishImportJob_NotRunning(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectExec(`UPDATE user_import_job SET status`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = adapter.FinishImportJob(context.Background(), uuid.New(), entities.ImportJobStatusSucceeded, "", time.Now())
+	g.Expect(err).To(MatchError(entities.ErrImportJobNotFound))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_FailStaleImportJobs(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	now := time.Now().UTC()
+	staleBefore := now.Add(-time.Minute)
+	mock.ExpectExec(`UPDATE user_import_job SET status = \$1, error = \$2, finished_at = \$3\s+WHERE status = \$4 AND \(heartbeat_at IS NULL OR heartbeat_at < \$5\)`).
+		WithArgs(entities.ImportJobStatusFailed, "interrupted", now, entities.ImportJobStatusRunning, staleBefore).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	failed, err := adapter.FailStaleImportJobs(context.Background(), staleBefore, "interrupted", now)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(failed).To(Equal(2))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetImportJob(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	job := entities.ImportJob{
+		ID:           uuid.New(),
+		Format:       entities.ImportFormatNDJSON,
+		DryRun:       true,
+		Status:       entities.ImportJobStatusRunning,
+		ImportedRows: 2,
+		FailedRows:   1,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	mock.ExpectQuery(`SELECT id, format, dry_run, status, imported_rows, failed_rows, error, created_at, finished_at\s+FROM user_import_job WHERE id = \$1`).
+		WithArgs(job.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "format", "dry_run", "status", "imported_rows", "failed_rows", "error", "created_at", "finished_at"}).
+			AddRow(job.ID, job.Format, job.DryRun, string(job.Status), job.ImportedRows, job.FailedRows, nil, job.CreatedAt, nil))
+
+	found, err := adapter.GetImportJob(context.Background(), job.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*found).To(Equal(job))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetImportJob_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectQuery(`SELECT id, format, dry_run, status`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	found, err := adapter.GetImportJob(context.Background(), uuid.New())
+	g.Expect(err).To(MatchError(entities.ErrImportJobNotFound))
+	g.Expect(found).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetImportResults(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	jobID := uuid.New()
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT row_number, user_id, error FROM user_import_result WHERE job_id = \$1 AND row_number > \$2\s+ORDER BY row_number LIMIT \$3`).
+		WithArgs(jobID, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"row_number", "user_id", "error"}).
+			AddRow(2, nil, "missing country").
+			AddRow(3, userID, nil))
+
+	results, err := adapter.GetImportResults(context.Background(), jobID, 1, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.ImportRowResult{
+		{Row: 2, Error: "missing country"},
+		{Row: 3, UserID: &userID},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ExportUsers(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	// a full batch has to be followed by another fetch, the cursor is only done once a batch comes back short
+	firstBatch := make([]entities.User, 1000)
+	for i := range firstBatch {
+		firstBatch[i] = entities.User{ID: uuid.New(), FirstName: "alec", Country: "UK", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	}
+	lastUser := entities.User{ID: uuid.New(), FirstName: "alec", Country: "UK", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	// has every token of "alec" without containing it, so it isn't exported
+	lecale := entities.User{ID: uuid.New(), FirstName: "Lecale", Country: "UK", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE user_export NO SCROLL CURSOR FOR SELECT \* FROM platform_user WHERE 1=1 AND first_name_tokens @> \$1 AND country ILIKE \$2 ORDER BY created_at, id`).
+		WithArgs(searchTokens(pii, "first_name", "ale", "lec"), "%UK%").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 1000 FROM user_export`).
+		WillReturnRows(newUserRows(firstBatch...))
+	mock.ExpectQuery(`FETCH FORWARD 1000 FROM user_export`).
+		WillReturnRows(newUserRows(lastUser, lecale))
+	mock.ExpectCommit()
+
+	exported := []uuid.UUID{}
+	err = adapter.ExportUsers(context.Background(), "alec", "", "", "", "UK", func(user entities.User) error {
+		exported = append(exported, user.ID)
+		return nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(exported).To(HaveLen(1001))
+	g.Expect(exported[0]).To(Equal(firstBatch[0].ID))
+	g.Expect(exported[1000]).To(Equal(lastUser.ID))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ExportUsers_EachReturnsErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userEntity := entities.User{ID: uuid.New(), CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE user_export NO SCROLL CURSOR FOR SELECT \* FROM platform_user WHERE 1=1 ORDER BY created_at, id`).
+		WithoutArgs().
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 1000 FROM user_export`).
+		WillReturnRows(newUserRows(userEntity, userEntity))
+	mock.ExpectRollback()
+
+	calls := 0
+	err = adapter.ExportUsers(context.Background(), "", "", "", "", "", func(user entities.User) error {
+		calls++
+		return errors.New("an error occurred")
+	})
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(calls).To(Equal(1))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_ExportUsers_DeclareErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE user_export`).
+		WillReturnError(errors.New("an error occurred"))
+	mock.ExpectRollback()
+
+	err = adapter.ExportUsers(context.Background(), "", "", "", "", "", func(user entities.User) error {
+		return nil
+	})
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetUsersByIDs(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userEntities := []entities.User{
+		{ID: uuid.New(), FirstName: "alec", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+		{ID: uuid.New(), FirstName: "jane", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+	}
+	missingUserID := uuid.New()
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = ANY\(\$1\);`).
+		WithArgs(fmt.Sprintf(`{"%s","%s","%s"}`, userEntities[0].ID, missingUserID, userEntities[1].ID)).
+		WillReturnRows(newUserRows(userEntities...))
+
+	users, err := adapter.GetUsersByIDs(context.Background(), []uuid.UUID{userEntities[0].ID, missingUserID, userEntities[1].ID})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(HaveLen(2))
+	g.Expect(users[0].ID).To(Equal(userEntities[0].ID))
+	g.Expect(users[1].ID).To(Equal(userEntities[1].ID))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetUsersByIDs_QueryReturnsErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectQuery(`SELECT \* FROM platform_user WHERE id = ANY\(\$1\);`).
+		WillReturnError(errors.New("an error occurred"))
+
+	users, err := adapter.GetUsersByIDs(context.Background(), []uuid.UUID{uuid.New()})
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(users).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+const updateUserQuery = `UPDATE platform_user SET first_name = \$2, last_name = \$3, nickname = \$4, password = \$5, email = \$6, country = \$7, updated_at = \$8,\s+email_verified_at = CASE WHEN email_index = \$9 THEN email_verified_at END, password_changed_at = CASE WHEN password = \$5 THEN password_changed_at ELSE \$8 END,\s+email_index = \$9, first_name_index = \$10, last_name_index = \$11, first_name_tokens = \$12, last_name_tokens = \$13, email_tokens = \$14\s+WHERE id = \$1 RETURNING \*`
+
+func TestPostgresAdapter_UpdateUsers(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		LastName:  "smith",
+		Nickname:  "alecsmith",
+		Password:  "somepassword",
+		Email:     "alec@email.com",
+		Country:   "UK",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	missingUserID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(updateUserQuery).
+		WithArgs(userEntity.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "alecsmith", "somepassword", sqlmock.AnyArg(), "UK", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectQuery(updateUserQuery).
+		WithArgs(missingUserID, sqlmock.AnyArg(), sqlmock.AnyArg(), "janedoe", "somepassword", sqlmock.AnyArg(), "UK", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows())
+	mock.ExpectCommit()
+
+	results, err := adapter.UpdateUsers(context.Background(), []entities.UserUpdate{
+		{UserID: userEntity.ID, FirstName: "alec", LastName: "smith", Nickname: "alecsmith", Password: "somepassword", Email: "alec@email.com", Country: "UK"},
+		{UserID: missingUserID, FirstName: "jane", LastName: "doe", Nickname: "janedoe", Password: "somepassword", Email: "jane@email.com", Country: "UK"},
+	}, entities.BatchModeBestEffort)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.BatchItemResult{
+		{UserID: userEntity.ID, Status: entities.BatchItemStatusSucceeded, User: &userEntity},
+		{UserID: missingUserID, Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_UpdateUsers_AllOrNothing(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userEntity := entities.User{ID: uuid.New(), FirstName: "alec", Email: "alec@email.com", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	missingUserID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(updateUserQuery).WillReturnRows(newUserRows())
+	mock.ExpectQuery(updateUserQuery).WillReturnRows(newUserRows(userEntity))
+	mock.ExpectRollback()
+
+	results, err := adapter.UpdateUsers(context.Background(), []entities.UserUpdate{
+		{UserID: missingUserID, FirstName: "jane", Email: "jane@email.com"},
+		{UserID: userEntity.ID, FirstName: "alec", Email: "alec@email.com"},
+	}, entities.BatchModeAllOrNothing)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.BatchItemResult{
+		{UserID: missingUserID, Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+		{UserID: userEntity.ID, Status: entities.BatchItemStatusSkipped},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_UpdateUsers_QueryErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(updateUserQuery).WillReturnError(errors.New("an error occurred"))
+	mock.ExpectRollback()
+
+	_, err = adapter.UpdateUsers(context.Background(), []entities.UserUpdate{
+		{UserID: uuid.New(), FirstName: "alec", Email: "alec@email.com"},
+	}, entities.BatchModeBestEffort)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_UpdateUsers_Status(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userEntity := entities.User{
+		ID:        uuid.New(),
+		FirstName: "alec",
+		Email:     "alec@email.com",
+		Status:    entities.UserStatusSuspended,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	bannedUser := entities.User{
+		ID:        uuid.New(),
+		FirstName: "jane",
+		Nickname:  "janedoe",
+		Email:     "jane@email.com",
+		Status:    entities.UserStatusBanned,
+		BanReason: "cheating",
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	changedUserID := uuid.New()
+	const updateStatusQuery = `UPDATE platform_user SET status = \$3, ban_reason = \$4, ban_expires_at = \$5, updated_at = \$6 WHERE id = \$1 AND status = \$2 RETURNING \*`
+
+	mock.ExpectBegin()
+	// a status only update leaves the profile as it is
+	mock.ExpectQuery(updateStatusQuery).
+		WithArgs(userEntity.ID, entities.UserStatusActive, entities.UserStatusSuspended, "", nil, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(userEntity))
+	mock.ExpectQuery(updateUserQuery).
+		WithArgs(bannedUser.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "janedoe", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(entities.User{ID: bannedUser.ID, CreatedAt: bannedUser.CreatedAt, UpdatedAt: bannedUser.UpdatedAt}))
+	mock.ExpectQuery(updateStatusQuery).
+		WithArgs(bannedUser.ID, entities.UserStatusActive, entities.UserStatusBanned, "cheating", nil, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows(bannedUser))
+	mock.ExpectQuery(updateStatusQuery).
+		WithArgs(changedUserID, entities.UserStatusActive, entities.UserStatusSuspended, "", nil, sqlmock.AnyArg()).
+		WillReturnRows(newUserRows())
+	mock.ExpectCommit()
+
+	results, err := adapter.UpdateUsers(context.Background(), []entities.UserUpdate{
+		{UserID: userEntity.ID, StatusOnly: true, Status: entities.UserStatusSuspended, FromStatus: entities.UserStatusActive},
+		{
+			UserID:     bannedUser.ID,
+			FirstName:  "jane",
+			Nickname:   "janedoe",
+			Email:      "jane@email.com",
+			Status:     entities.UserStatusBanned,
+			FromStatus: entities.UserStatusActive,
+			BanReason:  "cheating",
+		},
+		{UserID: changedUserID, StatusOnly: true, Status: entities.UserStatusSuspended, FromStatus: entities.UserStatusActive},
+	}, entities.BatchModeBestEffort)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.BatchItemResult{
+		{UserID: userEntity.ID, Status: entities.BatchItemStatusSucceeded, User: &userEntity},
+		{UserID: bannedUser.ID, Status: entities.BatchItemStatusSucceeded, User: &bannedUser},
+		{UserID: changedUserID, Status: entities.BatchItemStatusFailed, Error: entities.ErrInvalidStatusTransition.Error()},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DeleteUsers(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).WithArgs(userIDs[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).WithArgs(userIDs[1]).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	results, err := adapter.DeleteUsers(context.Background(), userIDs, entities.BatchModeBestEffort)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.BatchItemResult{
+		{UserID: userIDs[0], Status: entities.BatchItemStatusSucceeded},
+		{UserID: userIDs[1], Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DeleteUsers_AllOrNothing(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).WithArgs(userIDs[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).WithArgs(userIDs[1]).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	results, err := adapter.DeleteUsers(context.Background(), userIDs, entities.BatchModeAllOrNothing)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.BatchItemResult{
+		{UserID: userIDs[0], Status: entities.BatchItemStatusSkipped},
+		{UserID: userIDs[1], Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+	}))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DeleteUsers_ExecErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM platform_user WHERE id = \$1;`).WillReturnError(errors.New("an error occurred"))
+	mock.ExpectRollback()
+
+	_, err = adapter.DeleteUsers(context.Background(), []uuid.UUID{uuid.New()}, entities.BatchModeBestEffort)
+	g.Expect(err).To(MatchError("an error occurred"))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_SearchUsers(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	users := []entities.User{
+		{ID: uuid.New(), FirstName: "alec", LastName: "smith", Nickname: "alecsmith", Email: "alec@email.com", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+		{ID: uuid.New(), FirstName: "jane", LastName: "doe", Nickname: "smithy", Email: "jane@email.com", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+		{ID: uuid.New(), FirstName: "Alex", LastName: "jones", Nickname: "aj", Email: "alex@email.com", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+		{ID: uuid.New(), FirstName: "lena", LastName: "brown", Nickname: "lb", Email: "lena@email.com", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+	}
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at", "status", "ban_reason", "ban_expires_at", "email_verified_at", "password_changed_at", "pending_email", "email_index", "pending_email_index", "first_name_index", "last_name_index", "first_name_tokens", "last_name_tokens", "email_tokens", "nickname_matched", "nickname_score", "first_name_shared", "last_name_shared", "email_shared"})
+	for i, extra := range [][]driver.Value{{true, 0.5, 5, 6, 5}, {true, 0.4, 0, 0, 0}, {false, 0.0, 3, 0, 3}, {false, 0.0, 1, 0, 1}} {
+		user := sealUser(g, pii, users[i])
+		rows.AddRow(append([]driver.Value{user.ID, user.FirstName, user.LastName, user.Nickname, user.Password, user.Email, user.Country, user.CreatedAt, user.UpdatedAt, "", "", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}, extra...)...)
+	}
+	// the search tokens are of the bigrams then trigrams of each word
+	ngrams := []string{"al", "le", "ec", "ale", "lec", "sm", "mt", "th", "smt", "mth"}
+
+	mock.ExpectQuery(`SELECT \* FROM \(\s+SELECT \*,\s+\$1 <% nickname OR to_tsvector\('simple', nickname\) @@ websearch_to_tsquery\('simple', \$1\) AS nickname_matched,.+first_name_tokens && \$2 OR last_name_tokens && \$3 OR email_tokens && \$4\s+\) matched\s+ORDER BY GREATEST\(.+LIMIT \$6;`).
+		WithArgs(
+			"alec smth",
+			searchTokens(pii, "first_name", ngrams...),
+			searchTokens(pii, "last_name", ngrams...),
+			searchTokens(pii, "email", ngrams...),
+			len(ngrams),
+			50,
+		).
+		WillReturnRows(rows)
+
+	results, err := adapter.SearchUsers(context.Background(), " alec smth ", 10)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(HaveLen(3))
+	g.Expect(results[0].User.FirstName).To(Equal("alec"))
+	g.Expect(results[0].Score).To(Equal(1.0))
+	// the misspelt last name still matches, as does the email containing the first name
+	g.Expect(results[0].MatchedFields).To(Equal([]string{entities.SearchFieldNickname, entities.SearchFieldFirstName, entities.SearchFieldLastName, entities.SearchFieldEmail}))
+	// encrypted names are scored once decrypted, so a similar one ranks above a worse nickname match
+	g.Expect(results[1].User.FirstName).To(Equal("Alex"))
+	g.Expect(results[1].Score).To(Equal(0.6))
+	g.Expect(results[1].MatchedFields).To(Equal([]string{entities.SearchFieldFirstName, entities.SearchFieldEmail}))
+	g.Expect(results[2].User.Email).To(Equal("jane@email.com"))
+	g.Expect(results[2].Score).To(Equal(0.4))
+	g.Expect(results[2].MatchedFields).To(Equal([]string{entities.SearchFieldNickname}))
+}
+
+func TestPostgresAdapter_SearchUsers_Limit(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pii := newTestPIICipher(g)
+	adapter := adapters.NewPostgresAdapter(db, pii)
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "nickname", "password", "email", "country", "created_at", "updated_at", "status", "ban_reason", "ban_expires_at", "email_verified_at", "password_changed_at", "pending_email", "email_index", "pending_email_index", "first_name_index", "last_name_index", "first_name_tokens", "last_name_tokens", "email_tokens", "nickname_matched", "nickname_score", "first_name_shared", "last_name_shared", "email_shared"})
+	for _, firstName := range []string{"alex", "alec", "alec"} {
+		user := sealUser(g, pii, entities.User{ID: uuid.New(), FirstName: firstName, Email: firstName + "@email.com", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()})
+		rows.AddRow(user.ID, user.FirstName, user.LastName, user.Nickname, user.Password, user.Email, user.Country, user.CreatedAt, user.UpdatedAt, "", "", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, false, 0.0, 3, 0, 3)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM \(`).
+		WithArgs("alec", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 5, 10).
+		WillReturnRows(rows)
+
+	results, err := adapter.SearchUsers(context.Background(), "alec", 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results[0].User.FirstName).To(Equal("alec"))
+	g.Expect(results[0].Score).To(Equal(1.0))
+	g.Expect(results[1].User.FirstName).To(Equal("alec"))
+}
+
+func TestPostgresAdapter_SearchUsers_QueryReturnsErr(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectQuery(`SELECT \* FROM \(`).WillReturnError(errors.New("an error occurred"))
+
+	_, err = adapter.SearchUsers(context.Background(), "alec", 10)
+	g.Expect(err).To(MatchError("an error occurred"))
+}
+
+func TestPostgresAdapter_GetUserCommandReply(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	reply := entities.UserCommandReply{
+		CorrelationID: "some-correlation-id",
+		Type:          entities.UserCommandCreate,
+		Status:        entities.UserCommandStatusFailed,
+		Error:         entities.UserCommandErrorPasswordRejected,
+		Violations:    []string{"too_short", "breached"},
+		ProcessedAt:   time.Now().UTC(),
+	}
+
+	mock.ExpectQuery(`SELECT correlation_id, type, status, user_id, error, violations, processed_at\s+FROM user_command_reply WHERE correlation_id = \$1`).
+		WithArgs(reply.CorrelationID).
+		WillReturnRows(sqlmock.NewRows([]string{"correlation_id", "type", "status", "user_id", "error", "violations", "processed_at"}).
+			AddRow(reply.CorrelationID, reply.Type, reply.Status, nil, reply.Error, "{too_short,breached}", reply.ProcessedAt))
+
+	found, err := adapter.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*found).To(Equal(reply))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_GetUserCommandReply_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	mock.ExpectQuery(`SELECT correlation_id, type, status`).
+		WillReturnRows(sqlmock.NewRows([]string{"correlation_id"}))
+
+	found, err := adapter.GetUserCommandReply(context.Background(), "some-correlation-id")
+	g.Expect(err).To(MatchError(entities.ErrUserCommandReplyNotFound))
+	g.Expect(found).To(BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_RecordUserCommandReply(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	userID := uuid.New()
+	reply := entities.UserCommandReply{
+		CorrelationID: "some-correlation-id",
+		Type:          entities.UserCommandCreate,
+		Status:        entities.UserCommandStatusSucceeded,
+		UserID:        &userID,
+		ProcessedAt:   time.Now().UTC(),
+	}
+
+	mock.ExpectExec(`INSERT INTO user_command_reply \(correlation_id, type, status, user_id, error, violations, processed_at\)\s+VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\)\s+ON CONFLICT \(correlation_id\) DO UPDATE`).
+		WithArgs(reply.CorrelationID, reply.Type, reply.Status, userID, "", sqlmock.AnyArg(), reply.ProcessedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = adapter.RecordUserCommandReply(context.Background(), reply)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
+
+func TestPostgresAdapter_DeleteUserCommandReplies(t *testing.T) {
+	g := NewWithT(t)
+	db, mock, err := sqlmock.New()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	adapter := adapters.NewPostgresAdapter(db, newTestPIICipher(g))
+
+	processedBefore := time.Now().UTC().Add(-time.Hour)
+	mock.ExpectExec(`DELETE FROM user_command_reply WHERE processed_at < \$1`).
+		WithArgs(processedBefore).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	deleted, err := adapter.DeleteUserCommandReplies(context.Background(), processedBefore)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(Equal(3))
+	g.Expect(mock.ExpectationsWereMet()).To(Succeed())
+}
@@ -0,0 +1,350 @@
+package adapters
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const rsaKeyBits = 2048
+
+var errNoActiveSigningKey = errors.New("no active signing key loaded")
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/adapters/signingKeyStore.go  . "SigningKeyStore"
+type SigningKeyStore interface {
+	GetSigningKeys(ctx context.Context, now time.Time) ([]entities.SigningKey, error)
+	CreateSigningKey(ctx context.Context, key entities.SigningKey) error
+	RotateSigningKeys(ctx context.Context, activeKeyID string, next entities.SigningKey, now, retiredKeyExpiresAt time.Time) error
+}
+
+// RSAIDTokenSigner signs ID tokens as RS256 JWTs with a single RSA key
+type RSAIDTokenSigner struct {
+	key   *rsa.PrivateKey
+	keyID string
+}
+
+var _ usecases.IDTokenSigner = &RSAIDTokenSigner{}
+
+// NewRSAIDTokenSigner signs with the RSA private key in a PEM block, in either PKCS #1 or PKCS #8 form
+func NewRSAIDTokenSigner(privateKeyPEM []byte) (*RSAIDTokenSigner, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsedKey, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("parsing private key: %w", pkcs8Err)
+		}
+
+		var ok bool
+		key, ok = parsedKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key isn't an RSA key")
+		}
+	}
+
+	return newRSAIDTokenSigner(key), nil
+}
+
+// GenerateRSAIDTokenSigner signs with a newly generated key, which is lost when the service stops
+func GenerateRSAIDTokenSigner() (*RSAIDTokenSigner, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("generating rsa key: %w", err)
+	}
+
+	return newRSAIDTokenSigner(key), nil
+}
+
+func newRSAIDTokenSigner(key *rsa.PrivateKey) *RSAIDTokenSigner {
+	return &RSAIDTokenSigner{key: key, keyID: rsaKeyID(&key.PublicKey)}
+}
+
+func (s *RSAIDTokenSigner) SignIDToken(_ context.Context, claims entities.IDTokenClaims) (string, error) {
+	return signRS256JWT(s.key, s.keyID, claims)
+}
+
+func (s *RSAIDTokenSigner) PublicKeys(_ context.Context) ([]entities.JSONWebKey, error) {
+	return []entities.JSONWebKey{rsaJSONWebKey(&s.key.PublicKey, s.keyID)}, nil
+}
+
+// rsaKeyID derives a key ID from the public key, so that the same key always gets the same kid
+func rsaKeyID(publicKey *rsa.PublicKey) string {
+	hash := sha256.Sum256(x509.MarshalPKCS1PublicKey(publicKey))
+	return base64.RawURLEncoding.EncodeToString(hash[:12])
+}
+
+func rsaJSONWebKey(publicKey *rsa.PublicKey, keyID string) entities.JSONWebKey {
+	return entities.JSONWebKey{
+		KeyType:   "RSA",
+		Use:       "sig",
+		KeyID:     keyID,
+		Algorithm: "RS256",
+		Modulus:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+	}
+}
+
+// signRS256JWT encodes the claims as a JWT signed with RSASSA-PKCS1-v1_5 and SHA-256 (RFC 7518)
+func signRS256JWT(key *rsa.PrivateKey, keyID string, claims any) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	if err != nil {
+		slog.Debug("unable to convert jwt header to json", "err", err)
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		slog.Debug("unable to convert jwt claims to json", "err", err)
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		slog.Debug("unable to sign jwt", "err", err)
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// RotatingRSAIDTokenSigner signs ID tokens with the active key in the SigningKeyStore and publishes the public half of
+// every key that hasn't expired. Keys move from next to active to retired, so relying services see a key before
+// anything is signed with it, and can still check tokens signed with it until retention has passed.
+type RotatingRSAIDTokenSigner struct {
+	store            SigningKeyStore
+	secretBox        usecases.SecretBox
+	rotationInterval time.Duration
+	retention        time.Duration
+
+	mu         sync.RWMutex
+	active     *RSAIDTokenSigner
+	publicKeys []publishedSigningKey
+}
+
+// publishedSigningKey is a public key along with when it stops being published
+type publishedSigningKey struct {
+	key       entities.JSONWebKey
+	expiresAt *time.Time
+}
+
+var _ usecases.IDTokenSigner = &RotatingRSAIDTokenSigner{}
+
+// NewRotatingRSAIDTokenSigner creates a RotatingRSAIDTokenSigner, Refresh must be called before it can sign anything.
+// Retention should be longer than ID tokens are valid for.
+func NewRotatingRSAIDTokenSigner(store SigningKeyStore, secretBox usecases.SecretBox, rotationInterval, retention time.Duration) *RotatingRSAIDTokenSigner {
+	return &RotatingRSAIDTokenSigner{
+		store:            store,
+		secretBox:        secretBox,
+		rotationInterval: rotationInterval,
+		retention:        retention,
+	}
+}
+
+// Refresh creates the active and next keys if they don't exist, rotates them once the active key is due, and reloads
+// the keys from the store. It runs on startup and then periodically, which is also how instances pick up keys created
+// or rotated by each other.
+func (s *RotatingRSAIDTokenSigner) Refresh(ctx context.Context) error {
+	now := time.Now()
+	keys, err := s.store.GetSigningKeys(ctx, now)
+	if err != nil {
+		return fmt.Errorf("getting signing keys: %w", err)
+	}
+
+	changed, err := s.rotateIfDue(ctx, keys, now)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		keys, err = s.store.GetSigningKeys(ctx, now)
+		if err != nil {
+			return fmt.Errorf("getting signing keys: %w", err)
+		}
+	}
+
+	return s.load(keys)
+}
+
+// rotateIfDue makes any changes needed to the stored keys, reporting whether it did. Another instance making the same
+// change first isn't an error, its keys are used instead.
+func (s *RotatingRSAIDTokenSigner) rotateIfDue(ctx context.Context, keys []entities.SigningKey, now time.Time) (bool, error) {
+	active := findSigningKey(keys, entities.SigningKeyStatusActive)
+	next := findSigningKey(keys, entities.SigningKeyStatusNext)
+
+	if active == nil || next == nil {
+		for _, status := range []entities.SigningKeyStatus{entities.SigningKeyStatusActive, entities.SigningKeyStatusNext} {
+			if findSigningKey(keys, status) != nil {
+				continue
+			}
+
+			key, err := s.generateSigningKey(status, now)
+			if err != nil {
+				return false, err
+			}
+
+			err = s.store.CreateSigningKey(ctx, key)
+			if err != nil {
+				if errors.Is(err, entities.ErrSigningKeysChanged) {
+					slog.Debug("signing key already created by another instance", "status", status)
+					continue
+				}
+				return false, fmt.Errorf("creating %s signing key: %w", status, err)
+			}
+			slog.Info("created signing key", "kid", key.ID, "status", status)
+		}
+
+		return true, nil
+	}
+
+	if active.ActivatedAt != nil && now.Before(active.ActivatedAt.Add(s.rotationInterval)) {
+		return false, nil
+	}
+
+	newNext, err := s.generateSigningKey(entities.SigningKeyStatusNext, now)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.store.RotateSigningKeys(ctx, active.ID, newNext, now, now.Add(s.retention))
+	if err != nil {
+		if errors.Is(err, entities.ErrSigningKeysChanged) {
+			slog.Debug("signing keys already rotated by another instance")
+			return true, nil
+		}
+		return false, fmt.Errorf("rotating signing keys: %w", err)
+	}
+	slog.Info("rotated signing keys", "activeKID", next.ID, "retiredKID", active.ID, "nextKID", newNext.ID)
+
+	return true, nil
+}
+
+// load decrypts the active key for signing and replaces the published keys
+func (s *RotatingRSAIDTokenSigner) load(keys []entities.SigningKey) error {
+	var active *RSAIDTokenSigner
+	publicKeys := make([]publishedSigningKey, 0, len(keys))
+	for _, key := range keys {
+		privateKey, err := s.openSigningKey(key)
+		if err != nil {
+			return err
+		}
+
+		if key.Status == entities.SigningKeyStatusActive {
+			active = &RSAIDTokenSigner{key: privateKey, keyID: key.ID}
+		}
+		publicKeys = append(publicKeys, publishedSigningKey{
+			key:       rsaJSONWebKey(&privateKey.PublicKey, key.ID),
+			expiresAt: key.ExpiresAt,
+		})
+	}
+
+	if active == nil {
+		return errNoActiveSigningKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = active
+	s.publicKeys = publicKeys
+
+	return nil
+}
+
+// generateSigningKey creates a new RSA key, encrypted with its kid as associated data so that it can't be swapped with
+// another key's row
+func (s *RotatingRSAIDTokenSigner) generateSigningKey(status entities.SigningKeyStatus, now time.Time) (entities.SigningKey, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return entities.SigningKey{}, fmt.Errorf("generating signing key: %w", err)
+	}
+
+	keyID := rsaKeyID(&privateKey.PublicKey)
+	encryptedPrivateKey, err := s.secretBox.Seal(x509.MarshalPKCS1PrivateKey(privateKey), []byte(keyID))
+	if err != nil {
+		return entities.SigningKey{}, fmt.Errorf("encrypting signing key: %w", err)
+	}
+
+	key := entities.SigningKey{
+		ID:                  keyID,
+		Status:              status,
+		EncryptedPrivateKey: encryptedPrivateKey,
+		CreatedAt:           now,
+	}
+	if status == entities.SigningKeyStatusActive {
+		key.ActivatedAt = &now
+	}
+
+	return key, nil
+}
+
+func (s *RotatingRSAIDTokenSigner) openSigningKey(key entities.SigningKey) (*rsa.PrivateKey, error) {
+	der, err := s.secretBox.Open(key.EncryptedPrivateKey, []byte(key.ID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting signing key %s: %w", key.ID, err)
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key %s: %w", key.ID, err)
+	}
+
+	return privateKey, nil
+}
+
+func (s *RotatingRSAIDTokenSigner) SignIDToken(ctx context.Context, claims entities.IDTokenClaims) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.active == nil {
+		return "", errNoActiveSigningKey
+	}
+
+	return s.active.SignIDToken(ctx, claims)
+}
+
+// PublicKeys returns the next, active and unexpired retired keys
+func (s *RotatingRSAIDTokenSigner) PublicKeys(_ context.Context) ([]entities.JSONWebKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]entities.JSONWebKey, 0, len(s.publicKeys))
+	for _, published := range s.publicKeys {
+		if published.expiresAt != nil && !now.Before(*published.expiresAt) {
+			continue
+		}
+		keys = append(keys, published.key)
+	}
+
+	return keys, nil
+}
+
+func findSigningKey(keys []entities.SigningKey, status entities.SigningKeyStatus) *entities.SigningKey {
+	for i := range keys {
+		if keys[i].Status == status {
+			return &keys[i]
+		}
+	}
+
+	return nil
+}
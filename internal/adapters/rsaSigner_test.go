@@ -0,0 +1,318 @@
+package adapters_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	mock_adapters "github.com/AlecSmith96/faceit-user-service/mocks/adapters"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verifyRS256JWT checks the token's signature against the published key and decodes its header and claims
+func verifyRS256JWT(g *WithT, token string, key entities.JSONWebKey) (map[string]string, entities.IDTokenClaims) {
+	parts := strings.Split(token, ".")
+	g.Expect(parts).To(HaveLen(3))
+
+	modulus, err := base64.RawURLEncoding.DecodeString(key.Modulus)
+	g.Expect(err).ToNot(HaveOccurred())
+	exponent, err := base64.RawURLEncoding.DecodeString(key.Exponent)
+	g.Expect(err).ToNot(HaveOccurred())
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	g.Expect(err).ToNot(HaveOccurred())
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	g.Expect(rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature)).To(Succeed())
+
+	var header map[string]string
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(json.Unmarshal(headerJSON, &header)).To(Succeed())
+
+	var claims entities.IDTokenClaims
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(json.Unmarshal(claimsJSON, &claims)).To(Succeed())
+
+	return header, claims
+}
+
+func TestRSAIDTokenSigner_SignIDToken(t *testing.T) {
+	g := NewWithT(t)
+
+	signer, err := adapters.GenerateRSAIDTokenSigner()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	claims := entities.IDTokenClaims{
+		UserInfoClaims: entities.UserInfoClaims{Subject: "user-id", Nickname: "AlecSmith96"},
+		Issuer:         "http://localhost:8080",
+		Audience:       "forum",
+		ExpiresAt:      1719136800,
+		IssuedAt:       1719133200,
+		AuthTime:       1719133200,
+		Nonce:          "nonce",
+	}
+
+	token, err := signer.SignIDToken(context.Background(), claims)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	keys, err := signer.PublicKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(keys).To(HaveLen(1))
+	g.Expect(keys[0].Algorithm).To(Equal("RS256"))
+	g.Expect(keys[0].Exponent).To(Equal("AQAB"))
+
+	header, parsedClaims := verifyRS256JWT(g, token, keys[0])
+	g.Expect(header).To(Equal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keys[0].KeyID}))
+	g.Expect(parsedClaims).To(Equal(claims))
+}
+
+func TestNewRSAIDTokenSigner(t *testing.T) {
+	g := NewWithT(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pkcs1Signer, err := adapters.NewRSAIDTokenSigner(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	g.Expect(err).ToNot(HaveOccurred())
+	pkcs8Signer, err := adapters.NewRSAIDTokenSigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pkcs1Keys, err := pkcs1Signer.PublicKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	pkcs8Keys, err := pkcs8Signer.PublicKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pkcs1Keys).To(Equal(pkcs8Keys))
+}
+
+func TestNewRSAIDTokenSigner_InvalidPEM(t *testing.T) {
+	g := NewWithT(t)
+
+	signer, err := adapters.NewRSAIDTokenSigner([]byte("not a key"))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(signer).To(BeNil())
+
+	signer, err = adapters.NewRSAIDTokenSigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")}))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(signer).To(BeNil())
+}
+
+// newStoredSigningKey generates a key sealed the way RotatingRSAIDTokenSigner stores them
+func newStoredSigningKey(g *WithT, secretBox *adapters.AESGCMSecretBox, kid string, status entities.SigningKeyStatus, activatedAt, expiresAt *time.Time) entities.SigningKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	encryptedPrivateKey, err := secretBox.Seal(x509.MarshalPKCS1PrivateKey(privateKey), []byte(kid))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return entities.SigningKey{
+		ID:                  kid,
+		Status:              status,
+		EncryptedPrivateKey: encryptedPrivateKey,
+		CreatedAt:           time.Now().Add(-time.Hour),
+		ActivatedAt:         activatedAt,
+		ExpiresAt:           expiresAt,
+	}
+}
+
+// signingKeyFixture is a RotatingRSAIDTokenSigner over an active and a next key held in a mock store
+type signingKeyFixture struct {
+	signer     *adapters.RotatingRSAIDTokenSigner
+	store      *mock_adapters.MockSigningKeyStore
+	secretBox  *adapters.AESGCMSecretBox
+	storedKeys []entities.SigningKey
+}
+
+func newSigningKeyFixture(g *WithT, t *testing.T) *signingKeyFixture {
+	f := &signingKeyFixture{
+		store:     mock_adapters.NewMockSigningKeyStore(gomock.NewController(t)),
+		secretBox: adapters.NewAESGCMSecretBox("some-signing-key-encryption-key"),
+	}
+	f.signer = adapters.NewRotatingRSAIDTokenSigner(f.store, f.secretBox, 30*24*time.Hour, 48*time.Hour)
+
+	activatedAt := time.Now().Add(-24 * time.Hour)
+	f.storedKeys = []entities.SigningKey{
+		newStoredSigningKey(g, f.secretBox, "active-key", entities.SigningKeyStatusActive, &activatedAt, nil),
+		newStoredSigningKey(g, f.secretBox, "next-key", entities.SigningKeyStatusNext, nil, nil),
+	}
+	f.store.EXPECT().GetSigningKeys(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+		DoAndReturn(func(context.Context, time.Time) ([]entities.SigningKey, error) {
+			return f.storedKeys, nil
+		}).MinTimes(1)
+
+	return f
+}
+
+// signedKeyID signs a token and checks it against the published key it names, returning that key's kid
+func (f *signingKeyFixture) signedKeyID(g *WithT) string {
+	claims := entities.IDTokenClaims{
+		UserInfoClaims: entities.UserInfoClaims{Subject: "user-id"},
+		Issuer:         "http://localhost:8080",
+		Audience:       "forum",
+	}
+	token, err := f.signer.SignIDToken(context.Background(), claims)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	var header map[string]string
+	g.Expect(json.Unmarshal(headerJSON, &header)).To(Succeed())
+
+	keys, err := f.signer.PublicKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, key := range keys {
+		if key.KeyID == header["kid"] {
+			_, parsedClaims := verifyRS256JWT(g, token, key)
+			g.Expect(parsedClaims).To(Equal(claims))
+			return key.KeyID
+		}
+	}
+
+	g.Expect(keys).To(ContainElement(HaveField("KeyID", header["kid"])), "no published key with the token's kid")
+	return ""
+}
+
+func (f *signingKeyFixture) publishedKeyIDs(g *WithT) []string {
+	keys, err := f.signer.PublicKeys(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	keyIDs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		keyIDs = append(keyIDs, key.KeyID)
+	}
+	return keyIDs
+}
+
+func TestRotatingRSAIDTokenSigner_Refresh(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+
+	g.Expect(f.signer.Refresh(context.Background())).To(Succeed())
+	g.Expect(f.signedKeyID(g)).To(Equal("active-key"))
+	g.Expect(f.publishedKeyIDs(g)).To(Equal([]string{"active-key", "next-key"}))
+}
+
+func TestRotatingRSAIDTokenSigner_Refresh_CreatesKeys(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+	f.storedKeys = nil
+
+	f.store.EXPECT().CreateSigningKey(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.SigningKey{})).
+		DoAndReturn(func(_ context.Context, key entities.SigningKey) error {
+			f.storedKeys = append(f.storedKeys, key)
+			return nil
+		}).Times(2)
+
+	g.Expect(f.signer.Refresh(context.Background())).To(Succeed())
+	g.Expect(f.storedKeys).To(HaveLen(2))
+	g.Expect(f.storedKeys[0].Status).To(Equal(entities.SigningKeyStatusActive))
+	g.Expect(f.storedKeys[0].ActivatedAt).ToNot(BeNil())
+	g.Expect(f.storedKeys[1].Status).To(Equal(entities.SigningKeyStatusNext))
+	g.Expect(f.storedKeys[1].ActivatedAt).To(BeNil())
+
+	// keys are bound to their kid, so one can't be opened as another
+	_, err := f.secretBox.Open(f.storedKeys[0].EncryptedPrivateKey, []byte(f.storedKeys[1].ID))
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(f.signedKeyID(g)).To(Equal(f.storedKeys[0].ID))
+	g.Expect(f.publishedKeyIDs(g)).To(Equal([]string{f.storedKeys[0].ID, f.storedKeys[1].ID}))
+}
+
+// expectRotation has the mock store rotate the fixture's keys, or fail with rotateErr
+func (f *signingKeyFixture) expectRotation(g *WithT, rotateErr error) {
+	activatedAt := time.Now().Add(-31 * 24 * time.Hour)
+	f.storedKeys[0].ActivatedAt = &activatedAt
+
+	f.store.EXPECT().RotateSigningKeys(gomock.AssignableToTypeOf(ctxType), "active-key", gomock.AssignableToTypeOf(entities.SigningKey{}), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, next entities.SigningKey, now, retiredKeyExpiresAt time.Time) error {
+			if rotateErr != nil {
+				return rotateErr
+			}
+
+			g.Expect(next.Status).To(Equal(entities.SigningKeyStatusNext))
+			g.Expect(retiredKeyExpiresAt).To(Equal(now.Add(48 * time.Hour)))
+			f.storedKeys[0].Status = entities.SigningKeyStatusRetired
+			f.storedKeys[0].RetiredAt = &now
+			f.storedKeys[0].ExpiresAt = &retiredKeyExpiresAt
+			f.storedKeys[1].Status = entities.SigningKeyStatusActive
+			f.storedKeys[1].ActivatedAt = &now
+			f.storedKeys = append(f.storedKeys, next)
+			return nil
+		}).Times(1)
+}
+
+func TestRotatingRSAIDTokenSigner_Refresh_Rotates(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+	f.expectRotation(g, nil)
+
+	g.Expect(f.signer.Refresh(context.Background())).To(Succeed())
+	g.Expect(f.signedKeyID(g)).To(Equal("next-key"))
+	g.Expect(f.publishedKeyIDs(g)).To(Equal([]string{"active-key", "next-key", f.storedKeys[2].ID}))
+}
+
+func TestRotatingRSAIDTokenSigner_Refresh_RotatedByAnotherInstance(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+	f.expectRotation(g, entities.ErrSigningKeysChanged)
+
+	g.Expect(f.signer.Refresh(context.Background())).To(Succeed())
+}
+
+func TestRotatingRSAIDTokenSigner_Refresh_RotateErr(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+	f.expectRotation(g, errors.New("an error occurred"))
+
+	g.Expect(f.signer.Refresh(context.Background())).To(MatchError(ContainSubstring("an error occurred")))
+}
+
+func TestRotatingRSAIDTokenSigner_PublicKeys_SkipsExpiredKeys(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+
+	retiredAt := time.Now().Add(-72 * time.Hour)
+	expiresAt := time.Now().Add(-time.Second)
+	retiredKey := newStoredSigningKey(g, f.secretBox, "retired-key", entities.SigningKeyStatusRetired, &retiredAt, &expiresAt)
+	f.storedKeys = append([]entities.SigningKey{retiredKey}, f.storedKeys...)
+
+	g.Expect(f.signer.Refresh(context.Background())).To(Succeed())
+	g.Expect(f.publishedKeyIDs(g)).To(Equal([]string{"active-key", "next-key"}))
+}
+
+func TestRotatingRSAIDTokenSigner_Refresh_UndecryptableKey(t *testing.T) {
+	g := NewWithT(t)
+	f := newSigningKeyFixture(g, t)
+
+	// sealed under another key's kid, as if the row had been swapped
+	f.storedKeys[0].EncryptedPrivateKey = f.storedKeys[1].EncryptedPrivateKey
+
+	g.Expect(f.signer.Refresh(context.Background())).To(MatchError(ContainSubstring("decrypting signing key active-key")))
+}
+
+func TestRotatingRSAIDTokenSigner_SignIDToken_NotLoaded(t *testing.T) {
+	g := NewWithT(t)
+
+	signer := adapters.NewRotatingRSAIDTokenSigner(nil, nil, time.Hour, time.Hour)
+
+	token, err := signer.SignIDToken(context.Background(), entities.IDTokenClaims{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(token).To(BeEmpty())
+}
@@ -0,0 +1,62 @@
+package adapters
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"log/slog"
+)
+
+var errSealedValueMalformed = errors.New("sealed value is malformed")
+
+// AESGCMSecretBox encrypts small secrets with AES-256-GCM. Sealed values are the base64 encoded nonce followed by the
+// ciphertext, and the associated data (such as the ID of the row the secret belongs to) must match when opening them.
+type AESGCMSecretBox struct {
+	aead cipher.AEAD
+}
+
+var _ usecases.SecretBox = &AESGCMSecretBox{}
+
+// NewAESGCMSecretBox derives the encryption key from the configured key with SHA-256 so that any length of key can be
+// configured
+func NewAESGCMSecretBox(key string) *AESGCMSecretBox {
+	derivedKey := sha256.Sum256([]byte(key))
+
+	// neither of these can fail for a 32 byte key
+	block, _ := aes.NewCipher(derivedKey[:])
+	aead, _ := cipher.NewGCM(block)
+
+	return &AESGCMSecretBox{aead: aead}
+}
+
+func (b *AESGCMSecretBox) Seal(plaintext, associatedData []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		slog.Debug("unable to generate nonce", "err", err)
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, plaintext, associatedData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *AESGCMSecretBox) Open(sealed string, associatedData []byte) ([]byte, error) {
+	sealedBytes, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(sealedBytes) < b.aead.NonceSize() {
+		return nil, errSealedValueMalformed
+	}
+
+	nonce, ciphertext := sealedBytes[:b.aead.NonceSize()], sealedBytes[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		slog.Debug("unable to open sealed value", "err", err)
+		return nil, err
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,65 @@
+package adapters_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestAESGCMSecretBox_Seal(t *testing.T) {
+	g := NewWithT(t)
+
+	box := adapters.NewAESGCMSecretBox("some-encryption-key")
+
+	sealed, err := box.Seal([]byte("some-secret"), []byte("some-user"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sealed).ToNot(ContainSubstring("some-secret"))
+
+	plaintext, err := box.Open(sealed, []byte("some-user"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(plaintext)).To(Equal("some-secret"))
+}
+
+func TestAESGCMSecretBox_Seal_UsesFreshNonce(t *testing.T) {
+	g := NewWithT(t)
+
+	box := adapters.NewAESGCMSecretBox("some-encryption-key")
+
+	first, err := box.Seal([]byte("some-secret"), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	second, err := box.Seal([]byte("some-secret"), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(first).ToNot(Equal(second))
+}
+
+func TestAESGCMSecretBox_Open_DifferentAssociatedData(t *testing.T) {
+	g := NewWithT(t)
+
+	box := adapters.NewAESGCMSecretBox("some-encryption-key")
+
+	sealed, err := box.Seal([]byte("some-secret"), []byte("some-user"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	plaintext, err := box.Open(sealed, []byte("another-user"))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(plaintext).To(BeNil())
+}
+
+func TestAESGCMSecretBox_Open_DifferentKey(t *testing.T) {
+	g := NewWithT(t)
+
+	sealed, err := adapters.NewAESGCMSecretBox("some-encryption-key").Seal([]byte("some-secret"), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	plaintext, err := adapters.NewAESGCMSecretBox("another-encryption-key").Open(sealed, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(plaintext).To(BeNil())
+}
+
+func TestAESGCMSecretBox_Open_Malformed(t *testing.T) {
+	g := NewWithT(t)
+
+	plaintext, err := adapters.NewAESGCMSecretBox("some-encryption-key").Open("not-base64!", nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(plaintext).To(BeNil())
+}
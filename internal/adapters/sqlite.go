@@ -0,0 +1,490 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	migrations "github.com/AlecSmith96/faceit-user-service/db"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	"log/slog"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+const StorageBackendSQLite = "sqlite"
+
+// sqliteTimeLayout is fixed width and always UTC, so that timestamps stored as text sort in time order
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+func init() {
+	// SQLite's lower only folds ASCII, unicode_lower folds values the same way the Postgres search tokens do
+	sqlite.MustRegisterDeterministicScalarFunction("unicode_lower", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		value, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+
+		return strings.ToLower(value), nil
+	})
+}
+
+// SQLiteAdapter stores users, and the replies to user commands, in a single SQLite file for self-contained single node
+// deployments. It runs the same pagination and filtering as PostgresAdapter. Users' PII isn't encrypted, the file
+// should be kept on an encrypted disk.
+type SQLiteAdapter struct {
+	db *sql.DB
+}
+
+var (
+	_ usecases.UserRepository        = &SQLiteAdapter{}
+	_ usecases.UserCommandReplyStore = &SQLiteAdapter{}
+)
+
+func NewSQLiteAdapter(db *sql.DB) *SQLiteAdapter {
+	return &SQLiteAdapter{db: db}
+}
+
+// NewSQLiteDB opens the SQLite database at path, creating it if it doesn't exist. SQLite only allows one writer at a
+// time and an in-memory database belongs to a single connection, so the pool is limited to one connection.
+func NewSQLiteDB(path string) (*sql.DB, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	db, err := sql.Open("sqlite", path+separator+"_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// PerformDataMigration runs the SQLite migrations, which are kept apart from the Postgres ones
+func (s *SQLiteAdapter) PerformDataMigration(ctx context.Context) error {
+	return s.Migrate(ctx, MigrateUp)
+}
+
+// Migrate runs a goose command against the embedded SQLite migrations. SQLite only runs on a single node, so there is
+// no other instance to wait for.
+func (s *SQLiteAdapter) Migrate(ctx context.Context, command string) error {
+	return runMigration(ctx, s.db, "sqlite3", migrations.SQLiteMigrations, migrations.SQLiteMigrationsDir, command)
+}
+
+func isSQLiteEmailConflict(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed: platform_user.email") ||
+		strings.Contains(err.Error(), "UNIQUE constraint failed: platform_user.pending_email")
+}
+
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteTime scans a timestamp stored as text
+type sqliteTime struct {
+	dest *time.Time
+}
+
+func (s sqliteTime) Scan(src any) error {
+	value, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("scanning %T as a timestamp", src)
+	}
+
+	t, err := time.Parse(sqliteTimeLayout, value)
+	if err != nil {
+		return err
+	}
+
+	*s.dest = t
+	return nil
+}
+
+// sqliteNullTime scans a nullable timestamp stored as text
+type sqliteNullTime struct {
+	dest **time.Time
+}
+
+func (s sqliteNullTime) Scan(src any) error {
+	if src == nil {
+		*s.dest = nil
+		return nil
+	}
+
+	var t time.Time
+	err := sqliteTime{dest: &t}.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	*s.dest = &t
+	return nil
+}
+
+// scanUser reads a full platform_user row, in table column order, into a User
+func (s *SQLiteAdapter) scanUser(row rowScanner) (*entities.User, error) {
+	var user entities.User
+	err := row.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.Nickname,
+		&user.Password,
+		&user.Email,
+		&user.Country,
+		sqliteTime{dest: &user.CreatedAt},
+		sqliteTime{dest: &user.UpdatedAt},
+		&user.Status,
+		&user.BanReason,
+		sqliteNullTime{dest: &user.BanExpiresAt},
+		sqliteNullTime{dest: &user.EmailVerifiedAt},
+		sqliteNullTime{dest: &user.PasswordChangedAt},
+		&user.PendingEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *SQLiteAdapter) CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	now := formatSQLiteTime(time.Now())
+	user, err := s.scanUser(s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO platform_user (id, first_name, last_name, nickname, password, email, country, created_at, updated_at, status)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?8, ?9) RETURNING *`,
+		uuid.New(),
+		firstName,
+		lastName,
+		nickname,
+		password,
+		email,
+		country,
+		now,
+		entities.UserStatusPendingVerification,
+	))
+	if err != nil {
+		if isSQLiteEmailConflict(err) {
+			slog.Debug("email already registered to a user", "err", err)
+			return nil, entities.ErrEmailAlreadyUsed
+		}
+		slog.Debug("error inserting user record", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *SQLiteAdapter) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM platform_user WHERE id = ?1;", userID)
+	if err != nil {
+		slog.Debug("unable to delete user", "err", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("unable to get rows affected", "err", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		slog.Debug("user not found", "userID", userID)
+		return entities.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUser clears the email verification if the email changes and records when the password changes, as
+// PostgresAdapter.UpdateUser does
+func (s *SQLiteAdapter) UpdateUser(ctx context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	user, err := s.scanUser(s.db.QueryRowContext(
+		ctx,
+		`UPDATE platform_user SET first_name = ?2, last_name = ?3, nickname = ?4, password = ?5, email = ?6, country = ?7, updated_at = ?8,
+		email_verified_at = CASE WHEN email = ?6 THEN email_verified_at END, password_changed_at = CASE WHEN password = ?5 THEN password_changed_at ELSE ?8 END
+		WHERE id = ?1 RETURNING *`,
+		userID,
+		firstName,
+		lastName,
+		nickname,
+		password,
+		email,
+		country,
+		formatSQLiteTime(time.Now()),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "userID", userID)
+			return nil, entities.ErrUserNotFound
+		}
+		if isSQLiteEmailConflict(err) {
+			slog.Debug("email already registered to a user", "err", err)
+			return nil, entities.ErrEmailAlreadyUsed
+		}
+		slog.Debug("error updating user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetPaginatedUsers filters users as PostgresAdapter does, each filter matching any part of its field ignoring case
+func (s *SQLiteAdapter) GetPaginatedUsers(
+	_ context.Context,
+	firstName,
+	lastName,
+	nickname,
+	email,
+	country string,
+	pageInfo entities.PageInfo,
+) ([]entities.User, string, error) {
+	var userID uuid.UUID
+	var createdAtPageToken time.Time
+	var err error
+	if pageInfo.NextPageToken != "" {
+		userID, createdAtPageToken, err = decodePageToken(pageInfo.NextPageToken)
+		if err != nil {
+			slog.Debug("decoding page token", "err", err)
+			return nil, "", err
+		}
+	}
+
+	queryString := `SELECT * FROM platform_user WHERE 1=1 `
+	queryParams := make([]any, 0)
+	// LIKE treats % and _ in the filter as wildcards, as ILIKE does
+	if firstName != "" {
+		queryString += "AND unicode_lower(first_name) LIKE ? "
+		queryParams = append(queryParams, "%"+strings.ToLower(firstName)+"%")
+	}
+
+	if lastName != "" {
+		queryString += "AND unicode_lower(last_name) LIKE ? "
+		queryParams = append(queryParams, "%"+strings.ToLower(lastName)+"%")
+	}
+
+	if nickname != "" {
+		queryString += "AND unicode_lower(nickname) LIKE ? "
+		queryParams = append(queryParams, "%"+strings.ToLower(nickname)+"%")
+	}
+
+	if email != "" {
+		queryString += "AND unicode_lower(email) LIKE ? "
+		queryParams = append(queryParams, "%"+strings.ToLower(email)+"%")
+	}
+
+	if country != "" {
+		queryString += "AND unicode_lower(country) LIKE ? "
+		queryParams = append(queryParams, "%"+strings.ToLower(country)+"%")
+	}
+
+	if pageInfo.NextPageToken != "" {
+		queryString += "AND (created_at, id) > (?, ?) "
+		queryParams = append(queryParams, formatSQLiteTime(createdAtPageToken), userID)
+	}
+
+	queryString += fmt.Sprintf(`ORDER BY created_at, id LIMIT %d;`, pageInfo.PageSize)
+	rows, err := s.db.Query(queryString, queryParams...)
+	if err != nil {
+		slog.Debug("error getting users", "err", err)
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	users := make([]entities.User, 0)
+	for rows.Next() {
+		user, err := s.scanUser(rows)
+		if err != nil {
+			slog.Debug("marshalling user to struct", "err", err)
+			return nil, "", err
+		}
+
+		users = append(users, *user)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating users", "err", err)
+		return nil, "", err
+	}
+
+	if len(users) == 0 || len(users) < pageInfo.PageSize {
+		return users, "", nil
+	}
+
+	lastUser := users[len(users)-1]
+	nextPageToken := encodePageToken(lastUser.ID, lastUser.CreatedAt)
+
+	return users, nextPageToken, nil
+}
+
+func (s *SQLiteAdapter) GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := s.scanUser(s.db.QueryRowContext(ctx, "SELECT * FROM platform_user WHERE id = ?1;", userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "userID", userID)
+			return nil, entities.ErrUserNotFound
+		}
+		slog.Debug("error getting user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUsersByIDs looks every user up in one query rather than a round trip each
+func (s *SQLiteAdapter) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error) {
+	users := make([]entities.User, 0, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	queryParams := make([]any, len(userIDs))
+	for i, userID := range userIDs {
+		placeholders[i] = "?"
+		queryParams[i] = userID
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT * FROM platform_user WHERE id IN ("+strings.Join(placeholders, ", ")+");",
+		queryParams...,
+	)
+	if err != nil {
+		slog.Debug("error getting users by ids", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := s.scanUser(rows)
+		if err != nil {
+			slog.Debug("marshalling user to struct", "err", err)
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		slog.Debug("error iterating users", "err", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (s *SQLiteAdapter) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	user, err := s.scanUser(s.db.QueryRowContext(ctx, "SELECT * FROM platform_user WHERE email = ?1;", email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user not found", "email", email)
+			return nil, entities.ErrUserNotFound
+		}
+		slog.Debug("error getting user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *SQLiteAdapter) GetUserCommandReply(ctx context.Context, correlationID string) (*entities.UserCommandReply, error) {
+	var reply entities.UserCommandReply
+	var violations string
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT correlation_id, type, status, user_id, error, violations, processed_at
+		FROM user_command_reply WHERE correlation_id = ?1;`,
+		correlationID,
+	).Scan(
+		&reply.CorrelationID,
+		&reply.Type,
+		&reply.Status,
+		&reply.UserID,
+		&reply.Error,
+		&violations,
+		sqliteTime{dest: &reply.ProcessedAt},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("user command reply not found", "correlationID", correlationID)
+			return nil, entities.ErrUserCommandReplyNotFound
+		}
+		slog.Debug("error getting user command reply", "err", err)
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(violations), &reply.Violations)
+	if err != nil {
+		slog.Debug("error decoding user command reply violations", "err", err)
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+func (s *SQLiteAdapter) RecordUserCommandReply(ctx context.Context, reply entities.UserCommandReply) error {
+	violations, err := json.Marshal(reply.Violations)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO user_command_reply (correlation_id, type, status, user_id, error, violations, processed_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+		ON CONFLICT (correlation_id) DO UPDATE SET type = excluded.type, status = excluded.status,
+			user_id = excluded.user_id, error = excluded.error, violations = excluded.violations,
+			processed_at = excluded.processed_at;`,
+		reply.CorrelationID,
+		reply.Type,
+		reply.Status,
+		reply.UserID,
+		reply.Error,
+		string(violations),
+		formatSQLiteTime(reply.ProcessedAt),
+	)
+	if err != nil {
+		slog.Debug("error recording user command reply", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLiteAdapter) DeleteUserCommandReplies(ctx context.Context, processedBefore time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM user_command_reply WHERE processed_at < ?1;", formatSQLiteTime(processedBefore))
+	if err != nil {
+		slog.Debug("error deleting user command replies", "err", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Debug("error getting rows affected", "err", err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+func (s *SQLiteAdapter) CheckConnection() error {
+	err := s.db.Ping()
+	if err != nil {
+		slog.Debug("database connection lost")
+		return err
+	}
+
+	return nil
+}
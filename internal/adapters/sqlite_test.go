@@ -0,0 +1,267 @@
+package adapters_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestSQLiteAdapter(g *WithT, t *testing.T) *adapters.SQLiteAdapter {
+	db, err := adapters.NewSQLiteDB(filepath.Join(t.TempDir(), "users.db"))
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	adapter := adapters.NewSQLiteAdapter(db)
+	err = adapter.PerformDataMigration(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return adapter
+}
+
+func TestSQLiteAdapter_CreateUser(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	user, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(user.ID).ToNot(Equal(uuid.Nil))
+	g.Expect(user.Status).To(Equal(entities.UserStatusPendingVerification))
+	g.Expect(user.CreatedAt).To(BeTemporally("~", time.Now(), time.Second))
+	g.Expect(user.CreatedAt).To(Equal(user.UpdatedAt))
+	g.Expect(user.EmailVerifiedAt).To(BeNil())
+
+	found, err := adapter.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(Equal(user))
+
+	found, err = adapter.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(Equal(user))
+}
+
+func TestSQLiteAdapter_CreateUser_EmailAlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	_, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = adapter.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "alec@email.com", "FR")
+	g.Expect(err).To(Equal(entities.ErrEmailAlreadyUsed))
+}
+
+func TestSQLiteAdapter_UpdateUser(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	user, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	updated, err := adapter.UpdateUser(context.Background(), user.ID, "alex", "smith", "alexsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.FirstName).To(Equal("alex"))
+	g.Expect(updated.Nickname).To(Equal("alexsmith"))
+	g.Expect(updated.CreatedAt).To(Equal(user.CreatedAt))
+	g.Expect(updated.PasswordChangedAt).To(BeNil())
+
+	updated, err = adapter.UpdateUser(context.Background(), user.ID, "alex", "smith", "alexsmith", "new-password", "alex@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(updated.Email).To(Equal("alex@email.com"))
+	g.Expect(updated.PasswordChangedAt).ToNot(BeNil())
+	g.Expect(*updated.PasswordChangedAt).To(Equal(updated.UpdatedAt))
+
+	found, err := adapter.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(Equal(updated))
+}
+
+func TestSQLiteAdapter_UpdateUser_EmailAlreadyUsed(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	user, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = adapter.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "jane@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = adapter.UpdateUser(context.Background(), user.ID, "alec", "smith", "alecsmith", "password", "jane@email.com", "UK")
+	g.Expect(err).To(Equal(entities.ErrEmailAlreadyUsed))
+}
+
+func TestSQLiteAdapter_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	_, err := adapter.GetUserByID(context.Background(), uuid.New())
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	_, err = adapter.GetUserByEmail(context.Background(), "alec@email.com")
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	_, err = adapter.UpdateUser(context.Background(), uuid.New(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+
+	err = adapter.DeleteUser(context.Background(), uuid.New())
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+}
+
+func TestSQLiteAdapter_DeleteUser(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	user, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = adapter.DeleteUser(context.Background(), user.ID)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = adapter.GetUserByID(context.Background(), user.ID)
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+}
+
+func TestSQLiteAdapter_GetUsersByIDs(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	alec, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	jane, err := adapter.CreateUser(context.Background(), "jane", "doe", "janedoe", "password", "jane@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	users, err := adapter.GetUsersByIDs(context.Background(), []uuid.UUID{alec.ID, uuid.New(), jane.ID})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(ConsistOf(*alec, *jane))
+
+	users, err = adapter.GetUsersByIDs(context.Background(), []uuid.UUID{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(BeEmpty())
+}
+
+func TestSQLiteAdapter_GetPaginatedUsers(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	var created []uuid.UUID
+	for _, email := range []string{"a@email.com", "b@email.com", "c@email.com", "d@email.com", "e@email.com"} {
+		user, err := adapter.CreateUser(context.Background(), "alec", "smith", "alecsmith", "password", email, "UK")
+		g.Expect(err).ToNot(HaveOccurred())
+		created = append(created, user.ID)
+	}
+
+	var listed []uuid.UUID
+	pageInfo := entities.PageInfo{PageSize: 2}
+	for pages := 0; pages < 5; pages++ {
+		users, nextPageToken, err := adapter.GetPaginatedUsers(context.Background(), "", "", "", "", "", pageInfo)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(len(users)).To(BeNumerically("<=", 2))
+		for _, user := range users {
+			listed = append(listed, user.ID)
+		}
+
+		if nextPageToken == "" {
+			break
+		}
+		pageInfo.NextPageToken = nextPageToken
+	}
+
+	g.Expect(listed).To(ConsistOf(created))
+}
+
+func TestSQLiteAdapter_GetPaginatedUsers_Filters(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	alec, err := adapter.CreateUser(context.Background(), "Álec", "Smith", "alec_smith", "password", "alec@email.com", "UK")
+	g.Expect(err).ToNot(HaveOccurred())
+	jane, err := adapter.CreateUser(context.Background(), "Jane", "Smith", "janesmith", "password", "jane@email.com", "FR")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	pageInfo := entities.PageInfo{PageSize: 10}
+	// names are folded beyond ASCII, as the Postgres search tokens are
+	users, _, err := adapter.GetPaginatedUsers(context.Background(), "ÁL", "", "", "", "", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(Equal([]entities.User{*alec}))
+
+	users, _, err = adapter.GetPaginatedUsers(context.Background(), "", "smith", "SMITH", "", "", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(ConsistOf(*alec, *jane))
+
+	users, _, err = adapter.GetPaginatedUsers(context.Background(), "", "smith", "", "jane@email.com", "f", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(Equal([]entities.User{*jane}))
+
+	users, _, err = adapter.GetPaginatedUsers(context.Background(), "LE", "", "", "JA", "", pageInfo)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(users).To(BeEmpty())
+}
+
+func TestSQLiteAdapter_GetPaginatedUsers_InvalidPageToken(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	_, _, err := adapter.GetPaginatedUsers(context.Background(), "", "", "", "", "", entities.PageInfo{
+		PageSize:      10,
+		NextPageToken: "not-a-token",
+	})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSQLiteAdapter_CheckConnection(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	err := adapter.CheckConnection()
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestSQLiteAdapter_UserCommandReplies(t *testing.T) {
+	g := NewWithT(t)
+	adapter := newTestSQLiteAdapter(g, t)
+
+	userID := uuid.New()
+	processedAt := time.Now().UTC().Truncate(time.Microsecond)
+	reply := entities.UserCommandReply{
+		CorrelationID: "some-correlation-id",
+		Type:          entities.UserCommandCreate,
+		Status:        entities.UserCommandStatusFailed,
+		Error:         entities.UserCommandErrorPasswordRejected,
+		Violations:    []string{"too_short"},
+		ProcessedAt:   processedAt.Add(-2 * time.Hour),
+	}
+
+	_, err := adapter.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).To(Equal(entities.ErrUserCommandReplyNotFound))
+
+	g.Expect(adapter.RecordUserCommandReply(context.Background(), reply)).To(Succeed())
+	found, err := adapter.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*found).To(Equal(reply))
+
+	// recording a reply for the same correlation ID replaces it
+	reply.Status = entities.UserCommandStatusSucceeded
+	reply.UserID = &userID
+	reply.Error = ""
+	reply.Violations = nil
+	reply.ProcessedAt = processedAt
+	g.Expect(adapter.RecordUserCommandReply(context.Background(), reply)).To(Succeed())
+	found, err = adapter.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*found).To(Equal(reply))
+
+	deleted, err := adapter.DeleteUserCommandReplies(context.Background(), processedAt.Add(-time.Hour))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(Equal(0))
+
+	deleted, err = adapter.DeleteUserCommandReplies(context.Background(), processedAt.Add(time.Hour))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(Equal(1))
+	_, err = adapter.GetUserCommandReply(context.Background(), reply.CorrelationID)
+	g.Expect(err).To(Equal(entities.ErrUserCommandReplyNotFound))
+}
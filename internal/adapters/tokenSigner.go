@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"log/slog"
+	"strings"
+)
+
+// accessTokenDomain is mixed into the signature of access tokens so that they can't be passed off as verification
+// tokens, or the other way round
+const accessTokenDomain = "access_token."
+
+// HMACTokenSigner signs tokens as a base64 encoded JSON payload followed by its HMAC-SHA256 signature
+type HMACTokenSigner struct {
+	key []byte
+}
+
+var _ usecases.TokenSigner = &HMACTokenSigner{}
+var _ usecases.AccessTokenSigner = &HMACTokenSigner{}
+
+func NewHMACTokenSigner(key string) *HMACTokenSigner {
+	return &HMACTokenSigner{key: []byte(key)}
+}
+
+func (s *HMACTokenSigner) SignToken(token entities.VerificationToken) (string, error) {
+	return s.signPayload("", token)
+}
+
+// ParseToken checks the signature of a signed token and returns its contents. It doesn't check expiry or whether the
+// token has been used, that is left to the token store.
+func (s *HMACTokenSigner) ParseToken(signedToken string) (*entities.VerificationToken, error) {
+	var token entities.VerificationToken
+	err := s.parsePayload("", signedToken, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (s *HMACTokenSigner) SignAccessToken(token entities.AccessToken) (string, error) {
+	return s.signPayload(accessTokenDomain, token)
+}
+
+// ParseAccessToken checks the signature of a signed access token and returns its contents. It doesn't check expiry.
+func (s *HMACTokenSigner) ParseAccessToken(signedToken string) (*entities.AccessToken, error) {
+	var token entities.AccessToken
+	err := s.parsePayload(accessTokenDomain, signedToken, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (s *HMACTokenSigner) signPayload(domain string, token any) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		slog.Debug("unable to convert token to json", "err", err)
+		return "", err
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(s.sign(domain+encodedPayload)), nil
+}
+
+func (s *HMACTokenSigner) parsePayload(domain, signedToken string, token any) error {
+	encodedPayload, encodedSignature, found := strings.Cut(signedToken, ".")
+	if !found {
+		return entities.ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(domain+encodedPayload)) {
+		slog.Debug("token signature mismatch")
+		return entities.ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return entities.ErrInvalidToken
+	}
+
+	err = json.Unmarshal(payload, token)
+	if err != nil {
+		slog.Debug("unable to parse token payload", "err", err)
+		return entities.ErrInvalidToken
+	}
+
+	return nil
+}
+
+func (s *HMACTokenSigner) sign(signingInput string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,97 @@
+package adapters_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestHMACTokenSigner_SignToken(t *testing.T) {
+	g := NewWithT(t)
+
+	signer := adapters.NewHMACTokenSigner("some-signing-key")
+
+	token := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Email:     "alec@email.com",
+		Purpose:   entities.TokenPurposeEmailVerification,
+		ExpiresAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	signedToken, err := signer.SignToken(token)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	parsedToken, err := signer.ParseToken(signedToken)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*parsedToken).To(Equal(token))
+}
+
+func TestHMACTokenSigner_ParseToken_SignedWithDifferentKey(t *testing.T) {
+	g := NewWithT(t)
+
+	signedToken, err := adapters.NewHMACTokenSigner("some-signing-key").SignToken(entities.VerificationToken{ID: uuid.New()})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	parsedToken, err := adapters.NewHMACTokenSigner("another-signing-key").ParseToken(signedToken)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(parsedToken).To(BeNil())
+}
+
+func TestHMACTokenSigner_ParseToken_Malformed(t *testing.T) {
+	g := NewWithT(t)
+
+	parsedToken, err := adapters.NewHMACTokenSigner("some-signing-key").ParseToken("not-a-token")
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(parsedToken).To(BeNil())
+}
+
+func TestHMACTokenSigner_SignAccessToken(t *testing.T) {
+	g := NewWithT(t)
+
+	signer := adapters.NewHMACTokenSigner("some-signing-key")
+
+	token := entities.AccessToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		SessionID: uuid.New(),
+		IssuedAt:  time.Now().UTC().Truncate(time.Second),
+		ExpiresAt: time.Now().Add(time.Minute).UTC().Truncate(time.Second),
+	}
+
+	signedToken, err := signer.SignAccessToken(token)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	parsedToken, err := signer.ParseAccessToken(signedToken)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*parsedToken).To(Equal(token))
+}
+
+func TestHMACTokenSigner_ParseAccessToken_VerificationToken(t *testing.T) {
+	g := NewWithT(t)
+
+	signer := adapters.NewHMACTokenSigner("some-signing-key")
+
+	signedToken, err := signer.SignToken(entities.VerificationToken{ID: uuid.New(), UserID: uuid.New()})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	parsedToken, err := signer.ParseAccessToken(signedToken)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(parsedToken).To(BeNil())
+}
+
+func TestHMACTokenSigner_ParseToken_AccessToken(t *testing.T) {
+	g := NewWithT(t)
+
+	signer := adapters.NewHMACTokenSigner("some-signing-key")
+
+	signedToken, err := signer.SignAccessToken(entities.AccessToken{ID: uuid.New(), UserID: uuid.New()})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	parsedToken, err := signer.ParseToken(signedToken)
+	g.Expect(err).To(MatchError(entities.ErrInvalidToken))
+	g.Expect(parsedToken).To(BeNil())
+}
@@ -0,0 +1,424 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodyBytes limits how much of an error response is kept to explain the error
+const maxErrorBodyBytes = 4096
+
+// UserServiceError is returned when the user service responds with an unexpected status
+type UserServiceError struct {
+	StatusCode int
+	// Body is the start of the response body, which is empty for most errors
+	Body string
+}
+
+func (e *UserServiceError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("user service responded %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("user service responded %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// UserServiceClient calls the user service's REST API with an API key, for tools that manage users without a
+// connection to the database
+type UserServiceClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+var _ usecases.UserCreator = &UserServiceClient{}
+var _ usecases.UserUpdater = &UserServiceClient{}
+var _ usecases.UserDeleter = &UserServiceClient{}
+var _ usecases.UserSearcher = &UserServiceClient{}
+
+// NewUserServiceClient creates a UserServiceClient for the service at baseURL, requests are sent without an API key if
+// apiKey is empty
+func NewUserServiceClient(baseURL, apiKey string, httpClient *http.Client) *UserServiceClient {
+	return &UserServiceClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+// GetUserByID gets a user through POST /users:batchGet, as there is no endpoint for getting a single user
+func (u *UserServiceClient) GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	users, err := u.GetUsersByIDs(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(users) == 0 {
+		return nil, entities.ErrUserNotFound
+	}
+
+	return &users[0], nil
+}
+
+// GetUsersByIDs returns the users that exist out of userIDs, in the order they were given
+func (u *UserServiceClient) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error) {
+	request := usecases.BatchGetUsersRequestBody{UserIDs: make([]string, 0, len(userIDs))}
+	for _, userID := range userIDs {
+		request.UserIDs = append(request.UserIDs, userID.String())
+	}
+
+	var response usecases.BatchGetUsersResponseBody
+	err := u.doJSON(ctx, http.MethodPost, "/users:batchGet", nil, request, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return usersFromResponses(response.Users)
+}
+
+func (u *UserServiceClient) SearchUsers(ctx context.Context, query string, limit int) ([]entities.UserSearchResult, error) {
+	params := url.Values{"q": {query}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var response usecases.SearchUsersResponseBody
+	err := u.doJSON(ctx, http.MethodGet, "/users/search", params, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]entities.UserSearchResult, 0, len(response.Results))
+	for _, result := range response.Results {
+		user, err := userFromPublicResponse(result.User)
+		if err != nil {
+			return nil, err
+		}
+
+		matchedFields := make([]string, 0, len(result.Highlights))
+		for _, field := range []string{
+			entities.SearchFieldNickname,
+			entities.SearchFieldFirstName,
+			entities.SearchFieldLastName,
+			entities.SearchFieldEmail,
+		} {
+			if _, found := result.Highlights[field]; found {
+				matchedFields = append(matchedFields, field)
+			}
+		}
+
+		results = append(results, entities.UserSearchResult{
+			User:          *user,
+			Score:         result.Score,
+			MatchedFields: matchedFields,
+		})
+	}
+
+	return results, nil
+}
+
+func (u *UserServiceClient) CreateUser(ctx context.Context, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	request := usecases.CreateUserRequestBody{
+		FirstName: firstName,
+		LastName:  lastName,
+		Nickname:  nickname,
+		Password:  password,
+		Email:     email,
+		Country:   country,
+	}
+
+	var response usecases.UserResponse
+	err := u.doJSON(ctx, http.MethodPost, "/user", nil, request, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return userFromResponse(response)
+}
+
+// UpdateUser replaces the user's profile, a new email address is held as pending until it is confirmed
+func (u *UserServiceClient) UpdateUser(ctx context.Context, userID uuid.UUID, firstName, lastName, nickname, password, email, country string) (*entities.User, error) {
+	request := usecases.UpdateUserRequestBody{
+		FirstName: firstName,
+		LastName:  lastName,
+		Nickname:  nickname,
+		Password:  password,
+		Email:     email,
+		Country:   country,
+	}
+
+	var response usecases.UserResponse
+	err := u.doJSON(ctx, http.MethodPut, "/user/"+userID.String(), nil, request, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return userFromResponse(response)
+}
+
+func (u *UserServiceClient) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return u.doJSON(ctx, http.MethodDelete, "/user/"+userID.String(), nil, nil, nil)
+}
+
+func (u *UserServiceClient) SuspendUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	return u.transitionUserStatus(ctx, userID, "suspend")
+}
+
+func (u *UserServiceClient) UnsuspendUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	return u.transitionUserStatus(ctx, userID, "unsuspend")
+}
+
+func (u *UserServiceClient) UnbanUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	return u.transitionUserStatus(ctx, userID, "unban")
+}
+
+// transitionUserStatus calls one of the admin status endpoints, returning entities.ErrInvalidStatusTransition if the
+// user's status doesn't allow it
+func (u *UserServiceClient) transitionUserStatus(ctx context.Context, userID uuid.UUID, action string) (*entities.User, error) {
+	var response usecases.UserResponse
+	err := u.doJSON(ctx, http.MethodPost, "/admin/user/"+userID.String()+"/"+action, nil, nil, &response)
+	if err != nil {
+		var serviceErr *UserServiceError
+		if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusConflict {
+			return nil, entities.ErrInvalidStatusTransition
+		}
+		return nil, err
+	}
+
+	return userFromResponse(response)
+}
+
+// ExportUsers streams the export file for users matching the filters to w, the filters match the same way as GET /users
+func (u *UserServiceClient) ExportUsers(
+	ctx context.Context,
+	format, columns, firstName, lastName, nickname, email, country string,
+	w io.Writer,
+) error {
+	params := url.Values{"format": {format}}
+	for name, value := range map[string]string{
+		"columns":    columns,
+		"first_name": firstName,
+		"last_name":  lastName,
+		"nickname":   nickname,
+		"email":      email,
+		"country":    country,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	response, err := u.do(ctx, http.MethodGet, "/users/export", params, "", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// the service drops the connection if the export fails part way, which surfaces here as a read error
+	_, err = io.Copy(w, response.Body)
+	if err != nil {
+		slog.Debug("error reading export", "err", err)
+		return fmt.Errorf("export incomplete: %w", err)
+	}
+
+	return nil
+}
+
+// ImportFile imports the users in a CSV or NDJSON file and returns the result of each row. When the import stops part
+// way the results of the rows imported before it stopped are returned along with the error.
+func (u *UserServiceClient) ImportFile(ctx context.Context, format string, file io.Reader, dryRun bool) ([]entities.ImportRowResult, error) {
+	params := url.Values{
+		"format":  {format},
+		"dry_run": {strconv.FormatBool(dryRun)},
+	}
+	contentType := "text/csv"
+	if format == entities.ImportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	response, err := u.do(ctx, http.MethodPost, "/users/import", params, contentType, file)
+	var serviceErr *UserServiceError
+	if err != nil && !errors.As(err, &serviceErr) {
+		return nil, err
+	}
+
+	var body usecases.ImportUsersResponseBody
+	if serviceErr != nil {
+		// failed imports still report the rows imported before they stopped, if the body has them
+		if json.Unmarshal([]byte(serviceErr.Body), &body) != nil {
+			return nil, err
+		}
+	} else {
+		defer response.Body.Close()
+		err = json.NewDecoder(response.Body).Decode(&body)
+		if err != nil {
+			slog.Debug("error decoding import response", "err", err)
+			return nil, err
+		}
+	}
+
+	results := make([]entities.ImportRowResult, 0, len(body.Results))
+	for _, result := range body.Results {
+		rowResult := entities.ImportRowResult{Row: result.Row, Error: result.Error}
+		if result.UserID != "" {
+			userID, err := uuid.Parse(result.UserID)
+			if err != nil {
+				return nil, err
+			}
+			rowResult.UserID = &userID
+		}
+		results = append(results, rowResult)
+	}
+
+	if body.Error != "" {
+		return results, fmt.Errorf("import stopped: %s", body.Error)
+	}
+	if serviceErr != nil {
+		return results, serviceErr
+	}
+
+	return results, nil
+}
+
+// doJSON sends request as JSON and decodes the response into response, unless either is nil
+func (u *UserServiceClient) doJSON(ctx context.Context, method, path string, params url.Values, request, response any) error {
+	var body io.Reader
+	contentType := ""
+	if request != nil {
+		encoded, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+
+	httpResponse, err := u.do(ctx, method, path, params, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if response == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(httpResponse.Body).Decode(response)
+	if err != nil {
+		slog.Debug("error decoding response", "err", err, "path", path)
+		return err
+	}
+
+	return nil
+}
+
+// do sends a request and returns the response if it was successful, otherwise a *UserServiceError. The caller has to
+// close the response body.
+func (u *UserServiceClient) do(ctx context.Context, method, path string, params url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	target := u.baseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if u.apiKey != "" {
+		request.Header.Set("Authorization", "ApiKey "+u.apiKey)
+	}
+
+	response, err := u.httpClient.Do(request)
+	if err != nil {
+		slog.Debug("error calling user service", "err", err, "method", method, "path", path)
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		defer response.Body.Close()
+		errorBody, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+		return nil, &UserServiceError{
+			StatusCode: response.StatusCode,
+			Body:       strings.TrimSpace(string(errorBody)),
+		}
+	}
+
+	return response, nil
+}
+
+func usersFromResponses(responses []usecases.PublicUserResponse) ([]entities.User, error) {
+	users := make([]entities.User, 0, len(responses))
+	for _, response := range responses {
+		user, err := userFromPublicResponse(response)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+// userFromResponse maps a user returned by the service back to its entity
+func userFromResponse(response usecases.UserResponse) (*entities.User, error) {
+	userID, err := uuid.Parse(response.ID)
+	if err != nil {
+		return nil, fmt.Errorf("user service returned invalid user id %q: %w", response.ID, err)
+	}
+
+	return &entities.User{
+		ID:              userID,
+		FirstName:       response.FirstName,
+		LastName:        response.LastName,
+		Nickname:        response.Nickname,
+		Password:        response.Password,
+		Email:           response.Email,
+		Country:         response.Country,
+		CreatedAt:       response.CreatedAt,
+		UpdatedAt:       response.UpdatedAt,
+		Status:          entities.UserStatus(response.Status),
+		BanReason:       response.BanReason,
+		BanExpiresAt:    response.BanExpiresAt,
+		EmailVerifiedAt: response.EmailVerifiedAt,
+		PendingEmail:    response.PendingEmail,
+	}, nil
+}
+
+// userFromPublicResponse maps a user returned by the service without their password back to its entity
+func userFromPublicResponse(response usecases.PublicUserResponse) (*entities.User, error) {
+	userID, err := uuid.Parse(response.ID)
+	if err != nil {
+		return nil, fmt.Errorf("user service returned invalid user id %q: %w", response.ID, err)
+	}
+
+	return &entities.User{
+		ID:              userID,
+		FirstName:       response.FirstName,
+		LastName:        response.LastName,
+		Nickname:        response.Nickname,
+		Email:           response.Email,
+		Country:         response.Country,
+		CreatedAt:       response.CreatedAt,
+		UpdatedAt:       response.UpdatedAt,
+		Status:          entities.UserStatus(response.Status),
+		BanReason:       response.BanReason,
+		BanExpiresAt:    response.BanExpiresAt,
+		EmailVerifiedAt: response.EmailVerifiedAt,
+		PendingEmail:    response.PendingEmail,
+	}, nil
+}
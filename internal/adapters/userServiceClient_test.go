@@ -0,0 +1,224 @@
+package adapters_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestUserServiceClient returns a client for a server that responds with handler
+func newTestUserServiceClient(t *testing.T, handler http.HandlerFunc) *adapters.UserServiceClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return adapters.NewUserServiceClient(server.URL+"/", "some-api-key", server.Client())
+}
+
+func TestUserServiceClient_GetUserByID(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+	createdAt := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodPost))
+		g.Expect(r.URL.Path).To(Equal("/users:batchGet"))
+		g.Expect(r.Header.Get("Authorization")).To(Equal("ApiKey some-api-key"))
+
+		var request usecases.BatchGetUsersRequestBody
+		g.Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+		g.Expect(request.UserIDs).To(Equal([]string{userID.String()}))
+
+		json.NewEncoder(w).Encode(usecases.BatchGetUsersResponseBody{
+			Users: []usecases.PublicUserResponse{{
+				ID:        userID.String(),
+				FirstName: "alec",
+				Nickname:  "alecsmith",
+				Email:     "alec@email.com",
+				CreatedAt: createdAt,
+				Status:    string(entities.UserStatusSuspended),
+			}},
+			MissingUserIDs: []string{},
+		})
+	})
+
+	user, err := client.GetUserByID(context.Background(), userID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*user).To(Equal(entities.User{
+		ID:        userID,
+		FirstName: "alec",
+		Nickname:  "alecsmith",
+		Email:     "alec@email.com",
+		CreatedAt: createdAt,
+		Status:    entities.UserStatusSuspended,
+	}))
+}
+
+func TestUserServiceClient_GetUserByID_NotFound(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(usecases.BatchGetUsersResponseBody{
+			Users:          []usecases.PublicUserResponse{},
+			MissingUserIDs: []string{userID.String()},
+		})
+	})
+
+	_, err := client.GetUserByID(context.Background(), userID)
+	g.Expect(err).To(Equal(entities.ErrUserNotFound))
+}
+
+func TestUserServiceClient_CreateUser_Rejected(t *testing.T) {
+	g := NewWithT(t)
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodPost))
+		g.Expect(r.URL.Path).To(Equal("/user"))
+		g.Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"password_rejected","violations":["too_short"]}`))
+	})
+
+	_, err := client.CreateUser(context.Background(), "alec", "smith", "alecsmith", "pw", "alec@email.com", "UK")
+	g.Expect(err).To(Equal(&adapters.UserServiceError{
+		StatusCode: http.StatusBadRequest,
+		Body:       `{"error":"password_rejected","violations":["too_short"]}`,
+	}))
+	g.Expect(err).To(MatchError(ContainSubstring("400 Bad Request")))
+}
+
+func TestUserServiceClient_SuspendUser_Conflict(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodPost))
+		g.Expect(r.URL.Path).To(Equal("/admin/user/" + userID.String() + "/suspend"))
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	_, err := client.SuspendUser(context.Background(), userID)
+	g.Expect(err).To(Equal(entities.ErrInvalidStatusTransition))
+}
+
+func TestUserServiceClient_DeleteUser(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+	called := false
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodDelete))
+		g.Expect(r.URL.Path).To(Equal("/user/" + userID.String()))
+		called = true
+	})
+
+	g.Expect(client.DeleteUser(context.Background(), userID)).To(Succeed())
+	g.Expect(called).To(BeTrue())
+}
+
+func TestUserServiceClient_SearchUsers(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/users/search"))
+		g.Expect(r.URL.Query().Get("q")).To(Equal("alec smith"))
+		g.Expect(r.URL.Query().Get("limit")).To(Equal("5"))
+
+		json.NewEncoder(w).Encode(usecases.SearchUsersResponseBody{
+			Results: []usecases.UserSearchResultResponse{{
+				User:  usecases.PublicUserResponse{ID: userID.String(), Nickname: "alecsmith"},
+				Score: 0.8,
+				Highlights: map[string]string{
+					entities.SearchFieldLastName: "<mark>smith</mark>",
+					entities.SearchFieldNickname: "<mark>alec</mark>smith",
+				},
+			}},
+		})
+	})
+
+	results, err := client.SearchUsers(context.Background(), "alec smith", 5)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(HaveLen(1))
+	g.Expect(results[0].User.ID).To(Equal(userID))
+	g.Expect(results[0].Score).To(Equal(0.8))
+	g.Expect(results[0].MatchedFields).To(Equal([]string{entities.SearchFieldNickname, entities.SearchFieldLastName}))
+}
+
+func TestUserServiceClient_ExportUsers(t *testing.T) {
+	g := NewWithT(t)
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/users/export"))
+		g.Expect(r.URL.Query()).To(HaveKeyWithValue("format", []string{"csv"}))
+		g.Expect(r.URL.Query()).To(HaveKeyWithValue("country", []string{"UK"}))
+		g.Expect(r.URL.Query()).ToNot(HaveKey("nickname"))
+
+		w.Write([]byte("id,nickname\n1,alecsmith\n"))
+	})
+
+	var out bytes.Buffer
+	err := client.ExportUsers(context.Background(), "csv", "", "", "", "", "", "UK", &out)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(out.String()).To(Equal("id,nickname\n1,alecsmith\n"))
+}
+
+func TestUserServiceClient_ImportFile(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/users/import"))
+		g.Expect(r.URL.Query().Get("format")).To(Equal("ndjson"))
+		g.Expect(r.URL.Query().Get("dry_run")).To(Equal("false"))
+		g.Expect(r.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+		body, err := io.ReadAll(r.Body)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(body)).To(Equal("some-file"))
+
+		json.NewEncoder(w).Encode(usecases.ImportUsersResponseBody{
+			ImportedRows: 1,
+			FailedRows:   1,
+			Results: []usecases.ImportRowResultResponse{
+				{Row: 1, UserID: userID.String()},
+				{Row: 2, Error: "missing email"},
+			},
+		})
+	})
+
+	results, err := client.ImportFile(context.Background(), "ndjson", bytes.NewBufferString("some-file"), false)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results).To(Equal([]entities.ImportRowResult{
+		{Row: 1, UserID: &userID},
+		{Row: 2, Error: "missing email"},
+	}))
+}
+
+func TestUserServiceClient_ImportFile_Stopped(t *testing.T) {
+	g := NewWithT(t)
+	userID := uuid.New()
+
+	client := newTestUserServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(usecases.ImportUsersResponseBody{
+			ImportedRows: 1,
+			Error:        "importing rows 2 to 2: an error occurred",
+			Results:      []usecases.ImportRowResultResponse{{Row: 1, UserID: userID.String()}},
+		})
+	})
+
+	results, err := client.ImportFile(context.Background(), "csv", bytes.NewBufferString("some-file"), false)
+	g.Expect(err).To(MatchError("import stopped: importing rows 2 to 2: an error occurred"))
+	g.Expect(results).To(Equal([]entities.ImportRowResult{{Row: 1, UserID: &userID}}))
+}
@@ -0,0 +1,67 @@
+package drivers
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"log/slog"
+	"time"
+)
+
+// RunConsumer passes each message fetched from consumer to process until ctx is cancelled. A message is only committed
+// once process has succeeded, it is retried every retryDelay until then rather than skipped. The whole message is
+// processed again, so process shouldn't return an error once it has made changes that can't be repeated.
+func RunConsumer(
+	ctx context.Context,
+	name string,
+	consumer usecases.MessageConsumer,
+	retryDelay time.Duration,
+	process func(ctx context.Context, message entities.InboundMessage) error,
+) {
+	for {
+		message, err := consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			slog.Error("fetching message", "consumer", name, "err", err)
+			if !waitToRetry(ctx, retryDelay) {
+				return
+			}
+			continue
+		}
+
+		for {
+			err = process(ctx, message)
+			if err == nil {
+				break
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			slog.Error("processing message", "consumer", name, "err", err, "partition", message.Partition, "offset", message.Offset)
+			if !waitToRetry(ctx, retryDelay) {
+				return
+			}
+		}
+
+		err = consumer.CommitMessage(ctx, message)
+		if err != nil && ctx.Err() == nil {
+			// the message has been processed, it is processed again if it is redelivered
+			slog.Error("committing message", "consumer", name, "err", err, "partition", message.Partition, "offset", message.Offset)
+		}
+	}
+}
+
+// waitToRetry waits for delay, returning false if ctx is cancelled first
+func waitToRetry(ctx context.Context, delay time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
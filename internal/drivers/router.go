@@ -2,32 +2,171 @@ package drivers
 
 import (
 	_ "github.com/AlecSmith96/faceit-user-service/docs"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
 	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
 	"github.com/gin-gonic/gin"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
+	"net/http"
+	"strings"
 )
 
-func NewRouter(
-	changelogWriter usecases.ChangelogWriter,
-	userGetter usecases.UserGetter,
-	userCreator usecases.UserCreator,
-	userDeleter usecases.UserDeleter,
-	userUpdater usecases.UserUpdater,
-	readinessChecker usecases.ReadinessChecker,
-) *gin.Engine {
+// RouterDependencies are the adapters and use cases the router's handlers are built from
+type RouterDependencies struct {
+	ChangelogWriter usecases.ChangelogWriter
+	// UserRepository backs the core user endpoints, the rest of the user data has its own stores
+	UserRepository          usecases.UserRepository
+	UserStatusUpdater       usecases.UserStatusUpdater
+	TokenSigner             usecases.TokenSigner
+	EmailVerificationStore  usecases.EmailVerificationStore
+	VerificationEmailSender *usecases.VerificationEmailSender
+	UnverifiedAccountPolicy entities.UnverifiedAccountPolicy
+	PasswordManager         usecases.PasswordManager
+	PasswordResetSender     *usecases.PasswordResetSender
+	EmailChangeStore        usecases.EmailChangeStore
+	EmailChanger            *usecases.EmailChanger
+	MFAManager              *usecases.MFAManager
+	SessionStore            usecases.SessionStore
+	SessionIssuer           *usecases.SessionIssuer
+	WebAuthnStore           usecases.WebAuthnStore
+	WebAuthnManager         *usecases.WebAuthnManager
+	OIDCStore               usecases.OIDCStore
+	OIDCProvider            *usecases.OIDCProvider
+	LoginThrottler          *usecases.LoginThrottler
+	PasswordValidator       *usecases.PasswordValidator
+	APIKeyStore             usecases.APIKeyStore
+	APIKeyAuthenticator     *usecases.APIKeyAuthenticator
+	AuditLog                usecases.AuditLog
+	UserImport              *usecases.UserImport
+	ImportJobStore          usecases.ImportJobStore
+	UserExporter            usecases.UserExporter
+	UserBatchWriter         usecases.UserBatchWriter
+	UserSearcher            usecases.UserSearcher
+	// BatchMaxUsers is the most users a single batch request can name
+	BatchMaxUsers int
+	// UsersOnly registers just the core user endpoints and the readiness check, for storage backends that store users
+	// and nothing else. The dependencies only the other endpoints use can be left out, as can AuditLog, APIKeyStore,
+	// VerificationEmailSender and EmailChanger.
+	UsersOnly bool
+}
+
+func NewRouter(deps RouterDependencies) *gin.Engine {
 	r := gin.Default()
 
 	// docs endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	r.GET("/users", usecases.NewGetUsers(userGetter, changelogWriter))
-	r.POST("/user", usecases.NewCreateUser(userCreator, changelogWriter))
-	r.DELETE("/user/:userId", usecases.NewDeleteUser(userDeleter, changelogWriter))
-	r.PUT("/user/:userId", usecases.NewUpdateUser(userUpdater, changelogWriter))
+	readUsers := usecases.NewRequireAPIKeyScope(deps.APIKeyAuthenticator, entities.APIKeyScopeUsersRead)
+	writeUsers := usecases.NewRequireAPIKeyScope(deps.APIKeyAuthenticator, entities.APIKeyScopeUsersWrite)
+	adminUsers := usecases.NewRequireAPIKeyScope(deps.APIKeyAuthenticator, entities.APIKeyScopeUsersAdmin)
+	// ownAccessToken is for endpoints a user manages their own account with, they need the user's access token
+	ownAccessToken := usecases.NewRequireUserAccessToken(deps.SessionIssuer, deps.UserRepository)
+
+	r.GET("/users", readUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUsersRead), usecases.NewGetUsers(deps.UserRepository, deps.ChangelogWriter))
+	r.POST("/user", writeUsers, usecases.NewCreateUser(deps.UserRepository, deps.ChangelogWriter, deps.VerificationEmailSender, deps.PasswordValidator))
+	r.DELETE("/user/:userId", writeUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserErased), usecases.NewDeleteUser(deps.UserRepository, deps.ChangelogWriter))
+	r.PUT("/user/:userId", writeUsers, usecases.NewUnverifiedAccountRestriction(deps.UserRepository, deps.UnverifiedAccountPolicy), usecases.NewUpdateUser(deps.UserRepository, deps.UserRepository, deps.EmailChanger, deps.ChangelogWriter, deps.PasswordValidator))
 
 	// health check
-	r.GET("/health/readiness", usecases.NewReadinessCheck(readinessChecker))
+	r.GET("/health/readiness", usecases.NewReadinessCheck(deps.UserRepository))
+
+	if deps.UsersOnly {
+		return r
+	}
+
+	// bulk import
+	r.POST("/users/import", writeUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUsersImported), usecases.NewImportUsers(deps.UserImport))
+	r.GET("/users/import/:jobId", writeUsers, usecases.NewGetImportJob(deps.ImportJobStore))
+
+	// bulk export
+	r.GET("/users/export", readUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUsersExported), usecases.NewExportUsers(deps.UserExporter))
+	r.GET("/users/search", readUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUsersRead), usecases.NewSearchUsers(deps.UserSearcher))
+
+	// batch operations, registered without the collection as they are routed by newCustomMethodRouter
+	userMethods := gin.New()
+	userMethods.POST("/batchGet", readUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUsersRead), usecases.NewBatchGetUsers(deps.UserRepository, deps.BatchMaxUsers))
+	userMethods.POST("/batchUpdate", writeUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserUpdated), usecases.NewBatchUpdateUsers(deps.UserRepository, deps.UserBatchWriter, deps.ChangelogWriter, deps.PasswordValidator, deps.UnverifiedAccountPolicy, deps.BatchMaxUsers))
+	userMethods.POST("/batchDelete", writeUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserErased), usecases.NewBatchDeleteUsers(deps.UserBatchWriter, deps.ChangelogWriter, deps.BatchMaxUsers))
+	r.POST("/users:method", newCustomMethodRouter(userMethods))
+
+	// email verification
+	r.POST("/user/:userId/verify-email/send", usecases.NewSendVerificationEmail(deps.UserRepository, deps.VerificationEmailSender))
+	r.POST("/verify-email", usecases.NewVerifyEmail(deps.TokenSigner, deps.EmailVerificationStore, deps.ChangelogWriter))
+
+	// email changes
+	r.POST("/email-change/confirm", usecases.NewConfirmEmailChange(deps.TokenSigner, deps.EmailChangeStore, deps.ChangelogWriter))
+	r.POST("/email-change/revert", usecases.NewRevertEmailChange(deps.TokenSigner, deps.EmailChangeStore, deps.ChangelogWriter))
+
+	// login
+	r.POST("/auth/login", usecases.NewLogin(deps.UserRepository, deps.MFAManager, deps.SessionIssuer, deps.LoginThrottler))
+	r.POST("/auth/login/mfa", usecases.NewLoginMFA(deps.UserRepository, deps.MFAManager, deps.SessionIssuer, deps.LoginThrottler))
+	r.POST("/auth/webauthn/login/begin", usecases.NewBeginWebAuthnLogin(deps.WebAuthnManager))
+	r.POST("/auth/webauthn/login/finish", usecases.NewFinishWebAuthnLogin(deps.UserRepository, deps.WebAuthnManager, deps.SessionIssuer))
+	r.POST("/auth/refresh", usecases.NewRefreshSession(deps.UserRepository, deps.SessionIssuer))
+
+	// sessions
+	r.GET("/user/:userId/sessions", ownAccessToken, usecases.NewListSessions(deps.SessionStore))
+	r.DELETE("/user/:userId/sessions", ownAccessToken, usecases.NewRevokeAllSessions(deps.SessionStore, deps.ChangelogWriter))
+	r.DELETE("/user/:userId/sessions/:sessionId", ownAccessToken, usecases.NewRevokeSession(deps.SessionStore, deps.ChangelogWriter))
+
+	// multi-factor authentication
+	r.POST("/user/:userId/mfa/totp", usecases.NewEnrolTOTP(deps.UserRepository, deps.MFAManager, deps.LoginThrottler))
+	r.POST("/user/:userId/mfa/totp/confirm", usecases.NewConfirmTOTP(deps.MFAManager, deps.ChangelogWriter))
+	r.POST("/user/:userId/mfa/totp/disable", ownAccessToken, usecases.NewDisableTOTP(deps.UserRepository, deps.MFAManager, deps.ChangelogWriter, deps.LoginThrottler))
+	r.POST("/user/:userId/mfa/recovery-codes", ownAccessToken, usecases.NewRegenerateRecoveryCodes(deps.UserRepository, deps.MFAManager, deps.ChangelogWriter, deps.LoginThrottler))
+
+	// openid connect provider
+	r.GET("/.well-known/openid-configuration", usecases.NewGetOpenIDConfiguration(deps.OIDCProvider))
+	r.GET("/.well-known/jwks.json", usecases.NewGetJSONWebKeySet(deps.OIDCProvider))
+	r.GET("/authorize", usecases.NewAuthorize(deps.OIDCProvider))
+	r.POST("/authorize/consent", usecases.NewAuthorizeConsent(deps.OIDCProvider))
+	r.POST("/token", usecases.NewOIDCToken(deps.OIDCProvider))
+	r.GET("/userinfo", usecases.NewUserInfo(deps.OIDCProvider))
+	r.POST("/admin/oidc/clients", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionOIDCClientCreated), usecases.NewCreateOIDCClient(deps.OIDCStore))
+
+	// passkeys and security keys
+	r.POST("/user/:userId/webauthn/register/begin", ownAccessToken, usecases.NewBeginWebAuthnRegistration(deps.UserRepository, deps.WebAuthnManager, deps.LoginThrottler))
+	r.POST("/user/:userId/webauthn/register/finish", ownAccessToken, usecases.NewFinishWebAuthnRegistration(deps.UserRepository, deps.WebAuthnManager, deps.ChangelogWriter))
+	r.GET("/user/:userId/webauthn/credentials", ownAccessToken, usecases.NewListWebAuthnCredentials(deps.WebAuthnStore))
+	r.DELETE("/user/:userId/webauthn/credentials/:credentialId", ownAccessToken, usecases.NewRevokeWebAuthnCredential(deps.WebAuthnStore, deps.ChangelogWriter))
+
+	// passwords
+	r.POST("/auth/password/forgot", usecases.NewForgotPassword(deps.UserRepository, deps.PasswordResetSender))
+	r.POST("/auth/password/reset", usecases.NewResetPassword(deps.PasswordManager, deps.ChangelogWriter, deps.PasswordValidator))
+	r.POST("/user/:userId/password", usecases.NewChangePassword(deps.UserRepository, deps.PasswordManager, deps.ChangelogWriter, deps.PasswordValidator, deps.LoginThrottler))
+
+	// account status moderation
+	r.POST("/admin/user/:userId/suspend", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserSuspended), usecases.NewSuspendUser(deps.UserRepository, deps.UserStatusUpdater, deps.ChangelogWriter))
+	r.POST("/admin/user/:userId/unsuspend", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserUnsuspended), usecases.NewUnsuspendUser(deps.UserRepository, deps.UserStatusUpdater, deps.ChangelogWriter))
+	r.POST("/admin/user/:userId/ban", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserBanned), usecases.NewBanUser(deps.UserRepository, deps.UserStatusUpdater, deps.ChangelogWriter))
+	r.POST("/admin/user/:userId/unban", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserUnbanned), usecases.NewUnbanUser(deps.UserRepository, deps.UserStatusUpdater, deps.ChangelogWriter))
+	r.POST("/admin/user/:userId/unlock", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionUserUnlocked), usecases.NewUnlockUser(deps.UserRepository, deps.LoginThrottler))
+
+	// api keys
+	r.POST("/admin/api-keys", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionAPIKeyCreated), usecases.NewCreateAPIKey(deps.APIKeyStore))
+	r.GET("/admin/api-keys", adminUsers, usecases.NewListAPIKeys(deps.APIKeyStore))
+	r.DELETE("/admin/api-keys/:apiKeyId", adminUsers, usecases.NewAuditAction(deps.AuditLog, entities.AuditActionAPIKeyRevoked), usecases.NewRevokeAPIKey(deps.APIKeyStore))
+
+	// audit log
+	r.GET("/admin/audit", adminUsers, usecases.NewGetAuditLog(deps.AuditLog))
 
 	return r
 }
+
+// newCustomMethodRouter routes custom methods, such as POST /users:batchGet, to the routes registered on methods under
+// the method's name. Gin reads a colon as the start of a path parameter, so a collection's custom methods all match
+// one route and have to be told apart here.
+func newCustomMethodRouter(methods *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method, found := strings.CutPrefix(c.Param("method"), ":")
+		if !found {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		path := c.Request.URL.Path
+		c.Request.URL.Path = "/" + method
+		methods.HandleContext(c)
+		c.Request.URL.Path = path
+	}
+}
@@ -0,0 +1,26 @@
+package drivers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunPeriodically runs job every interval until ctx is cancelled. Errors are logged rather than stopping the loop so
+// that a transient failure is retried on the next tick.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := job(ctx)
+			if err != nil {
+				slog.Error("running scheduled job", "job", name, "err", err)
+			}
+		}
+	}
+}
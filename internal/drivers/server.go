@@ -0,0 +1,45 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Serve serves the handler on addr until ctx is cancelled, then stops accepting connections and waits up to
+// shutdownTimeout for the requests in flight to finish
+func Serve(ctx context.Context, addr string, handler http.Handler, shutdownTimeout time.Duration) error {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <-serveErr
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
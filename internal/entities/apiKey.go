@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// API key scopes, each includes the ones before it so a users:admin key can also read and write users
+const (
+	APIKeyScopeUsersRead  = "users:read"
+	APIKeyScopeUsersWrite = "users:write"
+	APIKeyScopeUsersAdmin = "users:admin"
+)
+
+var APIKeyScopes = []string{APIKeyScopeUsersRead, APIKeyScopeUsersWrite, APIKeyScopeUsersAdmin}
+
+// APIKey lets another service call the API without a user logging in
+type APIKey struct {
+	ID   uuid.UUID
+	Name string
+	// Owner is the service or team responsible for the key
+	Owner      string
+	Scopes     []string
+	SecretHash string
+	CreatedAt  time.Time
+	// ExpiresAt is nil for keys that don't expire
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// HasScope reports whether the key was granted the scope, or a scope that includes it
+func (k APIKey) HasScope(scope string) bool {
+	required := slices.Index(APIKeyScopes, scope)
+	if required == -1 {
+		return false
+	}
+
+	for _, granted := range k.Scopes {
+		if slices.Index(APIKeyScopes, granted) >= required {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Audited actions, they are recorded once the action has succeeded
+const (
+	AuditActionUsersRead         = "USERS_READ"
+	AuditActionUserErased        = "USER_ERASED"
+	AuditActionUserUpdated       = "USER_UPDATED"
+	AuditActionUserSuspended     = "USER_SUSPENDED"
+	AuditActionUserUnsuspended   = "USER_UNSUSPENDED"
+	AuditActionUserBanned        = "USER_BANNED"
+	AuditActionUserUnbanned      = "USER_UNBANNED"
+	AuditActionUserUnlocked      = "USER_UNLOCKED"
+	AuditActionAPIKeyCreated     = "API_KEY_CREATED"
+	AuditActionAPIKeyRevoked     = "API_KEY_REVOKED"
+	AuditActionOIDCClientCreated = "OIDC_CLIENT_CREATED"
+	AuditActionUsersImported     = "USERS_IMPORTED"
+	AuditActionUsersExported     = "USERS_EXPORTED"
+)
+
+// AuditEntry records who did what. Entries form a hash chain, each one's hash covers its contents and the hash of the
+// entry before it, so changing or removing an entry breaks the chain from that point on.
+type AuditEntry struct {
+	// Sequence numbers entries from 1 with no gaps
+	Sequence int64
+	// Actor names the API key the action was taken with, or "anonymous" where keys aren't required
+	Actor         string
+	ActorAPIKeyID *uuid.UUID `json:",omitempty"`
+	Action        string
+	TargetUserID  *uuid.UUID        `json:",omitempty"`
+	Details       map[string]string `json:",omitempty"`
+	IPAddress     string
+	CreatedAt     time.Time
+	// PreviousHash is empty for the first entry
+	PreviousHash string
+	Hash         string
+}
+
+// ComputeHash returns the hex SHA-256 of the entry's contents and previous hash, ignoring its Hash
+func (e AuditEntry) ComputeHash() string {
+	// json encodes fields in declaration order and map keys sorted, so the same entry always encodes the same way
+	encoded, err := json.Marshal(struct {
+		Sequence      int64
+		PreviousHash  string
+		Actor         string
+		ActorAPIKeyID *uuid.UUID
+		Action        string
+		TargetUserID  *uuid.UUID
+		Details       map[string]string
+		IPAddress     string
+		CreatedAt     string
+	}{
+		Sequence:      e.Sequence,
+		PreviousHash:  e.PreviousHash,
+		Actor:         e.Actor,
+		ActorAPIKeyID: e.ActorAPIKeyID,
+		Action:        e.Action,
+		TargetUserID:  e.TargetUserID,
+		Details:       e.Details,
+		IPAddress:     e.IPAddress,
+		CreatedAt:     e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		// only strings, numbers and uuids are encoded, none of which can fail
+		panic(err)
+	}
+
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:])
+}
+
+// Chain fills in the entry's sequence, previous hash and hash so that it follows previous, which is nil for the first
+// entry
+func (e AuditEntry) Chain(previous *AuditEntry) AuditEntry {
+	e.Sequence = 1
+	e.PreviousHash = ""
+	if previous != nil {
+		e.Sequence = previous.Sequence + 1
+		e.PreviousHash = previous.Hash
+	}
+	e.Hash = e.ComputeHash()
+
+	return e
+}
+
+// AuditChainError is returned when verifying the audit log finds an entry that has been changed, or that doesn't
+// follow the entry before it
+type AuditChainError struct {
+	Sequence int64
+	Reason   string
+}
+
+func (e *AuditChainError) Error() string {
+	return fmt.Sprintf("audit log entry %d: %s", e.Sequence, e.Reason)
+}
+
+// VerifyFollows checks that the entry is unchanged and follows previous, which is nil for the first entry
+func (e AuditEntry) VerifyFollows(previous *AuditEntry) error {
+	expectedSequence := int64(1)
+	expectedPreviousHash := ""
+	if previous != nil {
+		expectedSequence = previous.Sequence + 1
+		expectedPreviousHash = previous.Hash
+	}
+
+	if e.Sequence != expectedSequence {
+		return &AuditChainError{Sequence: e.Sequence, Reason: fmt.Sprintf("expected entry %d, entries are missing", expectedSequence)}
+	}
+	if e.PreviousHash != expectedPreviousHash {
+		return &AuditChainError{Sequence: e.Sequence, Reason: "previous hash doesn't match the entry before it"}
+	}
+	if e.Hash != e.ComputeHash() {
+		return &AuditChainError{Sequence: e.Sequence, Reason: "hash doesn't match its contents"}
+	}
+
+	return nil
+}
+
+// AuditFilter narrows a query of the audit log, zero fields don't filter
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	TargetUserID *uuid.UUID
+	From         *time.Time
+	To           *time.Time
+	// AfterSequence returns entries after the one with this sequence, for paging through the log
+	AfterSequence int64
+	Limit         int
+}
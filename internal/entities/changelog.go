@@ -5,10 +5,35 @@ import (
 	"time"
 )
 
+const (
+	ChangeTypeSuspended                 = "SUSPENDED"
+	ChangeTypeUnsuspended               = "UNSUSPENDED"
+	ChangeTypeBanned                    = "BANNED"
+	ChangeTypeUnbanned                  = "UNBANNED"
+	ChangeTypeBanExpired                = "BAN_EXPIRED"
+	ChangeTypeEmailVerified             = "EMAIL_VERIFIED"
+	ChangeTypePasswordChanged           = "PASSWORD_CHANGED"
+	ChangeTypePasswordReset             = "PASSWORD_RESET"
+	ChangeTypeEmailChangeRequested      = "EMAIL_CHANGE_REQUESTED"
+	ChangeTypeEmailChanged              = "EMAIL_CHANGED"
+	ChangeTypeEmailChangeReverted       = "EMAIL_CHANGE_REVERTED"
+	ChangeTypeMFAEnabled                = "MFA_ENABLED"
+	ChangeTypeMFADisabled               = "MFA_DISABLED"
+	ChangeTypeRecoveryCodesRegenerated  = "MFA_RECOVERY_CODES_REGENERATED"
+	ChangeTypeWebAuthnCredentialAdded   = "WEBAUTHN_CREDENTIAL_ADDED"
+	ChangeTypeWebAuthnCredentialRevoked = "WEBAUTHN_CREDENTIAL_REVOKED"
+	ChangeTypeSessionsRevoked           = "SESSIONS_REVOKED"
+	ChangeTypeUsersImported             = "USERS_IMPORTED"
+)
+
 // ChangelogEntry is a struct that represents a change to a user entity.
 // Could be improved by adding a field for an identifier of the person who made the change
 type ChangelogEntry struct {
 	UserID     uuid.UUID
 	CreatedAt  time.Time
 	ChangeType string
+	// SessionIDs lists the sessions that were ended, for SESSIONS_REVOKED entries
+	SessionIDs []uuid.UUID `json:",omitempty"`
+	// UserIDs lists the users created by one batch of an import, for USERS_IMPORTED entries which have no UserID
+	UserIDs []uuid.UUID `json:",omitempty"`
 }
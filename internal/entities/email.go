@@ -0,0 +1,8 @@
+package entities
+
+// Email is a plain text message to be delivered to a single recipient
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
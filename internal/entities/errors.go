@@ -3,6 +3,23 @@ package entities
 import "errors"
 
 var (
-	ErrUserNotFound     = errors.New("user not found")
-	ErrEmailAlreadyUsed = errors.New("email already registered to a user")
+	ErrUserNotFound               = errors.New("user not found")
+	ErrEmailAlreadyUsed           = errors.New("email already registered to a user")
+	ErrInvalidStatusTransition    = errors.New("user status transition not allowed")
+	ErrInvalidToken               = errors.New("token is invalid, expired or already used")
+	ErrEmailAlreadyVerified       = errors.New("email already verified")
+	ErrIncorrectPassword          = errors.New("incorrect password")
+	ErrMFANotEnrolled             = errors.New("multi-factor authentication not enrolled")
+	ErrMFAAlreadyEnabled          = errors.New("multi-factor authentication already enabled")
+	ErrInvalidMFACode             = errors.New("multi-factor authentication code is invalid or already used")
+	ErrWebAuthnFailed             = errors.New("webauthn ceremony failed")
+	ErrWebAuthnCredentialExists   = errors.New("webauthn credential already registered")
+	ErrWebAuthnCredentialNotFound = errors.New("webauthn credential not found")
+	ErrOIDCClientNotFound         = errors.New("oidc client not found")
+	ErrInvalidRedirectURI         = errors.New("redirect uri not registered for oidc client")
+	ErrSessionNotFound            = errors.New("session not found")
+	ErrSigningKeysChanged         = errors.New("signing keys changed by another instance")
+	ErrAPIKeyNotFound             = errors.New("api key not found")
+	ErrImportJobNotFound          = errors.New("import job not found")
+	ErrUserCommandReplyNotFound   = errors.New("user command reply not found")
 )
@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	LoginFailureUnknownEmail      = "unknown_email"
+	LoginFailureIncorrectPassword = "incorrect_password"
+	LoginFailureInvalidMFACode    = "invalid_mfa_code"
+	LoginFailureThrottled         = "throttled"
+)
+
+// LoginAttempt is a record of someone trying to log in, kept in the login audit table
+type LoginAttempt struct {
+	ID uuid.UUID
+	// UserID is nil when the email doesn't belong to a user
+	UserID        *uuid.UUID
+	Email         string
+	IPAddress     string
+	UserAgent     string
+	Succeeded     bool
+	FailureReason string
+	CreatedAt     time.Time
+}
+
+// LoginThrottle is the failed login state for one account or IP address
+type LoginThrottle struct {
+	Failures      int
+	LastFailureAt time.Time
+	// BlockedUntil is when the next login can be attempted, nil if there is no need to wait
+	BlockedUntil *time.Time
+	// Locked is true when the block is a lockout rather than a backoff, only an admin or time can lift it
+	Locked bool
+}
+
+// RetryAfter returns how long is left to wait before another login can be attempted
+func (t LoginThrottle) RetryAfter(now time.Time) time.Duration {
+	if t.BlockedUntil == nil || !now.Before(*t.BlockedUntil) {
+		return 0
+	}
+
+	return t.BlockedUntil.Sub(now)
+}
+
+// LoginThrottlePolicy decides how long to block logins for after each failure. The delay doubles with every failure
+// after the free ones, and reaching LockoutFailures locks the account for LockoutDuration instead.
+type LoginThrottlePolicy struct {
+	// FreeFailures is how many failures are allowed before any delay
+	FreeFailures int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	// LockoutFailures is how many failures lock the account, zero never locks
+	LockoutFailures int
+	LockoutDuration time.Duration
+	// ResetAfter is how long after the last failure the count starts again
+	ResetAfter time.Duration
+}
+
+// Fail returns the throttle after one more failure at now
+func (p LoginThrottlePolicy) Fail(throttle LoginThrottle, now time.Time) LoginThrottle {
+	failures := throttle.Failures
+	lockExpired := throttle.Locked && throttle.RetryAfter(now) == 0
+	if lockExpired || now.Sub(throttle.LastFailureAt) >= p.ResetAfter {
+		failures = 0
+	}
+	failures++
+
+	next := LoginThrottle{Failures: failures, LastFailureAt: now}
+	if p.LockoutFailures > 0 && failures >= p.LockoutFailures {
+		blockedUntil := now.Add(p.LockoutDuration)
+		next.BlockedUntil = &blockedUntil
+		next.Locked = true
+		return next
+	}
+
+	if failures > p.FreeFailures {
+		blockedUntil := now.Add(p.delay(failures - p.FreeFailures))
+		next.BlockedUntil = &blockedUntil
+	}
+
+	return next
+}
+
+// delay returns BaseDelay doubled for each throttled failure after the first, up to MaxDelay
+func (p LoginThrottlePolicy) delay(throttledFailures int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < throttledFailures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// TOTPEnrolment is a user's TOTP authenticator. The secret is only ever held encrypted, and the enrolment doesn't
+// protect the account until it has been confirmed with a first code.
+type TOTPEnrolment struct {
+	UserID          uuid.UUID
+	EncryptedSecret string
+	ConfirmedAt     *time.Time
+	// LastUsedCounter is the time step of the last accepted code, so that a code can't be replayed
+	LastUsedCounter *int64
+	CreatedAt       time.Time
+}
+
+// Enabled reports whether the enrolment has been confirmed and should be challenged at login
+func (e TOTPEnrolment) Enabled() bool {
+	return e.ConfirmedAt != nil
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	OIDCScopeOpenID  = "openid"
+	OIDCScopeProfile = "profile"
+	OIDCScopeEmail   = "email"
+)
+
+// OIDCSupportedScopes are the scopes the provider understands, any others requested are ignored
+var OIDCSupportedScopes = []string{OIDCScopeOpenID, OIDCScopeProfile, OIDCScopeEmail}
+
+// OAuth error codes from RFC 6749 and OpenID Connect Core, returned to clients in the "error" parameter
+const (
+	OAuthErrorInvalidRequest          = "invalid_request"
+	OAuthErrorInvalidClient           = "invalid_client"
+	OAuthErrorInvalidGrant            = "invalid_grant"
+	OAuthErrorInvalidScope            = "invalid_scope"
+	OAuthErrorUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrorUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrorAccessDenied            = "access_denied"
+	OAuthErrorConsentRequired         = "consent_required"
+	OAuthErrorLoginRequired           = "login_required"
+	OAuthErrorInsufficientScope       = "insufficient_scope"
+)
+
+// OAuthError is an error that is reported to the OAuth client using one of the protocol's error codes
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// OIDCClient is an application registered to sign users in through the OpenID Connect provider. Public clients, such
+// as the desktop client, have no secret and rely on PKCE alone.
+type OIDCClient struct {
+	ID            string
+	SecretHash    string
+	Name          string
+	RedirectURIs  []string
+	AllowedScopes []string
+	CreatedAt     time.Time
+}
+
+// Public reports whether the client can't keep a secret
+func (c OIDCClient) Public() bool {
+	return c.SecretHash == ""
+}
+
+// AllowsRedirectURI reports whether uri exactly matches one of the client's registered redirect URIs
+func (c OIDCClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OIDCAuthorizationCode is a single use code issued by the authorize endpoint, of which only a hash is stored
+type OIDCAuthorizationCode struct {
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	Scopes        []string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      time.Time
+	ExpiresAt     time.Time
+}
+
+// UserInfoClaims are the claims about a user released to a client, depending on the scopes it was granted
+type UserInfoClaims struct {
+	Subject       string `json:"sub"`
+	Nickname      string `json:"nickname,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	UserInfoClaims
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+	AuthTime  int64  `json:"auth_time"`
+	Nonce     string `json:"nonce,omitempty"`
+}
+
+// JSONWebKey is a public key in the JWK format (RFC 7517), used by clients to check the signature of ID tokens
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
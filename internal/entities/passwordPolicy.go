@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password rules, each has its own code so that clients can explain exactly what to change
+const (
+	PasswordRuleTooShort         = "password_too_short"
+	PasswordRuleTooLong          = "password_too_long"
+	PasswordRuleTooPredictable   = "password_too_predictable"
+	PasswordRuleContainsNickname = "password_contains_nickname"
+	PasswordRuleContainsEmail    = "password_contains_email"
+	PasswordRuleBreached         = "password_breached"
+)
+
+// minPasswordIdentifierLength is the shortest nickname or email part that passwords are checked for, shorter ones
+// would reject too many unrelated passwords
+const minPasswordIdentifierLength = 3
+
+// PasswordPolicyError is returned when a password breaks one or more rules of the policy
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password rejected by policy: " + strings.Join(e.Violations, ", ")
+}
+
+// PasswordPolicy is what a password must satisfy before it's accepted, other than not having been breached
+type PasswordPolicy struct {
+	// MinLength and MaxLength are counted in characters rather than bytes
+	MinLength int
+	MaxLength int
+	// MinEntropyBits is the lowest EstimatePasswordEntropy accepted
+	MinEntropyBits float64
+}
+
+// Check returns the rules the password breaks for the user with the nickname and email
+func (p PasswordPolicy) Check(password, nickname, email string) []string {
+	violations := []string{}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PasswordRuleTooShort)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, PasswordRuleTooLong)
+	}
+
+	if EstimatePasswordEntropy(password) < p.MinEntropyBits {
+		violations = append(violations, PasswordRuleTooPredictable)
+	}
+
+	lowerPassword := strings.ToLower(password)
+	if containsIdentifier(lowerPassword, nickname) {
+		violations = append(violations, PasswordRuleContainsNickname)
+	}
+
+	localPart, domain, _ := strings.Cut(email, "@")
+	domainName, _, _ := strings.Cut(domain, ".")
+	if containsIdentifier(lowerPassword, localPart) || containsIdentifier(lowerPassword, domainName) {
+		violations = append(violations, PasswordRuleContainsEmail)
+	}
+
+	return violations
+}
+
+func containsIdentifier(lowerPassword, identifier string) bool {
+	identifier = strings.ToLower(strings.TrimSpace(identifier))
+	if utf8.RuneCountInString(identifier) < minPasswordIdentifierLength {
+		return false
+	}
+
+	return strings.Contains(lowerPassword, identifier)
+}
+
+// EstimatePasswordEntropy estimates the bits of entropy in a password from the size of the character classes it uses
+// and its length. Characters repeating the previous one, or continuing a run like "abc" or "321", don't add to the
+// length, so padding a short password out with them doesn't make it look stronger.
+func EstimatePasswordEntropy(password string) float64 {
+	var hasLower, hasUpper, hasDigit, hasSymbol, hasOther bool
+	effectiveLength := 0
+	previous, previousStep := rune(-1), rune(0)
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r < unicode.MaxASCII:
+			hasSymbol = true
+		default:
+			hasOther = true
+		}
+
+		step := r - previous
+		switch {
+		case previous == -1:
+			effectiveLength++
+			step = 0
+		case step == 0:
+		case (step == 1 || step == -1) && (previousStep == 0 || previousStep == step):
+		default:
+			effectiveLength++
+		}
+		previous, previousStep = r, step
+	}
+
+	poolSize := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{
+		{hasLower, 26},
+		{hasUpper, 26},
+		{hasDigit, 10},
+		{hasSymbol, 33},
+		{hasOther, 100},
+	} {
+		if class.used {
+			poolSize += class.size
+		}
+	}
+	if poolSize == 0 {
+		return 0
+	}
+
+	return float64(effectiveLength) * math.Log2(float64(poolSize))
+}
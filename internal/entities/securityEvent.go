@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	SecurityEventLoginFailed     = "LOGIN_FAILED"
+	SecurityEventAccountLocked   = "ACCOUNT_LOCKED"
+	SecurityEventAccountUnlocked = "ACCOUNT_UNLOCKED"
+)
+
+// SecurityEvent is published when something happens that security tooling should know about, such as repeated failed
+// logins. Unlike changelog entries they aren't always about a known user.
+type SecurityEvent struct {
+	Type      string
+	UserID    *uuid.UUID `json:",omitempty"`
+	Email     string     `json:",omitempty"`
+	IPAddress string     `json:",omitempty"`
+	// Reason explains why a login failed, for LOGIN_FAILED events
+	Reason    string `json:",omitempty"`
+	CreatedAt time.Time
+	// LockedUntil is when the lockout ends, for ACCOUNT_LOCKED events
+	LockedUntil *time.Time `json:",omitempty"`
+}
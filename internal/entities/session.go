@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Session is a login. It is identified to the client by an opaque refresh token, of which only a hash is stored.
+type Session struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Device SessionDevice
+	// Scope lists the OpenID Connect scopes granted to the client that started the session, if one did
+	Scope      string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// SessionDevice describes where a session is being used from. The user agent and IP address are updated each time the
+// session is refreshed.
+type SessionDevice struct {
+	// Name is chosen by the user when logging in, and may be empty
+	Name      string
+	UserAgent string
+	IPAddress string
+}
+
+// AccessToken is the short-lived bearer token issued for a session
+type AccessToken struct {
+	ID        uuid.UUID `json:"jti"`
+	UserID    uuid.UUID `json:"sub"`
+	SessionID uuid.UUID `json:"sid"`
+	IssuedAt  time.Time `json:"iat"`
+	ExpiresAt time.Time `json:"exp"`
+	// Scope lists the OpenID Connect scopes granted to the client the token was issued to, space separated
+	Scope string `json:"scope,omitempty"`
+}
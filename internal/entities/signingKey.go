@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// SigningKeyStatus represents where a signing key is in its rotation
+type SigningKeyStatus string
+
+const (
+	// SigningKeyStatusNext keys are published but not yet used, so that relying services have them before they are
+	SigningKeyStatusNext SigningKeyStatus = "next"
+	// SigningKeyStatusActive is the single key new tokens are signed with
+	SigningKeyStatusActive SigningKeyStatus = "active"
+	// SigningKeyStatusRetired keys no longer sign anything but stay published until the tokens they signed expire
+	SigningKeyStatusRetired SigningKeyStatus = "retired"
+)
+
+// SigningKey represents an RSA key used to sign ID tokens, its private key is encrypted with the master key
+type SigningKey struct {
+	ID                  string
+	Status              SigningKeyStatus
+	EncryptedPrivateKey string
+	CreatedAt           time.Time
+	ActivatedAt         *time.Time
+	RetiredAt           *time.Time
+	ExpiresAt           *time.Time
+}
@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+const (
+	// UnverifiedAccountPolicyAllow places no restrictions on accounts with an unverified email
+	UnverifiedAccountPolicyAllow = "allow"
+	// UnverifiedAccountPolicyRestrict restricts accounts with an unverified email once their grace period has passed
+	UnverifiedAccountPolicyRestrict = "restrict"
+)
+
+// UnverifiedAccountPolicy decides whether a user that hasn't verified their email is restricted
+type UnverifiedAccountPolicy struct {
+	Mode        string
+	GracePeriod time.Duration
+}
+
+// Restricts reports whether the policy restricts the user at the given time
+func (p UnverifiedAccountPolicy) Restricts(user User, now time.Time) bool {
+	if p.Mode != UnverifiedAccountPolicyRestrict || user.EmailVerifiedAt != nil {
+		return false
+	}
+
+	return now.After(user.CreatedAt.Add(p.GracePeriod))
+}
@@ -6,13 +6,21 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Nickname  string    `json:"nickname"`
-	Password  string    `json:"password"`
-	Email     string    `json:"email"`
-	Country   string    `json:"country"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Nickname        string     `json:"nickname"`
+	Password        string     `json:"password"`
+	Email           string     `json:"email"`
+	Country         string     `json:"country"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Status          UserStatus `json:"status"`
+	BanReason       string     `json:"ban_reason"`
+	BanExpiresAt    *time.Time `json:"ban_expires_at"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// PasswordChangedAt is when the password was last changed, any credentials issued before it are no longer valid
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+	// PendingEmail is the address the user is changing their email to, it replaces Email once confirmed
+	PendingEmail *string `json:"pending_email"`
 }
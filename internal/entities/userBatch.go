@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// BatchMode decides what happens to the rest of a batch write when one of its items fails
+type BatchMode string
+
+const (
+	// BatchModeAllOrNothing applies every item in one transaction, or none of them if any item fails
+	BatchModeAllOrNothing BatchMode = "all_or_nothing"
+	// BatchModeBestEffort applies every item that can be applied and reports the ones that can't
+	BatchModeBestEffort BatchMode = "best_effort"
+)
+
+// BatchItemStatus is the outcome of one item in a batch write
+type BatchItemStatus string
+
+const (
+	BatchItemStatusSucceeded BatchItemStatus = "SUCCEEDED"
+	BatchItemStatusFailed    BatchItemStatus = "FAILED"
+	// BatchItemStatusSkipped is for items that could have been applied but weren't, as another item of an all or
+	// nothing batch failed
+	BatchItemStatusSkipped BatchItemStatus = "SKIPPED"
+)
+
+// UserUpdate is the new profile and status for one user of a batch update. Emails can't be changed in a batch as a new
+// address has to be confirmed before it replaces the current one.
+type UserUpdate struct {
+	UserID    uuid.UUID
+	FirstName string
+	LastName  string
+	Nickname  string
+	Password  string
+	// Email is the user's current address, it is encrypted again along with the rest of the profile
+	Email   string
+	Country string
+	// StatusOnly is set when only the user's status changes, the profile fields are then left as they are
+	StatusOnly bool
+	// Status is the status the user moves into from FromStatus, it is empty to leave their status as it is. The update
+	// fails with ErrInvalidStatusTransition if the user is no longer in FromStatus.
+	Status       UserStatus
+	FromStatus   UserStatus
+	BanReason    string
+	BanExpiresAt *time.Time
+}
+
+// BatchItemResult is the outcome of one user of a batch write
+type BatchItemResult struct {
+	UserID uuid.UUID
+	Status BatchItemStatus
+	// Error is why the item failed, it is empty unless Status is FAILED
+	Error string
+	// User is the user after a successful update, it is nil for deletes
+	User *User
+}
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	UserCommandCreate = "create"
+	UserCommandUpdate = "update"
+	UserCommandDelete = "delete"
+)
+
+const (
+	UserCommandStatusSucceeded = "SUCCEEDED"
+	UserCommandStatusFailed    = "FAILED"
+)
+
+// Error codes given in the reply to a user command that failed
+const (
+	UserCommandErrorInvalidCommand    = "invalid_command"
+	UserCommandErrorUserNotFound      = "user_not_found"
+	UserCommandErrorEmailAlreadyUsed  = "email_already_used"
+	UserCommandErrorPasswordRejected  = "password_rejected"
+	UserCommandErrorAccountRestricted = "account_restricted"
+	UserCommandErrorInternal          = "internal_error"
+)
+
+// InboundMessage is a message consumed from a topic, kept as it was received so that it can be dead-lettered unchanged
+type InboundMessage struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// DeadLetter is a message that couldn't be processed, published to a dead-letter topic to be looked at and replayed
+type DeadLetter struct {
+	Message InboundMessage
+	// Reason explains why the message couldn't be processed
+	Reason   string
+	Attempts int
+	FailedAt time.Time
+}
+
+// UserCommandReply is the reply given to a processed user command, kept so a redelivered command is given it again
+// rather than being applied twice. The user is kept by ID, as their details are only stored encrypted, and looked up
+// again for the reply.
+type UserCommandReply struct {
+	CorrelationID string
+	Type          string
+	Status        string
+	// UserID is the user created or updated, it is nil for deletes and failed commands
+	UserID      *uuid.UUID
+	Error       string
+	Violations  []string
+	ProcessedAt time.Time
+}
@@ -0,0 +1,25 @@
+package entities
+
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+)
+
+// ExportColumns are the user columns an export can include, in the order they are written. Passwords are never
+// exported.
+var ExportColumns = []string{
+	"id",
+	"first_name",
+	"last_name",
+	"nickname",
+	"email",
+	"country",
+	"created_at",
+	"updated_at",
+	"status",
+	"ban_reason",
+	"ban_expires_at",
+	"email_verified_at",
+	"pending_email",
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// ImportColumns are the fields of an imported user, CSV files must have a header row naming each of them
+var ImportColumns = []string{"first_name", "last_name", "nickname", "password", "email", "country"}
+
+// ImportedUser is a user read from one row of an import file
+type ImportedUser struct {
+	// Row is the user's position in the file, the first user is row 1 whether or not there is a header row
+	Row       int    `json:"-"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Nickname  string `json:"nickname"`
+	Password  string `json:"password"`
+	Email     string `json:"email"`
+	Country   string `json:"country"`
+}
+
+// ImportRowResult is the outcome of importing one row, either the ID of the user it created or why it wasn't imported
+type ImportRowResult struct {
+	Row    int
+	UserID *uuid.UUID
+	Error  string
+}
+
+type ImportJobStatus string
+
+const (
+	ImportJobStatusRunning   ImportJobStatus = "RUNNING"
+	ImportJobStatusSucceeded ImportJobStatus = "SUCCEEDED"
+	ImportJobStatusFailed    ImportJobStatus = "FAILED"
+)
+
+// ImportJob is an import running in the background, its row results are stored as each batch is imported
+type ImportJob struct {
+	ID     uuid.UUID
+	Format string
+	DryRun bool
+	Status ImportJobStatus
+	// ImportedRows counts rows that created a user, or that would have in a dry run
+	ImportedRows int
+	FailedRows   int
+	// Error is why the import stopped, for FAILED jobs
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchSimilarityThreshold is the word similarity a value needs to match a search, the same as pg_trgm's default
+// word_similarity_threshold
+const SearchSimilarityThreshold = 0.6
+
+const (
+	SearchFieldNickname  = "nickname"
+	SearchFieldFirstName = "first_name"
+	SearchFieldLastName  = "last_name"
+	SearchFieldEmail     = "email"
+)
+
+// UserSearchResult is a user matching a search along with how well it matched
+type UserSearchResult struct {
+	User User
+	// Score is between 0 and 1, 1 being an exact match
+	Score float64
+	// MatchedFields lists the SearchField columns the search matched
+	MatchedFields []string
+}
+
+// SearchWord is a lower cased run of letters and digits, Start and Length are in runes of the text it came from
+type SearchWord struct {
+	Text   string
+	Start  int
+	Length int
+}
+
+// SearchWords splits text into words the way pg_trgm does, anything other than a letter or digit separates them
+func SearchWords(text string) []SearchWord {
+	words := []SearchWord{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		words = append(words, SearchWord{Text: strings.ToLower(string(runes[i:j])), Start: i, Length: j - i})
+		i = j
+	}
+
+	return words
+}
+
+// WordTrigrams returns the trigrams of a lower case word, the ith one starting at the ith rune of the padded word
+func WordTrigrams(word string) []string {
+	padded := []rune("  " + word + " ")
+	trigrams := make([]string, 0, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		trigrams = append(trigrams, string(padded[i:i+3]))
+	}
+
+	return trigrams
+}
+
+// WordSimilarity returns the share of the query's trigrams that are trigrams of the value, between 0 and 1. It is how
+// pg_trgm's word_similarity scores a query against the part of a value that matches it best, so an encrypted value
+// decrypted by the service is scored the same way Postgres scores a plain one.
+func WordSimilarity(query, value string) float64 {
+	valueTrigrams := map[string]bool{}
+	for _, word := range SearchWords(value) {
+		for _, trigram := range WordTrigrams(word.Text) {
+			valueTrigrams[trigram] = true
+		}
+	}
+
+	queryTrigrams := map[string]bool{}
+	for _, word := range SearchWords(query) {
+		for _, trigram := range WordTrigrams(word.Text) {
+			queryTrigrams[trigram] = true
+		}
+	}
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for trigram := range queryTrigrams {
+		if valueTrigrams[trigram] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(queryTrigrams))
+}
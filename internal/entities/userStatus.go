@@ -0,0 +1,35 @@
+package entities
+
+// UserStatus represents the lifecycle state of a user account
+type UserStatus string
+
+const (
+	UserStatusPendingVerification UserStatus = "pending_verification"
+	UserStatusActive              UserStatus = "active"
+	UserStatusSuspended           UserStatus = "suspended"
+	UserStatusBanned              UserStatus = "banned"
+)
+
+// userStatusTransitions holds the states each status is allowed to move to
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusPendingVerification: {UserStatusActive, UserStatusSuspended, UserStatusBanned},
+	UserStatusActive:              {UserStatusSuspended, UserStatusBanned},
+	UserStatusSuspended:           {UserStatusActive, UserStatusPendingVerification, UserStatusBanned},
+	UserStatusBanned:              {UserStatusActive, UserStatusPendingVerification},
+}
+
+// CanTransitionTo reports whether a user in this status is allowed to be moved to the target status
+func (s UserStatus) CanTransitionTo(target UserStatus) bool {
+	for _, allowed := range userStatusTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanLogIn reports whether a user in this status is allowed to start new sessions
+func (s UserStatus) CanLogIn() bool {
+	return s != UserStatusSuspended && s != UserStatusBanned
+}
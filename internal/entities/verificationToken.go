@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	// TokenPurposeEmailChange confirms a change to the address in the token
+	TokenPurposeEmailChange = "email_change"
+	// TokenPurposeEmailChangeRevert undoes an email change, restoring the address in the token
+	TokenPurposeEmailChangeRevert = "email_change_revert"
+	// TokenPurposeMFAChallenge is handed out after a correct password and exchanged, with an MFA code, for a session
+	TokenPurposeMFAChallenge = "mfa_challenge"
+)
+
+// VerificationToken is a single-use token proving control of an email address. It is handed to the user in signed
+// form and its ID is stored so that it can only be redeemed once.
+type VerificationToken struct {
+	ID        uuid.UUID `json:"jti"`
+	UserID    uuid.UUID `json:"sub"`
+	Email     string    `json:"email"`
+	Purpose   string    `json:"purpose"`
+	ExpiresAt time.Time `json:"exp"`
+}
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthnCredential is a passkey or security key registered to a user
+type WebAuthnCredential struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// CredentialID is the authenticator's identifier for the credential
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	AAGUID          []byte
+	// SignCount is the last signature counter reported by the authenticator. A counter that doesn't increase suggests
+	// the credential has been cloned.
+	SignCount      uint32
+	BackupEligible bool
+	BackupState    bool
+	Name           string
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
+}
+
+// WebAuthnCeremony holds the state of a registration or login between its begin and finish requests. It can only be
+// finished once.
+type WebAuthnCeremony struct {
+	ID uuid.UUID
+	// UserID is nil for logins, as the user is only known once their authenticator responds
+	UserID      *uuid.UUID
+	Purpose     string
+	SessionData []byte
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/apiKeyStore.go  . "APIKeyStore"
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key entities.APIKey) error
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	// AuthenticateAPIKey returns the unrevoked, unexpired key with the secret hash and records that it was used
+	AuthenticateAPIKey(ctx context.Context, secretHash string, now time.Time) (*entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, now time.Time) error
+}
+
+const (
+	apiKeyAuthorizationScheme = "ApiKey"
+	apiKeyPrefix              = "uk_"
+	apiKeyContextKey          = "apiKey"
+)
+
+// APIKeyAuthenticator checks the API keys other services call the API with
+type APIKeyAuthenticator struct {
+	// store is nil for storage backends that can't store API keys, only the bootstrap key is accepted then
+	store APIKeyStore
+	// bootstrapKey is a users:admin key from config, used to mint the first keys, ignored if empty
+	bootstrapKey string
+	// required is false to let requests without a key through, for deployments that check callers elsewhere
+	required bool
+}
+
+func NewAPIKeyAuthenticator(store APIKeyStore, bootstrapKey string, required bool) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		store:        store,
+		bootstrapKey: bootstrapKey,
+		required:     required,
+	}
+}
+
+// Authenticate returns the key for the raw secret, or entities.ErrAPIKeyNotFound if it isn't a usable key
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, secret string) (*entities.APIKey, error) {
+	if a.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(a.bootstrapKey)) == 1 {
+		return &entities.APIKey{
+			Name:   "bootstrap",
+			Owner:  "config",
+			Scopes: []string{entities.APIKeyScopeUsersAdmin},
+		}, nil
+	}
+
+	if a.store == nil || !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, entities.ErrAPIKeyNotFound
+	}
+
+	key, err := a.store.AuthenticateAPIKey(ctx, hashOpaqueToken(secret), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("authenticating api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// generateAPIKey returns a new key's secret and the hash it is stored as
+func generateAPIKey() (string, string, error) {
+	token, _, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	secret := apiKeyPrefix + token
+	return secret, hashOpaqueToken(secret), nil
+}
+
+// NewRequireAPIKeyScope is middleware that rejects requests without an `Authorization: ApiKey <key>` header for a key
+// granted the scope. The key is kept on the context for the handlers that follow.
+func NewRequireAPIKeyScope(authenticator *APIKeyAuthenticator, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret, found := apiKeyFromHeader(c.GetHeader("Authorization"))
+		if !found {
+			if !authenticator.required {
+				c.Next()
+				return
+			}
+
+			slog.Warn("api key missing", "path", c.FullPath())
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		key, err := authenticator.Authenticate(c.Request.Context(), secret)
+		if err != nil {
+			if errors.Is(err, entities.ErrAPIKeyNotFound) {
+				slog.Warn("invalid api key", "path", c.FullPath())
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("authenticating api key", "err", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		if !key.HasScope(scope) {
+			slog.Warn("api key missing scope", "apiKeyID", key.ID, "scope", scope, "path", c.FullPath())
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+func apiKeyFromHeader(header string) (string, bool) {
+	scheme, secret, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, apiKeyAuthorizationScheme) {
+		return "", false
+	}
+
+	secret = strings.TrimSpace(secret)
+	return secret, secret != ""
+}
@@ -0,0 +1,220 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/auditLog.go  . "AuditLog"
+type AuditLog interface {
+	// AppendAuditEntry chains the entry on to the last one and stores it. Appends must be serialised, two entries
+	// chained on to the same one would fork the chain.
+	AppendAuditEntry(ctx context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error)
+	// GetAuditEntries returns entries matching the filter in sequence order
+	GetAuditEntries(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error)
+}
+
+const (
+	auditActorAnonymous = "anonymous"
+	// auditTargetsContextKey holds the users a batch handler took the action against, as there is no userId param
+	auditTargetsContextKey = "auditTargets"
+)
+
+const (
+	// auditAppendAttempts is how many times an entry is tried before the action's request fails
+	auditAppendAttempts   = 3
+	auditAppendRetryDelay = 100 * time.Millisecond
+	// auditAppendTimeout bounds each attempt, as the request's own context isn't used
+	auditAppendTimeout = 5 * time.Second
+)
+
+// NewAuditAction is middleware that records the action in the audit log once the handler has succeeded. The response
+// is held back until the entry has been recorded, so nothing the action returns reaches the client without being
+// audited, and the request fails if it can't be. The actor is the API key the request was authenticated with, so it
+// must come after NewRequireAPIKeyScope. Handlers acting on many users at once list them with setAuditTargets before
+// responding and an entry is recorded for each.
+func NewAuditAction(auditLog AuditLog, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &auditedResponseWriter{ResponseWriter: c.Writer}
+		writer.audit = func() error {
+			return recordAuditAction(c, auditLog, action)
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		// handlers that only set a status haven't written anything yet
+		writer.commit()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// auditedResponseWriter records the audit entry for a response before any of it is written. If the entry can't be
+// recorded the response is replaced with a 500 Internal Server Error and what the handler writes is dropped.
+type auditedResponseWriter struct {
+	gin.ResponseWriter
+	audit     func() error
+	committed bool
+	failed    bool
+}
+
+func (w *auditedResponseWriter) commit() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+
+	if w.Status() >= 300 {
+		return
+	}
+
+	err := w.audit()
+	if err != nil {
+		slog.Error("recording audit entry, failing the request", "err", err)
+		w.failed = true
+		for header := range w.Header() {
+			w.Header().Del(header)
+		}
+		w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (w *auditedResponseWriter) Write(data []byte) (int, error) {
+	w.commit()
+	if w.failed {
+		return len(data), nil
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditedResponseWriter) WriteString(data string) (int, error) {
+	w.commit()
+	if w.failed {
+		return len(data), nil
+	}
+
+	return w.ResponseWriter.WriteString(data)
+}
+
+func (w *auditedResponseWriter) WriteHeaderNow() {
+	w.commit()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *auditedResponseWriter) Flush() {
+	w.commit()
+	w.ResponseWriter.Flush()
+}
+
+// recordAuditAction records an entry for the action the request took, one for each target set with setAuditTargets
+func recordAuditAction(c *gin.Context, auditLog AuditLog, action string) error {
+	entry := entities.AuditEntry{
+		Actor:     auditActorAnonymous,
+		Action:    action,
+		IPAddress: c.ClientIP(),
+		// postgres stores microseconds, the hash has to be of what is stored
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if key, found := c.Get(apiKeyContextKey); found {
+		apiKey := key.(*entities.APIKey)
+		entry.Actor = apiKey.Name
+		if apiKey.ID != uuid.Nil {
+			entry.ActorAPIKeyID = &apiKey.ID
+		}
+	}
+
+	details := map[string]string{}
+	for _, param := range c.Params {
+		if param.Key == "userId" {
+			userIDUUID, err := uuid.Parse(param.Value)
+			if err == nil {
+				entry.TargetUserID = &userIDUUID
+				continue
+			}
+		}
+		details[param.Key] = param.Value
+	}
+	if len(details) > 0 {
+		entry.Details = details
+	}
+
+	targets, found := c.Get(auditTargetsContextKey)
+	if !found {
+		return appendAuditEntry(c.Request.Context(), auditLog, entry)
+	}
+
+	userIDs := targets.([]uuid.UUID)
+	for i := range userIDs {
+		entry.TargetUserID = &userIDs[i]
+		err := appendAuditEntry(c.Request.Context(), auditLog, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendAuditEntry records the entry, trying auditAppendAttempts times before giving up. It ignores ctx being
+// cancelled, so an action that has been taken is still audited if the client goes away. auditLog is nil for storage
+// backends that don't keep an audit log, nothing is recorded then.
+func appendAuditEntry(ctx context.Context, auditLog AuditLog, entry entities.AuditEntry) error {
+	if auditLog == nil {
+		return nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	for attempt := 1; attempt <= auditAppendAttempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, auditAppendTimeout)
+		_, err = auditLog.AppendAuditEntry(attemptCtx, entry)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("appending audit entry", "err", err, "action", entry.Action, "actor", entry.Actor, "attempt", attempt)
+		if attempt < auditAppendAttempts {
+			time.Sleep(auditAppendRetryDelay * time.Duration(attempt))
+		}
+	}
+
+	return fmt.Errorf("appending audit entry: %w", err)
+}
+
+// VerifyAuditLog walks the whole audit log checking the hash chain, pageSize entries at a time. It returns the last
+// entry, which should be recorded somewhere the log can't be changed from as removing entries from the end of the log
+// can't be detected from the log alone, or an *entities.AuditChainError for the first entry that doesn't verify.
+func VerifyAuditLog(ctx context.Context, auditLog AuditLog, pageSize int) (*entities.AuditEntry, error) {
+	var previous *entities.AuditEntry
+	for {
+		filter := entities.AuditFilter{Limit: pageSize}
+		if previous != nil {
+			filter.AfterSequence = previous.Sequence
+		}
+
+		entries, err := auditLog.GetAuditEntries(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("getting audit entries: %w", err)
+		}
+
+		for i := range entries {
+			err = entries[i].VerifyFollows(previous)
+			if err != nil {
+				return nil, err
+			}
+			previous = &entries[i]
+		}
+
+		if len(entries) < pageSize {
+			return previous, nil
+		}
+	}
+}
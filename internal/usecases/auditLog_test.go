@@ -0,0 +1,260 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Auditing an action", func() {
+	var w *httptest.ResponseRecorder
+	var auditLog *mock_usecases.MockAuditLog
+	var apiKey *entities.APIKey
+	var userID uuid.UUID
+	var handlerStatus int
+	var requestCtx context.Context
+	var appendErrs []error
+	var appendCtxErrs []error
+	var appendedEntries []entities.AuditEntry
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		auditLog = mock_usecases.NewMockAuditLog(ctrl)
+		apiKey = &entities.APIKey{
+			ID:     uuid.New(),
+			Name:   "support-tooling",
+			Scopes: []string{entities.APIKeyScopeUsersAdmin},
+		}
+		userID = uuid.New()
+		handlerStatus = http.StatusOK
+		requestCtx = context.Background()
+		appendErrs = nil
+		appendCtxErrs = nil
+		appendedEntries = nil
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		auditLog.EXPECT().AppendAuditEntry(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.AuditEntry{})).
+			DoAndReturn(func(ctx context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+				appendCtxErrs = append(appendCtxErrs, ctx.Err())
+				if len(appendErrs) > 0 {
+					err := appendErrs[0]
+					appendErrs = appendErrs[1:]
+					return nil, err
+				}
+
+				appendedEntries = append(appendedEntries, entry)
+				return &entry, nil
+			}).AnyTimes()
+		apiKeyStore := mock_usecases.NewMockAPIKeyStore(gomock.NewController(GinkgoT()))
+		apiKeyStore.EXPECT().AuthenticateAPIKey(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any()).
+			Return(apiKey, nil).AnyTimes()
+
+		engine := gin.New()
+		authenticator := usecases.NewAPIKeyAuthenticator(apiKeyStore, "", true)
+		engine.POST(
+			"/admin/user/:userId/ban",
+			usecases.NewRequireAPIKeyScope(authenticator, entities.APIKeyScopeUsersAdmin),
+			usecases.NewAuditAction(auditLog, entities.AuditActionUserBanned),
+			func(c *gin.Context) {
+				c.JSON(handlerStatus, gin.H{"email": "alec@email.com"})
+			},
+		)
+
+		req, err := http.NewRequestWithContext(requestCtx, "POST", "http://localhost:8080/admin/user/"+userID.String()+"/ban", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "ApiKey uk_support-secret")
+		req.RemoteAddr = "198.51.100.9:41234"
+		engine.ServeHTTP(w, req)
+	})
+
+	It("should record who took the action on which user", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(appendedEntries).To(HaveLen(1))
+		Expect(appendedEntries[0].Action).To(Equal(entities.AuditActionUserBanned))
+		Expect(appendedEntries[0].Actor).To(Equal("support-tooling"))
+		Expect(appendedEntries[0].ActorAPIKeyID).To(Equal(&apiKey.ID))
+		Expect(appendedEntries[0].TargetUserID).To(Equal(&userID))
+		Expect(appendedEntries[0].IPAddress).To(Equal("198.51.100.9"))
+		Expect(w.Body.String()).To(ContainSubstring("alec@email.com"))
+	})
+
+	When("appending the entry fails at first", func() {
+		BeforeEach(func() {
+			appendErrs = []error{errors.New("an error occurred")}
+		})
+
+		It("should retry it before responding", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(appendedEntries).To(HaveLen(1))
+		})
+	})
+
+	When("appending the entry keeps failing", func() {
+		BeforeEach(func() {
+			appendErrs = []error{errors.New("an error occurred"), errors.New("an error occurred"), errors.New("an error occurred")}
+		})
+
+		It("should fail the request without returning what the action did", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+			Expect(w.Body.String()).ToNot(ContainSubstring("alec@email.com"))
+			Expect(appendedEntries).To(BeEmpty())
+		})
+	})
+
+	When("the client has gone away", func() {
+		BeforeEach(func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			requestCtx = ctx
+		})
+
+		It("should still record the entry", func() {
+			Expect(appendedEntries).To(HaveLen(1))
+			Expect(appendCtxErrs).To(ConsistOf(BeNil()))
+		})
+	})
+
+	When("the action fails", func() {
+		BeforeEach(func() {
+			handlerStatus = http.StatusBadRequest
+		})
+
+		It("should not record it", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(appendedEntries).To(BeEmpty())
+		})
+	})
+
+	When("the API key doesn't have the scope", func() {
+		BeforeEach(func() {
+			apiKey.Scopes = []string{entities.APIKeyScopeUsersRead}
+		})
+
+		It("should not record it", func() {
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+			Expect(appendedEntries).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Verifying the audit log", func() {
+	var auditLog *mock_usecases.MockAuditLog
+	var entries []entities.AuditEntry
+	var getEntriesErr error
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		auditLog = mock_usecases.NewMockAuditLog(ctrl)
+
+		entries = nil
+		var previous *entities.AuditEntry
+		for i := 0; i < 5; i++ {
+			userID := uuid.New()
+			entry := entities.AuditEntry{
+				Actor:        "support-tooling",
+				Action:       entities.AuditActionUserBanned,
+				TargetUserID: &userID,
+				IPAddress:    "198.51.100.9",
+				CreatedAt:    time.Date(2024, 7, 2, 9, 0, i, 0, time.UTC),
+			}.Chain(previous)
+			entries = append(entries, entry)
+			previous = &entries[len(entries)-1]
+		}
+		getEntriesErr = nil
+	})
+
+	// pages through entries two at a time, as the store would
+	expectPages := func() {
+		auditLog.EXPECT().GetAuditEntries(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.AuditFilter{})).
+			DoAndReturn(func(_ context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+				page := []entities.AuditEntry{}
+				for _, entry := range entries {
+					if entry.Sequence > filter.AfterSequence && len(page) < filter.Limit {
+						page = append(page, entry)
+					}
+				}
+				return page, getEntriesErr
+			}).AnyTimes()
+	}
+
+	It("should return the last entry when the chain is intact", func() {
+		expectPages()
+
+		last, err := usecases.VerifyAuditLog(context.Background(), auditLog, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(last.Sequence).To(Equal(int64(5)))
+		Expect(last.Hash).To(Equal(entries[4].Hash))
+	})
+
+	When("an entry has been changed", func() {
+		BeforeEach(func() {
+			entries[2].Action = entities.AuditActionUserUnbanned
+		})
+
+		It("should return an error for that entry", func() {
+			expectPages()
+
+			_, err := usecases.VerifyAuditLog(context.Background(), auditLog, 2)
+			var chainErr *entities.AuditChainError
+			Expect(errors.As(err, &chainErr)).To(BeTrue())
+			Expect(chainErr.Sequence).To(Equal(int64(3)))
+		})
+	})
+
+	When("an entry has been changed and its hash recomputed", func() {
+		BeforeEach(func() {
+			entries[2].Action = entities.AuditActionUserUnbanned
+			entries[2].Hash = entries[2].ComputeHash()
+		})
+
+		It("should return an error for the entry after it", func() {
+			expectPages()
+
+			_, err := usecases.VerifyAuditLog(context.Background(), auditLog, 2)
+			var chainErr *entities.AuditChainError
+			Expect(errors.As(err, &chainErr)).To(BeTrue())
+			Expect(chainErr.Sequence).To(Equal(int64(4)))
+		})
+	})
+
+	When("an entry has been removed", func() {
+		BeforeEach(func() {
+			entries = append(entries[:1], entries[2:]...)
+		})
+
+		It("should return an error for the entry after it", func() {
+			expectPages()
+
+			_, err := usecases.VerifyAuditLog(context.Background(), auditLog, 2)
+			var chainErr *entities.AuditChainError
+			Expect(errors.As(err, &chainErr)).To(BeTrue())
+			Expect(chainErr.Sequence).To(Equal(int64(3)))
+		})
+	})
+
+	When("the log can't be read", func() {
+		BeforeEach(func() {
+			getEntriesErr = errors.New("an error occurred")
+		})
+
+		It("should return the error", func() {
+			expectPages()
+
+			_, err := usecases.VerifyAuditLog(context.Background(), auditLog, 2)
+			Expect(err).To(MatchError(ContainSubstring("an error occurred")))
+		})
+	})
+})
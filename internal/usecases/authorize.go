@@ -0,0 +1,148 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// ConsentRequiredResponseBody represents the response from the authorize endpoint when the user must consent first
+// @Description The client and scopes to ask the user to consent to
+type ConsentRequiredResponseBody struct {
+	// ConsentRequired is always true, the user's decision is submitted to /authorize/consent
+	ConsentRequired bool `json:"consent_required"`
+	// ClientID represents the client asking for access
+	ClientID string `json:"client_id"`
+	// ClientName represents the name of the client to show the user
+	ClientName string `json:"client_name"`
+	// Scopes represents the scopes the client is asking for
+	Scopes []string `json:"scopes"`
+}
+
+// NewAuthorize is the OpenID Connect authorize endpoint
+// @Summary OpenID Connect authorize
+// @Description Starts the authorization code flow for a user signed in with a bearer access token. Redirects to the
+// @Description client with a code, or with an error. Returns the consent to ask for if the user hasn't consented yet.
+// @Tags oidc
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "A redirect URI registered for the client"
+// @Param scope query string true "Space separated scopes, including openid"
+// @Param state query string false "Returned to the client unchanged"
+// @Param nonce query string false "Copied into the ID token"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Param prompt query string false "none to fail rather than ask for consent"
+// @Success 200 {object} ConsentRequiredResponseBody
+// @Success 302
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /authorize [get]
+func NewAuthorize(oidcProvider *OIDCProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, client, scopes, ok := bindAuthorizationRequest(c, oidcProvider)
+		if !ok {
+			return
+		}
+
+		user, accessToken, ok := authenticateAuthorizationRequest(c, oidcProvider, request)
+		if !ok {
+			return
+		}
+
+		redirectURI, consentRequired, err := oidcProvider.Authorize(c.Request.Context(), *user, *accessToken, *client, *request, scopes)
+		if err != nil {
+			var oauthErr *entities.OAuthError
+			if errors.As(err, &oauthErr) {
+				slog.Warn("authorization request refused", "err", err, "clientID", client.ID, "userID", user.ID)
+				c.Redirect(http.StatusFound, authorizationErrorRedirect(request.RedirectURI, oauthErr, request.State))
+				return
+			}
+
+			slog.Error("authorizing client", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if consentRequired {
+			c.JSON(http.StatusOK, ConsentRequiredResponseBody{
+				ConsentRequired: true,
+				ClientID:        client.ID,
+				ClientName:      client.Name,
+				Scopes:          scopes,
+			})
+			return
+		}
+
+		c.Redirect(http.StatusFound, redirectURI)
+	}
+}
+
+// bindAuthorizationRequest reads and validates the authorization request, writing the response itself if it's invalid
+func bindAuthorizationRequest(c *gin.Context, oidcProvider *OIDCProvider) (*AuthorizationRequest, *entities.OIDCClient, []string, bool) {
+	var request AuthorizationRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		slog.Warn("unable to bind request", "err", err)
+		c.Status(http.StatusBadRequest)
+		return nil, nil, nil, false
+	}
+
+	client, scopes, err := oidcProvider.ValidateAuthorizationRequest(c.Request.Context(), request)
+	if err != nil {
+		// an unknown client or redirect uri can't be trusted with a redirect, so the error is shown here instead
+		if errors.Is(err, entities.ErrOIDCClientNotFound) || errors.Is(err, entities.ErrInvalidRedirectURI) {
+			slog.Warn("invalid authorization request", "err", err, "clientID", request.ClientID)
+			c.JSON(http.StatusBadRequest, OAuthErrorResponseBody{Error: entities.OAuthErrorInvalidRequest, ErrorDescription: err.Error()})
+			return nil, nil, nil, false
+		}
+
+		var oauthErr *entities.OAuthError
+		if errors.As(err, &oauthErr) {
+			slog.Warn("invalid authorization request", "err", err, "clientID", request.ClientID)
+			c.Redirect(http.StatusFound, authorizationErrorRedirect(request.RedirectURI, oauthErr, request.State))
+			return nil, nil, nil, false
+		}
+
+		slog.Error("validating authorization request", "err", err)
+		c.Status(http.StatusInternalServerError)
+		return nil, nil, nil, false
+	}
+
+	return &request, client, scopes, true
+}
+
+// authenticateAuthorizationRequest identifies the signed in user, writing the response itself if they can't be
+func authenticateAuthorizationRequest(
+	c *gin.Context,
+	oidcProvider *OIDCProvider,
+	request *AuthorizationRequest,
+) (*entities.User, *entities.AccessToken, bool) {
+	user, accessToken, err := oidcProvider.Authenticate(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidToken) {
+			slog.Warn("authorization request without a valid access token", "err", err)
+			if request.Prompt == oidcPromptNone {
+				c.Redirect(http.StatusFound, authorizationErrorRedirect(request.RedirectURI, &entities.OAuthError{
+					Code:        entities.OAuthErrorLoginRequired,
+					Description: "the user isn't signed in",
+				}, request.State))
+				return nil, nil, false
+			}
+
+			c.Status(http.StatusUnauthorized)
+			return nil, nil, false
+		}
+
+		slog.Error("authenticating access token", "err", err)
+		c.Status(http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	return user, accessToken, true
+}
@@ -0,0 +1,91 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// ConsentRequestBody represents the request body for answering a consent prompt
+// @Description Whether the user allows the client the scopes it asked for
+type ConsentRequestBody struct {
+	// Approve is true if the user allows the client access
+	Approve bool `json:"approve"`
+}
+
+// NewAuthorizeConsent records the user's answer to a consent prompt and finishes the authorization request
+// @Summary OpenID Connect consent
+// @Description Takes the same query parameters as /authorize. If the user approves, their consent is stored and they are
+// @Description redirected to the client with a code. Otherwise they are redirected with an access_denied error.
+// @Tags oidc
+// @Accept json
+// @Param Authorization header string true "Bearer access token"
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "A redirect URI registered for the client"
+// @Param scope query string true "Space separated scopes, including openid"
+// @Param state query string false "Returned to the client unchanged"
+// @Param nonce query string false "Copied into the ID token"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Param request body ConsentRequestBody true "Consent Request Body"
+// @Success 302
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /authorize/consent [post]
+func NewAuthorizeConsent(oidcProvider *OIDCProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, client, scopes, ok := bindAuthorizationRequest(c, oidcProvider)
+		if !ok {
+			return
+		}
+
+		var consent ConsentRequestBody
+		err := c.ShouldBindJSON(&consent)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, accessToken, ok := authenticateAuthorizationRequest(c, oidcProvider, request)
+		if !ok {
+			return
+		}
+
+		if !consent.Approve {
+			slog.Info("user refused consent", "clientID", client.ID, "userID", user.ID)
+			c.Redirect(http.StatusFound, authorizationErrorRedirect(request.RedirectURI, &entities.OAuthError{
+				Code:        entities.OAuthErrorAccessDenied,
+				Description: "the user refused consent",
+			}, request.State))
+			return
+		}
+
+		err = oidcProvider.GrantConsent(c.Request.Context(), user.ID, client.ID, scopes)
+		if err != nil {
+			slog.Error("granting consent", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		redirectURI, _, err := oidcProvider.Authorize(c.Request.Context(), *user, *accessToken, *client, *request, scopes)
+		if err != nil {
+			var oauthErr *entities.OAuthError
+			if errors.As(err, &oauthErr) {
+				slog.Warn("authorization request refused", "err", err, "clientID", client.ID, "userID", user.ID)
+				c.Redirect(http.StatusFound, authorizationErrorRedirect(request.RedirectURI, oauthErr, request.State))
+				return
+			}
+
+			slog.Error("authorizing client", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Redirect(http.StatusFound, redirectURI)
+	}
+}
@@ -0,0 +1,117 @@
+package usecases_test
+
+import (
+	"bytes"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+)
+
+var _ = Describe("Answering an OpenID Connect consent prompt", func() {
+	var w *httptest.ResponseRecorder
+	var params url.Values
+	var requestBody *usecases.ConsentRequestBody
+
+	var client *entities.OIDCClient
+	var user *entities.User
+	var accessToken *entities.AccessToken
+
+	var grantedScopes []string
+	var grantConsentCallCount int
+	var createCodeCallCount int
+
+	BeforeEach(func() {
+		client = &entities.OIDCClient{
+			ID:            "web",
+			Name:          "FACEIT Web",
+			RedirectURIs:  []string{"https://www.faceit.com/callback"},
+			AllowedScopes: []string{"openid", "profile", "email"},
+		}
+		params = url.Values{
+			"response_type":         {"code"},
+			"client_id":             {"web"},
+			"redirect_uri":          {"https://www.faceit.com/callback"},
+			"scope":                 {"openid email"},
+			"state":                 {"abc"},
+			"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+			"code_challenge_method": {"S256"},
+		}
+		requestBody = &usecases.ConsentRequestBody{Approve: true}
+
+		user = &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		accessToken = &entities.AccessToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			IssuedAt:  time.Now().UTC(),
+			ExpiresAt: time.Now().Add(time.Minute).UTC(),
+		}
+
+		grantedScopes = []string{"openid", "profile"}
+		grantConsentCallCount = 1
+		createCodeCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockOIDCStore.EXPECT().GetOIDCClient(gomock.AssignableToTypeOf(ctxType), "web").Return(client, nil).Times(1)
+		mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(accessToken, nil).Times(1)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, nil).Times(1)
+
+		// consent is read once before it is granted, and again when the code is issued
+		mockOIDCStore.EXPECT().GetOIDCConsent(gomock.AssignableToTypeOf(ctxType), user.ID, "web").
+			Return(grantedScopes, nil).Times(grantConsentCallCount)
+		mockOIDCStore.EXPECT().GetOIDCConsent(gomock.AssignableToTypeOf(ctxType), user.ID, "web").
+			Return([]string{"openid", "profile", "email"}, nil).Times(grantConsentCallCount)
+		mockOIDCStore.EXPECT().GrantOIDCConsent(
+			gomock.AssignableToTypeOf(ctxType),
+			user.ID,
+			"web",
+			[]string{"openid", "profile", "email"},
+			gomock.AssignableToTypeOf(time.Time{}),
+		).Return(nil).Times(grantConsentCallCount)
+		mockOIDCStore.EXPECT().CreateOIDCAuthorizationCode(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.OIDCAuthorizationCode{}), gomock.AssignableToTypeOf("")).
+			Return(nil).Times(createCodeCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/authorize/consent?"+params.Encode(), bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should store the consent alongside what was granted before and redirect to the client with a code", func() {
+		Expect(w.Code).To(Equal(http.StatusFound))
+
+		location, err := url.Parse(w.Header().Get("Location"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(location.Query().Get("code")).ToNot(BeEmpty())
+		Expect(location.Query().Get("state")).To(Equal("abc"))
+	})
+
+	When("the user refuses", func() {
+		BeforeEach(func() {
+			requestBody.Approve = false
+			grantConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should redirect to the client with an access_denied error", func() {
+			Expect(w.Code).To(Equal(http.StatusFound))
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(location.Query().Get("error")).To(Equal(entities.OAuthErrorAccessDenied))
+			Expect(location.Query().Get("code")).To(BeEmpty())
+		})
+	})
+})
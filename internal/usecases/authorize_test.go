@@ -0,0 +1,317 @@
+package usecases_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+)
+
+var _ = Describe("Authorizing an OpenID Connect client", func() {
+	var w *httptest.ResponseRecorder
+	var params url.Values
+	var authorizationHeader string
+
+	var client *entities.OIDCClient
+	var getClientErr error
+
+	var accessToken *entities.AccessToken
+	var parseTokenErr error
+	var parseTokenCallCount int
+
+	var user *entities.User
+	var getUserCallCount int
+
+	var grantedScopes []string
+	var getConsentCallCount int
+
+	var storedCode entities.OIDCAuthorizationCode
+	var createCodeCallCount int
+
+	BeforeEach(func() {
+		client = &entities.OIDCClient{
+			ID:            "forum",
+			Name:          "FACEIT Forum",
+			RedirectURIs:  []string{"https://forum.faceit.com/callback"},
+			AllowedScopes: []string{"openid", "profile", "email"},
+		}
+		getClientErr = nil
+
+		params = url.Values{
+			"response_type":         {"code"},
+			"client_id":             {"forum"},
+			"redirect_uri":          {"https://forum.faceit.com/callback"},
+			"scope":                 {"openid profile offline_access"},
+			"state":                 {"xyz"},
+			"nonce":                 {"n-0S6_WzA2Mj"},
+			"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+			"code_challenge_method": {"S256"},
+		}
+
+		user = &entities.User{
+			ID:       uuid.New(),
+			Nickname: "AlecSmith96",
+			Status:   entities.UserStatusActive,
+		}
+		getUserCallCount = 1
+
+		accessToken = &entities.AccessToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			SessionID: uuid.New(),
+			IssuedAt:  time.Now().Add(-time.Minute).UTC(),
+			ExpiresAt: time.Now().Add(time.Minute).UTC(),
+		}
+		authorizationHeader = "Bearer signed-access-token"
+		parseTokenErr = nil
+		parseTokenCallCount = 1
+
+		grantedScopes = []string{"openid", "profile"}
+		getConsentCallCount = 1
+
+		storedCode = entities.OIDCAuthorizationCode{}
+		createCodeCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockOIDCStore.EXPECT().GetOIDCClient(gomock.AssignableToTypeOf(ctxType), params.Get("client_id")).Return(client, getClientErr).Times(1)
+		mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(accessToken, parseTokenErr).Times(parseTokenCallCount)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, nil).Times(getUserCallCount)
+		mockOIDCStore.EXPECT().GetOIDCConsent(gomock.AssignableToTypeOf(ctxType), user.ID, client.ID).Return(grantedScopes, nil).Times(getConsentCallCount)
+		mockOIDCStore.EXPECT().CreateOIDCAuthorizationCode(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.OIDCAuthorizationCode{}), gomock.AssignableToTypeOf("")).
+			DoAndReturn(func(_ context.Context, code entities.OIDCAuthorizationCode, _ string) error {
+				storedCode = code
+				return nil
+			}).Times(createCodeCallCount)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/authorize?"+params.Encode(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		if authorizationHeader != "" {
+			req.Header.Set("Authorization", authorizationHeader)
+		}
+		r.ServeHTTP(w, req)
+	})
+
+	redirectParams := func() url.Values {
+		location, err := url.Parse(w.Header().Get("Location"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(location.Scheme + "://" + location.Host + location.Path).To(Equal("https://forum.faceit.com/callback"))
+		return location.Query()
+	}
+
+	It("should redirect to the client with a code", func() {
+		Expect(w.Code).To(Equal(http.StatusFound))
+
+		query := redirectParams()
+		Expect(query.Get("code")).ToNot(BeEmpty())
+		Expect(query.Get("state")).To(Equal("xyz"))
+
+		Expect(storedCode.ClientID).To(Equal("forum"))
+		Expect(storedCode.UserID).To(Equal(user.ID))
+		Expect(storedCode.RedirectURI).To(Equal("https://forum.faceit.com/callback"))
+		Expect(storedCode.Scopes).To(Equal([]string{"openid", "profile"}))
+		Expect(storedCode.Nonce).To(Equal("n-0S6_WzA2Mj"))
+		Expect(storedCode.CodeChallenge).To(Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"))
+		Expect(storedCode.AuthTime).To(Equal(accessToken.IssuedAt))
+		Expect(storedCode.ExpiresAt).To(BeTemporally("~", time.Now().Add(time.Minute), 5*time.Second))
+	})
+
+	When("the user hasn't consented to every requested scope", func() {
+		BeforeEach(func() {
+			grantedScopes = []string{"openid"}
+			createCodeCallCount = 0
+		})
+
+		It("should return the consent to ask for", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			var response usecases.ConsentRequiredResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal(usecases.ConsentRequiredResponseBody{
+				ConsentRequired: true,
+				ClientID:        "forum",
+				ClientName:      "FACEIT Forum",
+				Scopes:          []string{"openid", "profile"},
+			}))
+		})
+
+		When("the client asked not to prompt the user", func() {
+			BeforeEach(func() {
+				params.Set("prompt", "none")
+			})
+
+			It("should redirect to the client with a consent_required error", func() {
+				Expect(w.Code).To(Equal(http.StatusFound))
+				Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorConsentRequired))
+				Expect(redirectParams().Get("state")).To(Equal("xyz"))
+			})
+		})
+	})
+
+	When("the client is unknown", func() {
+		BeforeEach(func() {
+			getClientErr = entities.ErrOIDCClientNotFound
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should return a 400 Bad Request without redirecting", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Header().Get("Location")).To(BeEmpty())
+		})
+	})
+
+	When("the redirect URI isn't registered for the client", func() {
+		BeforeEach(func() {
+			params.Set("redirect_uri", "https://attacker.example.com/callback")
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should return a 400 Bad Request without redirecting", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Header().Get("Location")).To(BeEmpty())
+		})
+	})
+
+	When("there is no PKCE code challenge", func() {
+		BeforeEach(func() {
+			params.Del("code_challenge")
+			params.Del("code_challenge_method")
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should redirect to the client with an invalid_request error", func() {
+			Expect(w.Code).To(Equal(http.StatusFound))
+			Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorInvalidRequest))
+		})
+	})
+
+	When("the PKCE code challenge uses the plain method", func() {
+		BeforeEach(func() {
+			params.Set("code_challenge_method", "plain")
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should redirect to the client with an invalid_request error", func() {
+			Expect(w.Code).To(Equal(http.StatusFound))
+			Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorInvalidRequest))
+		})
+	})
+
+	When("the openid scope isn't requested", func() {
+		BeforeEach(func() {
+			params.Set("scope", "profile")
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should redirect to the client with an invalid_scope error", func() {
+			Expect(w.Code).To(Equal(http.StatusFound))
+			Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorInvalidScope))
+		})
+	})
+
+	When("the client isn't allowed a requested scope", func() {
+		BeforeEach(func() {
+			client.AllowedScopes = []string{"openid", "profile"}
+			params.Set("scope", "openid email")
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should redirect to the client with an invalid_scope error", func() {
+			Expect(w.Code).To(Equal(http.StatusFound))
+			Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorInvalidScope))
+		})
+	})
+
+	When("the user isn't signed in", func() {
+		BeforeEach(func() {
+			authorizationHeader = ""
+			parseTokenCallCount = 0
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		When("the client asked not to prompt the user", func() {
+			BeforeEach(func() {
+				params.Set("prompt", "none")
+			})
+
+			It("should redirect to the client with a login_required error", func() {
+				Expect(w.Code).To(Equal(http.StatusFound))
+				Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorLoginRequired))
+			})
+		})
+	})
+
+	When("the access token has expired", func() {
+		BeforeEach(func() {
+			accessToken.ExpiresAt = time.Now().Add(-time.Second)
+			getUserCallCount = 0
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the access token was issued before the password was changed", func() {
+		BeforeEach(func() {
+			passwordChangedAt := time.Now().UTC()
+			user.PasswordChangedAt = &passwordChangedAt
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the user has been suspended", func() {
+		BeforeEach(func() {
+			user.Status = entities.UserStatusSuspended
+			getConsentCallCount = 0
+			createCodeCallCount = 0
+		})
+
+		It("should redirect to the client with an access_denied error", func() {
+			Expect(w.Code).To(Equal(http.StatusFound))
+			Expect(redirectParams().Get("error")).To(Equal(entities.OAuthErrorAccessDenied))
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// BanUserRequestBody represents the request body for banning a user
+// @Description Optional reason and expiry for a ban, a ban with no expiry is permanent
+type BanUserRequestBody struct {
+	// Reason represents why the user was banned
+	Reason string `json:"reason"`
+	// ExpiresAt represents the timestamp when the ban is automatically lifted
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// NewBanUser bans a user
+// @Summary Ban user
+// @Description Bans a user account, either permanently or until the provided expiry
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param ban body BanUserRequestBody false "Ban User Request Body"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 409
+// @Failure 500
+// @Router /admin/user/{userId}/ban [post]
+func NewBanUser(userGetter UserGetter, userStatusUpdater UserStatusUpdater, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request BanUserRequestBody
+		if c.Request.ContentLength != 0 {
+			err := c.ShouldBindJSON(&request)
+			if err != nil {
+				slog.Warn("unable to bind request", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+		}
+
+		if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+			slog.Warn("ban expiry is in the past", "expiresAt", request.ExpiresAt)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		transitionUserStatus(c, userGetter, userStatusUpdater, changelogWriter, statusTransition{
+			to:           entities.UserStatusBanned,
+			changeType:   entities.ChangeTypeBanned,
+			banReason:    request.Reason,
+			banExpiresAt: request.ExpiresAt,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package usecases
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// BatchDeleteUsersRequestBody represents the request body for deleting many users
+// @Description The users to delete and how to apply the batch
+type BatchDeleteUsersRequestBody struct {
+	// Mode represents how the batch is applied, all_or_nothing by default or best_effort
+	Mode string `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+	// UserIDs represents the IDs of the users to delete, there can be at most BATCH_MAX_USERS of them
+	UserIDs []string `json:"user_ids" binding:"required,min=1,dive,uuid"`
+}
+
+// NewBatchDeleteUsers deletes many users at once
+// @Summary Delete users
+// @Description Deletes up to BATCH_MAX_USERS users in one transaction. In all_or_nothing mode, the default, nothing is
+// @Description deleted if any user doesn't exist and the response is a 400 listing them. In best_effort mode every user
+// @Description that exists is deleted and the rest are reported as failed. IDs given more than once are only reported
+// @Description once, at their first position.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body BatchDeleteUsersRequestBody true "Batch Delete Users Request Body"
+// @Success 200 {object} BatchWriteResponseBody
+// @Failure 400 {object} BatchWriteResponseBody
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /users:batchDelete [post]
+func NewBatchDeleteUsers(userBatchWriter UserBatchWriter, changelogWriter ChangelogWriter, maxUsers int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request BatchDeleteUsersRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userIDs, ok := parseBatchUserIDs(request.UserIDs, maxUsers)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		mode := batchMode(request.Mode)
+		results, err := userBatchWriter.DeleteUsers(c.Request.Context(), userIDs, mode)
+		if err != nil {
+			slog.Error("deleting users", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		publishBatchChangelog(changelogWriter, "DELETE", results)
+		setAuditTargets(c, results)
+
+		c.JSON(newBatchWriteResponse(mode, results))
+	}
+}
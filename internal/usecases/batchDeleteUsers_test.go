@@ -0,0 +1,183 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Deleting users in a batch", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody usecases.BatchDeleteUsersRequestBody
+	var userIDs []uuid.UUID
+	var expectedMode entities.BatchMode
+	var results []entities.BatchItemResult
+	var deleteUsersErr error
+	var deleteUsersCallCount int
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		userIDs = []uuid.UUID{uuid.New(), uuid.New()}
+		requestBody = usecases.BatchDeleteUsersRequestBody{
+			UserIDs: []string{userIDs[0].String(), userIDs[1].String(), userIDs[0].String()},
+		}
+		expectedMode = entities.BatchModeAllOrNothing
+		results = []entities.BatchItemResult{
+			{UserID: userIDs[0], Status: entities.BatchItemStatusSucceeded},
+			{UserID: userIDs[1], Status: entities.BatchItemStatusSucceeded},
+		}
+		deleteUsersErr = nil
+		deleteUsersCallCount = 1
+		changelogWriterCallCount = 2
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserBatchWriter.EXPECT().DeleteUsers(gomock.AssignableToTypeOf(ctxType), userIDs, expectedMode).
+			Return(results, deleteUsersErr).Times(deleteUsersCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+			return x.(entities.ChangelogEntry).ChangeType == "DELETE"
+		})).Return(nil).Times(changelogWriterCallCount)
+
+		body, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest("POST", "http://localhost:8080/users:batchDelete", bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should delete each user once and publish a changelog entry for each", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var response usecases.BatchWriteResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Succeeded).To(Equal(2))
+		Expect(response.Results).To(Equal([]usecases.BatchItemResultResponse{
+			{UserID: userIDs[0].String(), Status: "SUCCEEDED"},
+			{UserID: userIDs[1].String(), Status: "SUCCEEDED"},
+		}))
+	})
+
+	When("a user doesn't exist in an all or nothing batch", func() {
+		BeforeEach(func() {
+			results = []entities.BatchItemResult{
+				{UserID: userIDs[0], Status: entities.BatchItemStatusSkipped},
+				{UserID: userIDs[1], Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+			}
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response saying why", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			var response usecases.BatchWriteResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Skipped).To(Equal(1))
+			Expect(response.Failed).To(Equal(1))
+		})
+	})
+
+	When("a user doesn't exist in a best effort batch", func() {
+		BeforeEach(func() {
+			requestBody.Mode = "best_effort"
+			expectedMode = entities.BatchModeBestEffort
+			results = []entities.BatchItemResult{
+				{UserID: userIDs[0], Status: entities.BatchItemStatusSucceeded},
+				{UserID: userIDs[1], Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+			}
+			changelogWriterCallCount = 1
+		})
+
+		It("should return a 200 response and only publish a changelog entry for the deleted user", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var response usecases.BatchWriteResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Mode).To(Equal("best_effort"))
+			Expect(response.Succeeded).To(Equal(1))
+			Expect(response.Failed).To(Equal(1))
+		})
+	})
+
+	When("there are more users than the batch limit", func() {
+		BeforeEach(func() {
+			requestBody.UserIDs = []string{uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()}
+			deleteUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userBatchWriter adapter returns an error", func() {
+		BeforeEach(func() {
+			deleteUsersErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
+
+var _ = Describe("Auditing a batch delete", func() {
+	It("should record an erasure for each deleted user", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		auditLog := mock_usecases.NewMockAuditLog(ctrl)
+		userBatchWriter := mock_usecases.NewMockUserBatchWriter(ctrl)
+		changelogWriter := mock_usecases.NewMockChangelogWriter(ctrl)
+
+		userIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+		userBatchWriter.EXPECT().DeleteUsers(gomock.AssignableToTypeOf(ctxType), userIDs, entities.BatchModeBestEffort).
+			Return([]entities.BatchItemResult{
+				{UserID: userIDs[0], Status: entities.BatchItemStatusSucceeded},
+				{UserID: userIDs[1], Status: entities.BatchItemStatusFailed, Error: entities.ErrUserNotFound.Error()},
+				{UserID: userIDs[2], Status: entities.BatchItemStatusSucceeded},
+			}, nil).Times(1)
+		changelogWriter.EXPECT().PublishChangelogEntry(gomock.Any()).Return(nil).Times(2)
+		appendedEntries := []entities.AuditEntry{}
+		auditLog.EXPECT().AppendAuditEntry(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+			DoAndReturn(func(_ context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+				appendedEntries = append(appendedEntries, entry)
+				return &entry, nil
+			}).Times(2)
+
+		engine := gin.New()
+		engine.POST(
+			"/batchDelete",
+			usecases.NewAuditAction(auditLog, entities.AuditActionUserErased),
+			usecases.NewBatchDeleteUsers(userBatchWriter, changelogWriter, 3),
+		)
+
+		body, err := json.Marshal(usecases.BatchDeleteUsersRequestBody{
+			Mode:    "best_effort",
+			UserIDs: []string{userIDs[0].String(), userIDs[1].String(), userIDs[2].String()},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest("POST", "http://localhost:8080/batchDelete", bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(appendedEntries).To(HaveLen(2))
+		Expect(appendedEntries[0].Action).To(Equal(entities.AuditActionUserErased))
+		Expect(appendedEntries[0].TargetUserID).To(Equal(&userIDs[0]))
+		Expect(appendedEntries[1].TargetUserID).To(Equal(&userIDs[2]))
+	})
+})
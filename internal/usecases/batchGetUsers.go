@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// BatchGetUsersRequestBody represents the request body for getting users by ID
+// @Description The IDs of the users to get
+type BatchGetUsersRequestBody struct {
+	// UserIDs represents the IDs of the users to get, there can be at most BATCH_MAX_USERS of them
+	UserIDs []string `json:"user_ids" binding:"required,min=1,dive,uuid"`
+}
+
+// BatchGetUsersResponseBody represents the response body for getting users by ID
+// @Description The users that were found and the IDs that weren't
+type BatchGetUsersResponseBody struct {
+	// Users represents the users that were found, in the order their IDs were given
+	Users []PublicUserResponse `json:"users"`
+	// MissingUserIDs represents the IDs that no user has, in the order they were given
+	MissingUserIDs []string `json:"missing_user_ids"`
+}
+
+// NewBatchGetUsers gets many users by ID at once
+// @Summary Get users by ID
+// @Description Gets up to BATCH_MAX_USERS users by ID in one request. Found users are returned in the order their IDs
+// @Description were given and IDs that no user has are listed separately. IDs given more than once are only returned
+// @Description once, at their first position. Passwords are never returned.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body BatchGetUsersRequestBody true "Batch Get Users Request Body"
+// @Success 200 {object} BatchGetUsersResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /users:batchGet [post]
+func NewBatchGetUsers(userGetter UserGetter, maxUsers int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request BatchGetUsersRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userIDs, ok := parseBatchUserIDs(request.UserIDs, maxUsers)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		users, err := userGetter.GetUsersByIDs(c.Request.Context(), userIDs)
+		if err != nil {
+			slog.Error("getting users by ids", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		usersByID := make(map[uuid.UUID]PublicUserResponse, len(users))
+		for _, user := range users {
+			usersByID[user.ID] = newPublicUserResponse(user)
+		}
+
+		response := BatchGetUsersResponseBody{
+			Users:          []PublicUserResponse{},
+			MissingUserIDs: []string{},
+		}
+		for _, userID := range userIDs {
+			user, found := usersByID[userID]
+			if !found {
+				response.MissingUserIDs = append(response.MissingUserIDs, userID.String())
+				continue
+			}
+			response.Users = append(response.Users, user)
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// parseBatchUserIDs parses the IDs of a batch request, dropping repeats but keeping the order they were first given in.
+// Repeats count towards maxUsers, so a request can't be made arbitrarily large by repeating IDs.
+func parseBatchUserIDs(ids []string, maxUsers int) ([]uuid.UUID, bool) {
+	if len(ids) > maxUsers {
+		slog.Warn("too many users in batch", "count", len(ids), "max", maxUsers)
+		return nil, false
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			slog.Error("invalid userID", "userID", id, "err", err)
+			return nil, false
+		}
+
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, true
+}
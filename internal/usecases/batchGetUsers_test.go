@@ -0,0 +1,162 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Getting users by ID", func() {
+	var w *httptest.ResponseRecorder
+	var path string
+	var requestBody usecases.BatchGetUsersRequestBody
+	var users []entities.User
+	var missingUserID uuid.UUID
+	var expectedUserIDs []uuid.UUID
+	var getUsersErr error
+	var getUsersCallCount int
+
+	BeforeEach(func() {
+		path = "/users:batchGet"
+		users = []entities.User{
+			{ID: uuid.New(), FirstName: "alec", Password: "some-password", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+			{ID: uuid.New(), FirstName: "jane", Password: "other-password", CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
+		}
+		missingUserID = uuid.New()
+		requestBody = usecases.BatchGetUsersRequestBody{
+			UserIDs: []string{users[1].ID.String(), missingUserID.String(), users[0].ID.String()},
+		}
+		expectedUserIDs = []uuid.UUID{users[1].ID, missingUserID, users[0].ID}
+		getUsersErr = nil
+		getUsersCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		// the adapter doesn't return users in any particular order
+		mockUserGetter.EXPECT().GetUsersByIDs(gomock.AssignableToTypeOf(ctxType), expectedUserIDs).
+			Return([]entities.User{users[0], users[1]}, getUsersErr).Times(getUsersCallCount)
+
+		body, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest("POST", "http://localhost:8080"+path, bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the users in request order and list the missing IDs", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).ToNot(ContainSubstring("password"))
+		var response usecases.BatchGetUsersResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Users).To(HaveLen(2))
+		Expect(response.Users[0].ID).To(Equal(users[1].ID.String()))
+		Expect(response.Users[1].ID).To(Equal(users[0].ID.String()))
+		Expect(response.MissingUserIDs).To(Equal([]string{missingUserID.String()}))
+	})
+
+	When("an ID is repeated", func() {
+		BeforeEach(func() {
+			requestBody.UserIDs = []string{users[1].ID.String(), users[0].ID.String(), users[1].ID.String()}
+			expectedUserIDs = []uuid.UUID{users[1].ID, users[0].ID}
+		})
+
+		It("should only look the user up once", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var response usecases.BatchGetUsersResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Users).To(HaveLen(2))
+			Expect(response.MissingUserIDs).To(BeEmpty())
+		})
+	})
+
+	When("repeated IDs take the batch over the limit", func() {
+		BeforeEach(func() {
+			requestBody.UserIDs = []string{
+				users[0].ID.String(), users[0].ID.String(), users[0].ID.String(), users[0].ID.String(),
+			}
+			getUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("there are more IDs than the batch limit", func() {
+		BeforeEach(func() {
+			requestBody.UserIDs = []string{uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()}
+			getUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("no IDs are given", func() {
+		BeforeEach(func() {
+			requestBody.UserIDs = []string{}
+			getUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("an ID isn't a valid uuid", func() {
+		BeforeEach(func() {
+			requestBody.UserIDs = []string{users[0].ID.String(), "not-a-uuid"}
+			getUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userGetter adapter returns an error", func() {
+		BeforeEach(func() {
+			getUsersErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	When("the custom method doesn't exist", func() {
+		BeforeEach(func() {
+			path = "/users:batchFetch"
+			getUsersCallCount = 0
+		})
+
+		It("should return a 404 response", func() {
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	When("the path only starts with the collection", func() {
+		BeforeEach(func() {
+			path = "/usersbatchGet"
+			getUsersCallCount = 0
+		})
+
+		It("should return a 404 response", func() {
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
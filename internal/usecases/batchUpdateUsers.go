@@ -0,0 +1,286 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// errUserRestricted is reported for users of a batch update or user command that are restricted until their email
+// is verified
+var errUserRestricted = errors.New("user restricted until email is verified")
+
+// BatchUpdateUsersRequestBody represents the request body for updating many users
+// @Description The users to update and how to apply the batch
+type BatchUpdateUsersRequestBody struct {
+	// Mode represents how the batch is applied, all_or_nothing by default or best_effort
+	Mode string `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+	// Users represents the new profile of each user, there can be at most BATCH_MAX_USERS of them
+	Users []BatchUserUpdate `json:"users" binding:"required,min=1,dive"`
+}
+
+// BatchUserUpdate represents the changes to one user in a batch update, fields that are left out keep their current value
+// @Description The changes to one user, emails can only be changed with PUT /user/{userId}
+type BatchUserUpdate struct {
+	// UserID represents the user's unique identifier
+	UserID string `json:"user_id" binding:"required,uuid"`
+	// FirstName represents the user's first name
+	FirstName string `json:"first_name"`
+	// LastName represents the user's last name
+	LastName string `json:"last_name"`
+	// Nickname represents the user's nickname
+	Nickname string `json:"nickname"`
+	// Password represents the user's password
+	Password string `json:"password"`
+	// Country represents the user's country
+	Country string `json:"country"`
+	// Status represents the status to move the user into, suspended or banned
+	Status string `json:"status" binding:"omitempty,oneof=suspended banned"`
+	// BanReason represents why the user was banned, it can only be given with the banned status
+	BanReason string `json:"ban_reason"`
+	// BanExpiresAt represents the timestamp when the ban is automatically lifted, it can only be given with the banned
+	// status and a ban with no expiry is permanent
+	BanExpiresAt *time.Time `json:"ban_expires_at"`
+}
+
+// changesProfile reports whether the update changes any of the user's profile rather than only their status
+func (u BatchUserUpdate) changesProfile() bool {
+	return u.FirstName != "" || u.LastName != "" || u.Nickname != "" || u.Password != "" || u.Country != ""
+}
+
+// statusTransition returns the transition the update asks for, and false if it leaves the user's status as it is
+func (u BatchUserUpdate) statusTransition() (statusTransition, bool) {
+	switch entities.UserStatus(u.Status) {
+	case entities.UserStatusSuspended:
+		return suspendTransition, true
+	case entities.UserStatusBanned:
+		return statusTransition{
+			to:           entities.UserStatusBanned,
+			changeType:   entities.ChangeTypeBanned,
+			banReason:    u.BanReason,
+			banExpiresAt: u.BanExpiresAt,
+		}, true
+	default:
+		return statusTransition{}, false
+	}
+}
+
+// validate checks the parts of an update binding can't, that it changes something and only bans have ban details
+func (u BatchUserUpdate) validate(now time.Time) error {
+	if !u.changesProfile() && u.Status == "" {
+		return errors.New("update changes nothing")
+	}
+
+	if entities.UserStatus(u.Status) != entities.UserStatusBanned && (u.BanReason != "" || u.BanExpiresAt != nil) {
+		return errors.New("ban details given without banning the user")
+	}
+
+	if u.BanExpiresAt != nil && !u.BanExpiresAt.After(now) {
+		return errors.New("ban expiry is in the past")
+	}
+
+	return nil
+}
+
+// NewBatchUpdateUsers updates many users at once
+// @Summary Update users
+// @Description Updates up to BATCH_MAX_USERS users in one transaction. In all_or_nothing mode, the default, nothing is
+// @Description updated if any user can't be and the response is a 400 listing why. In best_effort mode every user that
+// @Description can be updated is and the rest are reported as failed. Each user only needs the fields that change, so
+// @Description users can be suspended or banned without giving their profile. Profile changes are checked as
+// @Description PUT /user/{userId} checks them, but emails can't be changed in a batch as a new address has to be
+// @Description confirmed first. Status changes are checked as the suspend and ban endpoints check them.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body BatchUpdateUsersRequestBody true "Batch Update Users Request Body"
+// @Success 200 {object} BatchWriteResponseBody
+// @Failure 400 {object} BatchWriteResponseBody
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /users:batchUpdate [post]
+func NewBatchUpdateUsers(
+	userGetter UserGetter,
+	userBatchWriter UserBatchWriter,
+	changelogWriter ChangelogWriter,
+	passwordValidator *PasswordValidator,
+	policy entities.UnverifiedAccountPolicy,
+	maxUsers int,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request BatchUpdateUsersRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		ids := make([]string, len(request.Users))
+		for i, update := range request.Users {
+			err = update.validate(now)
+			if err != nil {
+				slog.Warn("invalid user update", "err", err, "userID", update.UserID)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			ids[i] = update.UserID
+		}
+		userIDs, ok := parseBatchUserIDs(ids, maxUsers)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if len(userIDs) != len(request.Users) {
+			slog.Warn("user updated more than once in batch")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		users, err := userGetter.GetUsersByIDs(c.Request.Context(), userIDs)
+		if err != nil {
+			slog.Error("getting users by ids", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		usersByID := make(map[uuid.UUID]entities.User, len(users))
+		for _, user := range users {
+			usersByID[user.ID] = user
+		}
+
+		mode := batchMode(request.Mode)
+		results := make([]entities.BatchItemResult, len(request.Users))
+		// updates are only made for the users that pass the checks, positions maps them back to their results
+		updates := []entities.UserUpdate{}
+		positions := []int{}
+		for i, update := range request.Users {
+			results[i].UserID = userIDs[i]
+
+			user, found := usersByID[userIDs[i]]
+			if !found {
+				results[i].Status = entities.BatchItemStatusFailed
+				results[i].Error = entities.ErrUserNotFound.Error()
+				continue
+			}
+
+			userUpdate, err := newUserUpdate(user, update)
+			if err != nil {
+				results[i].Status = entities.BatchItemStatusFailed
+				results[i].Error = err.Error()
+				continue
+			}
+
+			// restricted users can still be suspended or banned, only their profile is locked
+			if !userUpdate.StatusOnly && policy.Restricts(user, now) {
+				results[i].Status = entities.BatchItemStatusFailed
+				results[i].Error = errUserRestricted.Error()
+				continue
+			}
+
+			// passwords set before the policy existed can be kept, only new ones are checked
+			if !passwordMatches(user, userUpdate.Password) {
+				err = passwordValidator.Validate(c.Request.Context(), userUpdate.Password, userUpdate.Nickname, user.Email)
+				if err != nil {
+					var policyErr *entities.PasswordPolicyError
+					if !errors.As(err, &policyErr) {
+						slog.Error("validating password", "err", err)
+						c.Status(http.StatusInternalServerError)
+						return
+					}
+
+					results[i].Status = entities.BatchItemStatusFailed
+					results[i].Error = policyErr.Error()
+					continue
+				}
+			}
+
+			updates = append(updates, userUpdate)
+			positions = append(positions, i)
+		}
+
+		if mode == entities.BatchModeAllOrNothing && len(updates) < len(results) {
+			slog.Warn("batch update not applied", "failed", len(results)-len(updates))
+			skipBatch(results)
+			c.JSON(newBatchWriteResponse(mode, results))
+			return
+		}
+
+		if len(updates) > 0 {
+			updated, err := userBatchWriter.UpdateUsers(c.Request.Context(), updates, mode)
+			if err != nil {
+				slog.Error("updating users", "err", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+
+			for j, result := range updated {
+				results[positions[j]] = result
+			}
+		}
+
+		// each user gets an entry for their profile change and one for their status change, like the single user endpoints
+		changes := map[string][]entities.BatchItemResult{}
+		for j, update := range updates {
+			result := results[positions[j]]
+			if !update.StatusOnly {
+				changes["PUT"] = append(changes["PUT"], result)
+			}
+			if transition, ok := request.Users[positions[j]].statusTransition(); ok {
+				changes[transition.changeType] = append(changes[transition.changeType], result)
+			}
+		}
+		for _, changeType := range []string{"PUT", entities.ChangeTypeSuspended, entities.ChangeTypeBanned} {
+			publishBatchChangelog(changelogWriter, changeType, changes[changeType])
+		}
+
+		setAuditTargets(c, results)
+		c.JSON(newBatchWriteResponse(mode, results))
+	}
+}
+
+// newUserUpdate merges a batch update into the user's current profile and checks the status change it asks for is
+// allowed, returning entities.ErrInvalidStatusTransition if it isn't
+func newUserUpdate(user entities.User, update BatchUserUpdate) (entities.UserUpdate, error) {
+	userUpdate := entities.UserUpdate{
+		UserID:     user.ID,
+		FirstName:  valueOr(update.FirstName, user.FirstName),
+		LastName:   valueOr(update.LastName, user.LastName),
+		Nickname:   valueOr(update.Nickname, user.Nickname),
+		Password:   valueOr(update.Password, user.Password),
+		Email:      user.Email,
+		Country:    valueOr(update.Country, user.Country),
+		StatusOnly: !update.changesProfile(),
+	}
+
+	transition, ok := update.statusTransition()
+	if !ok {
+		return userUpdate, nil
+	}
+
+	if !user.Status.CanTransitionTo(transition.to) {
+		return entities.UserUpdate{}, fmt.Errorf("%w: from %s to %s", entities.ErrInvalidStatusTransition, user.Status, transition.to)
+	}
+
+	userUpdate.Status = transition.to
+	userUpdate.FromStatus = user.Status
+	userUpdate.BanReason = transition.banReason
+	userUpdate.BanExpiresAt = transition.banExpiresAt
+
+	return userUpdate, nil
+}
+
+// valueOr returns value, or current if value wasn't given
+func valueOr(value, current string) string {
+	if value == "" {
+		return current
+	}
+
+	return value
+}
@@ -0,0 +1,390 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Updating users in a batch", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody usecases.BatchUpdateUsersRequestBody
+	var users []entities.User
+	var expectedUserIDs []uuid.UUID
+	var getUsersCallCount int
+	var breachLookupCallCount int
+	var expectedMode entities.BatchMode
+	var receivedUpdates []entities.UserUpdate
+	var updateUsersErr error
+	var updateUsersCallCount int
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		users = []entities.User{
+			{ID: uuid.New(), FirstName: "alec", Password: "old-password", Email: "alec@email.com", CreatedAt: time.Now().UTC()},
+			{ID: uuid.New(), FirstName: "jane", Password: "old-password", Email: "jane@email.com", CreatedAt: time.Now().UTC()},
+		}
+		requestBody = usecases.BatchUpdateUsersRequestBody{
+			Users: []usecases.BatchUserUpdate{
+				{UserID: users[0].ID.String(), FirstName: "alex", LastName: "smith", Nickname: "alexsmith", Password: "old-password", Country: "UK"},
+				{UserID: users[1].ID.String(), FirstName: "jane", LastName: "doe", Nickname: "janedoe", Password: "some-password", Country: "FR"},
+			},
+		}
+		expectedUserIDs = []uuid.UUID{users[0].ID, users[1].ID}
+		getUsersCallCount = 1
+		// only the changed password is checked
+		breachLookupCallCount = 1
+		expectedMode = entities.BatchModeAllOrNothing
+		receivedUpdates = nil
+		updateUsersErr = nil
+		updateUsersCallCount = 1
+		changelogWriterCallCount = 2
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserGetter.EXPECT().GetUsersByIDs(gomock.AssignableToTypeOf(ctxType), expectedUserIDs).
+			Return(users, nil).Times(getUsersCallCount)
+		expectBreachLookup(mockBreachCorpus, "some-password", 0, breachLookupCallCount)
+		mockUserBatchWriter.EXPECT().UpdateUsers(gomock.AssignableToTypeOf(ctxType), gomock.Any(), expectedMode).
+			DoAndReturn(func(_ context.Context, updates []entities.UserUpdate, _ entities.BatchMode) ([]entities.BatchItemResult, error) {
+				receivedUpdates = updates
+				if updateUsersErr != nil {
+					return nil, updateUsersErr
+				}
+
+				results := []entities.BatchItemResult{}
+				for _, update := range updates {
+					results = append(results, entities.BatchItemResult{
+						UserID: update.UserID,
+						Status: entities.BatchItemStatusSucceeded,
+						User:   &entities.User{ID: update.UserID, FirstName: update.FirstName, Password: update.Password, Email: update.Email},
+					})
+				}
+				return results, nil
+			}).Times(updateUsersCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+			return x.(entities.ChangelogEntry).ChangeType == "PUT"
+		})).Return(nil).Times(changelogWriterCallCount)
+
+		body, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest("POST", "http://localhost:8080/users:batchUpdate", bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should update every user, keeping their current email, without returning their passwords", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(receivedUpdates).To(Equal([]entities.UserUpdate{
+			{UserID: users[0].ID, FirstName: "alex", LastName: "smith", Nickname: "alexsmith", Password: "old-password", Email: "alec@email.com", Country: "UK"},
+			{UserID: users[1].ID, FirstName: "jane", LastName: "doe", Nickname: "janedoe", Password: "some-password", Email: "jane@email.com", Country: "FR"},
+		}))
+		Expect(w.Body.String()).ToNot(ContainSubstring("password"))
+
+		var response usecases.BatchWriteResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Mode).To(Equal("all_or_nothing"))
+		Expect(response.Succeeded).To(Equal(2))
+		Expect(response.Results).To(HaveLen(2))
+		Expect(response.Results[0].Status).To(Equal("SUCCEEDED"))
+		Expect(response.Results[0].User.FirstName).To(Equal("alex"))
+	})
+
+	When("a user doesn't exist", func() {
+		var missingUserID uuid.UUID
+
+		BeforeEach(func() {
+			missingUserID = uuid.New()
+			requestBody.Users = append(requestBody.Users, usecases.BatchUserUpdate{
+				UserID: missingUserID.String(), FirstName: "john", LastName: "doe", Nickname: "johndoe", Password: "old-password", Country: "UK",
+			})
+			expectedUserIDs = append(expectedUserIDs, missingUserID)
+			updateUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should update nobody and return a 400 response saying why", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			var response usecases.BatchWriteResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Failed).To(Equal(1))
+			Expect(response.Skipped).To(Equal(2))
+			Expect(response.Results[2]).To(Equal(usecases.BatchItemResultResponse{
+				UserID: missingUserID.String(),
+				Status: "FAILED",
+				Error:  entities.ErrUserNotFound.Error(),
+			}))
+		})
+
+		When("the batch is best effort", func() {
+			BeforeEach(func() {
+				requestBody.Mode = "best_effort"
+				expectedMode = entities.BatchModeBestEffort
+				updateUsersCallCount = 1
+				changelogWriterCallCount = 2
+			})
+
+			It("should update the users that exist", func() {
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(receivedUpdates).To(HaveLen(2))
+				var response usecases.BatchWriteResponseBody
+				err := json.NewDecoder(w.Body).Decode(&response)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response.Succeeded).To(Equal(2))
+				Expect(response.Failed).To(Equal(1))
+				Expect(response.Results[1].Status).To(Equal("SUCCEEDED"))
+				Expect(response.Results[2].Status).To(Equal("FAILED"))
+			})
+		})
+	})
+
+	When("a new password is rejected in a best effort batch", func() {
+		BeforeEach(func() {
+			requestBody.Mode = "best_effort"
+			requestBody.Users[1].Password = "short"
+			breachLookupCallCount = 0
+			expectBreachLookup(mockBreachCorpus, "short", 0, 1)
+			expectedMode = entities.BatchModeBestEffort
+			changelogWriterCallCount = 1
+		})
+
+		It("should report the user as failed and update the rest", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(receivedUpdates).To(HaveLen(1))
+			var response usecases.BatchWriteResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Results[1].Status).To(Equal("FAILED"))
+			Expect(response.Results[1].Error).To(ContainSubstring("password"))
+		})
+	})
+
+	When("a user is only suspended", func() {
+		BeforeEach(func() {
+			users[1].Status = entities.UserStatusActive
+			requestBody.Users[1] = usecases.BatchUserUpdate{UserID: users[1].ID.String(), Status: "suspended"}
+			breachLookupCallCount = 0
+			changelogWriterCallCount = 1
+			mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+				entry := x.(entities.ChangelogEntry)
+				return entry.ChangeType == entities.ChangeTypeSuspended && entry.UserID == users[1].ID
+			})).Return(nil).Times(1)
+		})
+
+		It("should suspend them without changing their profile", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(receivedUpdates[1]).To(Equal(entities.UserUpdate{
+				UserID:     users[1].ID,
+				FirstName:  "jane",
+				Password:   "old-password",
+				Email:      "jane@email.com",
+				StatusOnly: true,
+				Status:     entities.UserStatusSuspended,
+				FromStatus: entities.UserStatusActive,
+			}))
+		})
+	})
+
+	When("a user is banned along with a profile change", func() {
+		var banExpiresAt time.Time
+
+		BeforeEach(func() {
+			users[1].Status = entities.UserStatusActive
+			banExpiresAt = time.Now().Add(24 * time.Hour).UTC()
+			requestBody.Users[1].Status = "banned"
+			requestBody.Users[1].BanReason = "cheating"
+			requestBody.Users[1].BanExpiresAt = &banExpiresAt
+			mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+				entry := x.(entities.ChangelogEntry)
+				return entry.ChangeType == entities.ChangeTypeBanned && entry.UserID == users[1].ID
+			})).Return(nil).Times(1)
+		})
+
+		It("should update their profile and ban them", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(receivedUpdates[1].StatusOnly).To(BeFalse())
+			Expect(receivedUpdates[1].Nickname).To(Equal("janedoe"))
+			Expect(receivedUpdates[1].Status).To(Equal(entities.UserStatusBanned))
+			Expect(receivedUpdates[1].BanReason).To(Equal("cheating"))
+			Expect(receivedUpdates[1].BanExpiresAt).To(Equal(&banExpiresAt))
+		})
+	})
+
+	When("a user's status can't move into the one given in a best effort batch", func() {
+		BeforeEach(func() {
+			requestBody.Mode = "best_effort"
+			expectedMode = entities.BatchModeBestEffort
+			users[1].Status = entities.UserStatusBanned
+			requestBody.Users[1] = usecases.BatchUserUpdate{UserID: users[1].ID.String(), Status: "suspended"}
+			breachLookupCallCount = 0
+			changelogWriterCallCount = 1
+		})
+
+		It("should report the user as failed and update the rest", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(receivedUpdates).To(HaveLen(1))
+			var response usecases.BatchWriteResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Results[1].Status).To(Equal("FAILED"))
+			Expect(response.Results[1].Error).To(ContainSubstring(entities.ErrInvalidStatusTransition.Error()))
+		})
+	})
+
+	When("a user's update changes nothing", func() {
+		BeforeEach(func() {
+			requestBody.Users[1] = usecases.BatchUserUpdate{UserID: users[1].ID.String()}
+			getUsersCallCount = 0
+			breachLookupCallCount = 0
+			updateUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("ban details are given without banning the user", func() {
+		BeforeEach(func() {
+			requestBody.Users[1].Status = "suspended"
+			requestBody.Users[1].BanReason = "cheating"
+			getUsersCallCount = 0
+			breachLookupCallCount = 0
+			updateUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the status isn't one users can be moved into in a batch", func() {
+		BeforeEach(func() {
+			requestBody.Users[1].Status = "active"
+			getUsersCallCount = 0
+			breachLookupCallCount = 0
+			updateUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("a user is given more than once", func() {
+		BeforeEach(func() {
+			requestBody.Users[1].UserID = users[0].ID.String()
+			getUsersCallCount = 0
+			breachLookupCallCount = 0
+			updateUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the mode isn't known", func() {
+		BeforeEach(func() {
+			requestBody.Mode = "eventually"
+			getUsersCallCount = 0
+			breachLookupCallCount = 0
+			updateUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userBatchWriter adapter returns an error", func() {
+		BeforeEach(func() {
+			updateUsersErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	It("should record an audit entry for each user that was updated", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		auditLog := mock_usecases.NewMockAuditLog(ctrl)
+		userGetter := mock_usecases.NewMockUserGetter(ctrl)
+		userBatchWriter := mock_usecases.NewMockUserBatchWriter(ctrl)
+		changelogWriter := mock_usecases.NewMockChangelogWriter(ctrl)
+
+		users := []entities.User{
+			{ID: uuid.New(), Status: entities.UserStatusActive},
+			{ID: uuid.New(), Status: entities.UserStatusActive},
+		}
+		userGetter.EXPECT().GetUsersByIDs(gomock.AssignableToTypeOf(ctxType), []uuid.UUID{users[0].ID, users[1].ID}).
+			Return(users, nil).Times(1)
+		userBatchWriter.EXPECT().UpdateUsers(gomock.AssignableToTypeOf(ctxType), gomock.Any(), entities.BatchModeBestEffort).
+			Return([]entities.BatchItemResult{
+				{UserID: users[0].ID, Status: entities.BatchItemStatusFailed, Error: entities.ErrInvalidStatusTransition.Error()},
+				{UserID: users[1].ID, Status: entities.BatchItemStatusSucceeded, User: &users[1]},
+			}, nil).Times(1)
+		changelogWriter.EXPECT().PublishChangelogEntry(gomock.Any()).Return(nil).Times(1)
+		appendedEntries := []entities.AuditEntry{}
+		auditLog.EXPECT().AppendAuditEntry(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+			DoAndReturn(func(_ context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+				appendedEntries = append(appendedEntries, entry)
+				return &entry, nil
+			}).Times(1)
+
+		engine := gin.New()
+		engine.POST(
+			"/batchUpdate",
+			usecases.NewAuditAction(auditLog, entities.AuditActionUserUpdated),
+			usecases.NewBatchUpdateUsers(
+				userGetter,
+				userBatchWriter,
+				changelogWriter,
+				usecases.NewPasswordValidator(entities.PasswordPolicy{MinLength: 10, MaxLength: 128, MinEntropyBits: 40}, mockBreachCorpus),
+				entities.UnverifiedAccountPolicy{},
+				3,
+			),
+		)
+
+		body, err := json.Marshal(usecases.BatchUpdateUsersRequestBody{
+			Mode: "best_effort",
+			Users: []usecases.BatchUserUpdate{
+				{UserID: users[0].ID.String(), Status: "suspended"},
+				{UserID: users[1].ID.String(), Status: "suspended"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest("POST", "http://localhost:8080/batchUpdate", bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(appendedEntries).To(HaveLen(1))
+		Expect(appendedEntries[0].Action).To(Equal(entities.AuditActionUserUpdated))
+		Expect(appendedEntries[0].TargetUserID).To(Equal(&users[1].ID))
+	})
+})
@@ -0,0 +1,31 @@
+package usecases
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// NewBeginWebAuthnLogin starts a passkey login
+// @Summary Begin WebAuthn login
+// @Description Returns the options for logging in with a passkey. No username is needed, the authenticator picks the account.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} WebAuthnOptionsResponseBody
+// @Failure 500
+// @Router /auth/webauthn/login/begin [post]
+func NewBeginWebAuthnLogin(webAuthnManager *WebAuthnManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ceremonyID, options, err := webAuthnManager.BeginLogin(c.Request.Context())
+		if err != nil {
+			slog.Error("beginning webauthn login", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, WebAuthnOptionsResponseBody{
+			CeremonyID: ceremonyID.String(),
+			Options:    options,
+		})
+	}
+}
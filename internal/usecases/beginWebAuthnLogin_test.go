@@ -0,0 +1,76 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/goccy/go-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Beginning a WebAuthn login", func() {
+	var w *httptest.ResponseRecorder
+
+	var storedCeremony entities.WebAuthnCeremony
+	var createCeremonyErr error
+
+	BeforeEach(func() {
+		storedCeremony = entities.WebAuthnCeremony{}
+		createCeremonyErr = nil
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockWebAuthnStore.EXPECT().CreateWebAuthnCeremony(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.WebAuthnCeremony{})).
+			DoAndReturn(func(_ context.Context, ceremony entities.WebAuthnCeremony) error {
+				storedCeremony = ceremony
+				return createCeremonyErr
+			}).Times(1)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/webauthn/login/begin", nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the options for any of the user's passkeys", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response struct {
+			CeremonyID string `json:"ceremony_id"`
+			Options    struct {
+				PublicKey struct {
+					Challenge        string `json:"challenge"`
+					RPID             string `json:"rpId"`
+					AllowCredentials []any  `json:"allowCredentials"`
+					UserVerification string `json:"userVerification"`
+				} `json:"publicKey"`
+			} `json:"options"`
+		}
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(response.CeremonyID).To(Equal(storedCeremony.ID.String()))
+		Expect(response.Options.PublicKey.Challenge).ToNot(BeEmpty())
+		Expect(response.Options.PublicKey.RPID).To(Equal("localhost"))
+		Expect(response.Options.PublicKey.AllowCredentials).To(BeEmpty())
+		Expect(response.Options.PublicKey.UserVerification).To(Equal("required"))
+
+		Expect(storedCeremony.Purpose).To(Equal(entities.WebAuthnCeremonyLogin))
+		Expect(storedCeremony.UserID).To(BeNil())
+	})
+
+	When("storing the ceremony fails", func() {
+		BeforeEach(func() {
+			createCeremonyErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
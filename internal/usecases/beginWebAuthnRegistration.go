@@ -0,0 +1,92 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// BeginWebAuthnRegistrationRequestBody represents the request body for starting WebAuthn registration
+// @Description The user's current password
+type BeginWebAuthnRegistrationRequestBody struct {
+	// Password represents the user's current password
+	Password string `json:"password" binding:"required"`
+}
+
+// WebAuthnOptionsResponseBody represents the response body for starting a WebAuthn ceremony
+// @Description The ceremony to finish and the options to pass to the authenticator
+type WebAuthnOptionsResponseBody struct {
+	// CeremonyID represents the ceremony to submit the authenticator's response to
+	CeremonyID string `json:"ceremony_id"`
+	// Options represents the options to pass to navigator.credentials.create() or navigator.credentials.get()
+	Options any `json:"options" swaggertype:"object"`
+}
+
+// NewBeginWebAuthnRegistration starts registering a passkey for a user
+// @Summary Begin WebAuthn registration
+// @Description Returns the options for creating a passkey, excluding the user's existing credentials
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Param request body BeginWebAuthnRegistrationRequestBody true "Begin WebAuthn Registration Request Body"
+// @Success 200 {object} WebAuthnOptionsResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /user/{userId}/webauthn/register/begin [post]
+func NewBeginWebAuthnRegistration(userGetter UserGetter, webAuthnManager *WebAuthnManager, loginThrottler *LoginThrottler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request BeginWebAuthnRegistrationRequestBody
+		err = c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if rejectIncorrectPassword(c, loginThrottler, *user, request.Password) {
+			return
+		}
+
+		ceremonyID, options, err := webAuthnManager.BeginRegistration(c.Request.Context(), *user)
+		if err != nil {
+			slog.Error("beginning webauthn registration", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, WebAuthnOptionsResponseBody{
+			CeremonyID: ceremonyID.String(),
+			Options:    options,
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Beginning WebAuthn registration", func() {
+	var w *httptest.ResponseRecorder
+	var userID string
+	var requestBody *usecases.BeginWebAuthnRegistrationRequestBody
+
+	var user *entities.User
+	var getUserErr error
+	var getUserCallCount int
+
+	var existingCredentials []entities.WebAuthnCredential
+	var getCredentialsCallCount int
+
+	var storedCeremony entities.WebAuthnCeremony
+	var createCeremonyCallCount int
+
+	var accountThrottle *entities.LoginThrottle
+	var passwordCheckCallCount int
+	var passwordFailureCallCount int
+
+	BeforeEach(func() {
+		user = &entities.User{
+			ID:       uuid.New(),
+			Nickname: "AlecSmith96",
+			Password: "password",
+			Email:    "alec@email.com",
+			Status:   entities.UserStatusActive,
+		}
+		userID = user.ID.String()
+		requestBody = &usecases.BeginWebAuthnRegistrationRequestBody{Password: "password"}
+		getUserErr = nil
+		// once for the access token and once by the handler
+		getUserCallCount = 2
+
+		existingCredentials = []entities.WebAuthnCredential{
+			{ID: uuid.New(), UserID: user.ID, CredentialID: []byte("existing-credential")},
+		}
+		getCredentialsCallCount = 1
+
+		storedCeremony = entities.WebAuthnCeremony{}
+		createCeremonyCallCount = 1
+
+		accountThrottle = &entities.LoginThrottle{}
+		passwordCheckCallCount = 1
+		passwordFailureCallCount = 0
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(&entities.AccessToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			IssuedAt:  time.Now().UTC(),
+			ExpiresAt: time.Now().Add(time.Minute).UTC(),
+		}, nil).Times(1)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, getUserErr).Times(getUserCallCount)
+		mockWebAuthnStore.EXPECT().GetWebAuthnCredentials(gomock.AssignableToTypeOf(ctxType), user.ID).
+			Return(existingCredentials, nil).Times(getCredentialsCallCount)
+		mockWebAuthnStore.EXPECT().CreateWebAuthnCeremony(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.WebAuthnCeremony{})).
+			DoAndReturn(func(_ context.Context, ceremony entities.WebAuthnCeremony) error {
+				storedCeremony = ceremony
+				return nil
+			}).Times(createCeremonyCallCount)
+		expectPasswordCheck(user.ID, accountThrottle, passwordCheckCallCount, passwordFailureCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/user/"+userID+"/webauthn/register/begin", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the options for the authenticator and store the ceremony", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response struct {
+			CeremonyID string `json:"ceremony_id"`
+			Options    struct {
+				PublicKey struct {
+					Challenge string `json:"challenge"`
+					RP        struct {
+						ID string `json:"id"`
+					} `json:"rp"`
+					ExcludeCredentials []struct {
+						ID string `json:"id"`
+					} `json:"excludeCredentials"`
+				} `json:"publicKey"`
+			} `json:"options"`
+		}
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(response.CeremonyID).To(Equal(storedCeremony.ID.String()))
+		Expect(response.Options.PublicKey.Challenge).ToNot(BeEmpty())
+		Expect(response.Options.PublicKey.RP.ID).To(Equal("localhost"))
+		Expect(response.Options.PublicKey.ExcludeCredentials).To(HaveLen(1))
+		Expect(response.Options.PublicKey.ExcludeCredentials[0].ID).To(Equal(base64.RawURLEncoding.EncodeToString([]byte("existing-credential"))))
+
+		Expect(storedCeremony.Purpose).To(Equal(entities.WebAuthnCeremonyRegistration))
+		Expect(storedCeremony.UserID).To(Equal(&user.ID))
+		Expect(storedCeremony.SessionData).To(ContainSubstring(response.Options.PublicKey.Challenge))
+		Expect(storedCeremony.ExpiresAt).To(BeTemporally("~", time.Now().Add(5*time.Minute), time.Minute))
+	})
+
+	When("the password is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.Password = "wrong-password"
+			getCredentialsCallCount = 0
+			createCeremonyCallCount = 0
+			passwordFailureCallCount = 1
+		})
+
+		It("should return a 401 Unauthorized and count the failure against the account", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the account is throttled after failed logins", func() {
+		BeforeEach(func() {
+			blockedUntil := time.Now().Add(time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 4, BlockedUntil: &blockedUntil}
+			getCredentialsCallCount = 0
+			createCeremonyCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the password", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).ToNot(BeEmpty())
+		})
+	})
+
+	When("the user doesn't exist", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			getUserCallCount = 1
+			getCredentialsCallCount = 0
+			createCeremonyCallCount = 0
+			passwordCheckCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized as the access token is no longer valid", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ChangePasswordRequestBody represents the request body for changing a password
+// @Description The user's current password and the new password
+type ChangePasswordRequestBody struct {
+	// CurrentPassword represents the user's existing password
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// NewPassword represents the password to set
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// NewChangePassword changes a user's password
+// @Summary Change password
+// @Description Changes the user's password after checking their current one, invalidating any credentials issued before the change
+// @Tags users
+// @Accept json
+// @Param userId path string true "User ID"
+// @Param request body ChangePasswordRequestBody true "Change Password Request Body"
+// @Success 200
+// @Failure 400 {object} PasswordRejectedResponseBody
+// @Failure 401
+// @Failure 429
+// @Failure 500
+// @Router /user/{userId}/password [post]
+func NewChangePassword(
+	userGetter UserGetter,
+	passwordManager PasswordManager,
+	changelogWriter ChangelogWriter,
+	passwordValidator *PasswordValidator,
+	loginThrottler *LoginThrottler,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request ChangePasswordRequestBody
+		err = c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if rejectIncorrectPassword(c, loginThrottler, *user, request.CurrentPassword) {
+			return
+		}
+
+		err = passwordValidator.Validate(c.Request.Context(), request.NewPassword, user.Nickname, user.Email)
+		if err != nil {
+			respondPasswordInvalid(c, err)
+			return
+		}
+
+		user, err = passwordManager.ChangePassword(c.Request.Context(), userIDUUID, request.NewPassword, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("changing password", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     user.ID,
+			CreatedAt:  user.UpdatedAt,
+			ChangeType: entities.ChangeTypePasswordChanged,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,155 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Changing a password", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var requestBody *usecases.ChangePasswordRequestBody
+	var getUserResponse *entities.User
+	var getUserErr error
+	var getUserCallCount int
+	var changePasswordErr error
+	var changePasswordCallCount int
+	var changelogWriterCallCount int
+	var breachCount int
+	var breachLookupCallCount int
+	var accountThrottle *entities.LoginThrottle
+	var passwordCheckCallCount int
+	var passwordFailureCallCount int
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		requestBody = &usecases.ChangePasswordRequestBody{
+			CurrentPassword: "some-password",
+			NewPassword:     "new-password",
+		}
+		getUserResponse = &entities.User{
+			ID:       userID,
+			Password: "some-password",
+		}
+		getUserErr = nil
+		getUserCallCount = 1
+		changePasswordErr = nil
+		changePasswordCallCount = 1
+		changelogWriterCallCount = 1
+		breachCount = 0
+		breachLookupCallCount = 1
+		accountThrottle = &entities.LoginThrottle{}
+		passwordCheckCallCount = 1
+		passwordFailureCallCount = 0
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		changedAt := time.Now().UTC()
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(getUserResponse, getUserErr).Times(getUserCallCount)
+		expectBreachLookup(mockBreachCorpus, requestBody.NewPassword, breachCount, breachLookupCallCount)
+		mockPasswordManager.EXPECT().ChangePassword(gomock.AssignableToTypeOf(ctxType), userID, requestBody.NewPassword, gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.User{ID: userID, UpdatedAt: changedAt}, changePasswordErr).Times(changePasswordCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(entities.ChangelogEntry{
+			UserID:     userID,
+			CreatedAt:  changedAt,
+			ChangeType: entities.ChangeTypePasswordChanged,
+		}).Return(nil).Times(changelogWriterCallCount)
+		expectPasswordCheck(userID, accountThrottle, passwordCheckCallCount, passwordFailureCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/user/%s/password", userID), bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return a 200 OK", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the current password is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.CurrentPassword = "wrong-password"
+			changePasswordCallCount = 0
+			changelogWriterCallCount = 0
+			breachLookupCallCount = 0
+			passwordFailureCallCount = 1
+		})
+
+		It("should return a 401 Unauthorized and count the failure against the account", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the account is throttled after failed logins", func() {
+		BeforeEach(func() {
+			blockedUntil := time.Now().Add(time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 4, BlockedUntil: &blockedUntil}
+			changePasswordCallCount = 0
+			changelogWriterCallCount = 0
+			breachLookupCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the password", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).ToNot(BeEmpty())
+		})
+	})
+
+	When("the user is not found", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			changePasswordCallCount = 0
+			changelogWriterCallCount = 0
+			breachLookupCallCount = 0
+			passwordCheckCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the new password has appeared in a breach", func() {
+		BeforeEach(func() {
+			breachCount = 1
+			changePasswordCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request with the rule it broke", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+			var response usecases.PasswordRejectedResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Violations).To(Equal([]string{entities.PasswordRuleBreached}))
+		})
+	})
+
+	When("the passwordManager adapter returns generic error", func() {
+		BeforeEach(func() {
+			changePasswordErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// EmailChangeTokenRequestBody represents the request body for confirming or reverting an email change
+// @Description The token emailed to the user
+type EmailChangeTokenRequestBody struct {
+	// Token represents the signed token from the email
+	Token string `json:"token" binding:"required"`
+}
+
+// NewConfirmEmailChange confirms an email change
+// @Summary Confirm email change
+// @Description Redeems the token sent to the new address, replacing the user's email with their pending email
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body EmailChangeTokenRequestBody true "Email Change Token Request Body"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 500
+// @Router /email-change/confirm [post]
+func NewConfirmEmailChange(tokenSigner TokenSigner, emailChangeStore EmailChangeStore, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request EmailChangeTokenRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		token, err := tokenSigner.ParseToken(request.Token)
+		if err != nil || token.Purpose != entities.TokenPurposeEmailChange {
+			slog.Warn("invalid email change token", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := emailChangeStore.ConfirmEmailChange(c.Request.Context(), *token, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) || errors.Is(err, entities.ErrEmailAlreadyUsed) {
+				slog.Warn("unable to confirm email change", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("confirming email change", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     user.ID,
+			CreatedAt:  user.UpdatedAt,
+			ChangeType: entities.ChangeTypeEmailChanged,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.JSON(http.StatusOK, newUserResponse(*user))
+	}
+}
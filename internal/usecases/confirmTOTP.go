@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// MFACodeRequestBody represents a request body carrying a second factor
+// @Description A code from the user's authenticator, or one of their recovery codes
+type MFACodeRequestBody struct {
+	// Code represents a code from the user's authenticator, or one of their recovery codes
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponseBody represents the response body for newly generated recovery codes
+// @Description One-time recovery codes. They are only shown once.
+type RecoveryCodesResponseBody struct {
+	// RecoveryCodes represents the codes that can each be used once in place of an authenticator code
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// NewConfirmTOTP confirms TOTP enrolment for a user
+// @Summary Confirm TOTP
+// @Description Enables MFA once the user submits a first code from their authenticator, returning their recovery codes
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param request body MFACodeRequestBody true "MFA Code Request Body"
+// @Success 200 {object} RecoveryCodesResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 500
+// @Router /user/{userId}/mfa/totp/confirm [post]
+func NewConfirmTOTP(mfaManager *MFAManager, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request MFACodeRequestBody
+		err = c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		recoveryCodes, err := mfaManager.Confirm(c.Request.Context(), userIDUUID, request.Code)
+		if err != nil {
+			if errors.Is(err, entities.ErrMFANotEnrolled) {
+				slog.Warn("mfa not enrolled", "userID", userIDUUID)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			if errors.Is(err, entities.ErrInvalidMFACode) {
+				slog.Warn("invalid mfa code", "userID", userIDUUID)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			if errors.Is(err, entities.ErrMFAAlreadyEnabled) {
+				slog.Warn("mfa already enabled", "userID", userIDUUID)
+				c.Status(http.StatusConflict)
+				return
+			}
+
+			slog.Error("confirming totp", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     userIDUUID,
+			CreatedAt:  time.Now().UTC(),
+			ChangeType: entities.ChangeTypeMFAEnabled,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.JSON(http.StatusOK, RecoveryCodesResponseBody{RecoveryCodes: recoveryCodes})
+	}
+}
@@ -0,0 +1,122 @@
+package usecases_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Confirming TOTP enrolment", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.MFACodeRequestBody
+	var userID uuid.UUID
+	var secret []byte
+
+	var enrolment *entities.TOTPEnrolment
+	var getEnrolmentErr error
+	var openSecretCallCount int
+	var storedRecoveryCodeHashes []string
+	var confirmCallCount int
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		secret = []byte("12345678901234567890")
+		requestBody = &usecases.MFACodeRequestBody{Code: currentTOTPCode(secret)}
+		userID = uuid.New()
+
+		enrolment = &entities.TOTPEnrolment{UserID: userID, EncryptedSecret: "sealed-secret"}
+		getEnrolmentErr = nil
+		openSecretCallCount = 1
+		storedRecoveryCodeHashes = nil
+		confirmCallCount = 1
+		changelogWriterCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockMFAStore.EXPECT().GetTOTPEnrolment(gomock.AssignableToTypeOf(ctxType), userID).Return(enrolment, getEnrolmentErr).Times(1)
+		mockSecretBox.EXPECT().Open("sealed-secret", userID[:]).Return(secret, nil).Times(openSecretCallCount)
+		mockMFAStore.EXPECT().ConfirmTOTP(
+			gomock.AssignableToTypeOf(ctxType),
+			userID,
+			gomock.AssignableToTypeOf(int64(0)),
+			gomock.AssignableToTypeOf([]string{}),
+			gomock.AssignableToTypeOf(time.Time{}),
+		).Do(func(_ any, _ uuid.UUID, _ int64, recoveryCodeHashes []string, _ time.Time) {
+			storedRecoveryCodeHashes = recoveryCodeHashes
+		}).Return(nil).Times(confirmCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			Do(func(entry entities.ChangelogEntry) {
+				Expect(entry.UserID).To(Equal(userID))
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeMFAEnabled))
+			}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/user/%s/mfa/totp/confirm", userID), bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should enable MFA and return recovery codes, storing only their hashes", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.RecoveryCodesResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.RecoveryCodes).To(HaveLen(10))
+		Expect(storedRecoveryCodeHashes).To(HaveLen(10))
+		for _, code := range response.RecoveryCodes {
+			Expect(storedRecoveryCodeHashes).ToNot(ContainElement(code))
+		}
+	})
+
+	When("the code is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.Code = usecases.TOTPCode(secret, time.Now().Unix()/30+10)
+			confirmCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("TOTP enrolment hasn't been started", func() {
+		BeforeEach(func() {
+			getEnrolmentErr = entities.ErrMFANotEnrolled
+			openSecretCallCount = 0
+			confirmCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("MFA is already enabled", func() {
+		BeforeEach(func() {
+			confirmedAt := time.Now().UTC()
+			enrolment.ConfirmedAt = &confirmedAt
+			openSecretCallCount = 0
+			confirmCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// CreateAPIKeyRequestBody represents the request body for minting an API key
+// @Description The API key to mint
+type CreateAPIKeyRequestBody struct {
+	// Name represents what the key is used for
+	Name string `json:"name" binding:"required"`
+	// Owner represents the service or team responsible for the key
+	Owner string `json:"owner" binding:"required"`
+	// Scopes represents what the key can do, users:write includes users:read and users:admin includes both
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write users:admin"`
+	// ExpiresAt represents when the key stops working, it never expires if empty
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key
+// @Description A key another service calls the API with
+type APIKeyResponse struct {
+	// ID represents the key's identifier
+	ID uuid.UUID `json:"id"`
+	// Key represents the key's secret, it is only returned when the key is minted
+	Key string `json:"key,omitempty"`
+	// Name represents what the key is used for
+	Name string `json:"name"`
+	// Owner represents the service or team responsible for the key
+	Owner string `json:"owner"`
+	// Scopes represents what the key can do
+	Scopes []string `json:"scopes"`
+	// CreatedAt represents when the key was minted
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt represents when the key stops working, if it does
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// LastUsedAt represents when the key was last used, if it has been
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// RevokedAt represents when the key was revoked, if it has been
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func newAPIKeyResponse(key entities.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Owner:      key.Owner,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// NewCreateAPIKey mints an API key
+// @Summary Mint API key
+// @Description Mints a key for another service to call the API with, sent as `Authorization: ApiKey <key>`. The key is
+// @Description only shown once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateAPIKeyRequestBody true "Create API Key Request Body"
+// @Success 201 {object} APIKeyResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /admin/api-keys [post]
+func NewCreateAPIKey(apiKeyStore APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request CreateAPIKeyRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		if request.ExpiresAt != nil && !request.ExpiresAt.After(now) {
+			slog.Warn("api key expiry in the past", "expiresAt", request.ExpiresAt)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		secret, secretHash, err := generateAPIKey()
+		if err != nil {
+			slog.Error("generating api key", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		key := entities.APIKey{
+			ID:         uuid.New(),
+			Name:       request.Name,
+			Owner:      request.Owner,
+			Scopes:     request.Scopes,
+			SecretHash: secretHash,
+			CreatedAt:  now,
+			ExpiresAt:  request.ExpiresAt,
+		}
+		err = apiKeyStore.CreateAPIKey(c.Request.Context(), key)
+		if err != nil {
+			slog.Error("creating api key", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := newAPIKeyResponse(key)
+		response.Key = secret
+		c.JSON(http.StatusCreated, response)
+	}
+}
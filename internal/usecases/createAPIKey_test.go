@@ -0,0 +1,92 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Minting an API key", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.CreateAPIKeyRequestBody
+	var storedKey entities.APIKey
+	var createCallCount int
+
+	BeforeEach(func() {
+		expiresAt := time.Now().Add(90 * 24 * time.Hour).UTC().Truncate(time.Second)
+		requestBody = &usecases.CreateAPIKeyRequestBody{
+			Name:      "matchmaking",
+			Owner:     "matchmaking-team",
+			Scopes:    []string{entities.APIKeyScopeUsersRead},
+			ExpiresAt: &expiresAt,
+		}
+		storedKey = entities.APIKey{}
+		createCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockAPIKeyStore.EXPECT().CreateAPIKey(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.APIKey{})).
+			DoAndReturn(func(_ context.Context, key entities.APIKey) error {
+				storedKey = key
+				return nil
+			}).Times(createCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/admin/api-keys", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should store a hash of the key and return the key once", func() {
+		Expect(w.Code).To(Equal(http.StatusCreated))
+
+		var response usecases.APIKeyResponse
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.ID).To(Equal(storedKey.ID))
+		Expect(strings.HasPrefix(response.Key, "uk_")).To(BeTrue())
+		Expect(response.Scopes).To(Equal([]string{entities.APIKeyScopeUsersRead}))
+
+		keyHash := sha256.Sum256([]byte(response.Key))
+		Expect(storedKey.SecretHash).To(Equal(hex.EncodeToString(keyHash[:])))
+		Expect(storedKey.Owner).To(Equal("matchmaking-team"))
+		Expect(storedKey.ExpiresAt).To(Equal(requestBody.ExpiresAt))
+	})
+
+	When("a scope isn't supported", func() {
+		BeforeEach(func() {
+			requestBody.Scopes = []string{"users:delete"}
+			createCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the expiry has already passed", func() {
+		BeforeEach(func() {
+			expiresAt := time.Now().Add(-time.Hour)
+			requestBody.ExpiresAt = &expiresAt
+			createCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
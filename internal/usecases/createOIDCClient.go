@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// CreateOIDCClientRequestBody represents the request body for registering an OpenID Connect client
+// @Description The client to register
+type CreateOIDCClientRequestBody struct {
+	// Name represents the name shown to users when asking for consent
+	Name string `json:"name" binding:"required"`
+	// RedirectURIs represents the URIs the client can be redirected to, they must be matched exactly
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	// AllowedScopes represents the scopes the client can ask for, all supported scopes if empty
+	AllowedScopes []string `json:"allowed_scopes"`
+	// Confidential is true for clients that can keep a secret, such as server-side web apps
+	Confidential bool `json:"confidential"`
+}
+
+// OIDCClientResponse represents a registered OpenID Connect client
+// @Description A client registered to sign users in
+type OIDCClientResponse struct {
+	// ClientID represents the client's identifier
+	ClientID string `json:"client_id"`
+	// ClientSecret represents the client's secret, it is only returned when the client is registered
+	ClientSecret string `json:"client_secret,omitempty"`
+	// Name represents the name shown to users when asking for consent
+	Name string `json:"name"`
+	// RedirectURIs represents the URIs the client can be redirected to
+	RedirectURIs []string `json:"redirect_uris"`
+	// AllowedScopes represents the scopes the client can ask for
+	AllowedScopes []string `json:"allowed_scopes"`
+	// CreatedAt represents the timestamp when the client was registered
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewCreateOIDCClient registers an OpenID Connect client
+// @Summary Register OpenID Connect client
+// @Description Registers a first-party client. Confidential clients are given a secret, which is only shown once.
+// @Tags oidc
+// @Accept json
+// @Produce json
+// @Param request body CreateOIDCClientRequestBody true "Create OIDC Client Request Body"
+// @Success 201 {object} OIDCClientResponse
+// @Failure 400
+// @Failure 500
+// @Router /admin/oidc/clients [post]
+func NewCreateOIDCClient(oidcStore OIDCStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request CreateOIDCClientRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		allowedScopes := request.AllowedScopes
+		if len(allowedScopes) == 0 {
+			allowedScopes = entities.OIDCSupportedScopes
+		}
+		for _, scope := range allowedScopes {
+			if !slices.Contains(entities.OIDCSupportedScopes, scope) {
+				slog.Warn("unsupported scope", "scope", scope)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+		}
+
+		client := entities.OIDCClient{
+			ID:            uuid.New().String(),
+			Name:          request.Name,
+			RedirectURIs:  request.RedirectURIs,
+			AllowedScopes: allowedScopes,
+			CreatedAt:     time.Now().UTC(),
+		}
+
+		var secret string
+		if request.Confidential {
+			secret, client.SecretHash, err = generateOpaqueToken()
+			if err != nil {
+				slog.Error("generating client secret", "err", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		err = oidcStore.CreateOIDCClient(c.Request.Context(), client)
+		if err != nil {
+			slog.Error("creating oidc client", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusCreated, OIDCClientResponse{
+			ClientID:      client.ID,
+			ClientSecret:  secret,
+			Name:          client.Name,
+			RedirectURIs:  client.RedirectURIs,
+			AllowedScopes: client.AllowedScopes,
+			CreatedAt:     client.CreatedAt,
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Registering an OpenID Connect client", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.CreateOIDCClientRequestBody
+	var storedClient entities.OIDCClient
+	var createCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.CreateOIDCClientRequestBody{
+			Name:         "FACEIT Forum",
+			RedirectURIs: []string{"https://forum.faceit.com/callback"},
+			Confidential: true,
+		}
+		storedClient = entities.OIDCClient{}
+		createCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockOIDCStore.EXPECT().CreateOIDCClient(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.OIDCClient{})).
+			DoAndReturn(func(_ context.Context, client entities.OIDCClient) error {
+				storedClient = client
+				return nil
+			}).Times(createCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/admin/oidc/clients", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should store the client with a hash of its secret and return the secret once", func() {
+		Expect(w.Code).To(Equal(http.StatusCreated))
+
+		var response usecases.OIDCClientResponse
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.ClientID).To(Equal(storedClient.ID))
+		Expect(response.ClientSecret).ToNot(BeEmpty())
+		Expect(response.AllowedScopes).To(Equal(entities.OIDCSupportedScopes))
+
+		secretHash := sha256.Sum256([]byte(response.ClientSecret))
+		Expect(storedClient.SecretHash).To(Equal(hex.EncodeToString(secretHash[:])))
+		Expect(storedClient.RedirectURIs).To(Equal([]string{"https://forum.faceit.com/callback"}))
+	})
+
+	When("the client is public", func() {
+		BeforeEach(func() {
+			requestBody.Confidential = false
+			requestBody.AllowedScopes = []string{"openid", "profile"}
+		})
+
+		It("should store the client without a secret", func() {
+			Expect(w.Code).To(Equal(http.StatusCreated))
+			Expect(storedClient.Public()).To(BeTrue())
+			Expect(storedClient.AllowedScopes).To(Equal([]string{"openid", "profile"}))
+
+			var response usecases.OIDCClientResponse
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.ClientSecret).To(BeEmpty())
+		})
+	})
+
+	When("a scope isn't supported", func() {
+		BeforeEach(func() {
+			requestBody.AllowedScopes = []string{"openid", "admin"}
+			createCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("a redirect URI isn't a URL", func() {
+		BeforeEach(func() {
+			requestBody.RedirectURIs = []string{"not a url"}
+			createCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"errors"
+	"fmt"
 	_ "github.com/AlecSmith96/faceit-user-service/docs"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
 	"github.com/gin-gonic/gin"
@@ -54,20 +55,28 @@ type CreateUserResponseBody struct {
 	CreatedAt time.Time `json:"created_at"`
 	// UpdatedAt represents the timestamp when the user was last updated
 	UpdatedAt time.Time `json:"updated_at"`
+	// Status represents the user's account status
+	Status string `json:"status"`
 }
 
 // NewCreateUser creates a new user
 // @Summary Create a new user
-// @Description Create a new user with the provided details
+// @Description Create a new user with the provided details, and email them a token to verify their email address.
+// @Description The password must satisfy the password policy and not have appeared in a known data breach.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body CreateUserRequestBody true "Create User Request Body"
 // @Success 200 {object} CreateUserResponseBody
-// @Failure 400
+// @Failure 400 {object} PasswordRejectedResponseBody
 // @Failure 500
 // @Router /user [post]
-func NewCreateUser(userCreator UserCreator, changelogWriter ChangelogWriter) gin.HandlerFunc {
+func NewCreateUser(
+	userCreator UserCreator,
+	changelogWriter ChangelogWriter,
+	verificationEmailSender *VerificationEmailSender,
+	passwordValidator *PasswordValidator,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request CreateUserRequestBody
 		err := c.ShouldBindJSON(&request)
@@ -77,16 +86,14 @@ func NewCreateUser(userCreator UserCreator, changelogWriter ChangelogWriter) gin
 			return
 		}
 
-		user, err := userCreator.CreateUser(
-			c.Request.Context(),
-			request.FirstName,
-			request.LastName,
-			request.Nickname,
-			request.Password,
-			request.Email,
-			request.Country,
-		)
+		user, err := createUser(c.Request.Context(), userCreator, changelogWriter, verificationEmailSender, passwordValidator, request)
 		if err != nil {
+			var policyErr *entities.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				respondPasswordInvalid(c, err)
+				return
+			}
+
 			if errors.Is(err, entities.ErrEmailAlreadyUsed) {
 				slog.Warn("email already registered to a user", "err", err)
 				c.Status(http.StatusBadRequest)
@@ -98,17 +105,6 @@ func NewCreateUser(userCreator UserCreator, changelogWriter ChangelogWriter) gin
 			return
 		}
 
-		entry := entities.ChangelogEntry{
-			UserID:     user.ID,
-			CreatedAt:  user.CreatedAt,
-			ChangeType: "POST",
-		}
-		err = changelogWriter.PublishChangelogEntry(entry)
-		if err != nil {
-			// deliberately not returning error here as request didn't fail
-			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
-		}
-
 		c.JSON(http.StatusOK, CreateUserResponseBody{
 			ID:        user.ID.String(),
 			FirstName: user.FirstName,
@@ -119,6 +115,58 @@ func NewCreateUser(userCreator UserCreator, changelogWriter ChangelogWriter) gin
 			Country:   user.Country,
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
+			Status:    string(user.Status),
 		})
 	}
 }
+
+// createUser creates a user from a validated request, publishing its changelog entry and sending the verification
+// email. It is shared by every way of creating a single user, so they all apply the same checks.
+func createUser(
+	ctx context.Context,
+	userCreator UserCreator,
+	changelogWriter ChangelogWriter,
+	verificationEmailSender *VerificationEmailSender,
+	passwordValidator *PasswordValidator,
+	request CreateUserRequestBody,
+) (*entities.User, error) {
+	err := passwordValidator.Validate(ctx, request.Password, request.Nickname, request.Email)
+	if err != nil {
+		return nil, fmt.Errorf("validating password: %w", err)
+	}
+
+	user, err := userCreator.CreateUser(
+		ctx,
+		request.FirstName,
+		request.LastName,
+		request.Nickname,
+		request.Password,
+		request.Email,
+		request.Country,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := entities.ChangelogEntry{
+		UserID:     user.ID,
+		CreatedAt:  user.CreatedAt,
+		ChangeType: "POST",
+	}
+	err = changelogWriter.PublishChangelogEntry(entry)
+	if err != nil {
+		// deliberately not returning error here as request didn't fail
+		slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+	}
+
+	// storage backends without verification tokens don't send verification emails
+	if verificationEmailSender != nil {
+		err = verificationEmailSender.Send(ctx, *user)
+		if err != nil {
+			// deliberately not returning error here as the user can request another verification email
+			slog.Error("sending verification email", "err", err, "userID", user.ID)
+		}
+	}
+
+	return user, nil
+}
@@ -12,6 +12,7 @@ import (
 	"go.uber.org/mock/gomock"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"time"
 )
 
@@ -25,6 +26,10 @@ var _ = Describe("Creating a user", func() {
 
 	var changelogWriterErr error
 	var changelogWriterCallCount int
+	var sendMailErr error
+	var verificationEmailCallCount int
+	var breachCount int
+	var breachLookupCallCount int
 
 	BeforeEach(func() {
 		requestBody = &usecases.CreateUserRequestBody{
@@ -53,6 +58,10 @@ var _ = Describe("Creating a user", func() {
 
 		changelogWriterErr = nil
 		changelogWriterCallCount = 1
+		sendMailErr = nil
+		verificationEmailCallCount = 1
+		breachCount = 0
+		breachLookupCallCount = 1
 	})
 
 	JustBeforeEach(func() {
@@ -60,6 +69,7 @@ var _ = Describe("Creating a user", func() {
 		requestBodyJSON, err := json.Marshal(requestBody)
 		Expect(err).ToNot(HaveOccurred())
 
+		expectBreachLookup(mockBreachCorpus, requestBody.Password, breachCount, breachLookupCallCount)
 		mockUserCreator.EXPECT().CreateUser(
 			gomock.AssignableToTypeOf(ctxType),
 			requestBody.FirstName,
@@ -76,6 +86,25 @@ var _ = Describe("Creating a user", func() {
 			ChangeType: "POST",
 		}).Return(changelogWriterErr).Times(changelogWriterCallCount)
 
+		mockEmailVerificationStore.EXPECT().CreateVerificationToken(
+			gomock.AssignableToTypeOf(ctxType),
+			gomock.Cond(func(x any) bool {
+				token := x.(entities.VerificationToken)
+				return token.UserID == createUserResponse.ID &&
+					token.Email == createUserResponse.Email &&
+					token.Purpose == entities.TokenPurposeEmailVerification
+			}),
+		).Return(nil).Times(verificationEmailCallCount)
+		mockTokenSigner.EXPECT().SignToken(gomock.AssignableToTypeOf(entities.VerificationToken{})).
+			Return("signed-token", nil).Times(verificationEmailCallCount)
+		mockMailer.EXPECT().SendMail(
+			gomock.AssignableToTypeOf(ctxType),
+			gomock.Cond(func(x any) bool {
+				email := x.(entities.Email)
+				return email.To == createUserResponse.Email && strings.Contains(email.Body, "signed-token")
+			}),
+		).Return(sendMailErr).Times(verificationEmailCallCount)
+
 		req, err := http.NewRequest("POST", "http://localhost:8080/user", bytes.NewReader(requestBodyJSON))
 		Expect(err).ToNot(HaveOccurred())
 		r.ServeHTTP(w, req)
@@ -102,6 +131,8 @@ var _ = Describe("Creating a user", func() {
 			requestBody = &usecases.CreateUserRequestBody{}
 			createUserCallCount = 0
 			changelogWriterCallCount = 0
+			verificationEmailCallCount = 0
+			breachLookupCallCount = 0
 		})
 
 		It("should return a 400 Bad Request", func() {
@@ -109,10 +140,52 @@ var _ = Describe("Creating a user", func() {
 		})
 	})
 
+	When("the password has appeared in a breach", func() {
+		BeforeEach(func() {
+			breachCount = 42
+			createUserCallCount = 0
+			changelogWriterCallCount = 0
+			verificationEmailCallCount = 0
+		})
+
+		It("should return a 400 Bad Request with the rule it broke", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+			var response usecases.PasswordRejectedResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Error).To(Equal("password_rejected"))
+			Expect(response.Violations).To(Equal([]string{entities.PasswordRuleBreached}))
+		})
+	})
+
+	When("the password breaks the password policy", func() {
+		BeforeEach(func() {
+			requestBody.Password = "alecsmith"
+			createUserCallCount = 0
+			changelogWriterCallCount = 0
+			verificationEmailCallCount = 0
+		})
+
+		It("should return a 400 Bad Request with every rule it broke", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+			var response usecases.PasswordRejectedResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Violations).To(Equal([]string{
+				entities.PasswordRuleTooShort,
+				entities.PasswordRuleContainsNickname,
+				entities.PasswordRuleContainsEmail,
+			}))
+		})
+	})
+
 	When("the userCreator adapter returns ErrEmailAlreadyUsed", func() {
 		BeforeEach(func() {
 			createUserErr = entities.ErrEmailAlreadyUsed
 			changelogWriterCallCount = 0
+			verificationEmailCallCount = 0
 		})
 
 		It("should return a 400 Bad Request", func() {
@@ -124,6 +197,7 @@ var _ = Describe("Creating a user", func() {
 		BeforeEach(func() {
 			createUserErr = errors.New("an error occurred")
 			changelogWriterCallCount = 0
+			verificationEmailCallCount = 0
 		})
 
 		It("should return a 500 Internal Server Error", func() {
@@ -140,4 +214,14 @@ var _ = Describe("Creating a user", func() {
 			Expect(w.Code).To(Equal(http.StatusOK))
 		})
 	})
+
+	When("the verification email fails to send", func() {
+		BeforeEach(func() {
+			sendMailErr = errors.New("an error occurred")
+		})
+
+		It("should still return the created user", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
 })
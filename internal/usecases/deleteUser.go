@@ -38,7 +38,7 @@ func NewDeleteUser(userDeleter UserDeleter, changelogWriter ChangelogWriter) gin
 			return
 		}
 
-		err = userDeleter.DeleteUser(c.Request.Context(), userIDUUID)
+		err = deleteUser(c.Request.Context(), userDeleter, changelogWriter, userIDUUID)
 		if err != nil {
 			if errors.Is(err, entities.ErrUserNotFound) {
 				slog.Warn("user not found", "err", err)
@@ -51,17 +51,27 @@ func NewDeleteUser(userDeleter UserDeleter, changelogWriter ChangelogWriter) gin
 			return
 		}
 
-		entry := entities.ChangelogEntry{
-			UserID:     userIDUUID,
-			CreatedAt:  time.Now(),
-			ChangeType: "DELETE",
-		}
-		err = changelogWriter.PublishChangelogEntry(entry)
-		if err != nil {
-			// deliberately not returning error here as request didn't fail
-			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
-		}
-
 		c.Status(http.StatusOK)
 	}
 }
+
+// deleteUser deletes a user and publishes the changelog entry, it is shared by every way of deleting a single user
+func deleteUser(ctx context.Context, userDeleter UserDeleter, changelogWriter ChangelogWriter, userID uuid.UUID) error {
+	err := userDeleter.DeleteUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	entry := entities.ChangelogEntry{
+		UserID:     userID,
+		CreatedAt:  time.Now(),
+		ChangeType: "DELETE",
+	}
+	err = changelogWriter.PublishChangelogEntry(entry)
+	if err != nil {
+		// deliberately not returning error here as request didn't fail
+		slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package usecases_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Disabling TOTP", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.MFACodeRequestBody
+	var userID uuid.UUID
+	var secret []byte
+
+	var enrolment *entities.TOTPEnrolment
+	var getEnrolmentErr error
+	var getEnrolmentCallCount int
+	var openSecretCallCount int
+	var useCounterCallCount int
+	var disableCallCount int
+	var changelogWriterCallCount int
+	var accountThrottle *entities.LoginThrottle
+	var codeCheckCallCount int
+	var codeFailureCallCount int
+
+	BeforeEach(func() {
+		secret = []byte("12345678901234567890")
+		requestBody = &usecases.MFACodeRequestBody{Code: currentTOTPCode(secret)}
+		userID = uuid.New()
+
+		confirmedAt := time.Now().UTC()
+		enrolment = &entities.TOTPEnrolment{UserID: userID, EncryptedSecret: "sealed-secret", ConfirmedAt: &confirmedAt}
+		getEnrolmentErr = nil
+		getEnrolmentCallCount = 1
+		openSecretCallCount = 1
+		useCounterCallCount = 1
+		disableCallCount = 1
+		changelogWriterCallCount = 1
+		accountThrottle = &entities.LoginThrottle{}
+		codeCheckCallCount = 1
+		codeFailureCallCount = 0
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		expectUserAccessToken(userID)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(&entities.User{ID: userID, Email: "alec@email.com"}, nil).Times(1)
+		expectThrottledCheck(userID, accountThrottle, entities.LoginFailureInvalidMFACode, codeCheckCallCount, codeFailureCallCount)
+		mockMFAStore.EXPECT().GetTOTPEnrolment(gomock.AssignableToTypeOf(ctxType), userID).Return(enrolment, getEnrolmentErr).Times(getEnrolmentCallCount)
+		mockSecretBox.EXPECT().Open("sealed-secret", userID[:]).Return(secret, nil).Times(openSecretCallCount)
+		mockMFAStore.EXPECT().UseTOTPCounter(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf(int64(0))).
+			Return(nil).Times(useCounterCallCount)
+		mockMFAStore.EXPECT().DisableTOTP(gomock.AssignableToTypeOf(ctxType), userID).Return(nil).Times(disableCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			Do(func(entry entities.ChangelogEntry) {
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeMFADisabled))
+			}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/user/%s/mfa/totp/disable", userID), bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should disable MFA", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the code is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.Code = usecases.TOTPCode(secret, time.Now().Unix()/30+10)
+			useCounterCallCount = 0
+			disableCallCount = 0
+			changelogWriterCallCount = 0
+			codeFailureCallCount = 1
+		})
+
+		It("should return a 401 Unauthorized and count the failure", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the account is throttled after failed logins", func() {
+		BeforeEach(func() {
+			blockedUntil := time.Now().Add(time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 4, BlockedUntil: &blockedUntil}
+			getEnrolmentCallCount = 0
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			disableCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the code", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).ToNot(BeEmpty())
+		})
+	})
+
+	When("TOTP enrolment hasn't been confirmed", func() {
+		BeforeEach(func() {
+			enrolment.ConfirmedAt = nil
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			disableCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the user has no TOTP enrolment", func() {
+		BeforeEach(func() {
+			getEnrolmentErr = entities.ErrMFANotEnrolled
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			disableCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/emailChangeStore.go  . "EmailChangeStore"
+type EmailChangeStore interface {
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string, confirmToken, revertToken entities.VerificationToken) (*entities.User, error)
+	ConfirmEmailChange(ctx context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error)
+	RevertEmailChange(ctx context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error)
+}
+
+// EmailChanger starts email changes, which only take effect once confirmed from the new address
+type EmailChanger struct {
+	store      EmailChangeStore
+	signer     TokenSigner
+	mailer     Mailer
+	confirmTTL time.Duration
+	revertTTL  time.Duration
+	confirmURL string
+	revertURL  string
+}
+
+func NewEmailChanger(
+	store EmailChangeStore,
+	signer TokenSigner,
+	mailer Mailer,
+	confirmTTL time.Duration,
+	revertTTL time.Duration,
+	confirmURL string,
+	revertURL string,
+) *EmailChanger {
+	return &EmailChanger{
+		store:      store,
+		signer:     signer,
+		mailer:     mailer,
+		confirmTTL: confirmTTL,
+		revertTTL:  revertTTL,
+		confirmURL: confirmURL,
+		revertURL:  revertURL,
+	}
+}
+
+// RequestChange records newEmail as the user's pending email, then mails a confirmation token to the new address and
+// a notice with a revert token to the current one
+func (e *EmailChanger) RequestChange(ctx context.Context, user entities.User, newEmail string) (*entities.User, error) {
+	now := time.Now()
+	confirmToken := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     newEmail,
+		Purpose:   entities.TokenPurposeEmailChange,
+		ExpiresAt: now.Add(e.confirmTTL).UTC(),
+	}
+	revertToken := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		Purpose:   entities.TokenPurposeEmailChangeRevert,
+		ExpiresAt: now.Add(e.revertTTL).UTC(),
+	}
+
+	signedConfirmToken, err := e.signer.SignToken(confirmToken)
+	if err != nil {
+		return nil, fmt.Errorf("signing email change token: %w", err)
+	}
+
+	signedRevertToken, err := e.signer.SignToken(revertToken)
+	if err != nil {
+		return nil, fmt.Errorf("signing email change revert token: %w", err)
+	}
+
+	updatedUser, err := e.store.RequestEmailChange(ctx, user.ID, newEmail, confirmToken, revertToken)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.mailer.SendMail(ctx, entities.Email{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nTo finish changing your email address to this one, submit the following token to %s before %s:\n\n%s\n",
+			user.Nickname,
+			e.confirmURL,
+			confirmToken.ExpiresAt.Format(time.RFC1123),
+			signedConfirmToken,
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sending email change confirmation: %w", err)
+	}
+
+	err = e.mailer.SendMail(ctx, entities.Email{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nA request was made to change the email address on your account to %s. If this wasn't you, submit the following token to %s before %s to keep this address:\n\n%s\n",
+			user.Nickname,
+			newEmail,
+			e.revertURL,
+			revertToken.ExpiresAt.Format(time.RFC1123),
+			signedRevertToken,
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sending email change notice: %w", err)
+	}
+
+	return updatedUser, nil
+}
@@ -0,0 +1,89 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/mailer.go  . "Mailer"
+type Mailer interface {
+	SendMail(ctx context.Context, email entities.Email) error
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/tokenSigner.go  . "TokenSigner"
+type TokenSigner interface {
+	SignToken(token entities.VerificationToken) (string, error)
+	ParseToken(signedToken string) (*entities.VerificationToken, error)
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/emailVerificationStore.go  . "EmailVerificationStore"
+type EmailVerificationStore interface {
+	CreateVerificationToken(ctx context.Context, token entities.VerificationToken) error
+	VerifyEmail(ctx context.Context, token entities.VerificationToken, now time.Time) (*entities.User, error)
+}
+
+// VerificationEmailSender issues email verification tokens and mails them to users
+type VerificationEmailSender struct {
+	store           EmailVerificationStore
+	signer          TokenSigner
+	mailer          Mailer
+	tokenTTL        time.Duration
+	verificationURL string
+}
+
+func NewVerificationEmailSender(
+	store EmailVerificationStore,
+	signer TokenSigner,
+	mailer Mailer,
+	tokenTTL time.Duration,
+	verificationURL string,
+) *VerificationEmailSender {
+	return &VerificationEmailSender{
+		store:           store,
+		signer:          signer,
+		mailer:          mailer,
+		tokenTTL:        tokenTTL,
+		verificationURL: verificationURL,
+	}
+}
+
+// Send stores a new single-use verification token for the user's current email and mails it to them
+func (s *VerificationEmailSender) Send(ctx context.Context, user entities.User) error {
+	token := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		Purpose:   entities.TokenPurposeEmailVerification,
+		ExpiresAt: time.Now().Add(s.tokenTTL).UTC(),
+	}
+
+	err := s.store.CreateVerificationToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("storing verification token: %w", err)
+	}
+
+	signedToken, err := s.signer.SignToken(token)
+	if err != nil {
+		return fmt.Errorf("signing verification token: %w", err)
+	}
+
+	err = s.mailer.SendMail(ctx, entities.Email{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease verify your email address by submitting the following token to %s before %s:\n\n%s\n",
+			user.Nickname,
+			s.verificationURL,
+			token.ExpiresAt.Format(time.RFC1123),
+			signedToken,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("sending verification email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// EnrolTOTPRequestBody represents the request body for starting TOTP enrolment
+// @Description The user's current password
+type EnrolTOTPRequestBody struct {
+	// Password represents the user's current password
+	Password string `json:"password" binding:"required"`
+}
+
+// EnrolTOTPResponseBody represents the response body for starting TOTP enrolment
+// @Description The secret to add to an authenticator app
+type EnrolTOTPResponseBody struct {
+	// Secret represents the base32 encoded TOTP secret, for entering manually
+	Secret string `json:"secret"`
+	// OTPAuthURI represents the otpauth URI, for rendering as a QR code
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// NewEnrolTOTP starts TOTP enrolment for a user
+// @Summary Enrol TOTP
+// @Description Generates a TOTP secret for the user. MFA isn't enabled until the enrolment is confirmed with a first
+// @Description code, and enrolling again before then replaces the secret.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param request body EnrolTOTPRequestBody true "Enrol TOTP Request Body"
+// @Success 201 {object} EnrolTOTPResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 409
+// @Failure 429
+// @Failure 500
+// @Router /user/{userId}/mfa/totp [post]
+func NewEnrolTOTP(userGetter UserGetter, mfaManager *MFAManager, loginThrottler *LoginThrottler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request EnrolTOTPRequestBody
+		err = c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if rejectIncorrectPassword(c, loginThrottler, *user, request.Password) {
+			return
+		}
+
+		secret, uri, err := mfaManager.Enrol(c.Request.Context(), *user)
+		if err != nil {
+			if errors.Is(err, entities.ErrMFAAlreadyEnabled) {
+				slog.Warn("mfa already enabled", "userID", userIDUUID)
+				c.Status(http.StatusConflict)
+				return
+			}
+
+			slog.Error("enrolling totp", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusCreated, EnrolTOTPResponseBody{
+			Secret:     secret,
+			OTPAuthURI: uri,
+		})
+	}
+}
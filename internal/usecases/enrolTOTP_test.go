@@ -0,0 +1,179 @@
+package usecases_test
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+)
+
+var _ = Describe("Enrolling TOTP", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.EnrolTOTPRequestBody
+	var userID string
+
+	var user *entities.User
+	var getUserErr error
+	var getUserCallCount int
+
+	var sealedSecret []byte
+	var sealCallCount int
+	var enrolErr error
+	var enrolCallCount int
+
+	var accountThrottle *entities.LoginThrottle
+	var passwordCheckCallCount int
+	var passwordFailureCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.EnrolTOTPRequestBody{Password: "some-password"}
+		userID = uuid.New().String()
+
+		user = &entities.User{
+			ID:       uuid.MustParse(userID),
+			Email:    "alec@email.com",
+			Password: "some-password",
+			Status:   entities.UserStatusActive,
+		}
+		getUserErr = nil
+		getUserCallCount = 1
+
+		sealedSecret = nil
+		sealCallCount = 1
+		enrolErr = nil
+		enrolCallCount = 1
+
+		accountThrottle = &entities.LoginThrottle{}
+		passwordCheckCallCount = 1
+		passwordFailureCallCount = 0
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, getUserErr).Times(getUserCallCount)
+		mockSecretBox.EXPECT().Seal(gomock.AssignableToTypeOf([]byte{}), user.ID[:]).
+			DoAndReturn(func(plaintext, _ []byte) (string, error) {
+				sealedSecret = plaintext
+				return "sealed-secret", nil
+			}).Times(sealCallCount)
+		mockMFAStore.EXPECT().EnrolTOTP(gomock.AssignableToTypeOf(ctxType), user.ID, "sealed-secret", gomock.AssignableToTypeOf(time.Time{})).
+			Return(enrolErr).Times(enrolCallCount)
+		expectPasswordCheck(user.ID, accountThrottle, passwordCheckCallCount, passwordFailureCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/user/%s/mfa/totp", userID), bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should store the encrypted secret and return it with its otpauth URI", func() {
+		Expect(w.Code).To(Equal(http.StatusCreated))
+
+		var response usecases.EnrolTOTPResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+
+		secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(response.Secret)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret).To(HaveLen(20))
+		Expect(secret).To(Equal(sealedSecret))
+
+		uri, err := url.Parse(response.OTPAuthURI)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uri.Scheme).To(Equal("otpauth"))
+		Expect(uri.Host).To(Equal("totp"))
+		Expect(uri.Path).To(Equal("/FACEIT:alec@email.com"))
+		Expect(uri.Query().Get("secret")).To(Equal(response.Secret))
+		Expect(uri.Query().Get("issuer")).To(Equal("FACEIT"))
+		Expect(uri.Query().Get("digits")).To(Equal("6"))
+		Expect(uri.Query().Get("period")).To(Equal("30"))
+	})
+
+	When("the password is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.Password = "wrong-password"
+			sealCallCount = 0
+			enrolCallCount = 0
+			passwordFailureCallCount = 1
+		})
+
+		It("should return a 401 Unauthorized and count the failure against the account", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the account is throttled after failed logins", func() {
+		BeforeEach(func() {
+			blockedUntil := time.Now().Add(time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 4, BlockedUntil: &blockedUntil}
+			sealCallCount = 0
+			enrolCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the password", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).ToNot(BeEmpty())
+		})
+	})
+
+	When("MFA is already enabled", func() {
+		BeforeEach(func() {
+			enrolErr = entities.ErrMFAAlreadyEnabled
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	When("the user doesn't exist", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			sealCallCount = 0
+			enrolCallCount = 0
+			passwordCheckCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userID isnt a valid uuid", func() {
+		BeforeEach(func() {
+			userID = "invalid-uuid"
+			getUserCallCount = 0
+			sealCallCount = 0
+			enrolCallCount = 0
+			passwordCheckCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the mfaStore adapter returns generic error", func() {
+		BeforeEach(func() {
+			enrolErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
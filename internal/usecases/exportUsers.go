@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// ExportUsersRequest holds the query parameters for exporting users
+type ExportUsersRequest struct {
+	// Format represents the file's format, csv, ndjson or parquet
+	Format string `form:"format" binding:"required,oneof=csv ndjson parquet"`
+	// Columns represents a comma separated list of the columns to export, every column is exported if it is empty
+	Columns string `form:"columns"`
+	// FirstName represents the user's first name
+	FirstName string `form:"first_name"`
+	// LastName represents the user's last name
+	LastName string `form:"last_name"`
+	// Nickname represents the user's nickname
+	Nickname string `form:"nickname"`
+	// Email represents the user's email address
+	Email string `form:"email"`
+	// Country represents the user's country
+	Country string `form:"country"`
+}
+
+// NewExportUsers exports users in bulk
+// @Summary Export users
+// @Description Streams every user matching the same filters as GET /users, in creation order, as a CSV file with a
+// @Description header row, an NDJSON file with one user per line or a Parquet file. Passwords are never exported. If
+// @Description the export fails part way through the connection is dropped rather than the file being finished.
+// @Tags users
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.apache.parquet
+// @Param format query string true "File format" Enums(csv, ndjson, parquet)
+// @Param columns query string false "Comma separated columns to export"
+// @Param first_name query string false "First name"
+// @Param last_name query string false "Last name"
+// @Param nickname query string false "Nickname"
+// @Param email query string false "Email"
+// @Param country query string false "Country"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /users/export [get]
+func NewExportUsers(userExporter UserExporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ExportUsersRequest
+		err := c.ShouldBindQuery(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		columns, err := parseExportColumns(request.Columns)
+		if err != nil {
+			slog.Warn("invalid export columns", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		writer, contentType, extension := newUserExportWriter(request.Format, columns, c.Writer)
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="users.`+extension+`"`)
+
+		exported := 0
+		err = userExporter.ExportUsers(
+			c.Request.Context(),
+			request.FirstName,
+			request.LastName,
+			request.Nickname,
+			request.Email,
+			request.Country,
+			func(user entities.User) error {
+				exported++
+				return writer.Write(user)
+			},
+		)
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			if !c.Writer.Written() {
+				slog.Error("exporting users", "err", err)
+				c.Header("Content-Type", "")
+				c.Header("Content-Disposition", "")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+
+			// the status has already been sent, so dropping the connection is the only way left to tell the client
+			// the file is incomplete
+			slog.Error("exporting users, dropping connection", "err", err, "exportedUsers", exported)
+			c.Abort()
+			// the users already written are flushed first so the client gets everything up to the failure
+			c.Writer.Flush()
+			conn, _, err := c.Writer.Hijack()
+			if err != nil {
+				slog.Error("unable to drop connection", "err", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,248 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/parquet-go/parquet-go"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Exporting users", func() {
+	var w *httptest.ResponseRecorder
+	var query string
+	var users []entities.User
+	var exportUsersErr error
+	var exportUsersCallCount int
+	var receivedFilters []string
+
+	BeforeEach(func() {
+		query = "?format=csv"
+		bannedUntil := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+		users = []entities.User{
+			{
+				ID:        uuid.New(),
+				FirstName: "alec",
+				LastName:  "smith",
+				Nickname:  "alecsmith",
+				Password:  "some-password",
+				Email:     "alec@email.com",
+				Country:   "UK",
+				CreatedAt: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+				UpdatedAt: time.Date(2024, 7, 2, 9, 0, 0, 0, time.UTC),
+				Status:    entities.UserStatusActive,
+			},
+			{
+				ID:           uuid.New(),
+				FirstName:    "jane",
+				LastName:     "doe",
+				Nickname:     "janedoe",
+				Password:     "some-password",
+				Email:        "jane@email.com",
+				Country:      "UK",
+				CreatedAt:    time.Date(2024, 7, 3, 9, 0, 0, 0, time.UTC),
+				UpdatedAt:    time.Date(2024, 7, 4, 9, 0, 0, 0, time.UTC),
+				Status:       entities.UserStatusBanned,
+				BanReason:    "cheating",
+				BanExpiresAt: &bannedUntil,
+			},
+		}
+		exportUsersErr = nil
+		exportUsersCallCount = 1
+		receivedFilters = nil
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserExporter.EXPECT().ExportUsers(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, firstName, lastName, nickname, email, country string, each func(entities.User) error) error {
+				receivedFilters = []string{firstName, lastName, nickname, email, country}
+				for _, user := range users {
+					err := each(user)
+					if err != nil {
+						return err
+					}
+				}
+				return exportUsersErr
+			}).Times(exportUsersCallCount)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/users/export"+query, nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should stream every column except the password as CSV", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("text/csv"))
+		Expect(w.Header().Get("Content-Disposition")).To(Equal(`attachment; filename="users.csv"`))
+		Expect(receivedFilters).To(Equal([]string{"", "", "", "", ""}))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(Equal([][]string{
+			entities.ExportColumns,
+			{users[0].ID.String(), "alec", "smith", "alecsmith", "alec@email.com", "UK", "2024-07-01T09:00:00Z", "2024-07-02T09:00:00Z", "active", "", "", "", ""},
+			{users[1].ID.String(), "jane", "doe", "janedoe", "jane@email.com", "UK", "2024-07-03T09:00:00Z", "2024-07-04T09:00:00Z", "banned", "cheating", "2024-08-01T00:00:00Z", "", ""},
+		}))
+	})
+
+	When("there are no matching users", func() {
+		BeforeEach(func() {
+			users = nil
+		})
+
+		It("should still write the header row", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(Equal(strings.Join(entities.ExportColumns, ",") + "\n"))
+		})
+	})
+
+	When("the format is ndjson with filters and columns", func() {
+		BeforeEach(func() {
+			query = "?format=ndjson&columns=id,email,ban_expires_at&first_name=alec&nickname=smith&country=UK"
+		})
+
+		It("should pass the filters on and write only those columns in order", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+			Expect(receivedFilters).To(Equal([]string{"alec", "", "smith", "", "UK"}))
+			Expect(w.Body.String()).To(Equal(
+				`{"id":"` + users[0].ID.String() + `","email":"alec@email.com","ban_expires_at":null}` + "\n" +
+					`{"id":"` + users[1].ID.String() + `","email":"jane@email.com","ban_expires_at":"2024-08-01T00:00:00Z"}` + "\n",
+			))
+
+			var line map[string]any
+			err := json.Unmarshal([]byte(strings.Split(w.Body.String(), "\n")[0]), &line)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("the format is parquet", func() {
+		BeforeEach(func() {
+			query = "?format=parquet&columns=id,created_at,ban_reason"
+		})
+
+		It("should write a parquet file with the columns", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Header().Get("Content-Type")).To(Equal("application/vnd.apache.parquet"))
+
+			reader := parquet.NewReader(bytes.NewReader(w.Body.Bytes()))
+			Expect(reader.NumRows()).To(Equal(int64(2)))
+
+			rows := []map[string]any{}
+			for {
+				row := map[string]any{}
+				err := reader.Read(&row)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				rows = append(rows, row)
+			}
+			Expect(rows).To(HaveLen(2))
+			Expect(rows[0]).To(HaveKeyWithValue("id", users[0].ID.String()))
+			Expect(rows[0]).To(HaveKeyWithValue("ban_reason", BeNil()))
+			Expect(rows[0]).To(HaveKeyWithValue("created_at", users[0].CreatedAt.UnixMicro()))
+			Expect(rows[1]).To(HaveKeyWithValue("ban_reason", "cheating"))
+		})
+	})
+
+	When("the format is missing", func() {
+		BeforeEach(func() {
+			query = "?columns=id"
+			exportUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("a column isn't known", func() {
+		BeforeEach(func() {
+			query = "?format=csv&columns=id,password"
+			exportUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userExporter adapter returns an error before any users are written", func() {
+		BeforeEach(func() {
+			users = nil
+			exportUsersErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+			Expect(w.Header().Get("Content-Disposition")).To(BeEmpty())
+			Expect(w.Body.String()).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Exporting users when the export fails part way through", func() {
+	BeforeEach(func() {
+		mockUserExporter.EXPECT().ExportUsers(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _, _, _, _ string, each func(entities.User) error) error {
+				err := each(entities.User{ID: uuid.New(), FirstName: "alec"})
+				if err != nil {
+					return err
+				}
+				return errors.New("an error occurred")
+			}).Times(1)
+	})
+
+	It("should drop the connection rather than finish the response", func() {
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		response, err := http.Get(server.URL + "/users/export?format=ndjson")
+		Expect(err).ToNot(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+		body, err := io.ReadAll(response.Body)
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+		Expect(string(body)).To(HavePrefix(`{"id":`))
+	})
+})
+
+var _ = Describe("Writing an export without the API", func() {
+	It("should write the users matching the filters in the format", func() {
+		userID := uuid.New()
+		mockUserExporter.EXPECT().ExportUsers(gomock.AssignableToTypeOf(ctxType), "", "", "", "", "UK", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _, _, _, _ string, each func(entities.User) error) error {
+				return each(entities.User{ID: userID, Nickname: "alecsmith", Password: "some-password"})
+			}).Times(1)
+
+		var out bytes.Buffer
+		err := usecases.WriteUserExport(context.Background(), mockUserExporter, "ndjson", "id,nickname", "", "", "", "", "UK", &out)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out.String()).To(Equal(`{"id":"` + userID.String() + `","nickname":"alecsmith"}` + "\n"))
+	})
+
+	It("should reject unknown formats and columns before exporting", func() {
+		var out bytes.Buffer
+		err := usecases.WriteUserExport(context.Background(), mockUserExporter, "xml", "", "", "", "", "", "", &out)
+		Expect(err).To(MatchError(`unknown export format "xml"`))
+
+		err = usecases.WriteUserExport(context.Background(), mockUserExporter, "csv", "password", "", "", "", "", "", &out)
+		Expect(err).To(MatchError(`unknown column "password"`))
+	})
+})
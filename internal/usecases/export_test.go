@@ -0,0 +1,7 @@
+package usecases
+
+// exported for tests so that they can produce valid codes for a secret
+var (
+	TOTPCode   = totpCode
+	TOTPPeriod = totpPeriod
+)
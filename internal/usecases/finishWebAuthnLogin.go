@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// FinishWebAuthnLoginRequestBody represents the request body for finishing a passkey login
+// @Description The authenticator's response to the login options
+type FinishWebAuthnLoginRequestBody struct {
+	// CeremonyID represents the ceremony returned when the login began
+	CeremonyID string `json:"ceremony_id" binding:"required"`
+	// Credential represents the PublicKeyCredential returned by navigator.credentials.get()
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+	// DeviceName represents a name for the device logging in, shown when the user lists their sessions
+	DeviceName string `json:"device_name"`
+}
+
+// NewFinishWebAuthnLogin finishes a passkey login
+// @Summary Finish WebAuthn login
+// @Description Checks the authenticator's response and returns the tokens for a new session. Passkeys verify the user
+// @Description themselves, so no MFA challenge follows.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body FinishWebAuthnLoginRequestBody true "Finish WebAuthn Login Request Body"
+// @Success 200 {object} LoginResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /auth/webauthn/login/finish [post]
+func NewFinishWebAuthnLogin(userGetter UserGetter, webAuthnManager *WebAuthnManager, sessionIssuer *SessionIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request FinishWebAuthnLoginRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		ceremonyID, err := uuid.Parse(request.CeremonyID)
+		if err != nil {
+			slog.Warn("invalid ceremonyID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userID, err := webAuthnManager.FinishLogin(c.Request.Context(), ceremonyID, request.Credential)
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) || errors.Is(err, entities.ErrWebAuthnFailed) {
+				slog.Warn("webauthn login failed", "err", err)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("finishing webauthn login", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if !user.Status.CanLogIn() {
+			slog.Warn("login for user that can't log in", "userID", user.ID, "status", user.Status)
+			c.Status(http.StatusForbidden)
+			return
+		}
+
+		response, err := sessionIssuer.Issue(c.Request.Context(), *user, sessionDevice(c, request.DeviceName))
+		if err != nil {
+			slog.Error("issuing session", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
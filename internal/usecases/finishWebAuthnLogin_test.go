@@ -0,0 +1,176 @@
+package usecases_test
+
+import (
+	"bytes"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Finishing a WebAuthn login", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.FinishWebAuthnLoginRequestBody
+
+	var user *entities.User
+	var authenticator *softwareAuthenticator
+	var ceremony *entities.WebAuthnCeremony
+	var redeemCeremonyErr error
+	var answeredChallenge string
+
+	var storedCredential entities.WebAuthnCredential
+	var getCredentialsCallCount int
+	var updateUsageCallCount int
+
+	var getUserCallCount int
+	var sessionCallCount int
+
+	BeforeEach(func() {
+		user = &entities.User{
+			ID:     uuid.New(),
+			Email:  "alec@email.com",
+			Status: entities.UserStatusActive,
+		}
+
+		authenticator = newSoftwareAuthenticator(user.ID)
+		authenticator.signCount = 5
+		storedCredential = entities.WebAuthnCredential{
+			ID:              uuid.New(),
+			UserID:          user.ID,
+			CredentialID:    authenticator.credentialID,
+			PublicKey:       authenticator.publicKey(),
+			AttestationType: "none",
+			Transports:      []string{"internal"},
+			SignCount:       5,
+			Name:            "Laptop",
+			CreatedAt:       time.Now().UTC(),
+		}
+
+		ceremony = newWebAuthnCeremony(entities.WebAuthnCeremonyLogin, nil, "bG9naW4tY2hhbGxlbmdl")
+		redeemCeremonyErr = nil
+		answeredChallenge = "bG9naW4tY2hhbGxlbmdl"
+
+		getCredentialsCallCount = 1
+		updateUsageCallCount = 1
+		getUserCallCount = 1
+		sessionCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		requestBody = &usecases.FinishWebAuthnLoginRequestBody{
+			CeremonyID: ceremony.ID.String(),
+			Credential: authenticator.login(answeredChallenge),
+		}
+
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockWebAuthnStore.EXPECT().RedeemWebAuthnCeremony(gomock.AssignableToTypeOf(ctxType), ceremony.ID, entities.WebAuthnCeremonyLogin, gomock.Any()).
+			Return(ceremony, redeemCeremonyErr).Times(1)
+		mockWebAuthnStore.EXPECT().GetWebAuthnCredentials(gomock.AssignableToTypeOf(ctxType), user.ID).
+			Return([]entities.WebAuthnCredential{storedCredential}, nil).Times(getCredentialsCallCount)
+		mockWebAuthnStore.EXPECT().UpdateWebAuthnCredentialUsage(
+			gomock.AssignableToTypeOf(ctxType),
+			authenticator.credentialID,
+			uint32(6),
+			false,
+			gomock.AssignableToTypeOf(time.Time{}),
+		).Return(nil).Times(updateUsageCallCount)
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, nil).Times(getUserCallCount)
+		mockSessionStore.EXPECT().CreateSession(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.Session{}), gomock.AssignableToTypeOf("")).
+			Return(nil).Times(sessionCallCount)
+		mockAccessTokenSigner.EXPECT().SignAccessToken(gomock.AssignableToTypeOf(entities.AccessToken{})).
+			Return("signed-access-token", nil).Times(sessionCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/webauthn/login/finish", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should record the new signature counter and return the tokens for a new session", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.LoginResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.MFARequired).To(BeFalse())
+		Expect(response.AccessToken).To(Equal("signed-access-token"))
+		Expect(response.RefreshToken).ToNot(BeEmpty())
+	})
+
+	When("the signature counter hasn't increased since the credential was last used", func() {
+		BeforeEach(func() {
+			// a cloned authenticator replaying the counter the original has already used
+			authenticator.signCount = 4
+			updateUsageCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the response is signed by a different key", func() {
+		BeforeEach(func() {
+			impostor := newSoftwareAuthenticator(user.ID)
+			impostor.credentialID = authenticator.credentialID
+			impostor.signCount = 5
+			authenticator = impostor
+			updateUsageCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the authenticator answered a different challenge", func() {
+		BeforeEach(func() {
+			answeredChallenge = "c29tZS1vdGhlci1jaGFsbGVuZ2U"
+			updateUsageCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the ceremony has expired or already been finished", func() {
+		BeforeEach(func() {
+			redeemCeremonyErr = entities.ErrInvalidToken
+			getCredentialsCallCount = 0
+			updateUsageCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the user has been banned", func() {
+		BeforeEach(func() {
+			user.Status = entities.UserStatusBanned
+			sessionCallCount = 0
+		})
+
+		It("should return a 403 Forbidden", func() {
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+})
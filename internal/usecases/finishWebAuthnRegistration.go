@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// FinishWebAuthnRegistrationRequestBody represents the request body for finishing WebAuthn registration
+// @Description The authenticator's response to the registration options
+type FinishWebAuthnRegistrationRequestBody struct {
+	// CeremonyID represents the ceremony returned when registration began
+	CeremonyID string `json:"ceremony_id" binding:"required"`
+	// Name represents a label for the credential, so the user can tell their credentials apart
+	Name string `json:"name" binding:"required"`
+	// Credential represents the PublicKeyCredential returned by navigator.credentials.create()
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+// WebAuthnCredentialResponse represents a registered WebAuthn credential
+// @Description A passkey or security key registered to the user
+type WebAuthnCredentialResponse struct {
+	// ID represents the credential's unique identifier
+	ID string `json:"id"`
+	// CredentialID represents the authenticator's identifier for the credential, base64url encoded
+	CredentialID string `json:"credential_id"`
+	// Name represents the label the user gave the credential
+	Name string `json:"name"`
+	// Transports represents how the browser can reach the authenticator
+	Transports []string `json:"transports"`
+	// BackupEligible is true for credentials that can be synced between devices
+	BackupEligible bool `json:"backup_eligible"`
+	// BackupState is true for credentials that have been synced between devices
+	BackupState bool `json:"backup_state"`
+	// CreatedAt represents the timestamp when the credential was registered
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt represents the timestamp when the credential was last used to log in
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newWebAuthnCredentialResponse(credential entities.WebAuthnCredential) WebAuthnCredentialResponse {
+	return WebAuthnCredentialResponse{
+		ID:             credential.ID.String(),
+		CredentialID:   base64.RawURLEncoding.EncodeToString(credential.CredentialID),
+		Name:           credential.Name,
+		Transports:     credential.Transports,
+		BackupEligible: credential.BackupEligible,
+		BackupState:    credential.BackupState,
+		CreatedAt:      credential.CreatedAt,
+		LastUsedAt:     credential.LastUsedAt,
+	}
+}
+
+// NewFinishWebAuthnRegistration finishes registering a passkey for a user
+// @Summary Finish WebAuthn registration
+// @Description Checks the authenticator's response and stores the new credential. Each ceremony can only be finished once.
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Param request body FinishWebAuthnRegistrationRequestBody true "Finish WebAuthn Registration Request Body"
+// @Success 201 {object} WebAuthnCredentialResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 409
+// @Failure 500
+// @Router /user/{userId}/webauthn/register/finish [post]
+func NewFinishWebAuthnRegistration(userGetter UserGetter, webAuthnManager *WebAuthnManager, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request FinishWebAuthnRegistrationRequestBody
+		err = c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		ceremonyID, err := uuid.Parse(request.CeremonyID)
+		if err != nil {
+			slog.Warn("invalid ceremonyID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		credential, err := webAuthnManager.FinishRegistration(c.Request.Context(), *user, ceremonyID, request.Name, request.Credential)
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) || errors.Is(err, entities.ErrWebAuthnFailed) {
+				slog.Warn("webauthn registration failed", "err", err, "userID", userIDUUID)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			if errors.Is(err, entities.ErrWebAuthnCredentialExists) {
+				slog.Warn("webauthn credential already registered", "userID", userIDUUID)
+				c.Status(http.StatusConflict)
+				return
+			}
+
+			slog.Error("finishing webauthn registration", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     user.ID,
+			CreatedAt:  credential.CreatedAt,
+			ChangeType: entities.ChangeTypeWebAuthnCredentialAdded,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.JSON(http.StatusCreated, newWebAuthnCredentialResponse(*credential))
+	}
+}
@@ -0,0 +1,176 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Finishing WebAuthn registration", func() {
+	var w *httptest.ResponseRecorder
+	var userID string
+	var requestBody *usecases.FinishWebAuthnRegistrationRequestBody
+
+	var user *entities.User
+	var authenticator *softwareAuthenticator
+	var ceremony *entities.WebAuthnCeremony
+	var redeemCeremonyErr error
+
+	var storedCredential entities.WebAuthnCredential
+	var createCredentialErr error
+	var createCredentialCallCount int
+	var changelogCallCount int
+
+	BeforeEach(func() {
+		user = &entities.User{
+			ID:       uuid.New(),
+			Nickname: "AlecSmith96",
+			Email:    "alec@email.com",
+			Status:   entities.UserStatusActive,
+		}
+		userID = user.ID.String()
+
+		authenticator = newSoftwareAuthenticator(user.ID)
+		ceremony = newWebAuthnCeremony(entities.WebAuthnCeremonyRegistration, &user.ID, "cmVnaXN0cmF0aW9uLWNoYWxsZW5nZQ")
+		redeemCeremonyErr = nil
+
+		requestBody = &usecases.FinishWebAuthnRegistrationRequestBody{
+			CeremonyID: ceremony.ID.String(),
+			Name:       "Laptop",
+			Credential: authenticator.register("cmVnaXN0cmF0aW9uLWNoYWxsZW5nZQ"),
+		}
+
+		storedCredential = entities.WebAuthnCredential{}
+		createCredentialErr = nil
+		createCredentialCallCount = 1
+		changelogCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		expectUserAccessToken(user.ID)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, nil).Times(1)
+		mockWebAuthnStore.EXPECT().RedeemWebAuthnCeremony(gomock.AssignableToTypeOf(ctxType), ceremony.ID, entities.WebAuthnCeremonyRegistration, gomock.Any()).
+			Return(ceremony, redeemCeremonyErr).Times(1)
+		mockWebAuthnStore.EXPECT().CreateWebAuthnCredential(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.WebAuthnCredential{})).
+			DoAndReturn(func(_ context.Context, credential entities.WebAuthnCredential) error {
+				storedCredential = credential
+				return createCredentialErr
+			}).Times(createCredentialCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			DoAndReturn(func(entry entities.ChangelogEntry) error {
+				Expect(entry.UserID).To(Equal(user.ID))
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeWebAuthnCredentialAdded))
+				return nil
+			}).Times(changelogCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/user/"+userID+"/webauthn/register/finish", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should store the credential and return it", func() {
+		Expect(w.Code).To(Equal(http.StatusCreated))
+
+		Expect(storedCredential.UserID).To(Equal(user.ID))
+		Expect(storedCredential.CredentialID).To(Equal(authenticator.credentialID))
+		Expect(storedCredential.PublicKey).To(Equal(authenticator.publicKey()))
+		Expect(storedCredential.AttestationType).To(Equal("none"))
+		Expect(storedCredential.Transports).To(Equal([]string{"internal"}))
+		Expect(storedCredential.SignCount).To(Equal(uint32(0)))
+		Expect(storedCredential.Name).To(Equal("Laptop"))
+
+		var response usecases.WebAuthnCredentialResponse
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.ID).To(Equal(storedCredential.ID.String()))
+		Expect(response.CredentialID).To(Equal(base64.RawURLEncoding.EncodeToString(authenticator.credentialID)))
+		Expect(response.Name).To(Equal("Laptop"))
+		Expect(response.LastUsedAt).To(BeNil())
+	})
+
+	When("the authenticator answered a different challenge", func() {
+		BeforeEach(func() {
+			requestBody.Credential = authenticator.register("c29tZS1vdGhlci1jaGFsbGVuZ2U")
+			createCredentialCallCount = 0
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the credential isn't a valid response", func() {
+		BeforeEach(func() {
+			requestBody.Credential = []byte(`{"id":"abc"}`)
+			createCredentialCallCount = 0
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the ceremony has expired or already been finished", func() {
+		BeforeEach(func() {
+			redeemCeremonyErr = entities.ErrInvalidToken
+			createCredentialCallCount = 0
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the ceremony was started for another user", func() {
+		BeforeEach(func() {
+			otherUserID := uuid.New()
+			ceremony.UserID = &otherUserID
+			createCredentialCallCount = 0
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the credential is already registered", func() {
+		BeforeEach(func() {
+			createCredentialErr = entities.ErrWebAuthnCredentialExists
+			changelogCallCount = 0
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	When("storing the credential fails", func() {
+		BeforeEach(func() {
+			createCredentialErr = errors.New("an error occurred")
+			changelogCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
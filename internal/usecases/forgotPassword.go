@@ -0,0 +1,54 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// ForgotPasswordRequestBody represents the request body for requesting a password reset
+// @Description The email address of the account to reset
+type ForgotPasswordRequestBody struct {
+	// Email represents the user's email address
+	Email string `json:"email" binding:"required"`
+}
+
+// NewForgotPassword starts a password reset
+// @Summary Forgot password
+// @Description Emails a password reset token to the user with the provided email. Always returns 202 Accepted so that it can't be used to discover which emails are registered.
+// @Tags auth
+// @Accept json
+// @Param request body ForgotPasswordRequestBody true "Forgot Password Request Body"
+// @Success 202
+// @Failure 400
+// @Router /auth/password/forgot [post]
+func NewForgotPassword(userGetter UserGetter, passwordResetSender *PasswordResetSender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ForgotPasswordRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		// the response is the same whether or not the email is registered, so failures are only logged
+		user, err := userGetter.GetUserByEmail(c.Request.Context(), request.Email)
+		if err != nil {
+			if !errors.Is(err, entities.ErrUserNotFound) {
+				slog.Error("getting user", "err", err)
+			}
+			c.Status(http.StatusAccepted)
+			return
+		}
+
+		err = passwordResetSender.Send(c.Request.Context(), *user)
+		if err != nil {
+			slog.Error("sending password reset", "err", err)
+		}
+
+		c.Status(http.StatusAccepted)
+	}
+}
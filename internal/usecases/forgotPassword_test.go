@@ -0,0 +1,92 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Requesting a password reset", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.ForgotPasswordRequestBody
+	var user *entities.User
+	var getUserErr error
+	var createTokenErr error
+	var createTokenCallCount int
+	var sendMailCallCount int
+	var storedTokenHash string
+
+	BeforeEach(func() {
+		requestBody = &usecases.ForgotPasswordRequestBody{Email: "alec@email.com"}
+		user = &entities.User{
+			ID:       uuid.New(),
+			Nickname: "alecsmith",
+			Email:    "alec@email.com",
+		}
+		getUserErr = nil
+		createTokenErr = nil
+		createTokenCallCount = 1
+		sendMailCallCount = 1
+		storedTokenHash = ""
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockUserGetter.EXPECT().GetUserByEmail(gomock.AssignableToTypeOf(ctxType), requestBody.Email).
+			Return(user, getUserErr).Times(1)
+		mockPasswordManager.EXPECT().CreatePasswordResetToken(gomock.AssignableToTypeOf(ctxType), user.ID, gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			DoAndReturn(func(_ any, _ uuid.UUID, tokenHash string, _ time.Time) error {
+				storedTokenHash = tokenHash
+				return createTokenErr
+			}).Times(createTokenCallCount)
+		mockMailer.EXPECT().SendMail(gomock.AssignableToTypeOf(ctxType), gomock.Cond(func(x any) bool {
+			email := x.(entities.Email)
+			// the stored hash must never be the value sent to the user
+			return email.To == user.Email && !bytes.Contains([]byte(email.Body), []byte(storedTokenHash))
+		})).Return(nil).Times(sendMailCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/password/forgot", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return a 202 Accepted", func() {
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		Expect(storedTokenHash).To(HaveLen(64))
+	})
+
+	When("no user has the email", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			createTokenCallCount = 0
+			sendMailCallCount = 0
+		})
+
+		It("should still return a 202 Accepted", func() {
+			Expect(w.Code).To(Equal(http.StatusAccepted))
+		})
+	})
+
+	When("the token can't be stored", func() {
+		BeforeEach(func() {
+			createTokenErr = errors.New("an error occurred")
+			sendMailCallCount = 0
+		})
+
+		It("should still return a 202 Accepted", func() {
+			Expect(w.Code).To(Equal(http.StatusAccepted))
+		})
+	})
+})
@@ -0,0 +1,141 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const defaultAuditPageSize = 100
+
+// GetAuditLogRequest holds the query parameters for querying the audit log
+type GetAuditLogRequest struct {
+	// Actor represents the name of the API key the action was taken with
+	Actor string `form:"actor"`
+	// Action represents the kind of action, such as USER_BANNED
+	Action string `form:"action"`
+	// UserID represents the user the action was taken on
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	// From represents the earliest time to return entries from, in RFC 3339 format
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	// To represents the time to return entries until, in RFC 3339 format
+	To *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	// After represents the sequence of the last entry of the previous page
+	After int64 `form:"after" binding:"min=0"`
+	// Limit represents the number of entries to return, default is 100 and the most is 1000
+	Limit int `form:"limit" binding:"min=0,max=1000"`
+}
+
+// AuditEntryResponse represents an entry in the audit log
+// @Description Who did what, and the hashes chaining the entry to the one before it
+type AuditEntryResponse struct {
+	// Sequence represents the entry's position in the log
+	Sequence int64 `json:"sequence"`
+	// Actor represents the name of the API key the action was taken with
+	Actor string `json:"actor"`
+	// ActorAPIKeyID represents the ID of the API key the action was taken with, if it wasn't the bootstrap key
+	ActorAPIKeyID *uuid.UUID `json:"actor_api_key_id,omitempty"`
+	// Action represents the kind of action
+	Action string `json:"action"`
+	// TargetUserID represents the user the action was taken on, if it was taken on one
+	TargetUserID *uuid.UUID `json:"target_user_id,omitempty"`
+	// Details represents anything else identifying what the action was taken on
+	Details map[string]string `json:"details,omitempty"`
+	// IPAddress represents the IP address the request came from
+	IPAddress string `json:"ip_address"`
+	// CreatedAt represents when the action was taken
+	CreatedAt time.Time `json:"created_at"`
+	// PreviousHash represents the hash of the entry before, empty for the first entry
+	PreviousHash string `json:"previous_hash"`
+	// Hash represents the hash of the entry's contents and PreviousHash
+	Hash string `json:"hash"`
+}
+
+// GetAuditLogResponseBody represents the response body for querying the audit log
+// @Description Audit log entries matching the query
+type GetAuditLogResponseBody struct {
+	// Entries represents the matching entries in sequence order
+	Entries []AuditEntryResponse `json:"entries"`
+	// NextAfter represents the value of after to get the next page with, 0 if this is the last page
+	NextAfter int64 `json:"next_after"`
+}
+
+// NewGetAuditLog queries the audit log
+// @Summary Query audit log
+// @Description Returns audit log entries in the order they were recorded, optionally filtered by actor, action, user and
+// @Description time. Pages are fetched by passing next_after as after.
+// @Tags admin
+// @Produce json
+// @Param actor query string false "API key name"
+// @Param action query string false "Action"
+// @Param user_id query string false "User ID"
+// @Param from query string false "Earliest time, RFC 3339"
+// @Param to query string false "Latest time, RFC 3339"
+// @Param after query int false "Sequence to return entries after"
+// @Param limit query int false "Page size"
+// @Success 200 {object} GetAuditLogResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /admin/audit [get]
+func NewGetAuditLog(auditLog AuditLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request GetAuditLogRequest
+		err := c.ShouldBindQuery(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		filter := entities.AuditFilter{
+			Actor:         request.Actor,
+			Action:        request.Action,
+			From:          request.From,
+			To:            request.To,
+			AfterSequence: request.After,
+			Limit:         request.Limit,
+		}
+		if filter.Limit == 0 {
+			filter.Limit = defaultAuditPageSize
+		}
+		if request.UserID != "" {
+			userIDUUID := uuid.MustParse(request.UserID)
+			filter.TargetUserID = &userIDUUID
+		}
+
+		entries, err := auditLog.GetAuditEntries(c.Request.Context(), filter)
+		if err != nil {
+			slog.Error("getting audit entries", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := GetAuditLogResponseBody{
+			Entries: make([]AuditEntryResponse, 0, len(entries)),
+		}
+		for _, entry := range entries {
+			response.Entries = append(response.Entries, AuditEntryResponse{
+				Sequence:      entry.Sequence,
+				Actor:         entry.Actor,
+				ActorAPIKeyID: entry.ActorAPIKeyID,
+				Action:        entry.Action,
+				TargetUserID:  entry.TargetUserID,
+				Details:       entry.Details,
+				IPAddress:     entry.IPAddress,
+				CreatedAt:     entry.CreatedAt,
+				PreviousHash:  entry.PreviousHash,
+				Hash:          entry.Hash,
+			})
+		}
+		if len(entries) == filter.Limit {
+			response.NextAfter = entries[len(entries)-1].Sequence
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
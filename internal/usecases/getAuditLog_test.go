@@ -0,0 +1,118 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Querying the audit log", func() {
+	var w *httptest.ResponseRecorder
+	var query string
+	var entries []entities.AuditEntry
+	var getEntriesErr error
+	var getEntriesCallCount int
+	var receivedFilter entities.AuditFilter
+
+	BeforeEach(func() {
+		userID := uuid.New()
+		query = "?user_id=" + userID.String() + "&limit=1"
+		entries = []entities.AuditEntry{
+			entities.AuditEntry{
+				Actor:        "support-tooling",
+				Action:       entities.AuditActionUserBanned,
+				TargetUserID: &userID,
+				IPAddress:    "198.51.100.9",
+				CreatedAt:    time.Date(2024, 7, 2, 9, 0, 0, 0, time.UTC),
+			}.Chain(nil),
+		}
+		getEntriesErr = nil
+		getEntriesCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockAuditLog.EXPECT().GetAuditEntries(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.AuditFilter{})).
+			DoAndReturn(func(_ context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+				receivedFilter = filter
+				return entries, getEntriesErr
+			}).Times(getEntriesCallCount)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/admin/audit"+query, nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the matching entries and where the next page starts", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(receivedFilter.TargetUserID).To(Equal(entries[0].TargetUserID))
+		Expect(receivedFilter.Limit).To(Equal(1))
+
+		var response usecases.GetAuditLogResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Entries).To(HaveLen(1))
+		Expect(response.Entries[0].Hash).To(Equal(entries[0].Hash))
+		Expect(response.NextAfter).To(Equal(int64(1)))
+	})
+
+	When("no limit is given", func() {
+		BeforeEach(func() {
+			query = "?action=USER_BANNED&from=2024-07-01T00:00:00Z"
+		})
+
+		It("should return a page of 100 with no next page", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(receivedFilter.Limit).To(Equal(100))
+			Expect(receivedFilter.Action).To(Equal(entities.AuditActionUserBanned))
+			Expect(*receivedFilter.From).To(BeTemporally("==", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+
+			var response usecases.GetAuditLogResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.NextAfter).To(BeZero())
+		})
+	})
+
+	When("the user ID isn't a uuid", func() {
+		BeforeEach(func() {
+			query = "?user_id=not-a-uuid"
+			getEntriesCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the limit is too large", func() {
+		BeforeEach(func() {
+			query = "?limit=5000"
+			getEntriesCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("getting the entries fails", func() {
+		BeforeEach(func() {
+			getEntriesErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const defaultImportResultsPageSize = 1000
+
+// GetImportJobRequest holds the query parameters for getting an import job
+type GetImportJobRequest struct {
+	// After represents the row of the last result of the previous page
+	After int `form:"after" binding:"min=0"`
+	// Limit represents the number of results to return, default is 1000 and the most is 10000
+	Limit int `form:"limit" binding:"min=0,max=10000"`
+}
+
+// ImportJobResponseBody represents an import running in the background
+// @Description The import's progress and a page of its row results
+type ImportJobResponseBody struct {
+	// JobID represents the import job's unique identifier
+	JobID string `json:"job_id"`
+	// Format represents the format of the file being imported
+	Format string `json:"format"`
+	// DryRun represents whether the import is only being validated
+	DryRun bool `json:"dry_run"`
+	// Status represents whether the import is RUNNING, SUCCEEDED or FAILED
+	Status string `json:"status"`
+	// ImportedRows represents the number of rows so far that created a user, or would have in a dry run
+	ImportedRows int `json:"imported_rows"`
+	// FailedRows represents the number of rows so far that weren't imported
+	FailedRows int `json:"failed_rows"`
+	// Error represents why a FAILED import stopped, rows after the last result weren't imported
+	Error string `json:"error,omitempty"`
+	// CreatedAt represents when the import was started
+	CreatedAt time.Time `json:"created_at"`
+	// FinishedAt represents when the import finished, if it has
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Results represents a page of row results in row order
+	Results []ImportRowResultResponse `json:"results"`
+	// NextAfter represents the value of after to get the next page with, 0 if there are no more results yet
+	NextAfter int `json:"next_after"`
+}
+
+func newImportJobResponseBody(job entities.ImportJob) ImportJobResponseBody {
+	return ImportJobResponseBody{
+		JobID:        job.ID.String(),
+		Format:       job.Format,
+		DryRun:       job.DryRun,
+		Status:       string(job.Status),
+		ImportedRows: job.ImportedRows,
+		FailedRows:   job.FailedRows,
+		Error:        job.Error,
+		CreatedAt:    job.CreatedAt,
+		FinishedAt:   job.FinishedAt,
+		Results:      []ImportRowResultResponse{},
+	}
+}
+
+// NewGetImportJob gets an import job's progress and results
+// @Summary Get import job
+// @Description Returns an import job started with async, along with a page of its row results. Pages are fetched by
+// @Description passing next_after as after.
+// @Tags users
+// @Produce json
+// @Param jobId path string true "Import job ID"
+// @Param after query int false "Row to return results after"
+// @Param limit query int false "Page size"
+// @Success 200 {object} ImportJobResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /users/import/{jobId} [get]
+func NewGetImportJob(jobStore ImportJobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID := c.Param("jobId")
+		jobIDUUID, err := uuid.Parse(jobID)
+		if err != nil {
+			slog.Error("invalid jobID", "jobID", jobID, "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request GetImportJobRequest
+		err = c.ShouldBindQuery(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if request.Limit == 0 {
+			request.Limit = defaultImportResultsPageSize
+		}
+
+		job, err := jobStore.GetImportJob(c.Request.Context(), jobIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrImportJobNotFound) {
+				slog.Warn("import job not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting import job", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		results, err := jobStore.GetImportResults(c.Request.Context(), jobIDUUID, request.After, request.Limit)
+		if err != nil {
+			slog.Error("getting import results", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := newImportJobResponseBody(*job)
+		for _, result := range results {
+			response.Results = append(response.Results, newImportRowResultResponse(result))
+		}
+		if len(results) == request.Limit {
+			response.NextAfter = results[len(results)-1].Row
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
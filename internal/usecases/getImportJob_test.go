@@ -0,0 +1,160 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Getting an import job", func() {
+	var w *httptest.ResponseRecorder
+	var jobID string
+	var query string
+	var job *entities.ImportJob
+	var getJobErr error
+	var getJobCallCount int
+	var results []entities.ImportRowResult
+	var getResultsErr error
+	var getResultsCallCount int
+	var expectedAfter int
+	var expectedLimit int
+
+	BeforeEach(func() {
+		jobUUID := uuid.New()
+		userID := uuid.New()
+		jobID = jobUUID.String()
+		query = "?after=1&limit=2"
+		job = &entities.ImportJob{
+			ID:           jobUUID,
+			Format:       entities.ImportFormatCSV,
+			Status:       entities.ImportJobStatusRunning,
+			ImportedRows: 2,
+			FailedRows:   1,
+			CreatedAt:    time.Date(2024, 7, 4, 9, 0, 0, 0, time.UTC),
+		}
+		getJobErr = nil
+		getJobCallCount = 1
+		results = []entities.ImportRowResult{
+			{Row: 2, Error: "missing country"},
+			{Row: 3, UserID: &userID},
+		}
+		getResultsErr = nil
+		getResultsCallCount = 1
+		expectedAfter = 1
+		expectedLimit = 2
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockImportJobStore.EXPECT().GetImportJob(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+			Return(job, getJobErr).Times(getJobCallCount)
+		mockImportJobStore.EXPECT().GetImportResults(gomock.AssignableToTypeOf(ctxType), gomock.Any(), expectedAfter, expectedLimit).
+			Return(results, getResultsErr).Times(getResultsCallCount)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/users/import/"+jobID+query, nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the job with a page of results and where the next page starts", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var response usecases.ImportJobResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.JobID).To(Equal(jobID))
+		Expect(response.Status).To(Equal(string(entities.ImportJobStatusRunning)))
+		Expect(response.ImportedRows).To(Equal(2))
+		Expect(response.FailedRows).To(Equal(1))
+		Expect(response.FinishedAt).To(BeNil())
+		Expect(response.Results).To(Equal([]usecases.ImportRowResultResponse{
+			{Row: 2, Error: "missing country"},
+			{Row: 3, UserID: results[1].UserID.String()},
+		}))
+		Expect(response.NextAfter).To(Equal(3))
+	})
+
+	When("no limit is given", func() {
+		BeforeEach(func() {
+			query = ""
+			expectedAfter = 0
+			expectedLimit = 1000
+		})
+
+		It("should return a page of 1000 with no next page", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var response usecases.ImportJobResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Results).To(HaveLen(2))
+			Expect(response.NextAfter).To(BeZero())
+		})
+	})
+
+	When("the limit is too large", func() {
+		BeforeEach(func() {
+			query = "?limit=10001"
+			getJobCallCount = 0
+			getResultsCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the jobId isn't a valid uuid", func() {
+		BeforeEach(func() {
+			jobID = "not-a-uuid"
+			getJobCallCount = 0
+			getResultsCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the job doesn't exist", func() {
+		BeforeEach(func() {
+			job = nil
+			getJobErr = entities.ErrImportJobNotFound
+			getResultsCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the importJobStore adapter returns an error getting the job", func() {
+		BeforeEach(func() {
+			job = nil
+			getJobErr = errors.New("an error occurred")
+			getResultsCallCount = 0
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	When("the importJobStore adapter returns an error getting the results", func() {
+		BeforeEach(func() {
+			results = nil
+			getResultsErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
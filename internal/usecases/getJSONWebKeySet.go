@@ -0,0 +1,36 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// JSONWebKeySetResponseBody represents a JWK set (RFC 7517)
+// @Description The public keys ID tokens are signed with
+type JSONWebKeySetResponseBody struct {
+	// Keys represents the public keys, identified by their kid
+	Keys []entities.JSONWebKey `json:"keys" swaggertype:"array,object"`
+}
+
+// NewGetJSONWebKeySet publishes the public keys ID tokens are signed with
+// @Summary JSON Web Key Set
+// @Description Returns the public keys clients can check the signatures of ID tokens with
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} JSONWebKeySetResponseBody
+// @Failure 500
+// @Router /.well-known/jwks.json [get]
+func NewGetJSONWebKeySet(oidcProvider *OIDCProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := oidcProvider.PublicKeys(c.Request.Context())
+		if err != nil {
+			slog.Error("getting public keys", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, JSONWebKeySetResponseBody{Keys: keys})
+	}
+}
@@ -0,0 +1,49 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Getting the JSON Web Key Set", func() {
+	var w *httptest.ResponseRecorder
+	var keys []entities.JSONWebKey
+	var publicKeysErr error
+
+	BeforeEach(func() {
+		keys = []entities.JSONWebKey{
+			{KeyType: "RSA", Use: "sig", KeyID: "key-1", Algorithm: "RS256", Modulus: "modulus", Exponent: "AQAB"},
+		}
+		publicKeysErr = nil
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockIDTokenSigner.EXPECT().PublicKeys(gomock.AssignableToTypeOf(ctxType)).Return(keys, publicKeysErr).Times(1)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/.well-known/jwks.json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the public keys", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"keys":[{"kty":"RSA","use":"sig","kid":"key-1","alg":"RS256","n":"modulus","e":"AQAB"}]}`))
+	})
+
+	When("getting the keys fails", func() {
+		BeforeEach(func() {
+			publicKeysErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
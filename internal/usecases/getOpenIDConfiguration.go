@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// OpenIDConfigurationResponseBody represents the OpenID Connect discovery document
+// @Description Where the provider's endpoints are and what it supports
+type OpenIDConfigurationResponseBody struct {
+	// Issuer represents the issuer identifier, it is the iss claim of every ID token
+	Issuer string `json:"issuer"`
+	// AuthorizationEndpoint represents the URL of the authorize endpoint
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	// TokenEndpoint represents the URL of the token endpoint
+	TokenEndpoint string `json:"token_endpoint"`
+	// UserInfoEndpoint represents the URL of the userinfo endpoint
+	UserInfoEndpoint string `json:"userinfo_endpoint"`
+	// JWKSURI represents the URL of the keys ID tokens are signed with
+	JWKSURI string `json:"jwks_uri"`
+	// ScopesSupported represents the scopes clients can ask for
+	ScopesSupported []string `json:"scopes_supported"`
+	// ResponseTypesSupported represents the supported response types
+	ResponseTypesSupported []string `json:"response_types_supported"`
+	// GrantTypesSupported represents the supported grant types
+	GrantTypesSupported []string `json:"grant_types_supported"`
+	// SubjectTypesSupported represents the supported subject identifier types
+	SubjectTypesSupported []string `json:"subject_types_supported"`
+	// IDTokenSigningAlgValuesSupported represents the algorithms ID tokens are signed with
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	// TokenEndpointAuthMethodsSupported represents how clients can authenticate at the token endpoint
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	// CodeChallengeMethodsSupported represents the supported PKCE code challenge methods
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+	// ClaimsSupported represents the claims that can be released about a user
+	ClaimsSupported []string `json:"claims_supported"`
+}
+
+// Configuration returns the provider's discovery document
+func (p *OIDCProvider) Configuration() OpenIDConfigurationResponseBody {
+	return OpenIDConfigurationResponseBody{
+		Issuer:                            p.issuer,
+		AuthorizationEndpoint:             p.issuer + "/authorize",
+		TokenEndpoint:                     p.issuer + "/token",
+		UserInfoEndpoint:                  p.issuer + "/userinfo",
+		JWKSURI:                           p.issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   entities.OIDCSupportedScopes,
+		ResponseTypesSupported:            []string{oidcResponseTypeCode},
+		GrantTypesSupported:               []string{oidcGrantTypeAuthorizationCode},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{pkceMethodS256},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"nickname", "given_name", "family_name", "country", "email", "email_verified",
+		},
+	}
+}
+
+// NewGetOpenIDConfiguration serves the OpenID Connect discovery document
+// @Summary OpenID Connect discovery
+// @Description Describes where the provider's endpoints are and what it supports
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} OpenIDConfigurationResponseBody
+// @Router /.well-known/openid-configuration [get]
+func NewGetOpenIDConfiguration(oidcProvider *OIDCProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, oidcProvider.Configuration())
+	}
+}
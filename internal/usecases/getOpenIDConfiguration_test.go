@@ -0,0 +1,34 @@
+package usecases_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Getting the OpenID Connect discovery document", func() {
+	It("should describe the provider's endpoints", func() {
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/.well-known/openid-configuration", nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.OpenIDConfigurationResponseBody
+		err = json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Issuer).To(Equal("http://localhost:8080"))
+		Expect(response.AuthorizationEndpoint).To(Equal("http://localhost:8080/authorize"))
+		Expect(response.TokenEndpoint).To(Equal("http://localhost:8080/token"))
+		Expect(response.UserInfoEndpoint).To(Equal("http://localhost:8080/userinfo"))
+		Expect(response.JWKSURI).To(Equal("http://localhost:8080/.well-known/jwks.json"))
+		Expect(response.ScopesSupported).To(ConsistOf("openid", "profile", "email"))
+		Expect(response.CodeChallengeMethodsSupported).To(Equal([]string{"S256"}))
+		Expect(response.IDTokenSigningAlgValuesSupported).To(Equal([]string{"RS256"}))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"log/slog"
 	"net/http"
 	"time"
@@ -12,6 +13,10 @@ import (
 //go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userGetter.go  . "UserGetter"
 type UserGetter interface {
 	GetPaginatedUsers(ctx context.Context, firstName, lastName, nickname, email, country string, pageInfo entities.PageInfo) ([]entities.User, string, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.User, error)
+	// GetUsersByIDs returns the users that exist out of userIDs, in no particular order
+	GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]entities.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entities.User, error)
 }
 
 // GetUsersRequestBody represents the request body for getting users
@@ -70,6 +75,86 @@ type UserResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 	// UpdatedAt represents the timestamp when the user was last updated
 	UpdatedAt time.Time `json:"updated_at"`
+	// Status represents the user's account status
+	Status string `json:"status"`
+	// BanReason represents the reason given when the user was banned
+	BanReason string `json:"ban_reason,omitempty"`
+	// BanExpiresAt represents the timestamp when the user's ban is lifted, a banned user with no expiry is banned permanently
+	BanExpiresAt *time.Time `json:"ban_expires_at,omitempty"`
+	// EmailVerifiedAt represents the timestamp when the user verified their email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// PendingEmail represents an email address awaiting confirmation before it replaces Email
+	PendingEmail *string `json:"pending_email,omitempty"`
+}
+
+// newUserResponse maps a user entity to its response representation
+func newUserResponse(user entities.User) UserResponse {
+	return UserResponse{
+		ID:              user.ID.String(),
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Nickname:        user.Nickname,
+		Password:        user.Password,
+		Email:           user.Email,
+		Country:         user.Country,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		Status:          string(user.Status),
+		BanReason:       user.BanReason,
+		BanExpiresAt:    user.BanExpiresAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PendingEmail:    user.PendingEmail,
+	}
+}
+
+// PublicUserResponse represents a user without their password
+// @Description Information of an individual user, without their password
+type PublicUserResponse struct {
+	// ID represents the user's unique identifier
+	ID string `json:"id"`
+	// FirstName represents the user's first name
+	FirstName string `json:"first_name"`
+	// LastName represents the user's last name
+	LastName string `json:"last_name"`
+	// Nickname represents the user's nickname
+	Nickname string `json:"nickname"`
+	// Email represents the user's email address
+	Email string `json:"email"`
+	// Country represents the user's country
+	Country string `json:"country"`
+	// CreatedAt represents the timestamp when the user was created
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt represents the timestamp when the user was last updated
+	UpdatedAt time.Time `json:"updated_at"`
+	// Status represents the user's account status
+	Status string `json:"status"`
+	// BanReason represents the reason given when the user was banned
+	BanReason string `json:"ban_reason,omitempty"`
+	// BanExpiresAt represents the timestamp when the user's ban is lifted, a banned user with no expiry is banned permanently
+	BanExpiresAt *time.Time `json:"ban_expires_at,omitempty"`
+	// EmailVerifiedAt represents the timestamp when the user verified their email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// PendingEmail represents an email address awaiting confirmation before it replaces Email
+	PendingEmail *string `json:"pending_email,omitempty"`
+}
+
+// newPublicUserResponse maps a user entity to its response representation, leaving out their password
+func newPublicUserResponse(user entities.User) PublicUserResponse {
+	return PublicUserResponse{
+		ID:              user.ID.String(),
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Nickname:        user.Nickname,
+		Email:           user.Email,
+		Country:         user.Country,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		Status:          string(user.Status),
+		BanReason:       user.BanReason,
+		BanExpiresAt:    user.BanExpiresAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PendingEmail:    user.PendingEmail,
+	}
 }
 
 // NewGetUsers Get Users
@@ -119,17 +204,7 @@ func NewGetUsers(userGetter UserGetter, changelogWriter ChangelogWriter) gin.Han
 
 		usersResponse := make([]UserResponse, 0)
 		for _, user := range users {
-			usersResponse = append(usersResponse, UserResponse{
-				ID:        user.ID.String(),
-				FirstName: user.FirstName,
-				LastName:  user.LastName,
-				Nickname:  user.Nickname,
-				Password:  user.Password,
-				Email:     user.Email,
-				Country:   user.Country,
-				CreatedAt: user.CreatedAt,
-				UpdatedAt: user.UpdatedAt,
-			})
+			usersResponse = append(usersResponse, newUserResponse(user))
 		}
 
 		response := GetUsersResponseBody{
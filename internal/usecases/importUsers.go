@@ -0,0 +1,167 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// ImportUsersRequest holds the query parameters for importing users, the file itself is the request body
+type ImportUsersRequest struct {
+	// Format represents the file's format, csv or ndjson, it defaults to the one matching the Content-Type
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	// DryRun represents whether to only validate the file and report what would be imported
+	DryRun bool `form:"dry_run"`
+	// Async represents whether to import the file in the background, which large files should be
+	Async bool `form:"async"`
+}
+
+// ImportRowResultResponse represents the outcome of importing one row
+// @Description The user a row created, or why it wasn't imported
+type ImportRowResultResponse struct {
+	// Row represents the row's position in the file, the first user is row 1 whether or not there is a header row
+	Row int `json:"row"`
+	// UserID represents the user the row created, it is empty for rows that failed and in dry runs
+	UserID string `json:"user_id,omitempty"`
+	// Error represents why the row wasn't imported
+	Error string `json:"error,omitempty"`
+}
+
+// ImportUsersResponseBody represents the response body for an import that has finished
+// @Description The result of every row in the file
+type ImportUsersResponseBody struct {
+	// DryRun represents whether the import was only validated, in which case no users were created
+	DryRun bool `json:"dry_run"`
+	// ImportedRows represents the number of rows that created a user, or would have in a dry run
+	ImportedRows int `json:"imported_rows"`
+	// FailedRows represents the number of rows that weren't imported
+	FailedRows int `json:"failed_rows"`
+	// Error represents why the import stopped early, rows after the last result weren't imported
+	Error string `json:"error,omitempty"`
+	// Results represents the result of each row in row order
+	Results []ImportRowResultResponse `json:"results"`
+}
+
+func (r *ImportUsersResponseBody) addResults(results []entities.ImportRowResult) {
+	for _, result := range results {
+		response := newImportRowResultResponse(result)
+		if response.Error != "" {
+			r.FailedRows++
+		} else {
+			r.ImportedRows++
+		}
+		r.Results = append(r.Results, response)
+	}
+}
+
+func newImportRowResultResponse(result entities.ImportRowResult) ImportRowResultResponse {
+	response := ImportRowResultResponse{
+		Row:   result.Row,
+		Error: result.Error,
+	}
+	if result.UserID != nil {
+		response.UserID = result.UserID.String()
+	}
+
+	return response
+}
+
+// NewImportUsers imports users in bulk
+// @Summary Import users
+// @Description Creates users from a CSV file with a header row, or an NDJSON file with one user per line. Each user
+// @Description needs a first_name, last_name, nickname, password, email and country, and is validated the same way as
+// @Description POST /user. Valid rows are imported in batches, rows that fail are reported without stopping the
+// @Description import. Imported users aren't sent verification emails. With async the file is imported in the
+// @Description background and the job is returned, its results can be fetched from GET /users/import/{jobId}.
+// @Tags users
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or ndjson, defaults to matching the Content-Type"
+// @Param dry_run query bool false "Only validate the file"
+// @Param async query bool false "Import in the background"
+// @Success 200 {object} ImportUsersResponseBody
+// @Success 202 {object} ImportJobResponseBody
+// @Failure 400 {object} ImportUsersResponseBody
+// @Failure 401
+// @Failure 403
+// @Failure 500 {object} ImportUsersResponseBody
+// @Failure 503
+// @Router /users/import [post]
+func NewImportUsers(userImport *UserImport) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ImportUsersRequest
+		err := c.ShouldBindQuery(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		format := request.Format
+		if format == "" {
+			format = importFormatForContentType(c.ContentType())
+		}
+
+		if request.Async {
+			job, err := userImport.StartJob(c.Request.Context(), format, c.Request.Body, request.DryRun)
+			if err != nil {
+				if errors.Is(err, errInvalidImportFile) {
+					slog.Warn("invalid import file", "err", err)
+					c.Status(http.StatusBadRequest)
+					return
+				}
+
+				if errors.Is(err, errImportJobsStopped) {
+					slog.Warn("import job started while shutting down")
+					c.Status(http.StatusServiceUnavailable)
+					return
+				}
+
+				slog.Error("starting import job", "err", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+
+			c.JSON(http.StatusAccepted, newImportJobResponseBody(*job))
+			return
+		}
+
+		response := ImportUsersResponseBody{
+			DryRun:  request.DryRun,
+			Results: []ImportRowResultResponse{},
+		}
+		err = userImport.Import(c.Request.Context(), format, c.Request.Body, request.DryRun, func(results []entities.ImportRowResult) error {
+			response.addResults(results)
+			return nil
+		})
+		if err != nil {
+			// earlier batches may already have been imported, so the caller needs their results to carry on from
+			response.Error = err.Error()
+			if errors.Is(err, errInvalidImportFile) {
+				slog.Warn("invalid import file", "err", err)
+				c.JSON(http.StatusBadRequest, response)
+				return
+			}
+
+			slog.Error("importing users", "err", err)
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func importFormatForContentType(contentType string) string {
+	switch contentType {
+	case "text/csv":
+		return entities.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		return entities.ImportFormatNDJSON
+	default:
+		return ""
+	}
+}
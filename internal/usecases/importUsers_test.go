@@ -0,0 +1,325 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Importing users", func() {
+	var w *httptest.ResponseRecorder
+	var path string
+	var contentType string
+	var body string
+
+	var breachLookupCallCount int
+	// registeredEmails are the emails the importer treats as already registered
+	var registeredEmails map[string]bool
+	var importUsersErr error
+	var importUsersCallCount int
+	var importedBatches [][]int
+	var importedUserIDs []uuid.UUID
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		path = "/users/import"
+		contentType = "text/csv"
+		body = "first_name,last_name,nickname,password,email,country\n" +
+			"alec,smith,alecsmith,some-password,alec@email.com,UK\n" +
+			"jane,doe,janedoe,some-password,jane@email.com,\n" +
+			"john,doe,johndoe,some-password,john@email.com,UK\n" +
+			"alex,smith,alexsmith,some-password,alec@email.com,UK\n" +
+			"bob,jones,bobjones,some-password,bob@email.com,UK\n"
+
+		breachLookupCallCount = 3
+		registeredEmails = map[string]bool{"bob@email.com": true}
+		importUsersErr = nil
+		importUsersCallCount = 2
+		importedBatches = nil
+		importedUserIDs = nil
+		changelogWriterCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		expectBreachLookup(mockBreachCorpus, "some-password", 0, breachLookupCallCount)
+		mockUserImporter.EXPECT().ImportUsers(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, users []entities.ImportedUser, dryRun bool) ([]entities.ImportRowResult, error) {
+				rows := []int{}
+				results := []entities.ImportRowResult{}
+				for _, user := range users {
+					rows = append(rows, user.Row)
+					result := entities.ImportRowResult{Row: user.Row}
+					if registeredEmails[user.Email] {
+						result.Error = entities.ErrEmailAlreadyUsed.Error()
+					} else if !dryRun {
+						userID := uuid.New()
+						result.UserID = &userID
+						importedUserIDs = append(importedUserIDs, userID)
+					}
+					results = append(results, result)
+				}
+				importedBatches = append(importedBatches, rows)
+
+				if importUsersErr != nil {
+					return nil, importUsersErr
+				}
+				return results, nil
+			}).Times(importUsersCallCount)
+
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+			entry := x.(entities.ChangelogEntry)
+			return entry.ChangeType == entities.ChangeTypeUsersImported && entry.UserID == uuid.Nil
+		})).Do(func(entry entities.ChangelogEntry) {
+			Expect(entry.UserIDs).To(Equal(importedUserIDs))
+		}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080"+path, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(w, req)
+	})
+
+	It("should import the valid rows in batches and report every row", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var response usecases.ImportUsersResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(importedBatches).To(Equal([][]int{{1, 3}, {5}}))
+		Expect(response.DryRun).To(BeFalse())
+		Expect(response.ImportedRows).To(Equal(2))
+		Expect(response.FailedRows).To(Equal(3))
+		Expect(response.Results).To(Equal([]usecases.ImportRowResultResponse{
+			{Row: 1, UserID: importedUserIDs[0].String()},
+			{Row: 2, Error: "missing country"},
+			{Row: 3, UserID: importedUserIDs[1].String()},
+			{Row: 4, Error: "email already used by row 1"},
+			{Row: 5, Error: entities.ErrEmailAlreadyUsed.Error()},
+		}))
+	})
+
+	When("it is a dry run", func() {
+		BeforeEach(func() {
+			path = "/users/import?dry_run=true"
+			changelogWriterCallCount = 0
+		})
+
+		It("should report what would be imported without publishing a changelog entry", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var response usecases.ImportUsersResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.DryRun).To(BeTrue())
+			Expect(response.ImportedRows).To(Equal(2))
+			Expect(response.FailedRows).To(Equal(3))
+			Expect(response.Results[0]).To(Equal(usecases.ImportRowResultResponse{Row: 1}))
+		})
+	})
+
+	When("the file is NDJSON", func() {
+		BeforeEach(func() {
+			contentType = "application/x-ndjson"
+			body = `{"first_name":"alec","last_name":"smith","nickname":"alecsmith","password":"some-password","email":"alec@email.com","country":"UK"}` + "\n" +
+				"\n" +
+				`{"first_name":"jane",` + "\n" +
+				`{"first_name":"john","last_name":"doe","nickname":"johndoe","password":"some-password","email":"john@email.com","country":"UK"}` + "\n"
+			breachLookupCallCount = 2
+			importUsersCallCount = 1
+		})
+
+		It("should skip blank lines and report lines that aren't valid", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var response usecases.ImportUsersResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(importedBatches).To(Equal([][]int{{1, 3}}))
+			Expect(response.ImportedRows).To(Equal(2))
+			Expect(response.FailedRows).To(Equal(1))
+			Expect(response.Results[1].Row).To(Equal(2))
+			Expect(response.Results[1].Error).To(HavePrefix("invalid json"))
+		})
+	})
+
+	When("the CSV header is missing a column", func() {
+		BeforeEach(func() {
+			body = "first_name,last_name,nickname,password,email\nalec,smith,alecsmith,some-password,alec@email.com\n"
+			breachLookupCallCount = 0
+			importUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			var response usecases.ImportUsersResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Error).To(Equal("invalid import file: header row is missing country"))
+			Expect(response.Results).To(BeEmpty())
+		})
+	})
+
+	When("the format isn't known", func() {
+		BeforeEach(func() {
+			contentType = "application/json"
+			breachLookupCallCount = 0
+			importUsersCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userImporter adapter returns an error", func() {
+		BeforeEach(func() {
+			breachLookupCallCount = 2
+			importUsersErr = errors.New("an error occurred")
+			importUsersCallCount = 1
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 response with the results recorded before the error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+			var response usecases.ImportUsersResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Error).To(Equal("importing rows 1 to 3: an error occurred"))
+			Expect(response.Results).To(BeEmpty())
+		})
+	})
+
+	When("the import is async", func() {
+		var createdJob entities.ImportJob
+		var recordedResults []entities.ImportRowResult
+		var finished chan entities.ImportJobStatus
+
+		BeforeEach(func() {
+			path = "/users/import?async=true"
+			recordedResults = nil
+			finished = make(chan entities.ImportJobStatus, 1)
+
+			mockImportJobStore.EXPECT().CreateImportJob(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+				Do(func(_ context.Context, job entities.ImportJob) {
+					createdJob = job
+				}).Return(nil).Times(1)
+			mockImportJobStore.EXPECT().RecordImportResults(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, jobID uuid.UUID, results []entities.ImportRowResult) {
+					Expect(jobID).To(Equal(createdJob.ID))
+					recordedResults = append(recordedResults, results...)
+				}).Return(nil).Times(2)
+			mockImportJobStore.EXPECT().FinishImportJob(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any(), "", gomock.Any()).
+				Do(func(_ context.Context, _ uuid.UUID, status entities.ImportJobStatus, _ string, _ time.Time) {
+					finished <- status
+				}).Return(nil).Times(1)
+		})
+
+		It("should return the job and import the file in the background", func() {
+			Expect(w.Code).To(Equal(http.StatusAccepted))
+			var response usecases.ImportJobResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.JobID).To(Equal(createdJob.ID.String()))
+			Expect(response.Status).To(Equal(string(entities.ImportJobStatusRunning)))
+			Expect(response.Format).To(Equal(entities.ImportFormatCSV))
+
+			Eventually(finished).Should(Receive(Equal(entities.ImportJobStatusSucceeded)))
+			Expect(recordedResults).To(HaveLen(5))
+		})
+	})
+})
+
+var _ = Describe("Running import jobs", func() {
+	var importer *mock_usecases.MockUserImporter
+	var jobStore *mock_usecases.MockImportJobStore
+	var corpus *mock_usecases.MockBreachCorpus
+	var userImport *usecases.UserImport
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		importer = mock_usecases.NewMockUserImporter(ctrl)
+		jobStore = mock_usecases.NewMockImportJobStore(ctrl)
+		corpus = mock_usecases.NewMockBreachCorpus(ctrl)
+		userImport = usecases.NewUserImport(
+			importer,
+			jobStore,
+			mock_usecases.NewMockChangelogWriter(ctrl),
+			usecases.NewPasswordValidator(entities.PasswordPolicy{MinLength: 10, MaxLength: 128, MinEntropyBits: 40}, corpus),
+			2,
+			"",
+		)
+	})
+
+	It("should fail running jobs when shut down, and not start any more", func() {
+		expectBreachLookup(corpus, "some-password", 0, 1)
+		jobStore.EXPECT().CreateImportJob(gomock.AssignableToTypeOf(ctxType), gomock.Any()).Return(nil).Times(1)
+		importing := make(chan struct{})
+		importer.EXPECT().ImportUsers(gomock.AssignableToTypeOf(ctxType), gomock.Any(), false).
+			DoAndReturn(func(ctx context.Context, _ []entities.ImportedUser, _ bool) ([]entities.ImportRowResult, error) {
+				close(importing)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).Times(1)
+		jobStore.EXPECT().FinishImportJob(
+			gomock.AssignableToTypeOf(ctxType),
+			gomock.Any(),
+			entities.ImportJobStatusFailed,
+			"interrupted before it finished, rows without a result weren't imported",
+			gomock.Any(),
+		).DoAndReturn(func(ctx context.Context, _ uuid.UUID, _ entities.ImportJobStatus, _ string, _ time.Time) error {
+			// the job's status is still recorded after it has been stopped
+			Expect(ctx.Err()).ToNot(HaveOccurred())
+			return nil
+		}).Times(1)
+
+		file := "first_name,last_name,nickname,password,email,country\n" +
+			"alec,smith,alecsmith,some-password,alec@email.com,UK\n"
+		_, err := userImport.StartJob(context.Background(), entities.ImportFormatCSV, strings.NewReader(file), false)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(importing).Should(BeClosed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(userImport.Shutdown(ctx)).To(Succeed())
+
+		_, err = userImport.StartJob(context.Background(), entities.ImportFormatCSV, strings.NewReader(file), false)
+		Expect(err).To(MatchError("import jobs stopped"))
+	})
+
+	It("should fail jobs that have gone without a heartbeat", func() {
+		jobStore.EXPECT().FailStaleImportJobs(
+			gomock.AssignableToTypeOf(ctxType),
+			gomock.Any(),
+			"interrupted before it finished, rows without a result weren't imported",
+			gomock.Any(),
+		).DoAndReturn(func(_ context.Context, staleBefore time.Time, _ string, now time.Time) (int, error) {
+			Expect(now.Sub(staleBefore)).To(Equal(90 * time.Second))
+			return 1, nil
+		}).Times(1)
+
+		Expect(userImport.FailInterruptedJobs(context.Background())).To(Succeed())
+	})
+
+	When("the job store returns an error", func() {
+		It("should return the error", func() {
+			jobStore.EXPECT().FailStaleImportJobs(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(0, errors.New("an error occurred")).Times(1)
+
+			err := userImport.FailInterruptedJobs(context.Background())
+			Expect(err).To(MatchError("failing interrupted import jobs: an error occurred"))
+		})
+	})
+})
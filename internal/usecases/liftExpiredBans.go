@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/expiredBanLifter.go  . "ExpiredBanLifter"
+type ExpiredBanLifter interface {
+	LiftExpiredBans(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+}
+
+// NewLiftExpiredBans returns a job that reactivates users whose ban has expired, publishing a changelog entry for each
+func NewLiftExpiredBans(expiredBanLifter ExpiredBanLifter, changelogWriter ChangelogWriter) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now()
+		userIDs, err := expiredBanLifter.LiftExpiredBans(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			entry := entities.ChangelogEntry{
+				UserID:     userID,
+				CreatedAt:  now,
+				ChangeType: entities.ChangeTypeBanExpired,
+			}
+			err = changelogWriter.PublishChangelogEntry(entry)
+			if err != nil {
+				// deliberately not returning error here as the ban has already been lifted
+				slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+			}
+		}
+
+		if len(userIDs) > 0 {
+			slog.Info("lifted expired bans", "count", len(userIDs))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Lifting expired bans", func() {
+	var mockExpiredBanLifter *mock_usecases.MockExpiredBanLifter
+	var liftedUserIDs []uuid.UUID
+	var liftExpiredBansErr error
+	var jobErr error
+
+	BeforeEach(func() {
+		mockExpiredBanLifter = mock_usecases.NewMockExpiredBanLifter(gomock.NewController(GinkgoT()))
+		liftedUserIDs = []uuid.UUID{uuid.New(), uuid.New()}
+		liftExpiredBansErr = nil
+	})
+
+	JustBeforeEach(func() {
+		mockExpiredBanLifter.EXPECT().LiftExpiredBans(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+			Return(liftedUserIDs, liftExpiredBansErr).Times(1)
+
+		for _, userID := range liftedUserIDs {
+			userID := userID
+			mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+				entry := x.(entities.ChangelogEntry)
+				return entry.UserID == userID && entry.ChangeType == entities.ChangeTypeBanExpired
+			})).Return(nil).Times(1)
+		}
+
+		jobErr = usecases.NewLiftExpiredBans(mockExpiredBanLifter, mockChangelogWriter)(context.Background())
+	})
+
+	It("should publish a changelog entry for each lifted ban", func() {
+		Expect(jobErr).ToNot(HaveOccurred())
+	})
+
+	When("the expiredBanLifter adapter returns an error", func() {
+		BeforeEach(func() {
+			liftedUserIDs = nil
+			liftExpiredBansErr = errors.New("an error occurred")
+		})
+
+		It("should return the error", func() {
+			Expect(jobErr).To(MatchError("an error occurred"))
+		})
+	})
+})
@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// ListAPIKeysResponseBody represents the response body for listing API keys
+// @Description The API keys that have been minted
+type ListAPIKeysResponseBody struct {
+	// APIKeys represents every key, including revoked and expired ones
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+// NewListAPIKeys lists API keys
+// @Summary List API keys
+// @Description Lists every API key that has been minted, without their secrets
+// @Tags admin
+// @Produce json
+// @Success 200 {object} ListAPIKeysResponseBody
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /admin/api-keys [get]
+func NewListAPIKeys(apiKeyStore APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := apiKeyStore.GetAPIKeys(c.Request.Context())
+		if err != nil {
+			slog.Error("getting api keys", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := ListAPIKeysResponseBody{
+			APIKeys: make([]APIKeyResponse, 0, len(keys)),
+		}
+		for _, key := range keys {
+			response.APIKeys = append(response.APIKeys, newAPIKeyResponse(key))
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,90 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SessionResponse represents one of a user's sessions
+// @Description A device the user is logged in on
+type SessionResponse struct {
+	// ID represents the session's identifier
+	ID uuid.UUID `json:"id"`
+	// DeviceName represents the name given to the device when logging in, or the client for OpenID Connect sessions
+	DeviceName string `json:"device_name"`
+	// UserAgent represents the user agent the session was last used from
+	UserAgent string `json:"user_agent"`
+	// IPAddress represents the IP address the session was last used from
+	IPAddress string `json:"ip_address"`
+	// CreatedAt represents when the user logged in
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt represents when the session was last refreshed, if it has been
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// ExpiresAt represents when the session ends if it isn't revoked first
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newSessionResponse(session entities.Session) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		DeviceName: session.Device.Name,
+		UserAgent:  session.Device.UserAgent,
+		IPAddress:  session.Device.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
+
+// ListSessionsResponseBody represents the response body for listing a user's sessions
+// @Description The devices the user is logged in on
+type ListSessionsResponseBody struct {
+	// Sessions represents the user's sessions that haven't expired or been revoked
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// NewListSessions lists a user's sessions
+// @Summary List sessions
+// @Description Lists the devices the user is logged in on
+// @Tags sessions
+// @Produce json
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Success 200 {object} ListSessionsResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /user/{userId}/sessions [get]
+func NewListSessions(sessionStore SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		sessions, err := sessionStore.GetSessions(c.Request.Context(), userIDUUID, time.Now().UTC())
+		if err != nil {
+			slog.Error("getting sessions", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := ListSessionsResponseBody{
+			Sessions: make([]SessionResponse, 0, len(sessions)),
+		}
+		for _, session := range sessions {
+			response.Sessions = append(response.Sessions, newSessionResponse(session))
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,103 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Listing a user's sessions", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var sessions []entities.Session
+	var getSessionsErr error
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		createdAt := time.Date(2024, 6, 27, 9, 0, 0, 0, time.UTC)
+		lastUsedAt := createdAt.Add(time.Hour)
+		sessions = []entities.Session{
+			{
+				ID:         uuid.MustParse("5f0e6a4c-5a55-4f4e-9b5e-3d1f0b1c2a01"),
+				UserID:     userID,
+				Device:     entities.SessionDevice{Name: "Alec's phone", UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.7"},
+				CreatedAt:  createdAt,
+				LastUsedAt: &lastUsedAt,
+				ExpiresAt:  createdAt.Add(720 * time.Hour),
+			},
+			{
+				ID:        uuid.MustParse("5f0e6a4c-5a55-4f4e-9b5e-3d1f0b1c2a02"),
+				UserID:    userID,
+				Device:    entities.SessionDevice{Name: "FACEIT Forum", UserAgent: "forum/1.0", IPAddress: "198.51.100.2"},
+				Scope:     "openid",
+				CreatedAt: createdAt,
+				ExpiresAt: createdAt.Add(720 * time.Hour),
+			},
+		}
+		getSessionsErr = nil
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockSessionStore.EXPECT().GetSessions(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf(time.Time{})).
+			Return(sessions, getSessionsErr).Times(1)
+
+		expectUserAccessToken(userID)
+		req, err := http.NewRequest("GET", "http://localhost:8080/user/"+userID.String()+"/sessions", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the user's sessions", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"sessions":[
+			{
+				"id":"5f0e6a4c-5a55-4f4e-9b5e-3d1f0b1c2a01",
+				"device_name":"Alec's phone",
+				"user_agent":"Mozilla/5.0",
+				"ip_address":"203.0.113.7",
+				"created_at":"2024-06-27T09:00:00Z",
+				"last_used_at":"2024-06-27T10:00:00Z",
+				"expires_at":"2024-07-27T09:00:00Z"
+			},
+			{
+				"id":"5f0e6a4c-5a55-4f4e-9b5e-3d1f0b1c2a02",
+				"device_name":"FACEIT Forum",
+				"user_agent":"forum/1.0",
+				"ip_address":"198.51.100.2",
+				"created_at":"2024-06-27T09:00:00Z",
+				"expires_at":"2024-07-27T09:00:00Z"
+			}
+		]}`))
+	})
+
+	When("the user has no sessions", func() {
+		BeforeEach(func() {
+			sessions = []entities.Session{}
+		})
+
+		It("should return an empty list", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(MatchJSON(`{"sessions":[]}`))
+		})
+	})
+
+	When("the sessionStore adapter returns an error", func() {
+		BeforeEach(func() {
+			sessions = nil
+			getSessionsErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
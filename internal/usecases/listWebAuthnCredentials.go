@@ -0,0 +1,57 @@
+package usecases
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// ListWebAuthnCredentialsResponseBody represents the response body for listing a user's WebAuthn credentials
+// @Description The passkeys and security keys registered to the user
+type ListWebAuthnCredentialsResponseBody struct {
+	// Credentials represents the user's registered credentials
+	Credentials []WebAuthnCredentialResponse `json:"credentials"`
+}
+
+// NewListWebAuthnCredentials lists a user's WebAuthn credentials
+// @Summary List WebAuthn credentials
+// @Description Lists the passkeys and security keys registered to the user
+// @Tags webauthn
+// @Produce json
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Success 200 {object} ListWebAuthnCredentialsResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /user/{userId}/webauthn/credentials [get]
+func NewListWebAuthnCredentials(webAuthnStore WebAuthnStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		credentials, err := webAuthnStore.GetWebAuthnCredentials(c.Request.Context(), userIDUUID)
+		if err != nil {
+			slog.Error("getting webauthn credentials", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := ListWebAuthnCredentialsResponseBody{
+			Credentials: make([]WebAuthnCredentialResponse, 0, len(credentials)),
+		}
+		for _, credential := range credentials {
+			response.Credentials = append(response.Credentials, newWebAuthnCredentialResponse(credential))
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
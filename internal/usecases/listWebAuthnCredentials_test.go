@@ -0,0 +1,91 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Listing WebAuthn credentials", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+
+	var credentials []entities.WebAuthnCredential
+	var getCredentialsErr error
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		lastUsedAt := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+		credentials = []entities.WebAuthnCredential{
+			{
+				ID:             uuid.New(),
+				UserID:         userID,
+				CredentialID:   []byte{0x01, 0x02, 0x03},
+				Transports:     []string{"internal", "hybrid"},
+				BackupEligible: true,
+				BackupState:    true,
+				Name:           "Phone",
+				CreatedAt:      time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC),
+				LastUsedAt:     &lastUsedAt,
+			},
+		}
+		getCredentialsErr = nil
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockWebAuthnStore.EXPECT().GetWebAuthnCredentials(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(credentials, getCredentialsErr).Times(1)
+
+		expectUserAccessToken(userID)
+		req, err := http.NewRequest("GET", "http://localhost:8080/user/"+userID.String()+"/webauthn/credentials", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the user's credentials", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.ListWebAuthnCredentialsResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Credentials).To(HaveLen(1))
+		Expect(response.Credentials[0].ID).To(Equal(credentials[0].ID.String()))
+		Expect(response.Credentials[0].CredentialID).To(Equal("AQID"))
+		Expect(response.Credentials[0].Name).To(Equal("Phone"))
+		Expect(response.Credentials[0].Transports).To(Equal([]string{"internal", "hybrid"}))
+		Expect(response.Credentials[0].BackupEligible).To(BeTrue())
+		Expect(response.Credentials[0].LastUsedAt).To(Equal(credentials[0].LastUsedAt))
+	})
+
+	When("the user has no credentials", func() {
+		BeforeEach(func() {
+			credentials = []entities.WebAuthnCredential{}
+		})
+
+		It("should return an empty list", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(MatchJSON(`{"credentials":[]}`))
+		})
+	})
+
+	When("getting the credentials fails", func() {
+		BeforeEach(func() {
+			getCredentialsErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
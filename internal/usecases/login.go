@@ -0,0 +1,289 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/sessionStore.go  . "SessionStore"
+type SessionStore interface {
+	CreateSession(ctx context.Context, session entities.Session, refreshTokenHash string) error
+	GetSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]entities.Session, error)
+	RefreshSession(ctx context.Context, refreshTokenHash, newRefreshTokenHash string, device entities.SessionDevice, now time.Time) (*entities.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, now time.Time) error
+	RevokeSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]uuid.UUID, error)
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/accessTokenSigner.go  . "AccessTokenSigner"
+type AccessTokenSigner interface {
+	SignAccessToken(token entities.AccessToken) (string, error)
+	ParseAccessToken(signedToken string) (*entities.AccessToken, error)
+}
+
+// authenticateAccessToken identifies the user presenting a bearer access token in the Authorization header. Tokens
+// that are expired, issued before the user's password was last changed, or for a user that no longer exists return
+// ErrInvalidToken.
+func authenticateAccessToken(
+	ctx context.Context,
+	authorizationHeader string,
+	signer AccessTokenSigner,
+	userGetter UserGetter,
+	now time.Time,
+) (*entities.User, *entities.AccessToken, error) {
+	scheme, signedToken, found := strings.Cut(authorizationHeader, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, nil, entities.ErrInvalidToken
+	}
+
+	token, err := signer.ParseAccessToken(strings.TrimSpace(signedToken))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !now.Before(token.ExpiresAt) {
+		return nil, nil, entities.ErrInvalidToken
+	}
+
+	user, err := userGetter.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, entities.ErrUserNotFound) {
+			return nil, nil, entities.ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+
+	if user.PasswordChangedAt != nil && token.IssuedAt.Before(*user.PasswordChangedAt) {
+		return nil, nil, entities.ErrInvalidToken
+	}
+
+	return user, token, nil
+}
+
+// LoginRequestBody represents the request body for logging in
+// @Description The user's credentials
+type LoginRequestBody struct {
+	// Email represents the user's email address
+	Email string `json:"email" binding:"required"`
+	// Password represents the user's password
+	Password string `json:"password" binding:"required"`
+	// DeviceName represents a name for the device logging in, shown when the user lists their sessions
+	DeviceName string `json:"device_name"`
+}
+
+// LoginResponseBody represents the response body for a login attempt
+// @Description Either the tokens for a new session, or an MFA challenge to complete first
+type LoginResponseBody struct {
+	// MFARequired is true when the challenge must be completed with a second factor before tokens are issued
+	MFARequired bool `json:"mfa_required"`
+	// MFAChallenge represents the challenge to submit along with a second factor
+	MFAChallenge string `json:"mfa_challenge,omitempty"`
+	// AccessToken represents the bearer token for the session
+	AccessToken string `json:"access_token,omitempty"`
+	// TokenType represents how the access token should be presented
+	TokenType string `json:"token_type,omitempty"`
+	// ExpiresIn represents the number of seconds the access token is valid for
+	ExpiresIn int `json:"expires_in,omitempty"`
+	// RefreshToken represents the token identifying the session
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// SessionIssuer starts sessions for users who have passed every login check
+type SessionIssuer struct {
+	store          SessionStore
+	signer         AccessTokenSigner
+	accessTokenTTL time.Duration
+	sessionTTL     time.Duration
+}
+
+func NewSessionIssuer(store SessionStore, signer AccessTokenSigner, accessTokenTTL, sessionTTL time.Duration) *SessionIssuer {
+	return &SessionIssuer{
+		store:          store,
+		signer:         signer,
+		accessTokenTTL: accessTokenTTL,
+		sessionTTL:     sessionTTL,
+	}
+}
+
+// Issue stores a new session for the user and returns its refresh token along with a first access token
+func (s *SessionIssuer) Issue(ctx context.Context, user entities.User, device entities.SessionDevice) (*LoginResponseBody, error) {
+	return s.IssueWithScope(ctx, user, device, "")
+}
+
+// IssueWithScope is Issue for sessions started by an OpenID Connect client, recording the scopes it was granted so that
+// they are included in every access token for the session
+func (s *SessionIssuer) IssueWithScope(ctx context.Context, user entities.User, device entities.SessionDevice, scope string) (*LoginResponseBody, error) {
+	refreshToken, refreshTokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	session := entities.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Device:    device,
+		Scope:     scope,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.sessionTTL),
+	}
+	err = s.store.CreateSession(ctx, session, refreshTokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("storing session: %w", err)
+	}
+
+	return s.issueAccessToken(session, refreshToken, now)
+}
+
+// RotateRefreshToken swaps a session's refresh token for a new one, so that each refresh token can only be used once,
+// and returns the session along with the new token. Unknown, expired and revoked sessions return ErrInvalidToken.
+func (s *SessionIssuer) RotateRefreshToken(ctx context.Context, refreshToken string, device entities.SessionDevice) (*entities.Session, string, error) {
+	newRefreshToken, newRefreshTokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	session, err := s.store.RefreshSession(ctx, hashOpaqueToken(refreshToken), newRefreshTokenHash, device, time.Now().UTC())
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, newRefreshToken, nil
+}
+
+// issueAccessToken signs a new access token for the session and returns it along with the session's refresh token
+func (s *SessionIssuer) issueAccessToken(session entities.Session, refreshToken string, now time.Time) (*LoginResponseBody, error) {
+	accessToken, err := s.signer.SignAccessToken(entities.AccessToken{
+		ID:        uuid.New(),
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.accessTokenTTL),
+		Scope:     session.Scope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing access token: %w", err)
+	}
+
+	return &LoginResponseBody{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// sessionDevice describes the device a request came from, for sessions started or refreshed by it
+func sessionDevice(c *gin.Context, deviceName string) entities.SessionDevice {
+	return entities.SessionDevice{
+		Name:      deviceName,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// NewLogin logs a user in
+// @Summary Log in
+// @Description Checks the user's credentials. Users without MFA get the tokens for a new session, users with MFA get a
+// @Description challenge to complete at /auth/login/mfa
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body LoginRequestBody true "Login Request Body"
+// @Success 200 {object} LoginResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /auth/login [post]
+func NewLogin(userGetter UserGetter, mfaManager *MFAManager, sessionIssuer *SessionIssuer, loginThrottler *LoginThrottler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request LoginRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		device := sessionDevice(c, request.DeviceName)
+		user, err := userGetter.GetUserByEmail(c.Request.Context(), request.Email)
+		if err != nil {
+			if !errors.Is(err, entities.ErrUserNotFound) {
+				slog.Error("getting user", "err", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+
+			// unknown emails are throttled too, so that probing for accounts is no faster than guessing passwords
+			user = nil
+		}
+
+		attempt := loginAttempt(device, request.Email, user)
+		if rejectThrottledLogin(c, loginThrottler, attempt) {
+			return
+		}
+
+		if user == nil {
+			slog.Warn("login for unknown email")
+			recordLoginFailure(c, loginThrottler, attempt, entities.LoginFailureUnknownEmail)
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		if !passwordMatches(*user, request.Password) {
+			slog.Warn("incorrect password", "err", entities.ErrIncorrectPassword, "userID", user.ID)
+			recordLoginFailure(c, loginThrottler, attempt, entities.LoginFailureIncorrectPassword)
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		if !user.Status.CanLogIn() {
+			slog.Warn("login for user that can't log in", "userID", user.ID, "status", user.Status)
+			c.Status(http.StatusForbidden)
+			return
+		}
+
+		mfaEnabled, err := mfaManager.Enabled(c.Request.Context(), user.ID)
+		if err != nil {
+			slog.Error("checking mfa enrolment", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		// the account's failures aren't cleared until the second factor is checked too, otherwise knowing the password
+		// would be enough to keep guessing codes
+		if mfaEnabled {
+			challenge, err := mfaManager.IssueChallenge(c.Request.Context(), *user)
+			if err != nil {
+				slog.Error("issuing mfa challenge", "err", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+
+			c.JSON(http.StatusOK, LoginResponseBody{
+				MFARequired:  true,
+				MFAChallenge: challenge,
+			})
+			return
+		}
+
+		response, err := sessionIssuer.Issue(c.Request.Context(), *user, device)
+		if err != nil {
+			slog.Error("issuing session", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		recordLoginSuccess(c, loginThrottler, attempt)
+		c.JSON(http.StatusOK, response)
+	}
+}
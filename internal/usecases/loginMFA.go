@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// LoginMFARequestBody represents the request body for completing an MFA challenge
+// @Description The challenge from /auth/login and a second factor
+type LoginMFARequestBody struct {
+	// MFAChallenge represents the challenge returned when logging in
+	MFAChallenge string `json:"mfa_challenge" binding:"required"`
+	// Code represents a code from the user's authenticator, or one of their recovery codes
+	Code string `json:"code" binding:"required"`
+	// DeviceName represents a name for the device logging in, shown when the user lists their sessions
+	DeviceName string `json:"device_name"`
+}
+
+// NewLoginMFA completes a login for a user with MFA enabled
+// @Summary Complete MFA login
+// @Description Exchanges an MFA challenge and a second factor for the tokens of a new session. The challenge can only be
+// @Description submitted once, so a wrong code means logging in again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginMFARequestBody true "Login MFA Request Body"
+// @Success 200 {object} LoginResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /auth/login/mfa [post]
+func NewLoginMFA(userGetter UserGetter, mfaManager *MFAManager, sessionIssuer *SessionIssuer, loginThrottler *LoginThrottler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request LoginMFARequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		userID, err := mfaManager.RedeemChallenge(c.Request.Context(), request.MFAChallenge)
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("invalid mfa challenge", "err", err)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("redeeming mfa challenge", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		// the account may have been suspended or banned since the password was checked
+		user, err := userGetter.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		device := sessionDevice(c, request.DeviceName)
+		attempt := loginAttempt(device, user.Email, user)
+		if rejectThrottledLogin(c, loginThrottler, attempt) {
+			return
+		}
+
+		err = mfaManager.Verify(c.Request.Context(), userID, request.Code)
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidMFACode) || errors.Is(err, entities.ErrMFANotEnrolled) {
+				slog.Warn("mfa verification failed", "err", err, "userID", userID)
+				recordLoginFailure(c, loginThrottler, attempt, entities.LoginFailureInvalidMFACode)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("verifying mfa code", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if !user.Status.CanLogIn() {
+			slog.Warn("login for user that can't log in", "userID", user.ID, "status", user.Status)
+			c.Status(http.StatusForbidden)
+			return
+		}
+
+		response, err := sessionIssuer.Issue(c.Request.Context(), *user, device)
+		if err != nil {
+			slog.Error("issuing session", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		recordLoginSuccess(c, loginThrottler, attempt)
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,270 @@
+package usecases_test
+
+import (
+	"bytes"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Completing an MFA login", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.LoginMFARequestBody
+	var secret []byte
+
+	var challenge *entities.VerificationToken
+	var parseChallengeErr error
+	var redeemChallengeErr error
+	var redeemChallengeCallCount int
+
+	var getEnrolmentCallCount int
+	var openSecretCallCount int
+	var useCounterErr error
+	var useCounterCallCount int
+	var useRecoveryCodeCallCount int
+
+	var user *entities.User
+	var getUserCallCount int
+	var sessionCallCount int
+
+	var accountThrottle *entities.LoginThrottle
+	var throttleCallCount int
+	var failureCallCount int
+	var auditCallCount int
+	var successCallCount int
+
+	BeforeEach(func() {
+		secret = []byte("12345678901234567890")
+		requestBody = &usecases.LoginMFARequestBody{
+			MFAChallenge: "signed-challenge",
+			Code:         currentTOTPCode(secret),
+		}
+
+		challenge = &entities.VerificationToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Email:     "alec@email.com",
+			Purpose:   entities.TokenPurposeMFAChallenge,
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+		parseChallengeErr = nil
+		redeemChallengeErr = nil
+		redeemChallengeCallCount = 1
+
+		getEnrolmentCallCount = 1
+		openSecretCallCount = 1
+		useCounterErr = nil
+		useCounterCallCount = 1
+		useRecoveryCodeCallCount = 0
+
+		user = &entities.User{
+			ID:     challenge.UserID,
+			Email:  "alec@email.com",
+			Status: entities.UserStatusActive,
+		}
+		getUserCallCount = 1
+		sessionCallCount = 1
+
+		accountThrottle = &entities.LoginThrottle{}
+		throttleCallCount = 1
+		failureCallCount = 0
+		auditCallCount = 1
+		successCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		confirmedAt := time.Now().UTC()
+		mockTokenSigner.EXPECT().ParseToken(requestBody.MFAChallenge).Return(challenge, parseChallengeErr).Times(1)
+		mockMFAStore.EXPECT().RedeemVerificationToken(gomock.AssignableToTypeOf(ctxType), *challenge, gomock.AssignableToTypeOf(time.Time{})).
+			Return(redeemChallengeErr).Times(redeemChallengeCallCount)
+		mockMFAStore.EXPECT().GetTOTPEnrolment(gomock.AssignableToTypeOf(ctxType), challenge.UserID).
+			Return(&entities.TOTPEnrolment{UserID: challenge.UserID, EncryptedSecret: "sealed-secret", ConfirmedAt: &confirmedAt}, nil).
+			Times(getEnrolmentCallCount)
+		mockSecretBox.EXPECT().Open("sealed-secret", challenge.UserID[:]).Return(secret, nil).Times(openSecretCallCount)
+		mockMFAStore.EXPECT().UseTOTPCounter(gomock.AssignableToTypeOf(ctxType), challenge.UserID, gomock.AssignableToTypeOf(int64(0))).
+			Return(useCounterErr).Times(useCounterCallCount)
+		mockMFAStore.EXPECT().UseRecoveryCode(gomock.AssignableToTypeOf(ctxType), challenge.UserID, gomock.AssignableToTypeOf(""), gomock.AssignableToTypeOf(time.Time{})).
+			Return(nil).Times(useRecoveryCodeCallCount)
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), challenge.UserID).Return(user, nil).Times(getUserCallCount)
+		mockSessionStore.EXPECT().CreateSession(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.Session{}), gomock.AssignableToTypeOf("")).
+			Return(nil).Times(sessionCallCount)
+		mockAccessTokenSigner.EXPECT().SignAccessToken(gomock.AssignableToTypeOf(entities.AccessToken{})).
+			Return("signed-access-token", nil).Times(sessionCallCount)
+
+		accountKey := "user:" + challenge.UserID.String()
+		mockLoginLimiter.EXPECT().GetLoginThrottle(gomock.AssignableToTypeOf(ctxType), accountKey, gomock.AssignableToTypeOf(time.Time{})).
+			Return(accountThrottle, nil).Times(throttleCallCount)
+		mockLoginLimiter.EXPECT().GetLoginThrottle(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{}, nil).Times(throttleCallCount)
+		mockLoginAuditStore.EXPECT().RecordLoginAttempt(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.LoginAttempt{})).
+			Do(func(_ any, attempt entities.LoginAttempt) {
+				Expect(*attempt.UserID).To(Equal(challenge.UserID))
+				Expect(attempt.Email).To(Equal(user.Email))
+			}).Return(nil).Times(auditCallCount)
+		mockLoginLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), accountKey, gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{Failures: 1}, nil).Times(failureCallCount)
+		mockLoginLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{Failures: 1}, nil).Times(failureCallCount)
+		mockSecurityEventPublisher.EXPECT().PublishSecurityEvent(gomock.AssignableToTypeOf(entities.SecurityEvent{})).
+			Do(func(event entities.SecurityEvent) {
+				Expect(event.Type).To(Equal(entities.SecurityEventLoginFailed))
+				Expect(event.Reason).To(Equal(entities.LoginFailureInvalidMFACode))
+			}).Return(nil).Times(failureCallCount)
+		mockLoginLimiter.EXPECT().ResetLoginThrottle(gomock.AssignableToTypeOf(ctxType), accountKey).
+			Return(nil).Times(successCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/login/mfa", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the tokens for a new session", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.LoginResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.MFARequired).To(BeFalse())
+		Expect(response.AccessToken).To(Equal("signed-access-token"))
+		Expect(response.RefreshToken).ToNot(BeEmpty())
+	})
+
+	When("a recovery code is used", func() {
+		BeforeEach(func() {
+			requestBody.Code = "abcd-efgh"
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			useRecoveryCodeCallCount = 1
+		})
+
+		It("should return the tokens for a new session", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("the code is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.Code = usecases.TOTPCode(secret, time.Now().Unix()/30+10)
+			useCounterCallCount = 0
+			sessionCallCount = 0
+			failureCallCount = 1
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the code has already been used", func() {
+		BeforeEach(func() {
+			useCounterErr = entities.ErrInvalidMFACode
+			sessionCallCount = 0
+			failureCallCount = 1
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the challenge signature is invalid", func() {
+		BeforeEach(func() {
+			parseChallengeErr = entities.ErrInvalidToken
+			redeemChallengeCallCount = 0
+			getEnrolmentCallCount = 0
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+			throttleCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the token was issued for another purpose", func() {
+		BeforeEach(func() {
+			challenge.Purpose = entities.TokenPurposeEmailVerification
+			redeemChallengeCallCount = 0
+			getEnrolmentCallCount = 0
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+			throttleCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the challenge has already been used", func() {
+		BeforeEach(func() {
+			redeemChallengeErr = entities.ErrInvalidToken
+			getEnrolmentCallCount = 0
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+			throttleCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the user was banned after passing the password check", func() {
+		BeforeEach(func() {
+			user.Status = entities.UserStatusBanned
+			sessionCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return a 403 Forbidden", func() {
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	When("the account is locked", func() {
+		BeforeEach(func() {
+			lockedUntil := time.Now().Add(30 * time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 10, LastFailureAt: time.Now(), BlockedUntil: &lockedUntil, Locked: true}
+			getEnrolmentCallCount = 0
+			openSecretCallCount = 0
+			useCounterCallCount = 0
+			sessionCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the code", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).To(Equal("1800"))
+		})
+	})
+})
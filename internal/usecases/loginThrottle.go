@@ -0,0 +1,262 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoginLimiter stores failed login counts, keyed by account or IP address
+//
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/loginLimiter.go  . "LoginLimiter"
+type LoginLimiter interface {
+	GetLoginThrottle(ctx context.Context, key string, now time.Time) (*entities.LoginThrottle, error)
+	// RecordLoginFailure applies the policy to the key's throttle and stores the result, it must be atomic as
+	// concurrent failures are exactly what it is there to count
+	RecordLoginFailure(ctx context.Context, key string, policy entities.LoginThrottlePolicy, now time.Time) (*entities.LoginThrottle, error)
+	ResetLoginThrottle(ctx context.Context, key string) error
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/loginAuditStore.go  . "LoginAuditStore"
+type LoginAuditStore interface {
+	RecordLoginAttempt(ctx context.Context, attempt entities.LoginAttempt) error
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/securityEventPublisher.go  . "SecurityEventPublisher"
+type SecurityEventPublisher interface {
+	PublishSecurityEvent(event entities.SecurityEvent) error
+}
+
+// LoginThrottler slows down password guessing. Failures are counted per account and per IP address, each with its own
+// policy, so that guessing one account's password from many addresses and guessing many accounts' passwords from one
+// address are both throttled. Accounts are keyed by user ID where the email belongs to a user, so the lockout follows
+// the account through email changes.
+type LoginThrottler struct {
+	limiter       LoginLimiter
+	auditStore    LoginAuditStore
+	publisher     SecurityEventPublisher
+	accountPolicy entities.LoginThrottlePolicy
+	ipPolicy      entities.LoginThrottlePolicy
+}
+
+func NewLoginThrottler(
+	limiter LoginLimiter,
+	auditStore LoginAuditStore,
+	publisher SecurityEventPublisher,
+	accountPolicy entities.LoginThrottlePolicy,
+	ipPolicy entities.LoginThrottlePolicy,
+) *LoginThrottler {
+	return &LoginThrottler{
+		limiter:       limiter,
+		auditStore:    auditStore,
+		publisher:     publisher,
+		accountPolicy: accountPolicy,
+		ipPolicy:      ipPolicy,
+	}
+}
+
+// RetryAfter returns how long the attempt's account and IP address have to wait before logging in, zero if they can
+// log in now. Attempts that have to wait are audited, but don't count as failures.
+func (t *LoginThrottler) RetryAfter(ctx context.Context, attempt entities.LoginAttempt) (time.Duration, error) {
+	now := time.Now().UTC()
+	var retryAfter time.Duration
+	for _, key := range loginThrottleKeys(attempt) {
+		throttle, err := t.limiter.GetLoginThrottle(ctx, key, now)
+		if err != nil {
+			return 0, fmt.Errorf("getting login throttle: %w", err)
+		}
+		retryAfter = max(retryAfter, throttle.RetryAfter(now))
+	}
+
+	if retryAfter > 0 {
+		attempt.FailureReason = entities.LoginFailureThrottled
+		t.recordAttempt(ctx, attempt, now)
+	}
+
+	return retryAfter, nil
+}
+
+// RecordFailure counts a failed login against the attempt's account and IP address, publishing an event for the
+// failure and another if it locked the account
+func (t *LoginThrottler) RecordFailure(ctx context.Context, attempt entities.LoginAttempt, reason string) error {
+	now := time.Now().UTC()
+	attempt.FailureReason = reason
+	t.recordAttempt(ctx, attempt, now)
+
+	t.publish(entities.SecurityEvent{
+		Type:      entities.SecurityEventLoginFailed,
+		UserID:    attempt.UserID,
+		Email:     attempt.Email,
+		IPAddress: attempt.IPAddress,
+		Reason:    reason,
+		CreatedAt: now,
+	})
+
+	accountThrottle, err := t.limiter.RecordLoginFailure(ctx, loginAccountKey(attempt), t.accountPolicy, now)
+	if err != nil {
+		return fmt.Errorf("recording account login failure: %w", err)
+	}
+
+	_, err = t.limiter.RecordLoginFailure(ctx, loginIPAddressKey(attempt.IPAddress), t.ipPolicy, now)
+	if err != nil {
+		return fmt.Errorf("recording ip address login failure: %w", err)
+	}
+
+	// only the failure that reaches the lockout count locks the account, later ones are rejected before they're counted
+	if accountThrottle.Locked && accountThrottle.Failures == t.accountPolicy.LockoutFailures {
+		slog.Warn("account locked after failed logins", "userID", attempt.UserID, "failures", accountThrottle.Failures)
+		t.publish(entities.SecurityEvent{
+			Type:        entities.SecurityEventAccountLocked,
+			UserID:      attempt.UserID,
+			Email:       attempt.Email,
+			IPAddress:   attempt.IPAddress,
+			CreatedAt:   now,
+			LockedUntil: accountThrottle.BlockedUntil,
+		})
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the account's failures. The IP address's aren't cleared, otherwise guessing passwords from an
+// address that also logs in to an account of its own would never be throttled.
+func (t *LoginThrottler) RecordSuccess(ctx context.Context, attempt entities.LoginAttempt) error {
+	attempt.Succeeded = true
+	t.recordAttempt(ctx, attempt, time.Now().UTC())
+
+	err := t.limiter.ResetLoginThrottle(ctx, loginAccountKey(attempt))
+	if err != nil {
+		return fmt.Errorf("resetting login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// Unlock lets an admin lift a lockout, or backoff, on a user's account before it expires
+func (t *LoginThrottler) Unlock(ctx context.Context, user entities.User) error {
+	err := t.limiter.ResetLoginThrottle(ctx, loginAccountKey(entities.LoginAttempt{UserID: &user.ID}))
+	if err != nil {
+		return fmt.Errorf("resetting login throttle: %w", err)
+	}
+
+	t.publish(entities.SecurityEvent{
+		Type:      entities.SecurityEventAccountUnlocked,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		CreatedAt: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+// recordAttempt writes the attempt to the login audit table. Failing to is logged rather than failing the login, the
+// throttling doesn't depend on it.
+func (t *LoginThrottler) recordAttempt(ctx context.Context, attempt entities.LoginAttempt, now time.Time) {
+	attempt.ID = uuid.New()
+	attempt.CreatedAt = now
+	err := t.auditStore.RecordLoginAttempt(ctx, attempt)
+	if err != nil {
+		slog.Error("recording login attempt", "err", err)
+	}
+}
+
+func (t *LoginThrottler) publish(event entities.SecurityEvent) {
+	err := t.publisher.PublishSecurityEvent(event)
+	if err != nil {
+		// deliberately not returning error here as the login attempt has already been handled
+		slog.Error("publishing security event", "err", err, "type", event.Type)
+	}
+}
+
+func loginThrottleKeys(attempt entities.LoginAttempt) []string {
+	return []string{loginAccountKey(attempt), loginIPAddressKey(attempt.IPAddress)}
+}
+
+func loginAccountKey(attempt entities.LoginAttempt) string {
+	if attempt.UserID != nil {
+		return "user:" + attempt.UserID.String()
+	}
+
+	return "email:" + strings.ToLower(strings.TrimSpace(attempt.Email))
+}
+
+func loginIPAddressKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// loginAttempt describes a login by the request's device for the email, and user if the email belongs to one
+func loginAttempt(device entities.SessionDevice, email string, user *entities.User) entities.LoginAttempt {
+	attempt := entities.LoginAttempt{
+		Email:     email,
+		IPAddress: device.IPAddress,
+		UserAgent: device.UserAgent,
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+
+	return attempt
+}
+
+// rejectThrottledLogin responds with 429 and a Retry-After header when the attempt has to wait, reporting whether it
+// responded
+func rejectThrottledLogin(c *gin.Context, throttler *LoginThrottler, attempt entities.LoginAttempt) bool {
+	retryAfter, err := throttler.RetryAfter(c.Request.Context(), attempt)
+	if err != nil {
+		slog.Error("checking login throttle", "err", err)
+		c.Status(http.StatusInternalServerError)
+		return true
+	}
+
+	if retryAfter > 0 {
+		slog.Warn("login throttled", "userID", attempt.UserID, "ipAddress", attempt.IPAddress, "retryAfter", retryAfter)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.Status(http.StatusTooManyRequests)
+		return true
+	}
+
+	return false
+}
+
+// rejectIncorrectPassword checks the password a user gives to confirm a change to their account. It is throttled with
+// their logins, so guessing passwords here is no faster than logging in. It responds with 429 or 401, reporting whether
+// it responded. A correct password doesn't clear the account's failures, only logging in does.
+func rejectIncorrectPassword(c *gin.Context, throttler *LoginThrottler, user entities.User, password string) bool {
+	attempt := loginAttempt(sessionDevice(c, ""), user.Email, &user)
+	if rejectThrottledLogin(c, throttler, attempt) {
+		return true
+	}
+
+	if !passwordMatches(user, password) {
+		slog.Warn("incorrect password", "err", entities.ErrIncorrectPassword, "userID", user.ID)
+		recordLoginFailure(c, throttler, attempt, entities.LoginFailureIncorrectPassword)
+		c.Status(http.StatusUnauthorized)
+		return true
+	}
+
+	return false
+}
+
+func recordLoginFailure(c *gin.Context, throttler *LoginThrottler, attempt entities.LoginAttempt, reason string) {
+	err := throttler.RecordFailure(c.Request.Context(), attempt, reason)
+	if err != nil {
+		// deliberately not returning error here as the login has failed either way
+		slog.Error("recording login failure", "err", err)
+	}
+}
+
+func recordLoginSuccess(c *gin.Context, throttler *LoginThrottler, attempt entities.LoginAttempt) {
+	err := throttler.RecordSuccess(c.Request.Context(), attempt)
+	if err != nil {
+		// deliberately not returning error here as request didn't fail
+		slog.Error("recording login success", "err", err)
+	}
+}
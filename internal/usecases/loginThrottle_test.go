@@ -0,0 +1,137 @@
+package usecases_test
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"time"
+)
+
+// expectPasswordCheck expects callCount checks of the user's password through the login throttler from 198.51.100.4,
+// failureCallCount of them recording a failure
+func expectPasswordCheck(userID uuid.UUID, accountThrottle *entities.LoginThrottle, callCount, failureCallCount int) {
+	expectThrottledCheck(userID, accountThrottle, entities.LoginFailureIncorrectPassword, callCount, failureCallCount)
+}
+
+// expectThrottledCheck expects callCount checks of a user's credential through the login throttler from 198.51.100.4,
+// failureCallCount of them recording a failure for reason
+func expectThrottledCheck(userID uuid.UUID, accountThrottle *entities.LoginThrottle, reason string, callCount, failureCallCount int) {
+	auditCallCount := failureCallCount
+	if accountThrottle.RetryAfter(time.Now()) > 0 {
+		// throttled checks are audited without being counted as failures
+		auditCallCount = callCount
+	}
+
+	mockLoginLimiter.EXPECT().GetLoginThrottle(gomock.AssignableToTypeOf(ctxType), "user:"+userID.String(), gomock.AssignableToTypeOf(time.Time{})).
+		Return(accountThrottle, nil).Times(callCount)
+	mockLoginLimiter.EXPECT().GetLoginThrottle(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.AssignableToTypeOf(time.Time{})).
+		Return(&entities.LoginThrottle{}, nil).Times(callCount)
+	mockLoginAuditStore.EXPECT().RecordLoginAttempt(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.LoginAttempt{})).
+		Do(func(_ any, attempt entities.LoginAttempt) {
+			Expect(*attempt.UserID).To(Equal(userID))
+			Expect(attempt.Succeeded).To(BeFalse())
+		}).Return(nil).Times(auditCallCount)
+	mockLoginLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), "user:"+userID.String(), gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+		Return(&entities.LoginThrottle{Failures: 1}, nil).Times(failureCallCount)
+	mockLoginLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+		Return(&entities.LoginThrottle{Failures: 1}, nil).Times(failureCallCount)
+	mockSecurityEventPublisher.EXPECT().PublishSecurityEvent(gomock.Cond(func(x any) bool {
+		event := x.(entities.SecurityEvent)
+		return event.Type == entities.SecurityEventLoginFailed && event.Reason == reason
+	})).Return(nil).Times(failureCallCount)
+}
+
+var _ = Describe("Recording a failed login", func() {
+	var throttleLimiter *mock_usecases.MockLoginLimiter
+	var throttleAuditStore *mock_usecases.MockLoginAuditStore
+	var throttlePublisher *mock_usecases.MockSecurityEventPublisher
+	var throttler *usecases.LoginThrottler
+	var accountPolicy entities.LoginThrottlePolicy
+	var attempt entities.LoginAttempt
+
+	var accountThrottle *entities.LoginThrottle
+	var publishedEvents []entities.SecurityEvent
+	var recordErr error
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		throttleLimiter = mock_usecases.NewMockLoginLimiter(ctrl)
+		throttleAuditStore = mock_usecases.NewMockLoginAuditStore(ctrl)
+		throttlePublisher = mock_usecases.NewMockSecurityEventPublisher(ctrl)
+
+		accountPolicy = entities.LoginThrottlePolicy{
+			FreeFailures:    3,
+			BaseDelay:       time.Second,
+			MaxDelay:        5 * time.Minute,
+			LockoutFailures: 10,
+			LockoutDuration: 30 * time.Minute,
+			ResetAfter:      time.Hour,
+		}
+		throttler = usecases.NewLoginThrottler(
+			throttleLimiter,
+			throttleAuditStore,
+			throttlePublisher,
+			accountPolicy,
+			entities.LoginThrottlePolicy{FreeFailures: 20, BaseDelay: time.Second, MaxDelay: 5 * time.Minute, ResetAfter: time.Hour},
+		)
+
+		userID := uuid.New()
+		attempt = entities.LoginAttempt{
+			UserID:    &userID,
+			Email:     "alec@email.com",
+			IPAddress: "198.51.100.4",
+			UserAgent: "faceit-test",
+		}
+		accountThrottle = &entities.LoginThrottle{Failures: 1}
+		publishedEvents = nil
+	})
+
+	JustBeforeEach(func() {
+		throttleAuditStore.EXPECT().RecordLoginAttempt(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.LoginAttempt{})).
+			DoAndReturn(func(_ context.Context, recorded entities.LoginAttempt) error {
+				Expect(recorded.ID).ToNot(Equal(uuid.Nil))
+				Expect(recorded.Succeeded).To(BeFalse())
+				Expect(recorded.FailureReason).To(Equal(entities.LoginFailureIncorrectPassword))
+				return nil
+			}).Times(1)
+		throttleLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), "user:"+attempt.UserID.String(), accountPolicy, gomock.AssignableToTypeOf(time.Time{})).
+			Return(accountThrottle, nil).Times(1)
+		throttleLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{Failures: 1}, nil).Times(1)
+		throttlePublisher.EXPECT().PublishSecurityEvent(gomock.AssignableToTypeOf(entities.SecurityEvent{})).
+			DoAndReturn(func(event entities.SecurityEvent) error {
+				publishedEvents = append(publishedEvents, event)
+				return nil
+			}).AnyTimes()
+
+		recordErr = throttler.RecordFailure(context.Background(), attempt, entities.LoginFailureIncorrectPassword)
+	})
+
+	It("should publish a login failed event", func() {
+		Expect(recordErr).ToNot(HaveOccurred())
+		Expect(publishedEvents).To(HaveLen(1))
+		Expect(publishedEvents[0].Type).To(Equal(entities.SecurityEventLoginFailed))
+		Expect(publishedEvents[0].Reason).To(Equal(entities.LoginFailureIncorrectPassword))
+		Expect(publishedEvents[0].IPAddress).To(Equal("198.51.100.4"))
+	})
+
+	When("the failure locks the account", func() {
+		BeforeEach(func() {
+			lockedUntil := time.Now().Add(30 * time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 10, BlockedUntil: &lockedUntil, Locked: true}
+		})
+
+		It("should also publish an account locked event", func() {
+			Expect(recordErr).ToNot(HaveOccurred())
+			Expect(publishedEvents).To(HaveLen(2))
+			Expect(publishedEvents[1].Type).To(Equal(entities.SecurityEventAccountLocked))
+			Expect(publishedEvents[1].UserID).To(Equal(attempt.UserID))
+			Expect(publishedEvents[1].LockedUntil).To(Equal(accountThrottle.BlockedUntil))
+		})
+	})
+})
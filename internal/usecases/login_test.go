@@ -0,0 +1,254 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Logging in", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.LoginRequestBody
+
+	var user *entities.User
+	var getUserErr error
+
+	var enrolment *entities.TOTPEnrolment
+	var getEnrolmentErr error
+	var getEnrolmentCallCount int
+
+	var challengeCallCount int
+	var sessionCallCount int
+	var createSessionErr error
+
+	var accountKey string
+	var accountThrottle *entities.LoginThrottle
+	var throttleCallCount int
+	var failureReason string
+	var failureCallCount int
+	var auditCallCount int
+	var successCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.LoginRequestBody{
+			Email:      "alec@email.com",
+			Password:   "some-password",
+			DeviceName: "Alec's laptop",
+		}
+
+		user = &entities.User{
+			ID:       uuid.New(),
+			Email:    "alec@email.com",
+			Password: "some-password",
+			Status:   entities.UserStatusActive,
+		}
+		getUserErr = nil
+
+		enrolment = nil
+		getEnrolmentErr = entities.ErrMFANotEnrolled
+		getEnrolmentCallCount = 1
+
+		challengeCallCount = 0
+		sessionCallCount = 1
+		createSessionErr = nil
+
+		accountKey = "user:" + user.ID.String()
+		accountThrottle = &entities.LoginThrottle{}
+		throttleCallCount = 1
+		failureReason = ""
+		failureCallCount = 0
+		auditCallCount = 1
+		successCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockUserGetter.EXPECT().GetUserByEmail(gomock.AssignableToTypeOf(ctxType), requestBody.Email).
+			Return(user, getUserErr).Times(1)
+		mockMFAStore.EXPECT().GetTOTPEnrolment(gomock.AssignableToTypeOf(ctxType), user.ID).
+			Return(enrolment, getEnrolmentErr).Times(getEnrolmentCallCount)
+
+		mockMFAStore.EXPECT().CreateVerificationToken(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.VerificationToken{})).
+			Do(func(_ any, token entities.VerificationToken) {
+				Expect(token.UserID).To(Equal(user.ID))
+				Expect(token.Purpose).To(Equal(entities.TokenPurposeMFAChallenge))
+			}).Return(nil).Times(challengeCallCount)
+		mockTokenSigner.EXPECT().SignToken(gomock.AssignableToTypeOf(entities.VerificationToken{})).
+			Return("signed-challenge", nil).Times(challengeCallCount)
+
+		mockSessionStore.EXPECT().CreateSession(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.Session{}), gomock.AssignableToTypeOf("")).
+			Do(func(_ any, session entities.Session, _ string) {
+				Expect(session.UserID).To(Equal(user.ID))
+				Expect(session.Device).To(Equal(entities.SessionDevice{Name: "Alec's laptop", UserAgent: "faceit-test", IPAddress: "198.51.100.4"}))
+			}).Return(createSessionErr).Times(sessionCallCount)
+		accessTokenCallCount := sessionCallCount
+		if createSessionErr != nil {
+			accessTokenCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		}
+		mockAccessTokenSigner.EXPECT().SignAccessToken(gomock.AssignableToTypeOf(entities.AccessToken{})).
+			Return("signed-access-token", nil).Times(accessTokenCallCount)
+
+		mockLoginLimiter.EXPECT().GetLoginThrottle(gomock.AssignableToTypeOf(ctxType), accountKey, gomock.AssignableToTypeOf(time.Time{})).
+			Return(accountThrottle, nil).Times(throttleCallCount)
+		mockLoginLimiter.EXPECT().GetLoginThrottle(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{}, nil).Times(throttleCallCount)
+		mockLoginAuditStore.EXPECT().RecordLoginAttempt(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.LoginAttempt{})).
+			Do(func(_ any, attempt entities.LoginAttempt) {
+				Expect(attempt.Email).To(Equal(requestBody.Email))
+				Expect(attempt.IPAddress).To(Equal("198.51.100.4"))
+				Expect(attempt.UserAgent).To(Equal("faceit-test"))
+				Expect(attempt.Succeeded).To(Equal(failureReason == ""))
+				Expect(attempt.FailureReason).To(Equal(failureReason))
+			}).Return(nil).Times(auditCallCount)
+		mockLoginLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), accountKey, gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{Failures: 1}, nil).Times(failureCallCount)
+		mockLoginLimiter.EXPECT().RecordLoginFailure(gomock.AssignableToTypeOf(ctxType), "ip:198.51.100.4", gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			Return(&entities.LoginThrottle{Failures: 1}, nil).Times(failureCallCount)
+		mockSecurityEventPublisher.EXPECT().PublishSecurityEvent(gomock.AssignableToTypeOf(entities.SecurityEvent{})).
+			Do(func(event entities.SecurityEvent) {
+				Expect(event.Type).To(Equal(entities.SecurityEventLoginFailed))
+				Expect(event.Reason).To(Equal(failureReason))
+			}).Return(nil).Times(failureCallCount)
+		mockLoginLimiter.EXPECT().ResetLoginThrottle(gomock.AssignableToTypeOf(ctxType), accountKey).
+			Return(nil).Times(successCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/login", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("User-Agent", "faceit-test")
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the tokens for a new session", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.LoginResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.MFARequired).To(BeFalse())
+		Expect(response.AccessToken).To(Equal("signed-access-token"))
+		Expect(response.TokenType).To(Equal("Bearer"))
+		Expect(response.ExpiresIn).To(Equal(int((15 * time.Minute).Seconds())))
+		Expect(response.RefreshToken).ToNot(BeEmpty())
+	})
+
+	When("the user has MFA enabled", func() {
+		BeforeEach(func() {
+			confirmedAt := time.Now().UTC()
+			enrolment = &entities.TOTPEnrolment{UserID: user.ID, ConfirmedAt: &confirmedAt}
+			getEnrolmentErr = nil
+			challengeCallCount = 1
+			sessionCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return an MFA challenge instead of tokens", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			var response usecases.LoginResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.MFARequired).To(BeTrue())
+			Expect(response.MFAChallenge).To(Equal("signed-challenge"))
+			Expect(response.AccessToken).To(BeEmpty())
+			Expect(response.RefreshToken).To(BeEmpty())
+		})
+	})
+
+	When("the user has started but not confirmed TOTP enrolment", func() {
+		BeforeEach(func() {
+			enrolment = &entities.TOTPEnrolment{UserID: user.ID}
+			getEnrolmentErr = nil
+		})
+
+		It("should return the tokens for a new session", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("no user has the email", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			getEnrolmentCallCount = 0
+			sessionCallCount = 0
+			accountKey = "email:alec@email.com"
+			failureReason = entities.LoginFailureUnknownEmail
+			failureCallCount = 1
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the password is incorrect", func() {
+		BeforeEach(func() {
+			requestBody.Password = "wrong-password"
+			getEnrolmentCallCount = 0
+			sessionCallCount = 0
+			failureReason = entities.LoginFailureIncorrectPassword
+			failureCallCount = 1
+			successCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the user is banned", func() {
+		BeforeEach(func() {
+			user.Status = entities.UserStatusBanned
+			getEnrolmentCallCount = 0
+			sessionCallCount = 0
+			auditCallCount = 0
+			successCallCount = 0
+		})
+
+		It("should return a 403 Forbidden", func() {
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	When("the account has to wait before logging in again", func() {
+		BeforeEach(func() {
+			blockedUntil := time.Now().Add(90 * time.Second)
+			accountThrottle = &entities.LoginThrottle{Failures: 5, LastFailureAt: time.Now(), BlockedUntil: &blockedUntil}
+			getEnrolmentCallCount = 0
+			sessionCallCount = 0
+			failureReason = entities.LoginFailureThrottled
+			successCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the password", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).To(Equal("90"))
+		})
+	})
+
+	When("the session can't be stored", func() {
+		BeforeEach(func() {
+			createSessionErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/piiMaintainer.go  . "PIIMaintainer"
+type PIIMaintainer interface {
+	// RewrapDataKeys wraps data keys wrapped by a previous master key with the current one, returning how many it did
+	RewrapDataKeys(ctx context.Context) (int, error)
+	// SealUsers encrypts and tokenises up to limit users whose PII is still plaintext or has no search tokens,
+	// returning how many it did
+	SealUsers(ctx context.Context, limit int) (int, error)
+}
+
+// NewMaintainPII returns a job that moves data keys on to the current master key, and seals any users still stored in
+// plaintext or without search tokens batchSize at a time. Rows already encrypted stay under the data key they were
+// sealed with, rotating the master key only rewraps the data keys.
+func NewMaintainPII(maintainer PIIMaintainer, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		rewrapped, err := maintainer.RewrapDataKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("rewrapping data keys: %w", err)
+		}
+		if rewrapped > 0 {
+			slog.Info("rewrapped data keys with current master key", "count", rewrapped)
+		}
+
+		sealed := 0
+		for {
+			count, err := maintainer.SealUsers(ctx, batchSize)
+			if err != nil {
+				return fmt.Errorf("sealing users: %w", err)
+			}
+
+			sealed += count
+			if count < batchSize {
+				break
+			}
+		}
+		if sealed > 0 {
+			slog.Info("sealed users", "count", sealed)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Maintaining PII", func() {
+	var mockPIIMaintainer *mock_usecases.MockPIIMaintainer
+	var rewrapErr error
+	var batches []int
+	var sealErr error
+	var jobErr error
+
+	BeforeEach(func() {
+		mockPIIMaintainer = mock_usecases.NewMockPIIMaintainer(gomock.NewController(GinkgoT()))
+		rewrapErr = nil
+		batches = []int{2, 2, 1}
+		sealErr = nil
+	})
+
+	JustBeforeEach(func() {
+		mockPIIMaintainer.EXPECT().RewrapDataKeys(gomock.AssignableToTypeOf(ctxType)).Return(1, rewrapErr).Times(1)
+
+		if rewrapErr == nil {
+			calls := make([]any, 0, len(batches))
+			for _, count := range batches {
+				calls = append(calls, mockPIIMaintainer.EXPECT().SealUsers(gomock.AssignableToTypeOf(ctxType), 2).
+					Return(count, sealErr).Times(1))
+			}
+			gomock.InOrder(calls...)
+		}
+
+		jobErr = usecases.NewMaintainPII(mockPIIMaintainer, 2)(context.Background())
+	})
+
+	It("should seal batches until one isn't full", func() {
+		Expect(jobErr).ToNot(HaveOccurred())
+	})
+
+	When("rewrapping data keys returns an error", func() {
+		BeforeEach(func() {
+			rewrapErr = errors.New("an error occurred")
+		})
+
+		It("should return the error", func() {
+			Expect(jobErr).To(MatchError("rewrapping data keys: an error occurred"))
+		})
+	})
+
+	When("sealing users returns an error", func() {
+		BeforeEach(func() {
+			batches = []int{0}
+			sealErr = errors.New("an error occurred")
+		})
+
+		It("should return the error", func() {
+			Expect(jobErr).To(MatchError("sealing users: an error occurred"))
+		})
+	})
+})
@@ -0,0 +1,219 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/secretBox.go  . "SecretBox"
+type SecretBox interface {
+	Seal(plaintext, associatedData []byte) (string, error)
+	Open(sealed string, associatedData []byte) ([]byte, error)
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/mfaStore.go  . "MFAStore"
+type MFAStore interface {
+	GetTOTPEnrolment(ctx context.Context, userID uuid.UUID) (*entities.TOTPEnrolment, error)
+	EnrolTOTP(ctx context.Context, userID uuid.UUID, encryptedSecret string, now time.Time) error
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, counter int64, recoveryCodeHashes []string, now time.Time) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error
+	UseTOTPCounter(ctx context.Context, userID uuid.UUID, counter int64) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) error
+	CreateVerificationToken(ctx context.Context, token entities.VerificationToken) error
+	RedeemVerificationToken(ctx context.Context, token entities.VerificationToken, now time.Time) error
+}
+
+// MFAManager handles TOTP enrolment and checks second factors, both at login and before changes to MFA itself
+type MFAManager struct {
+	store        MFAStore
+	secretBox    SecretBox
+	signer       TokenSigner
+	issuer       string
+	challengeTTL time.Duration
+}
+
+func NewMFAManager(store MFAStore, secretBox SecretBox, signer TokenSigner, issuer string, challengeTTL time.Duration) *MFAManager {
+	return &MFAManager{
+		store:        store,
+		secretBox:    secretBox,
+		signer:       signer,
+		issuer:       issuer,
+		challengeTTL: challengeTTL,
+	}
+}
+
+// Enrol generates a new TOTP secret for the user, replacing any enrolment that hasn't been confirmed, and returns it
+// along with its otpauth URI
+func (m *MFAManager) Enrol(ctx context.Context, user entities.User) (string, string, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("generating totp secret: %w", err)
+	}
+
+	encryptedSecret, err := m.secretBox.Seal(secret, user.ID[:])
+	if err != nil {
+		return "", "", fmt.Errorf("encrypting totp secret: %w", err)
+	}
+
+	err = m.store.EnrolTOTP(ctx, user.ID, encryptedSecret, time.Now().UTC())
+	if err != nil {
+		return "", "", err
+	}
+
+	return totpSecretEncoding.EncodeToString(secret), totpURI(m.issuer, user.Email, secret), nil
+}
+
+// Confirm enables the user's TOTP enrolment once they prove their authenticator is set up, returning a fresh set of
+// recovery codes
+func (m *MFAManager) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	enrolment, err := m.store.GetTOTPEnrolment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrolment.Enabled() {
+		return nil, entities.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := m.secretBox.Open(enrolment.EncryptedSecret, userID[:])
+	if err != nil {
+		return nil, fmt.Errorf("decrypting totp secret: %w", err)
+	}
+
+	counter, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, entities.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("generating recovery codes: %w", err)
+	}
+
+	err = m.store.ConfirmTOTP(ctx, userID, counter, hashes, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Enabled reports whether the user has confirmed a TOTP enrolment
+func (m *MFAManager) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	enrolment, err := m.store.GetTOTPEnrolment(ctx, userID)
+	if err != nil {
+		if errors.Is(err, entities.ErrMFANotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return enrolment.Enabled(), nil
+}
+
+// Verify checks a second factor for a user with MFA enabled. The code can either be from their authenticator or one
+// of their recovery codes, and either way it can only be used once.
+func (m *MFAManager) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	enrolment, err := m.store.GetTOTPEnrolment(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !enrolment.Enabled() {
+		return entities.ErrMFANotEnrolled
+	}
+
+	if !looksLikeTOTPCode(code) {
+		return m.store.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), time.Now().UTC())
+	}
+
+	secret, err := m.secretBox.Open(enrolment.EncryptedSecret, userID[:])
+	if err != nil {
+		return fmt.Errorf("decrypting totp secret: %w", err)
+	}
+
+	counter, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return entities.ErrInvalidMFACode
+	}
+
+	return m.store.UseTOTPCounter(ctx, userID, counter)
+}
+
+// Disable removes the user's TOTP enrolment and recovery codes after checking a second factor
+func (m *MFAManager) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	err := m.Verify(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+
+	return m.store.DisableTOTP(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a second factor
+func (m *MFAManager) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	err := m.Verify(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("generating recovery codes: %w", err)
+	}
+
+	err = m.store.ReplaceRecoveryCodes(ctx, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// IssueChallenge returns a signed, single-use challenge for a user who has passed the password check and still needs
+// to provide a second factor
+func (m *MFAManager) IssueChallenge(ctx context.Context, user entities.User) (string, error) {
+	token := entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		Purpose:   entities.TokenPurposeMFAChallenge,
+		ExpiresAt: time.Now().Add(m.challengeTTL).UTC(),
+	}
+
+	err := m.store.CreateVerificationToken(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("storing mfa challenge: %w", err)
+	}
+
+	signedToken, err := m.signer.SignToken(token)
+	if err != nil {
+		return "", fmt.Errorf("signing mfa challenge: %w", err)
+	}
+
+	return signedToken, nil
+}
+
+// RedeemChallenge uses up a challenge, returning the ID of the user it was issued to
+func (m *MFAManager) RedeemChallenge(ctx context.Context, signedChallenge string) (uuid.UUID, error) {
+	token, err := m.signer.ParseToken(signedChallenge)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if token.Purpose != entities.TokenPurposeMFAChallenge {
+		return uuid.Nil, entities.ErrInvalidToken
+	}
+
+	err = m.store.RedeemVerificationToken(ctx, *token, time.Now())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return token.UserID, nil
+}
@@ -0,0 +1,426 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/oidcStore.go  . "OIDCStore"
+type OIDCStore interface {
+	CreateOIDCClient(ctx context.Context, client entities.OIDCClient) error
+	GetOIDCClient(ctx context.Context, clientID string) (*entities.OIDCClient, error)
+	GetOIDCConsent(ctx context.Context, userID uuid.UUID, clientID string) ([]string, error)
+	GrantOIDCConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string, now time.Time) error
+	CreateOIDCAuthorizationCode(ctx context.Context, code entities.OIDCAuthorizationCode, codeHash string) error
+	RedeemOIDCAuthorizationCode(ctx context.Context, codeHash string, now time.Time) (*entities.OIDCAuthorizationCode, error)
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/idTokenSigner.go  . "IDTokenSigner"
+type IDTokenSigner interface {
+	SignIDToken(ctx context.Context, claims entities.IDTokenClaims) (string, error)
+	PublicKeys(ctx context.Context) ([]entities.JSONWebKey, error)
+}
+
+const (
+	oidcResponseTypeCode           = "code"
+	oidcGrantTypeAuthorizationCode = "authorization_code"
+	pkceMethodS256                 = "S256"
+	oidcPromptNone                 = "none"
+)
+
+// AuthorizationRequest holds the query parameters of a request to the authorize endpoint
+type AuthorizationRequest struct {
+	// ResponseType must be "code"
+	ResponseType string `form:"response_type"`
+	// ClientID represents the client the user is signing in to
+	ClientID string `form:"client_id"`
+	// RedirectURI must exactly match one of the client's registered redirect URIs
+	RedirectURI string `form:"redirect_uri"`
+	// Scope represents the space separated scopes requested, it must include "openid"
+	Scope string `form:"scope"`
+	// State is passed back to the client unchanged
+	State string `form:"state"`
+	// Nonce is copied into the ID token
+	Nonce string `form:"nonce"`
+	// CodeChallenge represents the PKCE challenge, the base64url encoded SHA-256 of the code verifier
+	CodeChallenge string `form:"code_challenge"`
+	// CodeChallengeMethod must be "S256"
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	// Prompt can be "none" to fail rather than ask the user for consent
+	Prompt string `form:"prompt"`
+}
+
+// TokenRequest holds the form parameters of a request to the token endpoint
+type TokenRequest struct {
+	// GrantType must be "authorization_code"
+	GrantType string `form:"grant_type"`
+	// Code represents the authorization code returned to the redirect URI
+	Code string `form:"code"`
+	// RedirectURI must match the redirect URI the code was issued to
+	RedirectURI string `form:"redirect_uri"`
+	// ClientID represents the client exchanging the code, it can also be given using HTTP basic authentication
+	ClientID string `form:"client_id"`
+	// ClientSecret authenticates confidential clients, it can also be given using HTTP basic authentication
+	ClientSecret string `form:"client_secret"`
+	// CodeVerifier represents the PKCE code verifier the code challenge was made from
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// TokenResponseBody represents the response body of the token endpoint
+// @Description The tokens for a session started by an OpenID Connect client
+type TokenResponseBody struct {
+	// AccessToken represents the bearer token for the session, it can be used at the userinfo endpoint
+	AccessToken string `json:"access_token"`
+	// TokenType represents how the access token should be presented
+	TokenType string `json:"token_type"`
+	// ExpiresIn represents the number of seconds the access token is valid for
+	ExpiresIn int `json:"expires_in"`
+	// RefreshToken represents the token identifying the session
+	RefreshToken string `json:"refresh_token"`
+	// IDToken represents the signed JWT describing the user
+	IDToken string `json:"id_token"`
+	// Scope represents the space separated scopes granted
+	Scope string `json:"scope"`
+}
+
+// OAuthErrorResponseBody represents an error response from the token or userinfo endpoints
+// @Description An OAuth 2.0 error
+type OAuthErrorResponseBody struct {
+	// Error represents the OAuth error code
+	Error string `json:"error"`
+	// ErrorDescription represents a human readable explanation of the error
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OIDCProvider lets registered first-party clients sign users in with the OpenID Connect authorization code flow,
+// using PKCE
+type OIDCProvider struct {
+	store             OIDCStore
+	userGetter        UserGetter
+	accessTokenSigner AccessTokenSigner
+	idTokenSigner     IDTokenSigner
+	sessionIssuer     *SessionIssuer
+	issuer            string
+	codeTTL           time.Duration
+	idTokenTTL        time.Duration
+}
+
+func NewOIDCProvider(
+	store OIDCStore,
+	userGetter UserGetter,
+	accessTokenSigner AccessTokenSigner,
+	idTokenSigner IDTokenSigner,
+	sessionIssuer *SessionIssuer,
+	issuer string,
+	codeTTL time.Duration,
+	idTokenTTL time.Duration,
+) *OIDCProvider {
+	return &OIDCProvider{
+		store:             store,
+		userGetter:        userGetter,
+		accessTokenSigner: accessTokenSigner,
+		idTokenSigner:     idTokenSigner,
+		sessionIssuer:     sessionIssuer,
+		issuer:            strings.TrimSuffix(issuer, "/"),
+		codeTTL:           codeTTL,
+		idTokenTTL:        idTokenTTL,
+	}
+}
+
+// ValidateAuthorizationRequest checks a request to the authorize endpoint, returning the client and the scopes to
+// grant it. An unknown client or redirect URI returns ErrOIDCClientNotFound or ErrInvalidRedirectURI, and must not be
+// redirected to. Anything else wrong with the request returns an OAuthError to send to the redirect URI.
+func (p *OIDCProvider) ValidateAuthorizationRequest(ctx context.Context, request AuthorizationRequest) (*entities.OIDCClient, []string, error) {
+	client, err := p.store.GetOIDCClient(ctx, request.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		return nil, nil, entities.ErrInvalidRedirectURI
+	}
+
+	if request.ResponseType != oidcResponseTypeCode {
+		return nil, nil, &entities.OAuthError{Code: entities.OAuthErrorUnsupportedResponseType, Description: "only the code response type is supported"}
+	}
+
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != pkceMethodS256 {
+		return nil, nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidRequest, Description: "a PKCE code challenge using S256 is required"}
+	}
+
+	scopes, err := grantableScopes(*client, request.Scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, scopes, nil
+}
+
+// Authorize issues an authorization code for a signed in user, returning the URI to redirect them to. If the user
+// hasn't consented to the client having the scopes yet, consentRequired is true and no code is issued.
+func (p *OIDCProvider) Authorize(
+	ctx context.Context,
+	user entities.User,
+	accessToken entities.AccessToken,
+	client entities.OIDCClient,
+	request AuthorizationRequest,
+	scopes []string,
+) (redirectURI string, consentRequired bool, err error) {
+	if !user.Status.CanLogIn() {
+		return "", false, &entities.OAuthError{Code: entities.OAuthErrorAccessDenied, Description: "the user can't log in"}
+	}
+
+	grantedScopes, err := p.store.GetOIDCConsent(ctx, user.ID, client.ID)
+	if err != nil {
+		return "", false, fmt.Errorf("getting consent: %w", err)
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(grantedScopes, scope) {
+			if request.Prompt == oidcPromptNone {
+				return "", false, &entities.OAuthError{Code: entities.OAuthErrorConsentRequired, Description: "the user hasn't consented to the requested scopes"}
+			}
+
+			return "", true, nil
+		}
+	}
+
+	code, codeHash, err := generateOpaqueToken()
+	if err != nil {
+		return "", false, fmt.Errorf("generating authorization code: %w", err)
+	}
+
+	err = p.store.CreateOIDCAuthorizationCode(ctx, entities.OIDCAuthorizationCode{
+		ClientID:      client.ID,
+		UserID:        user.ID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        scopes,
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+		AuthTime:      accessToken.IssuedAt,
+		ExpiresAt:     time.Now().Add(p.codeTTL).UTC(),
+	}, codeHash)
+	if err != nil {
+		return "", false, fmt.Errorf("storing authorization code: %w", err)
+	}
+
+	return authorizationRedirect(request.RedirectURI, url.Values{"code": {code}}, request.State), false, nil
+}
+
+// GrantConsent records that the user has allowed the client the scopes, on top of any they allowed before
+func (p *OIDCProvider) GrantConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string) error {
+	grantedScopes, err := p.store.GetOIDCConsent(ctx, userID, clientID)
+	if err != nil {
+		return fmt.Errorf("getting consent: %w", err)
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(grantedScopes, scope) {
+			grantedScopes = append(grantedScopes, scope)
+		}
+	}
+
+	err = p.store.GrantOIDCConsent(ctx, userID, clientID, grantedScopes, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("storing consent: %w", err)
+	}
+
+	return nil
+}
+
+// Exchange redeems an authorization code for the tokens of a new session, which is named after the client. Problems the
+// client should be told about are returned as an OAuthError.
+func (p *OIDCProvider) Exchange(ctx context.Context, request TokenRequest, device entities.SessionDevice) (*TokenResponseBody, error) {
+	if request.GrantType != oidcGrantTypeAuthorizationCode {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorUnsupportedGrantType, Description: "only the authorization_code grant is supported"}
+	}
+
+	client, err := p.store.GetOIDCClient(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, entities.ErrOIDCClientNotFound) {
+			return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidClient, Description: "unknown client"}
+		}
+		return nil, fmt.Errorf("getting client: %w", err)
+	}
+
+	if !client.Public() && subtle.ConstantTimeCompare([]byte(hashOpaqueToken(request.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidClient, Description: "client authentication failed"}
+	}
+
+	// the code is used up even if the rest of the request turns out to be invalid, as it may have been intercepted
+	code, err := p.store.RedeemOIDCAuthorizationCode(ctx, hashOpaqueToken(request.Code), time.Now())
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidToken) {
+			return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidGrant, Description: "the code is invalid, expired or already used"}
+		}
+		return nil, fmt.Errorf("redeeming authorization code: %w", err)
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != request.RedirectURI {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidGrant, Description: "the code was issued to another client or redirect uri"}
+	}
+
+	if !pkceVerifierMatches(request.CodeVerifier, code.CodeChallenge) {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidGrant, Description: "the code verifier doesn't match the code challenge"}
+	}
+
+	user, err := p.userGetter.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, entities.ErrUserNotFound) {
+			return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidGrant, Description: "the user no longer exists"}
+		}
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+
+	if !user.Status.CanLogIn() {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidGrant, Description: "the user can't log in"}
+	}
+
+	scope := strings.Join(code.Scopes, " ")
+	device.Name = client.Name
+	session, err := p.sessionIssuer.IssueWithScope(ctx, *user, device, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	idToken, err := p.idTokenSigner.SignIDToken(ctx, entities.IDTokenClaims{
+		UserInfoClaims: userInfoClaims(*user, code.Scopes),
+		Issuer:         p.issuer,
+		Audience:       client.ID,
+		ExpiresAt:      now.Add(p.idTokenTTL).Unix(),
+		IssuedAt:       now.Unix(),
+		AuthTime:       code.AuthTime.Unix(),
+		Nonce:          code.Nonce,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing id token: %w", err)
+	}
+
+	return &TokenResponseBody{
+		AccessToken:  session.AccessToken,
+		TokenType:    session.TokenType,
+		ExpiresIn:    session.ExpiresIn,
+		RefreshToken: session.RefreshToken,
+		IDToken:      idToken,
+		Scope:        scope,
+	}, nil
+}
+
+// UserInfo returns the claims about the user presenting the access token that its scopes allow. A token that wasn't
+// issued to an OpenID Connect client returns an OAuthError.
+func (p *OIDCProvider) UserInfo(ctx context.Context, authorizationHeader string) (*entities.UserInfoClaims, error) {
+	user, token, err := authenticateAccessToken(ctx, authorizationHeader, p.accessTokenSigner, p.userGetter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := strings.Fields(token.Scope)
+	if !slices.Contains(scopes, entities.OIDCScopeOpenID) {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorInsufficientScope, Description: "the access token wasn't granted the openid scope"}
+	}
+
+	claims := userInfoClaims(*user, scopes)
+	return &claims, nil
+}
+
+// Authenticate identifies the user presenting a bearer access token to the authorize endpoints
+func (p *OIDCProvider) Authenticate(ctx context.Context, authorizationHeader string) (*entities.User, *entities.AccessToken, error) {
+	return authenticateAccessToken(ctx, authorizationHeader, p.accessTokenSigner, p.userGetter, time.Now())
+}
+
+// PublicKeys returns the keys clients can check ID token signatures with
+func (p *OIDCProvider) PublicKeys(ctx context.Context) ([]entities.JSONWebKey, error) {
+	return p.idTokenSigner.PublicKeys(ctx)
+}
+
+// grantableScopes returns the supported scopes in a space separated scope parameter, checking the client is allowed
+// them. Unsupported scopes are ignored, as OpenID Connect requires.
+func grantableScopes(client entities.OIDCClient, scope string) ([]string, error) {
+	scopes := []string{}
+	for _, requested := range strings.Fields(scope) {
+		if !slices.Contains(entities.OIDCSupportedScopes, requested) || slices.Contains(scopes, requested) {
+			continue
+		}
+
+		if !slices.Contains(client.AllowedScopes, requested) {
+			return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidScope, Description: "the client isn't allowed the " + requested + " scope"}
+		}
+
+		scopes = append(scopes, requested)
+	}
+
+	if !slices.Contains(scopes, entities.OIDCScopeOpenID) {
+		return nil, &entities.OAuthError{Code: entities.OAuthErrorInvalidScope, Description: "the openid scope is required"}
+	}
+
+	return scopes, nil
+}
+
+// userInfoClaims maps a user onto the standard claims released for each scope
+func userInfoClaims(user entities.User, scopes []string) entities.UserInfoClaims {
+	claims := entities.UserInfoClaims{Subject: user.ID.String()}
+
+	if slices.Contains(scopes, entities.OIDCScopeProfile) {
+		claims.Nickname = user.Nickname
+		claims.GivenName = user.FirstName
+		claims.FamilyName = user.LastName
+		claims.Country = user.Country
+	}
+
+	if slices.Contains(scopes, entities.OIDCScopeEmail) {
+		emailVerified := user.EmailVerifiedAt != nil
+		claims.Email = user.Email
+		claims.EmailVerified = &emailVerified
+	}
+
+	return claims
+}
+
+// pkceVerifierMatches checks a PKCE code verifier against its S256 code challenge (RFC 7636)
+func pkceVerifierMatches(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(hash[:])), []byte(challenge)) == 1
+}
+
+// authorizationRedirect adds the response parameters, and the client's state if it sent one, to its redirect URI
+func authorizationRedirect(redirectURI string, params url.Values, state string) string {
+	location, err := url.Parse(redirectURI)
+	if err != nil {
+		// redirect URIs are checked against the client's registered ones before getting here
+		return redirectURI
+	}
+
+	query := location.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	location.RawQuery = query.Encode()
+
+	return location.String()
+}
+
+// authorizationErrorRedirect sends an OAuth error back to the client's redirect URI
+func authorizationErrorRedirect(redirectURI string, oauthErr *entities.OAuthError, state string) string {
+	return authorizationRedirect(redirectURI, url.Values{
+		"error":             {oauthErr.Code},
+		"error_description": {oauthErr.Description},
+	}, state)
+}
@@ -0,0 +1,68 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// NewOIDCToken is the OpenID Connect token endpoint
+// @Summary OpenID Connect token
+// @Description Exchanges an authorization code and its PKCE code verifier for an ID token and the tokens of a new
+// @Description session. Confidential clients authenticate with HTTP basic authentication or client_secret.
+// @Tags oidc
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Must be authorization_code"
+// @Param code formData string true "Authorization code"
+// @Param redirect_uri formData string true "The redirect URI the code was issued to"
+// @Param client_id formData string false "Client ID, if not using basic authentication"
+// @Param client_secret formData string false "Client secret, if not using basic authentication"
+// @Param code_verifier formData string true "PKCE code verifier"
+// @Success 200 {object} TokenResponseBody
+// @Failure 400 {object} OAuthErrorResponseBody
+// @Failure 401 {object} OAuthErrorResponseBody
+// @Failure 500
+// @Router /token [post]
+func NewOIDCToken(oidcProvider *OIDCProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		var request TokenRequest
+		err := c.ShouldBind(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.JSON(http.StatusBadRequest, OAuthErrorResponseBody{Error: entities.OAuthErrorInvalidRequest})
+			return
+		}
+
+		clientID, clientSecret, ok := c.Request.BasicAuth()
+		if ok {
+			request.ClientID = clientID
+			request.ClientSecret = clientSecret
+		}
+
+		response, err := oidcProvider.Exchange(c.Request.Context(), request, sessionDevice(c, ""))
+		if err != nil {
+			var oauthErr *entities.OAuthError
+			if errors.As(err, &oauthErr) {
+				slog.Warn("token request refused", "err", err, "clientID", request.ClientID)
+				status := http.StatusBadRequest
+				if oauthErr.Code == entities.OAuthErrorInvalidClient {
+					status = http.StatusUnauthorized
+				}
+				c.JSON(status, OAuthErrorResponseBody{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+				return
+			}
+
+			slog.Error("exchanging authorization code", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
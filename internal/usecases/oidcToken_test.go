@@ -0,0 +1,296 @@
+package usecases_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Exchanging an OpenID Connect authorization code", func() {
+	var w *httptest.ResponseRecorder
+	var form url.Values
+	var basicAuth []string
+
+	var client *entities.OIDCClient
+	var getClientErr error
+
+	var code *entities.OIDCAuthorizationCode
+	var redeemCodeErr error
+	var redeemCodeCallCount int
+
+	var user *entities.User
+	var getUserCallCount int
+	var sessionCallCount int
+
+	var signedClaims entities.IDTokenClaims
+	var signIDTokenCallCount int
+
+	sha256Hex := func(value string) string {
+		hash := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(hash[:])
+	}
+
+	BeforeEach(func() {
+		codeVerifier := "dBjftJeZ4CVP-mJ92K9rrKwDxRFdPEu8e3k2eFG0o3k"
+		codeChallenge := sha256.Sum256([]byte(codeVerifier))
+
+		client = &entities.OIDCClient{
+			ID:            "desktop",
+			Name:          "FACEIT Anti-Cheat",
+			RedirectURIs:  []string{"http://127.0.0.1:4711/callback"},
+			AllowedScopes: []string{"openid", "profile", "email"},
+		}
+		getClientErr = nil
+
+		form = url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {"authorization-code"},
+			"redirect_uri":  {"http://127.0.0.1:4711/callback"},
+			"client_id":     {"desktop"},
+			"code_verifier": {codeVerifier},
+		}
+		basicAuth = nil
+
+		verifiedAt := time.Now().UTC()
+		user = &entities.User{
+			ID:              uuid.New(),
+			FirstName:       "Alec",
+			LastName:        "Smith",
+			Nickname:        "AlecSmith96",
+			Email:           "alec@email.com",
+			Country:         "UK",
+			Status:          entities.UserStatusActive,
+			EmailVerifiedAt: &verifiedAt,
+		}
+		getUserCallCount = 1
+		sessionCallCount = 1
+
+		code = &entities.OIDCAuthorizationCode{
+			ClientID:      "desktop",
+			UserID:        user.ID,
+			RedirectURI:   "http://127.0.0.1:4711/callback",
+			Scopes:        []string{"openid", "profile", "email"},
+			Nonce:         "n-0S6_WzA2Mj",
+			CodeChallenge: base64.RawURLEncoding.EncodeToString(codeChallenge[:]),
+			AuthTime:      time.Now().Add(-time.Minute).UTC(),
+			ExpiresAt:     time.Now().Add(time.Minute).UTC(),
+		}
+		redeemCodeErr = nil
+		redeemCodeCallCount = 1
+
+		signedClaims = entities.IDTokenClaims{}
+		signIDTokenCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockOIDCStore.EXPECT().GetOIDCClient(gomock.AssignableToTypeOf(ctxType), client.ID).Return(client, getClientErr).Times(1)
+		mockOIDCStore.EXPECT().RedeemOIDCAuthorizationCode(gomock.AssignableToTypeOf(ctxType), sha256Hex("authorization-code"), gomock.AssignableToTypeOf(time.Time{})).
+			Return(code, redeemCodeErr).Times(redeemCodeCallCount)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, nil).Times(getUserCallCount)
+		mockSessionStore.EXPECT().CreateSession(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.Session{}), gomock.AssignableToTypeOf("")).
+			Do(func(_ any, session entities.Session, _ string) {
+				Expect(session.Device.Name).To(Equal("FACEIT Anti-Cheat"))
+				Expect(session.Scope).To(Equal(strings.Join(code.Scopes, " ")))
+			}).Return(nil).Times(sessionCallCount)
+		mockAccessTokenSigner.EXPECT().SignAccessToken(gomock.AssignableToTypeOf(entities.AccessToken{})).
+			DoAndReturn(func(token entities.AccessToken) (string, error) {
+				Expect(token.Scope).To(Equal("openid profile email"))
+				return "signed-access-token", nil
+			}).Times(sessionCallCount)
+		mockIDTokenSigner.EXPECT().SignIDToken(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.IDTokenClaims{})).
+			DoAndReturn(func(_ context.Context, claims entities.IDTokenClaims) (string, error) {
+				signedClaims = claims
+				return "signed-id-token", nil
+			}).Times(signIDTokenCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/token", strings.NewReader(form.Encode()))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basicAuth != nil {
+			req.SetBasicAuth(basicAuth[0], basicAuth[1])
+		}
+		r.ServeHTTP(w, req)
+	})
+
+	oauthError := func() string {
+		var response usecases.OAuthErrorResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		return response.Error
+	}
+
+	It("should return an ID token and the tokens of a new session", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Cache-Control")).To(Equal("no-store"))
+
+		var response usecases.TokenResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.AccessToken).To(Equal("signed-access-token"))
+		Expect(response.TokenType).To(Equal("Bearer"))
+		Expect(response.RefreshToken).ToNot(BeEmpty())
+		Expect(response.IDToken).To(Equal("signed-id-token"))
+		Expect(response.Scope).To(Equal("openid profile email"))
+
+		emailVerified := true
+		Expect(signedClaims.UserInfoClaims).To(Equal(entities.UserInfoClaims{
+			Subject:       user.ID.String(),
+			Nickname:      "AlecSmith96",
+			GivenName:     "Alec",
+			FamilyName:    "Smith",
+			Country:       "UK",
+			Email:         "alec@email.com",
+			EmailVerified: &emailVerified,
+		}))
+		Expect(signedClaims.Issuer).To(Equal("http://localhost:8080"))
+		Expect(signedClaims.Audience).To(Equal("desktop"))
+		Expect(signedClaims.Nonce).To(Equal("n-0S6_WzA2Mj"))
+		Expect(signedClaims.AuthTime).To(Equal(code.AuthTime.Unix()))
+		Expect(signedClaims.ExpiresAt - signedClaims.IssuedAt).To(Equal(int64(time.Hour.Seconds())))
+	})
+
+	When("the code verifier doesn't match the code challenge", func() {
+		BeforeEach(func() {
+			form.Set("code_verifier", "some-other-verifier-that-is-long-enough-to-be-valid")
+			getUserCallCount = 0
+			sessionCallCount = 0
+			signIDTokenCallCount = 0
+		})
+
+		It("should return an invalid_grant error", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidGrant))
+		})
+	})
+
+	When("the code has already been used", func() {
+		BeforeEach(func() {
+			redeemCodeErr = entities.ErrInvalidToken
+			getUserCallCount = 0
+			sessionCallCount = 0
+			signIDTokenCallCount = 0
+		})
+
+		It("should return an invalid_grant error", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidGrant))
+		})
+	})
+
+	When("the redirect URI doesn't match the one the code was issued to", func() {
+		BeforeEach(func() {
+			form.Set("redirect_uri", "http://127.0.0.1:4712/callback")
+			getUserCallCount = 0
+			sessionCallCount = 0
+			signIDTokenCallCount = 0
+		})
+
+		It("should return an invalid_grant error", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidGrant))
+		})
+	})
+
+	When("the code was issued to another client", func() {
+		BeforeEach(func() {
+			code.ClientID = "forum"
+			getUserCallCount = 0
+			sessionCallCount = 0
+			signIDTokenCallCount = 0
+		})
+
+		It("should return an invalid_grant error", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidGrant))
+		})
+	})
+
+	When("the user has been banned since signing in", func() {
+		BeforeEach(func() {
+			user.Status = entities.UserStatusBanned
+			sessionCallCount = 0
+			signIDTokenCallCount = 0
+		})
+
+		It("should return an invalid_grant error", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidGrant))
+		})
+	})
+
+	When("the client is unknown", func() {
+		BeforeEach(func() {
+			getClientErr = entities.ErrOIDCClientNotFound
+			redeemCodeCallCount = 0
+			getUserCallCount = 0
+			sessionCallCount = 0
+			signIDTokenCallCount = 0
+		})
+
+		It("should return an invalid_client error", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+			Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidClient))
+		})
+	})
+
+	When("the client is confidential", func() {
+		BeforeEach(func() {
+			client.SecretHash = sha256Hex("client-secret")
+			form.Del("client_id")
+			basicAuth = []string{"desktop", "client-secret"}
+		})
+
+		It("should accept the secret using basic authentication", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		When("the secret is wrong", func() {
+			BeforeEach(func() {
+				basicAuth = []string{"desktop", "wrong-secret"}
+				redeemCodeCallCount = 0
+				getUserCallCount = 0
+				sessionCallCount = 0
+				signIDTokenCallCount = 0
+			})
+
+			It("should return an invalid_client error", func() {
+				Expect(w.Code).To(Equal(http.StatusUnauthorized))
+				Expect(oauthError()).To(Equal(entities.OAuthErrorInvalidClient))
+			})
+		})
+	})
+})
+
+var _ = Describe("Exchanging a code with an unsupported grant type", func() {
+	It("should return an unsupported_grant_type error", func() {
+		w := httptest.NewRecorder()
+		form := url.Values{"grant_type": {"password"}, "client_id": {"desktop"}}
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/token", strings.NewReader(form.Encode()))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+		var response usecases.OAuthErrorResponseBody
+		err = json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Error).To(Equal(entities.OAuthErrorUnsupportedGrantType))
+	})
+})
@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/passwordManager.go  . "PasswordManager"
+type PasswordManager interface {
+	CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	GetPasswordResetTokenUser(ctx context.Context, tokenHash string, now time.Time) (*entities.User, error)
+	ResetPassword(ctx context.Context, tokenHash, newPassword string, now time.Time) (*entities.User, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, newPassword string, now time.Time) (*entities.User, error)
+}
+
+// generateOpaqueToken returns a random URL safe token along with the hash that should be stored in its place
+func generateOpaqueToken() (string, string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	return token, hashOpaqueToken(token), nil
+}
+
+// hashOpaqueToken hashes a token so that it can be looked up without the raw value being stored
+func hashOpaqueToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// passwordMatches compares a candidate password against the user's in constant time
+func passwordMatches(user entities.User, candidate string) bool {
+	return subtle.ConstantTimeCompare([]byte(user.Password), []byte(candidate)) == 1
+}
+
+// PasswordResetSender issues password reset tokens and mails them to users
+type PasswordResetSender struct {
+	passwordManager PasswordManager
+	mailer          Mailer
+	tokenTTL        time.Duration
+	resetURL        string
+}
+
+func NewPasswordResetSender(passwordManager PasswordManager, mailer Mailer, tokenTTL time.Duration, resetURL string) *PasswordResetSender {
+	return &PasswordResetSender{
+		passwordManager: passwordManager,
+		mailer:          mailer,
+		tokenTTL:        tokenTTL,
+		resetURL:        resetURL,
+	}
+}
+
+// Send stores the hash of a new password reset token for the user and mails them the token itself
+func (s *PasswordResetSender) Send(ctx context.Context, user entities.User) error {
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("generating password reset token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.tokenTTL).UTC()
+	err = s.passwordManager.CreatePasswordResetToken(ctx, user.ID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("storing password reset token: %w", err)
+	}
+
+	err = s.mailer.SendMail(ctx, entities.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Submit the following token to %s before %s to choose a new one:\n\n%s\n\nIf you didn't request this you can ignore this email.\n",
+			user.Nickname,
+			s.resetURL,
+			expiresAt.Format(time.RFC1123),
+			token,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("sending password reset email: %w", err)
+	}
+
+	return nil
+}
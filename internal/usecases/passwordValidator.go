@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// BreachCorpus looks up passwords that have appeared in data breaches using k-anonymity, in the same way as the Have I
+// Been Pwned range API. Only the first 5 characters of a password's SHA-1 hash are used to look it up.
+//
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/breachCorpus.go  . "BreachCorpus"
+type BreachCorpus interface {
+	// GetBreachedSuffixes returns how many times each breached password whose uppercase hex SHA-1 hash starts with the
+	// prefix has been seen, keyed by the remaining 35 characters of its hash
+	GetBreachedSuffixes(ctx context.Context, prefix string) (map[string]int, error)
+}
+
+// PasswordRejectedResponseBody represents the response body when a password is rejected
+// @Description The rules the password broke
+type PasswordRejectedResponseBody struct {
+	// Error represents the error code, always password_rejected
+	Error string `json:"error"`
+	// Violations represents a code for each rule the password broke
+	Violations []string `json:"violations"`
+}
+
+// PasswordValidator checks new passwords against the password policy and the breach corpus
+type PasswordValidator struct {
+	policy entities.PasswordPolicy
+	corpus BreachCorpus
+}
+
+// NewPasswordValidator creates a PasswordValidator, passwords aren't checked for breaches if corpus is nil
+func NewPasswordValidator(policy entities.PasswordPolicy, corpus BreachCorpus) *PasswordValidator {
+	return &PasswordValidator{
+		policy: policy,
+		corpus: corpus,
+	}
+}
+
+// Validate returns a *entities.PasswordPolicyError listing every rule the password breaks for the user with the
+// nickname and email
+func (v *PasswordValidator) Validate(ctx context.Context, password, nickname, email string) error {
+	violations := v.policy.Check(password, nickname, email)
+
+	if v.corpus != nil {
+		breached, err := v.breached(ctx, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, entities.PasswordRuleBreached)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &entities.PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+func (v *PasswordValidator) breached(ctx context.Context, password string) (bool, error) {
+	hash := sha1.Sum([]byte(password))
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	suffixes, err := v.corpus.GetBreachedSuffixes(ctx, hexHash[:5])
+	if err != nil {
+		return false, fmt.Errorf("looking up breached passwords: %w", err)
+	}
+
+	return suffixes[hexHash[5:]] > 0, nil
+}
+
+// respondPasswordInvalid responds with the rules the password broke, or a 500 if it couldn't be checked
+func respondPasswordInvalid(c *gin.Context, err error) {
+	var policyErr *entities.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		slog.Warn("password rejected", "violations", policyErr.Violations)
+		c.JSON(http.StatusBadRequest, PasswordRejectedResponseBody{
+			Error:      "password_rejected",
+			Violations: policyErr.Violations,
+		})
+		return
+	}
+
+	slog.Error("validating password", "err", err)
+	c.Status(http.StatusInternalServerError)
+}
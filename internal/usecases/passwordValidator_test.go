@@ -0,0 +1,130 @@
+package usecases_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"strings"
+)
+
+// expectBreachLookup expects the password's hash prefix to be looked up in the corpus, returning a range in which the
+// password has been seen count times
+func expectBreachLookup(corpus *mock_usecases.MockBreachCorpus, password string, count, times int) {
+	hash := sha1.Sum([]byte(password))
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	suffixes := map[string]int{"0018A45C4D1DEF81644B54AB7F969B88D65": 10}
+	if count > 0 {
+		suffixes[hexHash[5:]] = count
+	}
+	corpus.EXPECT().GetBreachedSuffixes(gomock.AssignableToTypeOf(ctxType), hexHash[:5]).Return(suffixes, nil).Times(times)
+}
+
+var _ = Describe("Validating a password", func() {
+	var corpus *mock_usecases.MockBreachCorpus
+	var validator *usecases.PasswordValidator
+	var password string
+	var breachCount int
+	var validateErr error
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		corpus = mock_usecases.NewMockBreachCorpus(ctrl)
+		validator = usecases.NewPasswordValidator(entities.PasswordPolicy{MinLength: 10, MaxLength: 20, MinEntropyBits: 40}, corpus)
+
+		password = "purple-monkey-dish7"
+		breachCount = 0
+	})
+
+	JustBeforeEach(func() {
+		expectBreachLookup(corpus, password, breachCount, 1)
+		validateErr = validator.Validate(context.Background(), password, "headshot", "alec@email.com")
+	})
+
+	violations := func() []string {
+		var policyErr *entities.PasswordPolicyError
+		Expect(errors.As(validateErr, &policyErr)).To(BeTrue())
+		return policyErr.Violations
+	}
+
+	It("should accept a strong password", func() {
+		Expect(validateErr).ToNot(HaveOccurred())
+	})
+
+	When("the password has been breached", func() {
+		BeforeEach(func() {
+			breachCount = 3
+		})
+
+		It("should reject it", func() {
+			Expect(violations()).To(Equal([]string{entities.PasswordRuleBreached}))
+		})
+	})
+
+	When("the password is too long", func() {
+		BeforeEach(func() {
+			password = "correct-horse-battery-staple"
+		})
+
+		It("should reject it", func() {
+			Expect(violations()).To(Equal([]string{entities.PasswordRuleTooLong}))
+		})
+	})
+
+	When("the password is long but repetitive", func() {
+		BeforeEach(func() {
+			password = "aaaaaa1234567"
+		})
+
+		It("should reject it as too predictable", func() {
+			Expect(violations()).To(Equal([]string{entities.PasswordRuleTooPredictable}))
+		})
+	})
+
+	When("the password breaks several rules", func() {
+		BeforeEach(func() {
+			password = "Alec123"
+		})
+
+		It("should return a code for each of them", func() {
+			Expect(violations()).To(Equal([]string{
+				entities.PasswordRuleTooShort,
+				entities.PasswordRuleTooPredictable,
+				entities.PasswordRuleContainsEmail,
+			}))
+		})
+	})
+
+	When("the password contains the user's nickname", func() {
+		BeforeEach(func() {
+			password = "my name is HEADSHOT"
+		})
+
+		It("should reject it", func() {
+			Expect(violations()).To(Equal([]string{entities.PasswordRuleContainsNickname}))
+		})
+	})
+})
+
+var _ = Describe("Validating a password when the breach corpus can't be read", func() {
+	It("should return the error", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		corpus := mock_usecases.NewMockBreachCorpus(ctrl)
+		corpus.EXPECT().GetBreachedSuffixes(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf("")).
+			Return(nil, errors.New("an error occurred"))
+
+		validator := usecases.NewPasswordValidator(entities.PasswordPolicy{MinLength: 10, MinEntropyBits: 40}, corpus)
+		err := validator.Validate(context.Background(), "correct-horse-battery", "alecsmith", "alec@email.com")
+		Expect(err).To(MatchError(ContainSubstring("an error occurred")))
+
+		var policyErr *entities.PasswordPolicyError
+		Expect(errors.As(err, &policyErr)).To(BeFalse())
+	})
+})
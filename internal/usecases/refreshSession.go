@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RefreshSessionRequestBody represents the request body for refreshing a session
+// @Description The session's current refresh token
+type RefreshSessionRequestBody struct {
+	// RefreshToken represents the token returned when the session was started or last refreshed
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// NewRefreshSession issues a new access token for a session
+// @Summary Refresh session
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can only be
+// @Description used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshSessionRequestBody true "Refresh Session Request Body"
+// @Success 200 {object} LoginResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /auth/refresh [post]
+func NewRefreshSession(userGetter UserGetter, sessionIssuer *SessionIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request RefreshSessionRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		session, refreshToken, err := sessionIssuer.RotateRefreshToken(c.Request.Context(), request.RefreshToken, sessionDevice(c, ""))
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("invalid refresh token", "err", err)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("refreshing session", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), session.UserID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("refresh for session of deleted user", "sessionID", session.ID)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if !user.Status.CanLogIn() {
+			slog.Warn("refresh for user that can't log in", "userID", user.ID, "status", user.Status)
+			c.Status(http.StatusForbidden)
+			return
+		}
+
+		response, err := sessionIssuer.issueAccessToken(*session, refreshToken, time.Now().UTC())
+		if err != nil {
+			slog.Error("issuing access token", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
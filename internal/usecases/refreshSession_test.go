@@ -0,0 +1,143 @@
+package usecases_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Refreshing a session", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.RefreshSessionRequestBody
+	var user *entities.User
+	var session *entities.Session
+	var newRefreshTokenHash string
+
+	var refreshErr error
+	var getUserErr error
+	var getUserCallCount int
+	var signCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.RefreshSessionRequestBody{RefreshToken: "refresh-token"}
+		user = &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		session = &entities.Session{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Scope:     "openid profile",
+			CreatedAt: time.Now().Add(-time.Hour).UTC(),
+			ExpiresAt: time.Now().Add(time.Hour).UTC(),
+		}
+		newRefreshTokenHash = ""
+		refreshErr = nil
+		getUserErr = nil
+		getUserCallCount = 1
+		signCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		refreshTokenHash := sha256.Sum256([]byte("refresh-token"))
+		mockSessionStore.EXPECT().RefreshSession(
+			gomock.AssignableToTypeOf(ctxType),
+			hex.EncodeToString(refreshTokenHash[:]),
+			gomock.AssignableToTypeOf(""),
+			entities.SessionDevice{UserAgent: "faceit-test", IPAddress: "198.51.100.4"},
+			gomock.AssignableToTypeOf(time.Time{}),
+		).DoAndReturn(func(_ any, _ string, newHash string, _ entities.SessionDevice, _ time.Time) (*entities.Session, error) {
+			newRefreshTokenHash = newHash
+			if refreshErr != nil {
+				return nil, refreshErr
+			}
+			return session, nil
+		}).Times(1)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, getUserErr).Times(getUserCallCount)
+		mockAccessTokenSigner.EXPECT().SignAccessToken(gomock.AssignableToTypeOf(entities.AccessToken{})).
+			DoAndReturn(func(token entities.AccessToken) (string, error) {
+				Expect(token.UserID).To(Equal(user.ID))
+				Expect(token.SessionID).To(Equal(session.ID))
+				Expect(token.Scope).To(Equal("openid profile"))
+				return "signed-access-token", nil
+			}).Times(signCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/refresh", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("User-Agent", "faceit-test")
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return a new access token and rotate the refresh token", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.LoginResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.AccessToken).To(Equal("signed-access-token"))
+		Expect(response.TokenType).To(Equal("Bearer"))
+		Expect(response.RefreshToken).ToNot(Equal("refresh-token"))
+
+		responseTokenHash := sha256.Sum256([]byte(response.RefreshToken))
+		Expect(newRefreshTokenHash).To(Equal(hex.EncodeToString(responseTokenHash[:])))
+	})
+
+	When("the refresh token has been used, revoked or has expired", func() {
+		BeforeEach(func() {
+			refreshErr = entities.ErrInvalidToken
+			getUserCallCount = 0
+			signCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the user has been banned", func() {
+		BeforeEach(func() {
+			user.Status = entities.UserStatusBanned
+			signCallCount = 0
+		})
+
+		It("should return a 403 Forbidden", func() {
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	When("the user has been deleted", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			signCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the sessionStore adapter returns an error", func() {
+		BeforeEach(func() {
+			refreshErr = errors.New("an error occurred")
+			getUserCallCount = 0
+			signCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
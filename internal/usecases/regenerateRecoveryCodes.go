@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRegenerateRecoveryCodes replaces a user's recovery codes
+// @Summary Regenerate recovery codes
+// @Description Replaces the user's recovery codes after checking a second factor. Any unused codes stop working.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Param request body MFACodeRequestBody true "MFA Code Request Body"
+// @Success 200 {object} RecoveryCodesResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 429
+// @Failure 500
+// @Router /user/{userId}/mfa/recovery-codes [post]
+func NewRegenerateRecoveryCodes(
+	userGetter UserGetter,
+	mfaManager *MFAManager,
+	changelogWriter ChangelogWriter,
+	loginThrottler *LoginThrottler,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		var request MFACodeRequestBody
+		err = c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		// codes are throttled with the user's logins, so they can't be guessed here any faster than when logging in
+		attempt := loginAttempt(sessionDevice(c, ""), user.Email, user)
+		if rejectThrottledLogin(c, loginThrottler, attempt) {
+			return
+		}
+
+		recoveryCodes, err := mfaManager.RegenerateRecoveryCodes(c.Request.Context(), userIDUUID, request.Code)
+		if err != nil {
+			if errors.Is(err, entities.ErrMFANotEnrolled) {
+				slog.Warn("mfa not enabled", "userID", userIDUUID)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			if errors.Is(err, entities.ErrInvalidMFACode) {
+				slog.Warn("invalid mfa code", "userID", userIDUUID)
+				recordLoginFailure(c, loginThrottler, attempt, entities.LoginFailureInvalidMFACode)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("regenerating recovery codes", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     userIDUUID,
+			CreatedAt:  time.Now().UTC(),
+			ChangeType: entities.ChangeTypeRecoveryCodesRegenerated,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.JSON(http.StatusOK, RecoveryCodesResponseBody{RecoveryCodes: recoveryCodes})
+	}
+}
@@ -0,0 +1,122 @@
+package usecases_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Regenerating recovery codes", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.MFACodeRequestBody
+	var userID uuid.UUID
+
+	var usedRecoveryCodeHash string
+	var useRecoveryCodeErr error
+	var getEnrolmentCallCount int
+	var replaceCallCount int
+	var changelogWriterCallCount int
+	var accountThrottle *entities.LoginThrottle
+	var codeCheckCallCount int
+	var codeFailureCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.MFACodeRequestBody{Code: "ABCD-EFGH"}
+		userID = uuid.New()
+
+		usedRecoveryCodeHash = ""
+		useRecoveryCodeErr = nil
+		getEnrolmentCallCount = 1
+		replaceCallCount = 1
+		changelogWriterCallCount = 1
+		accountThrottle = &entities.LoginThrottle{}
+		codeCheckCallCount = 1
+		codeFailureCallCount = 0
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		expectUserAccessToken(userID)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(&entities.User{ID: userID, Email: "alec@email.com"}, nil).Times(1)
+		expectThrottledCheck(userID, accountThrottle, entities.LoginFailureInvalidMFACode, codeCheckCallCount, codeFailureCallCount)
+		confirmedAt := time.Now().UTC()
+		mockMFAStore.EXPECT().GetTOTPEnrolment(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(&entities.TOTPEnrolment{UserID: userID, EncryptedSecret: "sealed-secret", ConfirmedAt: &confirmedAt}, nil).Times(getEnrolmentCallCount)
+		mockMFAStore.EXPECT().UseRecoveryCode(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf(""), gomock.AssignableToTypeOf(time.Time{})).
+			DoAndReturn(func(_ any, _ uuid.UUID, codeHash string, _ time.Time) error {
+				usedRecoveryCodeHash = codeHash
+				return useRecoveryCodeErr
+			}).Times(getEnrolmentCallCount)
+		mockMFAStore.EXPECT().ReplaceRecoveryCodes(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf([]string{})).
+			Do(func(_ any, _ uuid.UUID, recoveryCodeHashes []string) {
+				Expect(recoveryCodeHashes).To(HaveLen(10))
+			}).Return(nil).Times(replaceCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			Do(func(entry entities.ChangelogEntry) {
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeRecoveryCodesRegenerated))
+			}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/user/%s/mfa/recovery-codes", userID), bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		req.RemoteAddr = "198.51.100.4:51234"
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return a new set of recovery codes", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.RecoveryCodesResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.RecoveryCodes).To(HaveLen(10))
+	})
+
+	It("should ignore the case and separators the recovery code was typed with", func() {
+		hash := sha256.Sum256([]byte("abcdefgh"))
+		Expect(usedRecoveryCodeHash).To(Equal(hex.EncodeToString(hash[:])))
+	})
+
+	When("the recovery code has already been used", func() {
+		BeforeEach(func() {
+			useRecoveryCodeErr = entities.ErrInvalidMFACode
+			replaceCallCount = 0
+			changelogWriterCallCount = 0
+			codeFailureCallCount = 1
+		})
+
+		It("should return a 401 Unauthorized and count the failure", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the account is throttled after failed logins", func() {
+		BeforeEach(func() {
+			blockedUntil := time.Now().Add(time.Minute)
+			accountThrottle = &entities.LoginThrottle{Failures: 4, BlockedUntil: &blockedUntil}
+			getEnrolmentCallCount = 0
+			replaceCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 429 Too Many Requests without checking the code", func() {
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(w.Header().Get("Retry-After")).ToNot(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ResetPasswordRequestBody represents the request body for resetting a password
+// @Description The emailed reset token and the new password
+type ResetPasswordRequestBody struct {
+	// Token represents the password reset token from the email
+	Token string `json:"token" binding:"required"`
+	// NewPassword represents the password to set
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// NewResetPassword completes a password reset
+// @Summary Reset password
+// @Description Redeems a password reset token and sets the user's new password, invalidating any credentials issued before the change
+// @Tags auth
+// @Accept json
+// @Param request body ResetPasswordRequestBody true "Reset Password Request Body"
+// @Success 200
+// @Failure 400 {object} PasswordRejectedResponseBody
+// @Failure 500
+// @Router /auth/password/reset [post]
+func NewResetPassword(passwordManager PasswordManager, changelogWriter ChangelogWriter, passwordValidator *PasswordValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ResetPasswordRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		tokenHash := hashOpaqueToken(request.Token)
+		tokenUser, err := passwordManager.GetPasswordResetTokenUser(c.Request.Context(), tokenHash, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("password reset token not redeemable", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting password reset token user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		// the token is only redeemed once the password is accepted, so a rejected password can be retried
+		err = passwordValidator.Validate(c.Request.Context(), request.NewPassword, tokenUser.Nickname, tokenUser.Email)
+		if err != nil {
+			respondPasswordInvalid(c, err)
+			return
+		}
+
+		user, err := passwordManager.ResetPassword(c.Request.Context(), tokenHash, request.NewPassword, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("password reset token not redeemable", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("resetting password", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     user.ID,
+			CreatedAt:  user.UpdatedAt,
+			ChangeType: entities.ChangeTypePasswordReset,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
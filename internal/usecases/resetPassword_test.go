@@ -0,0 +1,154 @@
+package usecases_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Resetting a password", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.ResetPasswordRequestBody
+	var resetPasswordResponse *entities.User
+	var resetPasswordErr error
+	var resetPasswordCallCount int
+	var changelogWriterCallCount int
+	var tokenUser *entities.User
+	var getTokenUserErr error
+	var getTokenUserCallCount int
+	var breachCount int
+	var breachLookupCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.ResetPasswordRequestBody{
+			Token:       "reset-token",
+			NewPassword: "new-password",
+		}
+		resetPasswordResponse = &entities.User{
+			ID:        uuid.New(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		resetPasswordErr = nil
+		resetPasswordCallCount = 1
+		changelogWriterCallCount = 1
+		tokenUser = &entities.User{
+			ID:       resetPasswordResponse.ID,
+			Nickname: "alecsmith",
+			Email:    "alec@email.com",
+		}
+		getTokenUserErr = nil
+		getTokenUserCallCount = 1
+		breachCount = 0
+		breachLookupCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		tokenHash := sha256.Sum256([]byte(requestBody.Token))
+		mockPasswordManager.EXPECT().GetPasswordResetTokenUser(
+			gomock.AssignableToTypeOf(ctxType),
+			hex.EncodeToString(tokenHash[:]),
+			gomock.AssignableToTypeOf(time.Time{}),
+		).Return(tokenUser, getTokenUserErr).Times(getTokenUserCallCount)
+		expectBreachLookup(mockBreachCorpus, requestBody.NewPassword, breachCount, breachLookupCallCount)
+		mockPasswordManager.EXPECT().ResetPassword(
+			gomock.AssignableToTypeOf(ctxType),
+			hex.EncodeToString(tokenHash[:]),
+			requestBody.NewPassword,
+			gomock.AssignableToTypeOf(time.Time{}),
+		).Return(resetPasswordResponse, resetPasswordErr).Times(resetPasswordCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(entities.ChangelogEntry{
+			UserID:     resetPasswordResponse.ID,
+			CreatedAt:  resetPasswordResponse.UpdatedAt,
+			ChangeType: entities.ChangeTypePasswordReset,
+		}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/auth/password/reset", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return a 200 OK", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the request fails to validate", func() {
+		BeforeEach(func() {
+			requestBody.NewPassword = ""
+			resetPasswordCallCount = 0
+			changelogWriterCallCount = 0
+			getTokenUserCallCount = 0
+			breachLookupCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the token is invalid, expired or used", func() {
+		BeforeEach(func() {
+			getTokenUserErr = entities.ErrInvalidToken
+			breachLookupCallCount = 0
+			resetPasswordCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the token is redeemed by another request first", func() {
+		BeforeEach(func() {
+			resetPasswordErr = entities.ErrInvalidToken
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the new password contains the user's email", func() {
+		BeforeEach(func() {
+			requestBody.NewPassword = "alec@email.com-2024"
+			resetPasswordCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request without redeeming the token", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+			var response usecases.PasswordRejectedResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Violations).To(Equal([]string{entities.PasswordRuleContainsEmail}))
+		})
+	})
+
+	When("the passwordManager adapter returns generic error", func() {
+		BeforeEach(func() {
+			resetPasswordErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
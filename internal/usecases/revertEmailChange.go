@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRevertEmailChange reverts an email change
+// @Summary Revert email change
+// @Description Redeems the token sent to the previous address, restoring it as the user's email and cancelling any pending change
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body EmailChangeTokenRequestBody true "Email Change Token Request Body"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 500
+// @Router /email-change/revert [post]
+func NewRevertEmailChange(tokenSigner TokenSigner, emailChangeStore EmailChangeStore, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request EmailChangeTokenRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		token, err := tokenSigner.ParseToken(request.Token)
+		if err != nil || token.Purpose != entities.TokenPurposeEmailChangeRevert {
+			slog.Warn("invalid email change revert token", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := emailChangeStore.RevertEmailChange(c.Request.Context(), *token, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) || errors.Is(err, entities.ErrEmailAlreadyUsed) {
+				slog.Warn("unable to revert email change", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("reverting email change", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     user.ID,
+			CreatedAt:  user.UpdatedAt,
+			ChangeType: entities.ChangeTypeEmailChangeReverted,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.JSON(http.StatusOK, newUserResponse(*user))
+	}
+}
@@ -0,0 +1,136 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Reverting an email change", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.EmailChangeTokenRequestBody
+	var parsedToken *entities.VerificationToken
+	var parseTokenErr error
+	var emailChangeResponse *entities.User
+	var emailChangeErr error
+	var emailChangeCallCount int
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.EmailChangeTokenRequestBody{Token: "signed-token"}
+		parsedToken = &entities.VerificationToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Email:     "alec@email.com",
+			Purpose:   entities.TokenPurposeEmailChangeRevert,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		parseTokenErr = nil
+		changedAt := time.Now().UTC()
+		emailChangeResponse = &entities.User{
+			ID:              parsedToken.UserID,
+			Email:           "alec@email.com",
+			Status:          entities.UserStatusActive,
+			EmailVerifiedAt: &changedAt,
+			UpdatedAt:       changedAt,
+		}
+		emailChangeErr = nil
+		emailChangeCallCount = 1
+		changelogWriterCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockTokenSigner.EXPECT().ParseToken(requestBody.Token).Return(parsedToken, parseTokenErr).Times(1)
+		mockEmailChangeStore.EXPECT().RevertEmailChange(gomock.AssignableToTypeOf(ctxType), *parsedToken, gomock.AssignableToTypeOf(time.Time{})).
+			Return(emailChangeResponse, emailChangeErr).Times(emailChangeCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(entities.ChangelogEntry{
+			UserID:     emailChangeResponse.ID,
+			CreatedAt:  emailChangeResponse.UpdatedAt,
+			ChangeType: entities.ChangeTypeEmailChangeReverted,
+		}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/email-change/revert", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the updated user", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var user usecases.UserResponse
+		err := json.NewDecoder(w.Body).Decode(&user)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(user.Email).To(Equal(emailChangeResponse.Email))
+		Expect(user.PendingEmail).To(BeNil())
+	})
+
+	When("the token signature is invalid", func() {
+		BeforeEach(func() {
+			parseTokenErr = entities.ErrInvalidToken
+			emailChangeCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the token was issued for another purpose", func() {
+		BeforeEach(func() {
+			parsedToken.Purpose = "something_else"
+			emailChangeCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the token has already been used", func() {
+		BeforeEach(func() {
+			emailChangeErr = entities.ErrInvalidToken
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the previous email has been claimed by another user since the change was requested", func() {
+		BeforeEach(func() {
+			emailChangeErr = entities.ErrEmailAlreadyUsed
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the emailChangeStore adapter returns generic error", func() {
+		BeforeEach(func() {
+			emailChangeErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRevokeAPIKey revokes an API key
+// @Summary Revoke API key
+// @Description Stops an API key from working, requests already authenticated with it are unaffected
+// @Tags admin
+// @Param apiKeyId path string true "API Key ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /admin/api-keys/{apiKeyId} [delete]
+func NewRevokeAPIKey(apiKeyStore APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKeyID := c.Param("apiKeyId")
+
+		apiKeyIDUUID, err := uuid.Parse(apiKeyID)
+		if err != nil {
+			slog.Error("invalid apiKeyID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err = apiKeyStore.RevokeAPIKey(c.Request.Context(), apiKeyIDUUID, time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, entities.ErrAPIKeyNotFound) {
+				slog.Warn("api key not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("revoking api key", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
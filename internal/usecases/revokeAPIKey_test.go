@@ -0,0 +1,72 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Revoking an API key", func() {
+	var w *httptest.ResponseRecorder
+	var apiKeyID string
+	var revokeErr error
+	var revokeCallCount int
+
+	BeforeEach(func() {
+		apiKeyID = uuid.New().String()
+		revokeErr = nil
+		revokeCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockAPIKeyStore.EXPECT().RevokeAPIKey(gomock.AssignableToTypeOf(ctxType), gomock.Any(), gomock.AssignableToTypeOf(time.Time{})).
+			Return(revokeErr).Times(revokeCallCount)
+
+		req, err := http.NewRequest("DELETE", "http://localhost:8080/admin/api-keys/"+apiKeyID, nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should revoke the key", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the key doesn't exist or is already revoked", func() {
+		BeforeEach(func() {
+			revokeErr = entities.ErrAPIKeyNotFound
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("revoking the key fails", func() {
+		BeforeEach(func() {
+			revokeErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	When("the key ID isn't a uuid", func() {
+		BeforeEach(func() {
+			apiKeyID = "not-a-uuid"
+			revokeCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
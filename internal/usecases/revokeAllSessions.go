@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRevokeAllSessions logs a user out everywhere
+// @Summary Revoke all sessions
+// @Description Ends every one of the user's sessions, so they have to log in again on all of their devices
+// @Tags sessions
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /user/{userId}/sessions [delete]
+func NewRevokeAllSessions(sessionStore SessionStore, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		sessionIDs, err := sessionStore.RevokeSessions(c.Request.Context(), userIDUUID, now)
+		if err != nil {
+			slog.Error("revoking sessions", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if len(sessionIDs) > 0 {
+			entry := entities.ChangelogEntry{
+				UserID:     userIDUUID,
+				CreatedAt:  now,
+				ChangeType: entities.ChangeTypeSessionsRevoked,
+				SessionIDs: sessionIDs,
+			}
+			err = changelogWriter.PublishChangelogEntry(entry)
+			if err != nil {
+				// deliberately not returning error here as request didn't fail
+				slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+			}
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
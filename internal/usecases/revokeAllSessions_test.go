@@ -0,0 +1,76 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Revoking all of a user's sessions", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var revokedSessionIDs []uuid.UUID
+
+	var revokeErr error
+	var changelogCallCount int
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		revokedSessionIDs = []uuid.UUID{uuid.New(), uuid.New()}
+		revokeErr = nil
+		changelogCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockSessionStore.EXPECT().RevokeSessions(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf(time.Time{})).
+			Return(revokedSessionIDs, revokeErr).Times(1)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			DoAndReturn(func(entry entities.ChangelogEntry) error {
+				Expect(entry.UserID).To(Equal(userID))
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeSessionsRevoked))
+				Expect(entry.SessionIDs).To(Equal(revokedSessionIDs))
+				return nil
+			}).Times(changelogCallCount)
+
+		expectUserAccessToken(userID)
+		req, err := http.NewRequest("DELETE", "http://localhost:8080/user/"+userID.String()+"/sessions", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should revoke the sessions and publish the ones that ended", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the user has no sessions", func() {
+		BeforeEach(func() {
+			revokedSessionIDs = []uuid.UUID{}
+			changelogCallCount = 0
+		})
+
+		It("should not publish a changelog entry", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("the sessionStore adapter returns an error", func() {
+		BeforeEach(func() {
+			revokedSessionIDs = nil
+			revokeErr = errors.New("an error occurred")
+			changelogCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRevokeSession logs a user out of one of their sessions
+// @Summary Revoke session
+// @Description Ends one of the user's sessions so that it can no longer be refreshed. Access tokens already issued for it
+// @Description remain valid until they expire, services should drop the session when they see the changelog entry.
+// @Tags sessions
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /user/{userId}/sessions/{sessionId} [delete]
+func NewRevokeSession(sessionStore SessionStore, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		sessionID := c.Param("sessionId")
+
+		sessionIDUUID, err := uuid.Parse(sessionID)
+		if err != nil {
+			slog.Error("invalid sessionID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		err = sessionStore.RevokeSession(c.Request.Context(), userIDUUID, sessionIDUUID, now)
+		if err != nil {
+			if errors.Is(err, entities.ErrSessionNotFound) {
+				slog.Warn("session not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("revoking session", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     userIDUUID,
+			CreatedAt:  now,
+			ChangeType: entities.ChangeTypeSessionsRevoked,
+			SessionIDs: []uuid.UUID{sessionIDUUID},
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,92 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Revoking a session", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var sessionID string
+
+	var revokeErr error
+	var revokeCallCount int
+	var changelogCallCount int
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		sessionID = uuid.New().String()
+		revokeErr = nil
+		revokeCallCount = 1
+		changelogCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockSessionStore.EXPECT().RevokeSession(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(time.Time{})).
+			DoAndReturn(func(_ any, _ uuid.UUID, id uuid.UUID, _ time.Time) error {
+				Expect(id.String()).To(Equal(sessionID))
+				return revokeErr
+			}).Times(revokeCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			DoAndReturn(func(entry entities.ChangelogEntry) error {
+				Expect(entry.UserID).To(Equal(userID))
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeSessionsRevoked))
+				Expect(entry.SessionIDs).To(Equal([]uuid.UUID{uuid.MustParse(sessionID)}))
+				return nil
+			}).Times(changelogCallCount)
+
+		expectUserAccessToken(userID)
+		req, err := http.NewRequest("DELETE", "http://localhost:8080/user/"+userID.String()+"/sessions/"+sessionID, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should revoke the session and publish the session that ended", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the session doesn't belong to the user or has already ended", func() {
+		BeforeEach(func() {
+			revokeErr = entities.ErrSessionNotFound
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the sessionStore adapter returns an error", func() {
+		BeforeEach(func() {
+			revokeErr = errors.New("an error occurred")
+			changelogCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	When("the session ID is invalid", func() {
+		BeforeEach(func() {
+			sessionID = "not-a-uuid"
+			revokeCallCount = 0
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRevokeWebAuthnCredential revokes one of a user's WebAuthn credentials
+// @Summary Revoke WebAuthn credential
+// @Description Removes a passkey or security key so it can no longer be used to log in
+// @Tags webauthn
+// @Param Authorization header string true "Bearer access token for the user"
+// @Param userId path string true "User ID"
+// @Param credentialId path string true "Credential ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /user/{userId}/webauthn/credentials/{credentialId} [delete]
+func NewRevokeWebAuthnCredential(webAuthnStore WebAuthnStore, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		credentialID := c.Param("credentialId")
+
+		credentialIDUUID, err := uuid.Parse(credentialID)
+		if err != nil {
+			slog.Error("invalid credentialID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		err = webAuthnStore.DeleteWebAuthnCredential(c.Request.Context(), userIDUUID, credentialIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrWebAuthnCredentialNotFound) {
+				slog.Warn("webauthn credential not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("deleting webauthn credential", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     userIDUUID,
+			CreatedAt:  time.Now().UTC(),
+			ChangeType: entities.ChangeTypeWebAuthnCredentialRevoked,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
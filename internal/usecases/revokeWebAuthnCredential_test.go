@@ -0,0 +1,78 @@
+package usecases_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Revoking a WebAuthn credential", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var credentialID string
+
+	var deleteErr error
+	var deleteCallCount int
+	var changelogCallCount int
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		credentialID = uuid.New().String()
+		deleteErr = nil
+		deleteCallCount = 1
+		changelogCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockWebAuthnStore.EXPECT().DeleteWebAuthnCredential(gomock.AssignableToTypeOf(ctxType), userID, gomock.AssignableToTypeOf(uuid.UUID{})).
+			DoAndReturn(func(_ any, _ uuid.UUID, id uuid.UUID) error {
+				Expect(id.String()).To(Equal(credentialID))
+				return deleteErr
+			}).Times(deleteCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			DoAndReturn(func(entry entities.ChangelogEntry) error {
+				Expect(entry.UserID).To(Equal(userID))
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeWebAuthnCredentialRevoked))
+				return nil
+			}).Times(changelogCallCount)
+
+		expectUserAccessToken(userID)
+		req, err := http.NewRequest("DELETE", "http://localhost:8080/user/"+userID.String()+"/webauthn/credentials/"+credentialID, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should delete the credential", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the credential doesn't belong to the user", func() {
+		BeforeEach(func() {
+			deleteErr = entities.ErrWebAuthnCredentialNotFound
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the credential ID is invalid", func() {
+		BeforeEach(func() {
+			credentialID = "not-a-uuid"
+			deleteCallCount = 0
+			changelogCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
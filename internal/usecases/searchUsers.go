@@ -0,0 +1,108 @@
+package usecases
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// defaultSearchLimit is how many users a search returns when it doesn't say
+const defaultSearchLimit = 20
+
+// SearchUsersRequest holds the query parameters for searching users
+type SearchUsersRequest struct {
+	// Query represents the text to search for
+	Query string `form:"q" binding:"required,max=200"`
+	// Limit represents the most users to return, 20 by default
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SearchUsersResponseBody represents the response body for searching users
+// @Description The users matching the search, best match first
+type SearchUsersResponseBody struct {
+	// Results represents the matching users, best match first
+	Results []UserSearchResultResponse `json:"results"`
+}
+
+// UserSearchResultResponse represents a user matching a search
+// @Description A user matching a search and where it matched
+type UserSearchResultResponse struct {
+	// User represents the matching user
+	User PublicUserResponse `json:"user"`
+	// Score represents how well the user matched, between 0 and 1 where 1 is an exact match
+	Score float64 `json:"score"`
+	// Highlights represents each field that matched, HTML escaped with the matching parts wrapped in <mark> tags
+	Highlights map[string]string `json:"highlights"`
+}
+
+// NewSearchUsers searches for users
+// @Summary Search users
+// @Description Searches users by nickname, names and email, best match first. Nicknames are matched by trigram
+// @Description similarity and full text search, names and emails by trigram similarity once decrypted, so typos are
+// @Description tolerated in all of them.
+// @Tags users
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Most users to return, 20 by default and at most 100"
+// @Success 200 {object} SearchUsersResponseBody
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /users/search [get]
+func NewSearchUsers(userSearcher UserSearcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request SearchUsersRequest
+		err := c.ShouldBindQuery(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if request.Limit == 0 {
+			request.Limit = defaultSearchLimit
+		}
+
+		results, err := userSearcher.SearchUsers(c.Request.Context(), request.Query, request.Limit)
+		if err != nil {
+			slog.Error("searching users", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		response := SearchUsersResponseBody{
+			Results: make([]UserSearchResultResponse, len(results)),
+		}
+		for i, result := range results {
+			highlights := make(map[string]string, len(result.MatchedFields))
+			for _, field := range result.MatchedFields {
+				highlights[field] = highlightMatch(searchFieldValue(result.User, field), request.Query)
+			}
+
+			response.Results[i] = UserSearchResultResponse{
+				User:       newPublicUserResponse(result.User),
+				Score:      result.Score,
+				Highlights: highlights,
+			}
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func searchFieldValue(user entities.User, field string) string {
+	switch field {
+	case entities.SearchFieldNickname:
+		return user.Nickname
+	case entities.SearchFieldFirstName:
+		return user.FirstName
+	case entities.SearchFieldLastName:
+		return user.LastName
+	case entities.SearchFieldEmail:
+		return user.Email
+	}
+
+	return ""
+}
@@ -0,0 +1,120 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+var _ = Describe("Searching users", func() {
+	var w *httptest.ResponseRecorder
+	var query string
+	var expectedQuery string
+	var expectedLimit int
+	var results []entities.UserSearchResult
+	var searchUsersErr error
+	var searchUsersCallCount int
+
+	BeforeEach(func() {
+		query = "?q=" + url.QueryEscape("alec smth")
+		expectedQuery = "alec smth"
+		expectedLimit = 20
+		results = []entities.UserSearchResult{
+			{
+				User:          entities.User{ID: uuid.New(), FirstName: "alec", LastName: "smith", Nickname: "alec_smith", Password: "some-password", Email: "alec@email.com"},
+				Score:         1,
+				MatchedFields: []string{entities.SearchFieldNickname, entities.SearchFieldFirstName},
+			},
+			{
+				User:          entities.User{ID: uuid.New(), FirstName: "jane", LastName: "doe", Nickname: "<smithy>", Email: "jane@email.com"},
+				Score:         0.5,
+				MatchedFields: []string{entities.SearchFieldNickname},
+			},
+		}
+		searchUsersErr = nil
+		searchUsersCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserSearcher.EXPECT().SearchUsers(gomock.AssignableToTypeOf(ctxType), expectedQuery, expectedLimit).
+			Return(results, searchUsersErr).Times(searchUsersCallCount)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/users/search"+query, nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the users in order with the matching parts of each matched field highlighted", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).ToNot(ContainSubstring("password"))
+		var response usecases.SearchUsersResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Results).To(HaveLen(2))
+
+		Expect(response.Results[0].User.ID).To(Equal(results[0].User.ID.String()))
+		Expect(response.Results[0].Score).To(Equal(1.0))
+		Expect(response.Results[0].Highlights).To(Equal(map[string]string{
+			"nickname":   "<mark>alec</mark>_<mark>sm</mark>i<mark>th</mark>",
+			"first_name": "<mark>alec</mark>",
+		}))
+
+		// the misspelt "smth" still shares trigrams with "smithy", and the rest of the nickname is escaped
+		Expect(response.Results[1].Highlights).To(Equal(map[string]string{
+			"nickname": "&lt;<mark>sm</mark>ithy&gt;",
+		}))
+	})
+
+	When("a limit is given", func() {
+		BeforeEach(func() {
+			query += "&limit=5"
+			expectedLimit = 5
+		})
+
+		It("should pass it on", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("the limit is too high", func() {
+		BeforeEach(func() {
+			query += "&limit=101"
+			searchUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("there is no query", func() {
+		BeforeEach(func() {
+			query = ""
+			searchUsersCallCount = 0
+		})
+
+		It("should return a 400 response", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userSearcher adapter returns an error", func() {
+		BeforeEach(func() {
+			searchUsersErr = errors.New("an error occurred")
+		})
+
+		It("should return a 500 response", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
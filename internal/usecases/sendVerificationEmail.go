@@ -0,0 +1,61 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// NewSendVerificationEmail sends a user a new email verification token
+// @Summary Send verification email
+// @Description Emails the user a new single-use token to verify their email address
+// @Tags users
+// @Param userId path string true "User ID"
+// @Success 202
+// @Failure 400
+// @Failure 409
+// @Failure 500
+// @Router /user/{userId}/verify-email/send [post]
+func NewSendVerificationEmail(userGetter UserGetter, verificationEmailSender *VerificationEmailSender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		if user.EmailVerifiedAt != nil {
+			slog.Warn("email already verified", "userID", userIDUUID)
+			c.Status(http.StatusConflict)
+			return
+		}
+
+		err = verificationEmailSender.Send(c.Request.Context(), *user)
+		if err != nil {
+			slog.Error("sending verification email", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Status(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,95 @@
+package usecases_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Sending a verification email", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var getUserResponse *entities.User
+	var getUserErr error
+	var createTokenErr error
+	var createTokenCallCount int
+	var sendMailCallCount int
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		getUserResponse = &entities.User{
+			ID:       userID,
+			Nickname: "alecsmith",
+			Email:    "alec@email.com",
+			Status:   entities.UserStatusPendingVerification,
+		}
+		getUserErr = nil
+		createTokenErr = nil
+		createTokenCallCount = 1
+		sendMailCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(getUserResponse, getUserErr).Times(1)
+		mockEmailVerificationStore.EXPECT().CreateVerificationToken(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.VerificationToken{})).
+			Return(createTokenErr).Times(createTokenCallCount)
+		mockTokenSigner.EXPECT().SignToken(gomock.AssignableToTypeOf(entities.VerificationToken{})).
+			Return("signed-token", nil).Times(sendMailCallCount)
+		mockMailer.EXPECT().SendMail(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.Email{})).
+			Return(nil).Times(sendMailCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/user/%s/verify-email/send", userID), nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return a 202 Accepted", func() {
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+	})
+
+	When("the user is not found", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			createTokenCallCount = 0
+			sendMailCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the user's email is already verified", func() {
+		BeforeEach(func() {
+			verifiedAt := time.Now()
+			getUserResponse.EmailVerifiedAt = &verifiedAt
+			createTokenCallCount = 0
+			sendMailCallCount = 0
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	When("the token can't be stored", func() {
+		BeforeEach(func() {
+			createTokenErr = errors.New("an error occurred")
+			sendMailCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
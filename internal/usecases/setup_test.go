@@ -3,12 +3,15 @@ package usecases_test
 import (
 	"context"
 	"github.com/AlecSmith96/faceit-user-service/internal/drivers"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
 	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/mock/gomock"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -24,15 +27,50 @@ func TestHandleUsers(t *testing.T) {
 }
 
 var (
-	r                    *gin.Engine
-	mockChangelogWriter  *mock_usecases.MockChangelogWriter
-	mockUserCreator      *mock_usecases.MockUserCreator
-	mockUserUpdater      *mock_usecases.MockUserUpdater
-	mockUserDeleter      *mock_usecases.MockUserDeleter
-	mockUserGetter       *mock_usecases.MockUserGetter
-	mockReadinessChecker *mock_usecases.MockReadinessChecker
+	r                          *gin.Engine
+	mockChangelogWriter        *mock_usecases.MockChangelogWriter
+	mockUserCreator            *mock_usecases.MockUserCreator
+	mockUserUpdater            *mock_usecases.MockUserUpdater
+	mockUserDeleter            *mock_usecases.MockUserDeleter
+	mockUserGetter             *mock_usecases.MockUserGetter
+	mockReadinessChecker       *mock_usecases.MockReadinessChecker
+	mockUserRepository         usecases.UserRepository
+	mockUserStatusUpdater      *mock_usecases.MockUserStatusUpdater
+	mockTokenSigner            *mock_usecases.MockTokenSigner
+	mockEmailVerificationStore *mock_usecases.MockEmailVerificationStore
+	mockMailer                 *mock_usecases.MockMailer
+	mockPasswordManager        *mock_usecases.MockPasswordManager
+	mockEmailChangeStore       *mock_usecases.MockEmailChangeStore
+	mockMFAStore               *mock_usecases.MockMFAStore
+	mockSecretBox              *mock_usecases.MockSecretBox
+	mockSessionStore           *mock_usecases.MockSessionStore
+	mockAccessTokenSigner      *mock_usecases.MockAccessTokenSigner
+	mockWebAuthnStore          *mock_usecases.MockWebAuthnStore
+	mockOIDCStore              *mock_usecases.MockOIDCStore
+	mockIDTokenSigner          *mock_usecases.MockIDTokenSigner
+	mockLoginLimiter           *mock_usecases.MockLoginLimiter
+	mockLoginAuditStore        *mock_usecases.MockLoginAuditStore
+	mockSecurityEventPublisher *mock_usecases.MockSecurityEventPublisher
+	mockBreachCorpus           *mock_usecases.MockBreachCorpus
+	mockAPIKeyStore            *mock_usecases.MockAPIKeyStore
+	mockAuditLog               *mock_usecases.MockAuditLog
+	mockUserImporter           *mock_usecases.MockUserImporter
+	mockImportJobStore         *mock_usecases.MockImportJobStore
+	mockUserExporter           *mock_usecases.MockUserExporter
+	mockUserBatchWriter        *mock_usecases.MockUserBatchWriter
+	mockUserSearcher           *mock_usecases.MockUserSearcher
 )
 
+// userRepositoryMocks makes a UserRepository out of the mocks of the interfaces it is made of, so tests expect calls
+// on the mock for the interface they belong to
+type userRepositoryMocks struct {
+	*mock_usecases.MockUserCreator
+	*mock_usecases.MockUserGetter
+	*mock_usecases.MockUserUpdater
+	*mock_usecases.MockUserDeleter
+	*mock_usecases.MockReadinessChecker
+}
+
 var _ = BeforeSuite(func() {
 	// Put gin in test mode
 	gin.SetMode(gin.TestMode)
@@ -44,15 +82,129 @@ var _ = BeforeSuite(func() {
 	mockUserDeleter = mock_usecases.NewMockUserDeleter(ctrl)
 	mockUserGetter = mock_usecases.NewMockUserGetter(ctrl)
 	mockReadinessChecker = mock_usecases.NewMockReadinessChecker(ctrl)
+	mockUserRepository = userRepositoryMocks{mockUserCreator, mockUserGetter, mockUserUpdater, mockUserDeleter, mockReadinessChecker}
+	mockUserStatusUpdater = mock_usecases.NewMockUserStatusUpdater(ctrl)
+	mockTokenSigner = mock_usecases.NewMockTokenSigner(ctrl)
+	mockEmailVerificationStore = mock_usecases.NewMockEmailVerificationStore(ctrl)
+	mockMailer = mock_usecases.NewMockMailer(ctrl)
+	mockPasswordManager = mock_usecases.NewMockPasswordManager(ctrl)
+	mockEmailChangeStore = mock_usecases.NewMockEmailChangeStore(ctrl)
+	mockMFAStore = mock_usecases.NewMockMFAStore(ctrl)
+	mockSecretBox = mock_usecases.NewMockSecretBox(ctrl)
+	mockSessionStore = mock_usecases.NewMockSessionStore(ctrl)
+	mockAccessTokenSigner = mock_usecases.NewMockAccessTokenSigner(ctrl)
+	mockWebAuthnStore = mock_usecases.NewMockWebAuthnStore(ctrl)
+	mockOIDCStore = mock_usecases.NewMockOIDCStore(ctrl)
+	mockIDTokenSigner = mock_usecases.NewMockIDTokenSigner(ctrl)
+	mockLoginLimiter = mock_usecases.NewMockLoginLimiter(ctrl)
+	mockLoginAuditStore = mock_usecases.NewMockLoginAuditStore(ctrl)
+	mockSecurityEventPublisher = mock_usecases.NewMockSecurityEventPublisher(ctrl)
+	mockBreachCorpus = mock_usecases.NewMockBreachCorpus(ctrl)
+	mockAPIKeyStore = mock_usecases.NewMockAPIKeyStore(ctrl)
+	mockAuditLog = mock_usecases.NewMockAuditLog(ctrl)
+	mockUserImporter = mock_usecases.NewMockUserImporter(ctrl)
+	mockImportJobStore = mock_usecases.NewMockImportJobStore(ctrl)
+	mockUserExporter = mock_usecases.NewMockUserExporter(ctrl)
+	mockUserBatchWriter = mock_usecases.NewMockUserBatchWriter(ctrl)
+	mockUserSearcher = mock_usecases.NewMockUserSearcher(ctrl)
+	// audited handlers record their actions after responding, that is tested on its own in auditLog_test.go
+	mockAuditLog.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).Return(&entities.AuditEntry{}, nil).AnyTimes()
 
-	r = drivers.NewRouter(
-		mockChangelogWriter,
-		mockUserGetter,
-		mockUserCreator,
-		mockUserDeleter,
-		mockUserUpdater,
-		mockReadinessChecker,
+	webAuthnManager, err := usecases.NewWebAuthnManager(
+		mockWebAuthnStore,
+		"localhost",
+		"FACEIT",
+		[]string{"http://localhost:8080"},
+		5*time.Minute,
 	)
+	Expect(err).ToNot(HaveOccurred())
+
+	sessionIssuer := usecases.NewSessionIssuer(mockSessionStore, mockAccessTokenSigner, 15*time.Minute, 720*time.Hour)
+	passwordValidator := usecases.NewPasswordValidator(
+		entities.PasswordPolicy{MinLength: 10, MaxLength: 128, MinEntropyBits: 40},
+		mockBreachCorpus,
+	)
+
+	r = drivers.NewRouter(drivers.RouterDependencies{
+		ChangelogWriter:        mockChangelogWriter,
+		UserRepository:         mockUserRepository,
+		UserStatusUpdater:      mockUserStatusUpdater,
+		TokenSigner:            mockTokenSigner,
+		EmailVerificationStore: mockEmailVerificationStore,
+		VerificationEmailSender: usecases.NewVerificationEmailSender(
+			mockEmailVerificationStore,
+			mockTokenSigner,
+			mockMailer,
+			time.Hour,
+			"http://localhost:8080/verify-email",
+		),
+		UnverifiedAccountPolicy: entities.UnverifiedAccountPolicy{Mode: entities.UnverifiedAccountPolicyAllow},
+		PasswordManager:         mockPasswordManager,
+		PasswordResetSender: usecases.NewPasswordResetSender(
+			mockPasswordManager,
+			mockMailer,
+			time.Hour,
+			"http://localhost:8080/auth/password/reset",
+		),
+		EmailChangeStore: mockEmailChangeStore,
+		EmailChanger: usecases.NewEmailChanger(
+			mockEmailChangeStore,
+			mockTokenSigner,
+			mockMailer,
+			time.Hour,
+			24*time.Hour,
+			"http://localhost:8080/email-change/confirm",
+			"http://localhost:8080/email-change/revert",
+		),
+		MFAManager:      usecases.NewMFAManager(mockMFAStore, mockSecretBox, mockTokenSigner, "FACEIT", 5*time.Minute),
+		SessionStore:    mockSessionStore,
+		SessionIssuer:   sessionIssuer,
+		WebAuthnStore:   mockWebAuthnStore,
+		WebAuthnManager: webAuthnManager,
+		OIDCStore:       mockOIDCStore,
+		OIDCProvider: usecases.NewOIDCProvider(
+			mockOIDCStore,
+			mockUserGetter,
+			mockAccessTokenSigner,
+			mockIDTokenSigner,
+			sessionIssuer,
+			"http://localhost:8080",
+			time.Minute,
+			time.Hour,
+		),
+		LoginThrottler: usecases.NewLoginThrottler(
+			mockLoginLimiter,
+			mockLoginAuditStore,
+			mockSecurityEventPublisher,
+			entities.LoginThrottlePolicy{
+				FreeFailures:    3,
+				BaseDelay:       time.Second,
+				MaxDelay:        5 * time.Minute,
+				LockoutFailures: 10,
+				LockoutDuration: 30 * time.Minute,
+				ResetAfter:      time.Hour,
+			},
+			entities.LoginThrottlePolicy{
+				FreeFailures: 20,
+				BaseDelay:    time.Second,
+				MaxDelay:     5 * time.Minute,
+				ResetAfter:   time.Hour,
+			},
+		),
+		PasswordValidator: passwordValidator,
+		APIKeyStore:       mockAPIKeyStore,
+		// requests without a key are let through so the handler tests don't each need one
+		APIKeyAuthenticator: usecases.NewAPIKeyAuthenticator(mockAPIKeyStore, "", false),
+		AuditLog:            mockAuditLog,
+		// imports are batched two users at a time so the tests cover more than one batch
+		UserImport:      usecases.NewUserImport(mockUserImporter, mockImportJobStore, mockChangelogWriter, passwordValidator, 2, ""),
+		ImportJobStore:  mockImportJobStore,
+		UserExporter:    mockUserExporter,
+		UserBatchWriter: mockUserBatchWriter,
+		UserSearcher:    mockUserSearcher,
+		// batches are capped at three users so the tests can go over the limit
+		BatchMaxUsers: 3,
+	})
 
 	go func() {
 		defer GinkgoRecover()
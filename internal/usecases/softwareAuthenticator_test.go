@@ -0,0 +1,167 @@
+package usecases_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+const (
+	webAuthnRPID   = "localhost"
+	webAuthnOrigin = "http://localhost:8080"
+
+	authenticatorFlagUserPresent   = 0x01
+	authenticatorFlagUserVerified  = 0x04
+	authenticatorFlagAttestedCreds = 0x40
+)
+
+// softwareAuthenticator stands in for a browser and platform authenticator, producing the same responses to WebAuthn
+// ceremonies that navigator.credentials.create() and navigator.credentials.get() would
+type softwareAuthenticator struct {
+	privateKey   *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(userID uuid.UUID) *softwareAuthenticator {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	Expect(err).ToNot(HaveOccurred())
+
+	return &softwareAuthenticator{
+		privateKey:   privateKey,
+		credentialID: credentialID,
+		userHandle:   userID[:],
+	}
+}
+
+// publicKey returns the credential's public key in the COSE format the relying party stores it in
+func (a *softwareAuthenticator) publicKey() []byte {
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.privateKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.privateKey.Y.FillBytes(make([]byte, 32)),
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	return publicKey
+}
+
+func (a *softwareAuthenticator) authenticatorData(flags byte, attestedCredentialData []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(webAuthnRPID))
+
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, flags)
+	authData = binary.BigEndian.AppendUint32(authData, a.signCount)
+	return append(authData, attestedCredentialData...)
+}
+
+func clientDataJSON(ceremonyType protocol.CeremonyType, challenge string) []byte {
+	clientData, err := json.Marshal(protocol.CollectedClientData{
+		Type:      ceremonyType,
+		Challenge: challenge,
+		Origin:    webAuthnOrigin,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	return clientData
+}
+
+// register responds to a registration ceremony with a new credential, using "none" attestation
+func (a *softwareAuthenticator) register(challenge string) []byte {
+	attestedCredentialData := make([]byte, 16) // zero AAGUID
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(a.credentialID)))
+	attestedCredentialData = append(attestedCredentialData, a.credentialID...)
+	attestedCredentialData = append(attestedCredentialData, a.publicKey()...)
+
+	attestationObject, err := webauthncbor.Marshal(protocol.AttestationObject{
+		Format:       "none",
+		RawAuthData:  a.authenticatorData(authenticatorFlagUserPresent|authenticatorFlagUserVerified|authenticatorFlagAttestedCreds, attestedCredentialData),
+		AttStatement: map[string]any{},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	response, err := json.Marshal(map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientDataJSON(protocol.CreateCeremony, challenge)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+			"transports":        []string{"internal"},
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	return response
+}
+
+// login responds to a login ceremony, incrementing the signature counter first as a real authenticator would
+func (a *softwareAuthenticator) login(challenge string) []byte {
+	a.signCount++
+
+	authData := a.authenticatorData(authenticatorFlagUserPresent|authenticatorFlagUserVerified, nil)
+	clientData := clientDataJSON(protocol.AssertCeremony, challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	signedData := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.privateKey, signedData[:])
+	Expect(err).ToNot(HaveOccurred())
+
+	response, err := json.Marshal(map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	return response
+}
+
+// newWebAuthnCeremony returns a stored ceremony as BeginRegistration or BeginLogin would have left it
+func newWebAuthnCeremony(purpose string, userID *uuid.UUID, challenge string) *entities.WebAuthnCeremony {
+	session := webauthn.SessionData{
+		Challenge:        challenge,
+		UserVerification: protocol.VerificationRequired,
+	}
+	if purpose == entities.WebAuthnCeremonyRegistration {
+		session.UserID = userID[:]
+	}
+
+	sessionData, err := json.Marshal(session)
+	Expect(err).ToNot(HaveOccurred())
+
+	return &entities.WebAuthnCeremony{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Purpose:     purpose,
+		SessionData: sessionData,
+		ExpiresAt:   time.Now().Add(5 * time.Minute),
+	}
+}
@@ -0,0 +1,20 @@
+package usecases
+
+import "github.com/gin-gonic/gin"
+
+// NewSuspendUser suspends a user
+// @Summary Suspend user
+// @Description Suspends a user account until it is unsuspended by an admin
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 409
+// @Failure 500
+// @Router /admin/user/{userId}/suspend [post]
+func NewSuspendUser(userGetter UserGetter, userStatusUpdater UserStatusUpdater, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transitionUserStatus(c, userGetter, userStatusUpdater, changelogWriter, suspendTransition)
+	}
+}
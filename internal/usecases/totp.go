@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is how many time steps either side of the current one are accepted, to allow for clock drift
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret returns a new random secret of the 160 bits recommended by RFC 4226
+func generateTOTPSecret() ([]byte, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// totpCode returns the RFC 6238 code for the given time step, using HMAC-SHA1 as authenticator apps expect
+func totpCode(secret []byte, counter int64) string {
+	mac := hmac.New(sha1.New, secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP checks a code against the time steps around now, returning the time step it matched
+func matchTOTP(secret []byte, code string, now time.Time) (int64, bool) {
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// looksLikeTOTPCode reports whether a code has the shape of a TOTP code rather than a recovery code
+func looksLikeTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// totpURI builds the otpauth URI that authenticator apps read from a QR code
+func totpURI(issuer, accountName string, secret []byte) string {
+	uri := url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   "/" + issuer + ":" + accountName,
+	}
+	query := url.Values{}
+	query.Set("secret", totpSecretEncoding.EncodeToString(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	uri.RawQuery = query.Encode()
+
+	return uri.String()
+}
+
+// generateRecoveryCodes returns a set of one-time recovery codes along with the hashes that should be stored in their place
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		codeBytes := make([]byte, 5)
+		_, err := rand.Read(codeBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(totpSecretEncoding.EncodeToString(codeBytes))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring the case and separators a user might type it with
+func hashRecoveryCode(code string) string {
+	normalised := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashOpaqueToken(normalised)
+}
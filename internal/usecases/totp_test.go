@@ -0,0 +1,29 @@
+package usecases_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+// currentTOTPCode returns the code an authenticator app would show right now
+func currentTOTPCode(secret []byte) string {
+	return usecases.TOTPCode(secret, time.Now().Unix()/int64(usecases.TOTPPeriod.Seconds()))
+}
+
+var _ = Describe("Generating TOTP codes", func() {
+	// test vectors from RFC 6238 appendix B for HMAC-SHA1, truncated to 6 digits
+	DescribeTable("should match the RFC 6238 test vectors",
+		func(unixTime int64, expectedCode string) {
+			secret := []byte("12345678901234567890")
+			Expect(usecases.TOTPCode(secret, unixTime/30)).To(Equal(expectedCode))
+		},
+		Entry("at 59", int64(59), "287082"),
+		Entry("at 1111111109", int64(1111111109), "081804"),
+		Entry("at 1111111111", int64(1111111111), "050471"),
+		Entry("at 1234567890", int64(1234567890), "005924"),
+		Entry("at 2000000000", int64(2000000000), "279037"),
+		Entry("at 20000000000", int64(20000000000), "353130"),
+	)
+})
@@ -0,0 +1,20 @@
+package usecases
+
+import "github.com/gin-gonic/gin"
+
+// NewUnbanUser lifts a user's ban
+// @Summary Unban user
+// @Description Reactivates a banned user account before its ban expires
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 409
+// @Failure 500
+// @Router /admin/user/{userId}/unban [post]
+func NewUnbanUser(userGetter UserGetter, userStatusUpdater UserStatusUpdater, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transitionUserStatus(c, userGetter, userStatusUpdater, changelogWriter, unbanTransition)
+	}
+}
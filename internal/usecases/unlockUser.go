@@ -0,0 +1,55 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+)
+
+// NewUnlockUser lifts a login lockout
+// @Summary Unlock user
+// @Description Clears the user's failed logins, lifting any lockout or backoff on their account before it expires.
+// @Description Failures counted against IP addresses are left as they are.
+// @Tags admin
+// @Param userId path string true "User ID"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /admin/user/{userId}/unlock [post]
+func NewUnlockUser(userGetter UserGetter, loginThrottler *LoginThrottler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.Param("userId")
+
+		userIDUUID, err := uuid.Parse(userID)
+		if err != nil {
+			slog.Error("invalid userID", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				slog.Warn("user not found", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		err = loginThrottler.Unlock(c.Request.Context(), *user)
+		if err != nil {
+			slog.Error("unlocking user", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,90 @@
+package usecases_test
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Unlocking a user's account", func() {
+	var w *httptest.ResponseRecorder
+	var user *entities.User
+
+	var getUserErr error
+	var resetErr error
+	var resetCallCount int
+	var publishCallCount int
+
+	BeforeEach(func() {
+		user = &entities.User{
+			ID:     uuid.New(),
+			Email:  "alec@email.com",
+			Status: entities.UserStatusActive,
+		}
+		getUserErr = nil
+		resetErr = nil
+		resetCallCount = 1
+		publishCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, getUserErr).Times(1)
+		mockLoginLimiter.EXPECT().ResetLoginThrottle(gomock.AssignableToTypeOf(ctxType), "user:"+user.ID.String()).
+			Return(resetErr).Times(resetCallCount)
+		mockSecurityEventPublisher.EXPECT().PublishSecurityEvent(gomock.AssignableToTypeOf(entities.SecurityEvent{})).
+			DoAndReturn(func(event entities.SecurityEvent) error {
+				Expect(event.Type).To(Equal(entities.SecurityEventAccountUnlocked))
+				Expect(*event.UserID).To(Equal(user.ID))
+				return nil
+			}).Times(publishCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/admin/user/"+user.ID.String()+"/unlock", nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should clear the account's failed logins and publish a security event", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	When("the user doesn't exist", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			resetCallCount = 0
+			publishCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the failed logins can't be cleared", func() {
+		BeforeEach(func() {
+			resetErr = errors.New("an error occurred")
+			publishCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
+
+var _ = Describe("Unlocking a user's account with an invalid ID", func() {
+	It("should return a 400 Bad Request", func() {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "http://localhost:8080/admin/user/not-a-uuid/unlock", nil)
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+})
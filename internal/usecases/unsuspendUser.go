@@ -0,0 +1,20 @@
+package usecases
+
+import "github.com/gin-gonic/gin"
+
+// NewUnsuspendUser lifts a user's suspension
+// @Summary Unsuspend user
+// @Description Reactivates a suspended user account
+// @Tags admin
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 409
+// @Failure 500
+// @Router /admin/user/{userId}/unsuspend [post]
+func NewUnsuspendUser(userGetter UserGetter, userStatusUpdater UserStatusUpdater, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transitionUserStatus(c, userGetter, userStatusUpdater, changelogWriter, unsuspendTransition)
+	}
+}
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewUnverifiedAccountRestriction is middleware that rejects requests acting on the user in the userId path parameter
+// when the policy restricts that user for not having verified their email
+func NewUnverifiedAccountRestriction(userGetter UserGetter, policy entities.UnverifiedAccountPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy.Mode != entities.UnverifiedAccountPolicyRestrict {
+			c.Next()
+			return
+		}
+
+		userIDUUID, err := uuid.Parse(c.Param("userId"))
+		if err != nil {
+			// left for the handler to reject
+			c.Next()
+			return
+		}
+
+		user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+		if err != nil {
+			if errors.Is(err, entities.ErrUserNotFound) {
+				c.Next()
+				return
+			}
+
+			slog.Error("getting user", "err", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		if policy.Restricts(*user, time.Now()) {
+			slog.Warn("user restricted until email is verified", "userID", userIDUUID)
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
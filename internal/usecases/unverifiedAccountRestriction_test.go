@@ -0,0 +1,85 @@
+package usecases_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Restricting unverified accounts", func() {
+	var w *httptest.ResponseRecorder
+	var policy entities.UnverifiedAccountPolicy
+	var user *entities.User
+	var getUserCallCount int
+
+	BeforeEach(func() {
+		policy = entities.UnverifiedAccountPolicy{
+			Mode:        entities.UnverifiedAccountPolicyRestrict,
+			GracePeriod: time.Hour,
+		}
+		user = &entities.User{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+		}
+		getUserCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).
+			Return(user, nil).Times(getUserCallCount)
+
+		engine := gin.New()
+		engine.PUT("/user/:userId", usecases.NewUnverifiedAccountRestriction(mockUserGetter, policy), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req, err := http.NewRequest("PUT", "http://localhost:8080/user/"+user.ID.String(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		engine.ServeHTTP(w, req)
+	})
+
+	It("should reject a user past their grace period", func() {
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+	})
+
+	When("the user is within their grace period", func() {
+		BeforeEach(func() {
+			user.CreatedAt = time.Now()
+		})
+
+		It("should allow the request", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("the user has verified their email", func() {
+		BeforeEach(func() {
+			verifiedAt := time.Now()
+			user.EmailVerifiedAt = &verifiedAt
+		})
+
+		It("should allow the request", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("the policy allows unverified accounts", func() {
+		BeforeEach(func() {
+			policy.Mode = entities.UnverifiedAccountPolicyAllow
+			getUserCallCount = 0
+		})
+
+		It("should allow the request", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/AlecSmith96/faceit-user-service/internal/entities"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -54,21 +55,32 @@ type UpdateUserResponseBody struct {
 	CreatedAt time.Time `json:"created_at"`
 	// UpdatedAt represents the timestamp when the user was last updated
 	UpdatedAt time.Time `json:"updated_at"`
+	// Status represents the user's account status
+	Status string `json:"status"`
+	// PendingEmail represents an email address awaiting confirmation before it replaces Email
+	PendingEmail *string `json:"pending_email,omitempty"`
 }
 
 // NewUpdateUser updates a users information
 // @Summary Update User
-// @Description Updates user information for the provided userId
+// @Description Updates user information for the provided userId. A new email address is held as pending until it is
+// @Description confirmed from that address
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param userId path string true "User ID"
 // @Param user body UpdateUserRequestBody true "Create User Request Body"
 // @Success 200 {object} UpdateUserResponseBody
-// @Failure 400
+// @Failure 400 {object} PasswordRejectedResponseBody
 // @Failure 500
 // @Router /user/{userId} [put]
-func NewUpdateUser(userUpdater UserUpdater, changelogWriter ChangelogWriter) gin.HandlerFunc {
+func NewUpdateUser(
+	userGetter UserGetter,
+	userUpdater UserUpdater,
+	emailChanger *EmailChanger,
+	changelogWriter ChangelogWriter,
+	passwordValidator *PasswordValidator,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.Param("userId")
 
@@ -87,17 +99,14 @@ func NewUpdateUser(userUpdater UserUpdater, changelogWriter ChangelogWriter) gin
 			return
 		}
 
-		user, err := userUpdater.UpdateUser(
-			c.Request.Context(),
-			userIDUUID,
-			request.FirstName,
-			request.LastName,
-			request.Nickname,
-			request.Password,
-			request.Email,
-			request.Country,
-		)
+		user, err := updateUser(c.Request.Context(), userGetter, userUpdater, emailChanger, changelogWriter, passwordValidator, userIDUUID, request)
 		if err != nil {
+			var policyErr *entities.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				respondPasswordInvalid(c, err)
+				return
+			}
+
 			if errors.Is(err, entities.ErrUserNotFound) {
 				slog.Warn("user not found", "err", err)
 				c.Status(http.StatusBadRequest)
@@ -105,7 +114,7 @@ func NewUpdateUser(userUpdater UserUpdater, changelogWriter ChangelogWriter) gin
 			}
 
 			if errors.Is(err, entities.ErrEmailAlreadyUsed) {
-				slog.Warn("email already registered to a uer", "err", err)
+				slog.Warn("email already registered to a user", "err", err)
 				c.Status(http.StatusBadRequest)
 				return
 			}
@@ -115,27 +124,95 @@ func NewUpdateUser(userUpdater UserUpdater, changelogWriter ChangelogWriter) gin
 			return
 		}
 
+		c.JSON(http.StatusOK, UpdateUserResponseBody{
+			ID:           user.ID.String(),
+			FirstName:    user.FirstName,
+			LastName:     user.LastName,
+			Nickname:     user.Nickname,
+			Password:     user.Password,
+			Email:        user.Email,
+			Country:      user.Country,
+			CreatedAt:    user.CreatedAt,
+			UpdatedAt:    user.UpdatedAt,
+			Status:       string(user.Status),
+			PendingEmail: user.PendingEmail,
+		})
+	}
+}
+
+// updateUser replaces a user's profile from a validated request, holding a new email address as pending until it is
+// confirmed, and publishes the changelog entries. It is shared by every way of updating a single user, so they all
+// apply the same checks. emailChanger is nil for storage backends that can't hold pending email changes, a new address
+// then replaces the current one straight away.
+func updateUser(
+	ctx context.Context,
+	userGetter UserGetter,
+	userUpdater UserUpdater,
+	emailChanger *EmailChanger,
+	changelogWriter ChangelogWriter,
+	passwordValidator *PasswordValidator,
+	userID uuid.UUID,
+	request CreateUserRequestBody,
+) (*entities.User, error) {
+	currentUser, err := userGetter.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// passwords set before the policy existed can be kept, only new ones are checked
+	if !passwordMatches(*currentUser, request.Password) {
+		err = passwordValidator.Validate(ctx, request.Password, request.Nickname, request.Email)
+		if err != nil {
+			return nil, fmt.Errorf("validating password: %w", err)
+		}
+	}
+
+	email := currentUser.Email
+	if request.Email != currentUser.Email && emailChanger == nil {
+		email = request.Email
+	} else if request.Email != currentUser.Email {
+		_, err = emailChanger.RequestChange(ctx, *currentUser, request.Email)
+		if err != nil {
+			return nil, fmt.Errorf("requesting email change: %w", err)
+		}
+
 		entry := entities.ChangelogEntry{
-			UserID:     user.ID,
-			CreatedAt:  user.CreatedAt,
-			ChangeType: "PUT",
+			UserID:     currentUser.ID,
+			CreatedAt:  time.Now().UTC(),
+			ChangeType: entities.ChangeTypeEmailChangeRequested,
 		}
 		err = changelogWriter.PublishChangelogEntry(entry)
 		if err != nil {
 			// deliberately not returning error here as request didn't fail
 			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
 		}
+	}
 
-		c.JSON(http.StatusOK, UpdateUserResponseBody{
-			ID:        user.ID.String(),
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			Nickname:  user.Nickname,
-			Password:  user.Password,
-			Email:     user.Email,
-			Country:   user.Country,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		})
+	// a held email only changes once confirmed, so the rest of the profile is updated against the current address
+	user, err := userUpdater.UpdateUser(
+		ctx,
+		userID,
+		request.FirstName,
+		request.LastName,
+		request.Nickname,
+		request.Password,
+		email,
+		request.Country,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := entities.ChangelogEntry{
+		UserID:     user.ID,
+		CreatedAt:  user.CreatedAt,
+		ChangeType: "PUT",
+	}
+	err = changelogWriter.PublishChangelogEntry(entry)
+	if err != nil {
+		// deliberately not returning error here as request didn't fail
+		slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
 	}
+
+	return user, nil
 }
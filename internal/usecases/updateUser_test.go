@@ -21,12 +21,21 @@ var _ = Describe("Updating a user", func() {
 	var requestBody *usecases.UpdateUserRequestBody
 	var userID string
 
+	var currentUser *entities.User
+	var getUserErr error
+
+	var requestEmailChangeErr error
+	var requestEmailChangeCallCount int
+	var emailChangeRequestedCallCount int
+
 	var updateUserResponse *entities.User
 	var updateUserErr error
 	var updateUserCallCount int
 
 	var changelogWriterErr error
 	var changelogWriterCallCount int
+	var breachCount int
+	var breachLookupCallCount int
 
 	BeforeEach(func() {
 		requestBody = &usecases.UpdateUserRequestBody{
@@ -40,6 +49,19 @@ var _ = Describe("Updating a user", func() {
 
 		userID = uuid.New().String()
 
+		currentUser = &entities.User{
+			ID:       uuid.MustParse(userID),
+			Nickname: "alecsmith",
+			Password: "some-password",
+			Email:    "alec@email.com",
+			Status:   entities.UserStatusActive,
+		}
+		getUserErr = nil
+
+		requestEmailChangeErr = nil
+		requestEmailChangeCallCount = 0
+		emailChangeRequestedCallCount = 0
+
 		updateUserResponse = &entities.User{
 			ID:        uuid.New(),
 			FirstName: "alec",
@@ -57,6 +79,8 @@ var _ = Describe("Updating a user", func() {
 
 		changelogWriterErr = nil
 		changelogWriterCallCount = 1
+		breachCount = 0
+		breachLookupCallCount = 0
 	})
 
 	JustBeforeEach(func() {
@@ -64,6 +88,36 @@ var _ = Describe("Updating a user", func() {
 		requestBodyJSON, err := json.Marshal(requestBody)
 		Expect(err).ToNot(HaveOccurred())
 
+		getUserCallCount := 0
+		if parsedUserID, err := uuid.Parse(userID); err == nil && requestBody.FirstName != "" {
+			getUserCallCount = 1
+			currentUser.ID = parsedUserID
+		}
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), currentUser.ID).
+			Return(currentUser, getUserErr).Times(getUserCallCount)
+
+		expectBreachLookup(mockBreachCorpus, requestBody.Password, breachCount, breachLookupCallCount)
+
+		mockTokenSigner.EXPECT().SignToken(gomock.AssignableToTypeOf(entities.VerificationToken{})).
+			Return("signed-token", nil).Times(2 * requestEmailChangeCallCount)
+		mockEmailChangeStore.EXPECT().RequestEmailChange(
+			gomock.AssignableToTypeOf(ctxType),
+			currentUser.ID,
+			requestBody.Email,
+			gomock.AssignableToTypeOf(entities.VerificationToken{}),
+			gomock.AssignableToTypeOf(entities.VerificationToken{}),
+		).Return(currentUser, requestEmailChangeErr).Times(requestEmailChangeCallCount)
+		sendMailCallCount := requestEmailChangeCallCount
+		if requestEmailChangeErr != nil {
+			sendMailCallCount = 0
+		}
+		mockMailer.EXPECT().SendMail(gomock.AssignableToTypeOf(ctxType), gomock.AssignableToTypeOf(entities.Email{})).
+			Return(nil).Times(2 * sendMailCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.AssignableToTypeOf(entities.ChangelogEntry{})).
+			Do(func(entry entities.ChangelogEntry) {
+				Expect(entry.ChangeType).To(Equal(entities.ChangeTypeEmailChangeRequested))
+			}).Return(nil).Times(emailChangeRequestedCallCount)
+
 		mockUserUpdater.EXPECT().UpdateUser(
 			gomock.AssignableToTypeOf(ctxType),
 			gomock.AssignableToTypeOf(uuid.UUID{}),
@@ -71,7 +125,7 @@ var _ = Describe("Updating a user", func() {
 			requestBody.LastName,
 			requestBody.Nickname,
 			requestBody.Password,
-			requestBody.Email,
+			currentUser.Email,
 			requestBody.Country,
 		).Return(updateUserResponse, updateUserErr).Times(updateUserCallCount)
 
@@ -127,6 +181,78 @@ var _ = Describe("Updating a user", func() {
 		})
 	})
 
+	When("the user doesn't exist", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			updateUserCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the password is changed", func() {
+		BeforeEach(func() {
+			requestBody.Password = "purple-monkey-dish7"
+			breachLookupCallCount = 1
+		})
+
+		It("should check the new password and update the user", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		When("the new password has appeared in a breach", func() {
+			BeforeEach(func() {
+				breachCount = 7
+				updateUserCallCount = 0
+				changelogWriterCallCount = 0
+			})
+
+			It("should return a 400 Bad Request with the rule it broke", func() {
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+				var response usecases.PasswordRejectedResponseBody
+				err := json.NewDecoder(w.Body).Decode(&response)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response.Violations).To(Equal([]string{entities.PasswordRuleBreached}))
+			})
+		})
+	})
+
+	When("the email address is changed", func() {
+		BeforeEach(func() {
+			requestBody.Email = "new@email.com"
+			requestEmailChangeCallCount = 1
+			emailChangeRequestedCallCount = 1
+			pendingEmail := "new@email.com"
+			updateUserResponse.PendingEmail = &pendingEmail
+		})
+
+		It("should keep the current email and hold the new one as pending", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var user usecases.UpdateUserResponseBody
+			err := json.NewDecoder(w.Body).Decode(&user)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(user.Email).To(Equal("alec@email.com"))
+			Expect(user.PendingEmail).To(Equal(updateUserResponse.PendingEmail))
+		})
+
+		When("the new email is already used by another user", func() {
+			BeforeEach(func() {
+				requestEmailChangeErr = entities.ErrEmailAlreadyUsed
+				emailChangeRequestedCallCount = 0
+				updateUserCallCount = 0
+				changelogWriterCallCount = 0
+			})
+
+			It("should return a 400 Bad Request", func() {
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
 	When("the userUpdater adapter returns ErrUserNotFound", func() {
 		BeforeEach(func() {
 			updateUserErr = entities.ErrUserNotFound
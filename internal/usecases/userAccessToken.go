@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewRequireUserAccessToken is middleware that only lets through requests with a bearer access token for the user in
+// the userId path parameter. Tokens issued to OpenID Connect clients are refused, a client being given access to a
+// user's profile doesn't let it manage their account.
+func NewRequireUserAccessToken(sessionIssuer *SessionIssuer, userGetter UserGetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, token, err := authenticateAccessToken(c.Request.Context(), c.GetHeader("Authorization"), sessionIssuer.signer, userGetter, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("request without a valid access token", "err", err)
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+
+			slog.Error("authenticating access token", "err", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		userIDUUID, err := uuid.Parse(c.Param("userId"))
+		if err != nil || userIDUUID != token.UserID || token.Scope != "" {
+			slog.Warn("access token isn't the user's own", "tokenUserID", token.UserID, "userID", c.Param("userId"))
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package usecases_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// expectUserAccessToken accepts "Bearer signed-access-token" as an access token for the user
+func expectUserAccessToken(userID uuid.UUID) {
+	mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(&entities.AccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		IssuedAt:  time.Now().UTC(),
+		ExpiresAt: time.Now().Add(time.Minute).UTC(),
+	}, nil).Times(1)
+	mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+		Return(&entities.User{ID: userID}, nil).Times(1)
+}
+
+// userAccessTokenRoutes are routes that need the user's own access token, by method and path under /user/{userId}
+var userAccessTokenRoutes = [][2]string{
+	{"GET", "/webauthn/credentials"},
+	{"DELETE", "/webauthn/credentials/" + uuid.NewString()},
+	{"GET", "/sessions"},
+	{"DELETE", "/sessions"},
+	{"DELETE", "/sessions/" + uuid.NewString()},
+	{"POST", "/mfa/totp/disable"},
+	{"POST", "/mfa/recovery-codes"},
+	{"POST", "/webauthn/register/begin"},
+	{"POST", "/webauthn/register/finish"},
+}
+
+var _ = Describe("Requiring the user's own access token", func() {
+	for _, route := range userAccessTokenRoutes {
+		method, path := route[0], route[1]
+
+		Context(method+" "+path, func() {
+			var w *httptest.ResponseRecorder
+			var userID uuid.UUID
+			var authorization string
+
+			BeforeEach(func() {
+				userID = uuid.New()
+				authorization = "Bearer signed-access-token"
+			})
+
+			JustBeforeEach(func() {
+				w = httptest.NewRecorder()
+
+				req, err := http.NewRequest(method, "http://localhost:8080/user/"+userID.String()+path, nil)
+				Expect(err).ToNot(HaveOccurred())
+				if authorization != "" {
+					req.Header.Set("Authorization", authorization)
+				}
+				r.ServeHTTP(w, req)
+			})
+
+			When("there is no access token", func() {
+				BeforeEach(func() {
+					authorization = ""
+				})
+
+				It("should return a 401 Unauthorized", func() {
+					Expect(w.Code).To(Equal(http.StatusUnauthorized))
+					Expect(w.Header().Get("WWW-Authenticate")).To(Equal(`Bearer error="invalid_token"`))
+				})
+			})
+
+			When("the access token has expired", func() {
+				BeforeEach(func() {
+					mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(&entities.AccessToken{
+						UserID:    userID,
+						IssuedAt:  time.Now().Add(-time.Hour).UTC(),
+						ExpiresAt: time.Now().Add(-time.Minute).UTC(),
+					}, nil).Times(1)
+				})
+
+				It("should return a 401 Unauthorized", func() {
+					Expect(w.Code).To(Equal(http.StatusUnauthorized))
+				})
+			})
+
+			When("the access token is for another user", func() {
+				BeforeEach(func() {
+					expectUserAccessToken(uuid.New())
+				})
+
+				It("should return a 403 Forbidden", func() {
+					Expect(w.Code).To(Equal(http.StatusForbidden))
+				})
+			})
+
+			When("the access token was issued to an OpenID Connect client", func() {
+				BeforeEach(func() {
+					mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(&entities.AccessToken{
+						UserID:    userID,
+						IssuedAt:  time.Now().UTC(),
+						ExpiresAt: time.Now().Add(time.Minute).UTC(),
+						Scope:     "openid profile",
+					}, nil).Times(1)
+					mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+						Return(&entities.User{ID: userID}, nil).Times(1)
+				})
+
+				It("should return a 403 Forbidden", func() {
+					Expect(w.Code).To(Equal(http.StatusForbidden))
+				})
+			})
+		})
+	}
+})
@@ -0,0 +1,135 @@
+package usecases
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userBatchWriter.go  . "UserBatchWriter"
+type UserBatchWriter interface {
+	// UpdateUsers applies the updates in one transaction and returns a result for each, in the same order. Updates of
+	// users that don't exist fail, in all or nothing mode that rolls back the rest, which are reported as skipped.
+	UpdateUsers(ctx context.Context, updates []entities.UserUpdate, mode entities.BatchMode) ([]entities.BatchItemResult, error)
+	// DeleteUsers deletes the users in one transaction and returns a result for each, in the same order. Deletes of
+	// users that don't exist fail, in all or nothing mode that rolls back the rest, which are reported as skipped.
+	DeleteUsers(ctx context.Context, userIDs []uuid.UUID, mode entities.BatchMode) ([]entities.BatchItemResult, error)
+}
+
+// BatchWriteResponseBody represents the response body for a batch update or delete
+// @Description The outcome of every user in the batch
+type BatchWriteResponseBody struct {
+	// Mode represents whether the batch was applied all or nothing or best effort
+	Mode string `json:"mode"`
+	// Succeeded represents how many users were written
+	Succeeded int `json:"succeeded"`
+	// Failed represents how many users couldn't be written
+	Failed int `json:"failed"`
+	// Skipped represents how many users could have been written but weren't, as the batch was all or nothing
+	Skipped int `json:"skipped"`
+	// Results represents the outcome for each user, in the order they were given
+	Results []BatchItemResultResponse `json:"results"`
+}
+
+// BatchItemResultResponse represents the outcome for one user of a batch update or delete
+// @Description The outcome for one user of a batch
+type BatchItemResultResponse struct {
+	// UserID represents the user's unique identifier
+	UserID string `json:"user_id"`
+	// Status represents the outcome, SUCCEEDED, FAILED or SKIPPED
+	Status string `json:"status"`
+	// Error represents why the user couldn't be written, it is only set for FAILED
+	Error string `json:"error,omitempty"`
+	// User represents the updated user, it is only set for successful updates
+	User *PublicUserResponse `json:"user,omitempty"`
+}
+
+// batchMode returns the mode a batch request asked for, all or nothing unless it says otherwise
+func batchMode(mode string) entities.BatchMode {
+	if mode == string(entities.BatchModeBestEffort) {
+		return entities.BatchModeBestEffort
+	}
+
+	return entities.BatchModeAllOrNothing
+}
+
+// skipBatch marks every result that hasn't failed as skipped, for all or nothing batches with a failed item
+func skipBatch(results []entities.BatchItemResult) {
+	for i := range results {
+		if results[i].Status != entities.BatchItemStatusFailed {
+			results[i].Status = entities.BatchItemStatusSkipped
+			results[i].User = nil
+		}
+	}
+}
+
+// newBatchWriteResponse returns the response for the results along with its status, which is 400 when an all or
+// nothing batch wasn't applied
+func newBatchWriteResponse(mode entities.BatchMode, results []entities.BatchItemResult) (int, BatchWriteResponseBody) {
+	response := BatchWriteResponseBody{
+		Mode:    string(mode),
+		Results: make([]BatchItemResultResponse, len(results)),
+	}
+	for i, result := range results {
+		response.Results[i] = BatchItemResultResponse{
+			UserID: result.UserID.String(),
+			Status: string(result.Status),
+			Error:  result.Error,
+		}
+		if result.User != nil {
+			user := newPublicUserResponse(*result.User)
+			response.Results[i].User = &user
+		}
+
+		switch result.Status {
+		case entities.BatchItemStatusSucceeded:
+			response.Succeeded++
+		case entities.BatchItemStatusFailed:
+			response.Failed++
+		case entities.BatchItemStatusSkipped:
+			response.Skipped++
+		}
+	}
+
+	if mode == entities.BatchModeAllOrNothing && response.Failed > 0 {
+		return http.StatusBadRequest, response
+	}
+
+	return http.StatusOK, response
+}
+
+// publishBatchChangelog publishes a changelog entry of the change type for every user the batch wrote
+func publishBatchChangelog(changelogWriter ChangelogWriter, changeType string, results []entities.BatchItemResult) {
+	for _, result := range results {
+		if result.Status != entities.BatchItemStatusSucceeded {
+			continue
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     result.UserID,
+			CreatedAt:  time.Now().UTC(),
+			ChangeType: changeType,
+		}
+		err := changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as the batch has already been written
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+	}
+}
+
+// setAuditTargets records the users an audited action was taken against, so NewAuditAction records an entry for each
+func setAuditTargets(c *gin.Context, results []entities.BatchItemResult) {
+	userIDs := []uuid.UUID{}
+	for _, result := range results {
+		if result.Status == entities.BatchItemStatusSucceeded {
+			userIDs = append(userIDs, result.UserID)
+		}
+	}
+
+	c.Set(auditTargetsContextKey, userIDs)
+}
@@ -0,0 +1,382 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/messageConsumer.go  . "MessageConsumer"
+type MessageConsumer interface {
+	// FetchMessage blocks until the next message of the subscription is available or ctx is cancelled
+	FetchMessage(ctx context.Context) (entities.InboundMessage, error)
+	// CommitMessage marks the message, and those before it on its partition, as processed so they aren't redelivered
+	CommitMessage(ctx context.Context, message entities.InboundMessage) error
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userCommandReplyWriter.go  . "UserCommandReplyWriter"
+type UserCommandReplyWriter interface {
+	// PublishUserCommandReply publishes the reply keyed by its correlation ID
+	PublishUserCommandReply(reply UserCommandReplyBody) error
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/deadLetterWriter.go  . "DeadLetterWriter"
+type DeadLetterWriter interface {
+	PublishDeadLetter(deadLetter entities.DeadLetter) error
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userCommandReplyStore.go  . "UserCommandReplyStore"
+type UserCommandReplyStore interface {
+	// GetUserCommandReply returns the reply given to the command with the correlation ID, or
+	// entities.ErrUserCommandReplyNotFound if no command with it has been processed
+	GetUserCommandReply(ctx context.Context, correlationID string) (*entities.UserCommandReply, error)
+	// RecordUserCommandReply keeps the reply given to a command, replacing any kept for its correlation ID
+	RecordUserCommandReply(ctx context.Context, reply entities.UserCommandReply) error
+	// DeleteUserCommandReplies deletes the replies to commands processed before processedBefore, returning how many
+	DeleteUserCommandReplies(ctx context.Context, processedBefore time.Time) (int, error)
+}
+
+// UserCommandMessage represents a command consumed from the user commands topic
+type UserCommandMessage struct {
+	// CorrelationID is given back in the reply so the sender can match it to the command
+	CorrelationID string `json:"correlation_id" binding:"required"`
+	// Type is either create, update or delete
+	Type string `json:"type" binding:"required,oneof=create update delete"`
+	// UserID is the user to update or delete
+	UserID string `json:"user_id" binding:"required_unless=Type create"`
+	// User is the user to create or the details to update them with, the same as the body of POST /user
+	User *CreateUserRequestBody `json:"user" binding:"required_unless=Type delete"`
+}
+
+// UserCommandReplyBody represents the reply published for a user command
+type UserCommandReplyBody struct {
+	CorrelationID string `json:"correlation_id"`
+	Type          string `json:"type"`
+	// Status is either SUCCEEDED or FAILED
+	Status string `json:"status"`
+	// User is the user as it is after a create or update, without their password
+	User *PublicUserResponse `json:"user,omitempty"`
+	// Error is the code for why a command failed, one of entities.UserCommandErrorInternal and the like
+	Error string `json:"error,omitempty"`
+	// Violations are the password rules broken, for password_rejected errors
+	Violations []string `json:"violations,omitempty"`
+}
+
+// UserCommandProcessor applies user commands consumed from a topic through the same use cases as the HTTP handlers,
+// so they are validated, published to the changelog and audited the same way. Every command with a correlation ID gets
+// a reply, messages that can't be replied to or keep failing are dead-lettered. Replies are kept by correlation ID, so
+// a command that is redelivered is given its reply again rather than being applied twice.
+type UserCommandProcessor struct {
+	userRepository          UserRepository
+	changelogWriter         ChangelogWriter
+	verificationEmailSender *VerificationEmailSender
+	emailChanger            *EmailChanger
+	passwordValidator       *PasswordValidator
+	unverifiedAccountPolicy entities.UnverifiedAccountPolicy
+	auditLog                AuditLog
+	replyWriter             UserCommandReplyWriter
+	deadLetterWriter        DeadLetterWriter
+	replyStore              UserCommandReplyStore
+	maxAttempts             int
+	retryDelay              time.Duration
+	replyRetention          time.Duration
+}
+
+// NewUserCommandProcessor creates a UserCommandProcessor. Commands that fail for reasons other than what they ask for
+// are tried maxAttempts times, waiting retryDelay longer after each attempt, before being dead-lettered. Replies are
+// kept for replyRetention, which should be longer than a message can be redelivered after.
+func NewUserCommandProcessor(
+	userRepository UserRepository,
+	changelogWriter ChangelogWriter,
+	verificationEmailSender *VerificationEmailSender,
+	emailChanger *EmailChanger,
+	passwordValidator *PasswordValidator,
+	unverifiedAccountPolicy entities.UnverifiedAccountPolicy,
+	auditLog AuditLog,
+	replyWriter UserCommandReplyWriter,
+	deadLetterWriter DeadLetterWriter,
+	replyStore UserCommandReplyStore,
+	maxAttempts int,
+	retryDelay time.Duration,
+	replyRetention time.Duration,
+) *UserCommandProcessor {
+	return &UserCommandProcessor{
+		userRepository:          userRepository,
+		changelogWriter:         changelogWriter,
+		verificationEmailSender: verificationEmailSender,
+		emailChanger:            emailChanger,
+		passwordValidator:       passwordValidator,
+		unverifiedAccountPolicy: unverifiedAccountPolicy,
+		auditLog:                auditLog,
+		replyWriter:             replyWriter,
+		deadLetterWriter:        deadLetterWriter,
+		replyStore:              replyStore,
+		maxAttempts:             maxAttempts,
+		retryDelay:              retryDelay,
+		replyRetention:          replyRetention,
+	}
+}
+
+// Process applies the command in the message, then records and publishes its reply. A command whose reply has been
+// recorded was processed before its message was committed, so the reply is published again instead. A reply that
+// can't be recorded or published is retried on its own until it is, as a command that has been applied can't be
+// applied again. An error means the recorded replies couldn't be checked, a dead letter couldn't be published or ctx
+// was cancelled first, so the message shouldn't be committed.
+func (p *UserCommandProcessor) Process(ctx context.Context, message entities.InboundMessage) error {
+	var command UserCommandMessage
+	err := json.Unmarshal(message.Value, &command)
+	if err != nil {
+		slog.Warn("unable to decode user command", "err", err, "offset", message.Offset)
+		return p.deadLetter(message, fmt.Sprintf("decoding command: %s", err), 1)
+	}
+
+	if command.CorrelationID == "" {
+		slog.Warn("user command has no correlation id", "offset", message.Offset)
+		return p.deadLetter(message, "command has no correlation_id to reply to", 1)
+	}
+
+	// delivery is at least once, so the message may have been processed before and not committed
+	recorded, err := p.replyStore.GetUserCommandReply(ctx, command.CorrelationID)
+	if err == nil {
+		slog.Info("user command already processed, replying again", "correlationID", command.CorrelationID)
+		return p.replyAgain(ctx, *recorded)
+	}
+	if !errors.Is(err, entities.ErrUserCommandReplyNotFound) {
+		return fmt.Errorf("getting user command reply: %w", err)
+	}
+
+	reply := UserCommandReplyBody{
+		CorrelationID: command.CorrelationID,
+		Type:          command.Type,
+		Status:        entities.UserCommandStatusFailed,
+	}
+
+	userID, err := validateUserCommand(command)
+	if err != nil {
+		slog.Warn("invalid user command", "err", err, "correlationID", command.CorrelationID)
+		reply.Error = entities.UserCommandErrorInvalidCommand
+		return p.reply(ctx, reply, nil)
+	}
+
+	var user *entities.User
+	attempts := 0
+	for {
+		attempts++
+		user, err = p.apply(ctx, message, command, userID)
+		if err == nil || isUserCommandRejection(err) || attempts >= p.maxAttempts {
+			break
+		}
+
+		slog.Warn("applying user command, retrying", "err", err, "correlationID", command.CorrelationID, "attempt", attempts)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.retryDelay * time.Duration(attempts)):
+		}
+	}
+
+	if err != nil {
+		var policyErr *entities.PasswordPolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			reply.Error = entities.UserCommandErrorPasswordRejected
+			reply.Violations = policyErr.Violations
+		case errors.Is(err, entities.ErrUserNotFound):
+			reply.Error = entities.UserCommandErrorUserNotFound
+		case errors.Is(err, entities.ErrEmailAlreadyUsed):
+			reply.Error = entities.UserCommandErrorEmailAlreadyUsed
+		case errors.Is(err, errUserRestricted):
+			reply.Error = entities.UserCommandErrorAccountRestricted
+		default:
+			slog.Error("applying user command", "err", err, "correlationID", command.CorrelationID, "attempts", attempts)
+			// the command is kept so it can be replayed once whatever is failing has been fixed
+			err = p.deadLetter(message, err.Error(), attempts)
+			if err != nil {
+				return err
+			}
+			reply.Error = entities.UserCommandErrorInternal
+		}
+
+		slog.Warn("user command failed", "correlationID", command.CorrelationID, "error", reply.Error)
+		return p.reply(ctx, reply, nil)
+	}
+
+	reply.Status = entities.UserCommandStatusSucceeded
+	if user == nil {
+		return p.reply(ctx, reply, nil)
+	}
+
+	userResponse := newPublicUserResponse(*user)
+	reply.User = &userResponse
+	return p.reply(ctx, reply, &user.ID)
+}
+
+// validateUserCommand checks the command has everything its type needs, returning the user it is for
+func validateUserCommand(command UserCommandMessage) (uuid.UUID, error) {
+	err := binding.Validator.ValidateStruct(&command)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if command.Type == entities.UserCommandCreate {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(command.UserID)
+}
+
+// isUserCommandRejection reports whether err is down to what the command asked for, so trying again won't help
+func isUserCommandRejection(err error) bool {
+	var policyErr *entities.PasswordPolicyError
+	return errors.As(err, &policyErr) ||
+		errors.Is(err, entities.ErrUserNotFound) ||
+		errors.Is(err, entities.ErrEmailAlreadyUsed) ||
+		errors.Is(err, errUserRestricted)
+}
+
+// apply runs the command, returning the user as it is afterwards for creates and updates
+func (p *UserCommandProcessor) apply(ctx context.Context, message entities.InboundMessage, command UserCommandMessage, userID uuid.UUID) (*entities.User, error) {
+	switch command.Type {
+	case entities.UserCommandCreate:
+		return createUser(ctx, p.userRepository, p.changelogWriter, p.verificationEmailSender, p.passwordValidator, *command.User)
+	case entities.UserCommandUpdate:
+		if p.unverifiedAccountPolicy.Mode == entities.UnverifiedAccountPolicyRestrict {
+			currentUser, err := p.userRepository.GetUserByID(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+
+			if p.unverifiedAccountPolicy.Restricts(*currentUser, time.Now()) {
+				return nil, errUserRestricted
+			}
+		}
+
+		return updateUser(ctx, p.userRepository, p.userRepository, p.emailChanger, p.changelogWriter, p.passwordValidator, userID, *command.User)
+	default:
+		err := deleteUser(ctx, p.userRepository, p.changelogWriter, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = appendAuditEntry(ctx, p.auditLog, entities.AuditEntry{
+			// there is no API key behind a command, the topic it came through stands in for one
+			Actor:        message.Topic,
+			Action:       entities.AuditActionUserErased,
+			TargetUserID: &userID,
+			Details:      map[string]string{"correlation_id": command.CorrelationID},
+			// postgres stores microseconds, the hash has to be of what is stored
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		})
+		if err != nil {
+			// deliberately not returning error here as the user has been erased, applying the command again would
+			// only find them gone
+			slog.Error("auditing user command", "err", err, "correlationID", command.CorrelationID)
+		}
+		return nil, nil
+	}
+}
+
+// reply records the reply, with the ID of the user created or updated, and publishes it. Internal errors aren't
+// recorded, so the dead-lettered command is applied when it is replayed once whatever failed has been fixed.
+func (p *UserCommandProcessor) reply(ctx context.Context, reply UserCommandReplyBody, userID *uuid.UUID) error {
+	if reply.Error != entities.UserCommandErrorInternal {
+		recorded := entities.UserCommandReply{
+			CorrelationID: reply.CorrelationID,
+			Type:          reply.Type,
+			Status:        reply.Status,
+			UserID:        userID,
+			Error:         reply.Error,
+			Violations:    reply.Violations,
+			ProcessedAt:   time.Now().UTC(),
+		}
+		err := p.retry(ctx, "recording user command reply", reply.CorrelationID, func() error {
+			return p.replyStore.RecordUserCommandReply(ctx, recorded)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.retry(ctx, "publishing user command reply", reply.CorrelationID, func() error {
+		return p.replyWriter.PublishUserCommandReply(reply)
+	})
+}
+
+// replyAgain publishes a recorded reply. Only the user's ID is recorded, so the reply has the user as they are now, or
+// no user if they have since been deleted.
+func (p *UserCommandProcessor) replyAgain(ctx context.Context, recorded entities.UserCommandReply) error {
+	reply := UserCommandReplyBody{
+		CorrelationID: recorded.CorrelationID,
+		Type:          recorded.Type,
+		Status:        recorded.Status,
+		Error:         recorded.Error,
+		Violations:    recorded.Violations,
+	}
+
+	if recorded.UserID != nil {
+		user, err := p.userRepository.GetUserByID(ctx, *recorded.UserID)
+		if err != nil && !errors.Is(err, entities.ErrUserNotFound) {
+			return fmt.Errorf("getting user for user command reply: %w", err)
+		}
+
+		if user != nil {
+			userResponse := newPublicUserResponse(*user)
+			reply.User = &userResponse
+		}
+	}
+
+	return p.retry(ctx, "publishing user command reply", reply.CorrelationID, func() error {
+		return p.replyWriter.PublishUserCommandReply(reply)
+	})
+}
+
+// retry calls fn every retryDelay until it succeeds or ctx is cancelled
+func (p *UserCommandProcessor) retry(ctx context.Context, action, correlationID string, fn func() error) error {
+	for attempts := 1; ; attempts++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		slog.Error(action+", retrying", "err", err, "correlationID", correlationID, "attempt", attempts)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.retryDelay):
+		}
+	}
+}
+
+// DeleteOldReplies deletes the replies kept for longer than the retention, it is run periodically
+func (p *UserCommandProcessor) DeleteOldReplies(ctx context.Context) error {
+	deleted, err := p.replyStore.DeleteUserCommandReplies(ctx, time.Now().UTC().Add(-p.replyRetention))
+	if err != nil {
+		return fmt.Errorf("deleting user command replies: %w", err)
+	}
+
+	if deleted > 0 {
+		slog.Info("deleted old user command replies", "count", deleted)
+	}
+
+	return nil
+}
+
+func (p *UserCommandProcessor) deadLetter(message entities.InboundMessage, reason string, attempts int) error {
+	err := p.deadLetterWriter.PublishDeadLetter(entities.DeadLetter{
+		Message:  message,
+		Reason:   reason,
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("publishing dead letter: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,445 @@
+package usecases_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Processing user commands", func() {
+	var mockReplyWriter *mock_usecases.MockUserCommandReplyWriter
+	var mockDeadLetterWriter *mock_usecases.MockDeadLetterWriter
+	var mockReplyStore *mock_usecases.MockUserCommandReplyStore
+	var mockCommandAuditLog *mock_usecases.MockAuditLog
+	var policy entities.UnverifiedAccountPolicy
+
+	var userID uuid.UUID
+	var command map[string]any
+	var message entities.InboundMessage
+	var replies []usecases.UserCommandReplyBody
+	var recordedReplies []entities.UserCommandReply
+	var deadLetters []entities.DeadLetter
+	var processErr error
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		mockReplyWriter = mock_usecases.NewMockUserCommandReplyWriter(ctrl)
+		mockDeadLetterWriter = mock_usecases.NewMockDeadLetterWriter(ctrl)
+		mockReplyStore = mock_usecases.NewMockUserCommandReplyStore(ctrl)
+		mockCommandAuditLog = mock_usecases.NewMockAuditLog(ctrl)
+		policy = entities.UnverifiedAccountPolicy{Mode: entities.UnverifiedAccountPolicyAllow}
+
+		userID = uuid.New()
+		command = map[string]any{
+			"correlation_id": "some-correlation-id",
+			"type":           entities.UserCommandDelete,
+			"user_id":        userID.String(),
+		}
+		message = entities.InboundMessage{Topic: "users-commands", Partition: 1, Offset: 42, Key: []byte("some-key")}
+		replies = nil
+		recordedReplies = nil
+		deadLetters = nil
+
+		mockReplyWriter.EXPECT().PublishUserCommandReply(gomock.Any()).DoAndReturn(func(reply usecases.UserCommandReplyBody) error {
+			replies = append(replies, reply)
+			return nil
+		}).AnyTimes()
+		mockDeadLetterWriter.EXPECT().PublishDeadLetter(gomock.Any()).DoAndReturn(func(deadLetter entities.DeadLetter) error {
+			deadLetters = append(deadLetters, deadLetter)
+			return nil
+		}).AnyTimes()
+		mockReplyStore.EXPECT().GetUserCommandReply(gomock.AssignableToTypeOf(ctxType), "some-correlation-id").
+			Return(nil, entities.ErrUserCommandReplyNotFound).AnyTimes()
+		mockReplyStore.EXPECT().RecordUserCommandReply(gomock.AssignableToTypeOf(ctxType), gomock.Any()).DoAndReturn(func(_ context.Context, reply entities.UserCommandReply) error {
+			recordedReplies = append(recordedReplies, reply)
+			return nil
+		}).AnyTimes()
+	})
+
+	JustBeforeEach(func() {
+		if message.Value == nil {
+			value, err := json.Marshal(command)
+			Expect(err).ToNot(HaveOccurred())
+			message.Value = value
+		}
+
+		processor := usecases.NewUserCommandProcessor(
+			mockUserRepository,
+			mockChangelogWriter,
+			usecases.NewVerificationEmailSender(mockEmailVerificationStore, mockTokenSigner, mockMailer, time.Hour, "http://localhost:8080/verify-email"),
+			usecases.NewEmailChanger(mockEmailChangeStore, mockTokenSigner, mockMailer, time.Hour, 24*time.Hour, "http://localhost:8080/email-change/confirm", "http://localhost:8080/email-change/revert"),
+			usecases.NewPasswordValidator(entities.PasswordPolicy{MinLength: 10, MaxLength: 128, MinEntropyBits: 40}, mockBreachCorpus),
+			policy,
+			mockCommandAuditLog,
+			mockReplyWriter,
+			mockDeadLetterWriter,
+			mockReplyStore,
+			2,
+			time.Millisecond,
+			24*time.Hour,
+		)
+		processErr = processor.Process(context.Background(), message)
+	})
+
+	When("a delete command is applied", func() {
+		BeforeEach(func() {
+			mockUserDeleter.EXPECT().DeleteUser(gomock.AssignableToTypeOf(ctxType), userID).Return(nil).Times(1)
+			mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Cond(func(x any) bool {
+				entry := x.(entities.ChangelogEntry)
+				return entry.UserID == userID && entry.ChangeType == "DELETE"
+			})).Return(nil).Times(1)
+			mockCommandAuditLog.EXPECT().AppendAuditEntry(gomock.AssignableToTypeOf(ctxType), gomock.Cond(func(x any) bool {
+				entry := x.(entities.AuditEntry)
+				return entry.Actor == "users-commands" &&
+					entry.Action == entities.AuditActionUserErased &&
+					*entry.TargetUserID == userID &&
+					entry.Details["correlation_id"] == "some-correlation-id"
+			})).Return(&entities.AuditEntry{}, nil).Times(1)
+		})
+
+		It("should reply that it succeeded", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(Equal([]usecases.UserCommandReplyBody{{
+				CorrelationID: "some-correlation-id",
+				Type:          entities.UserCommandDelete,
+				Status:        entities.UserCommandStatusSucceeded,
+			}}))
+			Expect(deadLetters).To(BeEmpty())
+		})
+
+		It("should record the reply", func() {
+			Expect(recordedReplies).To(HaveLen(1))
+			Expect(recordedReplies[0].CorrelationID).To(Equal("some-correlation-id"))
+			Expect(recordedReplies[0].Type).To(Equal(entities.UserCommandDelete))
+			Expect(recordedReplies[0].Status).To(Equal(entities.UserCommandStatusSucceeded))
+			Expect(recordedReplies[0].UserID).To(BeNil())
+			Expect(recordedReplies[0].ProcessedAt).To(BeTemporally("~", time.Now(), time.Second))
+		})
+	})
+
+	When("a create command is applied", func() {
+		var createdUser *entities.User
+
+		BeforeEach(func() {
+			command = map[string]any{
+				"correlation_id": "some-correlation-id",
+				"type":           entities.UserCommandCreate,
+				"user": usecases.CreateUserRequestBody{
+					FirstName: "alec",
+					LastName:  "smith",
+					Nickname:  "alecsmith",
+					Password:  "some-password",
+					Email:     "alec@email.com",
+					Country:   "UK",
+				},
+			}
+			createdUser = &entities.User{
+				ID:        userID,
+				FirstName: "alec",
+				LastName:  "smith",
+				Nickname:  "alecsmith",
+				Email:     "alec@email.com",
+				Country:   "UK",
+				Status:    entities.UserStatusPendingVerification,
+				Password:  "some-password",
+				CreatedAt: time.Now().UTC(),
+			}
+
+			expectBreachLookup(mockBreachCorpus, "some-password", 0, 1)
+			mockUserCreator.EXPECT().CreateUser(
+				gomock.AssignableToTypeOf(ctxType), "alec", "smith", "alecsmith", "some-password", "alec@email.com", "UK",
+			).Return(createdUser, nil).Times(1)
+			mockChangelogWriter.EXPECT().PublishChangelogEntry(entities.ChangelogEntry{
+				UserID:     userID,
+				CreatedAt:  createdUser.CreatedAt,
+				ChangeType: "POST",
+			}).Return(nil).Times(1)
+			mockEmailVerificationStore.EXPECT().CreateVerificationToken(gomock.AssignableToTypeOf(ctxType), gomock.Any()).Return(nil).Times(1)
+			mockTokenSigner.EXPECT().SignToken(gomock.AssignableToTypeOf(entities.VerificationToken{})).Return("signed-token", nil).Times(1)
+			mockMailer.EXPECT().SendMail(gomock.AssignableToTypeOf(ctxType), gomock.Cond(func(x any) bool {
+				email := x.(entities.Email)
+				return email.To == "alec@email.com" && strings.Contains(email.Body, "signed-token")
+			})).Return(nil).Times(1)
+		})
+
+		It("should reply with the created user", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(HaveLen(1))
+			Expect(replies[0].Status).To(Equal(entities.UserCommandStatusSucceeded))
+			Expect(replies[0].User.ID).To(Equal(userID.String()))
+			Expect(replies[0].User.Status).To(Equal(string(entities.UserStatusPendingVerification)))
+
+			replyJSON, err := json.Marshal(replies[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(replyJSON)).ToNot(ContainSubstring("password"))
+		})
+
+		It("should record the reply with the created user's ID", func() {
+			Expect(recordedReplies).To(HaveLen(1))
+			Expect(recordedReplies[0].UserID).To(Equal(&userID))
+		})
+	})
+
+	When("the command has already been processed", func() {
+		BeforeEach(func() {
+			command["type"] = entities.UserCommandCreate
+			command["user"] = usecases.CreateUserRequestBody{
+				FirstName: "alec",
+				LastName:  "smith",
+				Nickname:  "alecsmith",
+				Password:  "some-password",
+				Email:     "alec@email.com",
+				Country:   "UK",
+			}
+
+			mockReplyStore = mock_usecases.NewMockUserCommandReplyStore(gomock.NewController(GinkgoT()))
+			mockReplyStore.EXPECT().GetUserCommandReply(gomock.AssignableToTypeOf(ctxType), "some-correlation-id").
+				Return(&entities.UserCommandReply{
+					CorrelationID: "some-correlation-id",
+					Type:          entities.UserCommandCreate,
+					Status:        entities.UserCommandStatusSucceeded,
+					UserID:        &userID,
+				}, nil).Times(1)
+		})
+
+		When("the user still exists", func() {
+			BeforeEach(func() {
+				mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).Return(&entities.User{
+					ID:       userID,
+					Email:    "alec@email.com",
+					Password: "some-password",
+					Status:   entities.UserStatusActive,
+				}, nil).Times(1)
+			})
+
+			It("should reply again with the user as they are now, without creating them again", func() {
+				Expect(processErr).ToNot(HaveOccurred())
+				Expect(replies).To(HaveLen(1))
+				Expect(replies[0].Status).To(Equal(entities.UserCommandStatusSucceeded))
+				Expect(replies[0].User.ID).To(Equal(userID.String()))
+				Expect(replies[0].User.Status).To(Equal(string(entities.UserStatusActive)))
+				Expect(deadLetters).To(BeEmpty())
+			})
+		})
+
+		When("the user has since been deleted", func() {
+			BeforeEach(func() {
+				mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+					Return(nil, entities.ErrUserNotFound).Times(1)
+			})
+
+			It("should reply again without the user", func() {
+				Expect(processErr).ToNot(HaveOccurred())
+				Expect(replies).To(Equal([]usecases.UserCommandReplyBody{{
+					CorrelationID: "some-correlation-id",
+					Type:          entities.UserCommandCreate,
+					Status:        entities.UserCommandStatusSucceeded,
+				}}))
+			})
+		})
+	})
+
+	When("the recorded replies can't be checked", func() {
+		BeforeEach(func() {
+			mockReplyStore = mock_usecases.NewMockUserCommandReplyStore(gomock.NewController(GinkgoT()))
+			mockReplyStore.EXPECT().GetUserCommandReply(gomock.AssignableToTypeOf(ctxType), "some-correlation-id").
+				Return(nil, errors.New("an error occurred")).Times(1)
+		})
+
+		It("should return an error without applying the command, so the message isn't committed", func() {
+			Expect(processErr).To(MatchError(ContainSubstring("an error occurred")))
+			Expect(replies).To(BeEmpty())
+			Expect(deadLetters).To(BeEmpty())
+		})
+	})
+
+	When("the message isn't json", func() {
+		BeforeEach(func() {
+			message.Value = []byte("not-json")
+		})
+
+		It("should dead-letter the message without replying", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(BeEmpty())
+			Expect(deadLetters).To(HaveLen(1))
+			Expect(deadLetters[0].Message).To(Equal(message))
+			Expect(deadLetters[0].Reason).To(HavePrefix("decoding command"))
+			Expect(deadLetters[0].Attempts).To(Equal(1))
+		})
+	})
+
+	When("the command has no correlation id", func() {
+		BeforeEach(func() {
+			delete(command, "correlation_id")
+		})
+
+		It("should dead-letter the message as it can't be replied to", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(BeEmpty())
+			Expect(deadLetters).To(HaveLen(1))
+			Expect(deadLetters[0].Reason).To(Equal("command has no correlation_id to reply to"))
+		})
+	})
+
+	When("the command fails to validate", func() {
+		BeforeEach(func() {
+			command["type"] = entities.UserCommandUpdate
+		})
+
+		It("should reply that the command is invalid", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(Equal([]usecases.UserCommandReplyBody{{
+				CorrelationID: "some-correlation-id",
+				Type:          entities.UserCommandUpdate,
+				Status:        entities.UserCommandStatusFailed,
+				Error:         entities.UserCommandErrorInvalidCommand,
+			}}))
+			Expect(deadLetters).To(BeEmpty())
+		})
+	})
+
+	When("the user doesn't exist", func() {
+		BeforeEach(func() {
+			mockUserDeleter.EXPECT().DeleteUser(gomock.AssignableToTypeOf(ctxType), userID).
+				Return(entities.ErrUserNotFound).Times(1)
+		})
+
+		It("should reply without retrying", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(HaveLen(1))
+			Expect(replies[0].Error).To(Equal(entities.UserCommandErrorUserNotFound))
+			Expect(deadLetters).To(BeEmpty())
+		})
+	})
+
+	When("the user is restricted until their email is verified", func() {
+		BeforeEach(func() {
+			policy = entities.UnverifiedAccountPolicy{Mode: entities.UnverifiedAccountPolicyRestrict, GracePeriod: time.Hour}
+			command["type"] = entities.UserCommandUpdate
+			command["user"] = usecases.CreateUserRequestBody{
+				FirstName: "alec",
+				LastName:  "smith",
+				Nickname:  "alecsmith",
+				Password:  "some-password",
+				Email:     "alec@email.com",
+				Country:   "UK",
+			}
+
+			mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).Return(&entities.User{
+				ID:        userID,
+				CreatedAt: time.Now().Add(-2 * time.Hour),
+			}, nil).Times(1)
+		})
+
+		It("should reply that the account is restricted", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(HaveLen(1))
+			Expect(replies[0].Error).To(Equal(entities.UserCommandErrorAccountRestricted))
+		})
+	})
+
+	When("applying the command keeps failing", func() {
+		BeforeEach(func() {
+			mockUserDeleter.EXPECT().DeleteUser(gomock.AssignableToTypeOf(ctxType), userID).
+				Return(errors.New("an error occurred")).Times(2)
+		})
+
+		It("should dead-letter the message after the last attempt and reply", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(deadLetters).To(HaveLen(1))
+			Expect(deadLetters[0].Reason).To(Equal("an error occurred"))
+			Expect(deadLetters[0].Attempts).To(Equal(2))
+			Expect(replies).To(HaveLen(1))
+			Expect(replies[0].Error).To(Equal(entities.UserCommandErrorInternal))
+		})
+
+		It("should not record the reply, so the dead letter can be replayed", func() {
+			Expect(recordedReplies).To(BeEmpty())
+		})
+	})
+
+	When("the reply can't be published at first", func() {
+		BeforeEach(func() {
+			mockReplyWriter = mock_usecases.NewMockUserCommandReplyWriter(gomock.NewController(GinkgoT()))
+			gomock.InOrder(
+				mockReplyWriter.EXPECT().PublishUserCommandReply(gomock.Any()).Return(errors.New("an error occurred")).Times(1),
+				mockReplyWriter.EXPECT().PublishUserCommandReply(gomock.Any()).DoAndReturn(func(reply usecases.UserCommandReplyBody) error {
+					replies = append(replies, reply)
+					return nil
+				}).Times(1),
+			)
+
+			mockUserDeleter.EXPECT().DeleteUser(gomock.AssignableToTypeOf(ctxType), userID).Return(nil).Times(1)
+			mockChangelogWriter.EXPECT().PublishChangelogEntry(gomock.Any()).Return(nil).Times(1)
+			mockCommandAuditLog.EXPECT().AppendAuditEntry(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+				Return(&entities.AuditEntry{}, nil).Times(1)
+		})
+
+		It("should retry the reply without applying the command again", func() {
+			Expect(processErr).ToNot(HaveOccurred())
+			Expect(replies).To(HaveLen(1))
+			Expect(replies[0].Status).To(Equal(entities.UserCommandStatusSucceeded))
+		})
+	})
+
+})
+
+var _ = Describe("Deleting old user command replies", func() {
+	var mockReplyStore *mock_usecases.MockUserCommandReplyStore
+	var deleteErr error
+
+	BeforeEach(func() {
+		mockReplyStore = mock_usecases.NewMockUserCommandReplyStore(gomock.NewController(GinkgoT()))
+	})
+
+	JustBeforeEach(func() {
+		processor := usecases.NewUserCommandProcessor(
+			mockUserRepository,
+			mockChangelogWriter,
+			nil,
+			nil,
+			nil,
+			entities.UnverifiedAccountPolicy{Mode: entities.UnverifiedAccountPolicyAllow},
+			nil,
+			nil,
+			nil,
+			mockReplyStore,
+			2,
+			time.Millisecond,
+			24*time.Hour,
+		)
+		deleteErr = processor.DeleteOldReplies(context.Background())
+	})
+
+	When("there are replies older than the retention", func() {
+		BeforeEach(func() {
+			mockReplyStore.EXPECT().DeleteUserCommandReplies(gomock.AssignableToTypeOf(ctxType), gomock.Cond(func(x any) bool {
+				return x.(time.Time).Sub(time.Now().Add(-24*time.Hour)).Abs() < time.Second
+			})).Return(3, nil).Times(1)
+		})
+
+		It("should delete them", func() {
+			Expect(deleteErr).ToNot(HaveOccurred())
+		})
+	})
+
+	When("the replies can't be deleted", func() {
+		BeforeEach(func() {
+			mockReplyStore.EXPECT().DeleteUserCommandReplies(gomock.AssignableToTypeOf(ctxType), gomock.Any()).
+				Return(0, errors.New("an error occurred")).Times(1)
+		})
+
+		It("should return an error", func() {
+			Expect(deleteErr).To(MatchError(ContainSubstring("an error occurred")))
+		})
+	})
+})
@@ -0,0 +1,255 @@
+package usecases
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/goccy/go-json"
+	"github.com/parquet-go/parquet-go"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// exportRowGroupSize is how many users a parquet export buffers before writing them out as a row group
+const exportRowGroupSize = 10000
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userExporter.go  . "UserExporter"
+type UserExporter interface {
+	// ExportUsers calls each for every user matching the same filters as GetPaginatedUsers, in creation order, without
+	// holding them all in memory. It stops at the first error each returns.
+	ExportUsers(ctx context.Context, firstName, lastName, nickname, email, country string, each func(entities.User) error) error
+}
+
+// parseExportColumns returns the columns named in a comma separated list, or every column if the list is empty
+func parseExportColumns(list string) ([]string, error) {
+	if list == "" {
+		return entities.ExportColumns, nil
+	}
+
+	columns := []string{}
+	for _, column := range strings.Split(list, ",") {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(entities.ExportColumns, column) {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		if slices.Contains(columns, column) {
+			return nil, fmt.Errorf("column %q is listed more than once", column)
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// exportValue returns the user's value for the column, as a string, a time or nil if it isn't set
+func exportValue(user entities.User, column string) any {
+	switch column {
+	case "id":
+		return user.ID.String()
+	case "first_name":
+		return user.FirstName
+	case "last_name":
+		return user.LastName
+	case "nickname":
+		return user.Nickname
+	case "email":
+		return user.Email
+	case "country":
+		return user.Country
+	case "created_at":
+		return user.CreatedAt.UTC()
+	case "updated_at":
+		return user.UpdatedAt.UTC()
+	case "status":
+		return string(user.Status)
+	case "ban_reason":
+		if user.BanReason == "" {
+			return nil
+		}
+		return user.BanReason
+	case "ban_expires_at":
+		return optionalExportTime(user.BanExpiresAt)
+	case "email_verified_at":
+		return optionalExportTime(user.EmailVerifiedAt)
+	case "pending_email":
+		if user.PendingEmail == nil {
+			return nil
+		}
+		return *user.PendingEmail
+	}
+
+	return nil
+}
+
+func optionalExportTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC()
+}
+
+// userExportWriter writes exported users to a file as they are read
+type userExportWriter interface {
+	Write(user entities.User) error
+	// Close finishes the file, it isn't called if the export fails so an incomplete file isn't made to look complete
+	Close() error
+}
+
+// newUserExportWriter returns a writer for the format along with the file's content type and extension
+func newUserExportWriter(format string, columns []string, w io.Writer) (userExportWriter, string, string) {
+	switch format {
+	case entities.ExportFormatNDJSON:
+		return &ndjsonExportWriter{w: w, columns: columns}, "application/x-ndjson", "ndjson"
+	case entities.ExportFormatParquet:
+		return newParquetExportWriter(columns, w), "application/vnd.apache.parquet", "parquet"
+	default:
+		return &csvExportWriter{w: csv.NewWriter(w), columns: columns}, "text/csv", "csv"
+	}
+}
+
+// WriteUserExport writes the users matching the filters to w in the same format as GET /users/export, for exporting
+// without going through the API. columnList is a comma separated list of columns, every column is written if it is
+// empty.
+func WriteUserExport(
+	ctx context.Context,
+	userExporter UserExporter,
+	format, columnList, firstName, lastName, nickname, email, country string,
+	w io.Writer,
+) error {
+	if !slices.Contains([]string{entities.ExportFormatCSV, entities.ExportFormatNDJSON, entities.ExportFormatParquet}, format) {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	columns, err := parseExportColumns(columnList)
+	if err != nil {
+		return err
+	}
+
+	writer, _, _ := newUserExportWriter(format, columns, w)
+	err = userExporter.ExportUsers(ctx, firstName, lastName, nickname, email, country, writer.Write)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// csvExportWriter writes a header row naming the columns then a row per user, unset values are left empty
+type csvExportWriter struct {
+	w             *csv.Writer
+	columns       []string
+	headerWritten bool
+}
+
+func (c *csvExportWriter) Write(user entities.User) error {
+	if !c.headerWritten {
+		err := c.w.Write(c.columns)
+		if err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	record := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		switch value := exportValue(user, column).(type) {
+		case string:
+			record[i] = value
+		case time.Time:
+			record[i] = value.Format(time.RFC3339Nano)
+		}
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvExportWriter) Close() error {
+	if !c.headerWritten {
+		err := c.w.Write(c.columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonExportWriter writes a JSON object per user with its fields in column order, unset values are null
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns []string
+	line    []byte
+}
+
+func (n *ndjsonExportWriter) Write(user entities.User) error {
+	n.line = append(n.line[:0], '{')
+	for i, column := range n.columns {
+		if i > 0 {
+			n.line = append(n.line, ',')
+		}
+
+		value, err := json.Marshal(exportValue(user, column))
+		if err != nil {
+			return err
+		}
+		n.line = append(n.line, '"')
+		n.line = append(n.line, column...)
+		n.line = append(n.line, '"', ':')
+		n.line = append(n.line, value...)
+	}
+	n.line = append(n.line, '}', '\n')
+
+	_, err := n.w.Write(n.line)
+	return err
+}
+
+func (n *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// parquetExportWriter writes users in row groups of exportRowGroupSize, so only one row group is held in memory.
+// Timestamps are stored in microseconds, as postgres stores them.
+type parquetExportWriter struct {
+	w       *parquet.Writer
+	columns []string
+	row     map[string]any
+}
+
+func newParquetExportWriter(columns []string, w io.Writer) *parquetExportWriter {
+	group := parquet.Group{}
+	for _, column := range columns {
+		switch column {
+		case "created_at", "updated_at":
+			group[column] = parquet.Timestamp(parquet.Microsecond)
+		case "ban_expires_at", "email_verified_at":
+			group[column] = parquet.Optional(parquet.Timestamp(parquet.Microsecond))
+		case "ban_reason", "pending_email":
+			group[column] = parquet.Optional(parquet.String())
+		default:
+			group[column] = parquet.String()
+		}
+	}
+
+	return &parquetExportWriter{
+		w:       parquet.NewWriter(w, parquet.NewSchema("user", group), parquet.MaxRowsPerRowGroup(exportRowGroupSize)),
+		columns: columns,
+		row:     make(map[string]any, len(columns)),
+	}
+}
+
+func (p *parquetExportWriter) Write(user entities.User) error {
+	for _, column := range p.columns {
+		p.row[column] = exportValue(user, column)
+	}
+
+	return p.w.Write(p.row)
+}
+
+func (p *parquetExportWriter) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,538 @@
+package usecases
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userImporter.go  . "UserImporter"
+type UserImporter interface {
+	// ImportUsers creates the users together, skipping any whose email is already registered, and returns a result
+	// for each of them in the order given. Nothing is stored in a dry run.
+	ImportUsers(ctx context.Context, users []entities.ImportedUser, dryRun bool) ([]entities.ImportRowResult, error)
+}
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/importJobStore.go  . "ImportJobStore"
+type ImportJobStore interface {
+	CreateImportJob(ctx context.Context, job entities.ImportJob) error
+	// RecordImportResults stores a batch of row results and adds them to the job's counts
+	RecordImportResults(ctx context.Context, jobID uuid.UUID, results []entities.ImportRowResult) error
+	// FinishImportJob moves a running job to status, errorMessage explains why a failed job stopped
+	FinishImportJob(ctx context.Context, jobID uuid.UUID, status entities.ImportJobStatus, errorMessage string, now time.Time) error
+	// HeartbeatImportJob records that a running job is still being run
+	HeartbeatImportJob(ctx context.Context, jobID uuid.UUID, now time.Time) error
+	// FailStaleImportJobs fails running jobs without a heartbeat since staleBefore, returning how many it failed
+	FailStaleImportJobs(ctx context.Context, staleBefore time.Time, errorMessage string, now time.Time) (int, error)
+	GetImportJob(ctx context.Context, jobID uuid.UUID) (*entities.ImportJob, error)
+	// GetImportResults returns up to limit results for the rows after afterRow, in row order
+	GetImportResults(ctx context.Context, jobID uuid.UUID, afterRow, limit int) ([]entities.ImportRowResult, error)
+}
+
+// errInvalidImportFile is returned for files that can't be imported at all, problems with single rows are reported
+// against the row instead
+var errInvalidImportFile = errors.New("invalid import file")
+
+// errImportJobsStopped is returned for jobs started after Shutdown
+var errImportJobsStopped = errors.New("import jobs stopped")
+
+const (
+	// importHeartbeatInterval is how often a running job records that it is still being run
+	importHeartbeatInterval = 30 * time.Second
+	// importJobStaleAfter is how long a running job can go without a heartbeat before it is taken to have been
+	// interrupted, by the instance running it crashing or being killed before it could fail the job itself
+	importJobStaleAfter = 3 * importHeartbeatInterval
+	// importFinishTimeout bounds recording a job's status once it has stopped, which may be after Shutdown
+	importFinishTimeout = 10 * time.Second
+	// importInterruptedMessage is the error of jobs that stopped before importing every row
+	importInterruptedMessage = "interrupted before it finished, rows without a result weren't imported"
+)
+
+// importRowError is returned by an importRowReader for a row that couldn't be parsed, the rows after it can still be
+// read
+type importRowError struct {
+	row    int
+	reason string
+}
+
+func (e *importRowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.row, e.reason)
+}
+
+// importRowReader reads users from an import file one row at a time, returning io.EOF once there are no more
+type importRowReader interface {
+	Read() (entities.ImportedUser, error)
+}
+
+// newImportRowReader returns a reader for a file in the format, CSV files have their header row read straight away
+func newImportRowReader(format string, r io.Reader) (importRowReader, error) {
+	switch format {
+	case entities.ImportFormatCSV:
+		return newCSVImportReader(r)
+	case entities.ImportFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineBytes)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", errInvalidImportFile, format)
+	}
+}
+
+// maxNDJSONLineBytes is far longer than any one user should be, it only stops a file without newlines being read into
+// memory whole
+const maxNDJSONLineBytes = 1024 * 1024
+
+type csvImportReader struct {
+	reader *csv.Reader
+	// columns maps each of entities.ImportColumns to its position in a record
+	columns map[string]int
+	row     int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: missing header row", errInvalidImportFile)
+		}
+		return nil, fmt.Errorf("%w: reading header row: %s", errInvalidImportFile, err)
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+	for _, column := range entities.ImportColumns {
+		if _, found := columns[column]; !found {
+			return nil, fmt.Errorf("%w: header row is missing %s", errInvalidImportFile, column)
+		}
+	}
+
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvImportReader) Read() (entities.ImportedUser, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return entities.ImportedUser{}, io.EOF
+	}
+
+	r.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return entities.ImportedUser{}, &importRowError{row: r.row, reason: parseErr.Err.Error()}
+	}
+	if err != nil {
+		return entities.ImportedUser{}, err
+	}
+
+	return entities.ImportedUser{
+		Row:       r.row,
+		FirstName: record[r.columns["first_name"]],
+		LastName:  record[r.columns["last_name"]],
+		Nickname:  record[r.columns["nickname"]],
+		Password:  record[r.columns["password"]],
+		Email:     record[r.columns["email"]],
+		Country:   record[r.columns["country"]],
+	}, nil
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func (r *ndjsonImportReader) Read() (entities.ImportedUser, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		r.row++
+		var user entities.ImportedUser
+		err := json.Unmarshal([]byte(line), &user)
+		if err != nil {
+			return entities.ImportedUser{}, &importRowError{row: r.row, reason: "invalid json: " + err.Error()}
+		}
+		user.Row = r.row
+
+		return user, nil
+	}
+
+	err := r.scanner.Err()
+	if err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return entities.ImportedUser{}, fmt.Errorf("%w: line after row %d is too long", errInvalidImportFile, r.row)
+		}
+		return entities.ImportedUser{}, err
+	}
+
+	return entities.ImportedUser{}, io.EOF
+}
+
+// UserImport creates users in bulk from CSV or NDJSON files. Rows are validated the same way as POST /user and
+// imported batchSize at a time, with one changelog entry for each batch.
+type UserImport struct {
+	importer          UserImporter
+	jobStore          ImportJobStore
+	changelogWriter   ChangelogWriter
+	passwordValidator *PasswordValidator
+	batchSize         int
+	spoolDir          string
+
+	// jobsCtx is cancelled by Shutdown to stop the jobs running in the background
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	// mu stops jobs being started once Shutdown has begun waiting for the others
+	mu   sync.Mutex
+	jobs sync.WaitGroup
+}
+
+// NewUserImport creates a UserImport, files imported in the background are copied to spoolDir first, or the default
+// temporary directory if it's empty
+func NewUserImport(
+	importer UserImporter,
+	jobStore ImportJobStore,
+	changelogWriter ChangelogWriter,
+	passwordValidator *PasswordValidator,
+	batchSize int,
+	spoolDir string,
+) *UserImport {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	return &UserImport{
+		importer:          importer,
+		jobStore:          jobStore,
+		changelogWriter:   changelogWriter,
+		passwordValidator: passwordValidator,
+		batchSize:         batchSize,
+		spoolDir:          spoolDir,
+		jobsCtx:           jobsCtx,
+		cancelJobs:        cancelJobs,
+	}
+}
+
+// Import reads every row of the file, importing valid ones a batch at a time, and passes the results for each batch's
+// rows to record in row order. An error means the rows after the last recorded result weren't imported, errors
+// wrapping errInvalidImportFile mean the file itself can't be imported.
+func (i *UserImport) Import(
+	ctx context.Context,
+	format string,
+	file io.Reader,
+	dryRun bool,
+	record func(results []entities.ImportRowResult) error,
+) error {
+	reader, err := newImportRowReader(format, file)
+	if err != nil {
+		return err
+	}
+
+	return i.importRows(ctx, reader, dryRun, record)
+}
+
+func (i *UserImport) importRows(
+	ctx context.Context,
+	reader importRowReader,
+	dryRun bool,
+	record func(results []entities.ImportRowResult) error,
+) error {
+	// the row each email was first seen on, so duplicates within the file are reported rather than failing a batch
+	emailRows := map[string]int{}
+	results := make([]entities.ImportRowResult, 0, i.batchSize)
+	batch := make([]entities.ImportedUser, 0, i.batchSize)
+	// batchResults holds the index in results of each user in the batch
+	batchResults := make([]int, 0, i.batchSize)
+
+	flush := func() error {
+		if len(batch) > 0 {
+			imported, err := i.importer.ImportUsers(ctx, batch, dryRun)
+			if err != nil {
+				return fmt.Errorf("importing rows %d to %d: %w", batch[0].Row, batch[len(batch)-1].Row, err)
+			}
+			for j, result := range imported {
+				results[batchResults[j]] = result
+			}
+			i.publishImported(imported)
+		}
+
+		if len(results) > 0 {
+			err := record(results)
+			if err != nil {
+				return fmt.Errorf("recording import results: %w", err)
+			}
+		}
+
+		results = results[:0]
+		batch = batch[:0]
+		batchResults = batchResults[:0]
+		return nil
+	}
+
+	for {
+		user, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			results = append(results, entities.ImportRowResult{Row: rowErr.row, Error: rowErr.reason})
+		} else if err != nil {
+			return err
+		} else {
+			reason, err := i.validate(ctx, user, emailRows)
+			if err != nil {
+				return fmt.Errorf("validating row %d: %w", user.Row, err)
+			}
+
+			results = append(results, entities.ImportRowResult{Row: user.Row, Error: reason})
+			if reason == "" {
+				batch = append(batch, user)
+				batchResults = append(batchResults, len(results)-1)
+			}
+		}
+
+		if len(batch) >= i.batchSize || len(results) >= maxImportResultsPerBatch {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// maxImportResultsPerBatch stops a file of mostly invalid rows from holding every result in memory before recording
+// them
+const maxImportResultsPerBatch = 10000
+
+// validate returns why the user can't be imported, or an empty string if it can
+func (i *UserImport) validate(ctx context.Context, user entities.ImportedUser, emailRows map[string]int) (string, error) {
+	values := map[string]string{
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"nickname":   user.Nickname,
+		"password":   user.Password,
+		"email":      user.Email,
+		"country":    user.Country,
+	}
+	missing := []string{}
+	for _, column := range entities.ImportColumns {
+		if values[column] == "" {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return "missing " + strings.Join(missing, ", "), nil
+	}
+
+	if firstRow, found := emailRows[user.Email]; found {
+		return fmt.Sprintf("email already used by row %d", firstRow), nil
+	}
+	emailRows[user.Email] = user.Row
+
+	err := i.passwordValidator.Validate(ctx, user.Password, user.Nickname, user.Email)
+	if err != nil {
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return policyErr.Error(), nil
+		}
+		return "", err
+	}
+
+	return "", nil
+}
+
+// publishImported publishes one changelog entry for the users a batch created
+func (i *UserImport) publishImported(results []entities.ImportRowResult) {
+	userIDs := []uuid.UUID{}
+	for _, result := range results {
+		if result.UserID != nil {
+			userIDs = append(userIDs, *result.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	entry := entities.ChangelogEntry{
+		CreatedAt:  time.Now().UTC(),
+		ChangeType: entities.ChangeTypeUsersImported,
+		UserIDs:    userIDs,
+	}
+	err := i.changelogWriter.PublishChangelogEntry(entry)
+	if err != nil {
+		// deliberately not returning error here as the users have been created
+		slog.Error("publishing changelog event", "err", err, "changeType", entry.ChangeType, "users", len(userIDs))
+	}
+}
+
+// StartJob copies the file to disk, so that the request can return straight away, then imports it in the background.
+// The job's results can be fetched from the ImportJobStore as it runs.
+func (i *UserImport) StartJob(ctx context.Context, format string, file io.Reader, dryRun bool) (*entities.ImportJob, error) {
+	i.mu.Lock()
+	if i.jobsCtx.Err() != nil {
+		i.mu.Unlock()
+		return nil, errImportJobsStopped
+	}
+	i.jobs.Add(1)
+	i.mu.Unlock()
+
+	job, err := i.startJob(ctx, format, file, dryRun)
+	if err != nil {
+		i.jobs.Done()
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (i *UserImport) startJob(ctx context.Context, format string, file io.Reader, dryRun bool) (*entities.ImportJob, error) {
+	spool, err := os.CreateTemp(i.spoolDir, "user-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating spool file: %w", err)
+	}
+
+	reader, err := i.spool(spool, format, file)
+	if err != nil {
+		spool.Close()
+		os.Remove(spool.Name())
+		return nil, err
+	}
+
+	job := entities.ImportJob{
+		ID:        uuid.New(),
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    entities.ImportJobStatusRunning,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err = i.jobStore.CreateImportJob(ctx, job)
+	if err != nil {
+		spool.Close()
+		os.Remove(spool.Name())
+		return nil, fmt.Errorf("creating import job: %w", err)
+	}
+
+	go i.runJob(job, spool, reader)
+
+	return &job, nil
+}
+
+// spool copies the file to the spool file and returns a reader over the copy, the header is checked before the job is
+// accepted
+func (i *UserImport) spool(spool *os.File, format string, file io.Reader) (importRowReader, error) {
+	_, err := io.Copy(spool, file)
+	if err != nil {
+		return nil, fmt.Errorf("spooling import file: %w", err)
+	}
+
+	_, err = spool.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("rewinding spool file: %w", err)
+	}
+
+	return newImportRowReader(format, bufio.NewReader(spool))
+}
+
+// runJob imports the spooled file until it is done or Shutdown is called. The request that started the job has
+// already returned, so it can't use its context.
+func (i *UserImport) runJob(job entities.ImportJob, spool *os.File, reader importRowReader) {
+	defer i.jobs.Done()
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	ctx, stopHeartbeat := context.WithCancel(i.jobsCtx)
+	defer stopHeartbeat()
+	go i.heartbeat(ctx, job.ID)
+
+	status := entities.ImportJobStatusSucceeded
+	errorMessage := ""
+	err := i.importRows(ctx, reader, job.DryRun, func(results []entities.ImportRowResult) error {
+		return i.jobStore.RecordImportResults(ctx, job.ID, results)
+	})
+	stopHeartbeat()
+	if err != nil {
+		slog.Error("importing users", "err", err, "jobID", job.ID)
+		status = entities.ImportJobStatusFailed
+		errorMessage = err.Error()
+		if i.jobsCtx.Err() != nil {
+			errorMessage = importInterruptedMessage
+		}
+	}
+
+	// the job's status is still recorded when it was stopped by Shutdown
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), importFinishTimeout)
+	defer cancel()
+	err = i.jobStore.FinishImportJob(finishCtx, job.ID, status, errorMessage, time.Now().UTC())
+	if err != nil {
+		slog.Error("finishing import job", "err", err, "jobID", job.ID)
+	}
+}
+
+// heartbeat records that the job is still being run every importHeartbeatInterval until ctx is cancelled
+func (i *UserImport) heartbeat(ctx context.Context, jobID uuid.UUID) {
+	ticker := time.NewTicker(importHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := i.jobStore.HeartbeatImportJob(ctx, jobID, time.Now().UTC())
+			if err != nil {
+				slog.Error("recording import job heartbeat", "err", err, "jobID", jobID)
+			}
+		}
+	}
+}
+
+// Shutdown stops the jobs running in the background, and waits until they have recorded that they failed or ctx is
+// done. Jobs can't be started once it has been called.
+func (i *UserImport) Shutdown(ctx context.Context) error {
+	i.mu.Lock()
+	i.cancelJobs()
+	i.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		i.jobs.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FailInterruptedJobs fails the jobs left running by an instance that stopped without running Shutdown, which no
+// longer have heartbeats. It is run at startup and then periodically, as jobs only go stale a while after they stop.
+func (i *UserImport) FailInterruptedJobs(ctx context.Context) error {
+	now := time.Now().UTC()
+	failed, err := i.jobStore.FailStaleImportJobs(ctx, now.Add(-importJobStaleAfter), importInterruptedMessage, now)
+	if err != nil {
+		return fmt.Errorf("failing interrupted import jobs: %w", err)
+	}
+	if failed > 0 {
+		slog.Warn("failed interrupted import jobs", "count", failed)
+	}
+
+	return nil
+}
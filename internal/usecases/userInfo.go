@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+// NewUserInfo is the OpenID Connect userinfo endpoint
+// @Summary OpenID Connect userinfo
+// @Description Returns the claims about the user that the access token's scopes allow: sub always, nickname,
+// @Description given_name, family_name and country for the profile scope, email and email_verified for the email scope
+// @Tags oidc
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /userinfo [get]
+func NewUserInfo(oidcProvider *OIDCProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := oidcProvider.UserInfo(c.Request.Context(), c.GetHeader("Authorization"))
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("userinfo request without a valid access token", "err", err)
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			var oauthErr *entities.OAuthError
+			if errors.As(err, &oauthErr) {
+				slog.Warn("userinfo request refused", "err", err)
+				c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s"`, oauthErr.Code))
+				c.Status(http.StatusForbidden)
+				return
+			}
+
+			slog.Error("getting userinfo", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, claims)
+	}
+}
@@ -0,0 +1,98 @@
+package usecases_test
+
+import (
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Getting OpenID Connect userinfo", func() {
+	var w *httptest.ResponseRecorder
+	var user *entities.User
+	var accessToken *entities.AccessToken
+	var parseTokenErr error
+	var getUserCallCount int
+
+	BeforeEach(func() {
+		user = &entities.User{
+			ID:        uuid.New(),
+			FirstName: "Alec",
+			LastName:  "Smith",
+			Nickname:  "AlecSmith96",
+			Email:     "alec@email.com",
+			Country:   "UK",
+			Status:    entities.UserStatusActive,
+		}
+		accessToken = &entities.AccessToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			IssuedAt:  time.Now().UTC(),
+			ExpiresAt: time.Now().Add(time.Minute).UTC(),
+			Scope:     "openid email",
+		}
+		parseTokenErr = nil
+		getUserCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockAccessTokenSigner.EXPECT().ParseAccessToken("signed-access-token").Return(accessToken, parseTokenErr).Times(1)
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), user.ID).Return(user, nil).Times(getUserCallCount)
+
+		req, err := http.NewRequest("GET", "http://localhost:8080/userinfo", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer signed-access-token")
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the claims the token's scopes allow", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"sub":"` + user.ID.String() + `","email":"alec@email.com","email_verified":false}`))
+	})
+
+	When("the token was granted the profile scope", func() {
+		BeforeEach(func() {
+			accessToken.Scope = "openid profile"
+		})
+
+		It("should return the profile claims", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(MatchJSON(`{
+				"sub":"` + user.ID.String() + `",
+				"nickname":"AlecSmith96",
+				"given_name":"Alec",
+				"family_name":"Smith",
+				"country":"UK"
+			}`))
+		})
+	})
+
+	When("the token wasn't issued to an OpenID Connect client", func() {
+		BeforeEach(func() {
+			accessToken.Scope = ""
+		})
+
+		It("should return a 403 Forbidden", func() {
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+			Expect(w.Header().Get("WWW-Authenticate")).To(ContainSubstring("insufficient_scope"))
+		})
+	})
+
+	When("the token is invalid", func() {
+		BeforeEach(func() {
+			parseTokenErr = entities.ErrInvalidToken
+			getUserCallCount = 0
+		})
+
+		It("should return a 401 Unauthorized", func() {
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+			Expect(w.Header().Get("WWW-Authenticate")).To(ContainSubstring("invalid_token"))
+		})
+	})
+})
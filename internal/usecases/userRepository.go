@@ -0,0 +1,12 @@
+package usecases
+
+// UserRepository is the storage behind the core user endpoints. PostgresAdapter is the production implementation,
+// adapters.SQLiteAdapter stores users in a single file for single node deployments and adapters.MemoryStore keeps
+// users in memory along with everything else, for running the service without a database.
+type UserRepository interface {
+	UserCreator
+	UserGetter
+	UserUpdater
+	UserDeleter
+	ReadinessChecker
+}
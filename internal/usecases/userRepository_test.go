@@ -0,0 +1,150 @@
+package usecases_test
+
+import (
+	"bytes"
+	"github.com/AlecSmith96/faceit-user-service/internal/adapters"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	mock_usecases "github.com/AlecSmith96/faceit-user-service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Managing users with the in-memory repository", func() {
+	var engine *gin.Engine
+	var breachCorpus *mock_usecases.MockBreachCorpus
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		changelogWriter := mock_usecases.NewMockChangelogWriter(ctrl)
+		changelogWriter.EXPECT().PublishChangelogEntry(gomock.Any()).Return(nil).AnyTimes()
+		breachCorpus = mock_usecases.NewMockBreachCorpus(ctrl)
+
+		userRepository := adapters.NewMemoryUserRepository()
+		passwordValidator := usecases.NewPasswordValidator(
+			entities.PasswordPolicy{MinLength: 10, MaxLength: 128, MinEntropyBits: 40},
+			breachCorpus,
+		)
+
+		// the memory backend can't store verification tokens or pending email changes, so neither is used
+		engine = gin.New()
+		engine.POST("/user", usecases.NewCreateUser(userRepository, changelogWriter, nil, passwordValidator))
+		engine.GET("/users", usecases.NewGetUsers(userRepository, changelogWriter))
+		engine.PUT("/user/:userId", usecases.NewUpdateUser(userRepository, userRepository, nil, changelogWriter, passwordValidator))
+		engine.DELETE("/user/:userId", usecases.NewDeleteUser(userRepository, changelogWriter))
+		engine.GET("/readyz", usecases.NewReadinessCheck(userRepository))
+	})
+
+	serve := func(method, url string, requestBody any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	createUser := func(nickname, email string) usecases.CreateUserResponseBody {
+		w := serve("POST", "http://localhost:8080/user", usecases.CreateUserRequestBody{
+			FirstName: "alec",
+			LastName:  "smith",
+			Nickname:  nickname,
+			Password:  "correct-horse-battery",
+			Email:     email,
+			Country:   "UK",
+		})
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var response usecases.CreateUserResponseBody
+		err := json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		return response
+	}
+
+	It("should create, list, update and delete users", func() {
+		expectBreachLookup(breachCorpus, "correct-horse-battery", 0, 3)
+		created := createUser("alecsmith", "alec@email.com")
+		createUser("janesmith", "jane@email.com")
+		createUser("johnsmith", "john@email.com")
+
+		var listed []string
+		pageInfo := usecases.PageInfo{PageSize: 2}
+		for {
+			w := serve("GET", "http://localhost:8080/users", usecases.GetUsersRequestBody{LastName: "Smith", PageInfo: pageInfo})
+			Expect(w.Code).To(Equal(http.StatusOK))
+			var response usecases.GetUsersResponseBody
+			err := json.NewDecoder(w.Body).Decode(&response)
+			Expect(err).ToNot(HaveOccurred())
+			for _, user := range response.Users {
+				listed = append(listed, user.Nickname)
+			}
+
+			if response.PageInfo.NextPageToken == "" {
+				break
+			}
+			pageInfo.NextPageToken = response.PageInfo.NextPageToken
+		}
+		Expect(listed).To(ConsistOf("alecsmith", "janesmith", "johnsmith"))
+
+		w := serve("PUT", "http://localhost:8080/user/"+created.ID, usecases.CreateUserRequestBody{
+			FirstName: "alex",
+			LastName:  "smith",
+			Nickname:  "alexsmith",
+			Password:  "correct-horse-battery",
+			Email:     "alex@email.com",
+			Country:   "FR",
+		})
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var updated usecases.UpdateUserResponseBody
+		err := json.NewDecoder(w.Body).Decode(&updated)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Nickname).To(Equal("alexsmith"))
+		Expect(updated.Country).To(Equal("FR"))
+		Expect(updated.Email).To(Equal("alex@email.com"))
+
+		w = serve("DELETE", "http://localhost:8080/user/"+created.ID, nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = serve("DELETE", "http://localhost:8080/user/"+created.ID, nil)
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+		w = serve("GET", "http://localhost:8080/users", usecases.GetUsersRequestBody{
+			Nickname: "smith",
+			PageInfo: usecases.PageInfo{PageSize: 10},
+		})
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var response usecases.GetUsersResponseBody
+		err = json.NewDecoder(w.Body).Decode(&response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Users).To(HaveLen(2))
+	})
+
+	It("should reject an email that is already used", func() {
+		expectBreachLookup(breachCorpus, "correct-horse-battery", 0, 2)
+		createUser("alecsmith", "alec@email.com")
+
+		w := serve("POST", "http://localhost:8080/user", usecases.CreateUserRequestBody{
+			FirstName: "alec",
+			LastName:  "smith",
+			Nickname:  "alecsmith2",
+			Password:  "correct-horse-battery",
+			Email:     "alec@email.com",
+			Country:   "UK",
+		})
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should always be ready", func() {
+		req, err := http.NewRequest("GET", "http://localhost:8080/readyz", nil)
+		Expect(err).ToNot(HaveOccurred())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+})
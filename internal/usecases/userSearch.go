@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"context"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"html"
+	"strings"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userSearcher.go  . "UserSearcher"
+type UserSearcher interface {
+	// SearchUsers returns up to limit users matching the query, best match first. Nicknames, names and emails are all
+	// matched by similarity so typos are tolerated.
+	SearchUsers(ctx context.Context, query string, limit int) ([]entities.UserSearchResult, error)
+}
+
+// highlightMatch returns the value, HTML escaped, with the parts of it that match the query wrapped in <mark> tags.
+// Parts match the way pg_trgm matches them, by sharing trigrams with a word of the query, so misspelt matches are
+// highlighted too. If no part matches the whole value is marked.
+func highlightMatch(value, query string) string {
+	queryTrigrams := map[string]bool{}
+	for _, word := range entities.SearchWords(query) {
+		for _, trigram := range entities.WordTrigrams(word.Text) {
+			queryTrigrams[trigram] = true
+		}
+	}
+
+	runes := []rune(value)
+	marked := make([]bool, len(runes))
+	anyMarked := false
+	for _, word := range entities.SearchWords(value) {
+		// the trigrams are of the word padded like pg_trgm pads it, with two spaces before and one after
+		for i, trigram := range entities.WordTrigrams(word.Text) {
+			if !queryTrigrams[trigram] {
+				continue
+			}
+
+			// trigrams made mostly of padding match too easily to be worth highlighting
+			start, end := max(i-2, 0), min(i, word.Length-1)
+			if end <= start {
+				continue
+			}
+			for j := start; j <= end; j++ {
+				marked[word.Start+j] = true
+			}
+			anyMarked = true
+		}
+	}
+
+	if !anyMarked {
+		return highlightStart + html.EscapeString(value) + highlightEnd
+	}
+
+	var highlighted strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+
+		segment := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			segment = highlightStart + segment + highlightEnd
+		}
+		highlighted.WriteString(segment)
+		i = j
+	}
+
+	return highlighted.String()
+}
@@ -0,0 +1,157 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/userStatusUpdater.go  . "UserStatusUpdater"
+type UserStatusUpdater interface {
+	UpdateUserStatus(ctx context.Context, userID uuid.UUID, from, to entities.UserStatus, banReason string, banExpiresAt *time.Time) (*entities.User, error)
+}
+
+// statusTransition describes a move of a user into a new status, and the changelog event it produces
+type statusTransition struct {
+	// from restricts the statuses the transition can start from, if empty any status allowed by the state machine is accepted
+	from         []entities.UserStatus
+	to           entities.UserStatus
+	changeType   string
+	banReason    string
+	banExpiresAt *time.Time
+}
+
+var (
+	suspendTransition = statusTransition{
+		to:         entities.UserStatusSuspended,
+		changeType: entities.ChangeTypeSuspended,
+	}
+	unsuspendTransition = statusTransition{
+		from:       []entities.UserStatus{entities.UserStatusSuspended},
+		to:         entities.UserStatusActive,
+		changeType: entities.ChangeTypeUnsuspended,
+	}
+	unbanTransition = statusTransition{
+		from:       []entities.UserStatus{entities.UserStatusBanned},
+		to:         entities.UserStatusActive,
+		changeType: entities.ChangeTypeUnbanned,
+	}
+)
+
+// transitionUserStatus applies a status transition to the user identified by the userId path parameter and writes the
+// updated user to the response
+func transitionUserStatus(
+	c *gin.Context,
+	userGetter UserGetter,
+	userStatusUpdater UserStatusUpdater,
+	changelogWriter ChangelogWriter,
+	transition statusTransition,
+) {
+	userID := c.Param("userId")
+
+	userIDUUID, err := uuid.Parse(userID)
+	if err != nil {
+		slog.Error("invalid userID", "err", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	user, err := userGetter.GetUserByID(c.Request.Context(), userIDUUID)
+	if err != nil {
+		if errors.Is(err, entities.ErrUserNotFound) {
+			slog.Warn("user not found", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		slog.Error("getting user", "err", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	user, err = changeUserStatus(c.Request.Context(), userStatusUpdater, *user, transition)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidStatusTransition) {
+			slog.Warn("invalid user status transition", "err", err, "userID", userIDUUID)
+			c.Status(http.StatusConflict)
+			return
+		}
+
+		slog.Error("updating user status", "err", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	entry := entities.ChangelogEntry{
+		UserID:     user.ID,
+		CreatedAt:  user.UpdatedAt,
+		ChangeType: transition.changeType,
+	}
+	err = changelogWriter.PublishChangelogEntry(entry)
+	if err != nil {
+		// deliberately not returning error here as request didn't fail
+		slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(*user))
+}
+
+// changeUserStatus moves the user into the transition's status, returning entities.ErrInvalidStatusTransition if their
+// current status doesn't allow it or changed in the meantime
+func changeUserStatus(
+	ctx context.Context,
+	userStatusUpdater UserStatusUpdater,
+	user entities.User,
+	transition statusTransition,
+) (*entities.User, error) {
+	to := transition.to
+	if to == entities.UserStatusActive && user.EmailVerifiedAt == nil {
+		// a user can't become active until they have verified their email
+		to = entities.UserStatusPendingVerification
+	}
+
+	if (len(transition.from) > 0 && !slices.Contains(transition.from, user.Status)) || !user.Status.CanTransitionTo(to) {
+		return nil, fmt.Errorf("%w: from %s to %s", entities.ErrInvalidStatusTransition, user.Status, to)
+	}
+
+	return userStatusUpdater.UpdateUserStatus(ctx, user.ID, user.Status, to, transition.banReason, transition.banExpiresAt)
+}
+
+// SuspendUser suspends a user the same way as POST /admin/user/{userId}/suspend but without publishing a changelog
+// entry, for tools that work on the repository directly
+func SuspendUser(ctx context.Context, userGetter UserGetter, userStatusUpdater UserStatusUpdater, userID uuid.UUID) (*entities.User, error) {
+	user, err := userGetter.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return changeUserStatus(ctx, userStatusUpdater, *user, suspendTransition)
+}
+
+// RestoreUser lifts a user's suspension or ban, the same way as the unsuspend or unban endpoint but without publishing
+// a changelog entry. It returns the changelog entry's change type along with the user.
+func RestoreUser(ctx context.Context, userGetter UserGetter, userStatusUpdater UserStatusUpdater, userID uuid.UUID) (*entities.User, string, error) {
+	user, err := userGetter.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	transition := unsuspendTransition
+	if user.Status == entities.UserStatusBanned {
+		transition = unbanTransition
+	}
+
+	user, err = changeUserStatus(ctx, userStatusUpdater, *user, transition)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, transition.changeType, nil
+}
@@ -0,0 +1,240 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Changing a user's status", func() {
+	var w *httptest.ResponseRecorder
+	var userID uuid.UUID
+	var path string
+	var requestBody []byte
+	var getUserResponse *entities.User
+	var getUserErr error
+	var getUserCallCount int
+	var updateStatusFrom entities.UserStatus
+	var updateStatusTo entities.UserStatus
+	var updateStatusResponse *entities.User
+	var updateStatusErr error
+	var updateStatusCallCount int
+	var changelogChangeType string
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		userID = uuid.New()
+		path = "suspend"
+		requestBody = nil
+		verifiedAt := time.Now().UTC()
+		getUserResponse = &entities.User{
+			ID:              userID,
+			Status:          entities.UserStatusActive,
+			EmailVerifiedAt: &verifiedAt,
+		}
+		getUserErr = nil
+		getUserCallCount = 1
+		updateStatusFrom = entities.UserStatusActive
+		updateStatusTo = entities.UserStatusSuspended
+		updateStatusResponse = &entities.User{
+			ID:        userID,
+			Status:    entities.UserStatusSuspended,
+			UpdatedAt: time.Now().UTC(),
+		}
+		updateStatusErr = nil
+		updateStatusCallCount = 1
+		changelogChangeType = entities.ChangeTypeSuspended
+		changelogWriterCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+
+		mockUserGetter.EXPECT().GetUserByID(gomock.AssignableToTypeOf(ctxType), userID).
+			Return(getUserResponse, getUserErr).Times(getUserCallCount)
+
+		mockUserStatusUpdater.EXPECT().UpdateUserStatus(
+			gomock.AssignableToTypeOf(ctxType),
+			userID,
+			updateStatusFrom,
+			updateStatusTo,
+			gomock.Any(),
+			gomock.Any(),
+		).Return(updateStatusResponse, updateStatusErr).Times(updateStatusCallCount)
+
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(entities.ChangelogEntry{
+			UserID:     userID,
+			CreatedAt:  updateStatusResponse.UpdatedAt,
+			ChangeType: changelogChangeType,
+		}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:8080/admin/user/%s/%s", userID, path), bytes.NewReader(requestBody))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should suspend the user", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var user usecases.UserResponse
+		err := json.NewDecoder(w.Body).Decode(&user)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(user.ID).To(Equal(userID.String()))
+		Expect(user.Status).To(Equal(string(entities.UserStatusSuspended)))
+	})
+
+	When("the user is not found", func() {
+		BeforeEach(func() {
+			getUserErr = entities.ErrUserNotFound
+			updateStatusCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the userGetter adapter returns generic error", func() {
+		BeforeEach(func() {
+			getUserErr = errors.New("an error occurred")
+			updateStatusCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	When("the user is already suspended", func() {
+		BeforeEach(func() {
+			getUserResponse.Status = entities.UserStatusSuspended
+			updateStatusCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	When("the user's status changes before the update is applied", func() {
+		BeforeEach(func() {
+			updateStatusErr = entities.ErrInvalidStatusTransition
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	When("the userStatusUpdater adapter returns generic error", func() {
+		BeforeEach(func() {
+			updateStatusErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	When("unsuspending a suspended user", func() {
+		BeforeEach(func() {
+			path = "unsuspend"
+			getUserResponse.Status = entities.UserStatusSuspended
+			updateStatusFrom = entities.UserStatusSuspended
+			updateStatusTo = entities.UserStatusActive
+			updateStatusResponse.Status = entities.UserStatusActive
+			changelogChangeType = entities.ChangeTypeUnsuspended
+		})
+
+		It("should reactivate the user", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("unsuspending a user that isn't suspended", func() {
+		BeforeEach(func() {
+			path = "unsuspend"
+			getUserResponse.Status = entities.UserStatusBanned
+			updateStatusCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 409 Conflict", func() {
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	When("banning a user with a reason and expiry", func() {
+		BeforeEach(func() {
+			path = "ban"
+			requestBody = []byte(fmt.Sprintf(`{"reason": "cheating", "expires_at": "%s"}`, time.Now().Add(time.Hour).Format(time.RFC3339)))
+			updateStatusTo = entities.UserStatusBanned
+			updateStatusResponse.Status = entities.UserStatusBanned
+			changelogChangeType = entities.ChangeTypeBanned
+		})
+
+		It("should ban the user", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("banning a user with an expiry in the past", func() {
+		BeforeEach(func() {
+			path = "ban"
+			requestBody = []byte(fmt.Sprintf(`{"expires_at": "%s"}`, time.Now().Add(-time.Hour).Format(time.RFC3339)))
+			getUserCallCount = 0
+			updateStatusCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("unbanning a banned user", func() {
+		BeforeEach(func() {
+			path = "unban"
+			getUserResponse.Status = entities.UserStatusBanned
+			updateStatusFrom = entities.UserStatusBanned
+			updateStatusTo = entities.UserStatusActive
+			updateStatusResponse.Status = entities.UserStatusActive
+			changelogChangeType = entities.ChangeTypeUnbanned
+		})
+
+		It("should reactivate the user", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("unbanning a user that never verified their email", func() {
+		BeforeEach(func() {
+			path = "unban"
+			getUserResponse.Status = entities.UserStatusBanned
+			getUserResponse.EmailVerifiedAt = nil
+			updateStatusFrom = entities.UserStatusBanned
+			updateStatusTo = entities.UserStatusPendingVerification
+			updateStatusResponse.Status = entities.UserStatusPendingVerification
+			changelogChangeType = entities.ChangeTypeUnbanned
+		})
+
+		It("should return the user to pending verification", func() {
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+})
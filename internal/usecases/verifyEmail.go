@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// VerifyEmailRequestBody represents the request body for verifying an email address
+// @Description The token sent to the user's email address
+type VerifyEmailRequestBody struct {
+	// Token represents the signed verification token from the email
+	Token string `json:"token" binding:"required"`
+}
+
+// NewVerifyEmail verifies a user's email address
+// @Summary Verify email
+// @Description Redeems an email verification token, marking the user's email as verified and activating a pending account
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body VerifyEmailRequestBody true "Verify Email Request Body"
+// @Success 200 {object} UserResponse
+// @Failure 400
+// @Failure 500
+// @Router /verify-email [post]
+func NewVerifyEmail(tokenSigner TokenSigner, emailVerificationStore EmailVerificationStore, changelogWriter ChangelogWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request VerifyEmailRequestBody
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			slog.Warn("unable to bind request", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		token, err := tokenSigner.ParseToken(request.Token)
+		if err != nil || token.Purpose != entities.TokenPurposeEmailVerification {
+			slog.Warn("invalid verification token", "err", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		user, err := emailVerificationStore.VerifyEmail(c.Request.Context(), *token, time.Now())
+		if err != nil {
+			if errors.Is(err, entities.ErrInvalidToken) {
+				slog.Warn("verification token not redeemable", "err", err)
+				c.Status(http.StatusBadRequest)
+				return
+			}
+
+			slog.Error("verifying email", "err", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		entry := entities.ChangelogEntry{
+			UserID:     user.ID,
+			CreatedAt:  user.UpdatedAt,
+			ChangeType: entities.ChangeTypeEmailVerified,
+		}
+		err = changelogWriter.PublishChangelogEntry(entry)
+		if err != nil {
+			// deliberately not returning error here as request didn't fail
+			slog.Error("publishing changelog event", "err", err, "changelogEntry", entry)
+		}
+
+		c.JSON(http.StatusOK, newUserResponse(*user))
+	}
+}
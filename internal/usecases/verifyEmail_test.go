@@ -0,0 +1,125 @@
+package usecases_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Verifying an email", func() {
+	var w *httptest.ResponseRecorder
+	var requestBody *usecases.VerifyEmailRequestBody
+	var parsedToken *entities.VerificationToken
+	var parseTokenErr error
+	var verifyEmailResponse *entities.User
+	var verifyEmailErr error
+	var verifyEmailCallCount int
+	var changelogWriterCallCount int
+
+	BeforeEach(func() {
+		requestBody = &usecases.VerifyEmailRequestBody{Token: "signed-token"}
+		parsedToken = &entities.VerificationToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Email:     "alec@email.com",
+			Purpose:   entities.TokenPurposeEmailVerification,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		parseTokenErr = nil
+		verifiedAt := time.Now().UTC()
+		verifyEmailResponse = &entities.User{
+			ID:              parsedToken.UserID,
+			Email:           "alec@email.com",
+			Status:          entities.UserStatusActive,
+			EmailVerifiedAt: &verifiedAt,
+			UpdatedAt:       verifiedAt,
+		}
+		verifyEmailErr = nil
+		verifyEmailCallCount = 1
+		changelogWriterCallCount = 1
+	})
+
+	JustBeforeEach(func() {
+		w = httptest.NewRecorder()
+		requestBodyJSON, err := json.Marshal(requestBody)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockTokenSigner.EXPECT().ParseToken(requestBody.Token).Return(parsedToken, parseTokenErr).Times(1)
+		mockEmailVerificationStore.EXPECT().VerifyEmail(gomock.AssignableToTypeOf(ctxType), *parsedToken, gomock.AssignableToTypeOf(time.Time{})).
+			Return(verifyEmailResponse, verifyEmailErr).Times(verifyEmailCallCount)
+		mockChangelogWriter.EXPECT().PublishChangelogEntry(entities.ChangelogEntry{
+			UserID:     verifyEmailResponse.ID,
+			CreatedAt:  verifyEmailResponse.UpdatedAt,
+			ChangeType: entities.ChangeTypeEmailVerified,
+		}).Return(nil).Times(changelogWriterCallCount)
+
+		req, err := http.NewRequest("POST", "http://localhost:8080/verify-email", bytes.NewReader(requestBodyJSON))
+		Expect(err).ToNot(HaveOccurred())
+		r.ServeHTTP(w, req)
+	})
+
+	It("should return the verified user", func() {
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var user usecases.UserResponse
+		err := json.NewDecoder(w.Body).Decode(&user)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(user.Status).To(Equal(string(entities.UserStatusActive)))
+		Expect(user.EmailVerifiedAt).ToNot(BeNil())
+	})
+
+	When("the token signature is invalid", func() {
+		BeforeEach(func() {
+			parseTokenErr = entities.ErrInvalidToken
+			verifyEmailCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the token was issued for another purpose", func() {
+		BeforeEach(func() {
+			parsedToken.Purpose = "something_else"
+			verifyEmailCallCount = 0
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the token has already been used", func() {
+		BeforeEach(func() {
+			verifyEmailErr = entities.ErrInvalidToken
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 400 Bad Request", func() {
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	When("the emailVerificationStore adapter returns generic error", func() {
+		BeforeEach(func() {
+			verifyEmailErr = errors.New("an error occurred")
+			changelogWriterCallCount = 0
+		})
+
+		It("should return a 500 Internal Server Error", func() {
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,283 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/AlecSmith96/faceit-user-service/internal/entities"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen --build_flags=--mod=mod -destination=../../mocks/webAuthnStore.go  . "WebAuthnStore"
+type WebAuthnStore interface {
+	CreateWebAuthnCeremony(ctx context.Context, ceremony entities.WebAuthnCeremony) error
+	RedeemWebAuthnCeremony(ctx context.Context, ceremonyID uuid.UUID, purpose string, now time.Time) (*entities.WebAuthnCeremony, error)
+	GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error)
+	CreateWebAuthnCredential(ctx context.Context, credential entities.WebAuthnCredential) error
+	UpdateWebAuthnCredentialUsage(ctx context.Context, credentialID []byte, signCount uint32, backupState bool, usedAt time.Time) error
+	DeleteWebAuthnCredential(ctx context.Context, userID, credentialID uuid.UUID) error
+}
+
+// webAuthnUser presents a user and their registered credentials in the form the webauthn library expects
+type webAuthnUser struct {
+	id          uuid.UUID
+	name        string
+	displayName string
+	credentials []entities.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.id[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.name
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.displayName
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, credential := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+		for _, transport := range credential.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              credential.CredentialID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: credential.BackupEligible,
+				BackupState:    credential.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    credential.AAGUID,
+				SignCount: credential.SignCount,
+			},
+		})
+	}
+
+	return credentials
+}
+
+// WebAuthnManager runs the registration and login ceremonies for passkeys and security keys
+type WebAuthnManager struct {
+	store       WebAuthnStore
+	webAuthn    *webauthn.WebAuthn
+	ceremonyTTL time.Duration
+}
+
+func NewWebAuthnManager(store WebAuthnStore, rpID, rpDisplayName string, rpOrigins []string, ceremonyTTL time.Duration) (*WebAuthnManager, error) {
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpDisplayName,
+		RPOrigins:     rpOrigins,
+		// discoverable credentials that verify the user are what make a passkey, and let it stand in for a password
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("configuring webauthn: %w", err)
+	}
+
+	return &WebAuthnManager{
+		store:       store,
+		webAuthn:    webAuthn,
+		ceremonyTTL: ceremonyTTL,
+	}, nil
+}
+
+// BeginRegistration starts registering a new credential for the user, returning the ceremony to finish it with and
+// the options to pass to the authenticator
+func (m *WebAuthnManager) BeginRegistration(ctx context.Context, user entities.User) (uuid.UUID, *protocol.CredentialCreation, error) {
+	credentials, err := m.store.GetWebAuthnCredentials(ctx, user.ID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	waUser := &webAuthnUser{id: user.ID, name: user.Email, displayName: user.Nickname, credentials: credentials}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range waUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, session, err := m.webAuthn.BeginRegistration(waUser, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("beginning webauthn registration: %w", err)
+	}
+
+	ceremonyID, err := m.storeCeremony(ctx, &user.ID, entities.WebAuthnCeremonyRegistration, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	return ceremonyID, options, nil
+}
+
+// FinishRegistration checks the authenticator's response to a registration ceremony and stores the new credential
+func (m *WebAuthnManager) FinishRegistration(
+	ctx context.Context,
+	user entities.User,
+	ceremonyID uuid.UUID,
+	name string,
+	response []byte,
+) (*entities.WebAuthnCredential, error) {
+	session, err := m.redeemCeremony(ctx, ceremonyID, entities.WebAuthnCeremonyRegistration, &user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrWebAuthnFailed, err)
+	}
+
+	waUser := &webAuthnUser{id: user.ID, name: user.Email, displayName: user.Nickname}
+	credential, err := m.webAuthn.CreateCredential(waUser, *session, parsedResponse)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrWebAuthnFailed, err)
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	storedCredential := entities.WebAuthnCredential{
+		ID:              uuid.New(),
+		UserID:          user.ID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+		CreatedAt:       time.Now().UTC(),
+	}
+	err = m.store.CreateWebAuthnCredential(ctx, storedCredential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedCredential, nil
+}
+
+// BeginLogin starts a passkey login. The user isn't known until their authenticator responds, so any of the relying
+// party's discoverable credentials can be used.
+func (m *WebAuthnManager) BeginLogin(ctx context.Context) (uuid.UUID, *protocol.CredentialAssertion, error) {
+	options, session, err := m.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("beginning webauthn login: %w", err)
+	}
+
+	ceremonyID, err := m.storeCeremony(ctx, nil, entities.WebAuthnCeremonyLogin, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	return ceremonyID, options, nil
+}
+
+// FinishLogin checks the authenticator's response to a login ceremony, returning the ID of the user it belongs to. A
+// signature counter that hasn't increased since the credential was last used fails the login, as it suggests the
+// credential has been cloned.
+func (m *WebAuthnManager) FinishLogin(ctx context.Context, ceremonyID uuid.UUID, response []byte) (uuid.UUID, error) {
+	session, err := m.redeemCeremony(ctx, ceremonyID, entities.WebAuthnCeremonyLogin, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", entities.ErrWebAuthnFailed, err)
+	}
+
+	var userID uuid.UUID
+	credential, err := m.webAuthn.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		handleUserID, handleErr := uuid.FromBytes(userHandle)
+		if handleErr != nil {
+			return nil, handleErr
+		}
+
+		credentials, handleErr := m.store.GetWebAuthnCredentials(ctx, handleUserID)
+		if handleErr != nil {
+			return nil, handleErr
+		}
+
+		userID = handleUserID
+		return &webAuthnUser{id: handleUserID, credentials: credentials}, nil
+	}, *session, parsedResponse)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", entities.ErrWebAuthnFailed, err)
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return uuid.Nil, fmt.Errorf("%w: signature counter didn't increase, credential may be cloned", entities.ErrWebAuthnFailed)
+	}
+
+	err = m.store.UpdateWebAuthnCredentialUsage(ctx, credential.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, time.Now().UTC())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+func (m *WebAuthnManager) storeCeremony(ctx context.Context, userID *uuid.UUID, purpose string, session *webauthn.SessionData) (uuid.UUID, error) {
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("converting webauthn session to json: %w", err)
+	}
+
+	ceremony := entities.WebAuthnCeremony{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Purpose:     purpose,
+		SessionData: sessionData,
+		ExpiresAt:   time.Now().Add(m.ceremonyTTL).UTC(),
+	}
+	err = m.store.CreateWebAuthnCeremony(ctx, ceremony)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("storing webauthn ceremony: %w", err)
+	}
+
+	return ceremony.ID, nil
+}
+
+// redeemCeremony uses up a ceremony, checking it was started for the given purpose and user
+func (m *WebAuthnManager) redeemCeremony(ctx context.Context, ceremonyID uuid.UUID, purpose string, userID *uuid.UUID) (*webauthn.SessionData, error) {
+	ceremony, err := m.store.RedeemWebAuthnCeremony(ctx, ceremonyID, purpose, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != nil && (ceremony.UserID == nil || *ceremony.UserID != *userID) {
+		return nil, entities.ErrInvalidToken
+	}
+
+	var session webauthn.SessionData
+	err = json.Unmarshal(ceremony.SessionData, &session)
+	if err != nil {
+		return nil, fmt.Errorf("parsing webauthn session: %w", err)
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: AccessTokenSigner)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/accessTokenSigner.go . AccessTokenSigner
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccessTokenSigner is a mock of AccessTokenSigner interface.
+type MockAccessTokenSigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessTokenSignerMockRecorder
+}
+
+// MockAccessTokenSignerMockRecorder is the mock recorder for MockAccessTokenSigner.
+type MockAccessTokenSignerMockRecorder struct {
+	mock *MockAccessTokenSigner
+}
+
+// NewMockAccessTokenSigner creates a new mock instance.
+func NewMockAccessTokenSigner(ctrl *gomock.Controller) *MockAccessTokenSigner {
+	mock := &MockAccessTokenSigner{ctrl: ctrl}
+	mock.recorder = &MockAccessTokenSignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessTokenSigner) EXPECT() *MockAccessTokenSignerMockRecorder {
+	return m.recorder
+}
+
+// ParseAccessToken mocks base method.
+func (m *MockAccessTokenSigner) ParseAccessToken(arg0 string) (*entities.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseAccessToken", arg0)
+	ret0, _ := ret[0].(*entities.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseAccessToken indicates an expected call of ParseAccessToken.
+func (mr *MockAccessTokenSignerMockRecorder) ParseAccessToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseAccessToken", reflect.TypeOf((*MockAccessTokenSigner)(nil).ParseAccessToken), arg0)
+}
+
+// SignAccessToken mocks base method.
+func (m *MockAccessTokenSigner) SignAccessToken(arg0 entities.AccessToken) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignAccessToken", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignAccessToken indicates an expected call of SignAccessToken.
+func (mr *MockAccessTokenSignerMockRecorder) SignAccessToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignAccessToken", reflect.TypeOf((*MockAccessTokenSigner)(nil).SignAccessToken), arg0)
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/adapters (interfaces: KafkaMessageReader)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/adapters/kafkaMessageReader.go . KafkaMessageReader
+//
+// Package mock_adapters is a generated GoMock package.
+package mock_adapters
+
+import (
+	context "context"
+	reflect "reflect"
+
+	kafka "github.com/segmentio/kafka-go"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockKafkaMessageReader is a mock of KafkaMessageReader interface.
+type MockKafkaMessageReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockKafkaMessageReaderMockRecorder
+}
+
+// MockKafkaMessageReaderMockRecorder is the mock recorder for MockKafkaMessageReader.
+type MockKafkaMessageReaderMockRecorder struct {
+	mock *MockKafkaMessageReader
+}
+
+// NewMockKafkaMessageReader creates a new mock instance.
+func NewMockKafkaMessageReader(ctrl *gomock.Controller) *MockKafkaMessageReader {
+	mock := &MockKafkaMessageReader{ctrl: ctrl}
+	mock.recorder = &MockKafkaMessageReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKafkaMessageReader) EXPECT() *MockKafkaMessageReaderMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockKafkaMessageReader) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockKafkaMessageReaderMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockKafkaMessageReader)(nil).Close))
+}
+
+// CommitMessages mocks base method.
+func (m *MockKafkaMessageReader) CommitMessages(arg0 context.Context, arg1 ...kafka.Message) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CommitMessages", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitMessages indicates an expected call of CommitMessages.
+func (mr *MockKafkaMessageReaderMockRecorder) CommitMessages(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitMessages", reflect.TypeOf((*MockKafkaMessageReader)(nil).CommitMessages), varargs...)
+}
+
+// FetchMessage mocks base method.
+func (m *MockKafkaMessageReader) FetchMessage(arg0 context.Context) (kafka.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchMessage", arg0)
+	ret0, _ := ret[0].(kafka.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchMessage indicates an expected call of FetchMessage.
+func (mr *MockKafkaMessageReaderMockRecorder) FetchMessage(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchMessage", reflect.TypeOf((*MockKafkaMessageReader)(nil).FetchMessage), arg0)
+}
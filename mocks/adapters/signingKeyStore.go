@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/adapters (interfaces: SigningKeyStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/adapters/signingKeyStore.go . SigningKeyStore
+//
+// Package mock_adapters is a generated GoMock package.
+package mock_adapters
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSigningKeyStore is a mock of SigningKeyStore interface.
+type MockSigningKeyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyStoreMockRecorder
+}
+
+// MockSigningKeyStoreMockRecorder is the mock recorder for MockSigningKeyStore.
+type MockSigningKeyStoreMockRecorder struct {
+	mock *MockSigningKeyStore
+}
+
+// NewMockSigningKeyStore creates a new mock instance.
+func NewMockSigningKeyStore(ctrl *gomock.Controller) *MockSigningKeyStore {
+	mock := &MockSigningKeyStore{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeyStore) EXPECT() *MockSigningKeyStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateSigningKey mocks base method.
+func (m *MockSigningKeyStore) CreateSigningKey(arg0 context.Context, arg1 entities.SigningKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSigningKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSigningKey indicates an expected call of CreateSigningKey.
+func (mr *MockSigningKeyStoreMockRecorder) CreateSigningKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSigningKey", reflect.TypeOf((*MockSigningKeyStore)(nil).CreateSigningKey), arg0, arg1)
+}
+
+// GetSigningKeys mocks base method.
+func (m *MockSigningKeyStore) GetSigningKeys(arg0 context.Context, arg1 time.Time) ([]entities.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSigningKeys", arg0, arg1)
+	ret0, _ := ret[0].([]entities.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSigningKeys indicates an expected call of GetSigningKeys.
+func (mr *MockSigningKeyStoreMockRecorder) GetSigningKeys(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSigningKeys", reflect.TypeOf((*MockSigningKeyStore)(nil).GetSigningKeys), arg0, arg1)
+}
+
+// RotateSigningKeys mocks base method.
+func (m *MockSigningKeyStore) RotateSigningKeys(arg0 context.Context, arg1 string, arg2 entities.SigningKey, arg3, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningKeys", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateSigningKeys indicates an expected call of RotateSigningKeys.
+func (mr *MockSigningKeyStoreMockRecorder) RotateSigningKeys(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKeys", reflect.TypeOf((*MockSigningKeyStore)(nil).RotateSigningKeys), arg0, arg1, arg2, arg3, arg4)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: AuditLog)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/auditLog.go . AuditLog
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditLog is a mock of AuditLog interface.
+type MockAuditLog struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditLogMockRecorder
+}
+
+// MockAuditLogMockRecorder is the mock recorder for MockAuditLog.
+type MockAuditLogMockRecorder struct {
+	mock *MockAuditLog
+}
+
+// NewMockAuditLog creates a new mock instance.
+func NewMockAuditLog(ctrl *gomock.Controller) *MockAuditLog {
+	mock := &MockAuditLog{ctrl: ctrl}
+	mock.recorder = &MockAuditLogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditLog) EXPECT() *MockAuditLogMockRecorder {
+	return m.recorder
+}
+
+// AppendAuditEntry mocks base method.
+func (m *MockAuditLog) AppendAuditEntry(arg0 context.Context, arg1 entities.AuditEntry) (*entities.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEntry", arg0, arg1)
+	ret0, _ := ret[0].(*entities.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendAuditEntry indicates an expected call of AppendAuditEntry.
+func (mr *MockAuditLogMockRecorder) AppendAuditEntry(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEntry", reflect.TypeOf((*MockAuditLog)(nil).AppendAuditEntry), arg0, arg1)
+}
+
+// GetAuditEntries mocks base method.
+func (m *MockAuditLog) GetAuditEntries(arg0 context.Context, arg1 entities.AuditFilter) ([]entities.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEntries", arg0, arg1)
+	ret0, _ := ret[0].([]entities.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEntries indicates an expected call of GetAuditEntries.
+func (mr *MockAuditLogMockRecorder) GetAuditEntries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEntries", reflect.TypeOf((*MockAuditLog)(nil).GetAuditEntries), arg0, arg1)
+}
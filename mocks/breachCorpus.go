@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: BreachCorpus)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/breachCorpus.go . BreachCorpus
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBreachCorpus is a mock of BreachCorpus interface.
+type MockBreachCorpus struct {
+	ctrl     *gomock.Controller
+	recorder *MockBreachCorpusMockRecorder
+}
+
+// MockBreachCorpusMockRecorder is the mock recorder for MockBreachCorpus.
+type MockBreachCorpusMockRecorder struct {
+	mock *MockBreachCorpus
+}
+
+// NewMockBreachCorpus creates a new mock instance.
+func NewMockBreachCorpus(ctrl *gomock.Controller) *MockBreachCorpus {
+	mock := &MockBreachCorpus{ctrl: ctrl}
+	mock.recorder = &MockBreachCorpusMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBreachCorpus) EXPECT() *MockBreachCorpusMockRecorder {
+	return m.recorder
+}
+
+// GetBreachedSuffixes mocks base method.
+func (m *MockBreachCorpus) GetBreachedSuffixes(arg0 context.Context, arg1 string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBreachedSuffixes", arg0, arg1)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBreachedSuffixes indicates an expected call of GetBreachedSuffixes.
+func (mr *MockBreachCorpusMockRecorder) GetBreachedSuffixes(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBreachedSuffixes", reflect.TypeOf((*MockBreachCorpus)(nil).GetBreachedSuffixes), arg0, arg1)
+}
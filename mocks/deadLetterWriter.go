@@ -0,0 +1,53 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: DeadLetterWriter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/deadLetterWriter.go . DeadLetterWriter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDeadLetterWriter is a mock of DeadLetterWriter interface.
+type MockDeadLetterWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadLetterWriterMockRecorder
+}
+
+// MockDeadLetterWriterMockRecorder is the mock recorder for MockDeadLetterWriter.
+type MockDeadLetterWriterMockRecorder struct {
+	mock *MockDeadLetterWriter
+}
+
+// NewMockDeadLetterWriter creates a new mock instance.
+func NewMockDeadLetterWriter(ctrl *gomock.Controller) *MockDeadLetterWriter {
+	mock := &MockDeadLetterWriter{ctrl: ctrl}
+	mock.recorder = &MockDeadLetterWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLetterWriter) EXPECT() *MockDeadLetterWriterMockRecorder {
+	return m.recorder
+}
+
+// PublishDeadLetter mocks base method.
+func (m *MockDeadLetterWriter) PublishDeadLetter(arg0 entities.DeadLetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDeadLetter", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishDeadLetter indicates an expected call of PublishDeadLetter.
+func (mr *MockDeadLetterWriterMockRecorder) PublishDeadLetter(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDeadLetter", reflect.TypeOf((*MockDeadLetterWriter)(nil).PublishDeadLetter), arg0)
+}
@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: EmailChangeStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/emailChangeStore.go . EmailChangeStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailChangeStore is a mock of EmailChangeStore interface.
+type MockEmailChangeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailChangeStoreMockRecorder
+}
+
+// MockEmailChangeStoreMockRecorder is the mock recorder for MockEmailChangeStore.
+type MockEmailChangeStoreMockRecorder struct {
+	mock *MockEmailChangeStore
+}
+
+// NewMockEmailChangeStore creates a new mock instance.
+func NewMockEmailChangeStore(ctrl *gomock.Controller) *MockEmailChangeStore {
+	mock := &MockEmailChangeStore{ctrl: ctrl}
+	mock.recorder = &MockEmailChangeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailChangeStore) EXPECT() *MockEmailChangeStoreMockRecorder {
+	return m.recorder
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockEmailChangeStore) ConfirmEmailChange(arg0 context.Context, arg1 entities.VerificationToken, arg2 time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockEmailChangeStoreMockRecorder) ConfirmEmailChange(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockEmailChangeStore)(nil).ConfirmEmailChange), arg0, arg1, arg2)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockEmailChangeStore) RequestEmailChange(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3, arg4 entities.VerificationToken) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockEmailChangeStoreMockRecorder) RequestEmailChange(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockEmailChangeStore)(nil).RequestEmailChange), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RevertEmailChange mocks base method.
+func (m *MockEmailChangeStore) RevertEmailChange(arg0 context.Context, arg1 entities.VerificationToken, arg2 time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertEmailChange", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertEmailChange indicates an expected call of RevertEmailChange.
+func (mr *MockEmailChangeStoreMockRecorder) RevertEmailChange(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertEmailChange", reflect.TypeOf((*MockEmailChangeStore)(nil).RevertEmailChange), arg0, arg1, arg2)
+}
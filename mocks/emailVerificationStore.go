@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: EmailVerificationStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/emailVerificationStore.go . EmailVerificationStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailVerificationStore is a mock of EmailVerificationStore interface.
+type MockEmailVerificationStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerificationStoreMockRecorder
+}
+
+// MockEmailVerificationStoreMockRecorder is the mock recorder for MockEmailVerificationStore.
+type MockEmailVerificationStoreMockRecorder struct {
+	mock *MockEmailVerificationStore
+}
+
+// NewMockEmailVerificationStore creates a new mock instance.
+func NewMockEmailVerificationStore(ctrl *gomock.Controller) *MockEmailVerificationStore {
+	mock := &MockEmailVerificationStore{ctrl: ctrl}
+	mock.recorder = &MockEmailVerificationStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerificationStore) EXPECT() *MockEmailVerificationStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateVerificationToken mocks base method.
+func (m *MockEmailVerificationStore) CreateVerificationToken(arg0 context.Context, arg1 entities.VerificationToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerificationToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateVerificationToken indicates an expected call of CreateVerificationToken.
+func (mr *MockEmailVerificationStoreMockRecorder) CreateVerificationToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerificationToken", reflect.TypeOf((*MockEmailVerificationStore)(nil).CreateVerificationToken), arg0, arg1)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockEmailVerificationStore) VerifyEmail(arg0 context.Context, arg1 entities.VerificationToken, arg2 time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockEmailVerificationStoreMockRecorder) VerifyEmail(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockEmailVerificationStore)(nil).VerifyEmail), arg0, arg1, arg2)
+}
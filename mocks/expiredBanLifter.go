@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: ExpiredBanLifter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/expiredBanLifter.go . ExpiredBanLifter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockExpiredBanLifter is a mock of ExpiredBanLifter interface.
+type MockExpiredBanLifter struct {
+	ctrl     *gomock.Controller
+	recorder *MockExpiredBanLifterMockRecorder
+}
+
+// MockExpiredBanLifterMockRecorder is the mock recorder for MockExpiredBanLifter.
+type MockExpiredBanLifterMockRecorder struct {
+	mock *MockExpiredBanLifter
+}
+
+// NewMockExpiredBanLifter creates a new mock instance.
+func NewMockExpiredBanLifter(ctrl *gomock.Controller) *MockExpiredBanLifter {
+	mock := &MockExpiredBanLifter{ctrl: ctrl}
+	mock.recorder = &MockExpiredBanLifterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExpiredBanLifter) EXPECT() *MockExpiredBanLifterMockRecorder {
+	return m.recorder
+}
+
+// LiftExpiredBans mocks base method.
+func (m *MockExpiredBanLifter) LiftExpiredBans(arg0 context.Context, arg1 time.Time) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LiftExpiredBans", arg0, arg1)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LiftExpiredBans indicates an expected call of LiftExpiredBans.
+func (mr *MockExpiredBanLifterMockRecorder) LiftExpiredBans(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiftExpiredBans", reflect.TypeOf((*MockExpiredBanLifter)(nil).LiftExpiredBans), arg0, arg1)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: IDTokenSigner)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/idTokenSigner.go . IDTokenSigner
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIDTokenSigner is a mock of IDTokenSigner interface.
+type MockIDTokenSigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockIDTokenSignerMockRecorder
+}
+
+// MockIDTokenSignerMockRecorder is the mock recorder for MockIDTokenSigner.
+type MockIDTokenSignerMockRecorder struct {
+	mock *MockIDTokenSigner
+}
+
+// NewMockIDTokenSigner creates a new mock instance.
+func NewMockIDTokenSigner(ctrl *gomock.Controller) *MockIDTokenSigner {
+	mock := &MockIDTokenSigner{ctrl: ctrl}
+	mock.recorder = &MockIDTokenSignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIDTokenSigner) EXPECT() *MockIDTokenSignerMockRecorder {
+	return m.recorder
+}
+
+// PublicKeys mocks base method.
+func (m *MockIDTokenSigner) PublicKeys(arg0 context.Context) ([]entities.JSONWebKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublicKeys", arg0)
+	ret0, _ := ret[0].([]entities.JSONWebKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublicKeys indicates an expected call of PublicKeys.
+func (mr *MockIDTokenSignerMockRecorder) PublicKeys(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublicKeys", reflect.TypeOf((*MockIDTokenSigner)(nil).PublicKeys), arg0)
+}
+
+// SignIDToken mocks base method.
+func (m *MockIDTokenSigner) SignIDToken(arg0 context.Context, arg1 entities.IDTokenClaims) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignIDToken", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignIDToken indicates an expected call of SignIDToken.
+func (mr *MockIDTokenSignerMockRecorder) SignIDToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIDToken", reflect.TypeOf((*MockIDTokenSigner)(nil).SignIDToken), arg0, arg1)
+}
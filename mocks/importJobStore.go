@@ -0,0 +1,143 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: ImportJobStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/importJobStore.go . ImportJobStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockImportJobStore is a mock of ImportJobStore interface.
+type MockImportJobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportJobStoreMockRecorder
+}
+
+// MockImportJobStoreMockRecorder is the mock recorder for MockImportJobStore.
+type MockImportJobStoreMockRecorder struct {
+	mock *MockImportJobStore
+}
+
+// NewMockImportJobStore creates a new mock instance.
+func NewMockImportJobStore(ctrl *gomock.Controller) *MockImportJobStore {
+	mock := &MockImportJobStore{ctrl: ctrl}
+	mock.recorder = &MockImportJobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportJobStore) EXPECT() *MockImportJobStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateImportJob mocks base method.
+func (m *MockImportJobStore) CreateImportJob(arg0 context.Context, arg1 entities.ImportJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateImportJob", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateImportJob indicates an expected call of CreateImportJob.
+func (mr *MockImportJobStoreMockRecorder) CreateImportJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateImportJob", reflect.TypeOf((*MockImportJobStore)(nil).CreateImportJob), arg0, arg1)
+}
+
+// FailStaleImportJobs mocks base method.
+func (m *MockImportJobStore) FailStaleImportJobs(arg0 context.Context, arg1 time.Time, arg2 string, arg3 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailStaleImportJobs", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailStaleImportJobs indicates an expected call of FailStaleImportJobs.
+func (mr *MockImportJobStoreMockRecorder) FailStaleImportJobs(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailStaleImportJobs", reflect.TypeOf((*MockImportJobStore)(nil).FailStaleImportJobs), arg0, arg1, arg2, arg3)
+}
+
+// FinishImportJob mocks base method.
+func (m *MockImportJobStore) FinishImportJob(arg0 context.Context, arg1 uuid.UUID, arg2 entities.ImportJobStatus, arg3 string, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishImportJob", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishImportJob indicates an expected call of FinishImportJob.
+func (mr *MockImportJobStoreMockRecorder) FinishImportJob(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishImportJob", reflect.TypeOf((*MockImportJobStore)(nil).FinishImportJob), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetImportJob mocks base method.
+func (m *MockImportJobStore) GetImportJob(arg0 context.Context, arg1 uuid.UUID) (*entities.ImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportJob", arg0, arg1)
+	ret0, _ := ret[0].(*entities.ImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImportJob indicates an expected call of GetImportJob.
+func (mr *MockImportJobStoreMockRecorder) GetImportJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportJob", reflect.TypeOf((*MockImportJobStore)(nil).GetImportJob), arg0, arg1)
+}
+
+// GetImportResults mocks base method.
+func (m *MockImportJobStore) GetImportResults(arg0 context.Context, arg1 uuid.UUID, arg2, arg3 int) ([]entities.ImportRowResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportResults", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]entities.ImportRowResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImportResults indicates an expected call of GetImportResults.
+func (mr *MockImportJobStoreMockRecorder) GetImportResults(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportResults", reflect.TypeOf((*MockImportJobStore)(nil).GetImportResults), arg0, arg1, arg2, arg3)
+}
+
+// HeartbeatImportJob mocks base method.
+func (m *MockImportJobStore) HeartbeatImportJob(arg0 context.Context, arg1 uuid.UUID, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeartbeatImportJob", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HeartbeatImportJob indicates an expected call of HeartbeatImportJob.
+func (mr *MockImportJobStoreMockRecorder) HeartbeatImportJob(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeartbeatImportJob", reflect.TypeOf((*MockImportJobStore)(nil).HeartbeatImportJob), arg0, arg1, arg2)
+}
+
+// RecordImportResults mocks base method.
+func (m *MockImportJobStore) RecordImportResults(arg0 context.Context, arg1 uuid.UUID, arg2 []entities.ImportRowResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordImportResults", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordImportResults indicates an expected call of RecordImportResults.
+func (mr *MockImportJobStoreMockRecorder) RecordImportResults(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordImportResults", reflect.TypeOf((*MockImportJobStore)(nil).RecordImportResults), arg0, arg1, arg2)
+}
@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: LoginAuditStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/loginAuditStore.go . LoginAuditStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginAuditStore is a mock of LoginAuditStore interface.
+type MockLoginAuditStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAuditStoreMockRecorder
+}
+
+// MockLoginAuditStoreMockRecorder is the mock recorder for MockLoginAuditStore.
+type MockLoginAuditStoreMockRecorder struct {
+	mock *MockLoginAuditStore
+}
+
+// NewMockLoginAuditStore creates a new mock instance.
+func NewMockLoginAuditStore(ctrl *gomock.Controller) *MockLoginAuditStore {
+	mock := &MockLoginAuditStore{ctrl: ctrl}
+	mock.recorder = &MockLoginAuditStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAuditStore) EXPECT() *MockLoginAuditStoreMockRecorder {
+	return m.recorder
+}
+
+// RecordLoginAttempt mocks base method.
+func (m *MockLoginAuditStore) RecordLoginAttempt(arg0 context.Context, arg1 entities.LoginAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginAttempt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLoginAttempt indicates an expected call of RecordLoginAttempt.
+func (mr *MockLoginAuditStoreMockRecorder) RecordLoginAttempt(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginAttempt", reflect.TypeOf((*MockLoginAuditStore)(nil).RecordLoginAttempt), arg0, arg1)
+}
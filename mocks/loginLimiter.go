@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: LoginLimiter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/loginLimiter.go . LoginLimiter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginLimiter is a mock of LoginLimiter interface.
+type MockLoginLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginLimiterMockRecorder
+}
+
+// MockLoginLimiterMockRecorder is the mock recorder for MockLoginLimiter.
+type MockLoginLimiterMockRecorder struct {
+	mock *MockLoginLimiter
+}
+
+// NewMockLoginLimiter creates a new mock instance.
+func NewMockLoginLimiter(ctrl *gomock.Controller) *MockLoginLimiter {
+	mock := &MockLoginLimiter{ctrl: ctrl}
+	mock.recorder = &MockLoginLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginLimiter) EXPECT() *MockLoginLimiterMockRecorder {
+	return m.recorder
+}
+
+// GetLoginThrottle mocks base method.
+func (m *MockLoginLimiter) GetLoginThrottle(arg0 context.Context, arg1 string, arg2 time.Time) (*entities.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginThrottle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entities.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginThrottle indicates an expected call of GetLoginThrottle.
+func (mr *MockLoginLimiterMockRecorder) GetLoginThrottle(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottle", reflect.TypeOf((*MockLoginLimiter)(nil).GetLoginThrottle), arg0, arg1, arg2)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockLoginLimiter) RecordLoginFailure(arg0 context.Context, arg1 string, arg2 entities.LoginThrottlePolicy, arg3 time.Time) (*entities.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entities.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockLoginLimiterMockRecorder) RecordLoginFailure(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockLoginLimiter)(nil).RecordLoginFailure), arg0, arg1, arg2, arg3)
+}
+
+// ResetLoginThrottle mocks base method.
+func (m *MockLoginLimiter) ResetLoginThrottle(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginThrottle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginThrottle indicates an expected call of ResetLoginThrottle.
+func (mr *MockLoginLimiterMockRecorder) ResetLoginThrottle(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginThrottle", reflect.TypeOf((*MockLoginLimiter)(nil).ResetLoginThrottle), arg0, arg1)
+}
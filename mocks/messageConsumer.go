@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: MessageConsumer)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/messageConsumer.go . MessageConsumer
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageConsumer is a mock of MessageConsumer interface.
+type MockMessageConsumer struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageConsumerMockRecorder
+}
+
+// MockMessageConsumerMockRecorder is the mock recorder for MockMessageConsumer.
+type MockMessageConsumerMockRecorder struct {
+	mock *MockMessageConsumer
+}
+
+// NewMockMessageConsumer creates a new mock instance.
+func NewMockMessageConsumer(ctrl *gomock.Controller) *MockMessageConsumer {
+	mock := &MockMessageConsumer{ctrl: ctrl}
+	mock.recorder = &MockMessageConsumerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageConsumer) EXPECT() *MockMessageConsumerMockRecorder {
+	return m.recorder
+}
+
+// CommitMessage mocks base method.
+func (m *MockMessageConsumer) CommitMessage(arg0 context.Context, arg1 entities.InboundMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitMessage indicates an expected call of CommitMessage.
+func (mr *MockMessageConsumerMockRecorder) CommitMessage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitMessage", reflect.TypeOf((*MockMessageConsumer)(nil).CommitMessage), arg0, arg1)
+}
+
+// FetchMessage mocks base method.
+func (m *MockMessageConsumer) FetchMessage(arg0 context.Context) (entities.InboundMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchMessage", arg0)
+	ret0, _ := ret[0].(entities.InboundMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchMessage indicates an expected call of FetchMessage.
+func (mr *MockMessageConsumerMockRecorder) FetchMessage(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchMessage", reflect.TypeOf((*MockMessageConsumer)(nil).FetchMessage), arg0)
+}
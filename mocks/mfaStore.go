@@ -0,0 +1,169 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: MFAStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/mfaStore.go . MFAStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMFAStore is a mock of MFAStore interface.
+type MockMFAStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAStoreMockRecorder
+}
+
+// MockMFAStoreMockRecorder is the mock recorder for MockMFAStore.
+type MockMFAStoreMockRecorder struct {
+	mock *MockMFAStore
+}
+
+// NewMockMFAStore creates a new mock instance.
+func NewMockMFAStore(ctrl *gomock.Controller) *MockMFAStore {
+	mock := &MockMFAStore{ctrl: ctrl}
+	mock.recorder = &MockMFAStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAStore) EXPECT() *MockMFAStoreMockRecorder {
+	return m.recorder
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockMFAStore) ConfirmTOTP(arg0 context.Context, arg1 uuid.UUID, arg2 int64, arg3 []string, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockMFAStoreMockRecorder) ConfirmTOTP(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockMFAStore)(nil).ConfirmTOTP), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CreateVerificationToken mocks base method.
+func (m *MockMFAStore) CreateVerificationToken(arg0 context.Context, arg1 entities.VerificationToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerificationToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateVerificationToken indicates an expected call of CreateVerificationToken.
+func (mr *MockMFAStoreMockRecorder) CreateVerificationToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerificationToken", reflect.TypeOf((*MockMFAStore)(nil).CreateVerificationToken), arg0, arg1)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockMFAStore) DisableTOTP(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockMFAStoreMockRecorder) DisableTOTP(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockMFAStore)(nil).DisableTOTP), arg0, arg1)
+}
+
+// EnrolTOTP mocks base method.
+func (m *MockMFAStore) EnrolTOTP(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrolTOTP", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnrolTOTP indicates an expected call of EnrolTOTP.
+func (mr *MockMFAStoreMockRecorder) EnrolTOTP(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrolTOTP", reflect.TypeOf((*MockMFAStore)(nil).EnrolTOTP), arg0, arg1, arg2, arg3)
+}
+
+// GetTOTPEnrolment mocks base method.
+func (m *MockMFAStore) GetTOTPEnrolment(arg0 context.Context, arg1 uuid.UUID) (*entities.TOTPEnrolment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTPEnrolment", arg0, arg1)
+	ret0, _ := ret[0].(*entities.TOTPEnrolment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTPEnrolment indicates an expected call of GetTOTPEnrolment.
+func (mr *MockMFAStoreMockRecorder) GetTOTPEnrolment(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTPEnrolment", reflect.TypeOf((*MockMFAStore)(nil).GetTOTPEnrolment), arg0, arg1)
+}
+
+// RedeemVerificationToken mocks base method.
+func (m *MockMFAStore) RedeemVerificationToken(arg0 context.Context, arg1 entities.VerificationToken, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemVerificationToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RedeemVerificationToken indicates an expected call of RedeemVerificationToken.
+func (mr *MockMFAStoreMockRecorder) RedeemVerificationToken(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemVerificationToken", reflect.TypeOf((*MockMFAStore)(nil).RedeemVerificationToken), arg0, arg1, arg2)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockMFAStore) ReplaceRecoveryCodes(arg0 context.Context, arg1 uuid.UUID, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockMFAStoreMockRecorder) ReplaceRecoveryCodes(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockMFAStore)(nil).ReplaceRecoveryCodes), arg0, arg1, arg2)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockMFAStore) UseRecoveryCode(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockMFAStoreMockRecorder) UseRecoveryCode(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockMFAStore)(nil).UseRecoveryCode), arg0, arg1, arg2, arg3)
+}
+
+// UseTOTPCounter mocks base method.
+func (m *MockMFAStore) UseTOTPCounter(arg0 context.Context, arg1 uuid.UUID, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPCounter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTOTPCounter indicates an expected call of UseTOTPCounter.
+func (mr *MockMFAStoreMockRecorder) UseTOTPCounter(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPCounter", reflect.TypeOf((*MockMFAStore)(nil).UseTOTPCounter), arg0, arg1, arg2)
+}
@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: OIDCStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/oidcStore.go . OIDCStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOIDCStore is a mock of OIDCStore interface.
+type MockOIDCStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCStoreMockRecorder
+}
+
+// MockOIDCStoreMockRecorder is the mock recorder for MockOIDCStore.
+type MockOIDCStoreMockRecorder struct {
+	mock *MockOIDCStore
+}
+
+// NewMockOIDCStore creates a new mock instance.
+func NewMockOIDCStore(ctrl *gomock.Controller) *MockOIDCStore {
+	mock := &MockOIDCStore{ctrl: ctrl}
+	mock.recorder = &MockOIDCStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCStore) EXPECT() *MockOIDCStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateOIDCAuthorizationCode mocks base method.
+func (m *MockOIDCStore) CreateOIDCAuthorizationCode(arg0 context.Context, arg1 entities.OIDCAuthorizationCode, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCAuthorizationCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOIDCAuthorizationCode indicates an expected call of CreateOIDCAuthorizationCode.
+func (mr *MockOIDCStoreMockRecorder) CreateOIDCAuthorizationCode(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCAuthorizationCode", reflect.TypeOf((*MockOIDCStore)(nil).CreateOIDCAuthorizationCode), arg0, arg1, arg2)
+}
+
+// CreateOIDCClient mocks base method.
+func (m *MockOIDCStore) CreateOIDCClient(arg0 context.Context, arg1 entities.OIDCClient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCClient", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOIDCClient indicates an expected call of CreateOIDCClient.
+func (mr *MockOIDCStoreMockRecorder) CreateOIDCClient(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCClient", reflect.TypeOf((*MockOIDCStore)(nil).CreateOIDCClient), arg0, arg1)
+}
+
+// GetOIDCClient mocks base method.
+func (m *MockOIDCStore) GetOIDCClient(arg0 context.Context, arg1 string) (*entities.OIDCClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOIDCClient", arg0, arg1)
+	ret0, _ := ret[0].(*entities.OIDCClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOIDCClient indicates an expected call of GetOIDCClient.
+func (mr *MockOIDCStoreMockRecorder) GetOIDCClient(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOIDCClient", reflect.TypeOf((*MockOIDCStore)(nil).GetOIDCClient), arg0, arg1)
+}
+
+// GetOIDCConsent mocks base method.
+func (m *MockOIDCStore) GetOIDCConsent(arg0 context.Context, arg1 uuid.UUID, arg2 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOIDCConsent", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOIDCConsent indicates an expected call of GetOIDCConsent.
+func (mr *MockOIDCStoreMockRecorder) GetOIDCConsent(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOIDCConsent", reflect.TypeOf((*MockOIDCStore)(nil).GetOIDCConsent), arg0, arg1, arg2)
+}
+
+// GrantOIDCConsent mocks base method.
+func (m *MockOIDCStore) GrantOIDCConsent(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 []string, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantOIDCConsent", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantOIDCConsent indicates an expected call of GrantOIDCConsent.
+func (mr *MockOIDCStoreMockRecorder) GrantOIDCConsent(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantOIDCConsent", reflect.TypeOf((*MockOIDCStore)(nil).GrantOIDCConsent), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RedeemOIDCAuthorizationCode mocks base method.
+func (m *MockOIDCStore) RedeemOIDCAuthorizationCode(arg0 context.Context, arg1 string, arg2 time.Time) (*entities.OIDCAuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemOIDCAuthorizationCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entities.OIDCAuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemOIDCAuthorizationCode indicates an expected call of RedeemOIDCAuthorizationCode.
+func (mr *MockOIDCStoreMockRecorder) RedeemOIDCAuthorizationCode(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemOIDCAuthorizationCode", reflect.TypeOf((*MockOIDCStore)(nil).RedeemOIDCAuthorizationCode), arg0, arg1, arg2)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: PasswordManager)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/passwordManager.go . PasswordManager
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPasswordManager is a mock of PasswordManager interface.
+type MockPasswordManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordManagerMockRecorder
+}
+
+// MockPasswordManagerMockRecorder is the mock recorder for MockPasswordManager.
+type MockPasswordManagerMockRecorder struct {
+	mock *MockPasswordManager
+}
+
+// NewMockPasswordManager creates a new mock instance.
+func NewMockPasswordManager(ctrl *gomock.Controller) *MockPasswordManager {
+	mock := &MockPasswordManager{ctrl: ctrl}
+	mock.recorder = &MockPasswordManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordManager) EXPECT() *MockPasswordManagerMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockPasswordManager) ChangePassword(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockPasswordManagerMockRecorder) ChangePassword(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockPasswordManager)(nil).ChangePassword), arg0, arg1, arg2, arg3)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockPasswordManager) CreatePasswordResetToken(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockPasswordManagerMockRecorder) CreatePasswordResetToken(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockPasswordManager)(nil).CreatePasswordResetToken), arg0, arg1, arg2, arg3)
+}
+
+// GetPasswordResetTokenUser mocks base method.
+func (m *MockPasswordManager) GetPasswordResetTokenUser(arg0 context.Context, arg1 string, arg2 time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetTokenUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordResetTokenUser indicates an expected call of GetPasswordResetTokenUser.
+func (mr *MockPasswordManagerMockRecorder) GetPasswordResetTokenUser(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetTokenUser", reflect.TypeOf((*MockPasswordManager)(nil).GetPasswordResetTokenUser), arg0, arg1, arg2)
+}
+
+// ResetPassword mocks base method.
+func (m *MockPasswordManager) ResetPassword(arg0 context.Context, arg1, arg2 string, arg3 time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockPasswordManagerMockRecorder) ResetPassword(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockPasswordManager)(nil).ResetPassword), arg0, arg1, arg2, arg3)
+}
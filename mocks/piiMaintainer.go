@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: PIIMaintainer)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/piiMaintainer.go . PIIMaintainer
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPIIMaintainer is a mock of PIIMaintainer interface.
+type MockPIIMaintainer struct {
+	ctrl     *gomock.Controller
+	recorder *MockPIIMaintainerMockRecorder
+}
+
+// MockPIIMaintainerMockRecorder is the mock recorder for MockPIIMaintainer.
+type MockPIIMaintainerMockRecorder struct {
+	mock *MockPIIMaintainer
+}
+
+// NewMockPIIMaintainer creates a new mock instance.
+func NewMockPIIMaintainer(ctrl *gomock.Controller) *MockPIIMaintainer {
+	mock := &MockPIIMaintainer{ctrl: ctrl}
+	mock.recorder = &MockPIIMaintainerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPIIMaintainer) EXPECT() *MockPIIMaintainerMockRecorder {
+	return m.recorder
+}
+
+// RewrapDataKeys mocks base method.
+func (m *MockPIIMaintainer) RewrapDataKeys(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RewrapDataKeys", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RewrapDataKeys indicates an expected call of RewrapDataKeys.
+func (mr *MockPIIMaintainerMockRecorder) RewrapDataKeys(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RewrapDataKeys", reflect.TypeOf((*MockPIIMaintainer)(nil).RewrapDataKeys), arg0)
+}
+
+// SealUsers mocks base method.
+func (m *MockPIIMaintainer) SealUsers(arg0 context.Context, arg1 int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SealUsers", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SealUsers indicates an expected call of SealUsers.
+func (mr *MockPIIMaintainerMockRecorder) SealUsers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SealUsers", reflect.TypeOf((*MockPIIMaintainer)(nil).SealUsers), arg0, arg1)
+}
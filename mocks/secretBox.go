@@ -0,0 +1,68 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: SecretBox)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/secretBox.go . SecretBox
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSecretBox is a mock of SecretBox interface.
+type MockSecretBox struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecretBoxMockRecorder
+}
+
+// MockSecretBoxMockRecorder is the mock recorder for MockSecretBox.
+type MockSecretBoxMockRecorder struct {
+	mock *MockSecretBox
+}
+
+// NewMockSecretBox creates a new mock instance.
+func NewMockSecretBox(ctrl *gomock.Controller) *MockSecretBox {
+	mock := &MockSecretBox{ctrl: ctrl}
+	mock.recorder = &MockSecretBoxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecretBox) EXPECT() *MockSecretBoxMockRecorder {
+	return m.recorder
+}
+
+// Open mocks base method.
+func (m *MockSecretBox) Open(arg0 string, arg1 []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockSecretBoxMockRecorder) Open(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockSecretBox)(nil).Open), arg0, arg1)
+}
+
+// Seal mocks base method.
+func (m *MockSecretBox) Seal(arg0, arg1 []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seal", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Seal indicates an expected call of Seal.
+func (mr *MockSecretBoxMockRecorder) Seal(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seal", reflect.TypeOf((*MockSecretBox)(nil).Seal), arg0, arg1)
+}
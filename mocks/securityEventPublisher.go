@@ -0,0 +1,53 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: SecurityEventPublisher)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/securityEventPublisher.go . SecurityEventPublisher
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSecurityEventPublisher is a mock of SecurityEventPublisher interface.
+type MockSecurityEventPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecurityEventPublisherMockRecorder
+}
+
+// MockSecurityEventPublisherMockRecorder is the mock recorder for MockSecurityEventPublisher.
+type MockSecurityEventPublisherMockRecorder struct {
+	mock *MockSecurityEventPublisher
+}
+
+// NewMockSecurityEventPublisher creates a new mock instance.
+func NewMockSecurityEventPublisher(ctrl *gomock.Controller) *MockSecurityEventPublisher {
+	mock := &MockSecurityEventPublisher{ctrl: ctrl}
+	mock.recorder = &MockSecurityEventPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecurityEventPublisher) EXPECT() *MockSecurityEventPublisherMockRecorder {
+	return m.recorder
+}
+
+// PublishSecurityEvent mocks base method.
+func (m *MockSecurityEventPublisher) PublishSecurityEvent(arg0 entities.SecurityEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishSecurityEvent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishSecurityEvent indicates an expected call of PublishSecurityEvent.
+func (mr *MockSecurityEventPublisherMockRecorder) PublishSecurityEvent(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishSecurityEvent", reflect.TypeOf((*MockSecurityEventPublisher)(nil).PublishSecurityEvent), arg0)
+}
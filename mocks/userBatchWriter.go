@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserBatchWriter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userBatchWriter.go . UserBatchWriter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserBatchWriter is a mock of UserBatchWriter interface.
+type MockUserBatchWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserBatchWriterMockRecorder
+}
+
+// MockUserBatchWriterMockRecorder is the mock recorder for MockUserBatchWriter.
+type MockUserBatchWriterMockRecorder struct {
+	mock *MockUserBatchWriter
+}
+
+// NewMockUserBatchWriter creates a new mock instance.
+func NewMockUserBatchWriter(ctrl *gomock.Controller) *MockUserBatchWriter {
+	mock := &MockUserBatchWriter{ctrl: ctrl}
+	mock.recorder = &MockUserBatchWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserBatchWriter) EXPECT() *MockUserBatchWriterMockRecorder {
+	return m.recorder
+}
+
+// DeleteUsers mocks base method.
+func (m *MockUserBatchWriter) DeleteUsers(arg0 context.Context, arg1 []uuid.UUID, arg2 entities.BatchMode) ([]entities.BatchItemResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entities.BatchItemResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUsers indicates an expected call of DeleteUsers.
+func (mr *MockUserBatchWriterMockRecorder) DeleteUsers(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUsers", reflect.TypeOf((*MockUserBatchWriter)(nil).DeleteUsers), arg0, arg1, arg2)
+}
+
+// UpdateUsers mocks base method.
+func (m *MockUserBatchWriter) UpdateUsers(arg0 context.Context, arg1 []entities.UserUpdate, arg2 entities.BatchMode) ([]entities.BatchItemResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entities.BatchItemResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUsers indicates an expected call of UpdateUsers.
+func (mr *MockUserBatchWriterMockRecorder) UpdateUsers(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUsers", reflect.TypeOf((*MockUserBatchWriter)(nil).UpdateUsers), arg0, arg1, arg2)
+}
@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserCommandReplyStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userCommandReplyStore.go . UserCommandReplyStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserCommandReplyStore is a mock of UserCommandReplyStore interface.
+type MockUserCommandReplyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserCommandReplyStoreMockRecorder
+}
+
+// MockUserCommandReplyStoreMockRecorder is the mock recorder for MockUserCommandReplyStore.
+type MockUserCommandReplyStoreMockRecorder struct {
+	mock *MockUserCommandReplyStore
+}
+
+// NewMockUserCommandReplyStore creates a new mock instance.
+func NewMockUserCommandReplyStore(ctrl *gomock.Controller) *MockUserCommandReplyStore {
+	mock := &MockUserCommandReplyStore{ctrl: ctrl}
+	mock.recorder = &MockUserCommandReplyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserCommandReplyStore) EXPECT() *MockUserCommandReplyStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteUserCommandReplies mocks base method.
+func (m *MockUserCommandReplyStore) DeleteUserCommandReplies(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserCommandReplies", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserCommandReplies indicates an expected call of DeleteUserCommandReplies.
+func (mr *MockUserCommandReplyStoreMockRecorder) DeleteUserCommandReplies(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserCommandReplies", reflect.TypeOf((*MockUserCommandReplyStore)(nil).DeleteUserCommandReplies), arg0, arg1)
+}
+
+// GetUserCommandReply mocks base method.
+func (m *MockUserCommandReplyStore) GetUserCommandReply(arg0 context.Context, arg1 string) (*entities.UserCommandReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserCommandReply", arg0, arg1)
+	ret0, _ := ret[0].(*entities.UserCommandReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserCommandReply indicates an expected call of GetUserCommandReply.
+func (mr *MockUserCommandReplyStoreMockRecorder) GetUserCommandReply(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCommandReply", reflect.TypeOf((*MockUserCommandReplyStore)(nil).GetUserCommandReply), arg0, arg1)
+}
+
+// RecordUserCommandReply mocks base method.
+func (m *MockUserCommandReplyStore) RecordUserCommandReply(arg0 context.Context, arg1 entities.UserCommandReply) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordUserCommandReply", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordUserCommandReply indicates an expected call of RecordUserCommandReply.
+func (mr *MockUserCommandReplyStoreMockRecorder) RecordUserCommandReply(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUserCommandReply", reflect.TypeOf((*MockUserCommandReplyStore)(nil).RecordUserCommandReply), arg0, arg1)
+}
@@ -0,0 +1,53 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserCommandReplyWriter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userCommandReplyWriter.go . UserCommandReplyWriter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	reflect "reflect"
+
+	usecases "github.com/AlecSmith96/faceit-user-service/internal/usecases"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserCommandReplyWriter is a mock of UserCommandReplyWriter interface.
+type MockUserCommandReplyWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserCommandReplyWriterMockRecorder
+}
+
+// MockUserCommandReplyWriterMockRecorder is the mock recorder for MockUserCommandReplyWriter.
+type MockUserCommandReplyWriterMockRecorder struct {
+	mock *MockUserCommandReplyWriter
+}
+
+// NewMockUserCommandReplyWriter creates a new mock instance.
+func NewMockUserCommandReplyWriter(ctrl *gomock.Controller) *MockUserCommandReplyWriter {
+	mock := &MockUserCommandReplyWriter{ctrl: ctrl}
+	mock.recorder = &MockUserCommandReplyWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserCommandReplyWriter) EXPECT() *MockUserCommandReplyWriterMockRecorder {
+	return m.recorder
+}
+
+// PublishUserCommandReply mocks base method.
+func (m *MockUserCommandReplyWriter) PublishUserCommandReply(arg0 usecases.UserCommandReplyBody) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishUserCommandReply", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishUserCommandReply indicates an expected call of PublishUserCommandReply.
+func (mr *MockUserCommandReplyWriterMockRecorder) PublishUserCommandReply(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishUserCommandReply", reflect.TypeOf((*MockUserCommandReplyWriter)(nil).PublishUserCommandReply), arg0)
+}
@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserExporter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userExporter.go . UserExporter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserExporter is a mock of UserExporter interface.
+type MockUserExporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserExporterMockRecorder
+}
+
+// MockUserExporterMockRecorder is the mock recorder for MockUserExporter.
+type MockUserExporterMockRecorder struct {
+	mock *MockUserExporter
+}
+
+// NewMockUserExporter creates a new mock instance.
+func NewMockUserExporter(ctrl *gomock.Controller) *MockUserExporter {
+	mock := &MockUserExporter{ctrl: ctrl}
+	mock.recorder = &MockUserExporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserExporter) EXPECT() *MockUserExporterMockRecorder {
+	return m.recorder
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserExporter) ExportUsers(arg0 context.Context, arg1, arg2, arg3, arg4, arg5 string, arg6 func(entities.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserExporterMockRecorder) ExportUsers(arg0, arg1, arg2, arg3, arg4, arg5, arg6 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserExporter)(nil).ExportUsers), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserImporter)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userImporter.go . UserImporter
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserImporter is a mock of UserImporter interface.
+type MockUserImporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserImporterMockRecorder
+}
+
+// MockUserImporterMockRecorder is the mock recorder for MockUserImporter.
+type MockUserImporterMockRecorder struct {
+	mock *MockUserImporter
+}
+
+// NewMockUserImporter creates a new mock instance.
+func NewMockUserImporter(ctrl *gomock.Controller) *MockUserImporter {
+	mock := &MockUserImporter{ctrl: ctrl}
+	mock.recorder = &MockUserImporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserImporter) EXPECT() *MockUserImporterMockRecorder {
+	return m.recorder
+}
+
+// ImportUsers mocks base method.
+func (m *MockUserImporter) ImportUsers(arg0 context.Context, arg1 []entities.ImportedUser, arg2 bool) ([]entities.ImportRowResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entities.ImportRowResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockUserImporterMockRecorder) ImportUsers(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockUserImporter)(nil).ImportUsers), arg0, arg1, arg2)
+}
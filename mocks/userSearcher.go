@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserSearcher)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userSearcher.go . UserSearcher
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserSearcher is a mock of UserSearcher interface.
+type MockUserSearcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserSearcherMockRecorder
+}
+
+// MockUserSearcherMockRecorder is the mock recorder for MockUserSearcher.
+type MockUserSearcherMockRecorder struct {
+	mock *MockUserSearcher
+}
+
+// NewMockUserSearcher creates a new mock instance.
+func NewMockUserSearcher(ctrl *gomock.Controller) *MockUserSearcher {
+	mock := &MockUserSearcher{ctrl: ctrl}
+	mock.recorder = &MockUserSearcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserSearcher) EXPECT() *MockUserSearcherMockRecorder {
+	return m.recorder
+}
+
+// SearchUsers mocks base method.
+func (m *MockUserSearcher) SearchUsers(arg0 context.Context, arg1 string, arg2 int) ([]entities.UserSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entities.UserSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUserSearcherMockRecorder) SearchUsers(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUserSearcher)(nil).SearchUsers), arg0, arg1, arg2)
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: UserStatusUpdater)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/userStatusUpdater.go . UserStatusUpdater
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserStatusUpdater is a mock of UserStatusUpdater interface.
+type MockUserStatusUpdater struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStatusUpdaterMockRecorder
+}
+
+// MockUserStatusUpdaterMockRecorder is the mock recorder for MockUserStatusUpdater.
+type MockUserStatusUpdaterMockRecorder struct {
+	mock *MockUserStatusUpdater
+}
+
+// NewMockUserStatusUpdater creates a new mock instance.
+func NewMockUserStatusUpdater(ctrl *gomock.Controller) *MockUserStatusUpdater {
+	mock := &MockUserStatusUpdater{ctrl: ctrl}
+	mock.recorder = &MockUserStatusUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStatusUpdater) EXPECT() *MockUserStatusUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdateUserStatus mocks base method.
+func (m *MockUserStatusUpdater) UpdateUserStatus(arg0 context.Context, arg1 uuid.UUID, arg2, arg3 entities.UserStatus, arg4 string, arg5 *time.Time) (*entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserStatus", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(*entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserStatus indicates an expected call of UpdateUserStatus.
+func (mr *MockUserStatusUpdaterMockRecorder) UpdateUserStatus(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserStatus", reflect.TypeOf((*MockUserStatusUpdater)(nil).UpdateUserStatus), arg0, arg1, arg2, arg3, arg4, arg5)
+}
@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/AlecSmith96/faceit-user-service/internal/usecases (interfaces: WebAuthnStore)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -destination=../../mocks/webAuthnStore.go . WebAuthnStore
+//
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/AlecSmith96/faceit-user-service/internal/entities"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWebAuthnStore is a mock of WebAuthnStore interface.
+type MockWebAuthnStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnStoreMockRecorder
+}
+
+// MockWebAuthnStoreMockRecorder is the mock recorder for MockWebAuthnStore.
+type MockWebAuthnStoreMockRecorder struct {
+	mock *MockWebAuthnStore
+}
+
+// NewMockWebAuthnStore creates a new mock instance.
+func NewMockWebAuthnStore(ctrl *gomock.Controller) *MockWebAuthnStore {
+	mock := &MockWebAuthnStore{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnStore) EXPECT() *MockWebAuthnStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateWebAuthnCeremony mocks base method.
+func (m *MockWebAuthnStore) CreateWebAuthnCeremony(arg0 context.Context, arg1 entities.WebAuthnCeremony) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnCeremony", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebAuthnCeremony indicates an expected call of CreateWebAuthnCeremony.
+func (mr *MockWebAuthnStoreMockRecorder) CreateWebAuthnCeremony(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnCeremony", reflect.TypeOf((*MockWebAuthnStore)(nil).CreateWebAuthnCeremony), arg0, arg1)
+}
+
+// CreateWebAuthnCredential mocks base method.
+func (m *MockWebAuthnStore) CreateWebAuthnCredential(arg0 context.Context, arg1 entities.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnCredential", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebAuthnCredential indicates an expected call of CreateWebAuthnCredential.
+func (mr *MockWebAuthnStoreMockRecorder) CreateWebAuthnCredential(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnCredential", reflect.TypeOf((*MockWebAuthnStore)(nil).CreateWebAuthnCredential), arg0, arg1)
+}
+
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockWebAuthnStore) DeleteWebAuthnCredential(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockWebAuthnStoreMockRecorder) DeleteWebAuthnCredential(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockWebAuthnStore)(nil).DeleteWebAuthnCredential), arg0, arg1, arg2)
+}
+
+// GetWebAuthnCredentials mocks base method.
+func (m *MockWebAuthnStore) GetWebAuthnCredentials(arg0 context.Context, arg1 uuid.UUID) ([]entities.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentials", arg0, arg1)
+	ret0, _ := ret[0].([]entities.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentials indicates an expected call of GetWebAuthnCredentials.
+func (mr *MockWebAuthnStoreMockRecorder) GetWebAuthnCredentials(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentials", reflect.TypeOf((*MockWebAuthnStore)(nil).GetWebAuthnCredentials), arg0, arg1)
+}
+
+// RedeemWebAuthnCeremony mocks base method.
+func (m *MockWebAuthnStore) RedeemWebAuthnCeremony(arg0 context.Context, arg1 uuid.UUID, arg2 string, arg3 time.Time) (*entities.WebAuthnCeremony, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemWebAuthnCeremony", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entities.WebAuthnCeremony)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemWebAuthnCeremony indicates an expected call of RedeemWebAuthnCeremony.
+func (mr *MockWebAuthnStoreMockRecorder) RedeemWebAuthnCeremony(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemWebAuthnCeremony", reflect.TypeOf((*MockWebAuthnStore)(nil).RedeemWebAuthnCeremony), arg0, arg1, arg2, arg3)
+}
+
+// UpdateWebAuthnCredentialUsage mocks base method.
+func (m *MockWebAuthnStore) UpdateWebAuthnCredentialUsage(arg0 context.Context, arg1 []byte, arg2 uint32, arg3 bool, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnCredentialUsage", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebAuthnCredentialUsage indicates an expected call of UpdateWebAuthnCredentialUsage.
+func (mr *MockWebAuthnStoreMockRecorder) UpdateWebAuthnCredentialUsage(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnCredentialUsage", reflect.TypeOf((*MockWebAuthnStore)(nil).UpdateWebAuthnCredentialUsage), arg0, arg1, arg2, arg3, arg4)
+}